@@ -42,8 +42,9 @@ type MCPServerConfig struct {
 	DisabledTools map[string]bool
 }
 
-// setupLiveCluster sets up the live cluster mode.
-func setupLiveCluster(serverCfg *MCPServerConfig, server *mcp.Server) {
+// setupLiveCluster sets up the live cluster mode. It returns a function that releases the
+// resources created in the cluster, the pooled debug pods, when the server stops.
+func setupLiveCluster(serverCfg *MCPServerConfig, server *mcp.Server) func() {
 	k8sMcpServer, err := kubernetesmcp.NewMCPServer(serverCfg.Kubernetes)
 	if err != nil {
 		log.Fatalf("Failed to create OVN-K MCP server: %v", err)
@@ -58,26 +59,35 @@ func setupLiveCluster(serverCfg *MCPServerConfig, server *mcp.Server) {
 	log.Println("Adding OVN tools to OVN-K MCP server")
 	ovnServer.AddTools(server)
 
-	ovsServer, err := ovsmcp.NewMCPServer(k8sMcpServer.RunPodExecCommand)
+	ovsServer, err := ovsmcp.NewMCPServer(k8sMcpServer.RunPodExecCommand, k8sMcpServer.ListPods)
 	if err != nil {
 		log.Fatalf("Failed to create OVS MCP server: %v", err)
 	}
 	log.Println("Adding OVS tools to OVN-K MCP server")
 	ovsServer.AddTools(server)
 
-	kernelMcpServer, err := kernelmcp.NewMCPServer(k8sMcpServer.RunDebugNode, serverCfg.Kernel)
+	kernelMcpServer, err := kernelmcp.NewMCPServer(k8sMcpServer.RunDebugNode, k8sMcpServer.RunPodExecCommand, serverCfg.Kernel)
 	if err != nil {
 		log.Fatalf("Failed to create Kernel MCP server: %v", err)
 	}
 	log.Println("Adding Kernel tools to OVN-K MCP server")
 	kernelMcpServer.AddTools(server)
 
-	netToolsServer, err := nettoolsmcp.NewMCPServer(k8sMcpServer.RunDebugNode, k8sMcpServer.RunPodExecCommand, serverCfg.NetworkTools)
+	netToolsServer, err := nettoolsmcp.NewMCPServer(nettoolsmcp.Dependencies{
+		RunDebugNodeCommand: k8sMcpServer.RunDebugNode,
+		RunPodExecCommand:   k8sMcpServer.RunPodExecCommand,
+		RunPodDebugCommand:  k8sMcpServer.RunPodDebugCommand,
+		GetPod:              k8sMcpServer.GetPod,
+		ListPods:            k8sMcpServer.ListPods,
+		GetService:          k8sMcpServer.GetService,
+		GetNode:             k8sMcpServer.GetNode,
+	}, serverCfg.NetworkTools)
 	if err != nil {
 		log.Fatalf("Failed to create Network Tools MCP server: %v", err)
 	}
 	log.Println("Adding network tools to OVN-K MCP server")
 	netToolsServer.AddTools(server)
+	return k8sMcpServer.Close
 }
 
 // setupOffline sets up the offline mode.
@@ -116,13 +126,14 @@ func main() {
 	}
 
 	// Setup the MCP server based on the mode.
+	cleanup := func() {}
 	switch serverCfg.Mode {
 	case "live-cluster":
-		setupLiveCluster(serverCfg, ovnkMcpServer)
+		cleanup = setupLiveCluster(serverCfg, ovnkMcpServer)
 	case "offline":
 		setupOffline(ovnkMcpServer)
 	case "dual":
-		setupLiveCluster(serverCfg, ovnkMcpServer)
+		cleanup = setupLiveCluster(serverCfg, ovnkMcpServer)
 		setupOffline(ovnkMcpServer)
 	default:
 		log.Fatalf("Invalid mode: %s. Valid modes are: live-cluster, offline, dual", serverCfg.Mode)
@@ -177,14 +188,20 @@ func main() {
 			log.Printf("HTTP server failed: %v", err)
 		}
 	default:
+		cleanup()
 		log.Fatalf("Invalid transport: %s", serverCfg.Transport)
 	}
+
+	// Release the cluster resources once the server stopped.
+	cleanup()
 }
 
 func parseFlags() *MCPServerConfig {
 	cfg := &MCPServerConfig{}
 	var (
 		timeoutSeconds     int
+		debugPodIdleTTL    int
+		pcapStoreSizeMiB   int
 		disabledCategories string
 		disabledTools      string
 		showVersion        bool
@@ -197,9 +214,14 @@ func parseFlags() *MCPServerConfig {
 	flag.StringVar(&cfg.Port, "port", "8080", "Port to use")
 	flag.StringVar(&cfg.Kubernetes.Kubeconfig, "kubeconfig", "", "Path to the kubeconfig file")
 	flag.StringVar(&cfg.NetworkTools.PwruImage, "pwru-image", "docker.io/cilium/pwru:v1.0.10", "Container image for pwru operations")
+	flag.StringVar(&cfg.NetworkTools.RetisImage, "retis-image", "quay.io/retis/retis:v1.5.2", "Container image for retis operations")
 	flag.StringVar(&cfg.NetworkTools.TcpdumpImage, "tcpdump-image", defaultNetshootImage, "Container image for tcpdump operations")
+	flag.IntVar(&pcapStoreSizeMiB, "pcap-store-size", nettoolsmcp.DefaultPcapStoreMaxBytes>>20,
+		"Total size in MiB of the tcpdump pcap captures kept in memory as MCP resources; the oldest captures are dropped first")
 	flag.StringVar(&cfg.Kernel.Image, "kernel-image", defaultNetshootImage, "Container image for kernel operations")
 	flag.IntVar(&timeoutSeconds, "tool-timeout", 120, "Timeout in seconds for tool operations (0 to disable)")
+	flag.IntVar(&debugPodIdleTTL, "debug-pod-idle-ttl", 300,
+		"Seconds an unused debug pod is kept for reuse by later commands on the same node and image (0 to create a debug pod per command)")
 	flag.StringVar(&disabledCategories, "disable-categories", "",
 		"Comma-separated tool categories to hide from clients (valid categories: "+strings.Join(toolfilter.Categories(), ",")+")")
 	flag.StringVar(&disabledTools, "disable-tools", "",
@@ -217,6 +239,8 @@ func parseFlags() *MCPServerConfig {
 	}
 
 	cfg.ToolTimeout = time.Duration(timeoutSeconds) * time.Second
+	cfg.Kernel.ToolTimeout = cfg.ToolTimeout
+	cfg.NetworkTools.ToolTimeout = cfg.ToolTimeout
 
 	if cfg.ToolTimeout == 0 {
 		log.Println("Tool timeout enforcement disabled")
@@ -224,6 +248,21 @@ func parseFlags() *MCPServerConfig {
 		log.Printf("Tool timeout: %v", cfg.ToolTimeout)
 	}
 
+	if debugPodIdleTTL < 0 {
+		debugPodIdleTTL = 0
+	}
+	cfg.Kubernetes.DebugPodIdleTTL = time.Duration(debugPodIdleTTL) * time.Second
+	if cfg.Kubernetes.DebugPodIdleTTL == 0 {
+		log.Println("Debug pod pooling disabled")
+	} else {
+		log.Printf("Debug pod idle TTL: %v", cfg.Kubernetes.DebugPodIdleTTL)
+	}
+
+	if pcapStoreSizeMiB <= 0 {
+		log.Fatalf("Invalid pcap store size: %d MiB, must be positive", pcapStoreSizeMiB)
+	}
+	cfg.NetworkTools.PcapStoreMaxBytes = pcapStoreSizeMiB << 20
+
 	disabled, err := toolfilter.ResolveDisabled(disabledCategories, disabledTools)
 	if err != nil {
 		log.Fatalf("Invalid tool filter configuration: %v", err)
@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -10,15 +11,15 @@ import (
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/headtail"
 )
 
 const conntrackSystemFile = "/proc/net/nf_conntrack"
 
 // GetConntrack MCP handler for conntrack operations.
 // GetConntrack retrieves connection tracking entries from a Kubernetes node.
-// TODO: Add support for conntrack event monitoring (-E flag).
-// TODO: Add support for -G (get specific entry) command.
-func (s *MCPServer) GetConntrack(ctx context.Context, req *mcp.CallToolRequest, in types.ListConntrackParams) (*mcp.CallToolResult, types.Result, error) {
+// List operations return parsed entries, or an aggregate of them when requested.
+func (s *MCPServer) GetConntrack(ctx context.Context, req *mcp.CallToolRequest, in types.ListConntrackParams) (*mcp.CallToolResult, types.ConntrackResult, error) {
 	// If timeout is specified, create a new context with timeout
 	var cancel context.CancelFunc
 	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
@@ -30,51 +31,135 @@ func (s *MCPServer) GetConntrack(ctx context.Context, req *mcp.CallToolRequest,
 	err := s.utilityExists(ctx, in.Namespace, in.Node, "conntrack")
 	conntrackCliAvailable := err == nil // true if conntrack CLI is available, false otherwise
 	if err := validateConntrackCommand(in.Command, conntrackCliAvailable); err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting list of conntrack entries: %w", err)
+		return nil, types.ConntrackResult{}, fmt.Errorf("error while getting list of conntrack entries: %w", err)
 	}
 	if err := utils.ValidateSafeString(in.FilterParameters, "filter parameters", true, utils.ShellMetaCharactersTypeDefault); err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting list of conntrack entries: %w", err)
+		return nil, types.ConntrackResult{}, fmt.Errorf("error while getting list of conntrack entries: %w", err)
+	}
+	// Filter parameters are conntrack CLI arguments, which cannot be applied to /proc/net/nf_conntrack.
+	if !conntrackCliAvailable && strings.TrimSpace(in.FilterParameters) != "" {
+		return nil, types.ConntrackResult{}, fmt.Errorf("error while getting list of conntrack entries: " +
+			"configured image does not have conntrack utility, filter parameters cannot be used, use zone, mark, labels and status instead")
+	}
+	filter, err := newConntrackFilter(in.Zone, in.Mark, in.Labels, in.Status)
+	if err != nil {
+		return nil, types.ConntrackResult{}, fmt.Errorf("error while getting list of conntrack entries: %w", err)
 	}
 
-	var stdout, stderr, summary string
-	if !conntrackCliAvailable {
-		stdout, stderr, err = s.getConntrackFromFile(ctx, in.Namespace, in.Node)
-	} else {
-		stdout, stderr, err = s.getConntrackUsingCLI(ctx, in.Namespace, in.Node, strings.TrimSpace(in.Command), in.FilterParameters)
+	command := strings.TrimSpace(in.Command)
+	families, err := conntrackFamilies(in.IPFamily, command, in.FilterParameters)
+	if err != nil {
+		return nil, types.ConntrackResult{}, fmt.Errorf("error while getting list of conntrack entries: %w", err)
+	}
+	if err := validatePodTarget(in.PodTargetParams); err != nil {
+		return nil, types.ConntrackResult{}, fmt.Errorf("error while getting list of conntrack entries: %w", err)
 	}
+	prefix, netns, err := s.podNetnsPrefix(ctx, in.Namespace, in.Node, in.PodTargetParams)
 	if err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting list of conntrack entries: %w", err)
+		return nil, types.ConntrackResult{}, fmt.Errorf("error while getting list of conntrack entries: %w", err)
+	}
+
+	// The CLI lists one family per run. /proc/net/nf_conntrack holds both families and is read once.
+	runFamilies := families
+	if len(runFamilies) == 0 || !conntrackCliAvailable {
+		runFamilies = []string{""}
 	}
+	var outputs []conntrackOutput
+	var summaries []string
+	for _, family := range runFamilies {
+		var stdout, stderr string
+		if !conntrackCliAvailable {
+			stdout, stderr, err = s.getConntrackFromFile(ctx, in.Namespace, in.Node, prefix)
+		} else {
+			stdout, stderr, err = s.getConntrackUsingCLI(ctx, in.Namespace, in.Node, prefix, command, in.FilterParameters, family, filter)
+		}
+		if err != nil {
+			return nil, types.ConntrackResult{}, fmt.Errorf("error while getting list of conntrack entries: %w", err)
+		}
 
-	if stderr != "" {
-		// Split the stderr into summary and remaining stderr
-		remainingStderr := ""
-		summary, remainingStderr = splitConntrackSummary(stderr)
-		if remainingStderr != "" {
-			return nil, types.Result{}, fmt.Errorf("error while running command: %s", remainingStderr)
+		if stderr != "" {
+			// Split the stderr into summary and remaining stderr
+			summary, remainingStderr := splitConntrackSummary(stderr)
+			if remainingStderr != "" {
+				return nil, types.ConntrackResult{}, fmt.Errorf("error while running command: %s", remainingStderr)
+			}
+			if summary != "" {
+				summaries = append(summaries, summary)
+			}
 		}
+		outputs = append(outputs, conntrackOutput{family: family, stdout: stdout})
+	}
+	summary := strings.Join(summaries, "\n")
+
+	// Count and stats operations are returned as they are
+	switch command {
+	case "-S", "--stats", "-C", "--count":
+		// Strip empty lines from the output
+		lines := utils.StripEmptyLines(strings.Split(outputs[0].stdout, "\n"))
+		lines = in.HeadTailParams.Apply(lines, DefaultMaxOutputLines)
+		return nil, types.ConntrackResult{Data: strings.Join(lines, "\n"), Summary: summary, Netns: netns}, nil
 	}
 
-	// Strip empty lines from the output
-	lines := utils.StripEmptyLines(strings.Split(stdout, "\n"))
-	// Apply the head and tail parameters to the lines
-	lines = in.HeadTailParams.Apply(lines, DefaultMaxOutputLines)
-	// Join the lines back into a single string
-	stdout = strings.Join(lines, "\n")
-	// If conntrack summary is present, add it to the output
-	if summary != "" {
-		stdout = fmt.Sprintf("%s\n -- conntrack summary --\n%s", stdout, summary)
+	entries := []types.ConntrackEntry{}
+	for _, output := range outputs {
+		for _, line := range utils.StripEmptyLines(strings.Split(output.stdout, "\n")) {
+			entry, ok := parseConntrackEntry(line)
+			if !ok {
+				continue
+			}
+			// Entries listed by the CLI do not carry their family, which is the family of the run.
+			if entry.Family == "" {
+				entry.Family = output.family
+			}
+			if filter.matches(entry) && (len(families) == 0 || slices.Contains(families, entry.Family)) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	if in.Aggregate {
+		return nil, types.ConntrackResult{Aggregate: aggregateConntrackEntries(entries), Summary: summary, Netns: netns}, nil
+	}
+	// Apply the head and tail parameters to the matching entries
+	entries = headtail.ApplyTo(&in.HeadTailParams, entries, DefaultMaxOutputLines)
+	return nil, types.ConntrackResult{Entries: entries, Summary: summary, Netns: netns}, nil
+}
+
+// conntrackOutput is the output of a conntrack listing of one family, or of all families if family is empty.
+type conntrackOutput struct {
+	family string
+	stdout string
+}
+
+// conntrackFamilies returns the families selected by ip_family. The family can only be selected for
+// list operations, and not both with ip_family and with -f/--family in the filter parameters.
+func conntrackFamilies(ipFamily, command, filterParameters string) ([]string, error) {
+	families, err := parseIPFamily(ipFamily)
+	if err != nil || families == nil {
+		return families, err
+	}
+	switch command {
+	case "-S", "--stats", "-C", "--count":
+		return nil, fmt.Errorf("ip_family can only be used with list operations")
+	}
+	for _, item := range strings.Fields(filterParameters) {
+		if item == "-f" || item == "--family" {
+			return nil, fmt.Errorf("filter parameters cannot select the family with %s when ip_family is set", item)
+		}
 	}
-	return nil, types.Result{Data: stdout}, nil
+	return families, nil
 }
 
-// getConntrackUsingCLI executes conntrack CLI commands.
-func (s *MCPServer) getConntrackUsingCLI(ctx context.Context, namespace, node, command, filterParameters string) (string, string, error) {
-	cmd := commandbuilder.NewCommand("conntrack")
+// getConntrackUsingCLI executes conntrack CLI commands, prefixed by the given command prefix.
+// If family is set, only the entries of the family are listed.
+func (s *MCPServer) getConntrackUsingCLI(ctx context.Context, namespace, node string, prefix []string, command, filterParameters, family string, filter *conntrackFilter) (string, string, error) {
+	cmd := commandbuilder.NewCommand(prefix...)
+	cmd.Add("conntrack")
 	switch command {
 	case "-L", "--dump":
 		cmd.Add(command)
 		cmd.Add(strings.Fields(filterParameters)...)
+		cmd.AddIfNotEmpty(family, "-f", family)
+		cmd.Add(filter.cliArgs()...)
 	case "-S", "--stats":
 		cmd.Add(command)
 	case "-C", "--count":
@@ -82,14 +167,18 @@ func (s *MCPServer) getConntrackUsingCLI(ctx context.Context, namespace, node, c
 	default:
 		cmd.Add("-L")
 		cmd.Add(strings.Fields(filterParameters)...)
+		cmd.AddIfNotEmpty(family, "-f", family)
+		cmd.Add(filter.cliArgs()...)
 	}
 	return s.executeCommand(ctx, namespace, node, cmd.Build())
 }
 
-// getConntrackFromFile parses /proc/net/nf_conntrack directly.
-// TODO: Add filter support while getting conntrack entries from /proc/net/nf_conntrack.
-func (s *MCPServer) getConntrackFromFile(ctx context.Context, namespace, node string) (string, string, error) {
-	cmd := commandbuilder.NewCommand("cat")
+// getConntrackFromFile parses /proc/net/nf_conntrack directly, which lists the entries of the network
+// namespace of the reader. The zone, mark, labels and status filters are applied to the parsed entries,
+// and filter parameters are rejected.
+func (s *MCPServer) getConntrackFromFile(ctx context.Context, namespace, node string, prefix []string) (string, string, error) {
+	cmd := commandbuilder.NewCommand(prefix...)
+	cmd.Add("cat")
 	cmd.Add(conntrackSystemFile)
 	return s.executeCommand(ctx, namespace, node, cmd.Build())
 }
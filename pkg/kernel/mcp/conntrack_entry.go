@@ -0,0 +1,325 @@
+package mcp
+
+import (
+	"cmp"
+	"fmt"
+	"math/big"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+// conntrackTopDestinations is the number of destinations reported by the conntrack aggregate.
+const conntrackTopDestinations = 20
+
+// conntrackStatusFlags are the status flags that can be used to filter conntrack entries.
+// SEEN_REPLY matches entries that are not UNREPLIED.
+var conntrackStatusFlags = map[string]bool{
+	"ASSURED":    true,
+	"UNREPLIED":  true,
+	"SEEN_REPLY": true,
+	"OFFLOAD":    true,
+	"HW_OFFLOAD": true,
+}
+
+// conntrackFilter holds the parsed zone, mark, labels and status filters of get-conntrack.
+type conntrackFilter struct {
+	zone       *int
+	mark       *uint32
+	markMask   uint32
+	labels     *big.Int
+	labelsMask *big.Int
+	status     []string
+}
+
+// newConntrackFilter validates and parses the conntrack filters. Empty values disable the filter.
+func newConntrackFilter(zone, mark, labels, status string) (*conntrackFilter, error) {
+	filter := &conntrackFilter{}
+	if zone = strings.TrimSpace(zone); zone != "" {
+		z, err := strconv.ParseUint(zone, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zone %q: must be a number between 0 and 65535", zone)
+		}
+		zoneID := int(z)
+		filter.zone = &zoneID
+	}
+	if mark = strings.TrimSpace(mark); mark != "" {
+		value, mask, err := parseMaskedUint32(mark)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mark %q: %w", mark, err)
+		}
+		filter.mark = &value
+		filter.markMask = mask
+	}
+	if labels = strings.TrimSpace(labels); labels != "" {
+		valueStr, maskStr, hasMask := strings.Cut(labels, "/")
+		value, ok := parseHexBigInt(valueStr)
+		if !ok {
+			return nil, fmt.Errorf("invalid labels %q: must be a hexadecimal value with an optional /mask", labels)
+		}
+		mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+		if hasMask {
+			if mask, ok = parseHexBigInt(maskStr); !ok {
+				return nil, fmt.Errorf("invalid labels %q: must be a hexadecimal value with an optional /mask", labels)
+			}
+		}
+		filter.labels = value
+		filter.labelsMask = mask
+	}
+	if status = strings.TrimSpace(status); status != "" {
+		for _, flag := range strings.Split(status, ",") {
+			flag = strings.ToUpper(strings.TrimSpace(flag))
+			if !conntrackStatusFlags[flag] {
+				return nil, fmt.Errorf("invalid status %q: must be a comma-separated list of ASSURED, UNREPLIED, SEEN_REPLY, OFFLOAD, HW_OFFLOAD", status)
+			}
+			filter.status = append(filter.status, flag)
+		}
+	}
+	return filter, nil
+}
+
+// cliArgs returns the conntrack CLI arguments that pre-filter the dump on the node.
+// Labels and status are only evaluated by matches.
+func (f *conntrackFilter) cliArgs() []string {
+	var args []string
+	if f.zone != nil {
+		args = append(args, "--zone", strconv.Itoa(*f.zone))
+	}
+	if f.mark != nil {
+		args = append(args, "--mark", fmt.Sprintf("0x%x/0x%x", *f.mark, f.markMask))
+	}
+	return args
+}
+
+// matches reports whether the entry satisfies every configured filter.
+func (f *conntrackFilter) matches(entry types.ConntrackEntry) bool {
+	if f.zone != nil && entry.Zone != *f.zone {
+		return false
+	}
+	if f.mark != nil && entry.Mark&f.markMask != *f.mark&f.markMask {
+		return false
+	}
+	if f.labels != nil {
+		labels, ok := parseHexBigInt(entry.Labels)
+		if !ok {
+			labels = new(big.Int)
+		}
+		if new(big.Int).And(labels, f.labelsMask).Cmp(new(big.Int).And(f.labels, f.labelsMask)) != 0 {
+			return false
+		}
+	}
+	for _, flag := range f.status {
+		unreplied := slices.Contains(entry.Flags, "UNREPLIED")
+		switch {
+		case flag == "SEEN_REPLY" && unreplied:
+			return false
+		case flag != "SEEN_REPLY" && !slices.Contains(entry.Flags, flag):
+			return false
+		}
+	}
+	return true
+}
+
+// parseMaskedUint32 parses a decimal or hexadecimal value with an optional /mask.
+func parseMaskedUint32(s string) (uint32, uint32, error) {
+	valueStr, maskStr, hasMask := strings.Cut(s, "/")
+	value, err := strconv.ParseUint(valueStr, 0, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("must be a 32-bit value with an optional /mask")
+	}
+	mask := uint64(0xffffffff)
+	if hasMask {
+		if mask, err = strconv.ParseUint(maskStr, 0, 32); err != nil {
+			return 0, 0, fmt.Errorf("must be a 32-bit value with an optional /mask")
+		}
+	}
+	return uint32(value), uint32(mask), nil
+}
+
+// parseHexBigInt parses a hexadecimal value with an optional 0x prefix.
+func parseHexBigInt(s string) (*big.Int, bool) {
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(s), "0x"), "0X")
+	if s == "" {
+		return nil, false
+	}
+	return new(big.Int).SetString(s, 16)
+}
+
+// parseConntrackEntries parses conntrack CLI or /proc/net/nf_conntrack lines, skipping
+// lines that are not connection tracking entries.
+func parseConntrackEntries(lines []string) []types.ConntrackEntry {
+	entries := []types.ConntrackEntry{}
+	for _, line := range lines {
+		if entry, ok := parseConntrackEntry(line); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// parseConntrackEntry parses a single connection tracking entry such as:
+//
+//	tcp 6 91 ESTABLISHED src=1.2.3.4 dst=5.6.7.8 sport=32000 dport=10250 src=5.6.7.8 dst=1.2.3.4 sport=10250 dport=32000 [ASSURED] mark=0 zone=5 use=2
+//	ipv4 2 udp 17 29 src=10.0.0.1 dst=10.0.0.2 sport=5353 dport=53 [UNREPLIED] src=10.0.0.2 dst=10.0.0.1 sport=53 dport=5353 mark=0 zone=0 use=2
+//
+// The second form is printed by /proc/net/nf_conntrack and by 'conntrack -o extended'.
+func parseConntrackEntry(line string) (types.ConntrackEntry, bool) {
+	entry := types.ConntrackEntry{}
+	fields := strings.Fields(line)
+	if len(fields) >= 2 && (fields[0] == "ipv4" || fields[0] == "ipv6") {
+		entry.Family = fields[0]
+		fields = fields[2:]
+	}
+	if len(fields) < 3 {
+		return entry, false
+	}
+	var err error
+	entry.Protocol = fields[0]
+	if entry.ProtocolNumber, err = strconv.Atoi(fields[1]); err != nil {
+		return entry, false
+	}
+	fields = fields[2:]
+	// The timeout is not printed for DESTROY events.
+	if !strings.Contains(fields[0], "=") {
+		if entry.Timeout, err = strconv.Atoi(fields[0]); err != nil {
+			return entry, false
+		}
+		fields = fields[1:]
+	}
+	if len(fields) == 0 {
+		return entry, false
+	}
+	if !strings.Contains(fields[0], "=") && !strings.HasPrefix(fields[0], "[") {
+		entry.State = fields[0]
+		fields = fields[1:]
+	}
+
+	// tuples counts the tuples seen so far; inTuple is true while the fields
+	// still belong to the most recent tuple.
+	tuples := 0
+	inTuple := false
+	for _, field := range fields {
+		if strings.HasPrefix(field, "[") && strings.HasSuffix(field, "]") {
+			entry.Flags = append(entry.Flags, strings.Trim(field, "[]"))
+			inTuple = false
+			continue
+		}
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		if key == "src" {
+			tuples++
+			inTuple = true
+		}
+		tuple := &entry.Original
+		if tuples > 1 {
+			tuple = &entry.Reply
+		}
+		if inTuple && tuples <= 2 {
+			switch key {
+			case "src":
+				tuple.Src = value
+				continue
+			case "dst":
+				tuple.Dst = value
+				continue
+			case "sport":
+				tuple.Sport, _ = strconv.Atoi(value)
+				continue
+			case "dport":
+				tuple.Dport, _ = strconv.Atoi(value)
+				continue
+			case "type":
+				tuple.ICMPType = atoiPtr(value)
+				continue
+			case "code":
+				tuple.ICMPCode = atoiPtr(value)
+				continue
+			case "id":
+				tuple.ICMPID = atoiPtr(value)
+				continue
+			}
+		}
+		inTuple = false
+		switch key {
+		case "zone", "zone-orig":
+			entry.Zone, _ = strconv.Atoi(value)
+		case "mark":
+			if mark, err := strconv.ParseUint(value, 0, 32); err == nil {
+				entry.Mark = uint32(mark)
+			}
+		case "labels":
+			entry.Labels = value
+		case "use":
+			entry.Use, _ = strconv.Atoi(value)
+		case "id":
+			entry.ID = value
+		}
+	}
+	if tuples == 0 {
+		return entry, false
+	}
+	return entry, true
+}
+
+// atoiPtr returns a pointer to the integer value of s, or nil if s is not a number.
+func atoiPtr(s string) *int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &i
+}
+
+// aggregateConntrackEntries counts the entries by zone, state, original destination and protocol.
+// Only the busiest destinations are reported.
+func aggregateConntrackEntries(entries []types.ConntrackEntry) *types.ConntrackAggregate {
+	byZone := map[string]int{}
+	byState := map[string]int{}
+	byDestination := map[string]int{}
+	byProtocol := map[string]int{}
+	for _, entry := range entries {
+		byZone[strconv.Itoa(entry.Zone)]++
+		state := entry.State
+		if state == "" {
+			state = "none"
+		}
+		byState[state]++
+		destination := entry.Original.Dst
+		if entry.Original.Dport != 0 {
+			destination = net.JoinHostPort(destination, strconv.Itoa(entry.Original.Dport))
+		}
+		byDestination[destination+"/"+entry.Protocol]++
+		byProtocol[entry.Protocol]++
+	}
+	destinations := sortedConntrackCounts(byDestination)
+	if len(destinations) > conntrackTopDestinations {
+		destinations = destinations[:conntrackTopDestinations]
+	}
+	return &types.ConntrackAggregate{
+		Total:         len(entries),
+		ByZone:        sortedConntrackCounts(byZone),
+		ByState:       sortedConntrackCounts(byState),
+		ByDestination: destinations,
+		ByProtocol:    sortedConntrackCounts(byProtocol),
+	}
+}
+
+// sortedConntrackCounts converts the counts to a slice sorted by descending count and key.
+func sortedConntrackCounts(counts map[string]int) []types.ConntrackCount {
+	result := make([]types.ConntrackCount, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, types.ConntrackCount{Key: key, Count: count})
+	}
+	slices.SortFunc(result, func(a, b types.ConntrackCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return result
+}
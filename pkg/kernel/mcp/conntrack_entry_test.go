@@ -0,0 +1,276 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestParseConntrackEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected types.ConntrackEntry
+		ok       bool
+	}{
+		{
+			name: "tcp entry from conntrack CLI",
+			line: "tcp      6 91 ESTABLISHED src=1.2.3.4 dst=5.6.7.8 sport=32000 dport=10250 src=5.6.7.8 dst=1.2.3.4 sport=10250 dport=32000 [ASSURED] mark=2 zone=64000 use=1",
+			expected: types.ConntrackEntry{
+				Protocol:       "tcp",
+				ProtocolNumber: 6,
+				Timeout:        91,
+				State:          "ESTABLISHED",
+				Original:       types.ConntrackTuple{Src: "1.2.3.4", Dst: "5.6.7.8", Sport: 32000, Dport: 10250},
+				Reply:          types.ConntrackTuple{Src: "5.6.7.8", Dst: "1.2.3.4", Sport: 10250, Dport: 32000},
+				Zone:           64000,
+				Mark:           2,
+				Flags:          []string{"ASSURED"},
+				Use:            1,
+			},
+			ok: true,
+		},
+		{
+			name: "udp entry from /proc/net/nf_conntrack",
+			line: "ipv4     2 udp      17 29 src=10.0.0.1 dst=10.0.0.2 sport=5353 dport=53 [UNREPLIED] src=10.0.0.2 dst=10.0.0.1 sport=53 dport=5353 mark=0 labels=0x10 zone=0 use=2",
+			expected: types.ConntrackEntry{
+				Family:         "ipv4",
+				Protocol:       "udp",
+				ProtocolNumber: 17,
+				Timeout:        29,
+				Original:       types.ConntrackTuple{Src: "10.0.0.1", Dst: "10.0.0.2", Sport: 5353, Dport: 53},
+				Reply:          types.ConntrackTuple{Src: "10.0.0.2", Dst: "10.0.0.1", Sport: 53, Dport: 5353},
+				Labels:         "0x10",
+				Flags:          []string{"UNREPLIED"},
+				Use:            2,
+			},
+			ok: true,
+		},
+		{
+			name: "icmp entry with id",
+			line: "icmp     1 29 src=10.244.1.3 dst=10.244.2.4 type=8 code=0 id=42 src=10.244.2.4 dst=10.244.1.3 type=0 code=0 id=42 mark=0 use=1 id=3705829376",
+			expected: types.ConntrackEntry{
+				Protocol:       "icmp",
+				ProtocolNumber: 1,
+				Timeout:        29,
+				Original:       types.ConntrackTuple{Src: "10.244.1.3", Dst: "10.244.2.4", ICMPType: intPtr(8), ICMPCode: intPtr(0), ICMPID: intPtr(42)},
+				Reply:          types.ConntrackTuple{Src: "10.244.2.4", Dst: "10.244.1.3", ICMPType: intPtr(0), ICMPCode: intPtr(0), ICMPID: intPtr(42)},
+				Use:            1,
+				ID:             "3705829376",
+			},
+			ok: true,
+		},
+		{
+			name: "ipv6 tcp entry with zone-orig",
+			line: "ipv6     10 tcp      6 117 TIME_WAIT src=fd00::1 dst=fd00::2 sport=41234 dport=443 src=fd00::2 dst=fd00::1 sport=443 dport=41234 [ASSURED] mark=0 zone-orig=7 use=2",
+			expected: types.ConntrackEntry{
+				Family:         "ipv6",
+				Protocol:       "tcp",
+				ProtocolNumber: 6,
+				Timeout:        117,
+				State:          "TIME_WAIT",
+				Original:       types.ConntrackTuple{Src: "fd00::1", Dst: "fd00::2", Sport: 41234, Dport: 443},
+				Reply:          types.ConntrackTuple{Src: "fd00::2", Dst: "fd00::1", Sport: 443, Dport: 41234},
+				Zone:           7,
+				Flags:          []string{"ASSURED"},
+				Use:            2,
+			},
+			ok: true,
+		},
+		{
+			name: "conntrack summary is not an entry",
+			line: "conntrack v1.4.7 (conntrack-tools): 12 flow entries have been shown.",
+		},
+		{
+			name: "empty line is not an entry",
+			line: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := parseConntrackEntry(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("parseConntrackEntry() ok = %v, expected %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if diff := cmp.Diff(tt.expected, entry); diff != "" {
+				t.Errorf("parseConntrackEntry() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewConntrackFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		zone      string
+		mark      string
+		labels    string
+		status    string
+		cliArgs   []string
+		wantError bool
+	}{
+		{
+			name: "no filters",
+		},
+		{
+			name:    "zone and mark are passed to the CLI",
+			zone:    "64000",
+			mark:    "0x2/0xf",
+			cliArgs: []string{"--zone", "64000", "--mark", "0x2/0xf"},
+		},
+		{
+			name:    "decimal mark without mask",
+			mark:    "16",
+			cliArgs: []string{"--mark", "0x10/0xffffffff"},
+		},
+		{
+			name:   "labels and status are not passed to the CLI",
+			labels: "0x1/0xff",
+			status: "assured, seen_reply",
+		},
+		{
+			name:      "zone out of range",
+			zone:      "65536",
+			wantError: true,
+		},
+		{
+			name:      "negative zone",
+			zone:      "-1",
+			wantError: true,
+		},
+		{
+			name:      "mark is not a number",
+			mark:      "0x2/abc",
+			wantError: true,
+		},
+		{
+			name:      "labels are not hexadecimal",
+			labels:    "0xzz",
+			wantError: true,
+		},
+		{
+			name:      "unknown status flag",
+			status:    "ASSURED,DYING",
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newConntrackFilter(tt.zone, tt.mark, tt.labels, tt.status)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("newConntrackFilter() error = %v, wantError %v", err, tt.wantError)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.cliArgs, filter.cliArgs()); diff != "" {
+				t.Errorf("cliArgs() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConntrackFilterMatches(t *testing.T) {
+	entry := types.ConntrackEntry{
+		Protocol: "tcp",
+		State:    "ESTABLISHED",
+		Zone:     5,
+		Mark:     0x12,
+		Labels:   "0x00000000000000000000000000000101",
+		Flags:    []string{"ASSURED"},
+	}
+	tests := []struct {
+		name     string
+		zone     string
+		mark     string
+		labels   string
+		status   string
+		expected bool
+	}{
+		{
+			name:     "no filters",
+			expected: true,
+		},
+		{
+			name:     "matching zone",
+			zone:     "5",
+			expected: true,
+		},
+		{
+			name:     "different zone",
+			zone:     "0",
+			expected: false,
+		},
+		{
+			name:     "matching masked mark",
+			mark:     "0x2/0xf",
+			expected: true,
+		},
+		{
+			name:     "different mark",
+			mark:     "0x2",
+			expected: false,
+		},
+		{
+			name:     "matching masked labels",
+			labels:   "0x1/0xf",
+			expected: true,
+		},
+		{
+			name:     "different labels",
+			labels:   "0x1",
+			expected: false,
+		},
+		{
+			name:     "matching status flags",
+			status:   "ASSURED,SEEN_REPLY",
+			expected: true,
+		},
+		{
+			name:     "missing status flag",
+			status:   "UNREPLIED",
+			expected: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newConntrackFilter(tt.zone, tt.mark, tt.labels, tt.status)
+			if err != nil {
+				t.Fatalf("newConntrackFilter() error = %v", err)
+			}
+			if got := filter.matches(entry); got != tt.expected {
+				t.Errorf("matches() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAggregateConntrackEntries(t *testing.T) {
+	entries := parseConntrackEntries([]string{
+		"tcp      6 91 ESTABLISHED src=1.2.3.4 dst=5.6.7.8 sport=32000 dport=10250 src=5.6.7.8 dst=1.2.3.4 sport=10250 dport=32000 [ASSURED] mark=0 zone=5 use=1",
+		"tcp      6 92 ESTABLISHED src=1.2.3.5 dst=5.6.7.8 sport=32001 dport=10250 src=5.6.7.8 dst=1.2.3.5 sport=10250 dport=32001 [ASSURED] mark=0 zone=5 use=1",
+		"udp      17 29 src=10.0.0.1 dst=fd00::a sport=5353 dport=53 [UNREPLIED] src=fd00::a dst=10.0.0.1 sport=53 dport=5353 mark=0 use=1",
+		"conntrack v1.4.7 (conntrack-tools): 3 flow entries have been shown.",
+	})
+	expected := &types.ConntrackAggregate{
+		Total:   3,
+		ByZone:  []types.ConntrackCount{{Key: "5", Count: 2}, {Key: "0", Count: 1}},
+		ByState: []types.ConntrackCount{{Key: "ESTABLISHED", Count: 2}, {Key: "none", Count: 1}},
+		ByDestination: []types.ConntrackCount{
+			{Key: "5.6.7.8:10250/tcp", Count: 2},
+			{Key: "[fd00::a]:53/udp", Count: 1},
+		},
+		ByProtocol: []types.ConntrackCount{{Key: "tcp", Count: 2}, {Key: "udp", Count: 1}},
+	}
+	if diff := cmp.Diff(expected, aggregateConntrackEntries(entries)); diff != "" {
+		t.Errorf("aggregateConntrackEntries() mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,227 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/timeout"
+)
+
+// DefaultConntrackEventsDuration is the number of seconds events are collected for when no duration is given.
+const DefaultConntrackEventsDuration = 10
+
+// conntrackEventTypes are the event types accepted by conntrack -E -e.
+var conntrackEventTypes = map[string]bool{
+	"NEW":     true,
+	"UPDATE":  true,
+	"DESTROY": true,
+}
+
+// conntrackProtocols are the layer 4 protocols accepted by the conntrack events protocol filter.
+// The value reports whether the protocol has ports.
+var conntrackProtocols = map[string]bool{
+	"tcp":     true,
+	"udp":     true,
+	"udplite": true,
+	"sctp":    true,
+	"dccp":    true,
+	"icmp":    false,
+	"icmpv6":  false,
+	"gre":     false,
+}
+
+// GetConntrackEvents monitors connection tracking events on a Kubernetes node for a bounded duration.
+// conntrack -E is stopped with SIGINT once the duration expires, which makes it print its summary
+// and exit successfully.
+func (s *MCPServer) GetConntrackEvents(ctx context.Context, req *mcp.CallToolRequest, in types.ConntrackEventsParams) (*mcp.CallToolResult, types.ConntrackEventsResult, error) {
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	// The events are collected for less than the call timeout, leaving time to start the debug pod,
+	// so that they are returned.
+	duration, err := timeout.ValidateDuration(ctx, "duration_seconds", in.DurationSeconds, DefaultConntrackEventsDuration, timeout.SetupTime)
+	if err != nil {
+		return nil, types.ConntrackEventsResult{}, fmt.Errorf("error while monitoring conntrack events: %w", err)
+	}
+	args, err := conntrackEventsArgs(in)
+	if err != nil {
+		return nil, types.ConntrackEventsResult{}, fmt.Errorf("error while monitoring conntrack events: %w", err)
+	}
+	filter, err := newConntrackFilter(in.Zone, "", "", "")
+	if err != nil {
+		return nil, types.ConntrackEventsResult{}, fmt.Errorf("error while monitoring conntrack events: %w", err)
+	}
+	if err := validatePodTarget(in.PodTargetParams); err != nil {
+		return nil, types.ConntrackEventsResult{}, fmt.Errorf("error while monitoring conntrack events: %w", err)
+	}
+
+	if err := s.utilityExists(ctx, in.Namespace, in.Node, "conntrack"); err != nil {
+		return nil, types.ConntrackEventsResult{}, fmt.Errorf("error while monitoring conntrack events: conntrack event monitoring requires the conntrack utility: %w", err)
+	}
+	prefix, netns, err := s.podNetnsPrefix(ctx, in.Namespace, in.Node, in.PodTargetParams)
+	if err != nil {
+		return nil, types.ConntrackEventsResult{}, fmt.Errorf("error while monitoring conntrack events: %w", err)
+	}
+
+	cmd := commandbuilder.NewCommand(prefix...)
+	cmd.Add("timeout", "--preserve-status", "-s", "INT", strconv.Itoa(duration), "conntrack", "-E", "-o", "timestamp")
+	cmd.Add(args...)
+	cmd.Add(filter.cliArgs()...)
+	stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+	if err != nil {
+		return nil, types.ConntrackEventsResult{}, fmt.Errorf("error while monitoring conntrack events: %w", err)
+	}
+
+	summary, remainingStderr := splitConntrackSummary(stderr)
+	if remainingStderr = strings.TrimSpace(remainingStderr); remainingStderr != "" {
+		return nil, types.ConntrackEventsResult{}, fmt.Errorf("error while running command: %s", remainingStderr)
+	}
+
+	var matched []string
+	for _, line := range utils.StripEmptyLines(strings.Split(stdout, "\n")) {
+		event, ok := parseConntrackEvent(line)
+		if ok && filter.matches(event.ConntrackEntry) {
+			matched = append(matched, line)
+		}
+	}
+	// Apply the head and tail parameters to the matching events
+	matched = in.HeadTailParams.Apply(matched, DefaultMaxOutputLines)
+
+	events := []types.ConntrackEvent{}
+	for _, line := range matched {
+		if event, ok := parseConntrackEvent(line); ok {
+			events = append(events, event)
+		}
+	}
+	return nil, types.ConntrackEventsResult{Events: events, DurationSeconds: duration, Summary: summary, Netns: netns}, nil
+}
+
+// conntrackEventsArgs validates the event filters and returns the matching conntrack -E arguments.
+func conntrackEventsArgs(in types.ConntrackEventsParams) ([]string, error) {
+	var args []string
+	if eventTypes := strings.TrimSpace(in.EventTypes); eventTypes != "" {
+		var eventTypeList []string
+		for _, eventType := range strings.Split(eventTypes, ",") {
+			eventType = strings.ToUpper(strings.TrimSpace(eventType))
+			if !conntrackEventTypes[eventType] {
+				return nil, fmt.Errorf("invalid event_types %q: must be a comma-separated list of NEW, UPDATE, DESTROY", in.EventTypes)
+			}
+			eventTypeList = append(eventTypeList, eventType)
+		}
+		args = append(args, "-e", strings.Join(eventTypeList, ","))
+	}
+
+	var family string
+	for _, address := range []struct{ name, value, flag string }{
+		{name: "src", value: in.Src, flag: "-s"},
+		{name: "dst", value: in.Dst, flag: "-d"},
+	} {
+		if address.value == "" {
+			continue
+		}
+		ip := net.ParseIP(address.value)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid %s %q: must be an IP address", address.name, address.value)
+		}
+		addressFamily := "ipv4"
+		if ip.To4() == nil {
+			addressFamily = "ipv6"
+		}
+		if family != "" && family != addressFamily {
+			return nil, fmt.Errorf("src and dst must belong to the same address family")
+		}
+		family = addressFamily
+		args = append(args, address.flag, ip.String())
+	}
+	if family == "ipv6" {
+		args = append([]string{"-f", "ipv6"}, args...)
+	}
+
+	protocol := strings.ToLower(strings.TrimSpace(in.Protocol))
+	hasPorts, ok := conntrackProtocols[protocol]
+	if protocol != "" && !ok {
+		return nil, fmt.Errorf("invalid protocol %q: must be one of tcp, udp, udplite, sctp, dccp, icmp, icmpv6, gre", in.Protocol)
+	}
+	if protocol != "" {
+		args = append(args, "-p", protocol)
+	}
+	for _, port := range []struct {
+		name  string
+		value int
+		flag  string
+	}{
+		{name: "sport", value: in.Sport, flag: "--sport"},
+		{name: "dport", value: in.Dport, flag: "--dport"},
+	} {
+		if port.value == 0 {
+			continue
+		}
+		if port.value < 0 || port.value > 65535 {
+			return nil, fmt.Errorf("invalid %s %d: must be between 1 and 65535", port.name, port.value)
+		}
+		if !hasPorts {
+			return nil, fmt.Errorf("%s requires protocol to be one of tcp, udp, udplite, sctp, dccp", port.name)
+		}
+		args = append(args, port.flag, strconv.Itoa(port.value))
+	}
+	return args, nil
+}
+
+// parseConntrackEvent parses a single conntrack -E -o timestamp line such as:
+//
+//	[1697040000.123456]	    [NEW] tcp      6 120 SYN_SENT src=10.244.1.3 dst=10.96.0.1 sport=40000 dport=443 [UNREPLIED] src=10.96.0.1 dst=10.244.1.3 sport=443 dport=40000 zone=0
+func parseConntrackEvent(line string) (types.ConntrackEvent, bool) {
+	event := types.ConntrackEvent{}
+	fields := strings.Fields(line)
+	if len(fields) > 0 && strings.HasPrefix(fields[0], "[") && strings.HasSuffix(fields[0], "]") {
+		if timestamp, ok := parseConntrackTimestamp(strings.Trim(fields[0], "[]")); ok {
+			event.Timestamp = timestamp
+			fields = fields[1:]
+		}
+	}
+	if len(fields) == 0 {
+		return event, false
+	}
+	eventType := strings.Trim(fields[0], "[]")
+	if !conntrackEventTypes[eventType] {
+		return event, false
+	}
+	event.Type = eventType
+	entry, ok := parseConntrackEntry(strings.Join(fields[1:], " "))
+	if !ok {
+		return event, false
+	}
+	event.ConntrackEntry = entry
+	return event, true
+}
+
+// parseConntrackTimestamp converts a seconds.microseconds timestamp into RFC 3339 format.
+func parseConntrackTimestamp(s string) (string, bool) {
+	secStr, fracStr, _ := strings.Cut(s, ".")
+	sec, err := strconv.ParseInt(secStr, 10, 64)
+	if err != nil {
+		return "", false
+	}
+	var nsec int64
+	if fracStr != "" {
+		if len(fracStr) > 9 {
+			fracStr = fracStr[:9]
+		}
+		if nsec, err = strconv.ParseInt(fracStr+strings.Repeat("0", 9-len(fracStr)), 10, 64); err != nil {
+			return "", false
+		}
+	}
+	return time.Unix(sec, nsec).UTC().Format(time.RFC3339Nano), true
+}
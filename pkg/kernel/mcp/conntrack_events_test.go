@@ -0,0 +1,262 @@
+package mcp
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/timeout"
+)
+
+func TestParseConntrackEvent(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected types.ConntrackEvent
+		ok       bool
+	}{
+		{
+			name: "new event with timestamp",
+			line: "[1697040000.123456]\t    [NEW] tcp      6 120 SYN_SENT src=10.244.1.3 dst=10.96.0.1 sport=40000 dport=443 [UNREPLIED] src=10.96.0.1 dst=10.244.1.3 sport=443 dport=40000 zone=5",
+			expected: types.ConntrackEvent{
+				Timestamp: "2023-10-11T16:00:00.123456Z",
+				Type:      "NEW",
+				ConntrackEntry: types.ConntrackEntry{
+					Protocol:       "tcp",
+					ProtocolNumber: 6,
+					Timeout:        120,
+					State:          "SYN_SENT",
+					Original:       types.ConntrackTuple{Src: "10.244.1.3", Dst: "10.96.0.1", Sport: 40000, Dport: 443},
+					Reply:          types.ConntrackTuple{Src: "10.96.0.1", Dst: "10.244.1.3", Sport: 443, Dport: 40000},
+					Zone:           5,
+					Flags:          []string{"UNREPLIED"},
+				},
+			},
+			ok: true,
+		},
+		{
+			name: "destroy event without timestamp",
+			line: " [DESTROY] udp      17 src=fd00::1 dst=fd00::a sport=5353 dport=53 src=fd00::a dst=fd00::1 sport=53 dport=5353",
+			expected: types.ConntrackEvent{
+				Type: "DESTROY",
+				ConntrackEntry: types.ConntrackEntry{
+					Protocol:       "udp",
+					ProtocolNumber: 17,
+					Original:       types.ConntrackTuple{Src: "fd00::1", Dst: "fd00::a", Sport: 5353, Dport: 53},
+					Reply:          types.ConntrackTuple{Src: "fd00::a", Dst: "fd00::1", Sport: 53, Dport: 5353},
+				},
+			},
+			ok: true,
+		},
+		{
+			name: "unknown event type",
+			line: "[1697040000.123456] [FOO] tcp 6 120 SYN_SENT src=10.244.1.3 dst=10.96.0.1 sport=40000 dport=443",
+		},
+		{
+			name: "summary is not an event",
+			line: "conntrack v1.4.7 (conntrack-tools): 1 flow events have been shown.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, ok := parseConntrackEvent(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("parseConntrackEvent() ok = %v, expected %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if diff := cmp.Diff(tt.expected, event); diff != "" {
+				t.Errorf("parseConntrackEvent() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConntrackEventsArgs(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    types.ConntrackEventsParams
+		expected  []string
+		wantError bool
+	}{
+		{
+			name: "no filters",
+		},
+		{
+			name: "ipv4 tuple and event types",
+			params: types.ConntrackEventsParams{
+				EventTypes: "new, destroy",
+				Protocol:   "TCP",
+				Src:        "10.244.1.3",
+				Dst:        "10.96.0.1",
+				Dport:      443,
+			},
+			expected: []string{"-e", "NEW,DESTROY", "-s", "10.244.1.3", "-d", "10.96.0.1", "-p", "tcp", "--dport", "443"},
+		},
+		{
+			name: "ipv6 destination selects the ipv6 family",
+			params: types.ConntrackEventsParams{
+				Dst: "fd00::a",
+			},
+			expected: []string{"-f", "ipv6", "-d", "fd00::a"},
+		},
+		{
+			name:      "invalid event type",
+			params:    types.ConntrackEventsParams{EventTypes: "NEW,EXPIRE"},
+			wantError: true,
+		},
+		{
+			name:      "invalid address",
+			params:    types.ConntrackEventsParams{Src: "10.244.1.3; true"},
+			wantError: true,
+		},
+		{
+			name:      "mixed address families",
+			params:    types.ConntrackEventsParams{Src: "10.244.1.3", Dst: "fd00::a"},
+			wantError: true,
+		},
+		{
+			name:      "invalid protocol",
+			params:    types.ConntrackEventsParams{Protocol: "quic"},
+			wantError: true,
+		},
+		{
+			name:      "port without protocol",
+			params:    types.ConntrackEventsParams{Dport: 443},
+			wantError: true,
+		},
+		{
+			name:      "port with icmp",
+			params:    types.ConntrackEventsParams{Protocol: "icmp", Sport: 1},
+			wantError: true,
+		},
+		{
+			name:      "port out of range",
+			params:    types.ConntrackEventsParams{Protocol: "udp", Dport: 70000},
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := conntrackEventsArgs(tt.params)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("conntrackEventsArgs() error = %v, wantError %v", err, tt.wantError)
+			}
+			if diff := cmp.Diff(tt.expected, args); diff != "" {
+				t.Errorf("conntrackEventsArgs() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetConntrackEventsDuration(t *testing.T) {
+	tests := []struct {
+		name           string
+		toolTimeout    time.Duration
+		duration       int
+		timeoutSeconds uint32
+		expected       int
+		wantError      bool
+	}{
+		{name: "default duration", toolTimeout: 120 * time.Second, expected: DefaultConntrackEventsDuration},
+		{name: "duration below the tool timeout", toolTimeout: 120 * time.Second, duration: 80, expected: 80},
+		{name: "duration without setup time before the tool timeout", toolTimeout: 120 * time.Second, duration: 100, wantError: true},
+		{name: "duration above the tool timeout", toolTimeout: 120 * time.Second, duration: 200, wantError: true},
+		{name: "duration below timeout_seconds", duration: 200, timeoutSeconds: 250, expected: 200},
+		{name: "duration without setup time before timeout_seconds", duration: 40, timeoutSeconds: 60, wantError: true},
+		{name: "duration equal to timeout_seconds", duration: 60, timeoutSeconds: 60, wantError: true},
+		{name: "duration above max timeout", duration: int(timeout.MaxTimeout.Seconds()), timeoutSeconds: 1000, wantError: true},
+		{name: "negative duration", duration: -1, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var command []string
+			runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+				if cmd[len(cmd)-1] != "-V" {
+					command = cmd
+				}
+				return "", "", nil
+			}
+			runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+				return "", "", nil
+			}
+			server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+			if err != nil {
+				t.Fatalf("NewMCPServer() error = %v", err)
+			}
+			// The tool timeout of the server is applied by the middleware to calls without timeout_seconds.
+			ctx := context.Background()
+			if tt.toolTimeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.toolTimeout)
+				defer cancel()
+			}
+			params := types.ConntrackEventsParams{CommonParams: types.CommonParams{Node: "ovn-worker"}, DurationSeconds: tt.duration}
+			params.TimeoutSeconds = tt.timeoutSeconds
+			_, _, err = server.GetConntrackEvents(ctx, nil, params)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("GetConntrackEvents() error = %v, wantError %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				if command != nil {
+					t.Errorf("GetConntrackEvents() ran %q, want no command", command)
+				}
+				return
+			}
+			if command[4] != strconv.Itoa(tt.expected) {
+				t.Errorf("GetConntrackEvents() command = %q, expected a duration of %d", command, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetConntrackEvents(t *testing.T) {
+	var command []string
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		if cmd[len(cmd)-1] == "-V" {
+			return "conntrack v1.4.7 (conntrack-tools)", "", nil
+		}
+		command = cmd
+		stdout := strings.Join([]string{
+			"[1697040000.000001]\t    [NEW] tcp      6 120 SYN_SENT src=10.244.1.3 dst=10.96.0.1 sport=40000 dport=443 [UNREPLIED] src=10.96.0.1 dst=10.244.1.3 sport=443 dport=40000 zone=5",
+			"[1697040000.000002]\t [UPDATE] tcp      6 60 SYN_RECV src=10.244.1.3 dst=10.96.0.1 sport=40000 dport=443 src=10.96.0.1 dst=10.244.1.3 sport=443 dport=40000 zone=5",
+			"[1697040000.000003]\t [UPDATE] tcp      6 432000 ESTABLISHED src=10.244.1.3 dst=10.96.0.1 sport=40000 dport=443 src=10.96.0.1 dst=10.244.1.3 sport=443 dport=40000 [ASSURED] zone=5",
+		}, "\n")
+		return stdout, "conntrack v1.4.7 (conntrack-tools): 3 flow events have been shown.\n", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	params := types.ConntrackEventsParams{
+		CommonParams:    types.CommonParams{Node: "ovn-worker"},
+		DurationSeconds: 5,
+		Protocol:        "tcp",
+		Dport:           443,
+		Zone:            "5",
+	}
+	params.Tail = 2
+	_, result, err := server.GetConntrackEvents(context.Background(), nil, params)
+	if err != nil {
+		t.Fatalf("GetConntrackEvents() error = %v", err)
+	}
+	expectedCommand := "timeout --preserve-status -s INT 5 conntrack -E -o timestamp -p tcp --dport 443 --zone 5"
+	if strings.Join(command, " ") != expectedCommand {
+		t.Errorf("GetConntrackEvents() command = %q, expected %q", strings.Join(command, " "), expectedCommand)
+	}
+	if len(result.Events) != 2 || result.Events[0].State != "SYN_RECV" || result.Events[1].State != "ESTABLISHED" {
+		t.Errorf("GetConntrackEvents() unexpected events: %+v", result.Events)
+	}
+	if result.DurationSeconds != 5 || result.Summary == "" {
+		t.Errorf("GetConntrackEvents() unexpected result: %+v", result)
+	}
+}
@@ -0,0 +1,381 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+)
+
+// GetConntrackEntry looks up the connection tracking entry of a single flow by its original
+// direction 5-tuple. If a zone is given the kernel lookup uses conntrack -G, otherwise all zones
+// are searched with conntrack -L. When an OVS pod is given, the OVS datapath view of the same
+// flow is collected with 'ovs-appctl dpctl/dump-conntrack' and compared with the kernel view.
+func (s *MCPServer) GetConntrackEntry(ctx context.Context, req *mcp.CallToolRequest, in types.ConntrackLookupParams) (*mcp.CallToolResult, types.ConntrackLookupResult, error) {
+	tuple, family, err := validateConntrackLookup(in)
+	if err != nil {
+		return nil, types.ConntrackLookupResult{}, fmt.Errorf("error while looking up conntrack entry: %w", err)
+	}
+	filter, err := newConntrackFilter(in.Zone, "", "", "")
+	if err != nil {
+		return nil, types.ConntrackLookupResult{}, fmt.Errorf("error while looking up conntrack entry: %w", err)
+	}
+	if err := validatePodTarget(in.PodTargetParams); err != nil {
+		return nil, types.ConntrackLookupResult{}, fmt.Errorf("error while looking up conntrack entry: %w", err)
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	if err := s.utilityExists(ctx, in.Namespace, in.Node, "conntrack"); err != nil {
+		return nil, types.ConntrackLookupResult{}, fmt.Errorf("error while looking up conntrack entry: conntrack entry lookup requires the conntrack utility: %w", err)
+	}
+	prefix, netns, err := s.podNetnsPrefix(ctx, in.Namespace, in.Node, in.PodTargetParams)
+	if err != nil {
+		return nil, types.ConntrackLookupResult{}, fmt.Errorf("error while looking up conntrack entry: %w", err)
+	}
+	kernelEntries, err := s.lookupKernelConntrack(ctx, in.Namespace, in.Node, prefix, family, tuple, filter)
+	if err != nil {
+		return nil, types.ConntrackLookupResult{}, fmt.Errorf("error while looking up conntrack entry: %w", err)
+	}
+	result := types.ConntrackLookupResult{Kernel: kernelEntries, Netns: netns}
+	if in.OVSPod == "" {
+		return nil, result, nil
+	}
+
+	ovsEntries, err := s.lookupOVSConntrack(ctx, in.OVSNamespace, in.OVSPod, in.OVSContainer, tuple, filter)
+	if err != nil {
+		return nil, types.ConntrackLookupResult{}, fmt.Errorf("error while looking up conntrack entry: %w", err)
+	}
+	result.OVS = ovsEntries
+	result.Comparisons = compareConntrackViews(kernelEntries, ovsEntries)
+	return nil, result, nil
+}
+
+// validateConntrackLookup validates the lookup parameters and returns the requested original
+// tuple together with its address family.
+func validateConntrackLookup(in types.ConntrackLookupParams) (conntrackLookupTuple, string, error) {
+	tuple := conntrackLookupTuple{protocol: strings.ToLower(strings.TrimSpace(in.Protocol))}
+	if hasPorts, ok := conntrackProtocols[tuple.protocol]; !ok || !hasPorts {
+		return tuple, "", fmt.Errorf("invalid protocol %q: must be one of tcp, udp, udplite, sctp, dccp", in.Protocol)
+	}
+	if tuple.src = net.ParseIP(in.Src); tuple.src == nil {
+		return tuple, "", fmt.Errorf("invalid src %q: must be an IP address", in.Src)
+	}
+	if tuple.dst = net.ParseIP(in.Dst); tuple.dst == nil {
+		return tuple, "", fmt.Errorf("invalid dst %q: must be an IP address", in.Dst)
+	}
+	family := "ipv4"
+	if tuple.src.To4() == nil {
+		family = "ipv6"
+	}
+	if (tuple.dst.To4() == nil) != (family == "ipv6") {
+		return tuple, "", fmt.Errorf("src and dst must belong to the same address family")
+	}
+	if in.Sport < 1 || in.Sport > 65535 {
+		return tuple, "", fmt.Errorf("invalid sport %d: must be between 1 and 65535", in.Sport)
+	}
+	if in.Dport < 1 || in.Dport > 65535 {
+		return tuple, "", fmt.Errorf("invalid dport %d: must be between 1 and 65535", in.Dport)
+	}
+	tuple.sport, tuple.dport = in.Sport, in.Dport
+
+	if (in.OVSNamespace == "") != (in.OVSPod == "") {
+		return tuple, "", fmt.Errorf("ovs_namespace and ovs_pod must be set together")
+	}
+	for _, value := range []struct{ name, value string }{
+		{name: "ovs_namespace", value: in.OVSNamespace},
+		{name: "ovs_pod", value: in.OVSPod},
+		{name: "ovs_container", value: in.OVSContainer},
+	} {
+		if value.value != "" && !utils.IsKubernetesName(value.value) {
+			return tuple, "", fmt.Errorf("invalid %s %q", value.name, value.value)
+		}
+	}
+	return tuple, family, nil
+}
+
+// conntrackLookupTuple is the original direction 5-tuple of a flow.
+type conntrackLookupTuple struct {
+	protocol     string
+	src, dst     net.IP
+	sport, dport int
+}
+
+// matches reports whether the original direction of the entry is the tuple.
+func (t conntrackLookupTuple) matches(entry types.ConntrackEntry) bool {
+	return entry.Protocol == t.protocol &&
+		t.src.Equal(net.ParseIP(entry.Original.Src)) &&
+		t.dst.Equal(net.ParseIP(entry.Original.Dst)) &&
+		entry.Original.Sport == t.sport &&
+		entry.Original.Dport == t.dport
+}
+
+// lookupKernelConntrack returns the kernel entries of the tuple, run after the prefix to enter the
+// network namespace of a pod. conntrack -G fails when the entry does not exist, so a failed lookup
+// is confirmed with conntrack -L before reporting an error.
+func (s *MCPServer) lookupKernelConntrack(ctx context.Context, namespace, node string, prefix []string, family string, tuple conntrackLookupTuple, filter *conntrackFilter) ([]types.ConntrackEntry, error) {
+	args := []string{"-p", tuple.protocol, "-s", tuple.src.String(), "-d", tuple.dst.String(),
+		"--sport", strconv.Itoa(tuple.sport), "--dport", strconv.Itoa(tuple.dport)}
+	if family == "ipv6" {
+		args = append([]string{"-f", "ipv6"}, args...)
+	}
+	args = append(args, filter.cliArgs()...)
+
+	var stdout string
+	var err error
+	if filter.zone != nil {
+		stdout, err = s.runConntrackLookup(ctx, namespace, node, prefix, "-G", args)
+	}
+	if filter.zone == nil || err != nil {
+		if stdout, err = s.runConntrackLookup(ctx, namespace, node, prefix, "-L", args); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := []types.ConntrackEntry{}
+	for _, entry := range parseConntrackEntries(utils.StripEmptyLines(strings.Split(stdout, "\n"))) {
+		if tuple.matches(entry) && filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// runConntrackLookup runs conntrack with the given command and lookup arguments on the node, after
+// the prefix entering the network namespace of a pod if any.
+func (s *MCPServer) runConntrackLookup(ctx context.Context, namespace, node string, prefix []string, command string, args []string) (string, error) {
+	cmd := commandbuilder.NewCommand(prefix...)
+	cmd.Add("conntrack", command)
+	cmd.Add(args...)
+	stdout, stderr, err := s.executeCommand(ctx, namespace, node, cmd.Build())
+	if err != nil {
+		return "", err
+	}
+	if _, remainingStderr := splitConntrackSummary(stderr); strings.TrimSpace(remainingStderr) != "" {
+		return "", fmt.Errorf("error while running command: %s", strings.TrimSpace(remainingStderr))
+	}
+	return stdout, nil
+}
+
+// lookupOVSConntrack returns the OVS datapath entries of the tuple.
+func (s *MCPServer) lookupOVSConntrack(ctx context.Context, namespace, pod, container string, tuple conntrackLookupTuple, filter *conntrackFilter) ([]types.ConntrackEntry, error) {
+	cmd := commandbuilder.NewCommand("ovs-appctl", "dpctl/dump-conntrack")
+	if filter.zone != nil {
+		cmd.Add("zone=" + strconv.Itoa(*filter.zone))
+	}
+	stdout, stderr, err := s.runPodExecCommand(ctx, namespace, pod, container, cmd.Build())
+	if err != nil {
+		return nil, fmt.Errorf("failed to dump OVS conntrack on pod %s/%s: %w", namespace, pod, err)
+	}
+	if stderr != "" {
+		return nil, fmt.Errorf("failed to dump OVS conntrack on pod %s/%s: %s", namespace, pod, stderr)
+	}
+
+	entries := []types.ConntrackEntry{}
+	for _, line := range utils.StripEmptyLines(strings.Split(stdout, "\n")) {
+		entry, ok := parseOVSConntrackEntry(line)
+		if ok && tuple.matches(entry) && filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// parseOVSConntrackEntry parses a single 'ovs-appctl dpctl/dump-conntrack' entry such as:
+//
+//	tcp,orig=(src=10.244.0.5,dst=10.96.0.1,sport=45678,dport=443),reply=(src=10.244.1.7,dst=10.244.0.5,sport=8080,dport=45678),zone=5,mark=2,protoinfo=(state=ESTABLISHED)
+func parseOVSConntrackEntry(line string) (types.ConntrackEntry, bool) {
+	entry := types.ConntrackEntry{}
+	fields := splitOVSConntrackFields(strings.TrimSpace(line))
+	if len(fields) < 2 || strings.Contains(fields[0], "=") {
+		return entry, false
+	}
+	entry.Protocol = fields[0]
+	hasOriginal := false
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "orig":
+			entry.Original, hasOriginal = parseOVSConntrackTuple(value), true
+		case "reply":
+			entry.Reply = parseOVSConntrackTuple(value)
+		case "zone":
+			entry.Zone, _ = strconv.Atoi(value)
+		case "mark":
+			if mark, err := strconv.ParseUint(value, 0, 32); err == nil {
+				entry.Mark = uint32(mark)
+			}
+		case "labels":
+			entry.Labels = value
+		case "timeout":
+			entry.Timeout, _ = strconv.Atoi(value)
+		case "id":
+			entry.ID = value
+		case "protoinfo":
+			for _, info := range splitOVSConntrackFields(strings.TrimSuffix(strings.TrimPrefix(value, "("), ")")) {
+				if key, value, ok := strings.Cut(info, "="); ok && (key == "state" || key == "state_orig") {
+					entry.State = value
+				}
+			}
+		}
+	}
+	return entry, hasOriginal
+}
+
+// parseOVSConntrackTuple parses a parenthesized dpctl/dump-conntrack tuple.
+func parseOVSConntrackTuple(value string) types.ConntrackTuple {
+	tuple := types.ConntrackTuple{}
+	for _, field := range splitOVSConntrackFields(strings.TrimSuffix(strings.TrimPrefix(value, "("), ")")) {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "src":
+			tuple.Src = value
+		case "dst":
+			tuple.Dst = value
+		case "sport":
+			tuple.Sport, _ = strconv.Atoi(value)
+		case "dport":
+			tuple.Dport, _ = strconv.Atoi(value)
+		case "type":
+			tuple.ICMPType = atoiPtr(value)
+		case "code":
+			tuple.ICMPCode = atoiPtr(value)
+		case "id":
+			tuple.ICMPID = atoiPtr(value)
+		}
+	}
+	return tuple
+}
+
+// splitOVSConntrackFields splits s on the commas that are not enclosed in parentheses.
+func splitOVSConntrackFields(s string) []string {
+	var fields []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				fields = append(fields, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(s) {
+		fields = append(fields, s[start:])
+	}
+	return fields
+}
+
+// compareConntrackViews pairs the kernel and OVS entries by zone and reports the NAT applied to
+// the flow and the fields whose values differ between the two views.
+func compareConntrackViews(kernelEntries, ovsEntries []types.ConntrackEntry) []types.ConntrackComparison {
+	kernelByZone := map[int]types.ConntrackEntry{}
+	ovsByZone := map[int]types.ConntrackEntry{}
+	var zones []int
+	for _, entry := range kernelEntries {
+		kernelByZone[entry.Zone] = entry
+		zones = append(zones, entry.Zone)
+	}
+	for _, entry := range ovsEntries {
+		ovsByZone[entry.Zone] = entry
+		zones = append(zones, entry.Zone)
+	}
+	slices.Sort(zones)
+	zones = slices.Compact(zones)
+
+	comparisons := []types.ConntrackComparison{}
+	for _, zone := range zones {
+		kernelEntry, inKernel := kernelByZone[zone]
+		ovsEntry, inOVS := ovsByZone[zone]
+		comparison := types.ConntrackComparison{Zone: zone, InKernel: inKernel, InOVS: inOVS}
+		entry := kernelEntry
+		if !inKernel {
+			entry = ovsEntry
+		}
+		comparison.DNAT, comparison.SNAT = conntrackNAT(entry)
+		if inKernel && inOVS {
+			comparison.Differences = conntrackDifferences(kernelEntry, ovsEntry)
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	return comparisons
+}
+
+// conntrackNAT returns the translated destination and source of the entry. A reply tuple that is
+// not the inverse of the original tuple means that the destination (DNAT) or the source (SNAT)
+// was translated.
+func conntrackNAT(entry types.ConntrackEntry) (string, string) {
+	var dnat, snat string
+	original, reply := entry.Original, entry.Reply
+	if reply.Src == "" {
+		return "", ""
+	}
+	if !net.ParseIP(reply.Src).Equal(net.ParseIP(original.Dst)) || reply.Sport != original.Dport {
+		dnat = net.JoinHostPort(reply.Src, strconv.Itoa(reply.Sport))
+	}
+	if !net.ParseIP(reply.Dst).Equal(net.ParseIP(original.Src)) || reply.Dport != original.Sport {
+		snat = net.JoinHostPort(reply.Dst, strconv.Itoa(reply.Dport))
+	}
+	return dnat, snat
+}
+
+// conntrackDifferences lists the fields whose values differ between the kernel and OVS entries.
+// Fields that are missing from one of the views are not compared.
+func conntrackDifferences(kernelEntry, ovsEntry types.ConntrackEntry) []string {
+	var differences []string
+	if formatConntrackTuple(kernelEntry.Reply) != formatConntrackTuple(ovsEntry.Reply) {
+		differences = append(differences, fmt.Sprintf("reply: kernel %s, ovs %s",
+			formatConntrackTuple(kernelEntry.Reply), formatConntrackTuple(ovsEntry.Reply)))
+	}
+	if kernelEntry.State != "" && ovsEntry.State != "" && kernelEntry.State != ovsEntry.State {
+		differences = append(differences, fmt.Sprintf("state: kernel %s, ovs %s", kernelEntry.State, ovsEntry.State))
+	}
+	if kernelEntry.Mark != ovsEntry.Mark {
+		differences = append(differences, fmt.Sprintf("mark: kernel 0x%x, ovs 0x%x", kernelEntry.Mark, ovsEntry.Mark))
+	}
+	kernelLabels, _ := parseHexBigInt(kernelEntry.Labels)
+	ovsLabels, _ := parseHexBigInt(ovsEntry.Labels)
+	if kernelLabels == nil {
+		kernelLabels = new(big.Int)
+	}
+	if ovsLabels == nil {
+		ovsLabels = new(big.Int)
+	}
+	if kernelLabels.Cmp(ovsLabels) != 0 {
+		differences = append(differences, fmt.Sprintf("labels: kernel 0x%x, ovs 0x%x", kernelLabels, ovsLabels))
+	}
+	return differences
+}
+
+// formatConntrackTuple formats a tuple as src:sport->dst:dport with normalized addresses.
+func formatConntrackTuple(tuple types.ConntrackTuple) string {
+	normalize := func(address string) string {
+		if ip := net.ParseIP(address); ip != nil {
+			return ip.String()
+		}
+		return address
+	}
+	return fmt.Sprintf("%s->%s",
+		net.JoinHostPort(normalize(tuple.Src), strconv.Itoa(tuple.Sport)),
+		net.JoinHostPort(normalize(tuple.Dst), strconv.Itoa(tuple.Dport)))
+}
@@ -0,0 +1,258 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+func TestParseOVSConntrackEntry(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected types.ConntrackEntry
+		ok       bool
+	}{
+		{
+			name: "tcp entry with dnat",
+			line: "tcp,orig=(src=10.244.0.5,dst=10.96.0.1,sport=45678,dport=443),reply=(src=172.18.0.3,dst=10.244.0.5,sport=6443,dport=45678),zone=5,mark=2,labels=0x1,protoinfo=(state=ESTABLISHED)",
+			expected: types.ConntrackEntry{
+				Protocol: "tcp",
+				State:    "ESTABLISHED",
+				Original: types.ConntrackTuple{Src: "10.244.0.5", Dst: "10.96.0.1", Sport: 45678, Dport: 443},
+				Reply:    types.ConntrackTuple{Src: "172.18.0.3", Dst: "10.244.0.5", Sport: 6443, Dport: 45678},
+				Zone:     5,
+				Mark:     2,
+				Labels:   "0x1",
+			},
+			ok: true,
+		},
+		{
+			name: "ipv6 udp entry",
+			line: "udp,orig=(src=fd00::5,dst=fd00::a,sport=5353,dport=53),reply=(src=fd00::a,dst=fd00::5,sport=53,dport=5353)",
+			expected: types.ConntrackEntry{
+				Protocol: "udp",
+				Original: types.ConntrackTuple{Src: "fd00::5", Dst: "fd00::a", Sport: 5353, Dport: 53},
+				Reply:    types.ConntrackTuple{Src: "fd00::a", Dst: "fd00::5", Sport: 53, Dport: 5353},
+			},
+			ok: true,
+		},
+		{
+			name: "tcp entry with different states per direction",
+			line: "tcp,orig=(src=10.0.0.1,dst=10.0.0.2,sport=1,dport=2),reply=(src=10.0.0.2,dst=10.0.0.1,sport=2,dport=1),protoinfo=(state_orig=FIN_WAIT_1,state_reply=ESTABLISHED)",
+			expected: types.ConntrackEntry{
+				Protocol: "tcp",
+				State:    "FIN_WAIT_1",
+				Original: types.ConntrackTuple{Src: "10.0.0.1", Dst: "10.0.0.2", Sport: 1, Dport: 2},
+				Reply:    types.ConntrackTuple{Src: "10.0.0.2", Dst: "10.0.0.1", Sport: 2, Dport: 1},
+			},
+			ok: true,
+		},
+		{
+			name: "line without tuples",
+			line: "ovs-vswitchd: no datapaths exist",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := parseOVSConntrackEntry(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("parseOVSConntrackEntry() ok = %v, expected %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if diff := cmp.Diff(tt.expected, entry); diff != "" {
+				t.Errorf("parseOVSConntrackEntry() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestConntrackNAT(t *testing.T) {
+	tests := []struct {
+		name         string
+		entry        types.ConntrackEntry
+		expectedDNAT string
+		expectedSNAT string
+	}{
+		{
+			name: "no translation",
+			entry: types.ConntrackEntry{
+				Original: types.ConntrackTuple{Src: "10.0.0.1", Dst: "10.0.0.2", Sport: 1000, Dport: 80},
+				Reply:    types.ConntrackTuple{Src: "10.0.0.2", Dst: "10.0.0.1", Sport: 80, Dport: 1000},
+			},
+		},
+		{
+			name: "destination translated",
+			entry: types.ConntrackEntry{
+				Original: types.ConntrackTuple{Src: "10.244.1.3", Dst: "10.96.0.1", Sport: 40000, Dport: 443},
+				Reply:    types.ConntrackTuple{Src: "172.18.0.3", Dst: "10.244.1.3", Sport: 6443, Dport: 40000},
+			},
+			expectedDNAT: "172.18.0.3:6443",
+		},
+		{
+			name: "source and destination translated",
+			entry: types.ConntrackEntry{
+				Original: types.ConntrackTuple{Src: "fd00::3", Dst: "fd00:96::1", Sport: 40000, Dport: 443},
+				Reply:    types.ConntrackTuple{Src: "fd00::10", Dst: "fd00::1", Sport: 8443, Dport: 30000},
+			},
+			expectedDNAT: "[fd00::10]:8443",
+			expectedSNAT: "[fd00::1]:30000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dnat, snat := conntrackNAT(tt.entry)
+			if dnat != tt.expectedDNAT || snat != tt.expectedSNAT {
+				t.Errorf("conntrackNAT() = (%q, %q), expected (%q, %q)", dnat, snat, tt.expectedDNAT, tt.expectedSNAT)
+			}
+		})
+	}
+}
+
+func TestValidateConntrackLookup(t *testing.T) {
+	valid := types.ConntrackLookupParams{Protocol: "tcp", Src: "10.244.1.3", Dst: "10.96.0.1", Sport: 40000, Dport: 443}
+	tests := []struct {
+		name           string
+		modify         func(*types.ConntrackLookupParams)
+		expectedFamily string
+		wantError      bool
+	}{
+		{name: "valid ipv4 tuple", modify: func(*types.ConntrackLookupParams) {}, expectedFamily: "ipv4"},
+		{name: "valid ipv6 tuple", modify: func(p *types.ConntrackLookupParams) { p.Src, p.Dst = "fd00::3", "fd00::1" }, expectedFamily: "ipv6"},
+		{name: "icmp is not supported", modify: func(p *types.ConntrackLookupParams) { p.Protocol = "icmp" }, wantError: true},
+		{name: "invalid src", modify: func(p *types.ConntrackLookupParams) { p.Src = "pod-a" }, wantError: true},
+		{name: "mixed families", modify: func(p *types.ConntrackLookupParams) { p.Dst = "fd00::1" }, wantError: true},
+		{name: "missing sport", modify: func(p *types.ConntrackLookupParams) { p.Sport = 0 }, wantError: true},
+		{name: "dport out of range", modify: func(p *types.ConntrackLookupParams) { p.Dport = 65536 }, wantError: true},
+		{name: "ovs pod without namespace", modify: func(p *types.ConntrackLookupParams) { p.OVSPod = "ovnkube-node-a" }, wantError: true},
+		{name: "ovs pod with metacharacters", modify: func(p *types.ConntrackLookupParams) { p.OVSNamespace, p.OVSPod = "ovn-kubernetes", "a;b" }, wantError: true},
+		{name: "ovs namespace not a Kubernetes name", modify: func(p *types.ConntrackLookupParams) { p.OVSNamespace, p.OVSPod = "OVN_Kubernetes", "ovnkube-node-a" }, wantError: true},
+		{name: "ovs container not a Kubernetes name", modify: func(p *types.ConntrackLookupParams) {
+			p.OVSNamespace, p.OVSPod, p.OVSContainer = "ovn-kubernetes", "ovnkube-node-a", "ovs/daemons"
+		}, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := valid
+			tt.modify(&params)
+			_, family, err := validateConntrackLookup(params)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("validateConntrackLookup() error = %v, wantError %v", err, tt.wantError)
+			}
+			if family != tt.expectedFamily {
+				t.Errorf("validateConntrackLookup() family = %q, expected %q", family, tt.expectedFamily)
+			}
+		})
+	}
+}
+
+func TestGetConntrackEntry(t *testing.T) {
+	const (
+		kernelEntry = "tcp      6 431999 ESTABLISHED src=10.244.1.3 dst=10.96.0.1 sport=40000 dport=443 src=172.18.0.3 dst=10.244.1.3 sport=6443 dport=40000 [ASSURED] mark=0 zone=12 use=1"
+		otherEntry  = "tcp      6 431999 ESTABLISHED src=10.244.1.3 dst=10.96.0.1 sport=40001 dport=443 src=172.18.0.3 dst=10.244.1.3 sport=6443 dport=40001 [ASSURED] mark=0 zone=12 use=1"
+		ovsEntry    = "tcp,orig=(src=10.244.1.3,dst=10.96.0.1,sport=40000,dport=443),reply=(src=172.18.0.3,dst=10.244.1.3,sport=6443,dport=40000),zone=12,mark=2,protoinfo=(state=ESTABLISHED)"
+	)
+	tests := []struct {
+		name             string
+		zone             string
+		getFails         bool
+		ovsPod           string
+		expectedCommands []string
+		expectedKernel   int
+		expected         []types.ConntrackComparison
+	}{
+		{
+			name:             "all zones are listed without a zone",
+			expectedCommands: []string{"conntrack -L -p tcp -s 10.244.1.3 -d 10.96.0.1 --sport 40000 --dport 443"},
+			expectedKernel:   1,
+		},
+		{
+			name:   "zone lookup uses -G and compares with OVS",
+			zone:   "12",
+			ovsPod: "ovnkube-node-a",
+			expectedCommands: []string{
+				"conntrack -G -p tcp -s 10.244.1.3 -d 10.96.0.1 --sport 40000 --dport 443 --zone 12",
+				"ovs-appctl dpctl/dump-conntrack zone=12",
+			},
+			expectedKernel: 1,
+			expected: []types.ConntrackComparison{
+				{Zone: 12, InKernel: true, InOVS: true, DNAT: "172.18.0.3:6443", Differences: []string{"mark: kernel 0x0, ovs 0x2"}},
+			},
+		},
+		{
+			name:     "failed -G lookup is confirmed with -L",
+			zone:     "12",
+			getFails: true,
+			ovsPod:   "ovnkube-node-a",
+			expectedCommands: []string{
+				"conntrack -G -p tcp -s 10.244.1.3 -d 10.96.0.1 --sport 40000 --dport 443 --zone 12",
+				"conntrack -L -p tcp -s 10.244.1.3 -d 10.96.0.1 --sport 40000 --dport 443 --zone 12",
+				"ovs-appctl dpctl/dump-conntrack zone=12",
+			},
+			expected: []types.ConntrackComparison{
+				{Zone: 12, InOVS: true, DNAT: "172.18.0.3:6443"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+				if cmd[len(cmd)-1] == "-V" {
+					return "conntrack v1.4.7 (conntrack-tools)", "", nil
+				}
+				commands = append(commands, strings.Join(cmd, " "))
+				switch {
+				case cmd[1] == "-G" && tt.getFails:
+					return "", "", fmt.Errorf("command terminated with exit code 1")
+				case cmd[1] == "-G":
+					return kernelEntry, "conntrack v1.4.7 (conntrack-tools): 1 flow entries have been shown.", nil
+				case tt.getFails:
+					return "", "conntrack v1.4.7 (conntrack-tools): 0 flow entries have been shown.", nil
+				default:
+					return kernelEntry + "\n" + otherEntry, "conntrack v1.4.7 (conntrack-tools): 2 flow entries have been shown.", nil
+				}
+			}
+			runPodExecCommand := func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+				commands = append(commands, strings.Join(cmd, " "))
+				return ovsEntry, "", nil
+			}
+			server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+			if err != nil {
+				t.Fatalf("NewMCPServer() error = %v", err)
+			}
+			params := types.ConntrackLookupParams{
+				Node:     "ovn-worker",
+				Protocol: "tcp",
+				Src:      "10.244.1.3",
+				Dst:      "10.96.0.1",
+				Sport:    40000,
+				Dport:    443,
+				Zone:     tt.zone,
+			}
+			if tt.ovsPod != "" {
+				params.OVSNamespace, params.OVSPod = "ovn-kubernetes", tt.ovsPod
+			}
+			_, result, err := server.GetConntrackEntry(context.Background(), nil, params)
+			if err != nil {
+				t.Fatalf("GetConntrackEntry() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.expectedCommands, commands); diff != "" {
+				t.Errorf("GetConntrackEntry() commands mismatch (-want +got):\n%s", diff)
+			}
+			if len(result.Kernel) != tt.expectedKernel {
+				t.Errorf("GetConntrackEntry() got %d kernel entries, expected %d", len(result.Kernel), tt.expectedKernel)
+			}
+			if diff := cmp.Diff(tt.expected, result.Comparisons); diff != "" {
+				t.Errorf("GetConntrackEntry() comparisons mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,7 +1,14 @@
 package mcp
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
 )
 
 func TestValidateConntrackCommand(t *testing.T) {
@@ -148,3 +155,131 @@ func TestValidateConntrackCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestGetConntrackBothFamilies(t *testing.T) {
+	var commands []string
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		command := strings.Join(cmd, " ")
+		commands = append(commands, command)
+		switch command {
+		case "conntrack -V":
+			return "conntrack v1.4.8 (conntrack-tools)", "", nil
+		case "conntrack -L -f ipv4":
+			return "tcp      6 431999 ESTABLISHED src=10.244.1.3 dst=10.96.0.1 sport=40000 dport=443 src=172.18.0.3 dst=10.244.1.3 sport=6443 dport=40000 [ASSURED] mark=0 use=1\n",
+				"conntrack v1.4.8 (conntrack-tools): 1 flow entries have been shown.", nil
+		case "conntrack -L -f ipv6":
+			return "udp      17 29 src=fd00:10:244:1::3 dst=fd00:10:96::a sport=5353 dport=53 [UNREPLIED] src=fd00:10:96::a dst=fd00:10:244:1::3 sport=53 dport=5353 mark=0 use=1\n",
+				"conntrack v1.4.8 (conntrack-tools): 1 flow entries have been shown.", nil
+		}
+		t.Fatalf("unexpected command %q", command)
+		return "", "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.GetConntrack(context.Background(), nil, types.ListConntrackParams{
+		CommonParams: types.CommonParams{Node: "ovn-worker"},
+		Command:      "-L",
+		IPFamily:     "both",
+	})
+	if err != nil {
+		t.Fatalf("GetConntrack() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"conntrack -V", "conntrack -L -f ipv4", "conntrack -L -f ipv6"}, commands); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+	var families []string
+	for _, entry := range result.Entries {
+		families = append(families, entry.Family+" "+entry.Original.Dst)
+	}
+	if diff := cmp.Diff([]string{"ipv4 10.96.0.1", "ipv6 fd00:10:96::a"}, families); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetConntrackFromFile(t *testing.T) {
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		switch command := strings.Join(cmd, " "); command {
+		case "conntrack -V":
+			return "", "", fmt.Errorf("executable file not found")
+		case "cat /proc/net/nf_conntrack":
+			return "ipv4     2 tcp      6 431999 ESTABLISHED src=10.244.1.3 dst=10.96.0.1 sport=40000 dport=443 src=172.18.0.3 dst=10.244.1.3 sport=6443 dport=40000 [ASSURED] mark=0 zone=64000 use=1\n" +
+				"ipv4     2 tcp      6 431999 ESTABLISHED src=10.244.1.4 dst=10.96.0.1 sport=40001 dport=443 src=172.18.0.3 dst=10.244.1.4 sport=6443 dport=40001 [ASSURED] mark=0 zone=0 use=1\n" +
+				"ipv6     10 udp      17 29 src=fd00:10:244:1::3 dst=fd00:10:96::a sport=5353 dport=53 [UNREPLIED] src=fd00:10:96::a dst=fd00:10:244:1::3 sport=53 dport=5353 mark=0 zone=64000 use=1\n", "", nil
+		default:
+			t.Fatalf("unexpected command %q", command)
+			return "", "", nil
+		}
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.GetConntrack(context.Background(), nil, types.ListConntrackParams{
+		CommonParams: types.CommonParams{Node: "ovn-worker"},
+		Zone:         "64000",
+		IPFamily:     "ipv4",
+	})
+	if err != nil {
+		t.Fatalf("GetConntrack() error = %v", err)
+	}
+	var sources []string
+	for _, entry := range result.Entries {
+		sources = append(sources, entry.Family+" "+entry.Original.Src)
+	}
+	if diff := cmp.Diff([]string{"ipv4 10.244.1.3"}, sources); diff != "" {
+		t.Errorf("entries mismatch (-want +got):\n%s", diff)
+	}
+
+	_, result, err = server.GetConntrack(context.Background(), nil, types.ListConntrackParams{
+		CommonParams: types.CommonParams{Node: "ovn-worker"},
+		Zone:         "64000",
+		Aggregate:    true,
+	})
+	if err != nil {
+		t.Fatalf("GetConntrack() error = %v", err)
+	}
+	if result.Aggregate == nil || result.Aggregate.Total != 2 {
+		t.Errorf("GetConntrack() unexpected aggregate: %+v", result.Aggregate)
+	}
+
+	if _, _, err := server.GetConntrack(context.Background(), nil, types.ListConntrackParams{
+		CommonParams:     types.CommonParams{Node: "ovn-worker"},
+		FilterParameters: "-p tcp",
+	}); err == nil || !strings.Contains(err.Error(), "filter parameters cannot be used") {
+		t.Errorf("GetConntrack() expected filter parameters error, got %v", err)
+	}
+}
+
+func TestConntrackFamilies(t *testing.T) {
+	tests := []struct {
+		name             string
+		ipFamily         string
+		command          string
+		filterParameters string
+		wantError        bool
+	}{
+		{name: "no family", command: "--count"},
+		{name: "list both families", ipFamily: "both", command: "-L"},
+		{name: "count with family", ipFamily: "ipv4", command: "-C", wantError: true},
+		{name: "family also in filter parameters", ipFamily: "ipv6", filterParameters: "-p tcp -f ipv6", wantError: true},
+		{name: "invalid family", ipFamily: "inet", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := conntrackFamilies(tt.ipFamily, tt.command, tt.filterParameters)
+			if (err != nil) != tt.wantError {
+				t.Errorf("conntrackFamilies() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
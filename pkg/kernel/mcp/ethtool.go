@@ -0,0 +1,354 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+)
+
+const (
+	// maxEthtoolNodes bounds the number of nodes an interface is compared across.
+	maxEthtoolNodes = 10
+
+	ethtoolSectionDriver     = "driver"
+	ethtoolSectionFeatures   = "features"
+	ethtoolSectionStatistics = "statistics"
+	ethtoolSectionRings      = "rings"
+	ethtoolSectionChannels   = "channels"
+	ethtoolSectionModule     = "module"
+
+	// ethtoolSectionFailed follows the output of a section whose ethtool command failed.
+	ethtoolSectionFailed = "failed"
+	// ethtoolMissing is the value of a difference on a node the value is missing on.
+	ethtoolMissing = "missing"
+)
+
+// ethtoolSections are the sections get-ethtool reads, in output order, and their ethtool options.
+var ethtoolSections = []struct {
+	name   string
+	option string
+}{
+	{name: ethtoolSectionDriver, option: "-i"},
+	{name: ethtoolSectionFeatures, option: "-k"},
+	{name: ethtoolSectionStatistics, option: "-S"},
+	{name: ethtoolSectionRings, option: "-g"},
+	{name: ethtoolSectionChannels, option: "-l"},
+	{name: ethtoolSectionModule, option: "-m"},
+}
+
+// ethtoolUncomparedDriverKeys are the driver values that naturally differ between nodes.
+var ethtoolUncomparedDriverKeys = map[string]bool{
+	"bus_info": true,
+}
+
+// GetEthtool reads the driver, offload features, statistics, rings, channels and module information
+// of an interface with ethtool. When other nodes are given, the same interface is read on every node
+// and the driver, features, rings and channels that differ between them are reported.
+func (s *MCPServer) GetEthtool(ctx context.Context, req *mcp.CallToolRequest, in types.EthtoolParams) (*mcp.CallToolResult, types.EthtoolResult, error) {
+	nodes, sections, statisticsFilter, err := validateEthtool(in)
+	if err != nil {
+		return nil, types.EthtoolResult{}, fmt.Errorf("error while getting ethtool information: %w", err)
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	// Every section is read by the same command so that a single debug pod is created per node.
+	cmd := commandbuilder.NewCommand("sh", "-c", ethtoolScript(in.Interface, sections))
+	result := types.EthtoolResult{Interfaces: []types.EthtoolInterface{}}
+	for _, node := range nodes {
+		stdout, stderr, err := s.executeCommand(ctx, in.Namespace, node, cmd.Build())
+		if err != nil {
+			return nil, types.EthtoolResult{}, fmt.Errorf("error while getting ethtool information on node %s: %w", node, err)
+		}
+		if stderr = strings.TrimSpace(stderr); stderr != "" {
+			return nil, types.EthtoolResult{}, fmt.Errorf("error while running command on node %s: %s", node, stderr)
+		}
+		iface := parseEthtoolOutput(node, in.Interface, stdout, statisticsFilter)
+		if len(iface.Errors) == len(sections) {
+			return nil, types.EthtoolResult{}, fmt.Errorf("error while getting ethtool information: failed to read interface %s on node %s: %s",
+				in.Interface, node, iface.Errors[sections[0]])
+		}
+		result.Interfaces = append(result.Interfaces, iface)
+	}
+	if len(nodes) > 1 {
+		result.Differences = compareEthtoolInterfaces(result.Interfaces)
+	}
+	return nil, result, nil
+}
+
+// validateEthtool validates the parameters and returns the nodes, the sections to read and the
+// statistics filter.
+func validateEthtool(in types.EthtoolParams) ([]string, []string, *regexp.Regexp, error) {
+	if strings.TrimSpace(in.Node) == "" {
+		return nil, nil, nil, fmt.Errorf("node is required")
+	}
+	if !validIPDeviceName.MatchString(in.Interface) {
+		return nil, nil, nil, fmt.Errorf("invalid interface %q: must be a network device name", in.Interface)
+	}
+	nodes := []string{strings.TrimSpace(in.Node)}
+	for _, node := range strings.Split(in.Nodes, ",") {
+		if node = strings.TrimSpace(node); node != "" && !slices.Contains(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	if len(nodes) > maxEthtoolNodes {
+		return nil, nil, nil, fmt.Errorf("too many nodes: at most %d nodes can be compared", maxEthtoolNodes)
+	}
+
+	requested := map[string]bool{}
+	for _, section := range strings.Split(in.Sections, ",") {
+		if section = strings.ToLower(strings.TrimSpace(section)); section != "" {
+			requested[section] = true
+		}
+	}
+	all := len(requested) == 0
+	var sections []string
+	for _, section := range ethtoolSections {
+		if all || requested[section.name] {
+			sections = append(sections, section.name)
+			delete(requested, section.name)
+		}
+	}
+	if len(requested) > 0 {
+		return nil, nil, nil, fmt.Errorf("invalid sections %s: must be driver, features, statistics, rings, channels or module",
+			strings.Join(slices.Sorted(maps.Keys(requested)), ", "))
+	}
+
+	var statisticsFilter *regexp.Regexp
+	if in.StatisticsFilter != "" {
+		var err error
+		statisticsFilter, err = regexp.Compile(in.StatisticsFilter)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid statistics_filter: %w", err)
+		}
+	}
+	return nodes, sections, statisticsFilter, nil
+}
+
+// ethtoolScript returns the script printing each section of the interface. The output of a section
+// whose command fails is followed by an ethtoolSectionFailed section, as -g, -l and -m are not
+// supported by every driver. The interface name is validated and needs no quoting.
+func ethtoolScript(iface string, sections []string) string {
+	parts := []string{`command -v ethtool >/dev/null 2>&1 || { echo "ethtool utility is unavailable in configured image" >&2; exit 0; }`}
+	for _, section := range ethtoolSections {
+		if slices.Contains(sections, section.name) {
+			parts = append(parts, fmt.Sprintf(`echo "%s%s"; ethtool %s %s 2>&1 || echo "%s%s"`,
+				outputSectionPrefix, section.name, section.option, iface, outputSectionPrefix, ethtoolSectionFailed))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// parseEthtoolOutput parses the output of the ethtool script of an interface.
+func parseEthtoolOutput(node, ifaceName, output string, statisticsFilter *regexp.Regexp) types.EthtoolInterface {
+	iface := types.EthtoolInterface{Node: node, Interface: ifaceName}
+	sections := splitOutputSections(output)
+	for i, section := range sections {
+		if section.name == ethtoolSectionFailed {
+			continue
+		}
+		if i+1 < len(sections) && sections[i+1].name == ethtoolSectionFailed {
+			if iface.Errors == nil {
+				iface.Errors = map[string]string{}
+			}
+			iface.Errors[section.name] = strings.TrimSpace(strings.Join(section.lines, "; "))
+			continue
+		}
+		switch section.name {
+		case ethtoolSectionDriver:
+			iface.Driver = parseEthtoolValues(section.lines)
+		case ethtoolSectionFeatures:
+			iface.Features = parseEthtoolFeatures(section.lines)
+		case ethtoolSectionStatistics:
+			iface.Statistics = parseEthtoolStatistics(section.lines, statisticsFilter)
+		case ethtoolSectionRings:
+			iface.Rings = parseEthtoolParameters(section.lines)
+		case ethtoolSectionChannels:
+			iface.Channels = parseEthtoolParameters(section.lines)
+		case ethtoolSectionModule:
+			iface.Module = parseEthtoolValues(section.lines)
+		}
+	}
+	return iface
+}
+
+// ethtoolKey normalizes the names printed by ethtool -i, -g, -l and -m, e.g. 'RX Mini' is rx_mini
+// and 'firmware-version' is firmware_version.
+func ethtoolKey(name string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(name)))
+}
+
+// parseEthtoolValues parses 'name: value' lines, as printed by ethtool -i and -m. Values of names
+// printed several times, such as the transceiver type of -m, are joined.
+func parseEthtoolValues(lines []string) map[string]string {
+	values := map[string]string{}
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value := ethtoolKey(name), strings.TrimSpace(value)
+		if previous, ok := values[key]; ok && previous != "" {
+			value = previous + "; " + value
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// parseEthtoolFeatures parses ethtool -k output, e.g. 'tx-checksum-ipv4: off [requested on]'.
+func parseEthtoolFeatures(lines []string) map[string]types.EthtoolFeature {
+	features := map[string]types.EthtoolFeature{}
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		fields := strings.Fields(value)
+		if !ok || len(fields) == 0 || (fields[0] != "on" && fields[0] != "off") {
+			continue
+		}
+		feature := types.EthtoolFeature{Enabled: fields[0] == "on"}
+		annotation := strings.Join(fields[1:], " ")
+		switch {
+		case annotation == "[fixed]":
+			feature.Fixed = true
+		case strings.HasPrefix(annotation, "[requested "):
+			feature.Requested = strings.TrimSuffix(strings.TrimPrefix(annotation, "[requested "), "]")
+		}
+		features[strings.TrimSpace(name)] = feature
+	}
+	return features
+}
+
+// parseEthtoolStatistics parses ethtool -S output, keeping the statistics whose name matches the filter.
+func parseEthtoolStatistics(lines []string, filter *regexp.Regexp) map[string]uint64 {
+	statistics := map[string]uint64{}
+	for _, line := range lines {
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		name = strings.TrimSpace(name)
+		if err != nil || (filter != nil && !filter.MatchString(name)) {
+			continue
+		}
+		statistics[name] = n
+	}
+	return statistics
+}
+
+// parseEthtoolParameters parses ethtool -g and -l output, which prints the pre-set maximums and
+// then the current hardware settings.
+func parseEthtoolParameters(lines []string) *types.EthtoolParameters {
+	parameters := &types.EthtoolParameters{Maximum: map[string]string{}, Current: map[string]string{}}
+	var values map[string]string
+	for _, line := range lines {
+		switch strings.TrimSpace(line) {
+		case "Pre-set maximums:":
+			values = parameters.Maximum
+			continue
+		case "Current hardware settings:":
+			values = parameters.Current
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || values == nil {
+			continue
+		}
+		values[ethtoolKey(name)] = strings.TrimSpace(value)
+	}
+	return parameters
+}
+
+// ethtoolComparedValues returns the values of a section of the interface that are compared across
+// nodes, or false if the section was not read.
+func ethtoolComparedValues(iface types.EthtoolInterface, section string) (map[string]string, bool) {
+	values := map[string]string{}
+	switch section {
+	case ethtoolSectionDriver:
+		if iface.Driver == nil {
+			return nil, false
+		}
+		for key, value := range iface.Driver {
+			if !ethtoolUncomparedDriverKeys[key] {
+				values[key] = value
+			}
+		}
+	case ethtoolSectionFeatures:
+		if iface.Features == nil {
+			return nil, false
+		}
+		for name, feature := range iface.Features {
+			value := "off"
+			if feature.Enabled {
+				value = "on"
+			}
+			if feature.Requested != "" {
+				value += " [requested " + feature.Requested + "]"
+			}
+			values[name] = value
+		}
+	case ethtoolSectionRings, ethtoolSectionChannels:
+		parameters := iface.Rings
+		if section == ethtoolSectionChannels {
+			parameters = iface.Channels
+		}
+		if parameters == nil {
+			return nil, false
+		}
+		maps.Copy(values, parameters.Current)
+	}
+	return values, true
+}
+
+// compareEthtoolInterfaces returns the driver, features, rings and channels values that differ
+// between the interfaces of the nodes, by section and key. Sections are only compared between the
+// nodes they were read on.
+func compareEthtoolInterfaces(interfaces []types.EthtoolInterface) []types.EthtoolDifference {
+	differences := []types.EthtoolDifference{}
+	for _, section := range []string{ethtoolSectionDriver, ethtoolSectionFeatures, ethtoolSectionRings, ethtoolSectionChannels} {
+		nodeValues := map[string]map[string]string{}
+		keys := map[string]bool{}
+		for _, iface := range interfaces {
+			values, ok := ethtoolComparedValues(iface, section)
+			if !ok {
+				continue
+			}
+			nodeValues[iface.Node] = values
+			for key := range values {
+				keys[key] = true
+			}
+		}
+		if len(nodeValues) < 2 {
+			continue
+		}
+		for _, key := range slices.Sorted(maps.Keys(keys)) {
+			values := map[string]string{}
+			distinct := map[string]bool{}
+			for node, nodeValue := range nodeValues {
+				value, ok := nodeValue[key]
+				if !ok {
+					value = ethtoolMissing
+				}
+				values[node] = value
+				distinct[value] = true
+			}
+			if len(distinct) > 1 {
+				differences = append(differences, types.EthtoolDifference{Section: section, Key: key, Values: values})
+			}
+		}
+	}
+	return differences
+}
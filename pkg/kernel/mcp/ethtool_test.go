@@ -0,0 +1,249 @@
+package mcp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+// ethtoolOutput returns the output of the ethtool script for an interface with the given transmit
+// checksumming and UDP tunnel segmentation states and current RX ring size.
+func ethtoolOutput(txChecksum, tunnelSegmentation, rxRing string) string {
+	return `### driver
+driver: ice
+version: 6.1.0
+firmware-version: 4.20 0x80017785 1.3346.0
+expansion-rom-version:
+bus-info: 0000:3b:00.0
+supports-statistics: yes
+### features
+Features for ens1f0:
+rx-checksumming: on
+tx-checksumming: ` + txChecksum + `
+	tx-checksum-ipv4: off [fixed]
+	tx-checksum-ip-generic: ` + txChecksum + `
+tx-udp_tnl-segmentation: ` + tunnelSegmentation + `
+hw-tc-offload: off [fixed]
+### statistics
+NIC statistics:
+     rx_packets: 1000
+     tx_packets: 900
+     rx_dropped: 3
+     tx_queue_0_packets: 450
+### rings
+Ring parameters for ens1f0:
+Pre-set maximums:
+RX:		8160
+RX Mini:	n/a
+TX:		8160
+Current hardware settings:
+RX:		` + rxRing + `
+RX Mini:	n/a
+TX:		2048
+### channels
+Channel parameters for ens1f0:
+Pre-set maximums:
+RX:		n/a
+TX:		n/a
+Combined:	64
+Current hardware settings:
+RX:		n/a
+TX:		n/a
+Combined:	16
+### module
+netlink error: Operation not supported
+### failed
+`
+}
+
+func TestValidateEthtool(t *testing.T) {
+	tests := []struct {
+		name         string
+		in           types.EthtoolParams
+		wantNodes    []string
+		wantSections []string
+		wantErr      string
+	}{
+		{
+			name:         "all sections",
+			in:           types.EthtoolParams{Node: "worker1", Interface: "ens1f0"},
+			wantNodes:    []string{"worker1"},
+			wantSections: []string{"driver", "features", "statistics", "rings", "channels", "module"},
+		},
+		{
+			name:         "compared nodes and selected sections",
+			in:           types.EthtoolParams{Node: "worker1", Nodes: "worker2, worker1,worker3", Interface: "ens1f0", Sections: "features,Driver"},
+			wantNodes:    []string{"worker1", "worker2", "worker3"},
+			wantSections: []string{"driver", "features"},
+		},
+		{
+			name:    "invalid interface",
+			in:      types.EthtoolParams{Node: "worker1", Interface: "eth0;reboot"},
+			wantErr: "invalid interface",
+		},
+		{
+			name:    "missing interface",
+			in:      types.EthtoolParams{Node: "worker1"},
+			wantErr: "invalid interface",
+		},
+		{
+			name:    "invalid section",
+			in:      types.EthtoolParams{Node: "worker1", Interface: "eth0", Sections: "features,eeprom,coalesce"},
+			wantErr: "invalid sections coalesce, eeprom",
+		},
+		{
+			name:    "invalid statistics filter",
+			in:      types.EthtoolParams{Node: "worker1", Interface: "eth0", StatisticsFilter: "rx_("},
+			wantErr: "invalid statistics_filter",
+		},
+		{
+			name:    "too many nodes",
+			in:      types.EthtoolParams{Node: "worker0", Interface: "eth0", Nodes: "w1,w2,w3,w4,w5,w6,w7,w8,w9,w10"},
+			wantErr: "too many nodes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodes, sections, _, err := validateEthtool(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("validateEthtool() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateEthtool() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.wantNodes, nodes); diff != "" {
+				t.Errorf("nodes mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantSections, sections); diff != "" {
+				t.Errorf("sections mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseEthtoolOutput(t *testing.T) {
+	got := parseEthtoolOutput("worker1", "ens1f0", ethtoolOutput("on", "off [requested on]", "2048"), regexp.MustCompile(`^rx_`))
+	want := types.EthtoolInterface{
+		Node:      "worker1",
+		Interface: "ens1f0",
+		Driver: map[string]string{
+			"driver":                "ice",
+			"version":               "6.1.0",
+			"firmware_version":      "4.20 0x80017785 1.3346.0",
+			"expansion_rom_version": "",
+			"bus_info":              "0000:3b:00.0",
+			"supports_statistics":   "yes",
+		},
+		Features: map[string]types.EthtoolFeature{
+			"rx-checksumming":         {Enabled: true},
+			"tx-checksumming":         {Enabled: true},
+			"tx-checksum-ipv4":        {Fixed: true},
+			"tx-checksum-ip-generic":  {Enabled: true},
+			"tx-udp_tnl-segmentation": {Requested: "on"},
+			"hw-tc-offload":           {Fixed: true},
+		},
+		Statistics: map[string]uint64{"rx_packets": 1000, "rx_dropped": 3},
+		Rings: &types.EthtoolParameters{
+			Maximum: map[string]string{"rx": "8160", "rx_mini": "n/a", "tx": "8160"},
+			Current: map[string]string{"rx": "2048", "rx_mini": "n/a", "tx": "2048"},
+		},
+		Channels: &types.EthtoolParameters{
+			Maximum: map[string]string{"rx": "n/a", "tx": "n/a", "combined": "64"},
+			Current: map[string]string{"rx": "n/a", "tx": "n/a", "combined": "16"},
+		},
+		Errors: map[string]string{"module": "netlink error: Operation not supported"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseEthtoolOutput() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseEthtoolModule(t *testing.T) {
+	got := parseEthtoolValues([]string{
+		"	Identifier                                : 0x03 (SFP)",
+		"	Transceiver type                          : 10G Ethernet: 10G Base-SR",
+		"	Transceiver type                          : Extended: 25G Base-SR",
+		"	Vendor OUI                                : 00:02:c9",
+	})
+	want := map[string]string{
+		"identifier":       "0x03 (SFP)",
+		"transceiver_type": "10G Ethernet: 10G Base-SR; Extended: 25G Base-SR",
+		"vendor_oui":       "00:02:c9",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseEthtoolValues() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompareEthtoolInterfaces(t *testing.T) {
+	worker1 := parseEthtoolOutput("worker1", "ens1f0", ethtoolOutput("on", "on", "2048"), nil)
+	worker2 := parseEthtoolOutput("worker2", "ens1f0", ethtoolOutput("off", "off [requested on]", "2048"), nil)
+	worker2.Driver["bus_info"] = "0000:5e:00.0"
+	delete(worker2.Features, "hw-tc-offload")
+	// Sections that could not be read are not compared.
+	worker3 := parseEthtoolOutput("worker3", "ens1f0", ethtoolOutput("on", "on", "512"), nil)
+	worker3.Driver, worker3.Features, worker3.Rings, worker3.Channels = nil, nil, nil, nil
+
+	got := compareEthtoolInterfaces([]types.EthtoolInterface{worker1, worker2, worker3})
+	want := []types.EthtoolDifference{
+		{Section: "features", Key: "hw-tc-offload", Values: map[string]string{"worker1": "off", "worker2": "missing"}},
+		{Section: "features", Key: "tx-checksum-ip-generic", Values: map[string]string{"worker1": "on", "worker2": "off"}},
+		{Section: "features", Key: "tx-checksumming", Values: map[string]string{"worker1": "on", "worker2": "off"}},
+		{Section: "features", Key: "tx-udp_tnl-segmentation", Values: map[string]string{"worker1": "on", "worker2": "off [requested on]"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("compareEthtoolInterfaces() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestGetEthtool(t *testing.T) {
+	var nodes []string
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		nodes = append(nodes, nodeName)
+		if len(cmd) != 3 || cmd[0] != "sh" || !strings.Contains(cmd[2], "ethtool -i ens1f0") {
+			t.Fatalf("unexpected command %q", cmd)
+		}
+		switch nodeName {
+		case "worker1":
+			return ethtoolOutput("on", "on", "2048"), "", nil
+		case "worker2":
+			return ethtoolOutput("on", "on", "4096"), "", nil
+		}
+		return "### driver\nCannot get driver information: No such device\n### failed\n", "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.GetEthtool(context.Background(), nil, types.EthtoolParams{Node: "worker1", Nodes: "worker2", Interface: "ens1f0"})
+	if err != nil {
+		t.Fatalf("GetEthtool() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"worker1", "worker2"}, nodes); diff != "" {
+		t.Errorf("nodes mismatch (-want +got):\n%s", diff)
+	}
+	if len(result.Interfaces) != 2 || result.Interfaces[1].Node != "worker2" {
+		t.Fatalf("interfaces = %+v, want the interfaces of worker1 and worker2", result.Interfaces)
+	}
+	want := []types.EthtoolDifference{{Section: "rings", Key: "rx", Values: map[string]string{"worker1": "2048", "worker2": "4096"}}}
+	if diff := cmp.Diff(want, result.Differences); diff != "" {
+		t.Errorf("differences mismatch (-want +got):\n%s", diff)
+	}
+
+	_, _, err = server.GetEthtool(context.Background(), nil, types.EthtoolParams{Node: "worker3", Interface: "ens1f0", Sections: "driver"})
+	if err == nil || !strings.Contains(err.Error(), "No such device") {
+		t.Errorf("GetEthtool() error = %v, want the ethtool error of a missing interface", err)
+	}
+}
@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -10,12 +11,14 @@ import (
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/headtail"
 )
 
 // GetIPCommandOutput MCP handler for ip utility operations.
 // GetIPCommandOutput executes 'ip' utility commands on a node.
+// Address, link, neighbour, route and rule commands are run with -j and return typed objects.
 // Requires ip utility in the debug container image.
-func (s *MCPServer) GetIPCommandOutput(ctx context.Context, req *mcp.CallToolRequest, in types.ListIPParams) (*mcp.CallToolResult, types.Result, error) {
+func (s *MCPServer) GetIPCommandOutput(ctx context.Context, req *mcp.CallToolRequest, in types.ListIPParams) (*mcp.CallToolResult, types.IPResult, error) {
 	// If timeout is specified, create a new context with timeout
 	var cancel context.CancelFunc
 	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
@@ -25,40 +28,131 @@ func (s *MCPServer) GetIPCommandOutput(ctx context.Context, req *mcp.CallToolReq
 
 	err := s.utilityExists(ctx, in.Namespace, in.Node, "ip")
 	if err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting ip data: failed to verify ip utility availability in configured image: %w", err)
+		return nil, types.IPResult{}, fmt.Errorf("error while getting ip data: failed to verify ip utility availability in configured image: %w", err)
 	}
 
 	if err := validateIPCommand(in.Command); err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting ip data: %w", err)
+		return nil, types.IPResult{}, fmt.Errorf("error while getting ip data: %w", err)
 	}
 	if err := utils.ValidateSafeString(in.FilterParameters, "filter parameters", true, utils.ShellMetaCharactersTypeDefault); err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting ip data: %w", err)
+		return nil, types.IPResult{}, fmt.Errorf("error while getting ip data: %w", err)
 	}
 	if err := utils.ValidateSafeString(in.Options, "options", true, utils.ShellMetaCharactersTypeDefault); err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting ip data: %w", err)
+		return nil, types.IPResult{}, fmt.Errorf("error while getting ip data: %w", err)
+	}
+	object := ipObjectForCommand(in.Command)
+	if err := validateIPFilters(object, in.Table, in.Device, in.Netns); err != nil {
+		return nil, types.IPResult{}, fmt.Errorf("error while getting ip data: %w", err)
+	}
+	if err := validatePodTarget(in.PodTargetParams); err != nil {
+		return nil, types.IPResult{}, fmt.Errorf("error while getting ip data: %w", err)
+	}
+	// Pod sandboxes are not named network namespaces, so they are entered with nsenter instead.
+	if in.Netns != "" && in.PodName != "" {
+		return nil, types.IPResult{}, fmt.Errorf("error while getting ip data: netns cannot be used with pod_namespace and pod_name")
+	}
+
+	prefix, netns, err := s.podNetnsPrefix(ctx, in.Namespace, in.Node, in.PodTargetParams)
+	if err != nil {
+		return nil, types.IPResult{}, fmt.Errorf("error while getting ip data: %w", err)
 	}
 
-	cmd := commandbuilder.NewCommand("ip")
+	cmd := commandbuilder.NewCommand(prefix...)
+	cmd.Add("ip")
 	cmd.AddIfNotEmpty(in.Options, strings.Fields(in.Options)...)
+	cmd.AddIfNotEmpty(in.Netns, "-n", in.Netns)
+	cmd.AddIf(object != "", "-j")
+	// Details are needed for the link type, VLAN and tunnel information.
+	cmd.AddIf(object == ipObjectLink, "-d")
 	cmd.Add(strings.Fields(in.Command)...)
+	cmd.AddIfNotEmpty(in.Table, "table", in.Table)
+	cmd.AddIfNotEmpty(in.Device, "dev", in.Device)
 	cmd.AddIfNotEmpty(in.FilterParameters, strings.Fields(in.FilterParameters)...)
 
 	stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
 	if err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting ip data: %w", err)
+		return nil, types.IPResult{}, fmt.Errorf("error while getting ip data: %w", err)
 	}
 
 	if stderr != "" {
-		return nil, types.Result{}, fmt.Errorf("error while running command: %s", stderr)
+		return nil, types.IPResult{}, fmt.Errorf("error while running command: %s", stderr)
+	}
+
+	result, err := parseIPOutput(object, stdout, in.Table, in.HeadTailParams)
+	if err != nil {
+		return nil, types.IPResult{}, fmt.Errorf("error while getting ip data: %w", err)
+	}
+	result.Netns = netns
+	return nil, result, nil
+}
+
+// parseIPOutput converts the ip output of the given object into the tool result and applies the
+// head and tail parameters to the objects, or to the lines of raw output.
+func parseIPOutput(object ipObject, stdout, table string, headTailParams headtail.HeadTailParams) (types.IPResult, error) {
+	var result types.IPResult
+	var err error
+	switch object {
+	case ipObjectRoute:
+		defaultTable := "main"
+		if table != "" && table != "all" {
+			defaultTable = table
+		}
+		result.Routes, err = parseIPRoutes(stdout, defaultTable)
+		result.Routes = headtail.ApplyTo(&headTailParams, result.Routes, DefaultMaxOutputLines)
+	case ipObjectLink:
+		result.Links, err = parseIPLinks(stdout)
+		result.Links = headtail.ApplyTo(&headTailParams, result.Links, DefaultMaxOutputLines)
+	case ipObjectAddress:
+		result.Addresses, err = parseIPAddresses(stdout)
+		result.Addresses = headtail.ApplyTo(&headTailParams, result.Addresses, DefaultMaxOutputLines)
+	case ipObjectNeighbour:
+		result.Neighbours, err = parseIPNeighbours(stdout)
+		result.Neighbours = headtail.ApplyTo(&headTailParams, result.Neighbours, DefaultMaxOutputLines)
+	case ipObjectRule:
+		result.Rules, err = parseIPRules(stdout)
+		result.Rules = headtail.ApplyTo(&headTailParams, result.Rules, DefaultMaxOutputLines)
+	default:
+		// Strip empty lines from the output
+		lines := utils.StripEmptyLines(strings.Split(stdout, "\n"))
+		// Apply the head and tail parameters to the lines
+		lines = headTailParams.Apply(lines, DefaultMaxOutputLines)
+		// Join the lines back into a single string
+		result.Data = strings.Join(lines, "\n")
 	}
+	return result, err
+}
+
+var (
+	// validIPTableName matches routing table names and numbers.
+	validIPTableName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	// validIPDeviceName matches network device names, which are at most 15 characters long.
+	validIPDeviceName = regexp.MustCompile(`^[a-zA-Z0-9_.:-]{1,15}$`)
+	// validIPNetnsName matches named network namespaces.
+	validIPNetnsName = regexp.MustCompile(`^[a-zA-Z0-9_.-]+$`)
+)
 
-	// Strip empty lines from the output
-	lines := utils.StripEmptyLines(strings.Split(stdout, "\n"))
-	// Apply the head and tail parameters to the lines
-	lines = in.HeadTailParams.Apply(lines, DefaultMaxOutputLines)
-	// Join the lines back into a single string
-	stdout = strings.Join(lines, "\n")
-	return nil, types.Result{Data: stdout}, nil
+// validateIPFilters validates the table, device and netns filters and that they apply to the object.
+func validateIPFilters(object ipObject, table, device, netns string) error {
+	if table != "" {
+		if !validIPTableName.MatchString(table) {
+			return fmt.Errorf("invalid table %q: must be a routing table name or number", table)
+		}
+		if object != ipObjectRoute && object != ipObjectRule {
+			return fmt.Errorf("table can only be used with route and rule commands")
+		}
+	}
+	if device != "" {
+		if !validIPDeviceName.MatchString(device) {
+			return fmt.Errorf("invalid device %q: must be a network device name", device)
+		}
+		if object != ipObjectRoute && object != ipObjectAddress && object != ipObjectLink && object != ipObjectNeighbour {
+			return fmt.Errorf("device can only be used with route, address, link and neighbour commands")
+		}
+	}
+	if netns != "" && !validIPNetnsName.MatchString(netns) {
+		return fmt.Errorf("invalid netns %q: must be a network namespace name", netns)
+	}
+	return nil
 }
 
 // validateIPCommand validates that the IP command is allowed.
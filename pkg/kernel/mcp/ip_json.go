@@ -0,0 +1,328 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+// ipObject is an ip object whose JSON output is parsed into typed results.
+type ipObject string
+
+const (
+	ipObjectAddress   ipObject = "address"
+	ipObjectRoute     ipObject = "route"
+	ipObjectRule      ipObject = "rule"
+	ipObjectNeighbour ipObject = "neighbour"
+	ipObjectLink      ipObject = "link"
+)
+
+// ipObjectAbbreviations are the objects with typed results in the order ip resolves abbreviations,
+// e.g. 'r' is route and 'ru' is rule.
+var ipObjectAbbreviations = []struct {
+	name   string
+	object ipObject
+}{
+	{name: "address", object: ipObjectAddress},
+	{name: "route", object: ipObjectRoute},
+	{name: "rule", object: ipObjectRule},
+	{name: "neighbour", object: ipObjectNeighbour},
+	{name: "neighbor", object: ipObjectNeighbour},
+	{name: "link", object: ipObjectLink},
+}
+
+// ipObjectForCommand returns the object of an ip command with typed results, or an empty string
+// for commands that return raw output.
+func ipObjectForCommand(command string) ipObject {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return ""
+	}
+	for _, abbreviation := range ipObjectAbbreviations {
+		if strings.HasPrefix(abbreviation.name, fields[0]) {
+			return abbreviation.object
+		}
+	}
+	return ""
+}
+
+// ipRouteJSON is a route printed by 'ip -j route show'.
+type ipRouteJSON struct {
+	Type     string            `json:"type"`
+	Dst      string            `json:"dst"`
+	Gateway  string            `json:"gateway"`
+	Dev      string            `json:"dev"`
+	Table    string            `json:"table"`
+	Protocol string            `json:"protocol"`
+	Scope    string            `json:"scope"`
+	PrefSrc  string            `json:"prefsrc"`
+	Metric   int               `json:"metric"`
+	Metrics  []map[string]any  `json:"metrics"`
+	Flags    []string          `json:"flags"`
+	Nexthops []types.IPNexthop `json:"nexthops"`
+}
+
+// parseIPRoutes parses 'ip -j route show' output. Routes of the main table do not carry their
+// table, nor do routes listed from a single table, so defaultTable is used for them.
+func parseIPRoutes(output, defaultTable string) ([]types.IPRoute, error) {
+	var raw []ipRouteJSON
+	if err := unmarshalIPJSON(output, &raw); err != nil {
+		return nil, err
+	}
+	routes := make([]types.IPRoute, 0, len(raw))
+	for _, r := range raw {
+		routes = append(routes, r.toIPRoute(defaultTable))
+	}
+	return routes, nil
+}
+
+// toIPRoute converts the route, using defaultTable if the route does not carry its table.
+func (r ipRouteJSON) toIPRoute(defaultTable string) types.IPRoute {
+	route := types.IPRoute{
+		Type:     r.Type,
+		Dst:      r.Dst,
+		Gateway:  r.Gateway,
+		Dev:      r.Dev,
+		Table:    r.Table,
+		Protocol: r.Protocol,
+		Scope:    r.Scope,
+		PrefSrc:  r.PrefSrc,
+		Metric:   r.Metric,
+		Flags:    r.Flags,
+		Nexthops: r.Nexthops,
+	}
+	if route.Table == "" {
+		route.Table = defaultTable
+	}
+	for _, metric := range r.Metrics {
+		if mtu, ok := metric["mtu"].(float64); ok {
+			route.MTU = int(mtu)
+		}
+	}
+	return route
+}
+
+// ipLinkJSON is a link printed by 'ip -j -d link show'.
+type ipLinkJSON struct {
+	Index       int      `json:"ifindex"`
+	Name        string   `json:"ifname"`
+	Link        string   `json:"link"`
+	Flags       []string `json:"flags"`
+	MTU         int      `json:"mtu"`
+	Master      string   `json:"master"`
+	OperState   string   `json:"operstate"`
+	LinkType    string   `json:"link_type"`
+	Address     string   `json:"address"`
+	LinkNetnsID *int     `json:"link_netnsid"`
+	LinkInfo    *struct {
+		Kind      string `json:"info_kind"`
+		SlaveKind string `json:"info_slave_kind"`
+		Data      *struct {
+			Protocol string `json:"protocol"`
+			ID       *int   `json:"id"`
+			Local    string `json:"local"`
+			Remote   string `json:"remote"`
+			Group    string `json:"group"`
+			Port     int    `json:"port"`
+			External bool   `json:"external"`
+		} `json:"info_data"`
+	} `json:"linkinfo"`
+}
+
+// parseIPLinks parses 'ip -j -d link show' output.
+func parseIPLinks(output string) ([]types.IPLink, error) {
+	var raw []ipLinkJSON
+	if err := unmarshalIPJSON(output, &raw); err != nil {
+		return nil, err
+	}
+	links := make([]types.IPLink, 0, len(raw))
+	for _, l := range raw {
+		link := types.IPLink{
+			Index:     l.Index,
+			Name:      l.Name,
+			Link:      l.Link,
+			Type:      l.LinkType,
+			Address:   l.Address,
+			MTU:       l.MTU,
+			State:     l.OperState,
+			Flags:     l.Flags,
+			Master:    l.Master,
+			LinkNetns: l.LinkNetnsID,
+		}
+		if l.LinkInfo != nil {
+			info := &types.IPLinkInfo{Kind: l.LinkInfo.Kind, SlaveKind: l.LinkInfo.SlaveKind}
+			if data := l.LinkInfo.Data; data != nil {
+				switch info.Kind {
+				case "vlan":
+					info.VLANID = data.ID
+					info.VLANProtocol = data.Protocol
+				case "vxlan", "geneve":
+					info.VNI = data.ID
+					info.Local = data.Local
+					info.Remote = data.Remote
+					info.Group = data.Group
+					info.Port = data.Port
+					info.External = data.External
+				}
+			}
+			link.Info = info
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// ipAddressJSON is an interface printed by 'ip -j address show'.
+type ipAddressJSON struct {
+	Index    int    `json:"ifindex"`
+	Name     string `json:"ifname"`
+	AddrInfo []struct {
+		Family    string `json:"family"`
+		Local     string `json:"local"`
+		PrefixLen int    `json:"prefixlen"`
+		Broadcast string `json:"broadcast"`
+		Scope     string `json:"scope"`
+		Label     string `json:"label"`
+		Dynamic   bool   `json:"dynamic"`
+	} `json:"addr_info"`
+}
+
+// parseIPAddresses parses 'ip -j address show' output into one entry per address.
+func parseIPAddresses(output string) ([]types.IPAddress, error) {
+	var raw []ipAddressJSON
+	if err := unmarshalIPJSON(output, &raw); err != nil {
+		return nil, err
+	}
+	addresses := []types.IPAddress{}
+	for _, iface := range raw {
+		for _, info := range iface.AddrInfo {
+			addresses = append(addresses, types.IPAddress{
+				Index:     iface.Index,
+				Dev:       iface.Name,
+				Family:    info.Family,
+				Address:   info.Local,
+				PrefixLen: info.PrefixLen,
+				Broadcast: info.Broadcast,
+				Scope:     info.Scope,
+				Label:     info.Label,
+				Dynamic:   info.Dynamic,
+			})
+		}
+	}
+	return addresses, nil
+}
+
+// ipNeighbourJSON is a neighbour printed by 'ip -j neighbour show'. Flags such as router are
+// printed as null values, so their presence is detected with json.RawMessage.
+type ipNeighbourJSON struct {
+	Dst    string          `json:"dst"`
+	Dev    string          `json:"dev"`
+	LLAddr string          `json:"lladdr"`
+	State  []string        `json:"state"`
+	Router json.RawMessage `json:"router"`
+}
+
+// parseIPNeighbours parses 'ip -j neighbour show' output.
+func parseIPNeighbours(output string) ([]types.IPNeighbour, error) {
+	var raw []ipNeighbourJSON
+	if err := unmarshalIPJSON(output, &raw); err != nil {
+		return nil, err
+	}
+	neighbours := make([]types.IPNeighbour, 0, len(raw))
+	for _, n := range raw {
+		neighbours = append(neighbours, types.IPNeighbour{
+			Dst:    n.Dst,
+			Dev:    n.Dev,
+			LLAddr: n.LLAddr,
+			State:  n.State,
+			Router: len(n.Router) > 0,
+		})
+	}
+	return neighbours, nil
+}
+
+// ipRuleJSON is a rule printed by 'ip -j rule show'.
+type ipRuleJSON struct {
+	Priority int             `json:"priority"`
+	Not      json.RawMessage `json:"not"`
+	Src      string          `json:"src"`
+	SrcLen   int             `json:"srclen"`
+	Dst      string          `json:"dst"`
+	DstLen   int             `json:"dstlen"`
+	IIF      string          `json:"iif"`
+	OIF      string          `json:"oif"`
+	FwMark   json.RawMessage `json:"fwmark"`
+	FwMask   json.RawMessage `json:"fwmask"`
+	UIDStart *uint32         `json:"uid_start"`
+	UIDEnd   *uint32         `json:"uid_end"`
+	L3MDev   json.RawMessage `json:"l3mdev"`
+	Table    string          `json:"table"`
+	Action   string          `json:"action"`
+}
+
+// parseIPRules parses 'ip -j rule show' output.
+func parseIPRules(output string) ([]types.IPRule, error) {
+	var raw []ipRuleJSON
+	if err := unmarshalIPJSON(output, &raw); err != nil {
+		return nil, err
+	}
+	rules := make([]types.IPRule, 0, len(raw))
+	for _, r := range raw {
+		rule := types.IPRule{
+			Priority: r.Priority,
+			Not:      len(r.Not) > 0,
+			Src:      r.Src,
+			Dst:      r.Dst,
+			IIF:      r.IIF,
+			OIF:      r.OIF,
+			L3MDev:   len(r.L3MDev) > 0,
+			Table:    r.Table,
+			Action:   r.Action,
+		}
+		if r.SrcLen != 0 {
+			rule.Src += "/" + strconv.Itoa(r.SrcLen)
+		}
+		if r.DstLen != 0 {
+			rule.Dst += "/" + strconv.Itoa(r.DstLen)
+		}
+		if len(r.FwMark) > 0 {
+			rule.FwMark = ipJSONHex(r.FwMark)
+			if len(r.FwMask) > 0 {
+				rule.FwMark += "/" + ipJSONHex(r.FwMask)
+			}
+		}
+		if r.UIDStart != nil && r.UIDEnd != nil {
+			rule.UIDRange = fmt.Sprintf("%d-%d", *r.UIDStart, *r.UIDEnd)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// ipJSONHex returns a hexadecimal value that ip prints either as a string or as a number.
+func ipJSONHex(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+	var n uint64
+	if err := json.Unmarshal(value, &n); err == nil {
+		return fmt.Sprintf("0x%x", n)
+	}
+	return strings.Trim(string(value), `"`)
+}
+
+// unmarshalIPJSON decodes the JSON array printed by 'ip -j'. Empty output means no objects.
+func unmarshalIPJSON(output string, v any) error {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(output), v); err != nil {
+		return fmt.Errorf("failed to parse ip JSON output: %w", err)
+	}
+	return nil
+}
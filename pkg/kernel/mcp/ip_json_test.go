@@ -0,0 +1,172 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/headtail"
+)
+
+func TestIPObjectForCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		expected ipObject
+	}{
+		{command: "route show", expected: ipObjectRoute},
+		{command: "r s", expected: ipObjectRoute},
+		{command: "ru show", expected: ipObjectRule},
+		{command: "a show", expected: ipObjectAddress},
+		{command: "addr show", expected: ipObjectAddress},
+		{command: "n show", expected: ipObjectNeighbour},
+		{command: "neighbor show", expected: ipObjectNeighbour},
+		{command: "l show", expected: ipObjectLink},
+		{command: "netns show", expected: ""},
+		{command: "vrf show", expected: ""},
+		{command: "xfrm state list", expected: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if got := ipObjectForCommand(tt.command); got != tt.expected {
+				t.Errorf("ipObjectForCommand() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseIPOutput(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+	tests := []struct {
+		name     string
+		object   ipObject
+		table    string
+		output   string
+		expected types.IPResult
+		wantErr  bool
+	}{
+		{
+			name:   "routes of the main and local tables",
+			object: ipObjectRoute,
+			table:  "all",
+			output: `[{"dst":"default","gateway":"172.18.0.1","dev":"breth0","protocol":"static","metric":48,"flags":[],"metrics":[{"mtu":1400}]},` +
+				`{"dst":"10.244.0.0/16","protocol":"static","flags":[],"nexthops":[{"gateway":"10.244.1.1","dev":"ovn-k8s-mp0","weight":1,"flags":["onlink"]}]},` +
+				`{"type":"local","dst":"172.18.0.3","table":"local","dev":"breth0","protocol":"kernel","scope":"host","prefsrc":"172.18.0.3","flags":[]}]`,
+			expected: types.IPResult{Routes: []types.IPRoute{
+				{Dst: "default", Gateway: "172.18.0.1", Dev: "breth0", Table: "main", Protocol: "static", Metric: 48, MTU: 1400, Flags: []string{}},
+				{Dst: "10.244.0.0/16", Table: "main", Protocol: "static", Flags: []string{}, Nexthops: []types.IPNexthop{{Gateway: "10.244.1.1", Dev: "ovn-k8s-mp0", Weight: 1, Flags: []string{"onlink"}}}},
+				{Type: "local", Dst: "172.18.0.3", Table: "local", Dev: "breth0", Protocol: "kernel", Scope: "host", PrefSrc: "172.18.0.3", Flags: []string{}},
+			}},
+		},
+		{
+			name:   "routes of a single table",
+			object: ipObjectRoute,
+			table:  "7",
+			output: `[{"dst":"default","gateway":"172.18.0.1","dev":"breth0","flags":[]}]`,
+			expected: types.IPResult{Routes: []types.IPRoute{
+				{Dst: "default", Gateway: "172.18.0.1", Dev: "breth0", Table: "7", Flags: []string{}},
+			}},
+		},
+		{
+			name:   "links with vlan and geneve details",
+			object: ipObjectLink,
+			output: `[{"ifindex":5,"ifname":"eth0.100","link":"eth0","flags":["BROADCAST","MULTICAST","UP","LOWER_UP"],"mtu":1500,"operstate":"UP","link_type":"ether","address":"02:42:ac:12:00:03","linkinfo":{"info_kind":"vlan","info_data":{"protocol":"802.1Q","id":100,"flags":["REORDER_HDR"]}}},` +
+				`{"ifindex":7,"ifname":"genev_sys_6081","flags":["BROADCAST","MULTICAST","UP","LOWER_UP"],"mtu":65000,"master":"ovs-system","operstate":"UNKNOWN","link_type":"ether","linkinfo":{"info_kind":"geneve","info_data":{"id":0,"port":6081,"external":true},"info_slave_kind":"openvswitch"}},` +
+				`{"ifindex":9,"link_index":3,"ifname":"veth1","flags":["UP"],"mtu":1400,"operstate":"UP","link_type":"ether","link_netnsid":2}]`,
+			expected: types.IPResult{Links: []types.IPLink{
+				{Index: 5, Name: "eth0.100", Link: "eth0", Type: "ether", Address: "02:42:ac:12:00:03", MTU: 1500, State: "UP", Flags: []string{"BROADCAST", "MULTICAST", "UP", "LOWER_UP"},
+					Info: &types.IPLinkInfo{Kind: "vlan", VLANID: intPtr(100), VLANProtocol: "802.1Q"}},
+				{Index: 7, Name: "genev_sys_6081", Type: "ether", MTU: 65000, State: "UNKNOWN", Flags: []string{"BROADCAST", "MULTICAST", "UP", "LOWER_UP"}, Master: "ovs-system",
+					Info: &types.IPLinkInfo{Kind: "geneve", SlaveKind: "openvswitch", VNI: intPtr(0), Port: 6081, External: true}},
+				{Index: 9, Name: "veth1", Type: "ether", MTU: 1400, State: "UP", Flags: []string{"UP"}, LinkNetns: intPtr(2)},
+			}},
+		},
+		{
+			name:   "addresses are flattened",
+			object: ipObjectAddress,
+			output: `[{"ifindex":2,"ifname":"breth0","flags":["UP"],"mtu":1500,"addr_info":[` +
+				`{"family":"inet","local":"172.18.0.3","prefixlen":16,"broadcast":"172.18.255.255","scope":"global","label":"breth0"},` +
+				`{"family":"inet6","local":"fc00:f853:ccd:e793::3","prefixlen":64,"scope":"global","dynamic":true}]}]`,
+			expected: types.IPResult{Addresses: []types.IPAddress{
+				{Index: 2, Dev: "breth0", Family: "inet", Address: "172.18.0.3", PrefixLen: 16, Broadcast: "172.18.255.255", Scope: "global", Label: "breth0"},
+				{Index: 2, Dev: "breth0", Family: "inet6", Address: "fc00:f853:ccd:e793::3", PrefixLen: 64, Scope: "global", Dynamic: true},
+			}},
+		},
+		{
+			name:   "neighbours with router flag",
+			object: ipObjectNeighbour,
+			output: `[{"dst":"172.18.0.1","dev":"breth0","lladdr":"02:42:5e:6b:1a:01","state":["REACHABLE"]},` +
+				`{"dst":"fe80::1","dev":"breth0","lladdr":"02:42:5e:6b:1a:01","router":null,"state":["STALE"]}]`,
+			expected: types.IPResult{Neighbours: []types.IPNeighbour{
+				{Dst: "172.18.0.1", Dev: "breth0", LLAddr: "02:42:5e:6b:1a:01", State: []string{"REACHABLE"}},
+				{Dst: "fe80::1", Dev: "breth0", LLAddr: "02:42:5e:6b:1a:01", State: []string{"STALE"}, Router: true},
+			}},
+		},
+		{
+			name:   "rules with selectors",
+			object: ipObjectRule,
+			output: `[{"priority":0,"src":"all","table":"local"},` +
+				`{"priority":5000,"src":"10.244.1.3","srclen":32,"table":"7"},` +
+				`{"priority":6000,"not":null,"src":"all","fwmark":"0x1745ec","fwmask":"0xffffffff","iif":"breth0","table":"7"},` +
+				`{"priority":1000,"src":"all","l3mdev":null,"uid_start":0,"uid_end":100,"action":"unreachable"}]`,
+			expected: types.IPResult{Rules: []types.IPRule{
+				{Priority: 0, Src: "all", Table: "local"},
+				{Priority: 5000, Src: "10.244.1.3/32", Table: "7"},
+				{Priority: 6000, Not: true, Src: "all", FwMark: "0x1745ec/0xffffffff", IIF: "breth0", Table: "7"},
+				{Priority: 1000, Src: "all", L3MDev: true, UIDRange: "0-100", Action: "unreachable"},
+			}},
+		},
+		{
+			name:     "raw output for other commands",
+			output:   "red\n\nblue\n",
+			expected: types.IPResult{Data: "red\nblue"},
+		},
+		{
+			name:    "invalid JSON",
+			object:  ipObjectRoute,
+			output:  "default via 172.18.0.1 dev breth0",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseIPOutput(tt.object, tt.output, tt.table, headtail.HeadTailParams{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseIPOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.expected, result); diff != "" {
+				t.Errorf("parseIPOutput() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateIPFilters(t *testing.T) {
+	tests := []struct {
+		name      string
+		object    ipObject
+		table     string
+		device    string
+		netns     string
+		wantError bool
+	}{
+		{name: "route with table and device", object: ipObjectRoute, table: "all", device: "breth0"},
+		{name: "rule with table", object: ipObjectRule, table: "7"},
+		{name: "link with device and netns", object: ipObjectLink, device: "eth0.100", netns: "cni-1234"},
+		{name: "table with link", object: ipObjectLink, table: "main", wantError: true},
+		{name: "device with rule", object: ipObjectRule, device: "breth0", wantError: true},
+		{name: "table with metacharacters", object: ipObjectRoute, table: "main;true", wantError: true},
+		{name: "device name too long", object: ipObjectLink, device: "a-very-long-device-name", wantError: true},
+		{name: "netns with path", object: ipObjectRoute, netns: "/proc/1/ns/net", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateIPFilters(tt.object, tt.table, tt.device, tt.netns)
+			if (err != nil) != tt.wantError {
+				t.Errorf("validateIPFilters() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -14,8 +15,8 @@ import (
 
 // GetIptables MCP handler for iptables operations.
 // GetIptables retrieves iptables/ip6tables rules from a Kubernetes node.
-// Automatically detects IPv6 and uses ip6tables when needed.
-func (s *MCPServer) GetIptables(ctx context.Context, req *mcp.CallToolRequest, in types.ListIPTablesParams) (*mcp.CallToolResult, types.Result, error) {
+// The ip_family parameter selects iptables, ip6tables or both, and each result is labeled with its family.
+func (s *MCPServer) GetIptables(ctx context.Context, req *mcp.CallToolRequest, in types.ListIPTablesParams) (*mcp.CallToolResult, types.IPTablesResult, error) {
 	// If timeout is specified, create a new context with timeout
 	var cancel context.CancelFunc
 	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
@@ -23,63 +24,120 @@ func (s *MCPServer) GetIptables(ctx context.Context, req *mcp.CallToolRequest, i
 		defer cancel()
 	}
 
-	err := s.utilityExists(ctx, in.Namespace, in.Node, "iptables")
-	if err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting list of iptables rules: failed to verify iptables utility availability in configured image: %w", err)
-	}
-
 	if err := validateTableName(in.Table); err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting list of iptables rules: %w", err)
+		return nil, types.IPTablesResult{}, fmt.Errorf("error while getting list of iptables rules: %w", err)
 	}
 	if err := validateIptablesCommand(in.Command); err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting list of iptables rules: %w", err)
+		return nil, types.IPTablesResult{}, fmt.Errorf("error while getting list of iptables rules: %w", err)
 	}
 
 	if err := utils.ValidateSafeString(in.FilterParameters, "filter parameters", true, utils.ShellMetaCharactersTypeDefault); err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting list of iptables rules: %w", err)
+		return nil, types.IPTablesResult{}, fmt.Errorf("error while getting list of iptables rules: %w", err)
+	}
+	families, err := iptablesFamilies(in.IPFamily, in.FilterParameters)
+	if err != nil {
+		return nil, types.IPTablesResult{}, fmt.Errorf("error while getting list of iptables rules: %w", err)
+	}
+	if err := validatePodTarget(in.PodTargetParams); err != nil {
+		return nil, types.IPTablesResult{}, fmt.Errorf("error while getting list of iptables rules: %w", err)
+	}
+	prefix, netns, err := s.podNetnsPrefix(ctx, in.Namespace, in.Node, in.PodTargetParams)
+	if err != nil {
+		return nil, types.IPTablesResult{}, fmt.Errorf("error while getting list of iptables rules: %w", err)
 	}
 
 	table := strings.TrimSpace(in.Table)
 	command := strings.TrimSpace(in.Command)
-	cmd := commandbuilder.NewCommand(iptablesCommand(in.FilterParameters))
-	// Defaults to 'filter' table when not specified
-	cmd.AddIf(table == "", "-t", "filter")
-	cmd.AddIfNotEmpty(table, "-t", table)
-	// Defaults to -L (list) when command not specified
-	cmd.AddIf(command == "", "-L")
-	cmd.AddIfNotEmpty(command, command)
-	// FilterParameters are invalid with -S/--list-rules command
-	cmd.AddIf(in.FilterParameters != "" && command != "-S" && command != "--list-rules", strings.Fields(in.FilterParameters)...)
-
-	stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
-	if err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting list of iptables rules: %w", err)
-	}
+	result := types.IPTablesResult{Families: []types.IPTablesFamilyResult{}, Netns: netns}
+	for _, family := range families {
+		binary := iptablesBinary(family)
+		if err := s.utilityExists(ctx, in.Namespace, in.Node, binary); err != nil {
+			return nil, types.IPTablesResult{}, fmt.Errorf("error while getting list of iptables rules: failed to verify %s utility availability in configured image: %w", binary, err)
+		}
 
-	if stderr != "" {
-		return nil, types.Result{}, fmt.Errorf("error while running command: %s", stderr)
-	}
+		cmd := commandbuilder.NewCommand(prefix...)
+		cmd.Add(binary)
+		// Defaults to 'filter' table when not specified
+		cmd.AddIf(table == "", "-t", "filter")
+		cmd.AddIfNotEmpty(table, "-t", table)
+		// Defaults to -L (list) when command not specified
+		cmd.AddIf(command == "", "-L")
+		cmd.AddIfNotEmpty(command, command)
+		// FilterParameters are invalid with -S/--list-rules command
+		cmd.AddIf(in.FilterParameters != "" && command != "-S" && command != "--list-rules", strings.Fields(in.FilterParameters)...)
+
+		stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+		if err != nil {
+			return nil, types.IPTablesResult{}, fmt.Errorf("error while getting list of %s rules: %w", binary, err)
+		}
+
+		if stderr != "" {
+			return nil, types.IPTablesResult{}, fmt.Errorf("error while running command: %s", stderr)
+		}
 
-	// Strip empty lines from the output
-	lines := utils.StripEmptyLines(strings.Split(stdout, "\n"))
-	// Apply the head and tail parameters to the lines
-	lines = in.HeadTailParams.Apply(lines, DefaultMaxOutputLines)
-	// Join the lines back into a single string
-	stdout = strings.Join(lines, "\n")
-	return nil, types.Result{Data: stdout}, nil
+		// Strip empty lines from the output
+		lines := utils.StripEmptyLines(strings.Split(stdout, "\n"))
+		// Apply the head and tail parameters to the lines
+		lines = in.HeadTailParams.Apply(lines, DefaultMaxOutputLines)
+		result.Families = append(result.Families, types.IPTablesFamilyResult{
+			Family:  family,
+			Command: strings.Join(cmd.Build(), " "),
+			Data:    strings.Join(lines, "\n"),
+		})
+	}
+	return nil, result, nil
 }
 
-// iptablesCommand determines whether to use iptables or ip6tables.
-func iptablesCommand(filterParameters string) string {
-	for _, item := range strings.Split(filterParameters, " ") {
-		// Use of ip6tables CLI is required while either --ipv6 or -6 flag is mentioned
-		if item == "--ipv6" || (strings.Contains(item, "-") && strings.Contains(item, "6")) {
-			return "ip6tables"
-		}
+// iptablesBinary returns the iptables binary of the IP family.
+func iptablesBinary(family string) string {
+	if family == ipFamilyIPv6 {
+		return "ip6tables"
 	}
 	return "iptables"
 }
 
+// validIPTablesFamilyFlags matches the family options -4, --ipv4, -6 and --ipv6, alone or
+// combined with the other listing options that take no argument, e.g. '-nv6'. A combination of
+// both families, e.g. '-46', is matched too, so that it is rejected.
+var validIPTablesFamilyFlags = regexp.MustCompile(`^(--ipv[46]|-[nvx]*[46][nvx46]*)$`)
+
+// iptablesFamilies returns the IP families to list. Without ip_family, the family is selected by the
+// -4/-6 options in the filter parameters and defaults to IPv4. With ip_family, the filter parameters
+// must not select another family, since both binaries reject the option of the other family.
+func iptablesFamilies(ipFamily, filterParameters string) ([]string, error) {
+	families, err := parseIPFamily(ipFamily)
+	if err != nil {
+		return nil, err
+	}
+	var flagFamily string
+	for _, item := range strings.Fields(filterParameters) {
+		if !validIPTablesFamilyFlags.MatchString(item) {
+			continue
+		}
+		if strings.Contains(item, "4") && strings.Contains(item, "6") {
+			return nil, fmt.Errorf("filter parameter %q selects both the ipv4 and ipv6 families, use ip_family 'both' instead", item)
+		}
+		family := ipFamilyIPv4
+		if strings.Contains(item, "6") {
+			family = ipFamilyIPv6
+		}
+		if flagFamily != "" && flagFamily != family {
+			return nil, fmt.Errorf("filter parameters select both the ipv4 and ipv6 families, use ip_family 'both' instead")
+		}
+		flagFamily = family
+	}
+	if families == nil {
+		if flagFamily == ipFamilyIPv6 {
+			return []string{ipFamilyIPv6}, nil
+		}
+		return []string{ipFamilyIPv4}, nil
+	}
+	if flagFamily != "" && (len(families) != 1 || families[0] != flagFamily) {
+		return nil, fmt.Errorf("filter parameter family options conflict with ip_family %q", ipFamily)
+	}
+	return families, nil
+}
+
 // validateTableName validates iptables table name
 func validateTableName(table string) error {
 	if table == "" {
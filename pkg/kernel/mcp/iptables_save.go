@@ -0,0 +1,189 @@
+package mcp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// iptablesRuleset is the parsed output of iptables-save, indexed by table and chain.
+type iptablesRuleset map[string]*iptablesTable
+
+// iptablesTable is a table of iptables-save output.
+type iptablesTable struct {
+	// policies holds the policy of the built-in chains.
+	policies map[string]string
+	// chains holds the rules of every chain, including user-defined chains without rules.
+	chains map[string][]iptablesRule
+}
+
+// iptablesRule is a rule of iptables-save output.
+type iptablesRule struct {
+	text       string
+	conditions []iptablesCondition
+	target     string
+	goTo       bool
+	targetArgs map[string]string
+}
+
+// iptablesCondition is a match option of a rule, e.g. '! -s 10.0.0.0/8' or '-m tcp --dport 80'.
+type iptablesCondition struct {
+	module string
+	option string
+	args   []string
+	negate bool
+}
+
+// iptablesOptionArgs holds the number of arguments of the match options that do not take exactly one.
+var iptablesOptionArgs = map[string]int{
+	"--syn":       0,
+	"-f":          0,
+	"--fragment":  0,
+	"--rcheck":    0,
+	"--update":    0,
+	"--set":       0,
+	"--tcp-flags": 2,
+	"--match-set": 2,
+}
+
+// parseIPTablesSave parses iptables-save output.
+func parseIPTablesSave(output string) (iptablesRuleset, error) {
+	ruleset := iptablesRuleset{}
+	var table *iptablesTable
+	for i, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || line == "COMMIT":
+		case strings.HasPrefix(line, "*"):
+			table = &iptablesTable{policies: map[string]string{}, chains: map[string][]iptablesRule{}}
+			ruleset[strings.TrimPrefix(line, "*")] = table
+		case table == nil:
+			return nil, fmt.Errorf("line %d: rule outside of a table: %s", i+1, line)
+		case strings.HasPrefix(line, ":"):
+			fields := strings.Fields(strings.TrimPrefix(line, ":"))
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("line %d: invalid chain: %s", i+1, line)
+			}
+			if fields[1] != "-" {
+				table.policies[fields[0]] = fields[1]
+			}
+			if _, ok := table.chains[fields[0]]; !ok {
+				table.chains[fields[0]] = nil
+			}
+		case strings.HasPrefix(line, "-A "):
+			chain, rule, err := parseIPTablesRule(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			table.chains[chain] = append(table.chains[chain], rule)
+		}
+	}
+	return ruleset, nil
+}
+
+// parseIPTablesRule parses an '-A CHAIN ...' line into its chain and rule.
+func parseIPTablesRule(line string) (string, iptablesRule, error) {
+	tokens, err := splitIPTablesRule(line)
+	if err != nil {
+		return "", iptablesRule{}, err
+	}
+	if len(tokens) < 2 {
+		return "", iptablesRule{}, fmt.Errorf("invalid rule: %s", line)
+	}
+	chain := tokens[1]
+	rule := iptablesRule{text: line}
+	module := ""
+	negate := false
+	for i := 2; i < len(tokens); i++ {
+		token := tokens[i]
+		switch {
+		case token == "!":
+			negate = true
+			continue
+		case token == "-m" || token == "--match":
+			if i+1 >= len(tokens) {
+				return "", iptablesRule{}, fmt.Errorf("missing match module: %s", line)
+			}
+			module = tokens[i+1]
+			i++
+		case token == "-j" || token == "--jump" || token == "-g" || token == "--goto":
+			if i+1 >= len(tokens) {
+				return "", iptablesRule{}, fmt.Errorf("missing target: %s", line)
+			}
+			rule.target = tokens[i+1]
+			rule.goTo = token == "-g" || token == "--goto"
+			rule.targetArgs = parseIPTablesTargetArgs(tokens[i+2:])
+			return chain, rule, nil
+		case strings.HasPrefix(token, "-"):
+			count, ok := iptablesOptionArgs[token]
+			if !ok {
+				count = 1
+			}
+			if i+count >= len(tokens) {
+				return "", iptablesRule{}, fmt.Errorf("missing argument of %s: %s", token, line)
+			}
+			rule.conditions = append(rule.conditions, iptablesCondition{
+				module: module,
+				option: token,
+				args:   tokens[i+1 : i+1+count],
+				negate: negate,
+			})
+			i += count
+		default:
+			return "", iptablesRule{}, fmt.Errorf("unexpected token %q: %s", token, line)
+		}
+		negate = false
+	}
+	return chain, rule, nil
+}
+
+// parseIPTablesTargetArgs parses the options of a target, e.g. '--to-destination 10.0.0.1:80'.
+func parseIPTablesTargetArgs(tokens []string) map[string]string {
+	args := map[string]string{}
+	for i := 0; i < len(tokens); i++ {
+		if !strings.HasPrefix(tokens[i], "--") {
+			continue
+		}
+		if i+1 < len(tokens) && !strings.HasPrefix(tokens[i+1], "--") {
+			args[tokens[i]] = tokens[i+1]
+			i++
+		} else {
+			args[tokens[i]] = ""
+		}
+	}
+	return args
+}
+
+// splitIPTablesRule splits a rule into tokens. iptables-save quotes arguments containing spaces,
+// such as comments, with double quotes and escapes quotes within them with a backslash.
+func splitIPTablesRule(line string) ([]string, error) {
+	var tokens []string
+	var token strings.Builder
+	inQuotes, inToken := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '\\' && inQuotes && i+1 < len(line):
+			i++
+			token.WriteByte(line[i])
+		case c == '"':
+			inQuotes = !inQuotes
+			inToken = true
+		case c == ' ' && !inQuotes:
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			token.WriteByte(c)
+			inToken = true
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote: %s", line)
+	}
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, nil
+}
@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseIPTablesSave(t *testing.T) {
+	output := `# Generated by iptables-save v1.8.8 on Mon Jan  1 00:00:00 2024
+*nat
+:PREROUTING ACCEPT [0:0]
+:OVN-KUBE-NODEPORT - [0:0]
+-A PREROUTING -j OVN-KUBE-NODEPORT
+-A OVN-KUBE-NODEPORT -p tcp -m addrtype --dst-type LOCAL -m tcp --dport 30080 -j DNAT --to-destination 169.254.169.3:30080
+COMMIT
+*filter
+:FORWARD DROP [0:0]
+-A FORWARD ! -s 10.244.0.0/16 -m comment --comment "allow \"cluster\" traffic" -m set --match-set allowed src -g ALLOWED
+COMMIT
+`
+	ruleset, err := parseIPTablesSave(output)
+	if err != nil {
+		t.Fatalf("parseIPTablesSave() error = %v", err)
+	}
+
+	nat := ruleset["nat"]
+	if diff := cmp.Diff(map[string]string{"PREROUTING": "ACCEPT"}, nat.policies); diff != "" {
+		t.Errorf("nat policies mismatch (-want +got):\n%s", diff)
+	}
+	nodePort := nat.chains["OVN-KUBE-NODEPORT"][0]
+	expectedNodePort := iptablesRule{
+		text: "-A OVN-KUBE-NODEPORT -p tcp -m addrtype --dst-type LOCAL -m tcp --dport 30080 -j DNAT --to-destination 169.254.169.3:30080",
+		conditions: []iptablesCondition{
+			{option: "-p", args: []string{"tcp"}},
+			{module: "addrtype", option: "--dst-type", args: []string{"LOCAL"}},
+			{module: "tcp", option: "--dport", args: []string{"30080"}},
+		},
+		target:     "DNAT",
+		targetArgs: map[string]string{"--to-destination": "169.254.169.3:30080"},
+	}
+	if diff := cmp.Diff(expectedNodePort, nodePort, cmp.AllowUnexported(iptablesRule{}, iptablesCondition{})); diff != "" {
+		t.Errorf("nat rule mismatch (-want +got):\n%s", diff)
+	}
+
+	forward := ruleset["filter"].chains["FORWARD"][0]
+	expectedForward := iptablesRule{
+		text: `-A FORWARD ! -s 10.244.0.0/16 -m comment --comment "allow \"cluster\" traffic" -m set --match-set allowed src -g ALLOWED`,
+		conditions: []iptablesCondition{
+			{option: "-s", args: []string{"10.244.0.0/16"}, negate: true},
+			{module: "comment", option: "--comment", args: []string{`allow "cluster" traffic`}},
+			{module: "set", option: "--match-set", args: []string{"allowed", "src"}},
+		},
+		target:     "ALLOWED",
+		goTo:       true,
+		targetArgs: map[string]string{},
+	}
+	if diff := cmp.Diff(expectedForward, forward, cmp.AllowUnexported(iptablesRule{}, iptablesCondition{})); diff != "" {
+		t.Errorf("filter rule mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseIPTablesSaveErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{name: "rule outside of a table", output: "-A INPUT -j ACCEPT"},
+		{name: "missing target", output: "*filter\n-A INPUT -j"},
+		{name: "missing argument", output: "*filter\n-A INPUT -s"},
+		{name: "unterminated quote", output: "*filter\n-A INPUT -m comment --comment \"open -j ACCEPT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseIPTablesSave(tt.output); err == nil {
+				t.Errorf("parseIPTablesSave() expected error")
+			}
+		})
+	}
+}
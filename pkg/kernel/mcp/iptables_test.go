@@ -1,7 +1,13 @@
 package mcp
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
 )
 
 func TestValidateTableName(t *testing.T) {
@@ -150,115 +156,152 @@ func TestValidateIptablesCommand(t *testing.T) {
 	}
 }
 
-func TestIptablesCommand(t *testing.T) {
+func TestIptablesFamilies(t *testing.T) {
 	tests := []struct {
 		name             string
+		ipFamily         string
 		filterParameters string
-		want             string
+		want             []string
+		wantError        bool
 	}{
 		{
-			name:             "empty filter parameters",
-			filterParameters: "",
-			want:             "iptables",
+			name: "defaults to ipv4",
+			want: []string{"ipv4"},
 		},
 		{
 			name:             "ipv6 flag --ipv6",
 			filterParameters: "--ipv6",
-			want:             "ip6tables",
-		},
-		{
-			name:             "ipv6 short flag -6",
-			filterParameters: "-6",
-			want:             "ip6tables",
-		},
-		{
-			name:             "ipv6 flag with other parameters",
-			filterParameters: "-p tcp --ipv6 --dport 80",
-			want:             "ip6tables",
+			want:             []string{"ipv6"},
 		},
 		{
 			name:             "ipv6 short flag with other parameters",
 			filterParameters: "-p tcp -6 --dport 80",
-			want:             "ip6tables",
+			want:             []string{"ipv6"},
 		},
 		{
-			name:             "ipv4 parameters only",
-			filterParameters: "-p tcp --dport 80",
-			want:             "iptables",
+			name:             "combined listing flags with 6",
+			filterParameters: "-nv6",
+			want:             []string{"ipv6"},
 		},
 		{
-			name:             "parameters with number 6 but not ipv6 flag",
-			filterParameters: "--dport 6000",
-			want:             "iptables",
+			name:             "combined listing flags with 4",
+			filterParameters: "-nv4",
+			want:             []string{"ipv4"},
 		},
 		{
-			name:             "flag containing both - and 6",
-			filterParameters: "-p6",
-			want:             "ip6tables",
+			name:             "port containing 6",
+			filterParameters: "-p tcp --dport 16443",
+			want:             []string{"ipv4"},
 		},
 		{
-			name:             "flag containing both - and 6 in middle",
-			filterParameters: "-m6state",
-			want:             "ip6tables",
+			name:             "protocol number 6",
+			filterParameters: "-p6",
+			want:             []string{"ipv4"},
 		},
 		{
-			name:             "multiple flags with ipv6 at end",
-			filterParameters: "-p tcp -m state --state NEW --ipv6",
-			want:             "ip6tables",
+			name:             "ipv6 address in parameter",
+			filterParameters: "-d 2001:db8::1",
+			want:             []string{"ipv4"},
 		},
 		{
-			name:             "multiple flags with -6 at beginning",
-			filterParameters: "-6 -p tcp -m state --state NEW",
-			want:             "ip6tables",
+			name:     "explicit ipv6",
+			ipFamily: "ipv6",
+			want:     []string{"ipv6"},
 		},
 		{
-			name:             "protocol number without dash",
-			filterParameters: "-p tcp --sport 6379",
-			want:             "iptables",
+			name:             "both families",
+			ipFamily:         "both",
+			filterParameters: "-n -v",
+			want:             []string{"ipv4", "ipv6"},
 		},
 		{
-			name:             "dash without 6",
-			filterParameters: "-p tcp -m state",
-			want:             "iptables",
+			name:             "ip_family matching the flag",
+			ipFamily:         "ipv6",
+			filterParameters: "-6",
+			want:             []string{"ipv6"},
 		},
 		{
-			name:             "number 6 without dash",
-			filterParameters: "6",
-			want:             "iptables",
+			name:             "ip_family conflicting with the flag",
+			ipFamily:         "ipv4",
+			filterParameters: "--ipv6",
+			wantError:        true,
 		},
 		{
-			name:             "chain name with 6",
-			filterParameters: "-A INPUT6",
-			want:             "iptables",
+			name:             "both families with a family flag",
+			ipFamily:         "both",
+			filterParameters: "-4",
+			wantError:        true,
 		},
 		{
-			name:             "ipv6 address in parameter",
-			filterParameters: "-d 2001:db8::1",
-			want:             "iptables",
+			name:             "conflicting flags",
+			filterParameters: "-4 -6",
+			wantError:        true,
 		},
 		{
-			name:             "combined short flags with 6",
-			filterParameters: "-t6n",
-			want:             "ip6tables",
+			name:             "combined flags with both families",
+			filterParameters: "-46",
+			wantError:        true,
 		},
 		{
-			name:             "whitespace variations with ipv6",
-			filterParameters: "  --ipv6  -p tcp  ",
-			want:             "ip6tables",
+			name:             "combined listing flags with both families",
+			filterParameters: "-n64",
+			wantError:        true,
 		},
 		{
-			name:             "whitespace variations without ipv6",
-			filterParameters: "  -p tcp  --dport 443  ",
-			want:             "iptables",
+			name:      "invalid ip_family",
+			ipFamily:  "inet",
+			wantError: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := iptablesCommand(tt.filterParameters)
-			if got != tt.want {
-				t.Errorf("iptablesCommand(%q) = %v, want %v", tt.filterParameters, got, tt.want)
+			got, err := iptablesFamilies(tt.ipFamily, tt.filterParameters)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("iptablesFamilies(%q, %q) error = %v, wantError %v", tt.ipFamily, tt.filterParameters, err, tt.wantError)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("iptablesFamilies(%q, %q) mismatch (-want +got):\n%s", tt.ipFamily, tt.filterParameters, diff)
 			}
 		})
 	}
 }
+
+func TestGetIptablesBothFamilies(t *testing.T) {
+	var commands []string
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		command := strings.Join(cmd, " ")
+		commands = append(commands, command)
+		if cmd[len(cmd)-1] == "-V" {
+			return cmd[0] + " v1.8.8 (nf_tables)", "", nil
+		}
+		return "-P INPUT ACCEPT\n\n-A INPUT -j KUBE-FIREWALL\n", "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.GetIptables(context.Background(), nil, types.ListIPTablesParams{
+		CommonParams: types.CommonParams{Node: "ovn-worker"},
+		Command:      "-S",
+		IPFamily:     "both",
+	})
+	if err != nil {
+		t.Fatalf("GetIptables() error = %v", err)
+	}
+	expectedCommands := []string{"iptables -V", "iptables -t filter -S", "ip6tables -V", "ip6tables -t filter -S"}
+	if diff := cmp.Diff(expectedCommands, commands); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+	expected := types.IPTablesResult{Families: []types.IPTablesFamilyResult{
+		{Family: "ipv4", Command: "iptables -t filter -S", Data: "-P INPUT ACCEPT\n-A INPUT -j KUBE-FIREWALL"},
+		{Family: "ipv6", Command: "ip6tables -t filter -S", Data: "-P INPUT ACCEPT\n-A INPUT -j KUBE-FIREWALL"},
+	}}
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("GetIptables() mismatch (-want +got):\n%s", diff)
+	}
+}
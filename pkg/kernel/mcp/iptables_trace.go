@@ -0,0 +1,616 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+)
+
+const (
+	// maxIPTablesChainDepth bounds the jumps followed, protecting against loops.
+	maxIPTablesChainDepth = 64
+	// defaultIPTablesCtState is the conntrack state of the packet if not given.
+	defaultIPTablesCtState = "NEW"
+)
+
+// iptablesHookTables are the tables traversed at each hook, in netfilter priority order.
+var iptablesHookTables = map[string][]string{
+	"PREROUTING":  {"raw", "mangle", "nat"},
+	"INPUT":       {"mangle", "filter", "nat"},
+	"FORWARD":     {"mangle", "filter"},
+	"OUTPUT":      {"raw", "mangle", "nat", "filter"},
+	"POSTROUTING": {"mangle", "nat"},
+}
+
+// iptablesProtocols maps the protocol names to their numbers.
+var iptablesProtocols = map[string]int{"tcp": 6, "udp": 17, "sctp": 132, "icmp": 1, "icmpv6": 58, "ipv6-icmp": 58}
+
+// iptablesCtStates are the valid conntrack states of the packet.
+var iptablesCtStates = map[string]bool{"NEW": true, "ESTABLISHED": true, "RELATED": true, "INVALID": true, "UNTRACKED": true}
+
+// TraceIPTables evaluates the iptables rules of a node, or of a pod running on it, against a packet.
+// The rules are read with iptables-save (ip6tables-save for IPv6 packets) and the local addresses
+// with 'ip -j address show' to tell local destinations and evaluate addrtype matches.
+func (s *MCPServer) TraceIPTables(ctx context.Context, req *mcp.CallToolRequest, in types.IPTablesTraceParams) (*mcp.CallToolResult, types.IPTablesTraceResult, error) {
+	packet, err := newIPTablesPacket(in)
+	if err != nil {
+		return nil, types.IPTablesTraceResult{}, fmt.Errorf("error while tracing iptables rules: %w", err)
+	}
+	if err := validatePodTarget(in.PodTargetParams); err != nil {
+		return nil, types.IPTablesTraceResult{}, fmt.Errorf("error while tracing iptables rules: %w", err)
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	save := "iptables-save"
+	if packet.dst.To4() == nil {
+		save = "ip6tables-save"
+	}
+	if err := s.utilityExists(ctx, in.Namespace, in.Node, save); err != nil {
+		return nil, types.IPTablesTraceResult{}, fmt.Errorf("error while tracing iptables rules: failed to verify %s utility availability in configured image: %w", save, err)
+	}
+	prefix, netns, err := s.podNetnsPrefix(ctx, in.Namespace, in.Node, in.PodTargetParams)
+	if err != nil {
+		return nil, types.IPTablesTraceResult{}, fmt.Errorf("error while tracing iptables rules: %w", err)
+	}
+	cmd := commandbuilder.NewCommand(prefix...)
+	cmd.Add(save)
+	stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+	if err != nil {
+		return nil, types.IPTablesTraceResult{}, fmt.Errorf("error while tracing iptables rules: %w", err)
+	}
+	if stderr != "" {
+		return nil, types.IPTablesTraceResult{}, fmt.Errorf("error while running command: %s", stderr)
+	}
+	ruleset, err := parseIPTablesSave(stdout)
+	if err != nil {
+		return nil, types.IPTablesTraceResult{}, fmt.Errorf("error while tracing iptables rules: failed to parse %s output: %w", save, err)
+	}
+
+	cmd = commandbuilder.NewCommand(prefix...)
+	cmd.Add("ip", "-j", "address", "show")
+	stdout, stderr, err = s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+	if err != nil {
+		return nil, types.IPTablesTraceResult{}, fmt.Errorf("error while tracing iptables rules: failed to list local addresses: %w", err)
+	}
+	if stderr != "" {
+		return nil, types.IPTablesTraceResult{}, fmt.Errorf("error while running command: %s", stderr)
+	}
+	addresses, err := parseIPAddresses(stdout)
+	if err != nil {
+		return nil, types.IPTablesTraceResult{}, fmt.Errorf("error while tracing iptables rules: %w", err)
+	}
+	var localAddresses []net.IP
+	for _, address := range addresses {
+		if ip := net.ParseIP(address.Address); ip != nil {
+			localAddresses = append(localAddresses, ip)
+		}
+	}
+
+	evaluator := &iptablesEvaluator{ruleset: ruleset, packet: packet, localAddresses: localAddresses}
+	result := evaluator.trace(strings.ToUpper(in.Hook))
+	result.Netns = netns
+	return nil, result, nil
+}
+
+// iptablesPacket is the packet being evaluated. Its addresses, ports and mark are updated by the
+// NAT and MARK targets.
+type iptablesPacket struct {
+	protocol     string
+	src          net.IP
+	dst          net.IP
+	sport        int
+	dport        int
+	inInterface  string
+	outInterface string
+	mark         uint32
+	ctState      string
+}
+
+// newIPTablesPacket validates the trace parameters and returns the packet to evaluate.
+func newIPTablesPacket(in types.IPTablesTraceParams) (iptablesPacket, error) {
+	packet := iptablesPacket{
+		protocol:     strings.ToLower(strings.TrimSpace(in.Protocol)),
+		sport:        in.Sport,
+		dport:        in.Dport,
+		inInterface:  in.InInterface,
+		outInterface: in.OutInterface,
+		ctState:      strings.ToUpper(strings.TrimSpace(in.CtState)),
+	}
+	if _, ok := iptablesHookTables[strings.ToUpper(in.Hook)]; !ok {
+		return packet, fmt.Errorf("invalid hook %q: must be one of PREROUTING, INPUT, FORWARD, OUTPUT, POSTROUTING", in.Hook)
+	}
+	if _, ok := iptablesProtocols[packet.protocol]; !ok {
+		return packet, fmt.Errorf("invalid protocol %q: must be one of tcp, udp, sctp, icmp, icmpv6", in.Protocol)
+	}
+	if packet.src = net.ParseIP(in.Src); packet.src == nil {
+		return packet, fmt.Errorf("invalid src %q: must be an IP address", in.Src)
+	}
+	if packet.dst = net.ParseIP(in.Dst); packet.dst == nil {
+		return packet, fmt.Errorf("invalid dst %q: must be an IP address", in.Dst)
+	}
+	if (packet.src.To4() == nil) != (packet.dst.To4() == nil) {
+		return packet, fmt.Errorf("src and dst must belong to the same address family")
+	}
+	for _, port := range []struct {
+		name  string
+		value int
+	}{{"sport", in.Sport}, {"dport", in.Dport}} {
+		if port.value < 0 || port.value > 65535 {
+			return packet, fmt.Errorf("invalid %s %d: must be between 1 and 65535", port.name, port.value)
+		}
+		if port.value != 0 && !iptablesProtocolHasPorts(packet.protocol) {
+			return packet, fmt.Errorf("%s cannot be used with protocol %s", port.name, packet.protocol)
+		}
+	}
+	for _, iface := range []struct {
+		name  string
+		value string
+	}{{"in_interface", in.InInterface}, {"out_interface", in.OutInterface}} {
+		if iface.value != "" && !validIPDeviceName.MatchString(iface.value) {
+			return packet, fmt.Errorf("invalid %s %q: must be a network device name", iface.name, iface.value)
+		}
+	}
+	if in.Mark != "" {
+		mark, err := strconv.ParseUint(in.Mark, 0, 32)
+		if err != nil {
+			return packet, fmt.Errorf("invalid mark %q: must be a 32 bit number", in.Mark)
+		}
+		packet.mark = uint32(mark)
+	}
+	if packet.ctState == "" {
+		packet.ctState = defaultIPTablesCtState
+	}
+	if !iptablesCtStates[packet.ctState] {
+		return packet, fmt.Errorf("invalid ct_state %q: must be one of NEW, ESTABLISHED, RELATED, INVALID, UNTRACKED", in.CtState)
+	}
+	return packet, nil
+}
+
+// iptablesProtocolHasPorts reports whether the protocol has ports.
+func iptablesProtocolHasPorts(protocol string) bool {
+	return protocol == "tcp" || protocol == "udp" || protocol == "sctp"
+}
+
+// iptablesEvaluator walks the chains of a ruleset for a packet and records the rules applied.
+type iptablesEvaluator struct {
+	ruleset        iptablesRuleset
+	packet         iptablesPacket
+	localAddresses []net.IP
+	result         types.IPTablesTraceResult
+	hook           string
+}
+
+// iptablesChainResult is the outcome of walking a chain: a terminal verdict, or none if the chain
+// returned or ended.
+type iptablesChainResult string
+
+const (
+	iptablesContinue iptablesChainResult = ""
+	iptablesAccept   iptablesChainResult = "ACCEPT"
+	iptablesDrop     iptablesChainResult = "DROP"
+	iptablesReject   iptablesChainResult = "REJECT"
+)
+
+// trace walks the packet from the hook through the following hooks until it is dropped, rejected
+// or accepted at the last hook. After PREROUTING a packet to a local address goes to INPUT, any
+// other to FORWARD, and FORWARD and OUTPUT continue to POSTROUTING.
+func (e *iptablesEvaluator) trace(hook string) types.IPTablesTraceResult {
+	e.result = types.IPTablesTraceResult{Steps: []types.IPTablesTraceStep{}, Verdict: string(iptablesAccept)}
+	for hook != "" {
+		e.hook = hook
+		e.result.Hooks = append(e.result.Hooks, hook)
+		if verdict := e.traverseHook(hook); verdict != iptablesAccept {
+			e.result.Verdict = string(verdict)
+			break
+		}
+		switch hook {
+		case "PREROUTING":
+			hook = "FORWARD"
+			if e.isLocal(e.packet.dst) {
+				hook = "INPUT"
+			}
+		case "FORWARD", "OUTPUT":
+			hook = "POSTROUTING"
+		default:
+			hook = ""
+		}
+	}
+	e.result.Packet = types.IPTablesTracePacket{
+		Src:   e.packet.src.String(),
+		Dst:   e.packet.dst.String(),
+		Sport: e.packet.sport,
+		Dport: e.packet.dport,
+		Mark:  fmt.Sprintf("0x%x", e.packet.mark),
+	}
+	return e.result
+}
+
+// traverseHook walks the built-in chain of the hook in each table. The nat table is only
+// traversed by packets creating a new connection.
+func (e *iptablesEvaluator) traverseHook(hook string) iptablesChainResult {
+	for _, tableName := range iptablesHookTables[hook] {
+		table, ok := e.ruleset[tableName]
+		if !ok {
+			continue
+		}
+		if _, ok := table.chains[hook]; !ok {
+			continue
+		}
+		if tableName == "nat" && e.packet.ctState != "NEW" {
+			continue
+		}
+		verdict := e.walkChain(tableName, hook, 0)
+		if verdict == iptablesContinue {
+			policy := table.policies[hook]
+			if policy == "" {
+				policy = string(iptablesAccept)
+			}
+			e.addStep(tableName, hook, 0, "-P "+hook+" "+policy, "policy")
+			verdict = iptablesChainResult(policy)
+		}
+		if verdict != iptablesAccept {
+			return verdict
+		}
+	}
+	return iptablesAccept
+}
+
+// walkChain walks the rules of a chain and returns the terminal verdict, or iptablesContinue if
+// the chain returned or ended without one.
+func (e *iptablesEvaluator) walkChain(tableName, chain string, depth int) iptablesChainResult {
+	if depth > maxIPTablesChainDepth {
+		return iptablesContinue
+	}
+	table := e.ruleset[tableName]
+	for i, rule := range table.chains[chain] {
+		matches, determined := e.ruleMatches(rule)
+		if !determined {
+			e.result.Undetermined = append(e.result.Undetermined, types.IPTablesTraceStep{
+				Hook: e.hook, Table: tableName, Chain: chain, RuleNumber: i + 1, Rule: rule.text, Action: "skipped",
+			})
+			continue
+		}
+		if !matches {
+			continue
+		}
+		switch target := rule.target; {
+		case target == "":
+			e.addStep(tableName, chain, i+1, rule.text, "continue")
+		case target == "ACCEPT" || target == "DROP" || target == "REJECT":
+			e.addStep(tableName, chain, i+1, rule.text, strings.ToLower(target))
+			return iptablesChainResult(target)
+		case target == "RETURN":
+			e.addStep(tableName, chain, i+1, rule.text, "return")
+			return iptablesContinue
+		case e.isChain(tableName, target):
+			action := "jump"
+			if rule.goTo {
+				action = "goto"
+			}
+			e.addStep(tableName, chain, i+1, rule.text, action)
+			if verdict := e.walkChain(tableName, target, depth+1); verdict != iptablesContinue || rule.goTo {
+				return verdict
+			}
+		case target == "DNAT" || target == "SNAT" || target == "MASQUERADE" || target == "REDIRECT":
+			e.addStep(tableName, chain, i+1, rule.text, strings.ToLower(target))
+			e.applyNAT(target, rule.targetArgs)
+			return iptablesAccept
+		case target == "MARK":
+			e.addStep(tableName, chain, i+1, rule.text, "mark")
+			e.applyMark(rule.targetArgs)
+		default:
+			// Other targets such as LOG, CONNMARK, CT or NOTRACK do not end the traversal.
+			e.addStep(tableName, chain, i+1, rule.text, "continue")
+		}
+	}
+	return iptablesContinue
+}
+
+// addStep records a rule or policy applied to the packet.
+func (e *iptablesEvaluator) addStep(table, chain string, ruleNumber int, rule, action string) {
+	e.result.Steps = append(e.result.Steps, types.IPTablesTraceStep{
+		Hook: e.hook, Table: table, Chain: chain, RuleNumber: ruleNumber, Rule: rule, Action: action,
+	})
+}
+
+// isChain reports whether the target is a chain of the table.
+func (e *iptablesEvaluator) isChain(table, target string) bool {
+	_, ok := e.ruleset[table].chains[target]
+	return ok
+}
+
+// isLocal reports whether the address is an address of the node or a loopback address.
+func (e *iptablesEvaluator) isLocal(ip net.IP) bool {
+	if ip.IsLoopback() {
+		return true
+	}
+	for _, local := range e.localAddresses {
+		if local.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleMatches reports whether all conditions of the rule match the packet. A rule with a condition
+// that cannot be evaluated is undetermined.
+func (e *iptablesEvaluator) ruleMatches(rule iptablesRule) (bool, bool) {
+	for _, condition := range rule.conditions {
+		matches, determined := e.conditionMatches(condition)
+		if !determined {
+			return false, false
+		}
+		if matches == condition.negate {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+// conditionMatches evaluates a condition, before negation. The second value is false if the
+// condition cannot be evaluated for the packet.
+func (e *iptablesEvaluator) conditionMatches(c iptablesCondition) (bool, bool) {
+	p := e.packet
+	arg := ""
+	if len(c.args) > 0 {
+		arg = c.args[0]
+	}
+	switch c.option {
+	case "-s", "--source":
+		return iptablesAddressMatches(arg, p.src), true
+	case "-d", "--destination":
+		return iptablesAddressMatches(arg, p.dst), true
+	case "-p", "--protocol":
+		return iptablesProtocolMatches(arg, p.protocol), true
+	case "-i", "--in-interface":
+		if p.inInterface == "" {
+			return false, false
+		}
+		return iptablesInterfaceMatches(arg, p.inInterface), true
+	case "-o", "--out-interface":
+		if p.outInterface == "" {
+			return false, false
+		}
+		return iptablesInterfaceMatches(arg, p.outInterface), true
+	case "--dport", "--destination-port", "--dports", "--destination-ports":
+		return iptablesPortMatches(arg, p.dport)
+	case "--sport", "--source-port", "--sports", "--source-ports":
+		return iptablesPortMatches(arg, p.sport)
+	case "--ports":
+		dport, determined := iptablesPortMatches(arg, p.dport)
+		sport, _ := iptablesPortMatches(arg, p.sport)
+		return dport || sport, determined
+	case "--mark":
+		if c.module != "mark" {
+			return false, false
+		}
+		return iptablesMarkMatches(arg, p.mark), true
+	case "--ctstate", "--state":
+		for _, state := range strings.Split(arg, ",") {
+			if state == p.ctState {
+				return true, true
+			}
+		}
+		return false, true
+	case "--dst-type":
+		return e.addressTypeMatches(arg, p.dst)
+	case "--src-type":
+		return e.addressTypeMatches(arg, p.src)
+	case "--syn":
+		return p.protocol == "tcp" && p.ctState == "NEW", true
+	case "--comment", "--limit", "--limit-burst", "--limit-iface-in", "--limit-iface-out":
+		return true, true
+	}
+	return false, false
+}
+
+// addressTypeMatches evaluates the addrtype types of the address. Only LOCAL, UNICAST,
+// MULTICAST and BROADCAST can be evaluated.
+func (e *iptablesEvaluator) addressTypeMatches(addressTypes string, ip net.IP) (bool, bool) {
+	for _, addressType := range strings.Split(addressTypes, ",") {
+		var matches bool
+		switch addressType {
+		case "LOCAL":
+			matches = e.isLocal(ip)
+		case "MULTICAST":
+			matches = ip.IsMulticast()
+		case "BROADCAST":
+			matches = ip.Equal(net.IPv4bcast)
+		case "UNICAST":
+			matches = !e.isLocal(ip) && !ip.IsMulticast() && !ip.Equal(net.IPv4bcast)
+		default:
+			return false, false
+		}
+		if matches {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// applyNAT applies a NAT target to the packet and records the translation.
+func (e *iptablesEvaluator) applyNAT(target string, args map[string]string) {
+	switch target {
+	case "DNAT":
+		ip, port := parseIPTablesNATTarget(args["--to-destination"])
+		if ip != nil {
+			e.packet.dst = ip
+		}
+		if port != 0 {
+			e.packet.dport = port
+		}
+		e.result.DNAT = args["--to-destination"]
+	case "REDIRECT":
+		if port, _ := parseIPTablesPortRange(args["--to-ports"]); port != 0 {
+			e.packet.dport = port
+		}
+		e.result.DNAT = "redirect to local port " + args["--to-ports"]
+	case "SNAT":
+		ip, port := parseIPTablesNATTarget(args["--to-source"])
+		if ip != nil {
+			e.packet.src = ip
+		}
+		if port != 0 {
+			e.packet.sport = port
+		}
+		e.result.SNAT = args["--to-source"]
+	case "MASQUERADE":
+		e.result.SNAT = "masquerade"
+	}
+}
+
+// applyMark applies a MARK target to the packet mark.
+func (e *iptablesEvaluator) applyMark(args map[string]string) {
+	for option, arg := range args {
+		valueString, maskString, hasMask := strings.Cut(arg, "/")
+		value, err := strconv.ParseUint(valueString, 0, 32)
+		if err != nil {
+			continue
+		}
+		mask := uint64(0xffffffff)
+		if hasMask {
+			if mask, err = strconv.ParseUint(maskString, 0, 32); err != nil {
+				continue
+			}
+		}
+		mark := uint64(e.packet.mark)
+		switch option {
+		case "--set-xmark":
+			mark = (mark &^ mask) ^ value
+		case "--set-mark":
+			mark = (mark &^ mask) | value
+		case "--or-mark":
+			mark |= value
+		case "--and-mark":
+			mark &= value
+		case "--xor-mark":
+			mark ^= value
+		default:
+			continue
+		}
+		e.packet.mark = uint32(mark)
+	}
+}
+
+// parseIPTablesNATTarget parses the first address and port of a NAT target such as
+// '10.96.0.10:53', '[fd00::a]:53', '10.0.0.1-10.0.0.5:80-90' or ':8080'.
+func parseIPTablesNATTarget(target string) (net.IP, int) {
+	address, port := target, ""
+	if strings.HasPrefix(target, "[") {
+		if end := strings.Index(target, "]"); end > 0 {
+			address = target[1:end]
+			port = strings.TrimPrefix(target[end+1:], ":")
+		}
+	} else if strings.Count(target, ":") == 1 {
+		address, port, _ = strings.Cut(target, ":")
+	}
+	address, _, _ = strings.Cut(address, "-")
+	first, _ := parseIPTablesPortRange(port)
+	return net.ParseIP(address), first
+}
+
+// parseIPTablesPortRange returns the first port of a port or port range such as '80' or '80-90'.
+func parseIPTablesPortRange(ports string) (int, error) {
+	first, _, _ := strings.Cut(ports, "-")
+	if first == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(first)
+}
+
+// iptablesAddressMatches reports whether the address is in an address or address/prefix of a rule.
+func iptablesAddressMatches(selector string, ip net.IP) bool {
+	for _, item := range strings.Split(selector, ",") {
+		if !strings.Contains(item, "/") {
+			if net.ParseIP(item).Equal(ip) {
+				return true
+			}
+			continue
+		}
+		address, mask, _ := strings.Cut(item, "/")
+		if _, err := strconv.Atoi(mask); err != nil {
+			// Dotted netmasks such as 255.255.255.0 are converted to a prefix length.
+			ones, _ := net.IPMask(net.ParseIP(mask).To4()).Size()
+			item = address + "/" + strconv.Itoa(ones)
+		}
+		if _, network, err := net.ParseCIDR(item); err == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// iptablesProtocolMatches reports whether the protocol of a rule, by name or number, is the
+// protocol of the packet.
+func iptablesProtocolMatches(selector, protocol string) bool {
+	selector = strings.ToLower(selector)
+	if selector == "all" || selector == "0" || selector == protocol {
+		return true
+	}
+	if number, err := strconv.Atoi(selector); err == nil {
+		return number == iptablesProtocols[protocol]
+	}
+	return iptablesProtocols[selector] != 0 && iptablesProtocols[selector] == iptablesProtocols[protocol]
+}
+
+// iptablesInterfaceMatches reports whether the interface matches an interface of a rule, which
+// may end with '+' to match any interface with the prefix.
+func iptablesInterfaceMatches(selector, iface string) bool {
+	if prefix, ok := strings.CutSuffix(selector, "+"); ok {
+		return strings.HasPrefix(iface, prefix)
+	}
+	return selector == iface
+}
+
+// iptablesPortMatches reports whether the port is in a comma-separated list of ports and
+// port ranges 'first:last'. It cannot be evaluated if the packet has no port.
+func iptablesPortMatches(selector string, port int) (bool, bool) {
+	if port == 0 {
+		return false, false
+	}
+	for _, item := range strings.Split(selector, ",") {
+		firstString, lastString, isRange := strings.Cut(item, ":")
+		first, err := strconv.Atoi(firstString)
+		if err != nil {
+			return false, false
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(lastString); err != nil {
+				return false, false
+			}
+		}
+		if port >= first && port <= last {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// iptablesMarkMatches reports whether the mark matches a rule mark value[/mask].
+func iptablesMarkMatches(selector string, mark uint32) bool {
+	valueString, maskString, hasMask := strings.Cut(selector, "/")
+	value, err := strconv.ParseUint(valueString, 0, 32)
+	if err != nil {
+		return false
+	}
+	mask := uint64(0xffffffff)
+	if hasMask {
+		if mask, err = strconv.ParseUint(maskString, 0, 32); err != nil {
+			return false
+		}
+	}
+	return uint64(mark)&mask == value
+}
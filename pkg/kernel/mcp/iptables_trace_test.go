@@ -0,0 +1,269 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+// testIPTablesSave is an excerpt of the iptables rules of an OVN-Kubernetes node.
+const testIPTablesSave = `*raw
+:PREROUTING ACCEPT [0:0]
+:OUTPUT ACCEPT [0:0]
+COMMIT
+*mangle
+:PREROUTING ACCEPT [0:0]
+:INPUT ACCEPT [0:0]
+:FORWARD ACCEPT [0:0]
+:OUTPUT ACCEPT [0:0]
+:POSTROUTING ACCEPT [0:0]
+:OVN-KUBE-ITP - [0:0]
+-A OUTPUT -j OVN-KUBE-ITP
+-A OVN-KUBE-ITP -d 172.30.0.50/32 -p tcp -m tcp --dport 80 -j MARK --set-xmark 0x1745ec/0xffffffff
+COMMIT
+*nat
+:PREROUTING ACCEPT [0:0]
+:INPUT ACCEPT [0:0]
+:OUTPUT ACCEPT [0:0]
+:POSTROUTING ACCEPT [0:0]
+:OVN-KUBE-NODEPORT - [0:0]
+:OVN-KUBE-EXTERNALIP - [0:0]
+:OVN-KUBE-SNAT-MGMTPORT - [0:0]
+-A PREROUTING -j OVN-KUBE-EXTERNALIP
+-A PREROUTING -j OVN-KUBE-NODEPORT
+-A OUTPUT -j OVN-KUBE-EXTERNALIP
+-A OUTPUT -j OVN-KUBE-NODEPORT
+-A POSTROUTING -o ovn-k8s-mp0 -j OVN-KUBE-SNAT-MGMTPORT
+-A OVN-KUBE-NODEPORT -p tcp -m addrtype --dst-type LOCAL -m tcp --dport 30080 -j DNAT --to-destination 172.30.0.50:80
+-A OVN-KUBE-EXTERNALIP -d 192.0.2.10/32 -p tcp -m tcp --dport 443 -j DNAT --to-destination 172.30.0.60:443
+-A OVN-KUBE-SNAT-MGMTPORT -p tcp -m tcp --dport 30080 -j RETURN
+-A OVN-KUBE-SNAT-MGMTPORT -m comment --comment "OVN SNAT to Management Port" -j SNAT --to-source 10.244.1.2
+COMMIT
+*filter
+:INPUT ACCEPT [0:0]
+:FORWARD DROP [0:0]
+:OUTPUT ACCEPT [0:0]
+:OVN-KUBE-FORWARD - [0:0]
+:KUBE-FIREWALL - [0:0]
+-A INPUT -j KUBE-FIREWALL
+-A FORWARD -j OVN-KUBE-FORWARD
+-A OVN-KUBE-FORWARD -d 10.244.0.0/16 -j ACCEPT
+-A OVN-KUBE-FORWARD -s 192.0.2.0/24 -m recent --name seen --rcheck -j ACCEPT
+-A OVN-KUBE-FORWARD -d 172.30.0.0/16 -j ACCEPT
+-A KUBE-FIREWALL ! -s 127.0.0.0/8 -d 127.0.0.0/8 -m conntrack ! --ctstate RELATED,ESTABLISHED,DNAT -j DROP
+-A KUBE-FIREWALL -p udp -m multiport --dports 4789,6081 -j REJECT --reject-with icmp-port-unreachable
+COMMIT
+`
+
+func TestNewIPTablesPacket(t *testing.T) {
+	valid := types.IPTablesTraceParams{Hook: "PREROUTING", Protocol: "tcp", Src: "10.0.0.1", Dst: "10.0.0.2", Dport: 80}
+	tests := []struct {
+		name      string
+		modify    func(*types.IPTablesTraceParams)
+		wantError bool
+	}{
+		{name: "valid packet", modify: func(p *types.IPTablesTraceParams) {}},
+		{name: "lowercase hook and mark", modify: func(p *types.IPTablesTraceParams) { p.Hook = "output"; p.Mark = "0x1745ec" }},
+		{name: "invalid hook", modify: func(p *types.IPTablesTraceParams) { p.Hook = "INGRESS" }, wantError: true},
+		{name: "invalid protocol", modify: func(p *types.IPTablesTraceParams) { p.Protocol = "gre" }, wantError: true},
+		{name: "invalid src", modify: func(p *types.IPTablesTraceParams) { p.Src = "10.0.0.0/8" }, wantError: true},
+		{name: "mixed address families", modify: func(p *types.IPTablesTraceParams) { p.Dst = "fd00::1" }, wantError: true},
+		{name: "port out of range", modify: func(p *types.IPTablesTraceParams) { p.Dport = 70000 }, wantError: true},
+		{name: "port without ports protocol", modify: func(p *types.IPTablesTraceParams) { p.Protocol = "icmp" }, wantError: true},
+		{name: "invalid interface", modify: func(p *types.IPTablesTraceParams) { p.InInterface = "eth0;reboot" }, wantError: true},
+		{name: "invalid mark", modify: func(p *types.IPTablesTraceParams) { p.Mark = "0x1ffffffff" }, wantError: true},
+		{name: "invalid ct_state", modify: func(p *types.IPTablesTraceParams) { p.CtState = "SYN_SENT" }, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := valid
+			tt.modify(&params)
+			_, err := newIPTablesPacket(params)
+			if (err != nil) != tt.wantError {
+				t.Errorf("newIPTablesPacket() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestIPTablesEvaluatorTrace(t *testing.T) {
+	ruleset, err := parseIPTablesSave(testIPTablesSave)
+	if err != nil {
+		t.Fatalf("parseIPTablesSave() error = %v", err)
+	}
+	tests := []struct {
+		name                 string
+		params               types.IPTablesTraceParams
+		expectedHooks        []string
+		expectedVerdict      string
+		expectedActions      []string
+		expectedUndetermined int
+		expectedDNAT         string
+		expectedSNAT         string
+		expectedPacket       types.IPTablesTracePacket
+	}{
+		{
+			name:            "node port translated to a service and forwarded to the management port",
+			params:          types.IPTablesTraceParams{Hook: "PREROUTING", Protocol: "tcp", Src: "192.0.2.100", Dst: "192.168.1.10", Sport: 40000, Dport: 30080, InInterface: "breth0", OutInterface: "ovn-k8s-mp0"},
+			expectedHooks:   []string{"PREROUTING", "FORWARD", "POSTROUTING"},
+			expectedVerdict: "ACCEPT",
+			expectedActions: []string{
+				"raw PREROUTING 0 policy",
+				"mangle PREROUTING 0 policy",
+				"nat PREROUTING 1 jump",
+				"nat PREROUTING 2 jump",
+				"nat OVN-KUBE-NODEPORT 1 dnat",
+				"mangle FORWARD 0 policy",
+				"filter FORWARD 1 jump",
+				"filter OVN-KUBE-FORWARD 3 accept",
+				"mangle POSTROUTING 0 policy",
+				"nat POSTROUTING 1 jump",
+				"nat OVN-KUBE-SNAT-MGMTPORT 2 snat",
+			},
+			expectedUndetermined: 1,
+			expectedDNAT:         "172.30.0.50:80",
+			expectedSNAT:         "10.244.1.2",
+			expectedPacket:       types.IPTablesTracePacket{Src: "10.244.1.2", Dst: "172.30.0.50", Sport: 40000, Dport: 80, Mark: "0x0"},
+		},
+		{
+			name:            "locally generated packet marked by a MARK target",
+			params:          types.IPTablesTraceParams{Hook: "OUTPUT", Protocol: "tcp", Src: "192.168.1.10", Dst: "172.30.0.50", Sport: 40000, Dport: 80, OutInterface: "breth0"},
+			expectedHooks:   []string{"OUTPUT", "POSTROUTING"},
+			expectedVerdict: "ACCEPT",
+			expectedActions: []string{
+				"raw OUTPUT 0 policy",
+				"mangle OUTPUT 1 jump",
+				"mangle OVN-KUBE-ITP 1 mark",
+				"mangle OUTPUT 0 policy",
+				"nat OUTPUT 1 jump",
+				"nat OUTPUT 2 jump",
+				"nat OUTPUT 0 policy",
+				"filter OUTPUT 0 policy",
+				"mangle POSTROUTING 0 policy",
+				"nat POSTROUTING 0 policy",
+			},
+			expectedPacket: types.IPTablesTracePacket{Src: "192.168.1.10", Dst: "172.30.0.50", Sport: 40000, Dport: 80, Mark: "0x1745ec"},
+		},
+		{
+			name:            "local packet rejected by a multiport rule",
+			params:          types.IPTablesTraceParams{Hook: "PREROUTING", Protocol: "udp", Src: "192.168.1.11", Dst: "192.168.1.10", Sport: 40000, Dport: 6081, CtState: "ESTABLISHED"},
+			expectedHooks:   []string{"PREROUTING", "INPUT"},
+			expectedVerdict: "REJECT",
+			expectedActions: []string{
+				"raw PREROUTING 0 policy",
+				"mangle PREROUTING 0 policy",
+				"mangle INPUT 0 policy",
+				"filter INPUT 1 jump",
+				"filter KUBE-FIREWALL 2 reject",
+			},
+			expectedPacket: types.IPTablesTracePacket{Src: "192.168.1.11", Dst: "192.168.1.10", Sport: 40000, Dport: 6081, Mark: "0x0"},
+		},
+		{
+			name:            "forwarded packet dropped by the chain policy",
+			params:          types.IPTablesTraceParams{Hook: "FORWARD", Protocol: "icmp", Src: "192.0.2.100", Dst: "198.51.100.1"},
+			expectedHooks:   []string{"FORWARD"},
+			expectedVerdict: "DROP",
+			expectedActions: []string{
+				"mangle FORWARD 0 policy",
+				"filter FORWARD 1 jump",
+				"filter FORWARD 0 policy",
+			},
+			expectedUndetermined: 1,
+			expectedPacket:       types.IPTablesTracePacket{Src: "192.0.2.100", Dst: "198.51.100.1", Mark: "0x0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packet, err := newIPTablesPacket(tt.params)
+			if err != nil {
+				t.Fatalf("newIPTablesPacket() error = %v", err)
+			}
+			evaluator := &iptablesEvaluator{ruleset: ruleset, packet: packet, localAddresses: []net.IP{net.ParseIP("192.168.1.10")}}
+			result := evaluator.trace(strings.ToUpper(tt.params.Hook))
+
+			var actions []string
+			for _, step := range result.Steps {
+				actions = append(actions, fmt.Sprintf("%s %s %d %s", step.Table, step.Chain, step.RuleNumber, step.Action))
+			}
+			if diff := cmp.Diff(tt.expectedActions, actions); diff != "" {
+				t.Errorf("steps mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedHooks, result.Hooks); diff != "" {
+				t.Errorf("hooks mismatch (-want +got):\n%s", diff)
+			}
+			if result.Verdict != tt.expectedVerdict {
+				t.Errorf("verdict = %q, want %q", result.Verdict, tt.expectedVerdict)
+			}
+			if len(result.Undetermined) != tt.expectedUndetermined {
+				t.Errorf("undetermined = %v, want %d rules", result.Undetermined, tt.expectedUndetermined)
+			}
+			if result.DNAT != tt.expectedDNAT || result.SNAT != tt.expectedSNAT {
+				t.Errorf("dnat, snat = %q, %q, want %q, %q", result.DNAT, result.SNAT, tt.expectedDNAT, tt.expectedSNAT)
+			}
+			if diff := cmp.Diff(tt.expectedPacket, result.Packet); diff != "" {
+				t.Errorf("packet mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTraceIPTables(t *testing.T) {
+	var commands []string
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		command := strings.Join(cmd, " ")
+		commands = append(commands, command)
+		switch command {
+		case "ip6tables-save -V":
+			return "ip6tables-save v1.8.8 (nf_tables)", "", nil
+		case "ip6tables-save":
+			return "*filter\n:INPUT ACCEPT [0:0]\n-A INPUT -s fd00:10:244::/48 -p tcp -m tcp --dport 22 -j DROP\nCOMMIT\n", "", nil
+		case "ip -j address show":
+			return `[{"ifindex":2,"ifname":"breth0","addr_info":[{"family":"inet6","local":"fc00:f853:ccd:e793::3","prefixlen":64}]}]`, "", nil
+		}
+		t.Fatalf("unexpected command %q", command)
+		return "", "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.TraceIPTables(context.Background(), nil, types.IPTablesTraceParams{
+		Node:     "ovn-worker",
+		Hook:     "INPUT",
+		Protocol: "tcp",
+		Src:      "fd00:10:244:1::5",
+		Dst:      "fc00:f853:ccd:e793::3",
+		Sport:    40000,
+		Dport:    22,
+	})
+	if err != nil {
+		t.Fatalf("TraceIPTables() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"ip6tables-save -V", "ip6tables-save", "ip -j address show"}, commands); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+	expectedSteps := []types.IPTablesTraceStep{{
+		Hook:       "INPUT",
+		Table:      "filter",
+		Chain:      "INPUT",
+		RuleNumber: 1,
+		Rule:       "-A INPUT -s fd00:10:244::/48 -p tcp -m tcp --dport 22 -j DROP",
+		Action:     "drop",
+	}}
+	if diff := cmp.Diff(expectedSteps, result.Steps); diff != "" {
+		t.Errorf("steps mismatch (-want +got):\n%s", diff)
+	}
+	if result.Verdict != "DROP" {
+		t.Errorf("verdict = %q, want DROP", result.Verdict)
+	}
+}
@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -10,27 +11,40 @@ import (
 )
 
 type RunDebugNodeCommandFuncType func(ctx context.Context, namespace string, nodeName string, image string, command []string, hostPath string, mountPath string, timeout time.Duration) (string, string, error)
+type RunPodExecCommandFuncType func(ctx context.Context, namespace, name, container string, command []string) (string, string, error)
 
 // Config contains the configuration for the kernel MCP server.
 type Config struct {
 	// Image is the container image to use for running the commands on the node.
 	Image string
+	// ToolTimeout is the tool timeout of the server, applied to the calls without timeout_seconds.
+	// 0 if it is disabled.
+	ToolTimeout time.Duration
 }
 
 // MCPServer provides MCP server functionality for kernel operations.
 type MCPServer struct {
 	runDebugNodeCommand RunDebugNodeCommandFuncType
+	runPodExecCommand   RunPodExecCommandFuncType
 	cfg                 Config
+
+	utilitiesMu sync.Mutex
+	utilities   map[utilityKey]error
 }
 
 // NewMCPServer creates a new MCP server instance
-func NewMCPServer(runDebugNodeCommand RunDebugNodeCommandFuncType, cfg Config) (*MCPServer, error) {
+func NewMCPServer(runDebugNodeCommand RunDebugNodeCommandFuncType, runPodExecCommand RunPodExecCommandFuncType, cfg Config) (*MCPServer, error) {
 	if runDebugNodeCommand == nil {
 		return nil, fmt.Errorf("function to run debug node command is nil")
 	}
+	if runPodExecCommand == nil {
+		return nil, fmt.Errorf("function to run pod exec command is nil")
+	}
 	return &MCPServer{
 		runDebugNodeCommand: runDebugNodeCommand,
+		runPodExecCommand:   runPodExecCommand,
 		cfg:                 cfg,
+		utilities:           map[utilityKey]error{},
 	}, nil
 }
 
@@ -42,9 +56,12 @@ func (s *MCPServer) AddTools(server *mcp.Server) {
 			Name: "get-conntrack",
 			Description: fmt.Sprintf(`get-conntrack allows to interact with the connection tracking system of a Kubernetes node.
 			              Use this command to discover a list of all (or a filtered selection of) currently tracked connections.
+			              List operations return parsed entries (protocol, state, timeout, original/reply tuples, zone, mark, labels and flags).
 Parameters:
 - node (required): Name of the node from where conntrack entries are expected to be extracted
 - namespace (optional): Namespace of the debug pod from where conntrack entries are expected to be extracted. Default: 'default'
+- pod_namespace (optional): Namespace of a pod running on the node in whose network namespace the conntrack entries are listed. Required with pod_name
+- pod_name (optional): Name of the pod in whose network namespace the conntrack entries are listed. Requires 'crictl' on the node. Required with pod_namespace
 - command (optional): These options specify the particular operation to perform. These options can only be used if configured image has 'conntrack' utility available. If omitted or empty, defaults to -L. 
 					  -L, --dump : List connection tracking table.
 					  -C, --count: Show the table counter.
@@ -55,21 +72,155 @@ Parameters:
 						-p, --proto PROTO                : Specify layer four (TCP, UDP, ...) protocol.
 						--sport, --orig-port-src PORT    : Source port in original direction.
 						--dport, --orig-port-dst PORT    : Destination port in original direction.
-- head (optional): Return only first N lines. Default: %d lines if tail is not specified
-- tail (optional): Return only last N lines
+- zone (optional): Match only entries in the given conntrack zone (0-65535).
+- mark (optional): Match only entries whose mark equals value[/mask], e.g. '0x2' or '0x2/0xf'.
+- labels (optional): Match only entries whose labels equal the hexadecimal value[/mask], e.g. '0x1/0xff'.
+- status (optional): Match only entries having all of the given comma-separated flags: ASSURED, UNREPLIED, SEEN_REPLY, OFFLOAD, HW_OFFLOAD.
+- aggregate (optional): Instead of entries, return the number of matching entries by zone, state, destination (top %d) and protocol. Head and tail are not applied. Default: false
+- ip_family (optional): List the entries of the IP family: ipv4, ipv6 or both. Each entry is labeled with its family. Only with list operations.
+                        If omitted, the conntrack CLI default is used.
+- head (optional): Return only first N entries. Default: %d entries if tail is not specified
+- tail (optional): Return only last N entries
 - apply_tail_first (optional): If both head and tail are set and apply_tail_first is true,
 apply tail before head. Default: false
 - timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
 
+The zone, mark, labels and status filters are also applied when the conntrack CLI is unavailable and entries are read from /proc/net/nf_conntrack. Filter parameters cannot be used there.
+
 Example:
 - node='ovn-control-plane', command='-L'
 - node='ovn-worker', namespace='ovn-kubernetes', command='-L'
 - node='ovn-worker', filter_parameters='-s 1.2.3.4 -d 5.6.7.8 -p tcp --sport 32000 --dport 10250'
+- node='ovn-worker', zone='64000', status='ASSURED'
+- node='ovn-worker', aggregate=true
+- node='ovn-worker', ip_family='both', filter_parameters='-p udp --dport 53'
 
 Example output:
-tcp 6 91 ESTABLISHED src=1.2.3.4 dst=5.6.7.8 sport=32000 dport=10250 src=5.6.7.8 dst=1.2.3.4  sport=10250 dport=32000 [ASSURED] mark=0 secctx=system_u:object_r:unlabeled_t:s0 use=2
-`, DefaultMaxOutputLines, int(timeout.MaxTimeout.Seconds())),
+{
+  "entries": [
+    {
+      "family": "ipv4", "protocol": "tcp", "protocol_number": 6, "timeout": 91, "state": "ESTABLISHED",
+      "original": {"src": "1.2.3.4", "dst": "5.6.7.8", "sport": 32000, "dport": 10250},
+      "reply": {"src": "5.6.7.8", "dst": "1.2.3.4", "sport": 10250, "dport": 32000},
+      "zone": 64000, "mark": 2, "flags": ["ASSURED"], "use": 2
+    }
+  ]
+}
+
+Example output (aggregate=true):
+{
+  "aggregate": {
+    "total": 1520,
+    "by_zone": [{"key": "0", "count": 1200}, {"key": "64000", "count": 320}],
+    "by_state": [{"key": "ESTABLISHED", "count": 900}, {"key": "TIME_WAIT", "count": 400}, {"key": "none", "count": 220}],
+    "by_destination": [{"key": "10.96.0.10:53/udp", "count": 210}, {"key": "5.6.7.8:10250/tcp", "count": 120}],
+    "by_protocol": [{"key": "tcp", "count": 1300}, {"key": "udp", "count": 220}]
+  }
+}
+`, conntrackTopDestinations, DefaultMaxOutputLines, int(timeout.MaxTimeout.Seconds())),
 		}, s.GetConntrack)
+	// get-conntrack-events tool registration
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "get-conntrack-events",
+			Description: fmt.Sprintf(`get-conntrack-events monitors connection tracking events (conntrack -E) on a Kubernetes node for a bounded duration.
+			              Use this command to watch what happens to a connection (creation, state updates, expiry) while a problem is reproduced.
+			              Requires the 'conntrack' utility in the configured image.
+Parameters:
+- node (required): Name of the node where conntrack events are monitored
+- namespace (optional): Namespace of the debug pod used to monitor conntrack events. Default: 'default'
+- pod_namespace (optional): Namespace of a pod running on the node in whose network namespace the events are monitored. Required with pod_name
+- pod_name (optional): Name of the pod in whose network namespace the events are monitored. Requires 'crictl' on the node. Required with pod_namespace
+- duration_seconds (optional): Number of seconds events are collected for. Must leave %d seconds to start the debug pod before timeout_seconds
+                      when set, and otherwise before the tool timeout of the server, %d seconds: set timeout_seconds for long durations. Default: %d
+- event_types (optional): Comma-separated list of event types to report: NEW, UPDATE, DESTROY. Default: all
+- protocol (optional): Match only events of the given layer 4 protocol: tcp, udp, udplite, sctp, dccp, icmp, icmpv6, gre
+- src (optional): Match only events whose source address in the original direction is the given IPv4 or IPv6 address
+- dst (optional): Match only events whose destination address in the original direction is the given IPv4 or IPv6 address
+- sport (optional): Match only events whose source port in the original direction is the given port. Requires protocol
+- dport (optional): Match only events whose destination port in the original direction is the given port. Requires protocol
+- zone (optional): Match only events in the given conntrack zone (0-65535)
+- head (optional): Return only first N events. Default: %d events if tail is not specified
+- tail (optional): Return only last N events
+- apply_tail_first (optional): If both head and tail are set and apply_tail_first is true,
+apply tail before head. Default: false
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+Example:
+- node='ovn-worker', duration_seconds=30, protocol='tcp', dst='10.96.0.1', dport=443
+- node='ovn-worker', event_types='NEW,DESTROY', zone='64000'
+
+Example output:
+{
+  "events": [
+    {
+      "timestamp": "2026-10-18T10:00:00.123456Z", "type": "NEW",
+      "protocol": "tcp", "protocol_number": 6, "timeout": 120, "state": "SYN_SENT",
+      "original": {"src": "10.244.1.3", "dst": "10.96.0.1", "sport": 40000, "dport": 443},
+      "reply": {"src": "10.96.0.1", "dst": "10.244.1.3", "sport": 443, "dport": 40000},
+      "flags": ["UNREPLIED"]
+    }
+  ],
+  "duration_seconds": 30,
+  "summary": "conntrack v1.4.7 (conntrack-tools): 1 flow events have been shown."
+}
+`, int(timeout.SetupTime.Seconds()), timeout.DurationLimit(s.cfg.ToolTimeout), DefaultConntrackEventsDuration, DefaultMaxOutputLines, int(timeout.MaxTimeout.Seconds())),
+		}, s.GetConntrackEvents)
+	// get-conntrack-entry tool registration
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "get-conntrack-entry",
+			Description: `get-conntrack-entry looks up the connection tracking entry of a single flow by its original direction 5-tuple on a Kubernetes node.
+			              Use this command to check whether a flow is tracked, its state, and whether a DNAT or SNAT was applied to it.
+			              When an OVS pod is given, the OVS datapath view of the same flow (ovs-appctl dpctl/dump-conntrack) is compared with the kernel view.
+			              Requires the 'conntrack' utility in the configured image.
+Parameters:
+- node (required): Name of the node where the kernel lookup is executed
+- namespace (optional): Namespace of the debug pod used for the kernel lookup. Default: 'default'
+- pod_namespace (optional): Namespace of a pod running on the node in whose network namespace the kernel lookup is executed. Required with pod_name
+- pod_name (optional): Name of the pod in whose network namespace the kernel lookup is executed. Requires 'crictl' on the node. Required with pod_namespace
+- protocol (required): Layer 4 protocol of the flow: tcp, udp, udplite, sctp, dccp
+- src (required): Source IPv4 or IPv6 address in the original direction
+- dst (required): Destination IPv4 or IPv6 address in the original direction. Must be of the same family as src
+- sport (required): Source port in the original direction
+- dport (required): Destination port in the original direction
+- zone (optional): Conntrack zone of the entry (0-65535). If set, the kernel lookup uses conntrack -G. Otherwise all zones are searched
+- ovs_namespace (optional): Namespace of the pod running OVS on the same node. Required with ovs_pod
+- ovs_pod (optional): Name of the pod running OVS on the same node, e.g. the ovnkube-node pod. Required with ovs_namespace
+- ovs_container (optional): Container of ovs_pod running OVS. Default: the first container
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used.
+
+The comparison is reported per zone. A reply tuple that is not the inverse of the original tuple means the destination (dnat) or
+the source (snat) was translated; dnat and snat hold the translated address and port.
+
+Example:
+- node='ovn-worker', protocol='tcp', src='10.244.1.3', dst='10.96.0.1', sport=40000, dport=443
+- node='ovn-worker', protocol='udp', src='fd00:10:244:1::3', dst='fd00:10:96::a', sport=5353, dport=53, zone='12', ovs_namespace='ovn-kubernetes', ovs_pod='ovnkube-node-xxxxx'
+
+Example output:
+{
+  "kernel": [
+    {
+      "protocol": "tcp", "protocol_number": 6, "timeout": 431999, "state": "ESTABLISHED",
+      "original": {"src": "10.244.1.3", "dst": "10.96.0.1", "sport": 40000, "dport": 443},
+      "reply": {"src": "172.18.0.3", "dst": "10.244.1.3", "sport": 6443, "dport": 40000},
+      "zone": 12, "flags": ["ASSURED"], "use": 1
+    }
+  ],
+  "ovs": [
+    {
+      "protocol": "tcp", "protocol_number": 0, "timeout": 0, "state": "ESTABLISHED",
+      "original": {"src": "10.244.1.3", "dst": "10.96.0.1", "sport": 40000, "dport": 443},
+      "reply": {"src": "172.18.0.3", "dst": "10.244.1.3", "sport": 6443, "dport": 40000},
+      "zone": 12
+    }
+  ],
+  "comparisons": [
+    {"zone": 12, "in_kernel": true, "in_ovs": true, "dnat": "172.18.0.3:6443"}
+  ]
+}
+`,
+		}, s.GetConntrackEntry)
 	// get-iptables tool registration
 	mcp.AddTool(server,
 		&mcp.Tool{
@@ -79,6 +230,8 @@ tcp 6 91 ESTABLISHED src=1.2.3.4 dst=5.6.7.8 sport=32000 dport=10250 src=5.6.7.8
 Parameters:
 - node (required): Name of the node from where packet filter rules are expected to be extracted
 - namespace (optional): Namespace of the debug pod from where packet filter rules are expected to be extracted. Default: 'default'
+- pod_namespace (optional): Namespace of a pod running on the node in whose network namespace the rules are listed. Required with pod_name
+- pod_name (optional): Name of the pod in whose network namespace the rules are listed. Requires 'crictl' on the node. Required with pod_namespace
 - table (optional): There are currently five independent tables (which tables are present at any time depends on the kernel configuration options and which modules are present).
                     filter	: This is the default table
 					nat   	: This  table is consulted when a packet that creates a new connection is encountered.
@@ -96,7 +249,9 @@ Parameters:
 								-p, --protocol protocol          : The protocol of the rule or of the packet to check.
 								-4, --ipv4                       : IPv4
 								-6, --ipv6                       : IPv6
-- head (optional): Return only first N lines. Default: %d lines if tail is not specified
+- ip_family (optional): List the rules of the IP family: ipv4 (iptables), ipv6 (ip6tables) or both. Each family is returned separately, labeled
+                        with its family. If omitted, ip6tables is used when filter_parameters contain -6 or --ipv6, otherwise iptables.
+- head (optional): Return only first N lines of each family. Default: %d lines if tail is not specified
 - tail (optional): Return only last N lines
 - apply_tail_first (optional): If both head and tail are set and apply_tail_first is true,
 apply tail before head. Default: false
@@ -105,55 +260,169 @@ apply tail before head. Default: false
 Example:
 - node='ovn-control-plane', table='nat', filter_parameters='-nv4'
 - node='ovn-control-plane', table='nat', command='-L'
+- node='ovn-worker', table='nat', command='-S', ip_family='both'
 Example output:
-Chain POSTROUTING (policy ACCEPT 675K packets, 41M bytes)
- pkts bytes target     prot opt in     out     source               destination         
- 675K   41M OVN-KUBE-EGRESS-IP-MULTI-NIC  all  --  *      *       0.0.0.0/0            0.0.0.0/0     							
+{
+  "families": [
+    {"family": "ipv4", "command": "iptables -t nat -S", "data": "-P POSTROUTING ACCEPT\n-A POSTROUTING -j OVN-KUBE-EGRESS-SVC"},
+    {"family": "ipv6", "command": "ip6tables -t nat -S", "data": "-P POSTROUTING ACCEPT\n-A POSTROUTING -j OVN-KUBE-EGRESS-SVC"}
+  ]
+}
 `, DefaultMaxOutputLines, int(timeout.MaxTimeout.Seconds())),
 		}, s.GetIptables)
+	// trace-iptables tool registration
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "trace-iptables",
+			Description: `trace-iptables evaluates the iptables rules of a Kubernetes node against a packet, without sending it.
+			              Use this command to find which rules a packet would match, which chains it would jump to, and whether it would be
+			              accepted, dropped or rejected, or translated by a DNAT or SNAT rule.
+			              The rules are read with iptables-save (ip6tables-save for IPv6 packets), which must be available in the configured image.
+Parameters:
+- node (required): Name of the node whose rules are evaluated
+- namespace (optional): Namespace of the debug pod. Default: 'default'
+- pod_namespace (optional): Namespace of a pod running on the node whose rules and addresses are used instead of those of the node. Required with pod_name
+- pod_name (optional): Name of the pod whose rules and addresses are used. Requires 'crictl' on the node. Required with pod_namespace
+- hook (required): Netfilter hook the packet enters: PREROUTING (received packets), INPUT, FORWARD, OUTPUT (locally generated packets), POSTROUTING
+- protocol (required): Protocol of the packet: tcp, udp, sctp, icmp, icmpv6
+- src (required): Source IPv4 or IPv6 address of the packet
+- dst (required): Destination IPv4 or IPv6 address of the packet. Must be of the same family as src
+- sport (optional): Source port of the packet. Only for tcp, udp and sctp
+- dport (optional): Destination port of the packet. Only for tcp, udp and sctp
+- in_interface (optional): Interface the packet is received on, matched by -i
+- out_interface (optional): Interface the packet is sent out of, matched by -o
+- mark (optional): Packet mark when entering the hook, e.g. '0x1745ec'. Default: 0
+- ct_state (optional): Conntrack state of the packet: NEW, ESTABLISHED, RELATED, INVALID, UNTRACKED. Default: 'NEW'. The nat table is
+                       only evaluated for NEW packets
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used.
+
+The packet is followed from the given hook through the next hooks: after PREROUTING to INPUT if the destination, after DNAT, is an
+address of the node, otherwise to FORWARD, and from FORWARD and OUTPUT to POSTROUTING. DNAT, SNAT, MASQUERADE, REDIRECT and MARK
+targets update the packet. Rules using matches that cannot be evaluated, such as ipsets or recent, are reported as undetermined and
+assumed not to match.
+
+Example:
+- node='ovn-worker', hook='PREROUTING', protocol='tcp', src='172.18.0.1', dst='172.18.0.3', sport=40000, dport=30080, in_interface='breth0'
+- node='ovn-worker', hook='OUTPUT', protocol='udp', src='172.18.0.3', dst='fd00:10:96::a', dport=53
+
+Example output:
+{
+  "hooks": ["PREROUTING", "FORWARD", "POSTROUTING"],
+  "steps": [
+    {"hook": "PREROUTING", "table": "nat", "chain": "PREROUTING", "rule_number": 2, "rule": "-A PREROUTING -j OVN-KUBE-NODEPORT", "action": "jump"},
+    {"hook": "PREROUTING", "table": "nat", "chain": "OVN-KUBE-NODEPORT", "rule_number": 1,
+     "rule": "-A OVN-KUBE-NODEPORT -p tcp -m addrtype --dst-type LOCAL -m tcp --dport 30080 -j DNAT --to-destination 10.96.0.50:80", "action": "dnat"},
+    {"hook": "FORWARD", "table": "filter", "chain": "FORWARD", "rule": "-P FORWARD ACCEPT", "action": "policy"}
+  ],
+  "verdict": "ACCEPT",
+  "dnat": "10.96.0.50:80",
+  "packet": {"src": "172.18.0.1", "dst": "10.96.0.50", "sport": 40000, "dport": 80, "mark": "0x0"}
+}
+`,
+		}, s.TraceIPTables)
 	// get-nft tool registration
 	mcp.AddTool(server,
 		&mcp.Tool{
 			Name: "get-nft",
 			Description: fmt.Sprintf(`get-nft allows to interact with kernel to list packet filtering and classification rules.
+The ruleset is listed with 'nft -j' and returned as typed tables, chains, rules, sets, maps and flowtables. Rules are parsed into
+their expressions in nft syntax, their verdict and their counter.
 Parameters:
 - node (required): Name of the node from where packet filtering and classification rules are expected to be extracted
 - namespace (optional): Namespace of the debug pod from where packet filtering and classification rules are expected to be extracted. Default: 'default'
+- pod_namespace (optional): Namespace of a pod running on the node in whose network namespace the ruleset is listed. Required with pod_name
+- pod_name (optional): Name of the pod in whose network namespace the ruleset is listed. Requires 'crictl' on the node. Required with pod_namespace
 - command (required): These options specify the desired action to perform. Only one of them can be specified on the command line unless otherwise stated below.
-                    - list ruleset   : The ruleset keyword is used to identify the whole set of tables, chains, etc. Print the ruleset in human-readable format.
-					- list tables    : List all chains and rules of the specified table.
-					- list chains    : List all rules of the specified chain.
-					- list sets      : Display the elements in the specified set.
-					- list maps      : Display the elements in the specified map.
+                    - list ruleset   : The ruleset keyword is used to identify the whole set of tables, chains, etc.
+					- list tables    : List all tables.
+					- list chains    : List all chains.
+					- list sets      : List all sets.
+					- list maps      : List all maps.
 					- list flowtables: List all flowtables.
+					- list table     : List the chains, rules, sets and maps of the table given in 'table'.
+					- list chain     : List the rules of the chain given in 'table' and 'name'.
+					- list set       : List the elements of the set given in 'table' and 'name'.
+					- list map       : List the elements of the map given in 'table' and 'name'.
 - address_families (optional): Address families determine the type of packets which are processed. For each address family, the kernel contains so-called hooks at specific stages of
-       						   the packet processing paths, which invoke nftables if rules for these hooks exist.
+       						   the packet processing paths, which invoke nftables if rules for these hooks exist. nft uses 'ip' if not set for list table/chain/set/map.
 							   - ip       IPv4 address family.
                                - ip6      IPv6 address family.
                                - inet     Internet (IPv4/IPv6) address family.
                                - arp      ARP address family, handling IPv4 ARP packets.
                                - bridge   Bridge address family, handling packets which traverse a bridge device.
                                - netdev   Netdev address family, handling packets on ingress and egress.
-- head (optional): Return only first N lines. Default: %d lines if tail is not specified
-- tail (optional): Return only last N lines
+- ip_family (optional): Return only the tables handling the IP family and their objects: ipv4 (ip and inet tables), ipv6 (ip6 and inet tables)
+                        or both (ip, ip6 and inet tables). Objects are labeled with their table family. Cannot be used with address_families,
+                        nor with 'list table', 'list chain', 'list set' and 'list map'.
+- table (optional): Table to list with 'list table', 'list chain', 'list set' and 'list map'. With the other commands, only objects of the table are returned.
+- name (optional): Chain, set or map name with 'list chain', 'list set' and 'list map'.
+- element (optional): With 'list set' and 'list map', return only the elements containing the value (membership lookup).
+                      Concatenations are written 'a . b', e.g. '10.96.0.10 . udp . 53'. Addresses match prefixes and ranges, numbers match ranges.
+- head (optional): Return only first N rules, and first N elements of each set and map. Default: %d if tail is not specified
+- tail (optional): Return only last N rules, and last N elements of each set and map
 - apply_tail_first (optional): If both head and tail are set and apply_tail_first is true,
 apply tail before head. Default: false
 - timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
 					
 Example:
 - node='ovn-control-plane', command='list tables', address_families='inet'
+- node='ovn-worker', command='list ruleset', ip_family='ipv6'
+- node='ovn-worker', command='list chain', address_families='inet', table='ovn-kubernetes', name='mgmtport-snat'
+- node='ovn-worker', command='list set', address_families='inet', table='ovn-kubernetes', name='mgmtport-no-snat-nodeports', element='tcp . 30080'
 Example output:
-table inet ovn-kubernetes
+{"rules":[{"family":"inet","table":"ovn-kubernetes","chain":"mgmtport-snat","handle":5,"expressions":["oifname != \"ovn-k8s-mp0\"", "meta nfproto ipv4", "ip saddr 10.244.1.2"],"verdict":"return","counter":{"packets":0,"bytes":0}}]}
 `, DefaultMaxOutputLines, int(timeout.MaxTimeout.Seconds())),
 		}, s.GetNFT)
+	// search-nft tool registration
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "search-nft",
+			Description: fmt.Sprintf(`search-nft searches the nftables ruleset of a Kubernetes node for the rules and the set and map elements that reference an address or a port.
+			              Use this command to answer "which rules handle IP X or port Y", e.g. for NodePort, ExternalIP and masquerade state that OVN-Kubernetes
+			              keeps in the sets and maps of its 'inet ovn-kubernetes' table.
+Parameters:
+- node (required): Name of the node whose ruleset is searched
+- namespace (optional): Namespace of the debug pod. Default: 'default'
+- pod_namespace (optional): Namespace of a pod running on the node in whose network namespace the ruleset is searched. Required with pod_name
+- pod_name (optional): Name of the pod in whose network namespace the ruleset is searched. Requires 'crictl' on the node. Required with pod_namespace
+- address (optional): IPv4 or IPv6 address to search for. Matches literals, prefixes and ranges
+- port (optional): Transport port to search for. Matches port matches, NAT ports and service typed set components
+- address_families (optional): Limit the search to the tables of the address family (ip, ip6, inet, arp, bridge, netdev)
+- ip_family (optional): Limit the search to the tables handling the IP family: ipv4 (ip and inet), ipv6 (ip6 and inet) or both. Cannot be used with address_families
+- table (optional): Limit the search to the table
+- head (optional): Return only first N rules and elements. Default: %d if tail is not specified
+- tail (optional): Return only last N rules and elements
+- apply_tail_first (optional): If both head and tail are set and apply_tail_first is true,
+apply tail before head. Default: false
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+At least one of address and port is required. When both are given, a rule must reference both, directly or through a set or map,
+and an element must contain both. Rules referencing a set or map that contains the value list '@name' in matches.
+
+Example:
+- node='ovn-worker', address='172.18.0.100'
+- node='ovn-worker', port=30080, table='ovn-kubernetes'
+
+Example output:
+{
+  "rules": [{"family": "inet", "table": "ovn-kubernetes", "chain": "mgmtport-snat", "handle": 7,
+             "expressions": ["meta l4proto . th dport @mgmtport-no-snat-nodeports"], "verdict": "return", "matches": ["@mgmtport-no-snat-nodeports"]}],
+  "elements": [{"family": "inet", "table": "ovn-kubernetes", "set": "mgmtport-no-snat-nodeports", "map": false, "key": "tcp . 30080"}]
+}
+`, DefaultMaxOutputLines, int(timeout.MaxTimeout.Seconds())),
+		}, s.SearchNFT)
 	// get-ip tool registration
 	mcp.AddTool(server,
 		&mcp.Tool{
 			Name: "get-ip",
 			Description: fmt.Sprintf(`get-ip allows to interact with kernel to list routing, network devices, interfaces.
+Address, link, neighbour, route and rule commands return typed objects parsed from 'ip -j' output (addresses, links,
+neighbours, routes, rules). Other commands return the raw output in data.
 Parameters:
 - node (required): Name of the node on which ip command is expected to be executed
 - namespace (optional): Namespace of the debug pod on which ip command is expected to be executed. Default: 'default'
+- pod_namespace (optional): Namespace of a pod running on the node in whose network namespace the ip command is run. Required with pod_name
+- pod_name (optional): Name of the pod in whose network namespace the ip command is run. Requires 'crictl' on the node. Required with pod_namespace
 - options (optional): These options helps in providing more details or formatting output data.
                       -d, -details        : Output more detailed information.
 					  -4                  : shortcut for -family inet.
@@ -173,18 +442,301 @@ Parameters:
 					  - xfrm policy list : show Security Policy Database.
 - filter_parameters (optional): This allows to mention sub command to get more filtered data. Available sub command varies and supportability depends on what is 
                           already supported with 'ip' utility.
-- head (optional): Return only first N lines. Default: %d lines if tail is not specified
-- tail (optional): Return only last N lines
+- table (optional): Routing table name or number (e.g. 'main', 'local', '7', 'all'). Only for route and rule commands.
+                    Routes without a table in the ip output are reported with the requested table, or 'main'.
+- device (optional): Network device name. Only for route, address, link and neighbour commands.
+- netns (optional): Named network namespace in which the ip command is run. Pod network namespaces are not named, use pod_namespace and pod_name instead.
+- head (optional): Return only first N objects (or lines of raw output). Default: %d if tail is not specified
+- tail (optional): Return only last N objects (or lines of raw output)
 - apply_tail_first (optional): If both head and tail are set and apply_tail_first is true,
 apply tail before head. Default: false
 - timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
 
 Example:
-- node='ovn-control-plane', options="-4", command='route show', filter_parameters='table all'
+- node='ovn-control-plane', options="-4", command='route show', table='all'
+- node='ovn-control-plane', command='link show', device='breth0'
 Example output:
-default via 10.0.0.254 dev br-ex proto dhcp src 10.0.0.10 metric 48
+{"routes":[{"dst":"default","gateway":"172.18.0.1","dev":"breth0","table":"main","protocol":"static","metric":48,"mtu":1400}]}
 `, DefaultMaxOutputLines, int(timeout.MaxTimeout.Seconds())),
 		}, s.GetIPCommandOutput)
+	// get-route-decision tool registration
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "get-route-decision",
+			Description: `get-route-decision asks the kernel how it would route a packet ('ip route get') on a Kubernetes node or inside a pod's network namespace.
+			              Use this command to verify policy routing, e.g. that EgressIP or local gateway mode traffic leaves through the expected table and interface.
+			              The routing policy rules are listed as well and the first rule matching the packet that selects the table of the route is reported.
+			              Requires the 'ip' utility in the configured image, and 'crictl' on the node when a pod is given.
+Parameters:
+- node (required): Name of the node where the lookup is executed. When a pod is given, the node the pod is running on
+- namespace (optional): Namespace of the debug pod used for the lookup. Default: 'default'
+- dst (required): Destination IPv4 or IPv6 address of the packet
+- src (optional): Source address of the packet. Must be of the same family as dst
+- iif (optional): Input interface of a forwarded packet, e.g. 'breth0'. Requires src. If not set the packet is locally generated
+- fwmark (optional): Firewall mark of the packet, decimal or hexadecimal (e.g. '0x1745ec')
+- uid (optional): Uid of the socket owner of a locally generated packet
+- vrf (optional): VRF device the packet is associated with
+- pod_namespace (optional): Namespace of the pod whose network namespace is used. Required with pod_name
+- pod_name (optional): Name of the pod whose network namespace is used. Required with pod_namespace
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used.
+
+Example:
+- node='ovn-worker', dst='8.8.8.8', src='10.244.1.3', iif='ovn-k8s-mp0'
+- node='ovn-worker', dst='10.96.0.1', pod_namespace='default', pod_name='client'
+
+Example output:
+{
+  "route": {"dst": "8.8.8.8", "src": "10.244.1.3", "iif": "ovn-k8s-mp0", "gateway": "172.18.0.1", "dev": "breth0", "table": "1008", "flags": []},
+  "rule": {"priority": 6000, "src": "10.244.1.3", "table": "1008"},
+  "netns": "node ovn-worker"
+}
+`,
+		}, s.GetRouteDecision)
+	// get-sysctl tool registration
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "get-sysctl",
+			Description: `get-sysctl reads the network sysctls OVN-Kubernetes depends on, on a Kubernetes node or inside a pod's network namespace,
+			              and compares them with an embedded baseline of the values OVN-Kubernetes expects.
+			              Use this command to find kernel misconfigurations such as strict rp_filter on the gateway bridge (br-ex or breth0).
+			              Requires the 'ip' and 'sysctl' utilities in the configured image, and 'crictl' on the node when a pod is given.
+Parameters:
+- node (required): Name of the node where the sysctls are read. When a pod is given, the node the pod is running on
+- namespace (optional): Namespace of the debug pod. Default: 'default'
+- pod_namespace (optional): Namespace of the pod whose network namespace is read. Required with pod_name
+- pod_name (optional): Name of the pod whose network namespace is read. Required with pod_namespace
+- interfaces (optional): Additional comma-separated interfaces whose per-interface sysctls are checked. By default the gateway bridge
+                         and management ports on a node, and every interface but loopback in a pod
+- keys (optional): Additional comma-separated sysctl keys to read and return in values, e.g. 'net.ipv4.tcp_rmem'. At most 50
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used.
+
+The baseline checks net.ipv4.ip_forward, net.ipv6.conf.all.forwarding, rp_filter, arp_ignore and arp_announce per interface,
+accept_ra per interface, net.netfilter.nf_conntrack_max, net.netfilter.nf_conntrack_tcp_be_liberal and net.core.rmem_max.
+rp_filter and arp_ignore are compared by their effective value, the maximum of the interface and 'all' values. Checks whose value
+differs from the baseline are returned in deviations with a severity, the others in checks with status ok, missing (the key does
+not exist, e.g. IPv6 disabled) or info (reported only). In a pod network namespace, rp_filter is only compared for multi-homed pods.
+
+Example:
+- node='ovn-worker'
+- node='ovn-worker', interfaces='eth1', keys='net.ipv4.tcp_rmem'
+- node='ovn-worker', pod_namespace='default', pod_name='client'
+
+Example output:
+{
+  "netns": "node ovn-worker",
+  "deviations": [
+    {"key": "net.ipv4.conf.breth0.rp_filter", "value": "0", "effective": "1", "expected": "0 (off) or 2 (loose)", "status": "deviation",
+     "severity": "error", "reason": "strict reverse path filtering drops asymmetrically routed packets, ..."}
+  ],
+  "checks": [
+    {"key": "net.ipv4.ip_forward", "value": "1", "expected": "1", "status": "ok", "reason": "OVN-Kubernetes routes IPv4 traffic ..."}
+  ],
+  "interfaces": ["breth0", "ovn-k8s-mp0"]
+}
+`,
+		}, s.GetSysctl)
+	// get-network-counters tool registration
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "get-network-counters",
+			Description: fmt.Sprintf(`get-network-counters snapshots the packet, drop and error counters of a Kubernetes node twice, interval_seconds apart,
+			              and reports only the counters that changed with their per-second rates. Drop and error counters are returned separately.
+			              Use this command to find where packets are being dropped while a problem is reproduced.
+			              The counters are read from 'ip -s -s link', /proc/net/snmp, /proc/net/netstat, /proc/net/softnet_stat and, when
+			              available, nstat for the IPv6 counters. Requires the 'ip' utility and a shell in the configured image.
+Parameters:
+- node (required): Name of the node where the counters are read
+- namespace (optional): Namespace of the debug pod used to read the counters. Default: 'default'
+- interval_seconds (optional): Number of seconds between the two snapshots. Must leave %d seconds to start the debug pod before timeout_seconds
+                      when set, and otherwise before the tool timeout of the server, %d seconds. Default: %d
+- interfaces (optional): Comma-separated list of interfaces whose counters are reported. Default: all interfaces
+- drops_only (optional): Report only the drop and error counters. Default: false
+- head (optional): Return only first N counters of drops and of counters. Default: %d counters if tail is not specified
+- tail (optional): Return only last N counters of drops and of counters
+- apply_tail_first (optional): If both head and tail are set and apply_tail_first is true,
+apply tail before head. Default: false
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+Counters are named after their source: link counters after their direction and ip statistic (rx_dropped, tx_errors) with the
+interface as object, SNMP and netstat counters like nstat names them (TcpExtListenDrops, IpInDiscards), and softnet counters
+after their column (dropped, time_squeeze, flow_limit_count), totalled over all CPUs and, for drop counters, per CPU with the CPU
+as object. Counters are sorted by decreasing rate. A negative delta means the counter was reset, e.g. the interface was recreated.
+
+Example:
+- node='ovn-worker'
+- node='ovn-worker', interval_seconds=10, interfaces='breth0,genev_sys_6081', drops_only=true
+
+Example output:
+{
+  "interval_seconds": 5.01,
+  "drops": [
+    {"source": "netstat", "name": "TcpExtListenDrops", "before": 120, "after": 170, "delta": 50, "rate": 9.98},
+    {"source": "link", "object": "breth0", "name": "rx_dropped", "before": 3, "after": 8, "delta": 5, "rate": 1}
+  ],
+  "counters": [
+    {"source": "link", "object": "breth0", "name": "rx_packets", "before": 100000, "after": 105000, "delta": 5000, "rate": 998}
+  ]
+}
+`, int(timeout.SetupTime.Seconds()), timeout.DurationLimit(s.cfg.ToolTimeout), DefaultNetworkCountersInterval, DefaultMaxOutputLines, int(timeout.MaxTimeout.Seconds())),
+		}, s.GetNetworkCounters)
+	// get-ethtool tool registration
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "get-ethtool",
+			Description: `get-ethtool reads the driver, offload features, driver statistics, rings, channels and module information of a network
+			              interface with ethtool on a Kubernetes node, and optionally compares the interface across nodes.
+			              Use this command to find NIC settings that differ between nodes, such as Geneve (tx-udp_tnl-segmentation) or
+			              checksum offloads that are disabled on some nodes and cause throughput drops.
+			              Requires a shell and the 'ethtool' utility in the configured image.
+Parameters:
+- node (required): Name of the node where the interface is read
+- namespace (optional): Namespace of the debug pod. Default: 'default'
+- interface (required): Name of the interface, e.g. 'ens1f0' or 'genev_sys_6081'
+- nodes (optional): Comma-separated list of other nodes whose interface of the same name is read and compared. At most 9
+- sections (optional): Comma-separated list of sections to read. Default: all
+                       driver     : Driver and firmware information (ethtool -i)
+                       features   : Offload features (ethtool -k)
+                       statistics : Driver statistics (ethtool -S)
+                       rings      : RX/TX ring sizes (ethtool -g)
+                       channels   : Channel counts (ethtool -l)
+                       module     : Plug-in module (SFP/QSFP) information (ethtool -m)
+- statistics_filter (optional): Regular expression the returned statistics names must match, e.g. 'drop|err|discard'
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used.
+
+Sections the driver does not support, e.g. rings, channels and module on virtual interfaces, are returned in errors.
+Names printed by -i, -g, -l and -m are lowercased with spaces and dashes replaced by underscores, e.g. firmware_version and rx_mini.
+When nodes are given, the driver information (but the bus address), features, current ring sizes and current channel counts that
+differ between the nodes are returned in differences, with the value on each node, or 'missing'.
+
+Example:
+- node='ovn-worker', interface='eth0'
+- node='worker-1', nodes='worker-2,worker-3', interface='ens1f0', sections='driver,features'
+- node='worker-1', interface='ens1f0', sections='statistics', statistics_filter='drop|err'
+
+Example output:
+{
+  "interfaces": [
+    {
+      "node": "worker-1", "interface": "ens1f0",
+      "driver": {"driver": "ice", "version": "6.1.0", "firmware_version": "4.20 0x80017785 1.3346.0", "bus_info": "0000:3b:00.0"},
+      "features": {"tx-checksumming": {"enabled": true}, "tx-udp_tnl-segmentation": {"enabled": true}, "hw-tc-offload": {"enabled": false, "fixed": true}}
+    },
+    {
+      "node": "worker-2", "interface": "ens1f0",
+      "driver": {"driver": "ice", "version": "6.1.0", "firmware_version": "4.20 0x80017785 1.3346.0", "bus_info": "0000:5e:00.0"},
+      "features": {"tx-checksumming": {"enabled": true}, "tx-udp_tnl-segmentation": {"enabled": false, "requested": "on"}, "hw-tc-offload": {"enabled": false, "fixed": true}}
+    }
+  ],
+  "differences": [
+    {"section": "features", "key": "tx-udp_tnl-segmentation", "values": {"worker-1": "on", "worker-2": "off [requested on]"}}
+  ]
+}
+`,
+		}, s.GetEthtool)
+	// get-sockets tool registration
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "get-sockets",
+			Description: fmt.Sprintf(`get-sockets lists the TCP and UDP sockets of a Kubernetes node or of a pod's network namespace ('ss -tunapeiOH')
+			              with their processes and TCP information: round trip time, retransmits, congestion window and queue sizes.
+			              Use this command to check whether anything is listening on a NodePort or service port, or why a connection is slow.
+			              Requires the 'ss' utility in the configured image, and 'crictl' on the node when a pod is given.
+Parameters:
+- node (required): Name of the node where the sockets are listed. When a pod is given, the node the pod is running on
+- namespace (optional): Namespace of the debug pod. Default: 'default'
+- pod_namespace (optional): Namespace of the pod whose network namespace is used. Required with pod_name
+- pod_name (optional): Name of the pod whose network namespace is used. Required with pod_namespace
+- protocol (optional): List the sockets of the protocol: 'tcp', 'udp' or 'both'. Default: 'both'
+- state (optional): Comma-separated list of states the sockets must be in: established, syn-sent, syn-recv, fin-wait-1,
+                    fin-wait-2, time-wait, close-wait, last-ack, closing, listen or unconn (unconnected UDP and closed sockets)
+- port (optional): Match only sockets whose local or peer port is the given port
+- address (optional): Match only sockets whose local or peer address is the given IPv4 or IPv6 address or is in the given CIDR
+- process (optional): Regular expression the name of a process using the socket must match, e.g. 'ovnkube|ovn-controller'
+- head (optional): Return only first N sockets. Default: %d sockets if tail is not specified
+- tail (optional): Return only last N sockets
+- apply_tail_first (optional): If both head and tail are set and apply_tail_first is true,
+apply tail before head. Default: false
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+States are returned as ss prints them: ESTAB, LISTEN, UNCONN, TIME-WAIT, ... For listening sockets recv_q is the number of connections
+waiting to be accepted and send_q the accept backlog. TCP times (rtt, rtt_var, min_rtt, rto) are in milliseconds, retransmits are the
+currently unrecovered retransmitted segments and total_retransmits all the segments retransmitted by the socket. A wildcard address is '*'
+and IPv4 connections of IPv6 sockets have IPv4-mapped addresses, which address filters match by their IPv4 address.
+
+Example:
+- node='ovn-worker', state='listen', port=30080
+- node='ovn-worker', state='established', address='10.96.0.0/16', protocol='tcp'
+- node='ovn-worker', pod_namespace='default', pod_name='client', state='established'
+
+Example output:
+{
+  "sockets": [
+    {
+      "protocol": "tcp", "state": "ESTAB", "recv_q": 0, "send_q": 36,
+      "local": {"address": "10.244.1.3", "port": 45678}, "peer": {"address": "10.96.0.1", "port": 443},
+      "processes": [{"name": "kube-proxy", "pid": 99, "fd": 3}], "timer": "on,204ms,0", "uid": 0, "inode": 40002,
+      "tcp_info": {"options": ["ts", "sack", "wscale:7,7"], "congestion": "cubic", "rtt": 1.5, "rtt_var": 0.75, "rto": 204, "mss": 1398,
+                   "cwnd": 4, "ssthresh": 7, "retransmits": 1, "total_retransmits": 2, "bytes_sent": 20480, "bytes_acked": 17684}
+    }
+  ]
+}
+`, DefaultMaxOutputLines, int(timeout.MaxTimeout.Seconds())),
+		}, s.GetSockets)
+	// node-network-snapshot tool registration
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "node-network-snapshot",
+			Description: fmt.Sprintf(`node-network-snapshot collects the read-only network state of a Kubernetes node in a single debug pod session:
+			              addresses, links, routes, rules and neighbours (ip -j), the nftables ruleset, iptables-save and ip6tables-save,
+			              the conntrack usage, the sysctls OVN-Kubernetes depends on, and the OVS bridges and bridge mappings.
+			              Use this command as the first step of node triage instead of running get-ip, get-nft, get-iptables,
+			              get-conntrack, get-sysctl and OVS commands one by one.
+			              Requires a shell and the 'ip', 'nft', 'iptables-save' and 'sysctl' utilities in the configured image.
+			              OVS is read with 'ovs-vsctl' of the image on the node database socket, or with 'ovs-vsctl' of the node.
+Parameters:
+- node (required): Name of the node where the snapshot is collected
+- namespace (optional): Namespace of the debug pod. Default: 'default'
+- sections (optional): Comma-separated list of sections to collect. Default: all
+                       ip        : Addresses, links, routes and rules of both families (all tables), and neighbours
+                       nft       : nftables ruleset
+                       iptables  : iptables-save and ip6tables-save output
+                       conntrack : Number of conntrack entries and the maximum (nf_conntrack_count, nf_conntrack_max)
+                       sysctl    : Sysctls checked against the OVN-Kubernetes baseline, as by get-sysctl
+                       ovs       : Bridges, ports and interfaces (ovs-vsctl show) and the ovn-bridge-mappings external ID
+- head (optional): Return only first N objects of each list: addresses, links, routes and rules of each family, neighbours,
+                   nft rules and set elements, iptables lines of each family and ports of each bridge. Default: %d if tail is not specified
+- tail (optional): Return only last N objects of each list
+- apply_tail_first (optional): If both head and tail are set and apply_tail_first is true,
+apply tail before head. Default: false
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+Commands that fail, e.g. because a utility is missing or OVS is not running on the node, are returned in errors by command:
+addresses, links, routes, routes6, rules, rules6, neighbours, nft, iptables, ip6tables, conntrack, sysctl, ovs_show or
+ovs_bridge_mappings. The other sections are still returned. Per-interface sysctls are checked for br-ex, breth0 and ovn-k8s-mp0.
+
+Example:
+- node='ovn-worker'
+- node='ovn-worker', sections='ip,ovs'
+
+Example output:
+{
+  "node": "ovn-worker",
+  "ip": {
+    "routes": [{"dst": "default", "gateway": "172.18.0.1", "dev": "breth0", "protocol": "static", "table": "main"}],
+    "addresses": [{"index": 6, "dev": "breth0", "family": "inet", "address": "172.18.0.3", "prefixlen": 16, "scope": "global"}]
+  },
+  "iptables": {"families": [{"family": "ipv4", "command": "iptables-save", "data": "*nat\n:PREROUTING ACCEPT [0:0]\n..."}]},
+  "conntrack": {"count": 1234, "max": 262144},
+  "sysctl": {"netns": "node ovn-worker", "deviations": [], "checks": [{"key": "net.ipv4.ip_forward", "value": "1", "expected": "1", "status": "ok"}]},
+  "ovs": {
+    "version": "3.1.2",
+    "bridges": [{"name": "breth0", "fail_mode": "standalone", "ports": [{"name": "eth0", "interfaces": [{"name": "eth0"}]}]}],
+    "bridge_mappings": {"physnet": "breth0"}
+  },
+  "errors": {"nft": "sh: nft: not found"}
+}
+`, DefaultMaxOutputLines, int(timeout.MaxTimeout.Seconds())),
+		}, s.NodeNetworkSnapshot)
 }
 
 // executeCommand executes a command on a node via kubectl debug
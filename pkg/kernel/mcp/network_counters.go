@@ -0,0 +1,347 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/headtail"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/timeout"
+)
+
+// DefaultNetworkCountersInterval is the number of seconds between the two snapshots when no interval is given.
+const DefaultNetworkCountersInterval = 5
+
+const (
+	counterSourceUptime  = "uptime"
+	counterSourceLink    = "link"
+	counterSourceSNMP    = "snmp"
+	counterSourceNetstat = "netstat"
+	counterSourceSoftnet = "softnet"
+	counterSourceNstat   = "nstat"
+)
+
+// networkCountersSnapshot prints the counters of every source, each in a section named after it.
+// The uptime section starts a snapshot and timestamps it. nstat is optional as it is not part of
+// every image; it adds the IPv6 counters of /proc/net/snmp6.
+const networkCountersSnapshot = `echo "### uptime"; cat /proc/uptime; ` +
+	`echo "### link"; ip -s -s -j link show; ` +
+	`echo "### snmp"; cat /proc/net/snmp; ` +
+	`echo "### netstat"; cat /proc/net/netstat; ` +
+	`echo "### softnet"; cat /proc/net/softnet_stat; ` +
+	`if command -v nstat >/dev/null 2>&1; then echo "### nstat"; nstat -a -s -z; fi`
+
+// dropCounterName matches the names of the counters that report drops, errors or packet loss.
+var dropCounterName = regexp.MustCompile(`(?i)(drop|err|discard|overflow|overrun|prune|collapse|squeeze|miss|fail|abort|nohandler|flow_limit|noroute|retrans|lost|collision|carrier)`)
+
+// snmpGauges are the /proc/net/snmp values that are settings or gauges rather than counters.
+var snmpGauges = map[string]bool{
+	"IpForwarding":    true,
+	"IpDefaultTTL":    true,
+	"TcpRtoAlgorithm": true,
+	"TcpRtoMin":       true,
+	"TcpRtoMax":       true,
+	"TcpMaxConn":      true,
+	"TcpCurrEstab":    true,
+}
+
+// softnetColumns names the /proc/net/softnet_stat columns that are reported. perCPU columns are
+// reported per CPU in addition to their total.
+var softnetColumns = []struct {
+	index  int
+	name   string
+	perCPU bool
+}{
+	{index: 0, name: "processed"},
+	{index: 1, name: "dropped", perCPU: true},
+	{index: 2, name: "time_squeeze", perCPU: true},
+	{index: 9, name: "received_rps"},
+	{index: 10, name: "flow_limit_count", perCPU: true},
+}
+
+// softnetCPUColumn is the column holding the CPU number in kernels that print it. Older kernels
+// print one line per online CPU without its number.
+const softnetCPUColumn = 12
+
+// counterKey identifies a counter in a snapshot.
+type counterKey struct {
+	source string
+	object string
+	name   string
+}
+
+// counterSnapshot is the value of every counter at one moment.
+type counterSnapshot struct {
+	uptime   float64
+	counters map[counterKey]uint64
+}
+
+// GetNetworkCounters takes two snapshots of the interface, SNMP, netstat, softnet and nstat counters
+// of a node, interval seconds apart, and reports the counters that changed with their rates. Drop
+// and error counters are reported separately so that they stand out.
+func (s *MCPServer) GetNetworkCounters(ctx context.Context, req *mcp.CallToolRequest, in types.NetworkCountersParams) (*mcp.CallToolResult, types.NetworkCountersResult, error) {
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	// The second snapshot is taken before the call times out, leaving time to start the debug pod,
+	// so that the counters are returned.
+	interval, err := timeout.ValidateDuration(ctx, "interval_seconds", in.IntervalSeconds, DefaultNetworkCountersInterval, timeout.SetupTime)
+	if err != nil {
+		return nil, types.NetworkCountersResult{}, fmt.Errorf("error while getting network counters: %w", err)
+	}
+	interfaces, err := parseNetworkCountersInterfaces(in.Interfaces)
+	if err != nil {
+		return nil, types.NetworkCountersResult{}, fmt.Errorf("error while getting network counters: %w", err)
+	}
+
+	if err := s.utilityExists(ctx, in.Namespace, in.Node, "ip"); err != nil {
+		return nil, types.NetworkCountersResult{}, fmt.Errorf("error while getting network counters: failed to verify ip utility availability in configured image: %w", err)
+	}
+
+	// Both snapshots are taken by the same command so that they are exactly interval seconds apart.
+	script := networkCountersSnapshot + "; sleep " + strconv.Itoa(interval) + "; " + networkCountersSnapshot
+	cmd := commandbuilder.NewCommand("sh", "-c", script)
+	stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+	if err != nil {
+		return nil, types.NetworkCountersResult{}, fmt.Errorf("error while getting network counters: %w", err)
+	}
+	if stderr != "" {
+		return nil, types.NetworkCountersResult{}, fmt.Errorf("error while running command: %s", stderr)
+	}
+
+	snapshots, err := parseCounterSnapshots(stdout)
+	if err != nil {
+		return nil, types.NetworkCountersResult{}, fmt.Errorf("error while getting network counters: %w", err)
+	}
+	if len(snapshots) != 2 {
+		return nil, types.NetworkCountersResult{}, fmt.Errorf("error while getting network counters: expected 2 snapshots, got %d", len(snapshots))
+	}
+
+	result := diffCounterSnapshots(snapshots[0], snapshots[1], interfaces, in.DropsOnly)
+	// Apply the head and tail parameters to both lists
+	result.Drops = headtail.ApplyTo(&in.HeadTailParams, result.Drops, DefaultMaxOutputLines)
+	result.Counters = headtail.ApplyTo(&in.HeadTailParams, result.Counters, DefaultMaxOutputLines)
+	return nil, result, nil
+}
+
+// parseNetworkCountersInterfaces parses the comma-separated interfaces the link counters are limited to.
+func parseNetworkCountersInterfaces(interfaces string) (map[string]bool, error) {
+	selected := map[string]bool{}
+	for _, iface := range strings.Split(interfaces, ",") {
+		if iface = strings.TrimSpace(iface); iface == "" {
+			continue
+		}
+		if !validIPDeviceName.MatchString(iface) {
+			return nil, fmt.Errorf("invalid interface %q: must be a network device name", iface)
+		}
+		selected[iface] = true
+	}
+	return selected, nil
+}
+
+// parseCounterSnapshots parses the output of the snapshot script into one snapshot per uptime section.
+func parseCounterSnapshots(output string) ([]counterSnapshot, error) {
+	var snapshots []counterSnapshot
+	for _, section := range splitOutputSections(output) {
+		if section.name == counterSourceUptime {
+			fields := strings.Fields(strings.Join(section.lines, " "))
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("empty /proc/uptime output")
+			}
+			uptime, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid /proc/uptime output %q: %w", fields[0], err)
+			}
+			snapshots = append(snapshots, counterSnapshot{uptime: uptime, counters: map[counterKey]uint64{}})
+			continue
+		}
+		if len(snapshots) == 0 {
+			return nil, fmt.Errorf("%s counters before the first snapshot", section.name)
+		}
+		counters := snapshots[len(snapshots)-1].counters
+		var err error
+		switch section.name {
+		case counterSourceLink:
+			err = parseLinkCounters(strings.Join(section.lines, "\n"), counters)
+		case counterSourceSNMP, counterSourceNetstat:
+			err = parseSNMPCounters(section.name, section.lines, counters)
+		case counterSourceSoftnet:
+			err = parseSoftnetCounters(section.lines, counters)
+		case counterSourceNstat:
+			parseNstatCounters(section.lines, counters)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return snapshots, nil
+}
+
+// parseLinkCounters parses the interface statistics of 'ip -s -s -j link show'. The rx and tx
+// statistics are named after their direction, e.g. rx_dropped.
+func parseLinkCounters(output string, counters map[counterKey]uint64) error {
+	var links []struct {
+		Ifname  string                                `json:"ifname"`
+		Stats64 map[string]map[string]json.RawMessage `json:"stats64"`
+	}
+	if err := unmarshalIPJSON(output, &links); err != nil {
+		return err
+	}
+	for _, link := range links {
+		for direction, stats := range link.Stats64 {
+			for name, raw := range stats {
+				var value uint64
+				if err := json.Unmarshal(raw, &value); err != nil {
+					continue
+				}
+				counters[counterKey{source: counterSourceLink, object: link.Ifname, name: direction + "_" + name}] = value
+			}
+		}
+	}
+	return nil
+}
+
+// parseSNMPCounters parses /proc/net/snmp and /proc/net/netstat, where each protocol has a line of
+// counter names followed by a line of values, e.g. 'Tcp: ActiveOpens ...' and 'Tcp: 12 ...'.
+// Counters are named like nstat names them, e.g. TcpActiveOpens.
+func parseSNMPCounters(source string, lines []string, counters map[counterKey]uint64) error {
+	for i := 0; i+1 < len(lines); i += 2 {
+		protocol, names, ok := strings.Cut(lines[i], ":")
+		valueProtocol, values, _ := strings.Cut(lines[i+1], ":")
+		if !ok || protocol != valueProtocol {
+			return fmt.Errorf("invalid %s output: unmatched lines %q and %q", source, lines[i], lines[i+1])
+		}
+		nameFields, valueFields := strings.Fields(names), strings.Fields(values)
+		if len(nameFields) != len(valueFields) {
+			return fmt.Errorf("invalid %s output: %d names and %d values for %s", source, len(nameFields), len(valueFields), protocol)
+		}
+		for j, name := range nameFields {
+			name = protocol + name
+			value, err := strconv.ParseUint(valueFields[j], 10, 64)
+			if err != nil || snmpGauges[name] {
+				continue
+			}
+			counters[counterKey{source: source, name: name}] = value
+		}
+	}
+	return nil
+}
+
+// parseSoftnetCounters parses /proc/net/softnet_stat, which has a line of hexadecimal counters per CPU.
+func parseSoftnetCounters(lines []string, counters map[counterKey]uint64) error {
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		cpu := strconv.Itoa(i)
+		if len(fields) > softnetCPUColumn {
+			value, err := strconv.ParseUint(fields[softnetCPUColumn], 16, 64)
+			if err != nil {
+				return fmt.Errorf("invalid softnet_stat line %q: %w", line, err)
+			}
+			cpu = strconv.FormatUint(value, 10)
+		}
+		for _, column := range softnetColumns {
+			if column.index >= len(fields) {
+				continue
+			}
+			value, err := strconv.ParseUint(fields[column.index], 16, 64)
+			if err != nil {
+				return fmt.Errorf("invalid softnet_stat line %q: %w", line, err)
+			}
+			counters[counterKey{source: counterSourceSoftnet, name: column.name}] += value
+			if column.perCPU {
+				counters[counterKey{source: counterSourceSoftnet, object: "cpu" + cpu, name: column.name}] = value
+			}
+		}
+	}
+	return nil
+}
+
+// parseNstatCounters parses 'nstat -a -s -z' output. Counters already read from /proc/net/snmp or
+// /proc/net/netstat are skipped, which leaves the IPv6 and SCTP counters.
+func parseNstatCounters(lines []string, counters map[counterKey]uint64) {
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil || snmpGauges[fields[0]] {
+			continue
+		}
+		_, inSNMP := counters[counterKey{source: counterSourceSNMP, name: fields[0]}]
+		_, inNetstat := counters[counterKey{source: counterSourceNetstat, name: fields[0]}]
+		if inSNMP || inNetstat {
+			continue
+		}
+		counters[counterKey{source: counterSourceNstat, name: fields[0]}] = value
+	}
+}
+
+// diffCounterSnapshots returns the counters that changed between the two snapshots, split into
+// drop and error counters and other counters, each sorted by decreasing rate. Link counters are
+// limited to the given interfaces, if any.
+func diffCounterSnapshots(before, after counterSnapshot, interfaces map[string]bool, dropsOnly bool) types.NetworkCountersResult {
+	interval := after.uptime - before.uptime
+	result := types.NetworkCountersResult{
+		IntervalSeconds: math.Round(interval*100) / 100,
+		Drops:           []types.CounterDelta{},
+		Counters:        []types.CounterDelta{},
+	}
+	for key, afterValue := range after.counters {
+		beforeValue, ok := before.counters[key]
+		if !ok || beforeValue == afterValue {
+			continue
+		}
+		if key.source == counterSourceLink && len(interfaces) > 0 && !interfaces[key.object] {
+			continue
+		}
+		drop := dropCounterName.MatchString(key.name)
+		if dropsOnly && !drop {
+			continue
+		}
+		delta := types.CounterDelta{
+			Source: key.source,
+			Object: key.object,
+			Name:   key.name,
+			Before: beforeValue,
+			After:  afterValue,
+			Delta:  int64(afterValue - beforeValue),
+		}
+		if interval > 0 {
+			delta.Rate = math.Round(float64(delta.Delta)/interval*100) / 100
+		}
+		if drop {
+			result.Drops = append(result.Drops, delta)
+		} else {
+			result.Counters = append(result.Counters, delta)
+		}
+	}
+	sortCounterDeltas(result.Drops)
+	sortCounterDeltas(result.Counters)
+	return result
+}
+
+// sortCounterDeltas sorts the counters by decreasing rate and then by source, object and name.
+func sortCounterDeltas(deltas []types.CounterDelta) {
+	slices.SortFunc(deltas, func(a, b types.CounterDelta) int {
+		return cmp.Or(
+			cmp.Compare(math.Abs(float64(b.Delta)), math.Abs(float64(a.Delta))),
+			cmp.Compare(a.Source, b.Source),
+			cmp.Compare(a.Object, b.Object),
+			cmp.Compare(a.Name, b.Name),
+		)
+	})
+}
@@ -0,0 +1,241 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+// networkCountersSnapshotOutput returns the output of one snapshot of the snapshot script.
+func networkCountersSnapshotOutput(uptime, rxPackets, rxDropped, listenDrops, inReceives, softnetDropped, ip6InDiscards string) string {
+	return `### uptime
+` + uptime + ` 4000.00
+### link
+[{"ifindex":1,"ifname":"lo","stats64":{"rx":{"bytes":100,"packets":10,"errors":0,"dropped":0},"tx":{"bytes":100,"packets":10,"errors":0,"dropped":0}}},` +
+		`{"ifindex":2,"ifname":"breth0","stats64":{"rx":{"bytes":5000,"packets":` + rxPackets + `,"errors":0,"dropped":` + rxDropped + `},"tx":{"bytes":4000,"packets":40,"errors":0,"dropped":0}}}]
+### snmp
+Ip: Forwarding DefaultTTL InReceives InDiscards
+Ip: 1 64 ` + inReceives + ` 0
+Tcp: RtoAlgorithm MaxConn CurrEstab
+Tcp: 1 -1 ` + rxPackets + `
+### netstat
+TcpExt: SyncookiesSent ListenDrops
+TcpExt: 0 ` + listenDrops + `
+### softnet
+0000a000 ` + softnetDropped + ` 00000002 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000
+0000b000 00000000 00000001 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000000 00000001
+### nstat
+#kernel
+IpInReceives                    ` + inReceives + `                0.0
+Ip6InDiscards                   ` + ip6InDiscards + `                0.0
+`
+}
+
+func TestParseCounterSnapshots(t *testing.T) {
+	snapshots, err := parseCounterSnapshots(networkCountersSnapshotOutput("100.00", "50", "1", "7", "1000", "00000003", "2"))
+	if err != nil {
+		t.Fatalf("parseCounterSnapshots() error = %v", err)
+	}
+	if len(snapshots) != 1 {
+		t.Fatalf("parseCounterSnapshots() returned %d snapshots, want 1", len(snapshots))
+	}
+	if snapshots[0].uptime != 100 {
+		t.Errorf("uptime = %v, want 100", snapshots[0].uptime)
+	}
+	counters := snapshots[0].counters
+	tests := []struct {
+		key     counterKey
+		want    uint64
+		present bool
+	}{
+		{key: counterKey{source: counterSourceLink, object: "breth0", name: "rx_dropped"}, want: 1, present: true},
+		{key: counterKey{source: counterSourceLink, object: "lo", name: "tx_packets"}, want: 10, present: true},
+		{key: counterKey{source: counterSourceSNMP, name: "IpInReceives"}, want: 1000, present: true},
+		{key: counterKey{source: counterSourceSNMP, name: "IpDefaultTTL"}},
+		{key: counterKey{source: counterSourceSNMP, name: "TcpMaxConn"}},
+		{key: counterKey{source: counterSourceSNMP, name: "TcpCurrEstab"}},
+		{key: counterKey{source: counterSourceNetstat, name: "TcpExtListenDrops"}, want: 7, present: true},
+		{key: counterKey{source: counterSourceSoftnet, name: "processed"}, want: 0xa000 + 0xb000, present: true},
+		{key: counterKey{source: counterSourceSoftnet, name: "time_squeeze"}, want: 3, present: true},
+		{key: counterKey{source: counterSourceSoftnet, object: "cpu0", name: "dropped"}, want: 3, present: true},
+		{key: counterKey{source: counterSourceSoftnet, object: "cpu1", name: "time_squeeze"}, want: 1, present: true},
+		{key: counterKey{source: counterSourceSoftnet, object: "cpu0", name: "processed"}},
+		{key: counterKey{source: counterSourceNstat, name: "IpInReceives"}},
+		{key: counterKey{source: counterSourceNstat, name: "Ip6InDiscards"}, want: 2, present: true},
+	}
+	for _, tt := range tests {
+		got, ok := counters[tt.key]
+		if ok != tt.present || got != tt.want {
+			t.Errorf("counter %+v = %d (present %v), want %d (present %v)", tt.key, got, ok, tt.want, tt.present)
+		}
+	}
+}
+
+func TestParseCounterSnapshotsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{
+			name:   "counters before uptime",
+			output: "### snmp\nIp: InReceives\nIp: 1\n",
+		},
+		{
+			name:   "invalid uptime",
+			output: "### uptime\nabc 1.00\n",
+		},
+		{
+			name:   "unmatched snmp lines",
+			output: "### uptime\n1.00 1.00\n### snmp\nIp: InReceives\nTcp: 1\n",
+		},
+		{
+			name:   "invalid softnet line",
+			output: "### uptime\n1.00 1.00\n### softnet\nzz 00000000\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseCounterSnapshots(tt.output); err == nil {
+				t.Errorf("parseCounterSnapshots() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestDiffCounterSnapshots(t *testing.T) {
+	before := counterSnapshot{uptime: 100, counters: map[counterKey]uint64{
+		{source: counterSourceLink, object: "breth0", name: "rx_packets"}: 100,
+		{source: counterSourceLink, object: "breth0", name: "rx_dropped"}: 1,
+		{source: counterSourceLink, object: "eth0", name: "rx_packets"}:   10,
+		{source: counterSourceLink, object: "veth1", name: "tx_packets"}:  500,
+		{source: counterSourceNetstat, name: "TcpExtListenDrops"}:         7,
+		{source: counterSourceSNMP, name: "IpInReceives"}:                 1000,
+	}}
+	after := counterSnapshot{uptime: 104, counters: map[counterKey]uint64{
+		{source: counterSourceLink, object: "breth0", name: "rx_packets"}: 300,
+		{source: counterSourceLink, object: "breth0", name: "rx_dropped"}: 5,
+		{source: counterSourceLink, object: "eth0", name: "rx_packets"}:   10,
+		{source: counterSourceLink, object: "veth1", name: "tx_packets"}:  20,
+		{source: counterSourceLink, object: "veth2", name: "tx_packets"}:  20,
+		{source: counterSourceNetstat, name: "TcpExtListenDrops"}:         47,
+		{source: counterSourceSNMP, name: "IpInReceives"}:                 3000,
+	}}
+
+	tests := []struct {
+		name       string
+		interfaces map[string]bool
+		dropsOnly  bool
+		want       types.NetworkCountersResult
+	}{
+		{
+			name: "all counters",
+			want: types.NetworkCountersResult{
+				IntervalSeconds: 4,
+				Drops: []types.CounterDelta{
+					{Source: counterSourceNetstat, Name: "TcpExtListenDrops", Before: 7, After: 47, Delta: 40, Rate: 10},
+					{Source: counterSourceLink, Object: "breth0", Name: "rx_dropped", Before: 1, After: 5, Delta: 4, Rate: 1},
+				},
+				Counters: []types.CounterDelta{
+					{Source: counterSourceSNMP, Name: "IpInReceives", Before: 1000, After: 3000, Delta: 2000, Rate: 500},
+					{Source: counterSourceLink, Object: "veth1", Name: "tx_packets", Before: 500, After: 20, Delta: -480, Rate: -120},
+					{Source: counterSourceLink, Object: "breth0", Name: "rx_packets", Before: 100, After: 300, Delta: 200, Rate: 50},
+				},
+			},
+		},
+		{
+			name:       "selected interfaces and drops only",
+			interfaces: map[string]bool{"breth0": true},
+			dropsOnly:  true,
+			want: types.NetworkCountersResult{
+				IntervalSeconds: 4,
+				Drops: []types.CounterDelta{
+					{Source: counterSourceNetstat, Name: "TcpExtListenDrops", Before: 7, After: 47, Delta: 40, Rate: 10},
+					{Source: counterSourceLink, Object: "breth0", Name: "rx_dropped", Before: 1, After: 5, Delta: 4, Rate: 1},
+				},
+				Counters: []types.CounterDelta{},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffCounterSnapshots(before, after, tt.interfaces, tt.dropsOnly)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("diffCounterSnapshots() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetNetworkCounters(t *testing.T) {
+	var commands []string
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		command := strings.Join(cmd, " ")
+		commands = append(commands, command)
+		switch {
+		case command == "ip -V":
+			return "ip utility, iproute2-6.1.0", "", nil
+		case strings.HasPrefix(command, "sh -c"):
+			return networkCountersSnapshotOutput("100.00", "50", "1", "7", "1000", "00000003", "2") +
+				networkCountersSnapshotOutput("102.00", "90", "5", "7", "1200", "00000003", "6"), "", nil
+		}
+		t.Fatalf("unexpected command %q", command)
+		return "", "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.GetNetworkCounters(context.Background(), nil, types.NetworkCountersParams{
+		CommonParams:    types.CommonParams{Node: "ovn-worker"},
+		IntervalSeconds: 2,
+	})
+	if err != nil {
+		t.Fatalf("GetNetworkCounters() error = %v", err)
+	}
+	if !strings.Contains(commands[1], "; sleep 2; ") {
+		t.Errorf("command %q does not sleep for the interval", commands[1])
+	}
+	want := types.NetworkCountersResult{
+		IntervalSeconds: 2,
+		Drops: []types.CounterDelta{
+			{Source: counterSourceLink, Object: "breth0", Name: "rx_dropped", Before: 1, After: 5, Delta: 4, Rate: 2},
+			{Source: counterSourceNstat, Name: "Ip6InDiscards", Before: 2, After: 6, Delta: 4, Rate: 2},
+		},
+		Counters: []types.CounterDelta{
+			{Source: counterSourceSNMP, Name: "IpInReceives", Before: 1000, After: 1200, Delta: 200, Rate: 100},
+			{Source: counterSourceLink, Object: "breth0", Name: "rx_packets", Before: 50, After: 90, Delta: 40, Rate: 20},
+		},
+	}
+	if diff := cmp.Diff(want, result); diff != "" {
+		t.Errorf("GetNetworkCounters() mismatch (-want +got):\n%s", diff)
+	}
+
+	// The interval must end before the tool timeout of the server, leaving time to start the debug pod.
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+	for _, interval := range []int{100, 200} {
+		_, _, err = server.GetNetworkCounters(ctx, nil, types.NetworkCountersParams{
+			CommonParams:    types.CommonParams{Node: "ovn-worker"},
+			IntervalSeconds: interval,
+		})
+		if err == nil || !strings.Contains(err.Error(), "seconds left before the call times out") {
+			t.Errorf("GetNetworkCounters() error = %v, want an error for an interval of %d seconds with a tool timeout of 120 seconds", err, interval)
+		}
+	}
+
+	_, _, err = server.GetNetworkCounters(context.Background(), nil, types.NetworkCountersParams{
+		CommonParams: types.CommonParams{Node: "ovn-worker"},
+		Interfaces:   "eth0;reboot",
+	})
+	if err == nil {
+		t.Errorf("GetNetworkCounters() error = nil, want error for an invalid interface")
+	}
+}
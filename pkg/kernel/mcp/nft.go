@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -10,11 +11,13 @@ import (
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/headtail"
 )
 
 // GetNFT MCP handler for nftables operations.
 // GetNFT retrieves nftables configuration from a Kubernetes node.
-func (s *MCPServer) GetNFT(ctx context.Context, req *mcp.CallToolRequest, in types.ListNFTParams) (*mcp.CallToolResult, types.Result, error) {
+// The ruleset is listed with 'nft -j' and returned as typed tables, chains, rules, sets, maps and flowtables.
+func (s *MCPServer) GetNFT(ctx context.Context, req *mcp.CallToolRequest, in types.ListNFTParams) (*mcp.CallToolResult, types.NFTResult, error) {
 	// If timeout is specified, create a new context with timeout
 	var cancel context.CancelFunc
 	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
@@ -24,41 +27,111 @@ func (s *MCPServer) GetNFT(ctx context.Context, req *mcp.CallToolRequest, in typ
 
 	err := s.utilityExists(ctx, in.Namespace, in.Node, "nft")
 	if err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting nft data: failed to verify nft utility availability in configured image: %w", err)
+		return nil, types.NFTResult{}, fmt.Errorf("error while getting nft data: failed to verify nft utility availability in configured image: %w", err)
 	}
 
 	if err := validateNFTCommand(in.Command); err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting nft data: %w", err)
+		return nil, types.NFTResult{}, fmt.Errorf("error while getting nft data: %w", err)
 	}
 	if err := utils.ValidateSafeString(in.AddressFamilies, "address families", true, utils.ShellMetaCharactersTypeDefault); err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting nft data: %w", err)
+		return nil, types.NFTResult{}, fmt.Errorf("error while getting nft data: %w", err)
 	}
 	if err := validateNFTAddressFamily(in.AddressFamilies); err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting nft data: %w", err)
+		return nil, types.NFTResult{}, fmt.Errorf("error while getting nft data: %w", err)
 	}
-
 	command := strings.TrimSpace(in.Command)
+	if err := validateNFTSelection(command, in.Table, in.Name, in.Element); err != nil {
+		return nil, types.NFTResult{}, fmt.Errorf("error while getting nft data: %w", err)
+	}
+	tableFamilies, err := nftTableFamilies(in.IPFamily, in.AddressFamilies)
+	if err != nil {
+		return nil, types.NFTResult{}, fmt.Errorf("error while getting nft data: %w", err)
+	}
+	if tableFamilies != nil && nftSingleObjectCommands[command] {
+		return nil, types.NFTResult{}, fmt.Errorf("error while getting nft data: ip_family cannot be used with '%s', use address_families to select the family of the table", command)
+	}
+	if err := validatePodTarget(in.PodTargetParams); err != nil {
+		return nil, types.NFTResult{}, fmt.Errorf("error while getting nft data: %w", err)
+	}
+	prefix, netns, err := s.podNetnsPrefix(ctx, in.Namespace, in.Node, in.PodTargetParams)
+	if err != nil {
+		return nil, types.NFTResult{}, fmt.Errorf("error while getting nft data: %w", err)
+	}
+
 	addressFamilies := strings.TrimSpace(in.AddressFamilies)
-	cmd := commandbuilder.NewCommand("nft")
+	cmd := commandbuilder.NewCommand(prefix...)
+	cmd.Add("nft", "-j")
 	cmd.Add(strings.Fields(command)...)
 	cmd.AddIf(addressFamilies != "", addressFamilies)
+	// Single objects are selected by nft, the plural listings are filtered by table below.
+	if nftSingleObjectCommands[command] {
+		cmd.Add(in.Table)
+		cmd.AddIfNotEmpty(in.Name, in.Name)
+	}
 
 	stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
 	if err != nil {
-		return nil, types.Result{}, fmt.Errorf("error while getting nft data: %w", err)
+		return nil, types.NFTResult{}, fmt.Errorf("error while getting nft data: %w", err)
 	}
 
 	if stderr != "" {
-		return nil, types.Result{}, fmt.Errorf("error while running command: %s", stderr)
+		return nil, types.NFTResult{}, fmt.Errorf("error while running command: %s", stderr)
 	}
 
-	// Strip empty lines from the output
-	lines := utils.StripEmptyLines(strings.Split(stdout, "\n"))
-	// Apply the head and tail parameters to the lines
-	lines = in.HeadTailParams.Apply(lines, DefaultMaxOutputLines)
-	// Join the lines back into a single string
-	stdout = strings.Join(lines, "\n")
-	return nil, types.Result{Data: stdout}, nil
+	ruleset, err := parseNFTRuleset(stdout)
+	if err != nil {
+		return nil, types.NFTResult{}, fmt.Errorf("error while getting nft data: %w", err)
+	}
+	if tableFamilies != nil {
+		ruleset = ruleset.filterFamilies(tableFamilies)
+	}
+	if in.Table != "" {
+		ruleset = ruleset.filterTable(in.Table)
+	}
+	result := newNFTResult(ruleset, in.Element, in.HeadTailParams)
+	result.Netns = netns
+	return nil, result, nil
+}
+
+// newNFTResult converts the ruleset into the tool result. If element is set, only the set and map
+// elements containing it are returned. The head and tail parameters are applied to the rules and
+// to the elements of each set and map.
+func newNFTResult(ruleset nftRuleset, element string, headTailParams headtail.HeadTailParams) types.NFTResult {
+	result := types.NFTResult{
+		Tables:     ruleset.tables,
+		Chains:     ruleset.chains,
+		Flowtables: ruleset.flowtables,
+	}
+	for _, rule := range ruleset.rules {
+		result.Rules = append(result.Rules, rule.NFTRule)
+	}
+	result.Rules = headtail.ApplyTo(&headTailParams, result.Rules, DefaultMaxOutputLines)
+	for _, set := range ruleset.sets {
+		if element != "" {
+			var elements []types.NFTElement
+			for i, e := range set.elem {
+				if key, _ := nftElementKeyValue(e, set.isMap); nftElementContains(key, element) {
+					elements = append(elements, set.Elements[i])
+				}
+			}
+			set.Elements = elements
+		}
+		set.Elements = headtail.ApplyTo(&headTailParams, set.Elements, DefaultMaxOutputLines)
+		if set.isMap {
+			result.Maps = append(result.Maps, set.NFTSet)
+		} else {
+			result.Sets = append(result.Sets, set.NFTSet)
+		}
+	}
+	return result
+}
+
+// nftSingleObjectCommands are the commands listing a single table, chain, set or map.
+var nftSingleObjectCommands = map[string]bool{
+	"list table": true,
+	"list chain": true,
+	"list set":   true,
+	"list map":   true,
 }
 
 // validateNFTCommand validates nftables command. This is to put a limitation on the use of the tool.
@@ -75,12 +148,74 @@ func validateNFTCommand(command string) error {
 		"list flowtables": true,
 	}
 
-	if !validCommand[strings.TrimSpace(command)] {
+	if !validCommand[strings.TrimSpace(command)] && !nftSingleObjectCommands[strings.TrimSpace(command)] {
 		return fmt.Errorf("invalid nft command: %s", command)
 	}
 	return nil
 }
 
+// validNFTName matches nftables table, chain, set and map names.
+var validNFTName = regexp.MustCompile(`^[a-zA-Z_.][a-zA-Z0-9_./-]{0,255}$`)
+
+// validNFTElement matches set and map element values in nft syntax, e.g. "10.96.0.10 . udp . 53".
+var validNFTElement = regexp.MustCompile(`^[a-zA-Z0-9_.:/ -]{1,256}$`)
+
+// validateNFTName validates a table, chain, set or map name.
+func validateNFTName(name, field string) error {
+	if name != "" && !validNFTName.MatchString(name) {
+		return fmt.Errorf("invalid nft %s name: %s", field, name)
+	}
+	return nil
+}
+
+// validateNFTSelection validates the table, name and element selection for the command.
+func validateNFTSelection(command, table, name, element string) error {
+	if err := validateNFTName(table, "table"); err != nil {
+		return err
+	}
+	if err := validateNFTName(name, "object"); err != nil {
+		return err
+	}
+	if element != "" && !validNFTElement.MatchString(element) {
+		return fmt.Errorf("invalid nft element: %s", element)
+	}
+	if nftSingleObjectCommands[command] && table == "" {
+		return fmt.Errorf("table is required with '%s'", command)
+	}
+	if name == "" && command != "list table" && nftSingleObjectCommands[command] {
+		return fmt.Errorf("name is required with '%s'", command)
+	}
+	if name != "" && (command == "list table" || !nftSingleObjectCommands[command]) {
+		return fmt.Errorf("name can only be used with 'list chain', 'list set' and 'list map'")
+	}
+	if element != "" && command != "list set" && command != "list map" {
+		return fmt.Errorf("element can only be used with 'list set' and 'list map'")
+	}
+	return nil
+}
+
+// nftTableFamilies returns the address families of the tables holding the rules of the IP families
+// selected by ip_family: ip for IPv4, ip6 for IPv6, and inet for both. It returns nil if ip_family
+// is not set. ip_family and address_families cannot be used together.
+func nftTableFamilies(ipFamily, addressFamilies string) ([]string, error) {
+	families, err := parseIPFamily(ipFamily)
+	if err != nil || families == nil {
+		return nil, err
+	}
+	if strings.TrimSpace(addressFamilies) != "" {
+		return nil, fmt.Errorf("ip_family and address_families cannot be used together")
+	}
+	var tableFamilies []string
+	for _, family := range families {
+		if family == ipFamilyIPv4 {
+			tableFamilies = append(tableFamilies, "ip")
+		} else {
+			tableFamilies = append(tableFamilies, "ip6")
+		}
+	}
+	return append(tableFamilies, "inet"), nil
+}
+
 // validateNFTAddressFamily validates nftables address family.
 func validateNFTAddressFamily(addrFamily string) error {
 	if addrFamily == "" {
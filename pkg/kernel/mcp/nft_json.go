@@ -0,0 +1,386 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+// nftRuleset is the parsed output of 'nft -j list ...'. Rules and sets keep their JSON
+// expressions and elements so that they can be searched by value.
+type nftRuleset struct {
+	tables     []types.NFTTable
+	chains     []types.NFTChain
+	rules      []nftRule
+	sets       []nftSet
+	flowtables []types.NFTFlowtable
+}
+
+// nftRule is a parsed rule together with its JSON expressions.
+type nftRule struct {
+	types.NFTRule
+	expr []any
+}
+
+// nftSet is a parsed set or map together with its JSON elements and the types of the element
+// (or map key) and map value components.
+type nftSet struct {
+	types.NFTSet
+	isMap      bool
+	keyTypes   []string
+	valueTypes []string
+	elem       []any
+}
+
+// nftObjectJSON is an object of the 'nftables' array printed by 'nft -j'. Only one field is set.
+type nftObjectJSON struct {
+	Table *struct {
+		Family string `json:"family"`
+		Name   string `json:"name"`
+		Handle int    `json:"handle"`
+	} `json:"table"`
+	Chain *struct {
+		Family string `json:"family"`
+		Table  string `json:"table"`
+		Name   string `json:"name"`
+		Handle int    `json:"handle"`
+		Type   string `json:"type"`
+		Hook   string `json:"hook"`
+		Prio   *int   `json:"prio"`
+		Policy string `json:"policy"`
+		Dev    any    `json:"dev"`
+	} `json:"chain"`
+	Rule *struct {
+		Family  string `json:"family"`
+		Table   string `json:"table"`
+		Chain   string `json:"chain"`
+		Handle  int    `json:"handle"`
+		Comment string `json:"comment"`
+		Expr    []any  `json:"expr"`
+	} `json:"rule"`
+	Set       *nftSetJSON `json:"set"`
+	Map       *nftSetJSON `json:"map"`
+	Flowtable *struct {
+		Family string `json:"family"`
+		Table  string `json:"table"`
+		Name   string `json:"name"`
+		Hook   string `json:"hook"`
+		Prio   *int   `json:"prio"`
+		Dev    any    `json:"dev"`
+	} `json:"flowtable"`
+}
+
+// nftSetJSON is a set or map printed by 'nft -j'. Concatenated types are printed as arrays.
+type nftSetJSON struct {
+	Family string `json:"family"`
+	Table  string `json:"table"`
+	Name   string `json:"name"`
+	Handle int    `json:"handle"`
+	Type   any    `json:"type"`
+	Map    any    `json:"map"`
+	Flags  any    `json:"flags"`
+	Elem   []any  `json:"elem"`
+}
+
+// parseNFTRuleset parses 'nft -j' output. Numbers in expressions are kept as json.Number so
+// that counters and marks do not lose precision.
+func parseNFTRuleset(output string) (nftRuleset, error) {
+	var ruleset nftRuleset
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return ruleset, nil
+	}
+	var doc struct {
+		Nftables []nftObjectJSON `json:"nftables"`
+	}
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return ruleset, fmt.Errorf("failed to parse nft JSON output: %w", err)
+	}
+	for _, object := range doc.Nftables {
+		switch {
+		case object.Table != nil:
+			t := object.Table
+			ruleset.tables = append(ruleset.tables, types.NFTTable{Family: t.Family, Name: t.Name, Handle: t.Handle})
+		case object.Chain != nil:
+			c := object.Chain
+			ruleset.chains = append(ruleset.chains, types.NFTChain{
+				Family:   c.Family,
+				Table:    c.Table,
+				Name:     c.Name,
+				Handle:   c.Handle,
+				Type:     c.Type,
+				Hook:     c.Hook,
+				Priority: c.Prio,
+				Policy:   c.Policy,
+				Device:   strings.Join(nftStrings(c.Dev), ","),
+			})
+		case object.Rule != nil:
+			r := object.Rule
+			ruleset.rules = append(ruleset.rules, newNFTRule(types.NFTRule{
+				Family:  r.Family,
+				Table:   r.Table,
+				Chain:   r.Chain,
+				Handle:  r.Handle,
+				Comment: r.Comment,
+			}, r.Expr))
+		case object.Set != nil:
+			ruleset.sets = append(ruleset.sets, newNFTSet(object.Set, false))
+		case object.Map != nil:
+			ruleset.sets = append(ruleset.sets, newNFTSet(object.Map, true))
+		case object.Flowtable != nil:
+			f := object.Flowtable
+			ruleset.flowtables = append(ruleset.flowtables, types.NFTFlowtable{
+				Family:   f.Family,
+				Table:    f.Table,
+				Name:     f.Name,
+				Hook:     f.Hook,
+				Priority: f.Prio,
+				Devices:  nftStrings(f.Dev),
+			})
+		}
+	}
+	return ruleset, nil
+}
+
+// newNFTRule renders the expressions of a rule and extracts its verdict and counter.
+func newNFTRule(rule types.NFTRule, expr []any) nftRule {
+	rule.Expressions = []string{}
+	for _, e := range expr {
+		statement, ok := e.(map[string]any)
+		if !ok || len(statement) != 1 {
+			rule.Expressions = append(rule.Expressions, nftCompactJSON(e))
+			continue
+		}
+		for key, value := range statement {
+			switch key {
+			case "counter":
+				if counter, ok := value.(map[string]any); ok {
+					rule.Counter = &types.NFTCounter{Packets: nftUint(counter["packets"]), Bytes: nftUint(counter["bytes"])}
+				} else {
+					rule.Expressions = append(rule.Expressions, "counter name "+nftExprString(value))
+				}
+			case "accept", "drop", "continue", "return", "queue", "reject":
+				rule.Verdict = key
+			case "jump", "goto":
+				rule.Verdict = nftExprString(statement)
+			default:
+				rule.Expressions = append(rule.Expressions, nftStatementString(key, value))
+			}
+		}
+	}
+	return nftRule{NFTRule: rule, expr: expr}
+}
+
+// newNFTSet converts a set or map and renders its elements.
+func newNFTSet(s *nftSetJSON, isMap bool) nftSet {
+	set := nftSet{
+		NFTSet: types.NFTSet{
+			Family: s.Family,
+			Table:  s.Table,
+			Name:   s.Name,
+			Handle: s.Handle,
+			Flags:  nftStrings(s.Flags),
+		},
+		isMap:      isMap,
+		keyTypes:   nftStrings(s.Type),
+		valueTypes: nftStrings(s.Map),
+		elem:       s.Elem,
+	}
+	set.Type = strings.Join(set.keyTypes, " . ")
+	set.ValueType = strings.Join(set.valueTypes, " . ")
+	for _, e := range s.Elem {
+		key, value := nftElementKeyValue(e, isMap)
+		element := types.NFTElement{Key: nftExprString(key)}
+		if isMap {
+			element.Value = nftExprString(value)
+		}
+		set.Elements = append(set.Elements, element)
+	}
+	return set
+}
+
+// nftElementKeyValue splits a map element into its key and value. Set elements have no value.
+func nftElementKeyValue(element any, isMap bool) (any, any) {
+	if pair, ok := element.([]any); ok && isMap && len(pair) == 2 {
+		return pair[0], pair[1]
+	}
+	return element, nil
+}
+
+// nftStatementString renders a rule statement in nft syntax.
+func nftStatementString(key string, value any) string {
+	m, _ := value.(map[string]any)
+	switch key {
+	case "match":
+		left, right := nftExprString(m["left"]), nftExprString(m["right"])
+		if op, _ := m["op"].(string); op != "" && op != "==" && op != "in" {
+			return left + " " + op + " " + right
+		}
+		return left + " " + right
+	case "snat", "dnat", "masquerade", "redirect":
+		s := key
+		if family, ok := m["family"].(string); ok {
+			s += " " + family
+		}
+		addr, port := nftExprString(m["addr"]), nftExprString(m["port"])
+		switch {
+		case addr != "" && port != "":
+			s += " to " + addr + ":" + port
+		case addr != "":
+			s += " to " + addr
+		case port != "":
+			s += " to :" + port
+		}
+		return s
+	case "mangle":
+		return nftExprString(m["key"]) + " set " + nftExprString(m["value"])
+	case "vmap":
+		return nftExprString(m["key"]) + " vmap " + nftExprString(m["data"])
+	case "set", "map":
+		return fmt.Sprintf("%s %s { %s }", nftExprString(m["op"]), nftExprString(m["set"]), nftExprString(m["elem"]))
+	case "log":
+		s := "log"
+		if prefix, ok := m["prefix"].(string); ok {
+			s += fmt.Sprintf(" prefix %q", prefix)
+		}
+		return s
+	case "limit":
+		return fmt.Sprintf("limit rate %s/%s", nftExprString(m["rate"]), nftExprString(m["per"]))
+	case "xt":
+		return fmt.Sprintf("xt %s %s", nftExprString(m["type"]), nftExprString(m["name"]))
+	}
+	if value == nil {
+		return key
+	}
+	return key + " " + nftExprString(value)
+}
+
+// nftExprString renders an expression in nft syntax. Expressions that are not known are rendered
+// as compact JSON.
+func nftExprString(v any) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case bool:
+		return strconv.FormatBool(value)
+	case []any:
+		parts := make([]string, 0, len(value))
+		for _, item := range value {
+			parts = append(parts, nftExprString(item))
+		}
+		return strings.Join(parts, ", ")
+	case map[string]any:
+		if len(value) != 1 {
+			return nftCompactJSON(value)
+		}
+		for key, inner := range value {
+			return nftExprObjectString(key, inner)
+		}
+	}
+	return nftCompactJSON(v)
+}
+
+// nftExprObjectString renders an expression object with a single key.
+func nftExprObjectString(key string, inner any) string {
+	m, _ := inner.(map[string]any)
+	switch key {
+	case "payload":
+		if _, ok := m["protocol"]; ok {
+			return nftExprString(m["protocol"]) + " " + nftExprString(m["field"])
+		}
+		return fmt.Sprintf("@%s,%s,%s", nftExprString(m["base"]), nftExprString(m["offset"]), nftExprString(m["len"]))
+	case "meta", "rt", "socket":
+		return key + " " + nftExprString(m["key"])
+	case "ct":
+		parts := []string{"ct"}
+		for _, field := range []string{"dir", "family", "key"} {
+			if s := nftExprString(m[field]); s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, " ")
+	case "fib":
+		return "fib " + strings.Join(nftStrings(m["flags"]), " . ") + " " + nftExprString(m["result"])
+	case "set":
+		return "{ " + nftExprString(inner) + " }"
+	case "prefix":
+		return nftExprString(m["addr"]) + "/" + nftExprString(m["len"])
+	case "range":
+		if bounds, ok := inner.([]any); ok && len(bounds) == 2 {
+			return nftExprString(bounds[0]) + "-" + nftExprString(bounds[1])
+		}
+	case "concat":
+		if items, ok := inner.([]any); ok {
+			parts := make([]string, 0, len(items))
+			for _, item := range items {
+				parts = append(parts, nftExprString(item))
+			}
+			return strings.Join(parts, " . ")
+		}
+	case "map", "vmap":
+		return nftExprString(m["key"]) + " " + key + " " + nftExprString(m["data"])
+	case "elem":
+		return nftExprString(m["val"])
+	case "&", "|", "^", "<<", ">>":
+		if operands, ok := inner.([]any); ok && len(operands) == 2 {
+			return nftExprString(operands[0]) + " " + key + " " + nftExprString(operands[1])
+		}
+	case "jump", "goto":
+		return key + " " + nftExprString(m["target"])
+	case "accept", "drop", "continue", "return":
+		return key
+	case "exthdr", "tcp option":
+		return key + " " + nftExprString(m["name"]) + " " + nftExprString(m["field"])
+	case "numgen":
+		return fmt.Sprintf("numgen %s mod %s", nftExprString(m["mode"]), nftExprString(m["mod"]))
+	case "jhash", "symhash":
+		return strings.TrimSpace(fmt.Sprintf("%s %s mod %s", key, nftExprString(m["expr"]), nftExprString(m["mod"])))
+	}
+	return key + " " + nftCompactJSON(inner)
+}
+
+// nftStrings returns a value that nft prints either as a string or as an array of strings.
+func nftStrings(v any) []string {
+	switch value := v.(type) {
+	case string:
+		return []string{value}
+	case []any:
+		var strs []string
+		for _, item := range value {
+			strs = append(strs, nftExprString(item))
+		}
+		return strs
+	}
+	return nil
+}
+
+// nftUint returns a non-negative JSON number, or zero.
+func nftUint(v any) uint64 {
+	number, ok := v.(json.Number)
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.ParseUint(number.String(), 10, 64)
+	return n
+}
+
+// nftCompactJSON renders a JSON value on a single line.
+func nftCompactJSON(v any) string {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(buf.String())
+}
@@ -0,0 +1,299 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/headtail"
+)
+
+// testNFTRuleset is an excerpt of the 'inet ovn-kubernetes' table in 'nft -j list ruleset' format.
+const testNFTRuleset = `{"nftables": [
+{"metainfo": {"version": "1.0.9", "release_name": "Old Doc Yak #3", "json_schema_version": 1}},
+{"table": {"family": "inet", "name": "ovn-kubernetes", "handle": 1}},
+{"chain": {"family": "inet", "table": "ovn-kubernetes", "name": "mgmtport-snat", "handle": 1, "type": "nat", "hook": "postrouting", "prio": 100, "policy": "accept"}},
+{"chain": {"family": "inet", "table": "ovn-kubernetes", "name": "egress-services", "handle": 2}},
+{"set": {"family": "inet", "name": "mgmtport-no-snat-nodeports", "table": "ovn-kubernetes", "type": ["inet_proto", "inet_service"], "handle": 3,
+  "elem": [{"concat": ["tcp", 30080]}, {"concat": ["udp", 30053]}]}},
+{"set": {"family": "inet", "name": "mgmtport-no-snat-subnets-v4", "table": "ovn-kubernetes", "type": "ipv4_addr", "handle": 4, "flags": ["interval"],
+  "elem": [{"prefix": {"addr": "172.18.0.0", "len": 16}}, {"range": ["192.168.1.10", "192.168.1.20"]}]}},
+{"map": {"family": "inet", "name": "no-pmtud-remote-node-ips-v4", "table": "ovn-kubernetes", "type": "ipv4_addr", "handle": 5, "map": "verdict",
+  "elem": [["172.18.0.4", {"goto": {"target": "egress-services"}}]]}},
+{"rule": {"family": "inet", "table": "ovn-kubernetes", "chain": "mgmtport-snat", "handle": 6,
+  "expr": [{"match": {"op": "!=", "left": {"meta": {"key": "oifname"}}, "right": "ovn-k8s-mp0"}}, {"counter": {"packets": 0, "bytes": 0}}, {"return": null}]}},
+{"rule": {"family": "inet", "table": "ovn-kubernetes", "chain": "mgmtport-snat", "handle": 7, "comment": "no SNAT for NodePorts",
+  "expr": [{"match": {"op": "==", "left": {"concat": [{"meta": {"key": "l4proto"}}, {"payload": {"protocol": "th", "field": "dport"}}]}, "right": "@mgmtport-no-snat-nodeports"}},
+           {"counter": {"packets": 12, "bytes": 720}}, {"return": null}]}},
+{"rule": {"family": "inet", "table": "ovn-kubernetes", "chain": "mgmtport-snat", "handle": 8,
+  "expr": [{"match": {"op": "==", "left": {"payload": {"protocol": "ip", "field": "daddr"}}, "right": {"prefix": {"addr": "10.244.0.0", "len": 16}}}},
+           {"match": {"op": "==", "left": {"payload": {"protocol": "tcp", "field": "dport"}}, "right": {"set": [80, {"range": [8000, 8080]}]}}},
+           {"snat": {"family": "ip", "addr": "10.244.1.2", "port": 6443}}]}},
+{"rule": {"family": "inet", "table": "ovn-kubernetes", "chain": "egress-services", "handle": 9,
+  "expr": [{"match": {"op": "==", "left": {"ct": {"key": "mark"}}, "right": 8080}}, {"jump": {"target": "mgmtport-snat"}}]}}
+]}`
+
+func TestParseNFTRuleset(t *testing.T) {
+	ruleset, err := parseNFTRuleset(testNFTRuleset)
+	if err != nil {
+		t.Fatalf("parseNFTRuleset() error = %v", err)
+	}
+	result := newNFTResult(ruleset, "", headtail.HeadTailParams{})
+	priority := 100
+	expected := types.NFTResult{
+		Tables: []types.NFTTable{{Family: "inet", Name: "ovn-kubernetes", Handle: 1}},
+		Chains: []types.NFTChain{
+			{Family: "inet", Table: "ovn-kubernetes", Name: "mgmtport-snat", Handle: 1, Type: "nat", Hook: "postrouting", Priority: &priority, Policy: "accept"},
+			{Family: "inet", Table: "ovn-kubernetes", Name: "egress-services", Handle: 2},
+		},
+		Rules: []types.NFTRule{
+			{Family: "inet", Table: "ovn-kubernetes", Chain: "mgmtport-snat", Handle: 6,
+				Expressions: []string{"meta oifname != ovn-k8s-mp0"}, Verdict: "return", Counter: &types.NFTCounter{}},
+			{Family: "inet", Table: "ovn-kubernetes", Chain: "mgmtport-snat", Handle: 7, Comment: "no SNAT for NodePorts",
+				Expressions: []string{"meta l4proto . th dport @mgmtport-no-snat-nodeports"}, Verdict: "return", Counter: &types.NFTCounter{Packets: 12, Bytes: 720}},
+			{Family: "inet", Table: "ovn-kubernetes", Chain: "mgmtport-snat", Handle: 8,
+				Expressions: []string{"ip daddr 10.244.0.0/16", "tcp dport { 80, 8000-8080 }", "snat ip to 10.244.1.2:6443"}},
+			{Family: "inet", Table: "ovn-kubernetes", Chain: "egress-services", Handle: 9,
+				Expressions: []string{"ct mark 8080"}, Verdict: "jump mgmtport-snat"},
+		},
+		Sets: []types.NFTSet{
+			{Family: "inet", Table: "ovn-kubernetes", Name: "mgmtport-no-snat-nodeports", Handle: 3, Type: "inet_proto . inet_service",
+				Elements: []types.NFTElement{{Key: "tcp . 30080"}, {Key: "udp . 30053"}}},
+			{Family: "inet", Table: "ovn-kubernetes", Name: "mgmtport-no-snat-subnets-v4", Handle: 4, Type: "ipv4_addr", Flags: []string{"interval"},
+				Elements: []types.NFTElement{{Key: "172.18.0.0/16"}, {Key: "192.168.1.10-192.168.1.20"}}},
+		},
+		Maps: []types.NFTSet{
+			{Family: "inet", Table: "ovn-kubernetes", Name: "no-pmtud-remote-node-ips-v4", Handle: 5, Type: "ipv4_addr", ValueType: "verdict",
+				Elements: []types.NFTElement{{Key: "172.18.0.4", Value: "goto egress-services"}}},
+		},
+	}
+	if diff := cmp.Diff(expected, result); diff != "" {
+		t.Errorf("newNFTResult() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestNFTElementLookup(t *testing.T) {
+	ruleset, err := parseNFTRuleset(testNFTRuleset)
+	if err != nil {
+		t.Fatalf("parseNFTRuleset() error = %v", err)
+	}
+	tests := []struct {
+		element  string
+		expected []string
+	}{
+		{element: "tcp . 30080", expected: []string{"tcp . 30080"}},
+		{element: "tcp . 30053", expected: nil},
+		{element: "172.18.0.3", expected: []string{"172.18.0.0/16"}},
+		{element: "192.168.1.15", expected: []string{"192.168.1.10-192.168.1.20"}},
+		{element: "172.18.0.4", expected: []string{"172.18.0.0/16", "172.18.0.4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.element, func(t *testing.T) {
+			result := newNFTResult(ruleset, tt.element, headtail.HeadTailParams{})
+			var keys []string
+			for _, set := range append(result.Sets, result.Maps...) {
+				for _, element := range set.Elements {
+					keys = append(keys, element.Key)
+				}
+			}
+			if diff := cmp.Diff(tt.expected, keys); diff != "" {
+				t.Errorf("newNFTResult() elements mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSearchNFTRuleset(t *testing.T) {
+	ruleset, err := parseNFTRuleset(testNFTRuleset)
+	if err != nil {
+		t.Fatalf("parseNFTRuleset() error = %v", err)
+	}
+	tests := []struct {
+		name            string
+		address         string
+		port            int
+		expectedRules   map[int][]string
+		expectedElement []string
+	}{
+		{
+			name:            "port in a concatenated set",
+			port:            30080,
+			expectedRules:   map[int][]string{7: {"@mgmtport-no-snat-nodeports"}},
+			expectedElement: []string{"mgmtport-no-snat-nodeports: tcp . 30080"},
+		},
+		{
+			name:          "port in an anonymous set range",
+			port:          8080,
+			expectedRules: map[int][]string{8: {"tcp dport { 80, 8000-8080 }"}},
+		},
+		{
+			name:          "nat port",
+			port:          6443,
+			expectedRules: map[int][]string{8: {"snat ip to 10.244.1.2:6443"}},
+		},
+		{
+			name:          "address in a prefix and a NAT address",
+			address:       "10.244.1.2",
+			expectedRules: map[int][]string{8: {"ip daddr 10.244.0.0/16", "snat ip to 10.244.1.2:6443"}},
+		},
+		{
+			name:          "address and port in the same rule",
+			address:       "10.244.1.2",
+			port:          80,
+			expectedRules: map[int][]string{8: {"ip daddr 10.244.0.0/16", "tcp dport { 80, 8000-8080 }", "snat ip to 10.244.1.2:6443"}},
+		},
+		{
+			name:            "address in sets and map keys",
+			address:         "172.18.0.4",
+			expectedRules:   map[int][]string{},
+			expectedElement: []string{"mgmtport-no-snat-subnets-v4: 172.18.0.0/16", "no-pmtud-remote-node-ips-v4: 172.18.0.4"},
+		},
+		{
+			name:          "address and port in different rules",
+			address:       "10.244.1.2",
+			port:          30080,
+			expectedRules: map[int][]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := newNFTQuery(tt.address, tt.port)
+			if err != nil {
+				t.Fatalf("newNFTQuery() error = %v", err)
+			}
+			result := searchNFTRuleset(ruleset, query, headtail.HeadTailParams{})
+			rules := map[int][]string{}
+			for _, rule := range result.Rules {
+				rules[rule.Handle] = rule.Matches
+			}
+			if diff := cmp.Diff(tt.expectedRules, rules); diff != "" {
+				t.Errorf("searchNFTRuleset() rules mismatch (-want +got):\n%s", diff)
+			}
+			var elements []string
+			for _, element := range result.Elements {
+				elements = append(elements, element.Set+": "+element.Key)
+			}
+			if diff := cmp.Diff(tt.expectedElement, elements); diff != "" {
+				t.Errorf("searchNFTRuleset() elements mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateNFTSelection(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		table     string
+		objName   string
+		element   string
+		wantError bool
+	}{
+		{name: "ruleset of a table", command: "list ruleset", table: "ovn-kubernetes"},
+		{name: "single table", command: "list table", table: "ovn-kubernetes"},
+		{name: "set element lookup", command: "list set", table: "ovn-kubernetes", objName: "mgmtport-no-snat-nodeports", element: "tcp . 30080"},
+		{name: "single table without table", command: "list table", wantError: true},
+		{name: "chain without name", command: "list chain", table: "ovn-kubernetes", wantError: true},
+		{name: "name with plural command", command: "list sets", objName: "x", wantError: true},
+		{name: "element with chain", command: "list chain", table: "ovn-kubernetes", objName: "mgmtport-snat", element: "80", wantError: true},
+		{name: "invalid table", command: "list table", table: "ovn;kubernetes", wantError: true},
+		{name: "invalid element", command: "list set", table: "t", objName: "s", element: "$(true)", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateNFTSelection(tt.command, tt.table, tt.objName, tt.element)
+			if (err != nil) != tt.wantError {
+				t.Errorf("validateNFTSelection() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestGetNFTCommand(t *testing.T) {
+	var command string
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		if cmd[len(cmd)-1] == "-V" {
+			return "nftables v1.0.9 (Old Doc Yak #3)", "", nil
+		}
+		command = strings.Join(cmd, " ")
+		return testNFTRuleset, "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.GetNFT(context.Background(), nil, types.ListNFTParams{
+		CommonParams:    types.CommonParams{Node: "ovn-worker"},
+		Command:         "list chain",
+		AddressFamilies: "inet",
+		Table:           "ovn-kubernetes",
+		Name:            "mgmtport-snat",
+	})
+	if err != nil {
+		t.Fatalf("GetNFT() error = %v", err)
+	}
+	if expected := "nft -j list chain inet ovn-kubernetes mgmtport-snat"; command != expected {
+		t.Errorf("GetNFT() command = %q, expected %q", command, expected)
+	}
+	if len(result.Rules) != 4 {
+		t.Errorf("GetNFT() returned %d rules, expected 4", len(result.Rules))
+	}
+}
+
+func TestFilterNFTFamilies(t *testing.T) {
+	output := `{"nftables": [
+{"table": {"family": "ip", "name": "nat", "handle": 1}},
+{"table": {"family": "ip6", "name": "nat", "handle": 2}},
+{"table": {"family": "inet", "name": "ovn-kubernetes", "handle": 3}},
+{"table": {"family": "bridge", "name": "filter", "handle": 4}},
+{"chain": {"family": "ip6", "table": "nat", "name": "POSTROUTING", "handle": 1}},
+{"chain": {"family": "ip", "table": "nat", "name": "POSTROUTING", "handle": 1}}
+]}`
+	ruleset, err := parseNFTRuleset(output)
+	if err != nil {
+		t.Fatalf("parseNFTRuleset() error = %v", err)
+	}
+	tests := []struct {
+		name           string
+		ipFamily       string
+		expectedTables []string
+		expectedChains []string
+	}{
+		{name: "ipv4", ipFamily: "ipv4", expectedTables: []string{"ip nat", "inet ovn-kubernetes"}, expectedChains: []string{"ip nat"}},
+		{name: "ipv6", ipFamily: "ipv6", expectedTables: []string{"ip6 nat", "inet ovn-kubernetes"}, expectedChains: []string{"ip6 nat"}},
+		{name: "both", ipFamily: "both", expectedTables: []string{"ip nat", "ip6 nat", "inet ovn-kubernetes"}, expectedChains: []string{"ip6 nat", "ip nat"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			families, err := nftTableFamilies(tt.ipFamily, "")
+			if err != nil {
+				t.Fatalf("nftTableFamilies() error = %v", err)
+			}
+			filtered := ruleset.filterFamilies(families)
+			var tables, chains []string
+			for _, table := range filtered.tables {
+				tables = append(tables, table.Family+" "+table.Name)
+			}
+			for _, chain := range filtered.chains {
+				chains = append(chains, chain.Family+" "+chain.Table)
+			}
+			if diff := cmp.Diff(tt.expectedTables, tables); diff != "" {
+				t.Errorf("tables mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.expectedChains, chains); diff != "" {
+				t.Errorf("chains mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := nftTableFamilies("ipv4", "ip"); err == nil {
+		t.Errorf("nftTableFamilies() expected error with both ip_family and address_families")
+	}
+}
@@ -0,0 +1,458 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/headtail"
+)
+
+// SearchNFT searches the nftables ruleset of a node for the rules and the set and map elements
+// that reference an address, a port, or both.
+func (s *MCPServer) SearchNFT(ctx context.Context, req *mcp.CallToolRequest, in types.SearchNFTParams) (*mcp.CallToolResult, types.NFTSearchResult, error) {
+	query, err := newNFTQuery(in.Address, in.Port)
+	if err != nil {
+		return nil, types.NFTSearchResult{}, fmt.Errorf("error while searching nft ruleset: %w", err)
+	}
+	if err := validateNFTAddressFamily(in.AddressFamilies); err != nil {
+		return nil, types.NFTSearchResult{}, fmt.Errorf("error while searching nft ruleset: %w", err)
+	}
+	if err := validateNFTName(in.Table, "table"); err != nil {
+		return nil, types.NFTSearchResult{}, fmt.Errorf("error while searching nft ruleset: %w", err)
+	}
+	tableFamilies, err := nftTableFamilies(in.IPFamily, in.AddressFamilies)
+	if err != nil {
+		return nil, types.NFTSearchResult{}, fmt.Errorf("error while searching nft ruleset: %w", err)
+	}
+	if err := validatePodTarget(in.PodTargetParams); err != nil {
+		return nil, types.NFTSearchResult{}, fmt.Errorf("error while searching nft ruleset: %w", err)
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	if err := s.utilityExists(ctx, in.Namespace, in.Node, "nft"); err != nil {
+		return nil, types.NFTSearchResult{}, fmt.Errorf("error while searching nft ruleset: failed to verify nft utility availability in configured image: %w", err)
+	}
+
+	prefix, netns, err := s.podNetnsPrefix(ctx, in.Namespace, in.Node, in.PodTargetParams)
+	if err != nil {
+		return nil, types.NFTSearchResult{}, fmt.Errorf("error while searching nft ruleset: %w", err)
+	}
+
+	cmd := commandbuilder.NewCommand(prefix...)
+	cmd.Add("nft", "-j", "list", "ruleset")
+	cmd.AddIfNotEmpty(in.AddressFamilies, strings.TrimSpace(in.AddressFamilies))
+	stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+	if err != nil {
+		return nil, types.NFTSearchResult{}, fmt.Errorf("error while searching nft ruleset: %w", err)
+	}
+	if stderr != "" {
+		return nil, types.NFTSearchResult{}, fmt.Errorf("error while running command: %s", stderr)
+	}
+	ruleset, err := parseNFTRuleset(stdout)
+	if err != nil {
+		return nil, types.NFTSearchResult{}, fmt.Errorf("error while searching nft ruleset: %w", err)
+	}
+	if tableFamilies != nil {
+		ruleset = ruleset.filterFamilies(tableFamilies)
+	}
+	if in.Table != "" {
+		ruleset = ruleset.filterTable(in.Table)
+	}
+	result := searchNFTRuleset(ruleset, query, in.HeadTailParams)
+	result.Netns = netns
+	return nil, result, nil
+}
+
+// nftQuery is the address and port searched for. A zero port is not searched for.
+type nftQuery struct {
+	address net.IP
+	port    int
+}
+
+// newNFTQuery validates the searched address and port.
+func newNFTQuery(address string, port int) (nftQuery, error) {
+	var query nftQuery
+	if address == "" && port == 0 {
+		return query, fmt.Errorf("address or port is required")
+	}
+	if address != "" {
+		if query.address = net.ParseIP(address); query.address == nil {
+			return query, fmt.Errorf("invalid address %q: must be an IP address", address)
+		}
+	}
+	if port < 0 || port > 65535 {
+		return query, fmt.Errorf("invalid port %d: must be between 1 and 65535", port)
+	}
+	query.port = port
+	return query, nil
+}
+
+// searchNFTRuleset returns the rules referencing the query, directly or through a set or map, and
+// the set and map elements containing it. When both an address and a port are searched for, a
+// rule must reference both and an element must contain both.
+func searchNFTRuleset(ruleset nftRuleset, query nftQuery, headTailParams headtail.HeadTailParams) types.NFTSearchResult {
+	result := types.NFTSearchResult{Rules: []types.NFTRuleMatch{}, Elements: []types.NFTElementMatch{}}
+	for _, rule := range ruleset.rules {
+		if matches := ruleset.ruleMatches(rule, query); matches != nil {
+			result.Rules = append(result.Rules, types.NFTRuleMatch{NFTRule: rule.NFTRule, Matches: matches})
+		}
+	}
+	for _, set := range ruleset.sets {
+		for i, element := range set.elem {
+			address, port := set.elementMatches(element, query)
+			if (query.address == nil || address) && (query.port == 0 || port) {
+				result.Elements = append(result.Elements, types.NFTElementMatch{
+					Family:     set.Family,
+					Table:      set.Table,
+					Set:        set.Name,
+					Map:        set.isMap,
+					NFTElement: set.Elements[i],
+				})
+			}
+		}
+	}
+	result.Rules = headtail.ApplyTo(&headTailParams, result.Rules, DefaultMaxOutputLines)
+	result.Elements = headtail.ApplyTo(&headTailParams, result.Elements, DefaultMaxOutputLines)
+	return result
+}
+
+// filterTable returns the objects of the ruleset that belong to the table.
+func (r nftRuleset) filterTable(table string) nftRuleset {
+	return r.filter(func(_, t string) bool { return t == table })
+}
+
+// filterFamilies returns the objects of the ruleset that belong to tables of the address families.
+func (r nftRuleset) filterFamilies(families []string) nftRuleset {
+	return r.filter(func(family, _ string) bool { return slices.Contains(families, family) })
+}
+
+// filter returns the objects of the ruleset whose table family and name are kept.
+func (r nftRuleset) filter(keep func(family, table string) bool) nftRuleset {
+	var filtered nftRuleset
+	for _, t := range r.tables {
+		if keep(t.Family, t.Name) {
+			filtered.tables = append(filtered.tables, t)
+		}
+	}
+	for _, c := range r.chains {
+		if keep(c.Family, c.Table) {
+			filtered.chains = append(filtered.chains, c)
+		}
+	}
+	for _, rule := range r.rules {
+		if keep(rule.Family, rule.Table) {
+			filtered.rules = append(filtered.rules, rule)
+		}
+	}
+	for _, set := range r.sets {
+		if keep(set.Family, set.Table) {
+			filtered.sets = append(filtered.sets, set)
+		}
+	}
+	for _, f := range r.flowtables {
+		if keep(f.Family, f.Table) {
+			filtered.flowtables = append(filtered.flowtables, f)
+		}
+	}
+	return filtered
+}
+
+// findSet returns the set or map referenced as @name from a rule of the given table.
+func (r nftRuleset) findSet(family, table, name string) *nftSet {
+	for i := range r.sets {
+		if r.sets[i].Family == family && r.sets[i].Table == table && r.sets[i].Name == name {
+			return &r.sets[i]
+		}
+	}
+	return nil
+}
+
+// ruleMatches returns the rendered statements and the referenced sets of the rule that match the
+// query, or nil if the rule does not reference all of the query.
+func (r nftRuleset) ruleMatches(rule nftRule, query nftQuery) []string {
+	var matches []string
+	var foundAddress, foundPort bool
+	for _, e := range rule.expr {
+		statement, ok := e.(map[string]any)
+		if !ok || len(statement) != 1 {
+			continue
+		}
+		for key, value := range statement {
+			if key == "counter" {
+				continue
+			}
+			address := query.address != nil && nftTreeContainsAddress(value, query.address)
+			port := query.port != 0 && nftStatementContainsPort(key, value, query.port)
+			if address || port {
+				matches = append(matches, nftStatementString(key, value))
+			}
+			for _, name := range nftSetReferences(value) {
+				set := r.findSet(rule.Family, rule.Table, name)
+				if set == nil {
+					continue
+				}
+				setAddress, setPort := set.containsQuery(query)
+				if setAddress || setPort {
+					matches = append(matches, "@"+name)
+				}
+				address = address || setAddress
+				port = port || setPort
+			}
+			foundAddress = foundAddress || address
+			foundPort = foundPort || port
+		}
+	}
+	if (query.address != nil && !foundAddress) || (query.port != 0 && !foundPort) {
+		return nil
+	}
+	return matches
+}
+
+// containsQuery reports whether any element of the set contains the address and whether any
+// element contains the port.
+func (s nftSet) containsQuery(query nftQuery) (bool, bool) {
+	var address, port bool
+	for _, element := range s.elem {
+		a, p := s.elementMatches(element, query)
+		address = address || a
+		port = port || p
+	}
+	return address, port
+}
+
+// elementMatches reports whether the element, including the value of map elements, contains the
+// address and the port. Components are only compared with the address or port if their type is
+// an address or a service, or, for addresses, if the type is unknown.
+func (s nftSet) elementMatches(element any, query nftQuery) (bool, bool) {
+	key, value := nftElementKeyValue(element, s.isMap)
+	var address, port bool
+	for _, part := range []struct {
+		value any
+		types []string
+	}{{value: key, types: s.keyTypes}, {value: value, types: s.valueTypes}} {
+		if part.value == nil {
+			continue
+		}
+		components := nftElementComponents(part.value)
+		for i, component := range components {
+			componentType := ""
+			if len(part.types) == len(components) {
+				componentType = part.types[i]
+			}
+			if query.address != nil && (componentType == "" || componentType == "ipv4_addr" || componentType == "ipv6_addr") &&
+				nftValueContainsAddress(component, query.address) {
+				address = true
+			}
+			if query.port != 0 && componentType == "inet_service" && nftValueContainsNumber(component, query.port) {
+				port = true
+			}
+		}
+	}
+	return address, port
+}
+
+// nftElementComponents returns the components of a concatenated element, or the element itself.
+func nftElementComponents(v any) []any {
+	if m, ok := v.(map[string]any); ok {
+		if elem, ok := m["elem"].(map[string]any); ok {
+			return nftElementComponents(elem["val"])
+		}
+		if concat, ok := m["concat"].([]any); ok {
+			return concat
+		}
+	}
+	return []any{v}
+}
+
+// nftElementContains reports whether the element contains the value given in nft syntax, e.g.
+// "10.96.0.10 . udp . 53". Each component of a concatenated value must match the element
+// component at the same position.
+func nftElementContains(element any, value string) bool {
+	queryComponents := strings.Split(value, " . ")
+	components := nftElementComponents(element)
+	if len(components) != len(queryComponents) {
+		return false
+	}
+	for i, q := range queryComponents {
+		q = strings.TrimSpace(q)
+		if ip := net.ParseIP(q); ip != nil {
+			if !nftValueContainsAddress(components[i], ip) {
+				return false
+			}
+		} else if n, err := strconv.Atoi(q); err == nil {
+			if !nftValueContainsNumber(components[i], n) {
+				return false
+			}
+		} else if nftExprString(components[i]) != q {
+			return false
+		}
+	}
+	return true
+}
+
+// nftValueContainsAddress reports whether an address literal, prefix, range or anonymous set
+// contains the address.
+func nftValueContainsAddress(v any, ip net.IP) bool {
+	switch value := v.(type) {
+	case string:
+		return net.ParseIP(value).Equal(ip)
+	case []any:
+		for _, item := range value {
+			if nftValueContainsAddress(item, ip) {
+				return true
+			}
+		}
+	case map[string]any:
+		if prefix, ok := value["prefix"].(map[string]any); ok {
+			_, network, err := net.ParseCIDR(nftExprString(prefix["addr"]) + "/" + nftExprString(prefix["len"]))
+			return err == nil && network.Contains(ip)
+		}
+		if bounds, ok := value["range"].([]any); ok && len(bounds) == 2 {
+			low, high := net.ParseIP(nftExprString(bounds[0])), net.ParseIP(nftExprString(bounds[1]))
+			if low == nil || high == nil || (low.To4() == nil) != (ip.To4() == nil) {
+				return false
+			}
+			return bytes.Compare(ip.To16(), low.To16()) >= 0 && bytes.Compare(ip.To16(), high.To16()) <= 0
+		}
+		if elem, ok := value["elem"].(map[string]any); ok {
+			return nftValueContainsAddress(elem["val"], ip)
+		}
+		if set, ok := value["set"]; ok {
+			return nftValueContainsAddress(set, ip)
+		}
+	}
+	return false
+}
+
+// nftValueContainsNumber reports whether a number literal, range or anonymous set contains the number.
+func nftValueContainsNumber(v any, n int) bool {
+	switch value := v.(type) {
+	case json.Number:
+		return value.String() == strconv.Itoa(n)
+	case []any:
+		for _, item := range value {
+			if nftValueContainsNumber(item, n) {
+				return true
+			}
+		}
+	case map[string]any:
+		if bounds, ok := value["range"].([]any); ok && len(bounds) == 2 {
+			low, lowErr := strconv.Atoi(nftExprString(bounds[0]))
+			high, highErr := strconv.Atoi(nftExprString(bounds[1]))
+			return lowErr == nil && highErr == nil && n >= low && n <= high
+		}
+		if elem, ok := value["elem"].(map[string]any); ok {
+			return nftValueContainsNumber(elem["val"], n)
+		}
+		if set, ok := value["set"]; ok {
+			return nftValueContainsNumber(set, n)
+		}
+	}
+	return false
+}
+
+// nftTreeContainsAddress reports whether any address literal, prefix or range in an expression
+// tree contains the address.
+func nftTreeContainsAddress(v any, ip net.IP) bool {
+	if nftValueContainsAddress(v, ip) {
+		return true
+	}
+	switch value := v.(type) {
+	case []any:
+		for _, item := range value {
+			if nftTreeContainsAddress(item, ip) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, item := range value {
+			if nftTreeContainsAddress(item, ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nftStatementContainsPort reports whether a statement matches or translates to the port. Only
+// matches on transport ports and NAT ports are considered, so that other numbers such as marks
+// or protocol numbers are not reported.
+func nftStatementContainsPort(key string, value any, port int) bool {
+	m, _ := value.(map[string]any)
+	switch key {
+	case "match":
+		leftComponents := nftElementComponents(m["left"])
+		candidates := []any{m["right"]}
+		if right, ok := m["right"].(map[string]any); ok {
+			if set, ok := right["set"].([]any); ok {
+				candidates = set
+			}
+		}
+		for _, candidate := range candidates {
+			components := nftElementComponents(candidate)
+			if len(components) != len(leftComponents) {
+				continue
+			}
+			for i, component := range components {
+				if nftIsPortExpr(leftComponents[i]) && nftValueContainsNumber(component, port) {
+					return true
+				}
+			}
+		}
+	case "snat", "dnat", "redirect", "masquerade":
+		return nftValueContainsNumber(m["port"], port)
+	}
+	return false
+}
+
+// nftIsPortExpr reports whether an expression selects a transport port.
+func nftIsPortExpr(v any) bool {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	if payload, ok := m["payload"].(map[string]any); ok {
+		field := nftExprString(payload["field"])
+		return field == "sport" || field == "dport"
+	}
+	if ct, ok := m["ct"].(map[string]any); ok {
+		key := nftExprString(ct["key"])
+		return key == "proto-src" || key == "proto-dst"
+	}
+	return false
+}
+
+// nftSetReferences returns the names of the sets and maps referenced as @name in an expression tree.
+func nftSetReferences(v any) []string {
+	var names []string
+	switch value := v.(type) {
+	case string:
+		if name, ok := strings.CutPrefix(value, "@"); ok {
+			names = append(names, name)
+		}
+	case []any:
+		for _, item := range value {
+			names = append(names, nftSetReferences(item)...)
+		}
+	case map[string]any:
+		for _, item := range value {
+			names = append(names, nftSetReferences(item)...)
+		}
+	}
+	return names
+}
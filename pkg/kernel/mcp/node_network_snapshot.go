@@ -0,0 +1,411 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/headtail"
+)
+
+const (
+	snapshotSectionIP        = "ip"
+	snapshotSectionNFT       = "nft"
+	snapshotSectionIPTables  = "iptables"
+	snapshotSectionConntrack = "conntrack"
+	snapshotSectionSysctl    = "sysctl"
+	snapshotSectionOVS       = "ovs"
+
+	// snapshotCommandFailed follows the output of a command of the snapshot script that failed.
+	snapshotCommandFailed = "failed"
+)
+
+// snapshotSections are the sections node-network-snapshot collects, in output order.
+var snapshotSections = []string{
+	snapshotSectionIP,
+	snapshotSectionNFT,
+	snapshotSectionIPTables,
+	snapshotSectionConntrack,
+	snapshotSectionSysctl,
+	snapshotSectionOVS,
+}
+
+// snapshotSysctlInterfaces are the OVN-Kubernetes interfaces whose per-interface sysctls are read
+// by the snapshot. The keys of the interfaces that do not exist on the node are skipped by sysctl -e.
+var snapshotSysctlInterfaces = []string{"br-ex", "breth0", "ovn-k8s-mp0"}
+
+// snapshotOVSFunction defines the ovs shell function running ovs-vsctl. The binary of the image is
+// pointed to the database socket of the node, otherwise the binary of the node is used.
+const snapshotOVSFunction = `ovs() { if command -v ovs-vsctl >/dev/null 2>&1; then ` +
+	`ovs-vsctl --timeout=5 --db=unix:` + hostMountPath + `/var/run/openvswitch/db.sock "$@"; ` +
+	`else chroot ` + hostMountPath + ` ovs-vsctl --timeout=5 "$@"; fi; }`
+
+// snapshotCommand is a command of the snapshot script. Its output is printed in its own output
+// section, and errors are reported by the name of the command.
+type snapshotCommand struct {
+	name    string
+	section string
+	command string
+}
+
+// snapshotCommands returns the commands collecting the sections, in output order.
+func snapshotCommands() []snapshotCommand {
+	return []snapshotCommand{
+		{name: "addresses", section: snapshotSectionIP, command: "ip -j address show"},
+		{name: "links", section: snapshotSectionIP, command: "ip -j -d link show"},
+		{name: "routes", section: snapshotSectionIP, command: "ip -j -4 route show table all"},
+		{name: "routes6", section: snapshotSectionIP, command: "ip -j -6 route show table all"},
+		{name: "rules", section: snapshotSectionIP, command: "ip -j -4 rule show"},
+		{name: "rules6", section: snapshotSectionIP, command: "ip -j -6 rule show"},
+		{name: "neighbours", section: snapshotSectionIP, command: "ip -j neighbour show"},
+		{name: "nft", section: snapshotSectionNFT, command: "nft -j list ruleset"},
+		{name: "iptables", section: snapshotSectionIPTables, command: "iptables-save"},
+		{name: "ip6tables", section: snapshotSectionIPTables, command: "ip6tables-save"},
+		{name: "conntrack", section: snapshotSectionConntrack,
+			command: "cat /proc/sys/net/netfilter/nf_conntrack_count /proc/sys/net/netfilter/nf_conntrack_max"},
+		{name: "sysctl", section: snapshotSectionSysctl,
+			command: "sysctl -e " + strings.Join(sysctlKeys(sysctlBaselineFor(false), snapshotSysctlInterfaces, nil), " ")},
+		{name: "ovs_show", section: snapshotSectionOVS, command: "ovs show"},
+		{name: "ovs_bridge_mappings", section: snapshotSectionOVS,
+			command: "ovs --if-exists get Open_vSwitch . external_ids:ovn-bridge-mappings"},
+	}
+}
+
+// NodeNetworkSnapshot collects the addresses, links, routes, rules and neighbours, the nftables
+// ruleset, the iptables rules, the conntrack usage, the sysctls and the OVS configuration of a node
+// with a single command, so that a single debug pod is used.
+func (s *MCPServer) NodeNetworkSnapshot(ctx context.Context, req *mcp.CallToolRequest, in types.NodeNetworkSnapshotParams) (*mcp.CallToolResult, types.NodeNetworkSnapshotResult, error) {
+	sections, err := validateNodeNetworkSnapshot(in)
+	if err != nil {
+		return nil, types.NodeNetworkSnapshotResult{}, fmt.Errorf("error while collecting node network snapshot: %w", err)
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	cmd := commandbuilder.NewCommand("sh", "-c", nodeNetworkSnapshotScript(sections))
+	stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+	if err != nil {
+		return nil, types.NodeNetworkSnapshotResult{}, fmt.Errorf("error while collecting node network snapshot: %w", err)
+	}
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		return nil, types.NodeNetworkSnapshotResult{}, fmt.Errorf("error while running command: %s", stderr)
+	}
+
+	result, collected := parseNodeNetworkSnapshot(in.Node, stdout, in.HeadTailParams)
+	if collected == 0 {
+		return nil, types.NodeNetworkSnapshotResult{}, fmt.Errorf("error while collecting node network snapshot: every command failed: %v", result.Errors)
+	}
+	return nil, result, nil
+}
+
+// validateNodeNetworkSnapshot validates the parameters and returns the sections to collect.
+func validateNodeNetworkSnapshot(in types.NodeNetworkSnapshotParams) ([]string, error) {
+	if strings.TrimSpace(in.Node) == "" {
+		return nil, fmt.Errorf("node is required")
+	}
+	requested := map[string]bool{}
+	for _, section := range strings.Split(in.Sections, ",") {
+		if section = strings.ToLower(strings.TrimSpace(section)); section != "" {
+			requested[section] = true
+		}
+	}
+	all := len(requested) == 0
+	var sections []string
+	for _, section := range snapshotSections {
+		if all || requested[section] {
+			sections = append(sections, section)
+			delete(requested, section)
+		}
+	}
+	if len(requested) > 0 {
+		return nil, fmt.Errorf("invalid sections %s: must be ip, nft, iptables, conntrack, sysctl or ovs",
+			strings.Join(slices.Sorted(maps.Keys(requested)), ", "))
+	}
+	return sections, nil
+}
+
+// nodeNetworkSnapshotScript returns the script running the commands of the sections. The output of
+// a command that fails is followed by a snapshotCommandFailed section, so that the other commands
+// still run and their output is returned.
+func nodeNetworkSnapshotScript(sections []string) string {
+	var parts []string
+	if slices.Contains(sections, snapshotSectionOVS) {
+		parts = append(parts, snapshotOVSFunction)
+	}
+	for _, command := range snapshotCommands() {
+		if slices.Contains(sections, command.section) {
+			parts = append(parts, fmt.Sprintf(`echo "%s%s"; %s 2>&1 || echo "%s%s"`,
+				outputSectionPrefix, command.name, command.command, outputSectionPrefix, snapshotCommandFailed))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// parseNodeNetworkSnapshot parses the output of the snapshot script and returns the snapshot and
+// the number of commands whose output was parsed.
+func parseNodeNetworkSnapshot(node, output string, headTailParams headtail.HeadTailParams) (types.NodeNetworkSnapshotResult, int) {
+	result := types.NodeNetworkSnapshotResult{Node: node}
+	addError := func(name string, err string) {
+		if result.Errors == nil {
+			result.Errors = map[string]string{}
+		}
+		result.Errors[name] = err
+	}
+
+	collected := 0
+	sysctlValues := map[string]string{}
+	sections := splitOutputSections(output)
+	for i, section := range sections {
+		if section.name == snapshotCommandFailed {
+			continue
+		}
+		if i+1 < len(sections) && sections[i+1].name == snapshotCommandFailed {
+			addError(section.name, strings.TrimSpace(strings.Join(section.lines, "; ")))
+			continue
+		}
+		if err := parseSnapshotSection(&result, section, sysctlValues, headTailParams); err != nil {
+			addError(section.name, err.Error())
+			continue
+		}
+		collected++
+	}
+	if result.Sysctl != nil {
+		result.Sysctl.Deviations = []types.SysctlCheck{}
+		result.Sysctl.Checks = []types.SysctlCheck{}
+		result.Sysctl.Interfaces = snapshotSysctlPresentInterfaces(sysctlValues)
+		for _, check := range evaluateSysctlBaseline(sysctlBaselineFor(false), result.Sysctl.Interfaces, sysctlValues, true) {
+			if check.Status == sysctlStatusDeviation {
+				result.Sysctl.Deviations = append(result.Sysctl.Deviations, check)
+			} else {
+				result.Sysctl.Checks = append(result.Sysctl.Checks, check)
+			}
+		}
+	}
+	return result, collected
+}
+
+// snapshotSysctlPresentInterfaces returns the snapshotSysctlInterfaces that exist on the node, i.e.
+// whose per-interface keys were read.
+func snapshotSysctlPresentInterfaces(values map[string]string) []string {
+	var interfaces []string
+	for _, iface := range snapshotSysctlInterfaces {
+		for key := range values {
+			if strings.Contains(key, ".conf."+iface+".") {
+				interfaces = append(interfaces, iface)
+				break
+			}
+		}
+	}
+	return interfaces
+}
+
+// parseSnapshotSection parses the output of a command of the snapshot script into the result.
+// The sysctl values are added to sysctlValues, as the baseline is evaluated once every interface is known.
+func parseSnapshotSection(result *types.NodeNetworkSnapshotResult, section outputSection, sysctlValues map[string]string,
+	headTailParams headtail.HeadTailParams) error {
+	output := strings.Join(section.lines, "\n")
+	switch section.name {
+	case "addresses", "links", "routes", "routes6", "rules", "rules6", "neighbours":
+		if result.IP == nil {
+			result.IP = &types.IPResult{}
+		}
+		return parseSnapshotIPSection(result.IP, section.name, output, headTailParams)
+	case "nft":
+		ruleset, err := parseNFTRuleset(output)
+		if err != nil {
+			return err
+		}
+		nft := newNFTResult(ruleset, "", headTailParams)
+		result.NFT = &nft
+	case "iptables", "ip6tables":
+		if result.IPTables == nil {
+			result.IPTables = &types.IPTablesResult{Families: []types.IPTablesFamilyResult{}}
+		}
+		family := ipFamilyIPv4
+		if section.name == "ip6tables" {
+			family = ipFamilyIPv6
+		}
+		lines := headTailParams.Apply(utils.StripEmptyLines(section.lines), DefaultMaxOutputLines)
+		result.IPTables.Families = append(result.IPTables.Families, types.IPTablesFamilyResult{
+			Family:  family,
+			Command: section.name + "-save",
+			Data:    strings.Join(lines, "\n"),
+		})
+	case "conntrack":
+		usage, err := parseConntrackUsage(section.lines)
+		if err != nil {
+			return err
+		}
+		result.Conntrack = usage
+	case "sysctl":
+		maps.Copy(sysctlValues, parseSysctlOutput(output))
+		result.Sysctl = &types.SysctlResult{Netns: "node " + result.Node}
+	case "ovs_show":
+		version, bridges := parseOVSShow(section.lines)
+		for i := range bridges {
+			bridges[i].Ports = headtail.ApplyTo(&headTailParams, bridges[i].Ports, DefaultMaxOutputLines)
+		}
+		if result.OVS == nil {
+			result.OVS = &types.OVSSnapshot{}
+		}
+		result.OVS.Version, result.OVS.Bridges = version, bridges
+	case "ovs_bridge_mappings":
+		mappings, err := parseOVSBridgeMappings(output)
+		if err != nil {
+			return err
+		}
+		if result.OVS == nil {
+			result.OVS = &types.OVSSnapshot{}
+		}
+		result.OVS.BridgeMappings = mappings
+	}
+	return nil
+}
+
+// parseSnapshotIPSection parses the output of an ip command of the snapshot script. Routes and
+// rules of both families are appended to the same list.
+func parseSnapshotIPSection(ip *types.IPResult, name, output string, headTailParams headtail.HeadTailParams) error {
+	switch name {
+	case "addresses":
+		addresses, err := parseIPAddresses(output)
+		if err != nil {
+			return err
+		}
+		ip.Addresses = headtail.ApplyTo(&headTailParams, addresses, DefaultMaxOutputLines)
+	case "links":
+		links, err := parseIPLinks(output)
+		if err != nil {
+			return err
+		}
+		ip.Links = headtail.ApplyTo(&headTailParams, links, DefaultMaxOutputLines)
+	case "routes", "routes6":
+		routes, err := parseIPRoutes(output, "main")
+		if err != nil {
+			return err
+		}
+		ip.Routes = append(ip.Routes, headtail.ApplyTo(&headTailParams, routes, DefaultMaxOutputLines)...)
+	case "rules", "rules6":
+		rules, err := parseIPRules(output)
+		if err != nil {
+			return err
+		}
+		ip.Rules = append(ip.Rules, headtail.ApplyTo(&headTailParams, rules, DefaultMaxOutputLines)...)
+	case "neighbours":
+		neighbours, err := parseIPNeighbours(output)
+		if err != nil {
+			return err
+		}
+		ip.Neighbours = headtail.ApplyTo(&headTailParams, neighbours, DefaultMaxOutputLines)
+	}
+	return nil
+}
+
+// parseConntrackUsage parses the nf_conntrack_count and nf_conntrack_max values.
+func parseConntrackUsage(lines []string) (*types.ConntrackUsage, error) {
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("failed to parse conntrack usage: expected 2 values, got %d", len(lines))
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse conntrack count %q: %w", lines[0], err)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse conntrack max %q: %w", lines[1], err)
+	}
+	return &types.ConntrackUsage{Count: count, Max: limit}, nil
+}
+
+// parseOVSShow parses 'ovs-vsctl show' output and returns the OVS version and the bridges. Lines
+// that are not bridges, ports, interfaces or one of their known attributes are ignored.
+func parseOVSShow(lines []string) (string, []types.OVSBridge) {
+	var version string
+	bridges := []types.OVSBridge{}
+	var bridge *types.OVSBridge
+	var port *types.OVSPort
+	var iface *types.OVSInterface
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if name, ok := strings.CutPrefix(line, "Bridge "); ok {
+			bridges = append(bridges, types.OVSBridge{Name: strings.Trim(name, `"`), Ports: []types.OVSPort{}})
+			bridge, port, iface = &bridges[len(bridges)-1], nil, nil
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "Port "); ok && bridge != nil {
+			bridge.Ports = append(bridge.Ports, types.OVSPort{Name: strings.Trim(name, `"`), Interfaces: []types.OVSInterface{}})
+			port, iface = &bridge.Ports[len(bridge.Ports)-1], nil
+			continue
+		}
+		if name, ok := strings.CutPrefix(line, "Interface "); ok && port != nil {
+			port.Interfaces = append(port.Interfaces, types.OVSInterface{Name: strings.Trim(name, `"`)})
+			iface = &port.Interfaces[len(port.Interfaces)-1]
+			continue
+		}
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case key == "ovs_version":
+			version = strings.Trim(value, `"`)
+		case key == "fail_mode" && bridge != nil:
+			bridge.FailMode = value
+		case key == "datapath_type" && bridge != nil:
+			bridge.DatapathType = value
+		case key == "tag" && port != nil:
+			port.Tag, _ = strconv.Atoi(value)
+		case key == "type" && iface != nil:
+			iface.Type = value
+		case key == "options" && iface != nil:
+			iface.Options = parseOVSMap(value)
+		case key == "error" && iface != nil:
+			iface.Error = strings.Trim(value, `"`)
+		}
+	}
+	return version, bridges
+}
+
+// parseOVSMap parses an OVS map column, e.g. '{csum="true", key=flow, remote_ip="172.18.0.3"}'.
+func parseOVSMap(value string) map[string]string {
+	values := map[string]string{}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "{"), "}")
+	for _, item := range strings.Split(value, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			continue
+		}
+		values[strings.Trim(key, `"`)] = strings.Trim(value, `"`)
+	}
+	return values
+}
+
+// parseOVSBridgeMappings parses the ovn-bridge-mappings external ID, e.g. '"physnet:breth0,tenant:br-tenant"'.
+// It is empty when the external ID is not set.
+func parseOVSBridgeMappings(output string) (map[string]string, error) {
+	output = strings.Trim(strings.TrimSpace(output), `"`)
+	if output == "" {
+		return nil, nil
+	}
+	mappings := map[string]string{}
+	for _, mapping := range strings.Split(output, ",") {
+		network, bridge, ok := strings.Cut(strings.TrimSpace(mapping), ":")
+		if !ok || network == "" || bridge == "" {
+			return nil, fmt.Errorf("failed to parse bridge mapping %q: must be <network>:<bridge>", mapping)
+		}
+		mappings[network] = bridge
+	}
+	return mappings, nil
+}
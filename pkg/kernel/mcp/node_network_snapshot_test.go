@@ -0,0 +1,279 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/headtail"
+)
+
+const ovsShowOutput = `0b0f5a36-7a2e-4d3c-9c1e-7d1a0e3d9d12
+    Bridge breth0
+        fail_mode: standalone
+        Port eth0
+            Interface eth0
+        Port patch-breth0_ovn-worker-to-br-int
+            Interface patch-breth0_ovn-worker-to-br-int
+                type: patch
+                options: {peer=patch-br-int-to-breth0_ovn-worker}
+        Port breth0
+            Interface breth0
+                type: internal
+    Bridge br-int
+        fail_mode: secure
+        datapath_type: system
+        Port ovn-6f0b3c-0
+            Interface ovn-6f0b3c-0
+                type: geneve
+                options: {csum="true", key=flow, remote_ip="172.18.0.4"}
+        Port vlan10
+            tag: 10
+            Interface vlan10
+                type: internal
+                error: "could not open network device vlan10 (No such device)"
+    ovs_version: "3.1.2"`
+
+// nodeNetworkSnapshotOutput returns the output of the snapshot script with every section, the nft
+// command failing.
+func nodeNetworkSnapshotOutput() string {
+	return `### addresses
+[{"ifindex":6,"ifname":"breth0","addr_info":[{"family":"inet","local":"172.18.0.3","prefixlen":16,"scope":"global"}]}]
+### links
+[{"ifindex":6,"ifname":"breth0","flags":["UP"],"mtu":1500,"operstate":"UNKNOWN","link_type":"ether","address":"02:42:ac:12:00:03"}]
+### routes
+[{"dst":"default","gateway":"172.18.0.1","dev":"breth0","protocol":"static","flags":[]}]
+### routes6
+[{"dst":"fd00:10:244:1::/64","dev":"ovn-k8s-mp0","protocol":"kernel","metric":256,"flags":[]}]
+### rules
+[{"priority":0,"src":"all","table":"local"}]
+### rules6
+### neighbours
+[{"dst":"172.18.0.1","dev":"breth0","lladdr":"02:42:1a:2b:3c:4d","state":["REACHABLE"]}]
+### nft
+sh: nft: not found
+### failed
+### iptables
+*nat
+:PREROUTING ACCEPT [0:0]
+-A PREROUTING -j OVN-KUBE-ETP
+COMMIT
+### ip6tables
+### conntrack
+1234
+262144
+### sysctl
+net.ipv4.ip_forward = 1
+net.ipv4.conf.all.rp_filter = 0
+net.ipv4.conf.breth0.rp_filter = 1
+### ovs_show
+` + ovsShowOutput + `
+### ovs_bridge_mappings
+"physnet:breth0,tenant:br-tenant"
+`
+}
+
+func TestParseNodeNetworkSnapshot(t *testing.T) {
+	result, collected := parseNodeNetworkSnapshot("ovn-worker", nodeNetworkSnapshotOutput(), headtail.HeadTailParams{})
+	if collected != 13 {
+		t.Errorf("collected = %d, want 13", collected)
+	}
+	if diff := cmp.Diff(map[string]string{"nft": "sh: nft: not found"}, result.Errors); diff != "" {
+		t.Errorf("errors mismatch (-want +got):\n%s", diff)
+	}
+	if result.NFT != nil {
+		t.Errorf("nft = %+v, want nil for a failed command", result.NFT)
+	}
+	if result.IP == nil || len(result.IP.Addresses) != 1 || len(result.IP.Links) != 1 || len(result.IP.Neighbours) != 1 || len(result.IP.Rules) != 1 {
+		t.Fatalf("ip = %+v, want one address, link, neighbour and rule", result.IP)
+	}
+	var routes []string
+	for _, route := range result.IP.Routes {
+		routes = append(routes, route.Dst+" "+route.Table)
+	}
+	if diff := cmp.Diff([]string{"default main", "fd00:10:244:1::/64 main"}, routes); diff != "" {
+		t.Errorf("routes mismatch (-want +got):\n%s", diff)
+	}
+	if result.IPTables == nil || len(result.IPTables.Families) != 2 {
+		t.Fatalf("iptables = %+v, want both families", result.IPTables)
+	}
+	if got := result.IPTables.Families[0]; got.Family != ipFamilyIPv4 || got.Command != "iptables-save" || !strings.Contains(got.Data, "OVN-KUBE-ETP") {
+		t.Errorf("iptables family = %+v", got)
+	}
+	if diff := cmp.Diff(&types.ConntrackUsage{Count: 1234, Max: 262144}, result.Conntrack); diff != "" {
+		t.Errorf("conntrack mismatch (-want +got):\n%s", diff)
+	}
+	if result.Sysctl == nil {
+		t.Fatalf("sysctl = nil")
+	}
+	if diff := cmp.Diff([]string{"breth0"}, result.Sysctl.Interfaces); diff != "" {
+		t.Errorf("sysctl interfaces mismatch (-want +got):\n%s", diff)
+	}
+	var deviations []string
+	for _, check := range result.Sysctl.Deviations {
+		deviations = append(deviations, check.Key)
+	}
+	if diff := cmp.Diff([]string{"net.ipv4.conf.breth0.rp_filter"}, deviations); diff != "" {
+		t.Errorf("sysctl deviations mismatch (-want +got):\n%s", diff)
+	}
+	if result.OVS == nil || result.OVS.Version != "3.1.2" || len(result.OVS.Bridges) != 2 {
+		t.Fatalf("ovs = %+v, want version 3.1.2 and two bridges", result.OVS)
+	}
+	if diff := cmp.Diff(map[string]string{"physnet": "breth0", "tenant": "br-tenant"}, result.OVS.BridgeMappings); diff != "" {
+		t.Errorf("bridge mappings mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseNodeNetworkSnapshotErrors(t *testing.T) {
+	output := "### conntrack\n1234\n### ovs_bridge_mappings\n\"physnet\"\n### sysctl\ncat: can't open\n### failed\n"
+	result, collected := parseNodeNetworkSnapshot("ovn-worker", output, headtail.HeadTailParams{})
+	if collected != 0 {
+		t.Errorf("collected = %d, want 0", collected)
+	}
+	for _, name := range []string{"conntrack", "ovs_bridge_mappings", "sysctl"} {
+		if result.Errors[name] == "" {
+			t.Errorf("errors[%s] is empty, want an error", name)
+		}
+	}
+}
+
+func TestParseOVSShow(t *testing.T) {
+	want := []types.OVSBridge{
+		{
+			Name:     "breth0",
+			FailMode: "standalone",
+			Ports: []types.OVSPort{
+				{Name: "eth0", Interfaces: []types.OVSInterface{{Name: "eth0"}}},
+				{Name: "patch-breth0_ovn-worker-to-br-int", Interfaces: []types.OVSInterface{{
+					Name:    "patch-breth0_ovn-worker-to-br-int",
+					Type:    "patch",
+					Options: map[string]string{"peer": "patch-br-int-to-breth0_ovn-worker"},
+				}}},
+				{Name: "breth0", Interfaces: []types.OVSInterface{{Name: "breth0", Type: "internal"}}},
+			},
+		},
+		{
+			Name:         "br-int",
+			FailMode:     "secure",
+			DatapathType: "system",
+			Ports: []types.OVSPort{
+				{Name: "ovn-6f0b3c-0", Interfaces: []types.OVSInterface{{
+					Name:    "ovn-6f0b3c-0",
+					Type:    "geneve",
+					Options: map[string]string{"csum": "true", "key": "flow", "remote_ip": "172.18.0.4"},
+				}}},
+				{Name: "vlan10", Tag: 10, Interfaces: []types.OVSInterface{{
+					Name:  "vlan10",
+					Type:  "internal",
+					Error: "could not open network device vlan10 (No such device)",
+				}}},
+			},
+		},
+	}
+	version, bridges := parseOVSShow(strings.Split(ovsShowOutput, "\n"))
+	if version != "3.1.2" {
+		t.Errorf("version = %q, want 3.1.2", version)
+	}
+	if diff := cmp.Diff(want, bridges); diff != "" {
+		t.Errorf("parseOVSShow() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidateNodeNetworkSnapshot(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       types.NodeNetworkSnapshotParams
+		expected []string
+		wantErr  string
+	}{
+		{
+			name:     "all sections by default",
+			in:       types.NodeNetworkSnapshotParams{CommonParams: types.CommonParams{Node: "ovn-worker"}},
+			expected: []string{"ip", "nft", "iptables", "conntrack", "sysctl", "ovs"},
+		},
+		{
+			name:     "sections in output order",
+			in:       types.NodeNetworkSnapshotParams{CommonParams: types.CommonParams{Node: "ovn-worker"}, Sections: "OVS, ip"},
+			expected: []string{"ip", "ovs"},
+		},
+		{
+			name:    "node is required",
+			in:      types.NodeNetworkSnapshotParams{},
+			wantErr: "node is required",
+		},
+		{
+			name:    "invalid section",
+			in:      types.NodeNetworkSnapshotParams{CommonParams: types.CommonParams{Node: "ovn-worker"}, Sections: "ip,routes"},
+			wantErr: "invalid sections routes",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := validateNodeNetworkSnapshot(tt.in)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("validateNodeNetworkSnapshot() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateNodeNetworkSnapshot() error = %v", err)
+			}
+			if diff := cmp.Diff(tt.expected, sections); diff != "" {
+				t.Errorf("sections mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNodeNetworkSnapshot(t *testing.T) {
+	var commands [][]string
+	output := nodeNetworkSnapshotOutput()
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		commands = append(commands, cmd)
+		return output, "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.NodeNetworkSnapshot(context.Background(), nil, types.NodeNetworkSnapshotParams{
+		CommonParams: types.CommonParams{Node: "ovn-worker"},
+	})
+	if err != nil {
+		t.Fatalf("NodeNetworkSnapshot() error = %v", err)
+	}
+	if len(commands) != 1 || len(commands[0]) != 3 || commands[0][0] != "sh" {
+		t.Fatalf("commands = %q, want a single script", commands)
+	}
+	script := commands[0][2]
+	for _, want := range []string{"ip -j address show", "nft -j list ruleset", "iptables-save", "nf_conntrack_max", "sysctl -e net.ipv4.ip_forward",
+		"ovs-vsctl --timeout=5 --db=unix:/host/var/run/openvswitch/db.sock", "ovs show"} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script %q does not contain %q", script, want)
+		}
+	}
+	if result.Node != "ovn-worker" || result.Conntrack == nil || result.Errors["nft"] == "" {
+		t.Errorf("NodeNetworkSnapshot() = %+v", result)
+	}
+
+	// Only the requested sections are collected, and a snapshot without any output is an error.
+	commands = nil
+	output = "### nft\nsh: nft: not found\n### failed\n"
+	if _, _, err := server.NodeNetworkSnapshot(context.Background(), nil, types.NodeNetworkSnapshotParams{
+		CommonParams: types.CommonParams{Node: "ovn-worker"},
+		Sections:     "nft",
+	}); err == nil || !strings.Contains(err.Error(), "every command failed") {
+		t.Errorf("NodeNetworkSnapshot() error = %v, want every command failed", err)
+	}
+	if script := commands[0][2]; strings.Contains(script, "ip -j") || strings.Contains(script, "ovs()") {
+		t.Errorf("script %q collects sections that were not requested", script)
+	}
+}
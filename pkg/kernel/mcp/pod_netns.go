@@ -0,0 +1,157 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+)
+
+// hostMountPath is where the debug pod mounts the root filesystem of the node.
+const hostMountPath = "/host"
+
+// podNetns identifies the network namespace of a pod sandbox on a node.
+type podNetns struct {
+	podNamespace string
+	podName      string
+	pid          int
+	path         string
+}
+
+// nsenterArgs returns the nsenter command prefix that runs a command in the network namespace.
+// The pid is preferred since the debug pod shares the host PID namespace, otherwise the
+// namespace path is entered through the host root mount.
+func (n podNetns) nsenterArgs() []string {
+	if n.pid > 0 {
+		return []string{"nsenter", "-t", strconv.Itoa(n.pid), "-n", "--"}
+	}
+	return []string{"nsenter", "--net=" + hostMountPath + n.path, "--"}
+}
+
+// String describes the network namespace.
+func (n podNetns) String() string {
+	if n.pid > 0 {
+		return fmt.Sprintf("pod %s/%s (pid %d)", n.podNamespace, n.podName, n.pid)
+	}
+	return fmt.Sprintf("pod %s/%s (%s)", n.podNamespace, n.podName, n.path)
+}
+
+// validatePodTarget validates the pod whose network namespace is used. Either both or neither of
+// the pod namespace and name must be given.
+func validatePodTarget(pod types.PodTargetParams) error {
+	if pod.PodNamespace == "" && pod.PodName == "" {
+		return nil
+	}
+	if pod.PodNamespace == "" || pod.PodName == "" {
+		return fmt.Errorf("pod_namespace and pod_name must be given together")
+	}
+	if !utils.IsKubernetesName(pod.PodNamespace) {
+		return fmt.Errorf("invalid pod_namespace %q", pod.PodNamespace)
+	}
+	if !utils.IsKubernetesName(pod.PodName) {
+		return fmt.Errorf("invalid pod_name %q", pod.PodName)
+	}
+	return nil
+}
+
+// resolvePodNetns finds the network namespace of a pod running on the node by looking up its
+// ready sandbox with crictl on the host.
+func (s *MCPServer) resolvePodNetns(ctx context.Context, namespace, node, podNamespace, podName string) (podNetns, error) {
+	cmd := commandbuilder.NewCommand("chroot", hostMountPath, "crictl", "pods",
+		"--namespace", podNamespace, "--name", podName, "--state", "ready", "-o", "json")
+	stdout, _, err := s.executeCommand(ctx, namespace, node, cmd.Build())
+	if err != nil {
+		return podNetns{}, fmt.Errorf("failed to list pod sandboxes with crictl: %w", err)
+	}
+	sandboxID, err := parseCrictlPodSandboxID(stdout, podNamespace, podName)
+	if err != nil {
+		return podNetns{}, fmt.Errorf("failed to find pod %s/%s on node %s: %w", podNamespace, podName, node, err)
+	}
+
+	cmd = commandbuilder.NewCommand("chroot", hostMountPath, "crictl", "inspectp", "-o", "json", sandboxID)
+	stdout, _, err = s.executeCommand(ctx, namespace, node, cmd.Build())
+	if err != nil {
+		return podNetns{}, fmt.Errorf("failed to inspect pod sandbox %s with crictl: %w", sandboxID, err)
+	}
+	netns, err := parseCrictlPodSandboxNetns(stdout)
+	if err != nil {
+		return podNetns{}, fmt.Errorf("failed to find network namespace of pod %s/%s: %w", podNamespace, podName, err)
+	}
+	netns.podNamespace = podNamespace
+	netns.podName = podName
+	return netns, nil
+}
+
+// podNetnsPrefix returns the command prefix that runs commands in the network namespace of the pod,
+// and the description of the namespace. Without a pod, commands run in the node network namespace
+// and both are empty.
+func (s *MCPServer) podNetnsPrefix(ctx context.Context, namespace, node string, pod types.PodTargetParams) ([]string, string, error) {
+	if pod.PodName == "" {
+		return nil, "", nil
+	}
+	netns, err := s.resolvePodNetns(ctx, namespace, node, pod.PodNamespace, pod.PodName)
+	if err != nil {
+		return nil, "", err
+	}
+	return netns.nsenterArgs(), netns.String(), nil
+}
+
+// parseCrictlPodSandboxID returns the ID of the ready sandbox of the pod from 'crictl pods -o json'
+// output. The crictl name filter is a regular expression, so the name is matched exactly here.
+func parseCrictlPodSandboxID(output, podNamespace, podName string) (string, error) {
+	var pods struct {
+		Items []struct {
+			ID       string `json:"id"`
+			State    string `json:"state"`
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(output), &pods); err != nil {
+		return "", fmt.Errorf("failed to parse crictl pods output: %w", err)
+	}
+	for _, pod := range pods.Items {
+		if pod.Metadata.Name == podName && pod.Metadata.Namespace == podNamespace && pod.State == "SANDBOX_READY" {
+			return pod.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no ready pod sandbox found")
+}
+
+// parseCrictlPodSandboxNetns returns the network namespace of a sandbox from 'crictl inspectp -o json'
+// output, using the sandbox pid or the network namespace path of its runtime spec.
+func parseCrictlPodSandboxNetns(output string) (podNetns, error) {
+	var sandbox struct {
+		Info struct {
+			PID         int `json:"pid"`
+			RuntimeSpec struct {
+				Linux struct {
+					Namespaces []struct {
+						Type string `json:"type"`
+						Path string `json:"path"`
+					} `json:"namespaces"`
+				} `json:"linux"`
+			} `json:"runtimeSpec"`
+		} `json:"info"`
+	}
+	if err := json.Unmarshal([]byte(output), &sandbox); err != nil {
+		return podNetns{}, fmt.Errorf("failed to parse crictl inspectp output: %w", err)
+	}
+	netns := podNetns{pid: sandbox.Info.PID}
+	for _, namespace := range sandbox.Info.RuntimeSpec.Linux.Namespaces {
+		if namespace.Type == "network" && strings.HasPrefix(namespace.Path, "/") {
+			netns.path = namespace.Path
+		}
+	}
+	if netns.pid <= 0 && netns.path == "" {
+		return podNetns{}, fmt.Errorf("sandbox has neither a pid nor a network namespace path")
+	}
+	return netns, nil
+}
@@ -0,0 +1,229 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+func TestParseCrictlPodSandboxID(t *testing.T) {
+	output := `{"items":[` +
+		`{"id":"old","metadata":{"name":"client","namespace":"default"},"state":"SANDBOX_NOTREADY"},` +
+		`{"id":"other","metadata":{"name":"client-2","namespace":"default"},"state":"SANDBOX_READY"},` +
+		`{"id":"ready","metadata":{"name":"client","namespace":"default"},"state":"SANDBOX_READY"}]}`
+	tests := []struct {
+		name      string
+		podName   string
+		expected  string
+		wantError bool
+	}{
+		{name: "ready sandbox of the pod", podName: "client", expected: "ready"},
+		{name: "name is matched exactly", podName: "client-", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := parseCrictlPodSandboxID(output, "default", tt.podName)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("parseCrictlPodSandboxID() error = %v, wantError %v", err, tt.wantError)
+			}
+			if id != tt.expected {
+				t.Errorf("parseCrictlPodSandboxID() = %q, expected %q", id, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseCrictlPodSandboxNetns(t *testing.T) {
+	tests := []struct {
+		name      string
+		output    string
+		expected  []string
+		wantError bool
+	}{
+		{
+			name:     "sandbox pid",
+			output:   `{"info":{"pid":4242,"runtimeSpec":{"linux":{"namespaces":[{"type":"network","path":"/var/run/netns/cni-1234"}]}}}}`,
+			expected: []string{"nsenter", "-t", "4242", "-n", "--"},
+		},
+		{
+			name:     "network namespace path",
+			output:   `{"info":{"runtimeSpec":{"linux":{"namespaces":[{"type":"pid"},{"type":"network","path":"/var/run/netns/cni-1234"}]}}}}`,
+			expected: []string{"nsenter", "--net=/host/var/run/netns/cni-1234", "--"},
+		},
+		{
+			name:      "no network namespace",
+			output:    `{"info":{}}`,
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			netns, err := parseCrictlPodSandboxNetns(tt.output)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("parseCrictlPodSandboxNetns() error = %v, wantError %v", err, tt.wantError)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.expected, netns.nsenterArgs()); diff != "" {
+				t.Errorf("nsenterArgs() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestPodTargetKernelTools(t *testing.T) {
+	pod := types.PodTargetParams{PodNamespace: "default", PodName: "client"}
+	common := types.CommonParams{Node: "ovn-worker"}
+	tests := []struct {
+		name string
+		// call runs the tool in the pod and returns the network namespace reported in its result.
+		call         func(s *MCPServer) (string, error)
+		wantCommands []string
+	}{
+		{
+			name: "get-ip",
+			call: func(s *MCPServer) (string, error) {
+				_, result, err := s.GetIPCommandOutput(context.Background(), nil, types.ListIPParams{CommonParams: common, PodTargetParams: pod, Command: "route show"})
+				return result.Netns, err
+			},
+			wantCommands: []string{"nsenter -t 4242 -n -- ip -j route show"},
+		},
+		{
+			name: "get-conntrack",
+			call: func(s *MCPServer) (string, error) {
+				_, result, err := s.GetConntrack(context.Background(), nil, types.ListConntrackParams{CommonParams: common, PodTargetParams: pod})
+				return result.Netns, err
+			},
+			wantCommands: []string{"nsenter -t 4242 -n -- conntrack -L"},
+		},
+		{
+			name: "get-conntrack-events",
+			call: func(s *MCPServer) (string, error) {
+				_, result, err := s.GetConntrackEvents(context.Background(), nil, types.ConntrackEventsParams{CommonParams: common, PodTargetParams: pod, DurationSeconds: 5})
+				return result.Netns, err
+			},
+			wantCommands: []string{"nsenter -t 4242 -n -- timeout --preserve-status -s INT 5 conntrack -E -o timestamp"},
+		},
+		{
+			name: "get-conntrack-entry",
+			call: func(s *MCPServer) (string, error) {
+				_, result, err := s.GetConntrackEntry(context.Background(), nil, types.ConntrackLookupParams{
+					Node: "ovn-worker", PodTargetParams: pod, Protocol: "tcp", Src: "10.244.1.3", Dst: "10.96.0.1", Sport: 40000, Dport: 443,
+				})
+				return result.Netns, err
+			},
+			wantCommands: []string{"nsenter -t 4242 -n -- conntrack -L -p tcp -s 10.244.1.3 -d 10.96.0.1 --sport 40000 --dport 443"},
+		},
+		{
+			name: "get-iptables",
+			call: func(s *MCPServer) (string, error) {
+				_, result, err := s.GetIptables(context.Background(), nil, types.ListIPTablesParams{CommonParams: common, PodTargetParams: pod, Table: "nat"})
+				return result.Netns, err
+			},
+			wantCommands: []string{"nsenter -t 4242 -n -- iptables -t nat -L"},
+		},
+		{
+			name: "trace-iptables",
+			call: func(s *MCPServer) (string, error) {
+				_, result, err := s.TraceIPTables(context.Background(), nil, types.IPTablesTraceParams{
+					Node: "ovn-worker", PodTargetParams: pod, Hook: "OUTPUT", Protocol: "tcp", Src: "10.244.1.3", Dst: "10.96.0.1", Dport: 443,
+				})
+				return result.Netns, err
+			},
+			wantCommands: []string{"nsenter -t 4242 -n -- iptables-save", "nsenter -t 4242 -n -- ip -j address show"},
+		},
+		{
+			name: "get-nft",
+			call: func(s *MCPServer) (string, error) {
+				_, result, err := s.GetNFT(context.Background(), nil, types.ListNFTParams{CommonParams: common, PodTargetParams: pod, Command: "list ruleset"})
+				return result.Netns, err
+			},
+			wantCommands: []string{"nsenter -t 4242 -n -- nft -j list ruleset"},
+		},
+		{
+			name: "search-nft",
+			call: func(s *MCPServer) (string, error) {
+				_, result, err := s.SearchNFT(context.Background(), nil, types.SearchNFTParams{CommonParams: common, PodTargetParams: pod, Port: 53})
+				return result.Netns, err
+			},
+			wantCommands: []string{"nsenter -t 4242 -n -- nft -j list ruleset"},
+		},
+		{
+			name: "get-sockets",
+			call: func(s *MCPServer) (string, error) {
+				_, result, err := s.GetSockets(context.Background(), nil, types.SocketsParams{CommonParams: common, PodTargetParams: pod, State: "listen"})
+				return result.Netns, err
+			},
+			wantCommands: []string{"nsenter -t 4242 -n -- ss -tunapeiOH"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+				command := strings.Join(cmd, " ")
+				switch {
+				case strings.HasSuffix(command, " -V"):
+					return "", "", nil
+				case strings.Contains(command, "crictl pods"):
+					return `{"items":[{"id":"a1b2","metadata":{"name":"client","namespace":"default"},"state":"SANDBOX_READY"}]}`, "", nil
+				case strings.Contains(command, "crictl inspectp"):
+					return `{"info":{"pid":4242}}`, "", nil
+				case strings.Contains(command, "nft -j"):
+					commands = append(commands, command)
+					return `{"nftables":[{"metainfo":{"json_schema_version":1}}]}`, "", nil
+				case strings.Contains(command, "ip -j address"):
+					commands = append(commands, command)
+					return "[]", "", nil
+				}
+				commands = append(commands, command)
+				return "", "", nil
+			}
+			runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+				return "", "", nil
+			}
+			server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+			if err != nil {
+				t.Fatalf("NewMCPServer() error = %v", err)
+			}
+
+			netns, err := tt.call(server)
+			if err != nil {
+				t.Fatalf("%s error = %v", tt.name, err)
+			}
+			if diff := cmp.Diff(tt.wantCommands, commands); diff != "" {
+				t.Errorf("commands mismatch (-want +got):\n%s", diff)
+			}
+			if netns != "pod default/client (pid 4242)" {
+				t.Errorf("netns = %q, want pod default/client (pid 4242)", netns)
+			}
+		})
+	}
+}
+
+func TestGetIPNetnsWithPodTarget(t *testing.T) {
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		return "", "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+	_, _, err = server.GetIPCommandOutput(context.Background(), nil, types.ListIPParams{
+		CommonParams:    types.CommonParams{Node: "ovn-worker"},
+		PodTargetParams: types.PodTargetParams{PodNamespace: "default", PodName: "client"},
+		Command:         "route show",
+		Netns:           "ns1",
+	})
+	if err == nil || !strings.Contains(err.Error(), "netns cannot be used with pod_namespace and pod_name") {
+		t.Errorf("GetIPCommandOutput() error = %v, want netns conflict error", err)
+	}
+}
@@ -0,0 +1,269 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+)
+
+// GetRouteDecision asks the kernel how it would route a packet with 'ip route get', on the node
+// or in the network namespace of a pod running on the node. The routing policy rules are listed
+// as well to report the first rule matching the packet that selects the table of the route.
+func (s *MCPServer) GetRouteDecision(ctx context.Context, req *mcp.CallToolRequest, in types.RouteGetParams) (*mcp.CallToolResult, types.RouteGetResult, error) {
+	flow, err := validateRouteGet(in)
+	if err != nil {
+		return nil, types.RouteGetResult{}, fmt.Errorf("error while getting route decision: %w", err)
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	if err := s.utilityExists(ctx, in.Namespace, in.Node, "ip"); err != nil {
+		return nil, types.RouteGetResult{}, fmt.Errorf("error while getting route decision: failed to verify ip utility availability in configured image: %w", err)
+	}
+
+	prefix, netns, err := s.podNetnsPrefix(ctx, in.Namespace, in.Node, in.PodTargetParams)
+	if err != nil {
+		return nil, types.RouteGetResult{}, fmt.Errorf("error while getting route decision: %w", err)
+	}
+	result := types.RouteGetResult{Netns: cmp.Or(netns, "node "+in.Node)}
+
+	cmd := commandbuilder.NewCommand(prefix...)
+	cmd.Add(routeGetArgs(in)...)
+	stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+	if err != nil {
+		return nil, types.RouteGetResult{}, fmt.Errorf("error while getting route decision: no route for %s, or 'ip route get' failed: %w", in.Dst, err)
+	}
+	if stderr != "" {
+		return nil, types.RouteGetResult{}, fmt.Errorf("error while running command: %s", stderr)
+	}
+	result.Route, err = parseRouteDecision(stdout)
+	if err != nil {
+		return nil, types.RouteGetResult{}, fmt.Errorf("error while getting route decision: %w", err)
+	}
+
+	cmd = commandbuilder.NewCommand(prefix...)
+	cmd.Add("ip")
+	cmd.AddIf(flow.dst.To4() == nil, "-6")
+	cmd.Add("-j", "rule", "show")
+	stdout, stderr, err = s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+	if err != nil {
+		return nil, types.RouteGetResult{}, fmt.Errorf("error while getting route decision: failed to list routing policy rules: %w", err)
+	}
+	if stderr != "" {
+		return nil, types.RouteGetResult{}, fmt.Errorf("error while running command: %s", stderr)
+	}
+	rules, err := parseIPRules(stdout)
+	if err != nil {
+		return nil, types.RouteGetResult{}, fmt.Errorf("error while getting route decision: %w", err)
+	}
+	result.Rule = matchIPRule(rules, flow, result.Route.Table)
+	return nil, result, nil
+}
+
+// routeFlow is the packet whose route is looked up, used to match routing policy rules.
+type routeFlow struct {
+	src    net.IP
+	dst    net.IP
+	iif    string
+	fwmark uint32
+	uid    uint32
+	vrf    string
+}
+
+// validateRouteGet validates the lookup parameters and returns the packet to match rules against.
+func validateRouteGet(in types.RouteGetParams) (routeFlow, error) {
+	flow := routeFlow{iif: in.IIF, vrf: in.VRF}
+	if flow.dst = net.ParseIP(in.Dst); flow.dst == nil {
+		return flow, fmt.Errorf("invalid dst %q: must be an IP address", in.Dst)
+	}
+	if in.Src != "" {
+		if flow.src = net.ParseIP(in.Src); flow.src == nil {
+			return flow, fmt.Errorf("invalid src %q: must be an IP address", in.Src)
+		}
+		if (flow.src.To4() == nil) != (flow.dst.To4() == nil) {
+			return flow, fmt.Errorf("src and dst must belong to the same address family")
+		}
+	}
+	if in.IIF != "" {
+		if !validIPDeviceName.MatchString(in.IIF) {
+			return flow, fmt.Errorf("invalid iif %q: must be a network device name", in.IIF)
+		}
+		if in.Src == "" {
+			return flow, fmt.Errorf("iif requires src")
+		}
+	}
+	if in.VRF != "" && !validIPDeviceName.MatchString(in.VRF) {
+		return flow, fmt.Errorf("invalid vrf %q: must be a VRF device name", in.VRF)
+	}
+	if in.FwMark != "" {
+		mark, err := strconv.ParseUint(in.FwMark, 0, 32)
+		if err != nil {
+			return flow, fmt.Errorf("invalid fwmark %q: must be a 32 bit number", in.FwMark)
+		}
+		flow.fwmark = uint32(mark)
+	}
+	if in.UID != "" {
+		uid, err := strconv.ParseUint(in.UID, 10, 32)
+		if err != nil {
+			return flow, fmt.Errorf("invalid uid %q: must be a 32 bit number", in.UID)
+		}
+		flow.uid = uint32(uid)
+	}
+	if err := validatePodTarget(in.PodTargetParams); err != nil {
+		return flow, err
+	}
+	return flow, nil
+}
+
+// routeGetArgs returns the 'ip route get' command for the validated parameters.
+func routeGetArgs(in types.RouteGetParams) []string {
+	cmd := commandbuilder.NewCommand("ip", "-j", "route", "get")
+	cmd.AddIfNotEmpty(in.VRF, "vrf", in.VRF)
+	cmd.Add("to", in.Dst)
+	cmd.AddIfNotEmpty(in.Src, "from", in.Src)
+	cmd.AddIfNotEmpty(in.IIF, "iif", in.IIF)
+	cmd.AddIfNotEmpty(in.FwMark, "mark", in.FwMark)
+	cmd.AddIfNotEmpty(in.UID, "uid", in.UID)
+	return cmd.Build()
+}
+
+// ipRouteGetJSON is a route printed by 'ip -j route get'.
+type ipRouteGetJSON struct {
+	ipRouteJSON
+	From string          `json:"from"`
+	IIF  string          `json:"iif"`
+	Mark json.RawMessage `json:"mark"`
+	UID  *int            `json:"uid"`
+}
+
+// parseRouteDecision parses 'ip -j route get' output. The main table is omitted by ip.
+func parseRouteDecision(output string) (types.RouteDecision, error) {
+	var raw []ipRouteGetJSON
+	if err := unmarshalIPJSON(output, &raw); err != nil {
+		return types.RouteDecision{}, err
+	}
+	if len(raw) == 0 {
+		return types.RouteDecision{}, fmt.Errorf("ip route get returned no route")
+	}
+	r := raw[0]
+	route := r.toIPRoute("main")
+	decision := types.RouteDecision{
+		Type:    route.Type,
+		Dst:     route.Dst,
+		Src:     r.From,
+		IIF:     r.IIF,
+		Gateway: route.Gateway,
+		Dev:     route.Dev,
+		Table:   route.Table,
+		PrefSrc: route.PrefSrc,
+		UID:     r.UID,
+		MTU:     route.MTU,
+		Flags:   route.Flags,
+	}
+	if len(r.Mark) > 0 {
+		decision.FwMark = ipJSONHex(r.Mark)
+	}
+	return decision, nil
+}
+
+// matchIPRule returns the first rule in priority order whose selector matches the packet and that
+// looks up the given table, or that selects the VRF table when the packet is associated with a VRF.
+// Rules that only match are skipped if they look up another table, as the kernel falls through to
+// the next rule when that table has no route for the packet.
+func matchIPRule(rules []types.IPRule, flow routeFlow, table string) *types.IPRule {
+	sorted := slices.Clone(rules)
+	slices.SortStableFunc(sorted, func(a, b types.IPRule) int { return a.Priority - b.Priority })
+	for i := range sorted {
+		rule := sorted[i]
+		if rule.Action != "" || !ipRuleSelectorMatches(rule, flow) {
+			continue
+		}
+		if rule.Table == table || (rule.L3MDev && flow.vrf != "") {
+			return &rule
+		}
+	}
+	return nil
+}
+
+// ipRuleSelectorMatches reports whether the selector of the rule matches the packet. Locally
+// generated packets have the loopback device as input interface.
+func ipRuleSelectorMatches(rule types.IPRule, flow routeFlow) bool {
+	iif := flow.iif
+	if iif == "" {
+		iif = "lo"
+	}
+	matches := ipRulePrefixMatches(rule.Src, flow.src) &&
+		ipRulePrefixMatches(rule.Dst, flow.dst) &&
+		(rule.IIF == "" || rule.IIF == iif) &&
+		(rule.OIF == "" || rule.OIF == flow.vrf) &&
+		ipRuleFwMarkMatches(rule.FwMark, flow.fwmark) &&
+		ipRuleUIDRangeMatches(rule.UIDRange, flow.uid) &&
+		(!rule.L3MDev || flow.vrf != "")
+	return matches != rule.Not
+}
+
+// ipRulePrefixMatches reports whether the address is in the prefix of a rule selector, which ip
+// prints as "all", as a plain address for host prefixes, or as a CIDR.
+func ipRulePrefixMatches(prefix string, ip net.IP) bool {
+	if prefix == "" || prefix == "all" {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	if !strings.Contains(prefix, "/") {
+		return net.ParseIP(prefix).Equal(ip)
+	}
+	_, network, err := net.ParseCIDR(prefix)
+	return err == nil && network.Contains(ip)
+}
+
+// ipRuleFwMarkMatches reports whether the mark matches a rule fwmark selector value[/mask].
+func ipRuleFwMarkMatches(selector string, mark uint32) bool {
+	if selector == "" {
+		return true
+	}
+	valueString, maskString, hasMask := strings.Cut(selector, "/")
+	value, err := strconv.ParseUint(valueString, 0, 32)
+	if err != nil {
+		return false
+	}
+	mask := uint64(0xffffffff)
+	if hasMask {
+		if mask, err = strconv.ParseUint(maskString, 0, 32); err != nil {
+			return false
+		}
+	}
+	return (uint64(mark)^value)&mask == 0
+}
+
+// ipRuleUIDRangeMatches reports whether the uid is in a rule uid range selector start-end.
+func ipRuleUIDRangeMatches(selector string, uid uint32) bool {
+	if selector == "" {
+		return true
+	}
+	startString, endString, _ := strings.Cut(selector, "-")
+	start, err := strconv.ParseUint(startString, 10, 32)
+	if err != nil {
+		return false
+	}
+	end, err := strconv.ParseUint(endString, 10, 32)
+	if err != nil {
+		return false
+	}
+	return uint64(uid) >= start && uint64(uid) <= end
+}
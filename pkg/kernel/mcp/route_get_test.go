@@ -0,0 +1,235 @@
+package mcp
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+func TestValidateRouteGet(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    types.RouteGetParams
+		expected  []string
+		wantError bool
+	}{
+		{
+			name:     "destination only",
+			params:   types.RouteGetParams{Dst: "10.96.0.1"},
+			expected: []string{"ip", "-j", "route", "get", "to", "10.96.0.1"},
+		},
+		{
+			name:     "forwarded packet with mark",
+			params:   types.RouteGetParams{Dst: "8.8.8.8", Src: "10.244.1.3", IIF: "ovn-k8s-mp0", FwMark: "0x1745ec"},
+			expected: []string{"ip", "-j", "route", "get", "to", "8.8.8.8", "from", "10.244.1.3", "iif", "ovn-k8s-mp0", "mark", "0x1745ec"},
+		},
+		{
+			name:     "vrf and uid",
+			params:   types.RouteGetParams{Dst: "fd00::a", VRF: "mp1-udn-vrf", UID: "1000"},
+			expected: []string{"ip", "-j", "route", "get", "vrf", "mp1-udn-vrf", "to", "fd00::a", "uid", "1000"},
+		},
+		{
+			name:      "invalid destination",
+			params:    types.RouteGetParams{Dst: "default"},
+			wantError: true,
+		},
+		{
+			name:      "mixed address families",
+			params:    types.RouteGetParams{Dst: "10.96.0.1", Src: "fd00::1"},
+			wantError: true,
+		},
+		{
+			name:      "iif without src",
+			params:    types.RouteGetParams{Dst: "10.96.0.1", IIF: "breth0"},
+			wantError: true,
+		},
+		{
+			name:      "invalid fwmark",
+			params:    types.RouteGetParams{Dst: "10.96.0.1", FwMark: "0x1ffffffff"},
+			wantError: true,
+		},
+		{
+			name:      "invalid uid",
+			params:    types.RouteGetParams{Dst: "10.96.0.1", UID: "-1"},
+			wantError: true,
+		},
+		{
+			name:      "pod name without namespace",
+			params:    types.RouteGetParams{Dst: "10.96.0.1", PodTargetParams: types.PodTargetParams{PodName: "client"}},
+			wantError: true,
+		},
+		{
+			name:      "invalid pod name",
+			params:    types.RouteGetParams{Dst: "10.96.0.1", PodTargetParams: types.PodTargetParams{PodNamespace: "default", PodName: "client;true"}},
+			wantError: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateRouteGet(tt.params)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("validateRouteGet() error = %v, wantError %v", err, tt.wantError)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.expected, routeGetArgs(tt.params)); diff != "" {
+				t.Errorf("routeGetArgs() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseRouteDecision(t *testing.T) {
+	uid := 0
+	tests := []struct {
+		name     string
+		output   string
+		expected types.RouteDecision
+		wantErr  bool
+	}{
+		{
+			name:   "main table route",
+			output: `[{"dst":"10.96.0.1","gateway":"172.18.0.1","dev":"breth0","prefsrc":"172.18.0.3","flags":[],"uid":0,"cache":[]}]`,
+			expected: types.RouteDecision{
+				Dst: "10.96.0.1", Gateway: "172.18.0.1", Dev: "breth0", Table: "main", PrefSrc: "172.18.0.3", UID: &uid, Flags: []string{},
+			},
+		},
+		{
+			name:   "forwarded packet in a policy table",
+			output: `[{"dst":"8.8.8.8","from":"10.244.1.3","iif":"ovn-k8s-mp0","gateway":"172.18.0.1","dev":"breth0","table":"1008","mark":"0x1745ec","flags":[],"cache":[]}]`,
+			expected: types.RouteDecision{
+				Dst: "8.8.8.8", Src: "10.244.1.3", IIF: "ovn-k8s-mp0", Gateway: "172.18.0.1", Dev: "breth0", Table: "1008", FwMark: "0x1745ec", Flags: []string{},
+			},
+		},
+		{
+			name:   "local route",
+			output: `[{"type":"local","dst":"172.18.0.3","dev":"lo","table":"local","prefsrc":"172.18.0.3","flags":[],"uid":0,"cache":[]}]`,
+			expected: types.RouteDecision{
+				Type: "local", Dst: "172.18.0.3", Dev: "lo", Table: "local", PrefSrc: "172.18.0.3", UID: &uid, Flags: []string{},
+			},
+		},
+		{
+			name:    "no route",
+			output:  "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := parseRouteDecision(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRouteDecision() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.expected, decision); diff != "" {
+				t.Errorf("parseRouteDecision() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMatchIPRule(t *testing.T) {
+	rules := []types.IPRule{
+		{Priority: 0, Src: "all", Table: "local"},
+		{Priority: 1000, Src: "all", L3MDev: true},
+		{Priority: 5000, Src: "10.244.1.0/24", FwMark: "0x1", Table: "100"},
+		{Priority: 6000, Src: "10.244.1.3", Table: "1008"},
+		{Priority: 7000, Src: "all", IIF: "breth0", Table: "200"},
+		{Priority: 7500, Src: "all", UIDRange: "1000-2000", Table: "300"},
+		{Priority: 8000, Src: "all", Not: true, FwMark: "0x2/0xf", Table: "400"},
+		{Priority: 32766, Src: "all", Table: "main"},
+		{Priority: 32767, Src: "all", Table: "default"},
+	}
+	tests := []struct {
+		name     string
+		flow     routeFlow
+		table    string
+		expected int
+	}{
+		{name: "local table", flow: routeFlow{dst: net.ParseIP("172.18.0.3")}, table: "local", expected: 0},
+		{name: "vrf table", flow: routeFlow{dst: net.ParseIP("10.0.0.1"), vrf: "mp1-udn-vrf"}, table: "1007", expected: 1000},
+		{name: "source and mark", flow: routeFlow{src: net.ParseIP("10.244.1.5"), dst: net.ParseIP("8.8.8.8"), fwmark: 1}, table: "100", expected: 5000},
+		{name: "mark mismatch", flow: routeFlow{src: net.ParseIP("10.244.1.5"), dst: net.ParseIP("8.8.8.8"), fwmark: 2}, table: "100", expected: -1},
+		{name: "host source", flow: routeFlow{src: net.ParseIP("10.244.1.3"), dst: net.ParseIP("8.8.8.8"), iif: "ovn-k8s-mp0"}, table: "1008", expected: 6000},
+		{name: "input interface", flow: routeFlow{src: net.ParseIP("1.1.1.1"), dst: net.ParseIP("10.244.1.3"), iif: "breth0"}, table: "200", expected: 7000},
+		{name: "locally generated does not match iif", flow: routeFlow{dst: net.ParseIP("10.244.1.3")}, table: "200", expected: -1},
+		{name: "uid range", flow: routeFlow{dst: net.ParseIP("10.244.1.3"), uid: 1500}, table: "300", expected: 7500},
+		{name: "inverted mark", flow: routeFlow{dst: net.ParseIP("10.244.1.3"), fwmark: 0x13}, table: "400", expected: 8000},
+		{name: "inverted mark matching", flow: routeFlow{dst: net.ParseIP("10.244.1.3"), fwmark: 0x12}, table: "400", expected: -1},
+		{name: "main table", flow: routeFlow{dst: net.ParseIP("10.96.0.1")}, table: "main", expected: 32766},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := matchIPRule(rules, tt.flow, tt.table)
+			priority := -1
+			if rule != nil {
+				priority = rule.Priority
+			}
+			if priority != tt.expected {
+				t.Errorf("matchIPRule() = rule %d, expected rule %d", priority, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetRouteDecision(t *testing.T) {
+	var commands []string
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		command := strings.Join(cmd, " ")
+		commands = append(commands, command)
+		switch {
+		case command == "ip -V":
+			return "ip utility, iproute2-6.1.0", "", nil
+		case strings.Contains(command, "crictl pods"):
+			return `{"items":[{"id":"a1b2","metadata":{"name":"client","namespace":"default"},"state":"SANDBOX_READY"}]}`, "", nil
+		case strings.Contains(command, "crictl inspectp"):
+			return `{"info":{"pid":4242}}`, "", nil
+		case strings.Contains(command, "route get"):
+			return `[{"dst":"10.96.0.1","gateway":"10.244.1.1","dev":"eth0","prefsrc":"10.244.1.3","flags":[],"uid":0,"cache":[]}]`, "", nil
+		case strings.Contains(command, "rule show"):
+			return `[{"priority":0,"src":"all","table":"local"},{"priority":32766,"src":"all","table":"main"}]`, "", nil
+		}
+		t.Fatalf("unexpected command %q", command)
+		return "", "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.GetRouteDecision(context.Background(), nil, types.RouteGetParams{
+		Node:            "ovn-worker",
+		Dst:             "10.96.0.1",
+		PodTargetParams: types.PodTargetParams{PodNamespace: "default", PodName: "client"},
+	})
+	if err != nil {
+		t.Fatalf("GetRouteDecision() error = %v", err)
+	}
+	expectedCommands := []string{
+		"ip -V",
+		"chroot /host crictl pods --namespace default --name client --state ready -o json",
+		"chroot /host crictl inspectp -o json a1b2",
+		"nsenter -t 4242 -n -- ip -j route get to 10.96.0.1",
+		"nsenter -t 4242 -n -- ip -j rule show",
+	}
+	if diff := cmp.Diff(expectedCommands, commands); diff != "" {
+		t.Errorf("GetRouteDecision() commands mismatch (-want +got):\n%s", diff)
+	}
+	if result.Route.Dev != "eth0" || result.Route.Table != "main" {
+		t.Errorf("GetRouteDecision() unexpected route: %+v", result.Route)
+	}
+	if result.Rule == nil || result.Rule.Priority != 32766 {
+		t.Errorf("GetRouteDecision() unexpected rule: %+v", result.Rule)
+	}
+	if result.Netns != "pod default/client (pid 4242)" {
+		t.Errorf("GetRouteDecision() netns = %q", result.Netns)
+	}
+}
@@ -0,0 +1,410 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/headtail"
+)
+
+const (
+	socketProtocolTCP  = "tcp"
+	socketProtocolUDP  = "udp"
+	socketProtocolBoth = "both"
+)
+
+// socketStates maps the accepted state names to the states printed by ss.
+var socketStates = map[string]string{
+	"established":  "ESTAB",
+	"estab":        "ESTAB",
+	"syn-sent":     "SYN-SENT",
+	"syn-recv":     "SYN-RECV",
+	"syn-received": "SYN-RECV",
+	"fin-wait-1":   "FIN-WAIT-1",
+	"fin-wait-2":   "FIN-WAIT-2",
+	"time-wait":    "TIME-WAIT",
+	"close-wait":   "CLOSE-WAIT",
+	"last-ack":     "LAST-ACK",
+	"closing":      "CLOSING",
+	"listen":       "LISTEN",
+	"listening":    "LISTEN",
+	"unconn":       "UNCONN",
+	"closed":       "UNCONN",
+}
+
+// socketTCPOptions are the bare words ss -i prints for the options negotiated on a TCP connection.
+var socketTCPOptions = map[string]bool{
+	"ts":       true,
+	"sack":     true,
+	"ecn":      true,
+	"ecnseen":  true,
+	"fastopen": true,
+}
+
+// socketIgnoredWords are the bare words ss prints that are not reported: the shutdown state of the
+// socket and whether the sender was application limited.
+var socketIgnoredWords = map[string]bool{
+	"<->":         true,
+	"->":          true,
+	"<-":          true,
+	"--":          true,
+	"app_limited": true,
+}
+
+// socketProcessPattern matches a process of the users:(...) field, e.g. ("kubelet",pid=1234,fd=12).
+var socketProcessPattern = regexp.MustCompile(`\("((?:[^"\\]|\\.)*)",pid=(\d+),fd=(\d+)\)`)
+
+// GetSockets lists the TCP and UDP sockets of a node, or of a pod network namespace, with ss
+// and returns the sockets matching the filters with their processes and TCP information.
+func (s *MCPServer) GetSockets(ctx context.Context, req *mcp.CallToolRequest, in types.SocketsParams) (*mcp.CallToolResult, types.SocketsResult, error) {
+	filter, err := newSocketFilter(in.Protocol, in.State, in.Port, in.Address, in.Process)
+	if err != nil {
+		return nil, types.SocketsResult{}, fmt.Errorf("error while getting sockets: %w", err)
+	}
+	if err := validatePodTarget(in.PodTargetParams); err != nil {
+		return nil, types.SocketsResult{}, fmt.Errorf("error while getting sockets: %w", err)
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	if err := s.utilityExists(ctx, in.Namespace, in.Node, "ss"); err != nil {
+		return nil, types.SocketsResult{}, fmt.Errorf("error while getting sockets: failed to verify ss utility availability in configured image: %w", err)
+	}
+	prefix, netns, err := s.podNetnsPrefix(ctx, in.Namespace, in.Node, in.PodTargetParams)
+	if err != nil {
+		return nil, types.SocketsResult{}, fmt.Errorf("error while getting sockets: %w", err)
+	}
+
+	// Both protocols are always listed so that ss prints the protocol column, and filtered here.
+	cmd := commandbuilder.NewCommand(prefix...)
+	cmd.Add("ss", "-tunapeiOH")
+	stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+	if err != nil {
+		return nil, types.SocketsResult{}, fmt.Errorf("error while getting sockets: %w", err)
+	}
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		return nil, types.SocketsResult{}, fmt.Errorf("error while running command: %s", stderr)
+	}
+
+	sockets, err := parseSockets(stdout)
+	if err != nil {
+		return nil, types.SocketsResult{}, fmt.Errorf("error while getting sockets: %w", err)
+	}
+	matching := []types.Socket{}
+	for _, socket := range sockets {
+		if filter.matches(socket) {
+			matching = append(matching, socket)
+		}
+	}
+	// Apply the head and tail parameters to the matching sockets
+	matching = headtail.ApplyTo(&in.HeadTailParams, matching, DefaultMaxOutputLines)
+	return nil, types.SocketsResult{Sockets: matching, Netns: netns}, nil
+}
+
+// socketFilter matches sockets against the get-sockets filters. Unset filters match every socket.
+type socketFilter struct {
+	protocol string
+	states   map[string]bool
+	port     int
+	address  netip.Prefix
+	process  *regexp.Regexp
+}
+
+// newSocketFilter validates the filters and returns the filter matching them.
+func newSocketFilter(protocol, state string, port int, address, process string) (*socketFilter, error) {
+	filter := &socketFilter{}
+	switch protocol = strings.ToLower(strings.TrimSpace(protocol)); protocol {
+	case "", socketProtocolBoth:
+	case socketProtocolTCP, socketProtocolUDP:
+		filter.protocol = protocol
+	default:
+		return nil, fmt.Errorf("invalid protocol %q: must be one of tcp, udp, both", protocol)
+	}
+
+	for _, name := range strings.Split(state, ",") {
+		name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "_", "-")
+		if name == "" {
+			continue
+		}
+		ssState, ok := socketStates[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid state %q: must be one of %s", name, strings.Join(slices.Sorted(maps.Keys(socketStates)), ", "))
+		}
+		if filter.states == nil {
+			filter.states = map[string]bool{}
+		}
+		filter.states[ssState] = true
+	}
+
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d: must be between 1 and 65535", port)
+	}
+	filter.port = port
+
+	if address = strings.TrimSpace(address); address != "" {
+		prefix, err := parseSocketAddressFilter(address)
+		if err != nil {
+			return nil, err
+		}
+		filter.address = prefix
+	}
+
+	// The process filter is only matched here and never passed to a command.
+	if process != "" {
+		re, err := regexp.Compile(process)
+		if err != nil {
+			return nil, fmt.Errorf("invalid process %q: %w", process, err)
+		}
+		filter.process = re
+	}
+	return filter, nil
+}
+
+// parseSocketAddressFilter parses the address filter, an IP address or a CIDR.
+func parseSocketAddressFilter(address string) (netip.Prefix, error) {
+	if strings.Contains(address, "/") {
+		prefix, err := netip.ParsePrefix(address)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid address %q: %w", address, err)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid address %q: %w", address, err)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// matches tells whether the socket matches every filter.
+func (f *socketFilter) matches(socket types.Socket) bool {
+	if f.protocol != "" && socket.Protocol != f.protocol {
+		return false
+	}
+	if f.states != nil && !f.states[socket.State] {
+		return false
+	}
+	if f.port != 0 && socket.Local.Port != f.port && socket.Peer.Port != f.port {
+		return false
+	}
+	if f.address.IsValid() && !f.matchesAddress(socket.Local.Address) && !f.matchesAddress(socket.Peer.Address) {
+		return false
+	}
+	if f.process != nil && !slices.ContainsFunc(socket.Processes, func(p types.SocketProcess) bool {
+		return f.process.MatchString(p.Name)
+	}) {
+		return false
+	}
+	return true
+}
+
+// matchesAddress tells whether the address of a socket is in the address filter. IPv4-mapped IPv6
+// addresses match their IPv4 address, and the wildcard address matches no filter.
+func (f *socketFilter) matchesAddress(address string) bool {
+	addr, err := netip.ParseAddr(address)
+	if err != nil {
+		return false
+	}
+	return f.address.Contains(addr.Unmap())
+}
+
+// parseSockets parses the output of 'ss -tunapeiOH', one socket per line:
+//
+//	tcp ESTAB 0 0 10.244.1.3:45678 10.96.0.1:443 users:(("coredns",pid=1234,fd=12)) uid:0 ino:5678 sk:1 <-> ts sack cubic ...
+func parseSockets(output string) ([]types.Socket, error) {
+	var sockets []types.Socket
+	for _, line := range utils.StripEmptyLines(strings.Split(output, "\n")) {
+		socket, err := parseSocket(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ss output line %q: %w", line, err)
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets, nil
+}
+
+// parseSocket parses a line of 'ss -tunapeiOH' output.
+func parseSocket(line string) (types.Socket, error) {
+	// The processes are removed first since process names may contain spaces.
+	var processes []types.SocketProcess
+	if start := strings.Index(line, "users:("); start >= 0 {
+		end := strings.Index(line[start:], "))")
+		if end < 0 {
+			return types.Socket{}, fmt.Errorf("unterminated users field")
+		}
+		end += start + len("))")
+		for _, match := range socketProcessPattern.FindAllStringSubmatch(line[start:end], -1) {
+			pid, _ := strconv.Atoi(match[2])
+			fd, _ := strconv.Atoi(match[3])
+			processes = append(processes, types.SocketProcess{Name: match[1], PID: pid, FD: fd})
+		}
+		line = line[:start] + line[end:]
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 6 {
+		return types.Socket{}, fmt.Errorf("expected at least 6 fields, got %d", len(fields))
+	}
+	socket := types.Socket{
+		Protocol:  fields[0],
+		State:     fields[1],
+		Processes: processes,
+	}
+	var err error
+	if socket.RecvQ, err = strconv.ParseUint(fields[2], 10, 64); err != nil {
+		return types.Socket{}, fmt.Errorf("invalid receive queue %q", fields[2])
+	}
+	if socket.SendQ, err = strconv.ParseUint(fields[3], 10, 64); err != nil {
+		return types.Socket{}, fmt.Errorf("invalid send queue %q", fields[3])
+	}
+	if socket.Local, err = parseSocketEndpoint(fields[4]); err != nil {
+		return types.Socket{}, fmt.Errorf("invalid local address: %w", err)
+	}
+	if socket.Peer, err = parseSocketEndpoint(fields[5]); err != nil {
+		return types.Socket{}, fmt.Errorf("invalid peer address: %w", err)
+	}
+
+	info := &types.SocketTCPInfo{}
+	hasInfo := false
+	details := fields[6:]
+	for i := 0; i < len(details); i++ {
+		key, value, found := strings.Cut(details[i], ":")
+		if !found {
+			switch {
+			case socketIgnoredWords[key]:
+			case socketTCPOptions[key]:
+				info.Options = append(info.Options, key)
+				hasInfo = true
+			case key == "send" || key == "delivery_rate" || key == "pacing_rate":
+				// Rates are printed as a word followed by the rate.
+				if i+1 < len(details) {
+					i++
+					switch key {
+					case "send":
+						info.SendRate = details[i]
+					case "delivery_rate":
+						info.DeliveryRate = details[i]
+					}
+					hasInfo = true
+				}
+			case info.Congestion == "":
+				info.Congestion = key
+				hasInfo = true
+			}
+			continue
+		}
+		if parseSocketDetail(&socket, info, key, value) {
+			hasInfo = true
+		}
+	}
+	if hasInfo && socket.Protocol == socketProtocolTCP {
+		socket.TCPInfo = info
+	}
+	return socket, nil
+}
+
+// parseSocketDetail sets the socket or TCP information value of a key:value detail printed by ss -e
+// and -i, and tells whether it was a TCP information value. Unknown keys are skipped and malformed
+// values are left unset.
+func parseSocketDetail(socket *types.Socket, info *types.SocketTCPInfo, key, value string) bool {
+	switch key {
+	case "timer":
+		socket.Timer = strings.TrimSuffix(strings.TrimPrefix(value, "("), ")")
+	case "uid":
+		if uid, err := strconv.Atoi(value); err == nil {
+			socket.UID = &uid
+		}
+	case "ino":
+		socket.Inode, _ = strconv.ParseUint(value, 10, 64)
+	case "wscale":
+		info.Options = append(info.Options, key+":"+value)
+		return true
+	case "rtt":
+		rtt, rttVar, _ := strings.Cut(value, "/")
+		info.RTT, _ = strconv.ParseFloat(rtt, 64)
+		info.RTTVar, _ = strconv.ParseFloat(rttVar, 64)
+		return true
+	case "retrans":
+		retransmits, total, _ := strings.Cut(value, "/")
+		info.Retransmits, _ = strconv.Atoi(retransmits)
+		info.TotalRetransmits, _ = strconv.Atoi(total)
+		return true
+	case "minrtt":
+		info.MinRTT, _ = strconv.ParseFloat(value, 64)
+		return true
+	case "rto":
+		info.RTO, _ = strconv.ParseFloat(value, 64)
+		return true
+	case "mss":
+		info.MSS, _ = strconv.Atoi(value)
+		return true
+	case "pmtu":
+		info.PMTU, _ = strconv.Atoi(value)
+		return true
+	case "cwnd":
+		info.Cwnd, _ = strconv.Atoi(value)
+		return true
+	case "ssthresh":
+		info.SSThresh, _ = strconv.Atoi(value)
+		return true
+	case "lost":
+		info.Lost, _ = strconv.Atoi(value)
+		return true
+	case "unacked":
+		info.Unacked, _ = strconv.Atoi(value)
+		return true
+	case "bytes_sent":
+		info.BytesSent, _ = strconv.ParseUint(value, 10, 64)
+		return true
+	case "bytes_retrans":
+		info.BytesRetrans, _ = strconv.ParseUint(value, 10, 64)
+		return true
+	case "bytes_acked":
+		info.BytesAcked, _ = strconv.ParseUint(value, 10, 64)
+		return true
+	case "bytes_received":
+		info.BytesReceived, _ = strconv.ParseUint(value, 10, 64)
+		return true
+	}
+	return false
+}
+
+// parseSocketEndpoint parses an address printed by ss: 10.0.0.1:80, [fd00::1]:80, *:*,
+// 10.0.0.1%eth0:53 or [fe80::1]%eth0:123.
+func parseSocketEndpoint(endpoint string) (types.SocketEndpoint, error) {
+	i := strings.LastIndex(endpoint, ":")
+	if i < 0 {
+		return types.SocketEndpoint{}, fmt.Errorf("missing port in %q", endpoint)
+	}
+	host, port := endpoint[:i], endpoint[i+1:]
+	var result types.SocketEndpoint
+	if port != "*" {
+		n, err := strconv.Atoi(port)
+		if err != nil {
+			return types.SocketEndpoint{}, fmt.Errorf("invalid port in %q", endpoint)
+		}
+		result.Port = n
+	}
+	host = strings.NewReplacer("[", "", "]", "").Replace(host)
+	result.Address, result.Interface, _ = strings.Cut(host, "%")
+	if result.Address == "" {
+		return types.SocketEndpoint{}, fmt.Errorf("missing address in %q", endpoint)
+	}
+	return result, nil
+}
@@ -0,0 +1,269 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+const ssOutput = `udp   UNCONN 0      0      10.96.0.10%eth0:53 0.0.0.0:* users:(("coredns",pid=812,fd=11)) ino:30211 sk:1 cgroup:/kubepods/pod1 <->
+tcp   LISTEN 0      4096   *:30080 *:* users:(("ovnkube",pid=2345,fd=7),("ovnkube",pid=2345,fd=9)) uid:0 ino:40001 sk:2 cgroup:/system.slice <-> cubic cwnd:10
+tcp   ESTAB  0      36     [::ffff:10.244.1.3]:45678 [fd00:10:96::1]:443 users:(("kube proxy",pid=99,fd=3)) timer:(on,204ms,0) uid:1000 ino:40002 sk:3 <-> ts sack cubic wscale:7,7 rto:204 rtt:1.5/0.75 ato:40 mss:1398 pmtu:1450 rcvmss:536 advmss:1398 cwnd:4 ssthresh:7 bytes_sent:20480 bytes_retrans:2796 bytes_acked:17684 bytes_received:512 segs_out:20 segs_in:12 send 29.8Mbps lastsnd:4 pacing_rate 35.7Mbps delivery_rate 12.1Mbps delivered:15 busy:200ms unacked:2 retrans:1/2 lost:1 rcv_space:13980 minrtt:0.9 snd_wnd:64256
+tcp   TIME-WAIT 0   0      [fe80::1]%breth0:6443 [fe80::2]:50000 timer:(timewait,59sec,0) ino:0 sk:4
+`
+
+func TestParseSockets(t *testing.T) {
+	uid0, uid1000 := 0, 1000
+	want := []types.Socket{
+		{
+			Protocol:  "udp",
+			State:     "UNCONN",
+			Local:     types.SocketEndpoint{Address: "10.96.0.10", Port: 53, Interface: "eth0"},
+			Peer:      types.SocketEndpoint{Address: "0.0.0.0"},
+			Processes: []types.SocketProcess{{Name: "coredns", PID: 812, FD: 11}},
+			Inode:     30211,
+		},
+		{
+			Protocol: "tcp",
+			State:    "LISTEN",
+			SendQ:    4096,
+			Local:    types.SocketEndpoint{Address: "*", Port: 30080},
+			Peer:     types.SocketEndpoint{Address: "*"},
+			Processes: []types.SocketProcess{
+				{Name: "ovnkube", PID: 2345, FD: 7},
+				{Name: "ovnkube", PID: 2345, FD: 9},
+			},
+			UID:     &uid0,
+			Inode:   40001,
+			TCPInfo: &types.SocketTCPInfo{Congestion: "cubic", Cwnd: 10},
+		},
+		{
+			Protocol:  "tcp",
+			State:     "ESTAB",
+			SendQ:     36,
+			Local:     types.SocketEndpoint{Address: "::ffff:10.244.1.3", Port: 45678},
+			Peer:      types.SocketEndpoint{Address: "fd00:10:96::1", Port: 443},
+			Processes: []types.SocketProcess{{Name: "kube proxy", PID: 99, FD: 3}},
+			Timer:     "on,204ms,0",
+			UID:       &uid1000,
+			Inode:     40002,
+			TCPInfo: &types.SocketTCPInfo{
+				Options:          []string{"ts", "sack", "wscale:7,7"},
+				Congestion:       "cubic",
+				RTT:              1.5,
+				RTTVar:           0.75,
+				MinRTT:           0.9,
+				RTO:              204,
+				MSS:              1398,
+				PMTU:             1450,
+				Cwnd:             4,
+				SSThresh:         7,
+				Retransmits:      1,
+				TotalRetransmits: 2,
+				Lost:             1,
+				Unacked:          2,
+				BytesSent:        20480,
+				BytesRetrans:     2796,
+				BytesAcked:       17684,
+				BytesReceived:    512,
+				SendRate:         "29.8Mbps",
+				DeliveryRate:     "12.1Mbps",
+			},
+		},
+		{
+			Protocol: "tcp",
+			State:    "TIME-WAIT",
+			Local:    types.SocketEndpoint{Address: "fe80::1", Port: 6443, Interface: "breth0"},
+			Peer:     types.SocketEndpoint{Address: "fe80::2", Port: 50000},
+			Timer:    "timewait,59sec,0",
+		},
+	}
+	got, err := parseSockets(ssOutput)
+	if err != nil {
+		t.Fatalf("parseSockets() error = %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("parseSockets() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseSocketsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+	}{
+		{
+			name:   "missing fields",
+			output: "tcp LISTEN 0 4096 *:22\n",
+		},
+		{
+			name:   "invalid queue",
+			output: "tcp LISTEN x 4096 *:22 *:*\n",
+		},
+		{
+			name:   "invalid port",
+			output: "tcp LISTEN 0 4096 *:ssh *:*\n",
+		},
+		{
+			name:   "unterminated users",
+			output: `tcp LISTEN 0 4096 *:22 *:* users:(("sshd",pid=1,fd=3)` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseSockets(tt.output); err == nil {
+				t.Errorf("parseSockets() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestSocketFilter(t *testing.T) {
+	sockets, err := parseSockets(ssOutput)
+	if err != nil {
+		t.Fatalf("parseSockets() error = %v", err)
+	}
+	tests := []struct {
+		name      string
+		protocol  string
+		state     string
+		port      int
+		address   string
+		process   string
+		wantState []string
+		wantErr   string
+	}{
+		{
+			name:      "no filter",
+			wantState: []string{"UNCONN", "LISTEN", "ESTAB", "TIME-WAIT"},
+		},
+		{
+			name:      "protocol",
+			protocol:  "TCP",
+			wantState: []string{"LISTEN", "ESTAB", "TIME-WAIT"},
+		},
+		{
+			name:      "states",
+			state:     "listening, time_wait",
+			wantState: []string{"LISTEN", "TIME-WAIT"},
+		},
+		{
+			name:      "local or peer port",
+			port:      443,
+			wantState: []string{"ESTAB"},
+		},
+		{
+			name:      "IPv4-mapped address in CIDR",
+			address:   "10.244.1.0/24",
+			wantState: []string{"ESTAB"},
+		},
+		{
+			name:      "peer address",
+			address:   "fd00:10:96::1",
+			wantState: []string{"ESTAB"},
+		},
+		{
+			name:      "process",
+			process:   "^ovnkube$|coredns",
+			wantState: []string{"UNCONN", "LISTEN"},
+		},
+		{
+			name:     "invalid protocol",
+			protocol: "sctp",
+			wantErr:  "invalid protocol",
+		},
+		{
+			name:    "invalid state",
+			state:   "established,open",
+			wantErr: `invalid state "open"`,
+		},
+		{
+			name:    "invalid port",
+			port:    70000,
+			wantErr: "invalid port",
+		},
+		{
+			name:    "invalid address",
+			address: "10.0.0.300",
+			wantErr: "invalid address",
+		},
+		{
+			name:    "invalid process",
+			process: "kube(",
+			wantErr: "invalid process",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := newSocketFilter(tt.protocol, tt.state, tt.port, tt.address, tt.process)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newSocketFilter() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newSocketFilter() error = %v", err)
+			}
+			var states []string
+			for _, socket := range sockets {
+				if filter.matches(socket) {
+					states = append(states, socket.State)
+				}
+			}
+			if diff := cmp.Diff(tt.wantState, states); diff != "" {
+				t.Errorf("matching sockets mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetSockets(t *testing.T) {
+	var commands []string
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		command := strings.Join(cmd, " ")
+		commands = append(commands, command)
+		switch command {
+		case "ss -V":
+			return "ss utility, iproute2-6.1.0", "", nil
+		case "ss -tunapeiOH":
+			return ssOutput, "", nil
+		}
+		t.Fatalf("unexpected command %q", command)
+		return "", "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.GetSockets(context.Background(), nil, types.SocketsParams{
+		CommonParams: types.CommonParams{Node: "ovn-worker"},
+		State:        "listen",
+		Port:         30080,
+	})
+	if err != nil {
+		t.Fatalf("GetSockets() error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"ss -V", "ss -tunapeiOH"}, commands); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+	if len(result.Sockets) != 1 || result.Sockets[0].Local.Port != 30080 || result.Netns != "" {
+		t.Errorf("GetSockets() = %+v, want the socket listening on port 30080", result)
+	}
+
+	_, result, err = server.GetSockets(context.Background(), nil, types.SocketsParams{
+		CommonParams: types.CommonParams{Node: "ovn-worker"},
+		State:        "unknown",
+	})
+	if err == nil {
+		t.Errorf("GetSockets() = %+v, want error for an invalid state", result)
+	}
+}
@@ -0,0 +1,378 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+)
+
+const (
+	// sysctlInterfacePlaceholder is replaced by the interface name in per-interface keys.
+	sysctlInterfacePlaceholder = "{interface}"
+	// maxSysctlKeys bounds the number of additional keys that can be requested.
+	maxSysctlKeys = 50
+
+	sysctlStatusOK        = "ok"
+	sysctlStatusDeviation = "deviation"
+	sysctlStatusMissing   = "missing"
+	sysctlStatusInfo      = "info"
+
+	sysctlSeverityError   = "error"
+	sysctlSeverityWarning = "warning"
+)
+
+// sysctlOVNKInterfaces matches the node interfaces OVN-Kubernetes sends traffic through: the
+// gateway bridge (br-ex, or breth0 in kind clusters) and the management ports.
+var sysctlOVNKInterfaces = regexp.MustCompile(`^(br-ex|breth\d+|ovn-k8s-mp\d+)$`)
+
+// validSysctlKey matches sysctl keys such as net.ipv4.conf.eth0/100.rp_filter.
+var validSysctlKey = regexp.MustCompile(`^[a-z0-9_]+(\.[a-zA-Z0-9_/:-]+)+$`)
+
+// sysctlBaselineEntry is a sysctl OVN-Kubernetes depends on and the values it expects.
+type sysctlBaselineEntry struct {
+	// key is the sysctl key. Per-interface keys contain sysctlInterfacePlaceholder.
+	key string
+	// combineAll is set for per-interface keys whose effective value is the maximum of the
+	// interface value and the 'all' value.
+	combineAll bool
+	// expected describes the expected values, and matches tells whether a value is expected.
+	// Entries without matches are only reported.
+	expected string
+	matches  func(value int64) bool
+	severity string
+	// node and pod tell whether the entry applies on the node and in pod network namespaces.
+	node   bool
+	pod    bool
+	reason string
+}
+
+// sysctlBaseline are the sysctls checked by get-sysctl.
+var sysctlBaseline = []sysctlBaselineEntry{
+	{
+		key:      "net.ipv4.ip_forward",
+		expected: "1",
+		matches:  func(v int64) bool { return v == 1 },
+		severity: sysctlSeverityError,
+		node:     true,
+		reason:   "OVN-Kubernetes routes IPv4 traffic between the gateway bridge, the management port and the pods",
+	},
+	{
+		key:    "net.ipv4.ip_forward",
+		pod:    true,
+		reason: "pods do not usually forward traffic",
+	},
+	{
+		key:        "net.ipv4.conf." + sysctlInterfacePlaceholder + ".rp_filter",
+		combineAll: true,
+		expected:   "0 (off) or 2 (loose)",
+		matches:    func(v int64) bool { return v != 1 },
+		severity:   sysctlSeverityError,
+		node:       true,
+		pod:        true,
+		reason: "strict reverse path filtering drops asymmetrically routed packets, such as NodePort, ExternalIP and Egress IP traffic " +
+			"on the gateway bridge and management port, or traffic of multi-homed pods. The effective value is the maximum of the 'all' and interface values",
+	},
+	{
+		key:        "net.ipv4.conf." + sysctlInterfacePlaceholder + ".arp_ignore",
+		combineAll: true,
+		expected:   "0 or 1",
+		matches:    func(v int64) bool { return v <= 1 },
+		severity:   sysctlSeverityWarning,
+		node:       true,
+		reason:     "2 or more stops ARP replies for addresses outside the subnet of the receiving interface, e.g. Egress IPs and load balancer IPs on the gateway bridge",
+	},
+	{
+		key:        "net.ipv4.conf." + sysctlInterfacePlaceholder + ".arp_announce",
+		combineAll: true,
+		node:       true,
+		reason:     "selects the source address of ARP requests; reported for reference",
+	},
+	{
+		key:      "net.netfilter.nf_conntrack_max",
+		expected: ">= 131072",
+		matches:  func(v int64) bool { return v >= 131072 },
+		severity: sysctlSeverityError,
+		node:     true,
+		reason:   "new connections are dropped when the conntrack table is full",
+	},
+	{
+		key:      "net.netfilter.nf_conntrack_tcp_be_liberal",
+		expected: "1",
+		matches:  func(v int64) bool { return v == 1 },
+		severity: sysctlSeverityWarning,
+		node:     true,
+		reason:   "out of window TCP packets are otherwise marked INVALID and dropped, breaking connections after conntrack entries are flushed or with asymmetric paths",
+	},
+	{
+		key:      "net.ipv6.conf.all.forwarding",
+		expected: "1",
+		matches:  func(v int64) bool { return v == 1 },
+		severity: sysctlSeverityError,
+		node:     true,
+		reason:   "OVN-Kubernetes routes IPv6 traffic on IPv6 and dual-stack clusters. Missing when IPv6 is disabled",
+	},
+	{
+		key:    "net.ipv6.conf.all.forwarding",
+		pod:    true,
+		reason: "pods do not usually forward traffic",
+	},
+	{
+		key:      "net.ipv6.conf." + sysctlInterfacePlaceholder + ".accept_ra",
+		expected: "0 or 2",
+		matches:  func(v int64) bool { return v != 1 },
+		severity: sysctlSeverityWarning,
+		node:     true,
+		reason:   "with forwarding enabled, 1 ignores router advertisements: use 2 if the node learns its IPv6 default route from router advertisements, otherwise 0",
+	},
+	{
+		key:      "net.core.rmem_max",
+		expected: ">= 1048576",
+		matches:  func(v int64) bool { return v >= 1048576 },
+		severity: sysctlSeverityWarning,
+		node:     true,
+		reason:   "small socket receive buffers make OVS, ovn-controller and tunnel sockets drop messages under load",
+	},
+}
+
+// GetSysctl reads the network sysctls OVN-Kubernetes depends on, on the node or in the network
+// namespace of a pod running on the node, and compares them with the embedded baseline.
+func (s *MCPServer) GetSysctl(ctx context.Context, req *mcp.CallToolRequest, in types.SysctlParams) (*mcp.CallToolResult, types.SysctlResult, error) {
+	extraInterfaces, keys, err := validateSysctl(in)
+	if err != nil {
+		return nil, types.SysctlResult{}, fmt.Errorf("error while getting sysctls: %w", err)
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	// sysctl has no version option in every implementation, so only ip is checked.
+	if err := s.utilityExists(ctx, in.Namespace, in.Node, "ip"); err != nil {
+		return nil, types.SysctlResult{}, fmt.Errorf("error while getting sysctls: failed to verify ip utility availability in configured image: %w", err)
+	}
+
+	prefix, netns, err := s.podNetnsPrefix(ctx, in.Namespace, in.Node, in.PodTargetParams)
+	if err != nil {
+		return nil, types.SysctlResult{}, fmt.Errorf("error while getting sysctls: %w", err)
+	}
+	result := types.SysctlResult{Netns: cmp.Or(netns, "node "+in.Node)}
+	inPod := in.PodName != ""
+
+	cmd := commandbuilder.NewCommand(prefix...)
+	cmd.Add("ip", "-j", "link", "show")
+	stdout, stderr, err := s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+	if err != nil {
+		return nil, types.SysctlResult{}, fmt.Errorf("error while getting sysctls: failed to list interfaces: %w", err)
+	}
+	if stderr != "" {
+		return nil, types.SysctlResult{}, fmt.Errorf("error while running command: %s", stderr)
+	}
+	links, err := parseIPLinks(stdout)
+	if err != nil {
+		return nil, types.SysctlResult{}, fmt.Errorf("error while getting sysctls: %w", err)
+	}
+	result.Interfaces = sysctlInterfaces(links, extraInterfaces, inPod)
+
+	baseline := sysctlBaselineFor(inPod)
+	cmd = commandbuilder.NewCommand(prefix...)
+	cmd.Add("sysctl", "-e")
+	cmd.Add(sysctlKeys(baseline, result.Interfaces, keys)...)
+	stdout, stderr, err = s.executeCommand(ctx, in.Namespace, in.Node, cmd.Build())
+	if err != nil {
+		return nil, types.SysctlResult{}, fmt.Errorf("error while getting sysctls: %w", err)
+	}
+	if stderr != "" {
+		return nil, types.SysctlResult{}, fmt.Errorf("error while running command: %s", stderr)
+	}
+	values := parseSysctlOutput(stdout)
+
+	// Strict reverse path filtering only matters in pods with more than one interface.
+	multiHomed := !inPod || len(result.Interfaces) > 1
+	result.Deviations = []types.SysctlCheck{}
+	result.Checks = []types.SysctlCheck{}
+	for _, check := range evaluateSysctlBaseline(baseline, result.Interfaces, values, multiHomed) {
+		if check.Status == sysctlStatusDeviation {
+			result.Deviations = append(result.Deviations, check)
+		} else {
+			result.Checks = append(result.Checks, check)
+		}
+	}
+	for _, key := range keys {
+		if value, ok := values[key]; ok {
+			result.Values = append(result.Values, types.SysctlValue{Key: key, Value: value})
+		}
+	}
+	return nil, result, nil
+}
+
+// validateSysctl validates the parameters and returns the additional interfaces and keys.
+func validateSysctl(in types.SysctlParams) ([]string, []string, error) {
+	if err := validatePodTarget(in.PodTargetParams); err != nil {
+		return nil, nil, err
+	}
+	var interfaces, keys []string
+	for _, iface := range strings.Split(in.Interfaces, ",") {
+		if iface = strings.TrimSpace(iface); iface == "" {
+			continue
+		}
+		if !validIPDeviceName.MatchString(iface) {
+			return nil, nil, fmt.Errorf("invalid interface %q: must be a network device name", iface)
+		}
+		interfaces = append(interfaces, iface)
+	}
+	for _, key := range strings.Split(in.Keys, ",") {
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		if !validSysctlKey.MatchString(key) {
+			return nil, nil, fmt.Errorf("invalid sysctl key %q", key)
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) > maxSysctlKeys {
+		return nil, nil, fmt.Errorf("too many keys: at most %d can be requested", maxSysctlKeys)
+	}
+	return interfaces, keys, nil
+}
+
+// sysctlInterfaces returns the interfaces whose per-interface sysctls are checked: the OVN-Kubernetes
+// gateway bridge and management ports on the node, every interface but loopback in a pod, and
+// the requested interfaces that exist.
+func sysctlInterfaces(links []types.IPLink, extra []string, inPod bool) []string {
+	var interfaces []string
+	for _, link := range links {
+		selected := slices.Contains(extra, link.Name)
+		if inPod {
+			selected = selected || link.Type != "loopback"
+		} else {
+			selected = selected || sysctlOVNKInterfaces.MatchString(link.Name)
+		}
+		if selected && !slices.Contains(interfaces, link.Name) {
+			interfaces = append(interfaces, link.Name)
+		}
+	}
+	return interfaces
+}
+
+// sysctlBaselineFor returns the baseline entries applying on the node or in a pod.
+func sysctlBaselineFor(inPod bool) []sysctlBaselineEntry {
+	var baseline []sysctlBaselineEntry
+	for _, entry := range sysctlBaseline {
+		if (inPod && entry.pod) || (!inPod && entry.node) {
+			baseline = append(baseline, entry)
+		}
+	}
+	return baseline
+}
+
+// sysctlInterfaceKey returns the key of an interface. Dots in interface names, e.g. of VLAN
+// interfaces, are written as slashes in sysctl keys.
+func sysctlInterfaceKey(key, iface string) string {
+	return strings.ReplaceAll(key, sysctlInterfacePlaceholder, strings.ReplaceAll(iface, ".", "/"))
+}
+
+// sysctlKeys returns the keys to read: the baseline keys for every interface, the 'all' keys of
+// the per-interface keys, and the additional keys.
+func sysctlKeys(baseline []sysctlBaselineEntry, interfaces, extra []string) []string {
+	var keys []string
+	add := func(key string) {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	for _, entry := range baseline {
+		if !strings.Contains(entry.key, sysctlInterfacePlaceholder) {
+			add(entry.key)
+			continue
+		}
+		if entry.combineAll {
+			add(sysctlInterfaceKey(entry.key, "all"))
+		}
+		for _, iface := range interfaces {
+			add(sysctlInterfaceKey(entry.key, iface))
+		}
+	}
+	for _, key := range extra {
+		add(key)
+	}
+	return keys
+}
+
+// parseSysctlOutput parses 'key = value' lines printed by sysctl.
+func parseSysctlOutput(output string) map[string]string {
+	values := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)] = strings.Join(strings.Fields(value), " ")
+	}
+	return values
+}
+
+// evaluateSysctlBaseline compares the values with the baseline entries, once per interface for
+// the per-interface entries. In pods that are not multi-homed, reverse path filtering is only reported.
+func evaluateSysctlBaseline(baseline []sysctlBaselineEntry, interfaces []string, values map[string]string, multiHomed bool) []types.SysctlCheck {
+	var checks []types.SysctlCheck
+	for _, entry := range baseline {
+		if !multiHomed && strings.HasSuffix(entry.key, ".rp_filter") {
+			entry.matches = nil
+		}
+		if !strings.Contains(entry.key, sysctlInterfacePlaceholder) {
+			checks = append(checks, evaluateSysctl(entry, entry.key, "", values))
+			continue
+		}
+		for _, iface := range interfaces {
+			all := ""
+			if entry.combineAll {
+				all = sysctlInterfaceKey(entry.key, "all")
+			}
+			checks = append(checks, evaluateSysctl(entry, sysctlInterfaceKey(entry.key, iface), all, values))
+		}
+	}
+	return checks
+}
+
+// evaluateSysctl compares the value of a key with a baseline entry. If allKey is set, the
+// effective value is the maximum of the key and allKey values.
+func evaluateSysctl(entry sysctlBaselineEntry, key, allKey string, values map[string]string) types.SysctlCheck {
+	check := types.SysctlCheck{Key: key, Expected: entry.expected, Reason: entry.reason}
+	value, ok := values[key]
+	if !ok {
+		check.Status = sysctlStatusMissing
+		return check
+	}
+	check.Value = value
+	effective, err := strconv.ParseInt(value, 10, 64)
+	if err == nil && allKey != "" {
+		if all, allErr := strconv.ParseInt(values[allKey], 10, 64); allErr == nil {
+			effective = max(effective, all)
+			check.Effective = strconv.FormatInt(effective, 10)
+		}
+	}
+	switch {
+	case entry.matches == nil:
+		check.Status = sysctlStatusInfo
+		check.Expected = ""
+	case err != nil:
+		check.Status = sysctlStatusInfo
+	case entry.matches(effective):
+		check.Status = sysctlStatusOK
+	default:
+		check.Status = sysctlStatusDeviation
+		check.Severity = entry.severity
+	}
+	return check
+}
@@ -0,0 +1,163 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kernel/types"
+)
+
+func TestValidateSysctl(t *testing.T) {
+	tests := []struct {
+		name      string
+		params    types.SysctlParams
+		wantError bool
+	}{
+		{name: "no parameters", params: types.SysctlParams{}},
+		{name: "interfaces and keys", params: types.SysctlParams{Interfaces: "eth0, eth0.100", Keys: "net.ipv4.tcp_rmem,net.ipv4.conf.eth0/100.forwarding"}},
+		{name: "pod target", params: types.SysctlParams{PodTargetParams: types.PodTargetParams{PodNamespace: "default", PodName: "client"}}},
+		{name: "pod name without namespace", params: types.SysctlParams{PodTargetParams: types.PodTargetParams{PodName: "client"}}, wantError: true},
+		{name: "invalid interface", params: types.SysctlParams{Interfaces: "eth0;reboot"}, wantError: true},
+		{name: "invalid key", params: types.SysctlParams{Keys: "net.ipv4.ip_forward=0"}, wantError: true},
+		{name: "key without dots", params: types.SysctlParams{Keys: "kernel"}, wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := validateSysctl(tt.params)
+			if (err != nil) != tt.wantError {
+				t.Errorf("validateSysctl() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestSysctlInterfaces(t *testing.T) {
+	links := []types.IPLink{
+		{Name: "lo", Type: "loopback"},
+		{Name: "eth0", Type: "ether"},
+		{Name: "eth0.100", Type: "ether"},
+		{Name: "breth0", Type: "ether"},
+		{Name: "ovn-k8s-mp0", Type: "ether"},
+		{Name: "genev_sys_6081", Type: "ether"},
+	}
+	if diff := cmp.Diff([]string{"eth0.100", "breth0", "ovn-k8s-mp0"}, sysctlInterfaces(links, []string{"eth0.100"}, false)); diff != "" {
+		t.Errorf("node interfaces mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"eth0", "eth0.100", "breth0", "ovn-k8s-mp0", "genev_sys_6081"}, sysctlInterfaces(links, nil, true)); diff != "" {
+		t.Errorf("pod interfaces mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSysctlKeys(t *testing.T) {
+	baseline := []sysctlBaselineEntry{
+		{key: "net.ipv4.ip_forward"},
+		{key: "net.ipv4.conf.{interface}.rp_filter", combineAll: true},
+		{key: "net.ipv6.conf.{interface}.accept_ra"},
+	}
+	expected := []string{
+		"net.ipv4.ip_forward",
+		"net.ipv4.conf.all.rp_filter",
+		"net.ipv4.conf.br-ex.rp_filter",
+		"net.ipv4.conf.eth0/100.rp_filter",
+		"net.ipv6.conf.br-ex.accept_ra",
+		"net.ipv6.conf.eth0/100.accept_ra",
+		"net.core.rmem_default",
+	}
+	got := sysctlKeys(baseline, []string{"br-ex", "eth0.100"}, []string{"net.ipv4.ip_forward", "net.core.rmem_default"})
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("sysctlKeys() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestEvaluateSysctlBaseline(t *testing.T) {
+	values := parseSysctlOutput(`net.ipv4.ip_forward = 1
+net.ipv4.conf.all.rp_filter = 1
+net.ipv4.conf.br-ex.rp_filter = 0
+net.ipv4.conf.ovn-k8s-mp0.rp_filter = 2
+net.ipv4.ip_local_port_range = 32768	60999
+`)
+	if values["net.ipv4.ip_local_port_range"] != "32768 60999" {
+		t.Errorf("parseSysctlOutput() ip_local_port_range = %q", values["net.ipv4.ip_local_port_range"])
+	}
+	baseline := []sysctlBaselineEntry{
+		sysctlBaseline[0],
+		sysctlBaseline[2],
+		{key: "net.netfilter.nf_conntrack_max", expected: ">= 131072", matches: func(v int64) bool { return v >= 131072 }, severity: sysctlSeverityError},
+	}
+
+	checks := evaluateSysctlBaseline(baseline, []string{"br-ex", "ovn-k8s-mp0"}, values, true)
+	var got []string
+	for _, check := range checks {
+		got = append(got, strings.Join([]string{check.Key, check.Value, check.Effective, check.Status, check.Severity}, " "))
+	}
+	expected := []string{
+		"net.ipv4.ip_forward 1  ok ",
+		"net.ipv4.conf.br-ex.rp_filter 0 1 deviation error",
+		"net.ipv4.conf.ovn-k8s-mp0.rp_filter 2 2 ok ",
+		"net.netfilter.nf_conntrack_max   missing ",
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("evaluateSysctlBaseline() mismatch (-want +got):\n%s", diff)
+	}
+
+	// In a pod with a single interface, strict reverse path filtering is only reported.
+	checks = evaluateSysctlBaseline(sysctlBaseline[2:3], []string{"br-ex"}, values, false)
+	if len(checks) != 1 || checks[0].Status != sysctlStatusInfo {
+		t.Errorf("evaluateSysctlBaseline() single-homed checks = %+v, want one info check", checks)
+	}
+}
+
+func TestGetSysctl(t *testing.T) {
+	var commands []string
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		command := strings.Join(cmd, " ")
+		commands = append(commands, command)
+		switch {
+		case command == "ip -V":
+			return "ip utility, iproute2-6.1.0", "", nil
+		case strings.HasPrefix(command, "ip -j link show"):
+			return `[{"ifindex":1,"ifname":"lo","link_type":"loopback"},{"ifindex":2,"ifname":"eth0","link_type":"ether"},` +
+				`{"ifindex":3,"ifname":"breth0","link_type":"ether"},{"ifindex":4,"ifname":"ovn-k8s-mp0","link_type":"ether"}]`, "", nil
+		case strings.HasPrefix(command, "sysctl -e"):
+			return `net.ipv4.ip_forward = 1
+net.ipv4.conf.all.rp_filter = 0
+net.ipv4.conf.breth0.rp_filter = 1
+net.ipv4.conf.ovn-k8s-mp0.rp_filter = 2
+net.netfilter.nf_conntrack_max = 262144
+net.core.somaxconn = 4096
+`, "", nil
+		}
+		t.Fatalf("unexpected command %q", command)
+		return "", "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.GetSysctl(context.Background(), nil, types.SysctlParams{Node: "ovn-worker", Keys: "net.core.somaxconn"})
+	if err != nil {
+		t.Fatalf("GetSysctl() error = %v", err)
+	}
+	if !strings.Contains(commands[2], "net.ipv4.conf.breth0.rp_filter") || !strings.Contains(commands[2], "net.core.somaxconn") {
+		t.Errorf("sysctl command %q does not read the expected keys", commands[2])
+	}
+	if diff := cmp.Diff([]string{"breth0", "ovn-k8s-mp0"}, result.Interfaces); diff != "" {
+		t.Errorf("interfaces mismatch (-want +got):\n%s", diff)
+	}
+	if len(result.Deviations) != 1 || result.Deviations[0].Key != "net.ipv4.conf.breth0.rp_filter" || result.Deviations[0].Effective != "1" {
+		t.Errorf("deviations = %+v, want the strict rp_filter of breth0", result.Deviations)
+	}
+	if diff := cmp.Diff([]types.SysctlValue{{Key: "net.core.somaxconn", Value: "4096"}}, result.Values); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+	if result.Netns != "node ovn-worker" {
+		t.Errorf("netns = %q, want node ovn-worker", result.Netns)
+	}
+}
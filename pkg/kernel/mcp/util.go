@@ -14,12 +14,28 @@ const (
 	DefaultMaxOutputLines = 100
 )
 
-// ConntrackSummaryPattern matches conntrack informational stderr printed on successful -L/--dump.
+// ConntrackSummaryPattern matches conntrack informational stderr printed on successful -L/--dump and -E.
 // Format: "conntrack v<version> (conntrack-tools): <count> flow entries have been shown."
-var ConntrackSummaryPattern = regexp.MustCompile(`^conntrack v\d+\.\d+\.\d+ \(conntrack-tools\): \d+ flow entries have been shown\.?$`)
+// or "conntrack v<version> (conntrack-tools): <count> flow events have been shown."
+var ConntrackSummaryPattern = regexp.MustCompile(`^conntrack v\d+\.\d+\.\d+ \(conntrack-tools\): \d+ flow (entries|events) have been shown\.?$`)
 
-// utilityExists checks if a utility/command exists in the container
+// utilityKey identifies the availability of a utility in the configured image on a node.
+type utilityKey struct {
+	node    string
+	utility string
+}
+
+// utilityExists checks if a utility/command exists in the container. The availability is cached
+// per node since the image does not change, while errors running the check are not cached.
 func (s *MCPServer) utilityExists(ctx context.Context, namespace, node, utility string) error {
+	key := utilityKey{node: node, utility: utility}
+	s.utilitiesMu.Lock()
+	availability, cached := s.utilities[key]
+	s.utilitiesMu.Unlock()
+	if cached {
+		return availability
+	}
+
 	cmd := commandbuilder.NewCommand(utility, "-V")
 	_, stderr, err := s.runDebugNodeCommand(ctx, namespace, node, s.cfg.Image, cmd.Build(), "", "", 0)
 	if err != nil {
@@ -27,9 +43,12 @@ func (s *MCPServer) utilityExists(ctx context.Context, namespace, node, utility
 	}
 	stderr = strings.TrimSpace(filterWarnings(stderr))
 	if stderr != "" {
-		return fmt.Errorf("utility %s is unavailable in configured image: %s", utility, stderr)
+		availability = fmt.Errorf("utility %s is unavailable in configured image: %s", utility, stderr)
 	}
-	return nil
+	s.utilitiesMu.Lock()
+	s.utilities[key] = availability
+	s.utilitiesMu.Unlock()
+	return availability
 }
 
 // filterWarnings filters out lines starting with "Warning" from the output
@@ -72,3 +91,54 @@ func splitConntrackSummary(output string) (summary, remaining string) {
 
 	return strings.Join(summaryLines, "\n"), strings.Join(remainingLines, "\n")
 }
+
+const (
+	// ipFamilyIPv4 selects the IPv4 family.
+	ipFamilyIPv4 = "ipv4"
+	// ipFamilyIPv6 selects the IPv6 family.
+	ipFamilyIPv6 = "ipv6"
+	// ipFamilyBoth selects both families.
+	ipFamilyBoth = "both"
+)
+
+// parseIPFamily validates an ip_family parameter and returns the families it selects, IPv4 first.
+// An empty parameter selects no family.
+func parseIPFamily(ipFamily string) ([]string, error) {
+	switch strings.ToLower(strings.TrimSpace(ipFamily)) {
+	case "":
+		return nil, nil
+	case ipFamilyIPv4:
+		return []string{ipFamilyIPv4}, nil
+	case ipFamilyIPv6:
+		return []string{ipFamilyIPv6}, nil
+	case ipFamilyBoth:
+		return []string{ipFamilyIPv4, ipFamilyIPv6}, nil
+	}
+	return nil, fmt.Errorf("invalid ip_family %q: must be one of ipv4, ipv6, both", ipFamily)
+}
+
+// outputSectionPrefix starts the line naming each section of the output of a script that runs
+// several commands, e.g. '### snmp'.
+const outputSectionPrefix = "### "
+
+// outputSection is a named section of the output of a script. Empty lines are dropped.
+type outputSection struct {
+	name  string
+	lines []string
+}
+
+// splitOutputSections splits the output of a script into its sections. Lines before the first
+// section are ignored.
+func splitOutputSections(output string) []outputSection {
+	var sections []outputSection
+	for _, line := range strings.Split(output, "\n") {
+		if name, ok := strings.CutPrefix(line, outputSectionPrefix); ok {
+			sections = append(sections, outputSection{name: strings.TrimSpace(name)})
+			continue
+		}
+		if len(sections) > 0 && strings.TrimSpace(line) != "" {
+			sections[len(sections)-1].lines = append(sections[len(sections)-1].lines, line)
+		}
+	}
+	return sections
+}
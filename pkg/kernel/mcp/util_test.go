@@ -1,7 +1,13 @@
 package mcp
 
 import (
+	"context"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestFilterWarnings(t *testing.T) {
@@ -139,3 +145,80 @@ func TestSplitConntrackSummary(t *testing.T) {
 		})
 	}
 }
+
+func TestParseIPFamily(t *testing.T) {
+	tests := []struct {
+		name      string
+		ipFamily  string
+		want      []string
+		wantError bool
+	}{
+		{name: "empty", ipFamily: ""},
+		{name: "ipv4", ipFamily: "ipv4", want: []string{"ipv4"}},
+		{name: "ipv6 with spaces and uppercase", ipFamily: " IPv6 ", want: []string{"ipv6"}},
+		{name: "both", ipFamily: "both", want: []string{"ipv4", "ipv6"}},
+		{name: "invalid", ipFamily: "inet6", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIPFamily(tt.ipFamily)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("parseIPFamily(%q) error = %v, wantError %v", tt.ipFamily, err, tt.wantError)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseIPFamily(%q) mismatch (-want +got):\n%s", tt.ipFamily, diff)
+			}
+		})
+	}
+}
+
+func TestUtilityExistsCache(t *testing.T) {
+	var commands []string
+	failing := true
+	runDebugNodeCommand := func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+		command := nodeName + ": " + strings.Join(cmd, " ")
+		commands = append(commands, command)
+		switch {
+		case cmd[0] == "nft" && failing:
+			return "", "", fmt.Errorf("debug pod did not reach running state")
+		case cmd[0] == "conntrack":
+			return "", "sh: conntrack: not found", nil
+		}
+		return "", "", nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		return "", "", nil
+	}
+	server, err := NewMCPServer(runDebugNodeCommand, runPodExecCommand, Config{Image: "test"})
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	checks := []struct {
+		node    string
+		utility string
+		wantErr bool
+	}{
+		{node: "worker1", utility: "ip"},
+		{node: "worker1", utility: "ip"},
+		{node: "worker2", utility: "ip"},
+		{node: "worker1", utility: "conntrack", wantErr: true},
+		{node: "worker1", utility: "conntrack", wantErr: true},
+		// Errors running the check are not cached.
+		{node: "worker1", utility: "nft", wantErr: true},
+	}
+	for _, check := range checks {
+		if err := server.utilityExists(context.Background(), "", check.node, check.utility); (err != nil) != check.wantErr {
+			t.Fatalf("utilityExists(%s, %s) error = %v, wantErr %v", check.node, check.utility, err, check.wantErr)
+		}
+	}
+	failing = false
+	if err := server.utilityExists(context.Background(), "", "worker1", "nft"); err != nil {
+		t.Fatalf("utilityExists(worker1, nft) error = %v", err)
+	}
+
+	want := []string{"worker1: ip -V", "worker2: ip -V", "worker1: conntrack -V", "worker1: nft -V", "worker1: nft -V"}
+	if diff := cmp.Diff(want, commands); diff != "" {
+		t.Errorf("commands mismatch (-want +got):\n%s", diff)
+	}
+}
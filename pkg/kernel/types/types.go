@@ -14,38 +14,741 @@ type CommonParams struct {
 	timeout.TimeoutParams
 }
 
+// PodTargetParams selects a pod running on the node in whose network namespace the command is run,
+// instead of the node network namespace. Both or neither of PodNamespace and PodName must be given.
+type PodTargetParams struct {
+	PodNamespace string `json:"pod_namespace,omitempty"` // PodNamespace is the namespace of the pod whose network namespace is used
+	PodName      string `json:"pod_name,omitempty"`      // PodName is the name of the pod whose network namespace is used
+}
+
 // ListConntrackParams contains parameters for listing connection tracking entries using conntrack command.
 // Connection tracking entries show active network connections and their state.
 type ListConntrackParams struct {
 	CommonParams
+	PodTargetParams
 	Command          string `json:"command,omitempty"`           // Command specifies the conntrack command to execute (e.g., "list", "dump")
 	FilterParameters string `json:"filter_parameters,omitempty"` // FilterParameters specifies additional filter criteria for conntrack entries
+	Zone             string `json:"zone,omitempty"`              // Zone matches only entries in the given conntrack zone
+	Mark             string `json:"mark,omitempty"`              // Mark matches only entries whose mark equals value[/mask]
+	Labels           string `json:"labels,omitempty"`            // Labels matches only entries whose labels equal value[/mask]
+	Status           string `json:"status,omitempty"`            // Status matches only entries having all of the given comma-separated status flags
+	Aggregate        bool   `json:"aggregate,omitempty"`         // Aggregate returns entry counts by zone, state, destination and protocol instead of entries
+	IPFamily         string `json:"ip_family,omitempty"`         // IPFamily lists the entries of the family: ipv4, ipv6 or both
+}
+
+// ConntrackTuple is one direction (original or reply) of a connection tracking entry.
+type ConntrackTuple struct {
+	Src      string `json:"src,omitempty"`       // Src is the source address
+	Dst      string `json:"dst,omitempty"`       // Dst is the destination address
+	Sport    int    `json:"sport,omitempty"`     // Sport is the source port
+	Dport    int    `json:"dport,omitempty"`     // Dport is the destination port
+	ICMPType *int   `json:"icmp_type,omitempty"` // ICMPType is the ICMP type for ICMP entries
+	ICMPCode *int   `json:"icmp_code,omitempty"` // ICMPCode is the ICMP code for ICMP entries
+	ICMPID   *int   `json:"icmp_id,omitempty"`   // ICMPID is the ICMP identifier for ICMP entries
+}
+
+// ConntrackEntry is a parsed connection tracking entry.
+type ConntrackEntry struct {
+	Family         string         `json:"family,omitempty"` // Family is the layer 3 family (ipv4 or ipv6) when present in the output
+	Protocol       string         `json:"protocol"`         // Protocol is the layer 4 protocol name (tcp, udp, icmp, ...)
+	ProtocolNumber int            `json:"protocol_number"`  // ProtocolNumber is the layer 4 protocol number
+	Timeout        int            `json:"timeout"`          // Timeout is the number of seconds until the entry expires
+	State          string         `json:"state,omitempty"`  // State is the protocol state (e.g. ESTABLISHED) for stateful protocols
+	Original       ConntrackTuple `json:"original"`         // Original is the tuple in the original direction
+	Reply          ConntrackTuple `json:"reply"`            // Reply is the tuple in the reply direction
+	Zone           int            `json:"zone,omitempty"`   // Zone is the conntrack zone of the entry
+	Mark           uint32         `json:"mark,omitempty"`   // Mark is the connection mark
+	Labels         string         `json:"labels,omitempty"` // Labels is the hexadecimal connection label bitmap
+	Flags          []string       `json:"flags,omitempty"`  // Flags are the status flags printed in brackets (e.g. ASSURED, UNREPLIED)
+	Use            int            `json:"use,omitempty"`    // Use is the reference count of the entry
+	ID             string         `json:"id,omitempty"`     // ID is the conntrack entry identifier when printed
+}
+
+// ConntrackCount is the number of entries sharing the same key.
+type ConntrackCount struct {
+	Key   string `json:"key"`   // Key is the value the entries are grouped by
+	Count int    `json:"count"` // Count is the number of entries with that value
+}
+
+// ConntrackAggregate summarizes connection tracking entries. Each grouping is
+// sorted by descending count.
+type ConntrackAggregate struct {
+	Total         int              `json:"total"`          // Total is the number of entries that matched the filters
+	ByZone        []ConntrackCount `json:"by_zone"`        // ByZone counts entries per conntrack zone
+	ByState       []ConntrackCount `json:"by_state"`       // ByState counts entries per protocol state
+	ByDestination []ConntrackCount `json:"by_destination"` // ByDestination counts entries per original destination address, port and protocol
+	ByProtocol    []ConntrackCount `json:"by_protocol"`    // ByProtocol counts entries per layer 4 protocol
+}
+
+// ConntrackResult represents the output of the get-conntrack tool. Entries or
+// Aggregate are populated for list operations, Data for count and stats.
+type ConntrackResult struct {
+	Entries   []ConntrackEntry    `json:"entries,omitempty"`   // Entries are the parsed connection tracking entries
+	Aggregate *ConntrackAggregate `json:"aggregate,omitempty"` // Aggregate is populated instead of Entries when aggregation is requested
+	Summary   string              `json:"summary,omitempty"`   // Summary is the informational summary printed by the conntrack CLI
+	Netns     string              `json:"netns,omitempty"`     // Netns describes the pod network namespace the entries were read in, when a pod is given
+	Data      string              `json:"data,omitempty"`      // Data contains the raw output of count and stats operations
+}
+
+// ConntrackEventsParams contains parameters for monitoring connection tracking events using conntrack -E.
+// Events are collected for DurationSeconds and then returned.
+type ConntrackEventsParams struct {
+	CommonParams
+	PodTargetParams
+	DurationSeconds int    `json:"duration_seconds,omitempty"` // DurationSeconds is how long events are collected for
+	EventTypes      string `json:"event_types,omitempty"`      // EventTypes is a comma-separated list of NEW, UPDATE and DESTROY
+	Protocol        string `json:"protocol,omitempty"`         // Protocol matches only events of the given layer 4 protocol
+	Src             string `json:"src,omitempty"`              // Src matches only events whose source address in the original direction is Src
+	Dst             string `json:"dst,omitempty"`              // Dst matches only events whose destination address in the original direction is Dst
+	Sport           int    `json:"sport,omitempty"`            // Sport matches only events whose source port in the original direction is Sport
+	Dport           int    `json:"dport,omitempty"`            // Dport matches only events whose destination port in the original direction is Dport
+	Zone            string `json:"zone,omitempty"`             // Zone matches only events in the given conntrack zone
+}
+
+// ConntrackEvent is a parsed connection tracking event.
+type ConntrackEvent struct {
+	Timestamp string `json:"timestamp,omitempty"` // Timestamp is the time the event was received, in RFC 3339 format
+	Type      string `json:"type"`                // Type is the event type (NEW, UPDATE or DESTROY)
+	ConntrackEntry
+}
+
+// ConntrackEventsResult represents the output of the get-conntrack-events tool.
+type ConntrackEventsResult struct {
+	Events          []ConntrackEvent `json:"events"`            // Events are the events received, in order
+	DurationSeconds int              `json:"duration_seconds"`  // DurationSeconds is how long events were collected for
+	Summary         string           `json:"summary,omitempty"` // Summary is the informational summary printed by the conntrack CLI
+	Netns           string           `json:"netns,omitempty"`   // Netns describes the pod network namespace the events were monitored in, when a pod is given
+}
+
+// ConntrackLookupParams contains parameters for looking up a single connection tracking entry by its
+// original direction 5-tuple. When OVSNamespace and OVSPod are set, the OVS datapath view of the same
+// flow is collected from that pod using 'ovs-appctl dpctl/dump-conntrack' and compared with the kernel view.
+type ConntrackLookupParams struct {
+	PodTargetParams
+	Node         string `json:"node"`                    // Node is the name of the Kubernetes node where the kernel lookup is executed
+	Namespace    string `json:"namespace,omitempty"`     // Namespace is the namespace of the debug pod used for the kernel lookup
+	Protocol     string `json:"protocol"`                // Protocol is the layer 4 protocol of the flow
+	Src          string `json:"src"`                     // Src is the source address in the original direction
+	Dst          string `json:"dst"`                     // Dst is the destination address in the original direction
+	Sport        int    `json:"sport"`                   // Sport is the source port in the original direction
+	Dport        int    `json:"dport"`                   // Dport is the destination port in the original direction
+	Zone         string `json:"zone,omitempty"`          // Zone is the conntrack zone of the entry. If empty, all zones are searched
+	OVSNamespace string `json:"ovs_namespace,omitempty"` // OVSNamespace is the namespace of the pod running OVS on the node
+	OVSPod       string `json:"ovs_pod,omitempty"`       // OVSPod is the name of the pod running OVS on the node
+	OVSContainer string `json:"ovs_container,omitempty"` // OVSContainer is the container of OVSPod running OVS. Defaults to the first container
+	timeout.TimeoutParams
+}
+
+// ConntrackComparison compares the kernel and OVS views of a flow in one conntrack zone.
+type ConntrackComparison struct {
+	Zone        int      `json:"zone"`                  // Zone is the conntrack zone of the compared entries
+	InKernel    bool     `json:"in_kernel"`             // InKernel is true if the kernel lookup found the entry
+	InOVS       bool     `json:"in_ovs"`                // InOVS is true if the OVS datapath dump contains the entry
+	DNAT        string   `json:"dnat,omitempty"`        // DNAT is the address and port the destination was translated to, if any
+	SNAT        string   `json:"snat,omitempty"`        // SNAT is the address and port the source was translated to, if any
+	Differences []string `json:"differences,omitempty"` // Differences lists fields whose values differ between the two views
+}
+
+// ConntrackLookupResult represents the output of the get-conntrack-entry tool.
+type ConntrackLookupResult struct {
+	Kernel      []ConntrackEntry      `json:"kernel"`                // Kernel are the entries found by the kernel lookup, one per zone
+	OVS         []ConntrackEntry      `json:"ovs,omitempty"`         // OVS are the matching entries of the OVS datapath, one per zone
+	Comparisons []ConntrackComparison `json:"comparisons,omitempty"` // Comparisons compare the two views per zone
+	Netns       string                `json:"netns,omitempty"`       // Netns describes the pod network namespace of the kernel lookup, when a pod is given
 }
 
 // ListIPTablesParams contains parameters for inspecting iptables/ip6tables packet filter rules.
 // Supports both IPv4 (iptables) and IPv6 (ip6tables) firewall rules.
 type ListIPTablesParams struct {
 	CommonParams
+	PodTargetParams
 	Table            string `json:"table,omitempty"`             // Table specifies the iptables table to query (e.g., "filter", "nat", "mangle", "raw")
 	Command          string `json:"command,omitempty"`           // Command specifies the iptables action (e.g., "-L", "-S"). If omitted or empty, defaults to "-L"
 	FilterParameters string `json:"filter_parameters,omitempty"` // FilterParameters specifies additional filter criteria for iptables rules
+	IPFamily         string `json:"ip_family,omitempty"`         // IPFamily selects iptables (ipv4), ip6tables (ipv6) or both. If omitted, derived from -4/-6 in FilterParameters
+}
+
+// IPTablesFamilyResult is the output of iptables or ip6tables.
+type IPTablesFamilyResult struct {
+	Family  string `json:"family"`  // Family is the IP family of the rules: ipv4 or ipv6
+	Command string `json:"command"` // Command is the command that listed the rules
+	Data    string `json:"data"`    // Data contains the command execution output
+}
+
+// IPTablesResult represents the output of the get-iptables tool, one result per IP family.
+type IPTablesResult struct {
+	Families []IPTablesFamilyResult `json:"families"`        // Families are the results of each listed IP family
+	Netns    string                 `json:"netns,omitempty"` // Netns describes the pod network namespace the rules were listed in, when a pod is given
+}
+
+// IPTablesTraceParams contains parameters for evaluating the iptables rules of a node against a
+// packet. The packet enters the netfilter hooks at Hook and is followed through the next hooks.
+type IPTablesTraceParams struct {
+	PodTargetParams
+	Node         string `json:"node"`                    // Node is the name of the Kubernetes node whose rules are evaluated
+	Namespace    string `json:"namespace,omitempty"`     // Namespace is the namespace of the debug pod
+	Hook         string `json:"hook"`                    // Hook is the netfilter hook the packet enters (PREROUTING, INPUT, FORWARD, OUTPUT, POSTROUTING)
+	Protocol     string `json:"protocol"`                // Protocol is the protocol of the packet (tcp, udp, sctp, icmp, icmpv6)
+	Src          string `json:"src"`                     // Src is the source address of the packet
+	Dst          string `json:"dst"`                     // Dst is the destination address of the packet
+	Sport        int    `json:"sport,omitempty"`         // Sport is the source port of the packet
+	Dport        int    `json:"dport,omitempty"`         // Dport is the destination port of the packet
+	InInterface  string `json:"in_interface,omitempty"`  // InInterface is the interface the packet is received on
+	OutInterface string `json:"out_interface,omitempty"` // OutInterface is the interface the packet is sent out of
+	Mark         string `json:"mark,omitempty"`          // Mark is the packet mark when entering Hook
+	CtState      string `json:"ct_state,omitempty"`      // CtState is the conntrack state of the packet (NEW, ESTABLISHED, RELATED, INVALID, UNTRACKED)
+	timeout.TimeoutParams
+}
+
+// IPTablesTraceStep is a rule, or a chain policy, applied to the packet.
+type IPTablesTraceStep struct {
+	Hook       string `json:"hook"`                  // Hook is the netfilter hook being traversed
+	Table      string `json:"table"`                 // Table is the table of the chain
+	Chain      string `json:"chain"`                 // Chain is the chain of the rule
+	RuleNumber int    `json:"rule_number,omitempty"` // RuleNumber is the position of the rule in the chain, or 0 for the chain policy
+	Rule       string `json:"rule"`                  // Rule is the rule in iptables-save format, or the chain policy
+	Action     string `json:"action"`                // Action is what the rule did: jump, goto, return, accept, drop, reject, dnat, snat, masquerade, redirect, mark, continue or policy
+}
+
+// IPTablesTracePacket is the packet after the translations and marks applied by the rules.
+type IPTablesTracePacket struct {
+	Src   string `json:"src"`             // Src is the source address
+	Dst   string `json:"dst"`             // Dst is the destination address
+	Sport int    `json:"sport,omitempty"` // Sport is the source port
+	Dport int    `json:"dport,omitempty"` // Dport is the destination port
+	Mark  string `json:"mark"`            // Mark is the packet mark
+}
+
+// IPTablesTraceResult represents the output of the trace-iptables tool.
+type IPTablesTraceResult struct {
+	Hooks        []string            `json:"hooks"`                  // Hooks are the netfilter hooks traversed by the packet
+	Steps        []IPTablesTraceStep `json:"steps"`                  // Steps are the matching rules and applied policies in evaluation order
+	Undetermined []IPTablesTraceStep `json:"undetermined,omitempty"` // Undetermined are rules whose matches could not be evaluated; they are assumed not to match
+	Verdict      string              `json:"verdict"`                // Verdict is the final verdict: ACCEPT, DROP or REJECT
+	DNAT         string              `json:"dnat,omitempty"`         // DNAT is the destination the packet was translated to
+	SNAT         string              `json:"snat,omitempty"`         // SNAT is the source the packet was translated to, or "masquerade"
+	Packet       IPTablesTracePacket `json:"packet"`                 // Packet is the packet after the evaluation
+	Netns        string              `json:"netns,omitempty"`        // Netns describes the pod network namespace whose rules were evaluated, when a pod is given
 }
 
 // ListNFTParams contains parameters for inspecting nftables packet filtering and classification rules.
 // nftables is the modern replacement for iptables in the Linux kernel.
 type ListNFTParams struct {
 	CommonParams
+	PodTargetParams
 	Command         string `json:"command"`                    // Command specifies the nft command to execute
 	AddressFamilies string `json:"address_families,omitempty"` // AddressFamilies specifies the address family to filter (e.g., "ip", "ip6", "inet", "arp", "bridge")
+	Table           string `json:"table,omitempty"`            // Table selects the table to list, or limits the listed objects to the table
+	Name            string `json:"name,omitempty"`             // Name is the chain, set or map to list
+	Element         string `json:"element,omitempty"`          // Element limits the listed set or map elements to those containing the value
+	IPFamily        string `json:"ip_family,omitempty"`        // IPFamily limits the listing to the ip (ipv4), ip6 (ipv6) and inet tables
+}
+
+// SearchNFTParams contains parameters for searching the nftables ruleset for the rules and the
+// set and map elements that reference an address or a port.
+type SearchNFTParams struct {
+	CommonParams
+	PodTargetParams
+	AddressFamilies string `json:"address_families,omitempty"` // AddressFamilies limits the search to the tables of the address family
+	Table           string `json:"table,omitempty"`            // Table limits the search to the table
+	Address         string `json:"address,omitempty"`          // Address is the IPv4 or IPv6 address to search for
+	Port            int    `json:"port,omitempty"`             // Port is the transport port to search for
+	IPFamily        string `json:"ip_family,omitempty"`        // IPFamily limits the search to the ip (ipv4), ip6 (ipv6) and inet tables
+}
+
+// NFTTable is an nftables table.
+type NFTTable struct {
+	Family string `json:"family"` // Family is the address family of the table
+	Name   string `json:"name"`   // Name is the table name
+	Handle int    `json:"handle"` // Handle is the table handle
+}
+
+// NFTChain is an nftables chain. Base chains carry their type, hook, priority and policy.
+type NFTChain struct {
+	Family   string `json:"family"`             // Family is the address family of the table
+	Table    string `json:"table"`              // Table is the table of the chain
+	Name     string `json:"name"`               // Name is the chain name
+	Handle   int    `json:"handle"`             // Handle is the chain handle
+	Type     string `json:"type,omitempty"`     // Type is the base chain type (filter, nat, route)
+	Hook     string `json:"hook,omitempty"`     // Hook is the base chain hook
+	Priority *int   `json:"priority,omitempty"` // Priority is the base chain priority
+	Policy   string `json:"policy,omitempty"`   // Policy is the base chain policy
+	Device   string `json:"device,omitempty"`   // Device is the device of netdev base chains
+}
+
+// NFTCounter holds the packet and byte counts of a rule counter.
+type NFTCounter struct {
+	Packets uint64 `json:"packets"` // Packets is the number of packets that matched the rule
+	Bytes   uint64 `json:"bytes"`   // Bytes is the number of bytes that matched the rule
+}
+
+// NFTRule is an nftables rule parsed from 'nft -j' output.
+type NFTRule struct {
+	Family      string      `json:"family"`            // Family is the address family of the table
+	Table       string      `json:"table"`             // Table is the table of the rule
+	Chain       string      `json:"chain"`             // Chain is the chain of the rule
+	Handle      int         `json:"handle"`            // Handle is the rule handle
+	Comment     string      `json:"comment,omitempty"` // Comment is the rule comment
+	Expressions []string    `json:"expressions"`       // Expressions are the matches and statements of the rule in nft syntax
+	Verdict     string      `json:"verdict,omitempty"` // Verdict is the verdict of the rule (accept, drop, jump <chain>, ...)
+	Counter     *NFTCounter `json:"counter,omitempty"` // Counter holds the rule counter, if the rule has one
+}
+
+// NFTElement is an element of an nftables set or map.
+type NFTElement struct {
+	Key   string `json:"key"`             // Key is the element, or the map key, in nft syntax
+	Value string `json:"value,omitempty"` // Value is the map value in nft syntax
+}
+
+// NFTSet is an nftables set or map.
+type NFTSet struct {
+	Family    string       `json:"family"`               // Family is the address family of the table
+	Table     string       `json:"table"`                // Table is the table of the set
+	Name      string       `json:"name"`                 // Name is the set name
+	Handle    int          `json:"handle"`               // Handle is the set handle
+	Type      string       `json:"type"`                 // Type is the element type, or the map key type
+	ValueType string       `json:"value_type,omitempty"` // ValueType is the map value type
+	Flags     []string     `json:"flags,omitempty"`      // Flags are the set flags (e.g. interval, timeout)
+	Elements  []NFTElement `json:"elements,omitempty"`   // Elements are the set or map elements
+}
+
+// NFTFlowtable is an nftables flowtable.
+type NFTFlowtable struct {
+	Family   string   `json:"family"`             // Family is the address family of the table
+	Table    string   `json:"table"`              // Table is the table of the flowtable
+	Name     string   `json:"name"`               // Name is the flowtable name
+	Hook     string   `json:"hook,omitempty"`     // Hook is the flowtable hook
+	Priority *int     `json:"priority,omitempty"` // Priority is the flowtable priority
+	Devices  []string `json:"devices,omitempty"`  // Devices are the devices of the flowtable
+}
+
+// NFTResult represents the output of the get-nft tool.
+type NFTResult struct {
+	Tables     []NFTTable     `json:"tables,omitempty"`     // Tables are the listed tables
+	Chains     []NFTChain     `json:"chains,omitempty"`     // Chains are the listed chains
+	Rules      []NFTRule      `json:"rules,omitempty"`      // Rules are the listed rules
+	Sets       []NFTSet       `json:"sets,omitempty"`       // Sets are the listed sets
+	Maps       []NFTSet       `json:"maps,omitempty"`       // Maps are the listed maps
+	Flowtables []NFTFlowtable `json:"flowtables,omitempty"` // Flowtables are the listed flowtables
+	Netns      string         `json:"netns,omitempty"`      // Netns describes the pod network namespace the ruleset was listed in, when a pod is given
+}
+
+// NFTRuleMatch is a rule found by the search-nft tool.
+type NFTRuleMatch struct {
+	NFTRule
+	Matches []string `json:"matches"` // Matches are the rule expressions, or the referenced sets and maps, that matched the search
+}
+
+// NFTElementMatch is a set or map element found by the search-nft tool.
+type NFTElementMatch struct {
+	Family string `json:"family"` // Family is the address family of the table
+	Table  string `json:"table"`  // Table is the table of the set or map
+	Set    string `json:"set"`    // Set is the name of the set or map
+	Map    bool   `json:"map"`    // Map is true if the element belongs to a map
+	NFTElement
+}
+
+// NFTSearchResult represents the output of the search-nft tool.
+type NFTSearchResult struct {
+	Rules    []NFTRuleMatch    `json:"rules"`           // Rules are the rules referencing the address and port directly or through a set or map
+	Elements []NFTElementMatch `json:"elements"`        // Elements are the set and map elements containing the address and port
+	Netns    string            `json:"netns,omitempty"` // Netns describes the pod network namespace the ruleset was searched in, when a pod is given
 }
 
 // ListIPParams contains parameters for inspecting routing, network devices, and network interfaces.
 // Uses the iproute2 suite (ip command) for network configuration and inspection.
 type ListIPParams struct {
 	CommonParams
+	PodTargetParams
 	Options          string `json:"options,omitempty"`           // Options specifies additional command-line options for the ip command
 	Command          string `json:"command"`                     // Command specifies the ip subcommand to execute (e.g., "route", "link", "addr", "neigh")
 	FilterParameters string `json:"filter_parameters,omitempty"` // FilterParameters specifies additional filter criteria for the output
+	Table            string `json:"table,omitempty"`             // Table limits route and rule output to the given routing table
+	Device           string `json:"device,omitempty"`            // Device limits route, address, link and neighbour output to the given device
+	Netns            string `json:"netns,omitempty"`             // Netns runs the ip command in the given named network namespace
+}
+
+// IPNexthop is one nexthop of a multipath route.
+type IPNexthop struct {
+	Gateway string   `json:"gateway,omitempty"` // Gateway is the nexthop gateway address
+	Dev     string   `json:"dev,omitempty"`     // Dev is the nexthop output device
+	Weight  int      `json:"weight,omitempty"`  // Weight is the nexthop weight
+	Flags   []string `json:"flags,omitempty"`   // Flags are the nexthop flags (e.g. onlink, linkdown)
+}
+
+// IPRoute is a route parsed from 'ip -j route show'.
+type IPRoute struct {
+	Type     string      `json:"type,omitempty"`     // Type is the route type (e.g. local, broadcast); empty for unicast routes
+	Dst      string      `json:"dst"`                // Dst is the destination prefix, or "default"
+	Gateway  string      `json:"gateway,omitempty"`  // Gateway is the gateway address of single path routes
+	Dev      string      `json:"dev,omitempty"`      // Dev is the output device of single path routes
+	Table    string      `json:"table"`              // Table is the routing table the route belongs to
+	Protocol string      `json:"protocol,omitempty"` // Protocol is the routing protocol that installed the route (e.g. kernel, static, dhcp)
+	Scope    string      `json:"scope,omitempty"`    // Scope is the route scope (e.g. link, host)
+	PrefSrc  string      `json:"prefsrc,omitempty"`  // PrefSrc is the preferred source address
+	Metric   int         `json:"metric,omitempty"`   // Metric is the route priority
+	MTU      int         `json:"mtu,omitempty"`      // MTU is the route MTU, if set
+	Flags    []string    `json:"flags,omitempty"`    // Flags are the route flags (e.g. onlink, linkdown)
+	Nexthops []IPNexthop `json:"nexthops,omitempty"` // Nexthops are the nexthops of multipath routes
+}
+
+// IPLinkInfo holds the type specific details of a link printed by 'ip -d'.
+type IPLinkInfo struct {
+	Kind         string `json:"kind,omitempty"`          // Kind is the link type (e.g. vlan, vxlan, geneve, veth, openvswitch)
+	SlaveKind    string `json:"slave_kind,omitempty"`    // SlaveKind is the type of the master the link is enslaved to (e.g. bridge, openvswitch)
+	VLANID       *int   `json:"vlan_id,omitempty"`       // VLANID is the VLAN ID of vlan links
+	VLANProtocol string `json:"vlan_protocol,omitempty"` // VLANProtocol is the VLAN protocol (802.1Q or 802.1ad) of vlan links
+	VNI          *int   `json:"vni,omitempty"`           // VNI is the VXLAN or Geneve network identifier
+	Local        string `json:"local,omitempty"`         // Local is the local tunnel endpoint address
+	Remote       string `json:"remote,omitempty"`        // Remote is the remote tunnel endpoint address
+	Group        string `json:"group,omitempty"`         // Group is the VXLAN multicast group
+	Port         int    `json:"port,omitempty"`          // Port is the VXLAN or Geneve destination UDP port
+	External     bool   `json:"external,omitempty"`      // External is true for tunnels in external (collect metadata) mode
+}
+
+// IPLink is a network device parsed from 'ip -j -d link show'.
+type IPLink struct {
+	Index     int         `json:"index"`                  // Index is the interface index
+	Name      string      `json:"name"`                   // Name is the interface name
+	Link      string      `json:"link,omitempty"`         // Link is the parent device of vlan, macvlan and veth links
+	Type      string      `json:"type,omitempty"`         // Type is the link layer type (e.g. ether, loopback, none)
+	Address   string      `json:"address,omitempty"`      // Address is the link layer address
+	MTU       int         `json:"mtu"`                    // MTU is the device MTU
+	State     string      `json:"state,omitempty"`        // State is the operational state (e.g. UP, DOWN, UNKNOWN)
+	Flags     []string    `json:"flags,omitempty"`        // Flags are the device flags (e.g. UP, LOWER_UP, BROADCAST)
+	Master    string      `json:"master,omitempty"`       // Master is the device this link is enslaved to
+	LinkNetns *int        `json:"link_netnsid,omitempty"` // LinkNetns is the network namespace ID of the peer of veth links
+	Info      *IPLinkInfo `json:"info,omitempty"`         // Info holds the type specific details of the link
+}
+
+// IPAddress is an address parsed from 'ip -j address show'. There is one entry per address.
+type IPAddress struct {
+	Index     int    `json:"index"`               // Index is the interface index
+	Dev       string `json:"dev"`                 // Dev is the interface name
+	Family    string `json:"family"`              // Family is inet or inet6
+	Address   string `json:"address"`             // Address is the local address
+	PrefixLen int    `json:"prefixlen"`           // PrefixLen is the prefix length
+	Broadcast string `json:"broadcast,omitempty"` // Broadcast is the broadcast address
+	Scope     string `json:"scope,omitempty"`     // Scope is the address scope (e.g. global, link, host)
+	Label     string `json:"label,omitempty"`     // Label is the address label
+	Dynamic   bool   `json:"dynamic,omitempty"`   // Dynamic is true for addresses learned by autoconfiguration or DHCP
+}
+
+// IPNeighbour is a neighbour cache entry parsed from 'ip -j neighbour show'.
+type IPNeighbour struct {
+	Dst    string   `json:"dst"`              // Dst is the neighbour address
+	Dev    string   `json:"dev,omitempty"`    // Dev is the device the neighbour is reachable through
+	LLAddr string   `json:"lladdr,omitempty"` // LLAddr is the link layer address of the neighbour
+	State  []string `json:"state,omitempty"`  // State is the neighbour state (e.g. REACHABLE, STALE, FAILED)
+	Router bool     `json:"router,omitempty"` // Router is true if the neighbour is a router
+}
+
+// IPRule is a routing policy rule parsed from 'ip -j rule show'.
+type IPRule struct {
+	Priority int    `json:"priority"`            // Priority is the rule priority
+	Not      bool   `json:"not,omitempty"`       // Not is true if the selector is inverted
+	Src      string `json:"src,omitempty"`       // Src is the source prefix selector, or "all"
+	Dst      string `json:"dst,omitempty"`       // Dst is the destination prefix selector
+	IIF      string `json:"iif,omitempty"`       // IIF is the input interface selector
+	OIF      string `json:"oif,omitempty"`       // OIF is the output interface selector
+	FwMark   string `json:"fwmark,omitempty"`    // FwMark is the firewall mark selector, with an optional /mask
+	UIDRange string `json:"uid_range,omitempty"` // UIDRange is the uid range selector
+	L3MDev   bool   `json:"l3mdev,omitempty"`    // L3MDev is true if the table is selected by the VRF device of the packet
+	Table    string `json:"table,omitempty"`     // Table is the routing table looked up when the rule matches
+	Action   string `json:"action,omitempty"`    // Action is the rule action when it does not look up a table (e.g. unreachable, blackhole)
+}
+
+// IPResult represents the output of the get-ip tool. Route, link, address, neighbour and rule
+// commands return typed objects; the other commands return their raw output in Data.
+type IPResult struct {
+	Routes     []IPRoute     `json:"routes,omitempty"`     // Routes are populated for route commands
+	Links      []IPLink      `json:"links,omitempty"`      // Links are populated for link commands
+	Addresses  []IPAddress   `json:"addresses,omitempty"`  // Addresses are populated for address commands
+	Neighbours []IPNeighbour `json:"neighbours,omitempty"` // Neighbours are populated for neighbour commands
+	Rules      []IPRule      `json:"rules,omitempty"`      // Rules are populated for rule commands
+	Data       string        `json:"data,omitempty"`       // Data contains the raw output of the other commands
+	Netns      string        `json:"netns,omitempty"`      // Netns describes the pod network namespace the command was run in, when a pod is given
+}
+
+// RouteGetParams contains parameters for asking the kernel how it would route a packet using
+// 'ip route get'. The lookup runs in the node network namespace, or in the network namespace of
+// the pod given by PodNamespace and PodName, which must be running on the node.
+type RouteGetParams struct {
+	Node      string `json:"node"`                // Node is the name of the Kubernetes node where the lookup is executed
+	Namespace string `json:"namespace,omitempty"` // Namespace is the namespace of the debug pod used for the lookup
+	Dst       string `json:"dst"`                 // Dst is the destination address of the packet
+	Src       string `json:"src,omitempty"`       // Src is the source address of the packet
+	IIF       string `json:"iif,omitempty"`       // IIF is the input interface of a forwarded packet. Requires Src
+	FwMark    string `json:"fwmark,omitempty"`    // FwMark is the firewall mark of the packet
+	UID       string `json:"uid,omitempty"`       // UID is the uid of the socket owner of a locally generated packet
+	VRF       string `json:"vrf,omitempty"`       // VRF is the VRF device the packet is associated with
+	PodTargetParams
+	timeout.TimeoutParams
+}
+
+// RouteDecision is the route selected by the kernel for a packet, parsed from 'ip -j route get'.
+type RouteDecision struct {
+	Type    string   `json:"type,omitempty"`    // Type is the route type (e.g. local, broadcast); empty for unicast routes
+	Dst     string   `json:"dst"`               // Dst is the destination address of the packet
+	Src     string   `json:"src,omitempty"`     // Src is the source address of the packet, if given
+	IIF     string   `json:"iif,omitempty"`     // IIF is the input interface of the packet, if given
+	Gateway string   `json:"gateway,omitempty"` // Gateway is the gateway address of the selected route
+	Dev     string   `json:"dev,omitempty"`     // Dev is the output device of the selected route
+	Table   string   `json:"table"`             // Table is the routing table the selected route belongs to
+	PrefSrc string   `json:"prefsrc,omitempty"` // PrefSrc is the source address used for locally generated packets
+	FwMark  string   `json:"fwmark,omitempty"`  // FwMark is the firewall mark of the packet, if given
+	UID     *int     `json:"uid,omitempty"`     // UID is the uid used for the lookup
+	MTU     int      `json:"mtu,omitempty"`     // MTU is the route MTU, if set
+	Flags   []string `json:"flags,omitempty"`   // Flags are the route flags
+}
+
+// RouteGetResult represents the output of the get-route-decision tool.
+type RouteGetResult struct {
+	Route RouteDecision `json:"route"`          // Route is the route selected by the kernel
+	Rule  *IPRule       `json:"rule,omitempty"` // Rule is the first routing policy rule matching the packet that selects the route table
+	Netns string        `json:"netns"`          // Netns describes the network namespace the lookup was executed in
+}
+
+// SysctlParams contains parameters for reading the network sysctls of a node, or of a pod running
+// on the node, and comparing them with the values OVN-Kubernetes expects.
+type SysctlParams struct {
+	Node      string `json:"node"`                // Node is the name of the Kubernetes node where the sysctls are read
+	Namespace string `json:"namespace,omitempty"` // Namespace is the namespace of the debug pod
+	PodTargetParams
+	Interfaces string `json:"interfaces,omitempty"` // Interfaces are additional comma-separated interfaces whose per-interface sysctls are checked
+	Keys       string `json:"keys,omitempty"`       // Keys are additional comma-separated sysctl keys to read
+	timeout.TimeoutParams
+}
+
+// SysctlCheck is a sysctl compared with the OVN-Kubernetes baseline.
+type SysctlCheck struct {
+	Key       string `json:"key"`                 // Key is the sysctl key
+	Value     string `json:"value,omitempty"`     // Value is the value of the key, empty if the key does not exist
+	Effective string `json:"effective,omitempty"` // Effective is the value applied by the kernel, for per-interface keys combined with the 'all' value
+	Expected  string `json:"expected,omitempty"`  // Expected describes the values OVN-Kubernetes expects, empty for keys that are only reported
+	Status    string `json:"status"`              // Status is ok, deviation, missing or info
+	Severity  string `json:"severity,omitempty"`  // Severity is error or warning for deviations
+	Reason    string `json:"reason,omitempty"`    // Reason explains why the value matters
+}
+
+// SysctlValue is a sysctl key and its value.
+type SysctlValue struct {
+	Key   string `json:"key"`   // Key is the sysctl key
+	Value string `json:"value"` // Value is the value of the key
+}
+
+// SysctlResult represents the output of the get-sysctl tool.
+type SysctlResult struct {
+	Netns      string        `json:"netns"`                // Netns describes the network namespace the sysctls were read in
+	Deviations []SysctlCheck `json:"deviations"`           // Deviations are the checks whose value differs from the baseline
+	Checks     []SysctlCheck `json:"checks"`               // Checks are the other baseline checks: ok, missing or info
+	Values     []SysctlValue `json:"values,omitempty"`     // Values are the additional keys requested
+	Interfaces []string      `json:"interfaces,omitempty"` // Interfaces are the interfaces whose per-interface sysctls were checked
+}
+
+// NetworkCountersParams contains parameters for measuring how the packet, drop and error counters
+// of a node change over an interval.
+type NetworkCountersParams struct {
+	CommonParams
+	IntervalSeconds int    `json:"interval_seconds,omitempty"` // IntervalSeconds is the time between the two snapshots
+	Interfaces      string `json:"interfaces,omitempty"`       // Interfaces limits the interface counters to the comma-separated interfaces
+	DropsOnly       bool   `json:"drops_only,omitempty"`       // DropsOnly returns only the drop and error counters
+}
+
+// CounterDelta is a counter that changed between the two snapshots.
+type CounterDelta struct {
+	Source string  `json:"source"`           // Source is where the counter was read: link, snmp, netstat, nstat or softnet
+	Object string  `json:"object,omitempty"` // Object is the interface of link counters or the CPU of per-CPU softnet counters
+	Name   string  `json:"name"`             // Name is the counter name
+	Before uint64  `json:"before"`           // Before is the value in the first snapshot
+	After  uint64  `json:"after"`            // After is the value in the second snapshot
+	Delta  int64   `json:"delta"`            // Delta is the change, negative if the counter was reset
+	Rate   float64 `json:"rate"`             // Rate is the change per second
+}
+
+// NetworkCountersResult represents the output of the get-network-counters tool.
+type NetworkCountersResult struct {
+	IntervalSeconds float64        `json:"interval_seconds"` // IntervalSeconds is the measured time between the two snapshots
+	Drops           []CounterDelta `json:"drops"`            // Drops are the drop and error counters that changed, highest rate first
+	Counters        []CounterDelta `json:"counters"`         // Counters are the other counters that changed, highest rate first
+}
+
+// EthtoolParams contains parameters for reading the driver, offload features, statistics, rings,
+// channels and module information of an interface with ethtool.
+type EthtoolParams struct {
+	Node             string `json:"node"`                        // Node is the name of the Kubernetes node where the interface is read
+	Namespace        string `json:"namespace,omitempty"`         // Namespace is the namespace of the debug pod
+	Nodes            string `json:"nodes,omitempty"`             // Nodes are additional comma-separated nodes whose interface is compared with the one of Node
+	Interface        string `json:"interface"`                   // Interface is the name of the interface
+	Sections         string `json:"sections,omitempty"`          // Sections are the comma-separated sections to read. Default: all
+	StatisticsFilter string `json:"statistics_filter,omitempty"` // StatisticsFilter is a regular expression the returned statistics names must match
+	timeout.TimeoutParams
+}
+
+// EthtoolFeature is an offload feature printed by ethtool -k.
+type EthtoolFeature struct {
+	Enabled   bool   `json:"enabled"`             // Enabled tells whether the feature is on
+	Fixed     bool   `json:"fixed,omitempty"`     // Fixed tells that the feature cannot be changed
+	Requested string `json:"requested,omitempty"` // Requested is the requested state when it differs from the actual state
+}
+
+// EthtoolParameters are the pre-set maximums and current settings printed by ethtool -g and -l.
+type EthtoolParameters struct {
+	Maximum map[string]string `json:"maximum,omitempty"` // Maximum are the pre-set maximums
+	Current map[string]string `json:"current,omitempty"` // Current are the current hardware settings
+}
+
+// EthtoolInterface is the ethtool information of an interface on a node.
+type EthtoolInterface struct {
+	Node       string                    `json:"node"`                 // Node is the node of the interface
+	Interface  string                    `json:"interface"`            // Interface is the name of the interface
+	Driver     map[string]string         `json:"driver,omitempty"`     // Driver is the driver information (ethtool -i)
+	Features   map[string]EthtoolFeature `json:"features,omitempty"`   // Features are the offload features (ethtool -k)
+	Statistics map[string]uint64         `json:"statistics,omitempty"` // Statistics are the driver statistics (ethtool -S)
+	Rings      *EthtoolParameters        `json:"rings,omitempty"`      // Rings are the ring sizes (ethtool -g)
+	Channels   *EthtoolParameters        `json:"channels,omitempty"`   // Channels are the channel counts (ethtool -l)
+	Module     map[string]string         `json:"module,omitempty"`     // Module is the plug-in module information (ethtool -m)
+	Errors     map[string]string         `json:"errors,omitempty"`     // Errors holds the error of each section that could not be read
+}
+
+// EthtoolDifference is a value of the interface that differs between nodes.
+type EthtoolDifference struct {
+	Section string            `json:"section"` // Section is the section of the value: driver, features, rings or channels
+	Key     string            `json:"key"`     // Key is the name of the value
+	Values  map[string]string `json:"values"`  // Values holds the value on each node, or 'missing'
+}
+
+// EthtoolResult represents the output of the get-ethtool tool.
+type EthtoolResult struct {
+	Interfaces  []EthtoolInterface  `json:"interfaces"`            // Interfaces holds the interface of each node
+	Differences []EthtoolDifference `json:"differences,omitempty"` // Differences are the values that differ between nodes, when nodes are compared
+}
+
+// SocketsParams contains parameters for listing the TCP and UDP sockets of a node or of a pod network
+// namespace with ss.
+type SocketsParams struct {
+	CommonParams
+	PodTargetParams
+	Protocol string `json:"protocol,omitempty"` // Protocol lists the sockets of the protocol: tcp, udp or both
+	State    string `json:"state,omitempty"`    // State matches only sockets in one of the comma-separated states
+	Port     int    `json:"port,omitempty"`     // Port matches only sockets whose local or peer port is Port
+	Address  string `json:"address,omitempty"`  // Address matches only sockets whose local or peer address is the address or in the CIDR
+	Process  string `json:"process,omitempty"`  // Process is a regular expression the name of a process using the socket must match
+}
+
+// SocketEndpoint is the local or peer address of a socket.
+type SocketEndpoint struct {
+	Address   string `json:"address"`             // Address is the IP address, or '*' for any address
+	Port      int    `json:"port,omitempty"`      // Port is the port, unset for any port
+	Interface string `json:"interface,omitempty"` // Interface is the interface the socket is bound to
+}
+
+// SocketProcess is a process using a socket.
+type SocketProcess struct {
+	Name string `json:"name"` // Name is the process name
+	PID  int    `json:"pid"`  // PID is the process ID
+	FD   int    `json:"fd"`   // FD is the file descriptor of the socket in the process
+}
+
+// SocketTCPInfo is the TCP information of a socket printed by ss -i. Times are in milliseconds.
+type SocketTCPInfo struct {
+	Options          []string `json:"options,omitempty"`           // Options are the negotiated options, e.g. ts, sack and wscale:7,7
+	Congestion       string   `json:"congestion,omitempty"`        // Congestion is the congestion control algorithm
+	RTT              float64  `json:"rtt,omitempty"`               // RTT is the smoothed round trip time
+	RTTVar           float64  `json:"rtt_var,omitempty"`           // RTTVar is the round trip time variance
+	MinRTT           float64  `json:"min_rtt,omitempty"`           // MinRTT is the minimum round trip time observed
+	RTO              float64  `json:"rto,omitempty"`               // RTO is the retransmission timeout
+	MSS              int      `json:"mss,omitempty"`               // MSS is the maximum segment size
+	PMTU             int      `json:"pmtu,omitempty"`              // PMTU is the path MTU
+	Cwnd             int      `json:"cwnd,omitempty"`              // Cwnd is the congestion window in segments
+	SSThresh         int      `json:"ssthresh,omitempty"`          // SSThresh is the slow start threshold in segments
+	Retransmits      int      `json:"retransmits,omitempty"`       // Retransmits are the unrecovered retransmitted segments
+	TotalRetransmits int      `json:"total_retransmits,omitempty"` // TotalRetransmits are all the segments retransmitted by the socket
+	Lost             int      `json:"lost,omitempty"`              // Lost are the segments considered lost
+	Unacked          int      `json:"unacked,omitempty"`           // Unacked are the segments sent but not acknowledged
+	BytesSent        uint64   `json:"bytes_sent,omitempty"`        // BytesSent is the number of bytes sent
+	BytesRetrans     uint64   `json:"bytes_retrans,omitempty"`     // BytesRetrans is the number of bytes retransmitted
+	BytesAcked       uint64   `json:"bytes_acked,omitempty"`       // BytesAcked is the number of bytes acknowledged by the peer
+	BytesReceived    uint64   `json:"bytes_received,omitempty"`    // BytesReceived is the number of bytes received
+	SendRate         string   `json:"send_rate,omitempty"`         // SendRate is the rate the congestion window allows, e.g. 942Mbps
+	DeliveryRate     string   `json:"delivery_rate,omitempty"`     // DeliveryRate is the measured delivery rate
+}
+
+// Socket is a parsed ss socket.
+type Socket struct {
+	Protocol  string          `json:"protocol"`            // Protocol is tcp or udp
+	State     string          `json:"state"`               // State is the socket state as printed by ss, e.g. ESTAB, LISTEN or UNCONN
+	RecvQ     uint64          `json:"recv_q"`              // RecvQ is the receive queue, or the accept queue of listening sockets
+	SendQ     uint64          `json:"send_q"`              // SendQ is the send queue, or the accept backlog of listening sockets
+	Local     SocketEndpoint  `json:"local"`               // Local is the local address
+	Peer      SocketEndpoint  `json:"peer"`                // Peer is the peer address
+	Processes []SocketProcess `json:"processes,omitempty"` // Processes are the processes using the socket
+	Timer     string          `json:"timer,omitempty"`     // Timer is the active timer, e.g. keepalive,29sec,0
+	UID       *int            `json:"uid,omitempty"`       // UID is the owner of the socket
+	Inode     uint64          `json:"inode,omitempty"`     // Inode is the inode of the socket
+	TCPInfo   *SocketTCPInfo  `json:"tcp_info,omitempty"`  // TCPInfo is the TCP information of TCP sockets
+}
+
+// SocketsResult represents the output of the get-sockets tool.
+type SocketsResult struct {
+	Sockets []Socket `json:"sockets"`         // Sockets are the sockets that matched the filters
+	Netns   string   `json:"netns,omitempty"` // Netns describes the pod network namespace the sockets were listed in, when a pod is given
+}
+
+// NodeNetworkSnapshotParams contains parameters for collecting the read-only network state of a node
+// in a single debug pod session.
+type NodeNetworkSnapshotParams struct {
+	CommonParams
+	Sections string `json:"sections,omitempty"` // Sections are the comma-separated sections to collect. Default: all
+}
+
+// ConntrackUsage is the number of connection tracking entries of a node and its limit.
+type ConntrackUsage struct {
+	Count int `json:"count"` // Count is the number of entries (net.netfilter.nf_conntrack_count)
+	Max   int `json:"max"`   // Max is the maximum number of entries (net.netfilter.nf_conntrack_max)
+}
+
+// OVSInterface is an interface of an OVS port printed by ovs-vsctl show.
+type OVSInterface struct {
+	Name    string            `json:"name"`              // Name is the name of the interface
+	Type    string            `json:"type,omitempty"`    // Type is the interface type, e.g. internal, geneve or patch; empty for system interfaces
+	Options map[string]string `json:"options,omitempty"` // Options are the interface options, e.g. remote_ip of tunnels or peer of patch ports
+	Error   string            `json:"error,omitempty"`   // Error is the error OVS reports for the interface
+}
+
+// OVSPort is a port of an OVS bridge printed by ovs-vsctl show.
+type OVSPort struct {
+	Name       string         `json:"name"`          // Name is the name of the port
+	Tag        int            `json:"tag,omitempty"` // Tag is the VLAN tag of access ports
+	Interfaces []OVSInterface `json:"interfaces"`    // Interfaces are the interfaces of the port
+}
+
+// OVSBridge is an OVS bridge printed by ovs-vsctl show.
+type OVSBridge struct {
+	Name         string    `json:"name"`                    // Name is the name of the bridge
+	FailMode     string    `json:"fail_mode,omitempty"`     // FailMode is the fail mode of the bridge, e.g. secure
+	DatapathType string    `json:"datapath_type,omitempty"` // DatapathType is the datapath type, e.g. system or netdev
+	Ports        []OVSPort `json:"ports"`                   // Ports are the ports of the bridge
+}
+
+// OVSSnapshot is the OVS configuration of a node.
+type OVSSnapshot struct {
+	Version        string            `json:"version,omitempty"`         // Version is the OVS version
+	Bridges        []OVSBridge       `json:"bridges,omitempty"`         // Bridges are the bridges printed by ovs-vsctl show
+	BridgeMappings map[string]string `json:"bridge_mappings,omitempty"` // BridgeMappings maps each physical network to its bridge (external_ids:ovn-bridge-mappings)
+}
+
+// NodeNetworkSnapshotResult represents the output of the node-network-snapshot tool. Sections that
+// were not requested or could not be read are omitted, and the errors of the latter are returned in Errors.
+type NodeNetworkSnapshotResult struct {
+	Node      string            `json:"node"`                // Node is the node the snapshot was collected on
+	IP        *IPResult         `json:"ip,omitempty"`        // IP holds the addresses, links, routes and rules of both families, and the neighbours
+	NFT       *NFTResult        `json:"nft,omitempty"`       // NFT is the nftables ruleset
+	IPTables  *IPTablesResult   `json:"iptables,omitempty"`  // IPTables holds the iptables-save and ip6tables-save output
+	Conntrack *ConntrackUsage   `json:"conntrack,omitempty"` // Conntrack is the number of connection tracking entries
+	Sysctl    *SysctlResult     `json:"sysctl,omitempty"`    // Sysctl holds the checks of the sysctls OVN-Kubernetes depends on
+	OVS       *OVSSnapshot      `json:"ovs,omitempty"`       // OVS holds the OVS bridges and the bridge mappings
+	Errors    map[string]string `json:"errors,omitempty"`    // Errors holds the error of each command that failed, by command section
 }
 
 // Result represents the output returned from executing a kernel command.
@@ -0,0 +1,350 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// DebugPodPoolLabel marks the debug pods kept alive by a DebugPodPool. Pods carrying it are
+	// deleted when the pool is closed, and at startup if the pool that created them is gone.
+	DebugPodPoolLabel = "ovn-kubernetes-mcp/debug-pod-pool"
+	// DebugPodPoolInstanceLabel holds the random ID of the pool that created a pooled debug pod.
+	DebugPodPoolInstanceLabel = "ovn-kubernetes-mcp/debug-pod-pool-instance"
+	// DebugPodPoolHeartbeatAnnotation holds the last time, in RFC 3339 format, the pool that created
+	// a pooled debug pod reported the pod as in use.
+	DebugPodPoolHeartbeatAnnotation = "ovn-kubernetes-mcp/debug-pod-pool-heartbeat"
+
+	// debugPodHeartbeatInterval is how often a pool refreshes the heartbeat of its pods.
+	debugPodHeartbeatInterval = time.Minute
+	// debugPodOrphanTimeout is how long the heartbeat of a pod is not refreshed before the pod is
+	// considered left behind by a pool that is gone, if the idle TTL is shorter.
+	debugPodOrphanTimeout = 3 * debugPodHeartbeatInterval
+)
+
+// debugPodKey identifies the pooled debug pods that can run a command: pods of the same node and
+// image, in the same namespace and with the same host mount.
+type debugPodKey struct {
+	namespace string
+	node      string
+	image     string
+	hostPath  string
+	mountPath string
+}
+
+// pooledDebugPod is a debug pod of the pool. ready is closed once the pod is running, or once its
+// creation failed with err.
+type pooledDebugPod struct {
+	key      debugPodKey
+	name     string
+	ready    chan struct{}
+	err      error
+	users    int
+	lastUsed time.Time
+	// retired pods are no longer handed out and are deleted once their last user is done.
+	retired bool
+}
+
+// DebugPodPool runs commands on nodes in long-lived debug pods. One pod is kept per node and image,
+// reused by the commands run on that node, and deleted after it has been idle for the idle TTL.
+type DebugPodPool struct {
+	client  *OVNKMCPServerClientSet
+	idleTTL time.Duration
+	now     func() time.Time
+	// instance is the random ID labelling the pods of the pool, telling them from the pods of the
+	// pools of other servers.
+	instance string
+
+	mu     sync.Mutex
+	pods   map[debugPodKey]*pooledDebugPod
+	closed bool
+	done   chan struct{}
+}
+
+// NewDebugPodPool creates a pool of debug pods deleted after being idle for idleTTL, and starts
+// reaping the idle pods in the background until the pool is closed.
+func NewDebugPodPool(client *OVNKMCPServerClientSet, idleTTL time.Duration) (*DebugPodPool, error) {
+	if idleTTL <= 0 {
+		return nil, fmt.Errorf("debug pod idle TTL must be positive, got %v", idleTTL)
+	}
+	instance := make([]byte, 8)
+	if _, err := rand.Read(instance); err != nil {
+		return nil, fmt.Errorf("failed to generate debug pod pool instance ID: %w", err)
+	}
+	p := &DebugPodPool{
+		client:   client,
+		idleTTL:  idleTTL,
+		now:      time.Now,
+		instance: hex.EncodeToString(instance),
+		pods:     map[debugPodKey]*pooledDebugPod{},
+		done:     make(chan struct{}),
+	}
+	go p.reapIdlePods(max(idleTTL/2, time.Second))
+	return p, nil
+}
+
+// DebugNode runs a command in the pooled debug pod of the node and image, creating the pod if
+// there is none. It accepts the same parameters as OVNKMCPServerClientSet.DebugNode.
+func (p *DebugPodPool) DebugNode(ctx context.Context, namespace, name, image string, command []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+	namespace, err := validateDebugNodeParams(namespace, name, hostPath, mountPath)
+	if err != nil {
+		return "", "", err
+	}
+
+	// If timeout is specified, create a new context with timeout
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	key := debugPodKey{namespace: namespace, node: name, image: image, hostPath: hostPath, mountPath: mountPath}
+	for attempt := 0; ; attempt++ {
+		pod, err := p.acquire(ctx, key)
+		if err != nil {
+			return "", "", err
+		}
+		stdout, stderr, err := p.client.ExecPod(ctx, pod.name, namespace, debugContainerName, command)
+		if err == nil {
+			p.release(pod, false)
+			return stdout, stderr, nil
+		}
+
+		// A command that failed in a running pod is a command failure and the pod is kept. A pod that
+		// is no longer running, e.g. deleted outside the pool, is replaced once. A cancelled command
+		// may still be running in the pod, so the pod is retired to stop it.
+		running := ctx.Err() == nil && p.client.podRunning(ctx, namespace, pod.name)
+		p.release(pod, !running)
+		if running || ctx.Err() != nil || attempt > 0 {
+			return "", "", fmt.Errorf("failed to execute command in debug pod: %w", err)
+		}
+		log.Printf("debug pod %s/%s is not running, replacing it", namespace, pod.name)
+	}
+}
+
+// acquire returns the running pod of the key, creating it if needed, and counts the caller as one
+// of its users until release is called.
+func (p *DebugPodPool) acquire(ctx context.Context, key debugPodKey) (*pooledDebugPod, error) {
+	for {
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, fmt.Errorf("debug pod pool is closed")
+		}
+		pod, found := p.pods[key]
+		if !found {
+			pod = &pooledDebugPod{key: key, ready: make(chan struct{})}
+			p.pods[key] = pod
+		}
+		pod.users++
+		p.mu.Unlock()
+
+		if !found {
+			if err := p.create(ctx, pod); err != nil {
+				return nil, err
+			}
+			return pod, nil
+		}
+
+		select {
+		case <-pod.ready:
+		case <-ctx.Done():
+			p.release(pod, false)
+			return nil, fmt.Errorf("failed waiting for debug pod: %w", ctx.Err())
+		}
+		if pod.err == nil {
+			return pod, nil
+		}
+		// The creator already removed the pod from the pool. The creation is retried if it only
+		// failed because the call of the creator was cancelled.
+		p.mu.Lock()
+		pod.users--
+		p.mu.Unlock()
+		if !errors.Is(pod.err, context.Canceled) && !errors.Is(pod.err, context.DeadlineExceeded) || ctx.Err() != nil {
+			return nil, pod.err
+		}
+	}
+}
+
+// create creates the pod and wakes up the callers waiting for it. On failure the pod is removed
+// from the pool.
+func (p *DebugPodPool) create(ctx context.Context, pod *pooledDebugPod) error {
+	labels := map[string]string{DebugPodPoolLabel: "true", DebugPodPoolInstanceLabel: p.instance}
+	annotations := map[string]string{DebugPodPoolHeartbeatAnnotation: p.now().UTC().Format(time.RFC3339)}
+	name, _, err := p.client.createPod(ctx, pod.key.node, pod.key.namespace, pod.key.image, pod.key.hostPath, pod.key.mountPath, labels, annotations)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pod.name, pod.err = name, err
+	if err != nil {
+		pod.users--
+		if p.pods[pod.key] == pod {
+			delete(p.pods, pod.key)
+		}
+	}
+	close(pod.ready)
+	return err
+}
+
+// release ends a use of the pod. If retire is set the pod is no longer handed out. A retired pod is
+// deleted once it has no users.
+func (p *DebugPodPool) release(pod *pooledDebugPod, retire bool) {
+	p.mu.Lock()
+	pod.users--
+	pod.lastUsed = p.now()
+	if retire {
+		p.retire(pod)
+	}
+	deletePod := pod.retired && pod.users == 0 && pod.name != ""
+	p.mu.Unlock()
+
+	if deletePod {
+		p.client.deletePod(pod.key.namespace, pod.name)
+	}
+}
+
+// retire removes the pod from the pool. It must be called with the lock held.
+func (p *DebugPodPool) retire(pod *pooledDebugPod) {
+	pod.retired = true
+	if p.pods[pod.key] == pod {
+		delete(p.pods, pod.key)
+	}
+}
+
+// reapIdlePods deletes the idle pods every interval, and refreshes the heartbeat of the other pods,
+// until the pool is closed.
+func (p *DebugPodPool) reapIdlePods(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	heartbeat := time.NewTicker(debugPodHeartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.deleteIdlePods()
+		case <-heartbeat.C:
+			p.refreshHeartbeats()
+		}
+	}
+}
+
+// refreshHeartbeats sets the heartbeat of the pods of the pool to now, so that other servers do
+// not delete them as left behind.
+func (p *DebugPodPool) refreshHeartbeats() {
+	p.mu.Lock()
+	var pods []*pooledDebugPod
+	for _, pod := range p.pods {
+		if pod.name != "" && !pod.retired {
+			pods = append(pods, pod)
+		}
+	}
+	now := p.now()
+	p.mu.Unlock()
+
+	patch := fmt.Appendf(nil, `{"metadata":{"annotations":{%q:%q}}}`, DebugPodPoolHeartbeatAnnotation, now.UTC().Format(time.RFC3339))
+	for _, pod := range pods {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		_, err := p.client.clientSet.CoreV1().Pods(pod.key.namespace).Patch(ctx, pod.name, types.MergePatchType, patch, metav1.PatchOptions{})
+		cancel()
+		if err != nil {
+			log.Printf("Failed to refresh the heartbeat of debug pod %s/%s: %v", pod.key.namespace, pod.name, err)
+		}
+	}
+}
+
+// deleteIdlePods deletes the running pods that have had no user for the idle TTL.
+func (p *DebugPodPool) deleteIdlePods() {
+	p.mu.Lock()
+	var idle []*pooledDebugPod
+	for _, pod := range p.pods {
+		if pod.users == 0 && pod.name != "" && p.now().Sub(pod.lastUsed) >= p.idleTTL {
+			p.retire(pod)
+			idle = append(idle, pod)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, pod := range idle {
+		p.client.deletePod(pod.key.namespace, pod.name)
+	}
+}
+
+// Close stops the pool and deletes its pods. Pods running a command are deleted once the command
+// is done, and no command can be run afterwards.
+func (p *DebugPodPool) Close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	p.closed = true
+	close(p.done)
+	var unused []*pooledDebugPod
+	for _, pod := range p.pods {
+		p.retire(pod)
+		if pod.users == 0 && pod.name != "" {
+			unused = append(unused, pod)
+		}
+	}
+	p.mu.Unlock()
+
+	for _, pod := range unused {
+		p.client.deletePod(pod.key.namespace, pod.name)
+	}
+}
+
+// DeleteOrphanedPods deletes the pooled debug pods of the namespaces left behind by a server that
+// did not shut down cleanly: pods of other pools whose heartbeat has not been refreshed for the
+// idle TTL, and for at least debugPodOrphanTimeout. The pods of the pools of live servers are kept,
+// as these refresh the heartbeat of their pods. It returns the number of pods deleted.
+func (p *DebugPodPool) DeleteOrphanedPods(ctx context.Context, namespaces ...string) (int, error) {
+	var pods []corev1.Pod
+	for _, namespace := range namespaces {
+		namespacePods, err := p.client.ListPods(ctx, namespace, DebugPodPoolLabel)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list pooled debug pods in namespace %s: %w", namespace, err)
+		}
+		pods = append(pods, namespacePods...)
+	}
+	timeout := max(p.idleTTL, debugPodOrphanTimeout)
+	var errs []error
+	deleted := 0
+	for _, pod := range pods {
+		if pod.Labels[DebugPodPoolInstanceLabel] == p.instance || p.now().Sub(debugPodHeartbeat(pod)) < timeout {
+			continue
+		}
+		if err := p.client.clientSet.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete pooled debug pod %s/%s: %w", pod.Namespace, pod.Name, err))
+			continue
+		}
+		deleted++
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// debugPodHeartbeat returns the last heartbeat of a pooled debug pod, or its creation time if it has
+// no valid heartbeat.
+func debugPodHeartbeat(pod corev1.Pod) time.Time {
+	heartbeat, err := time.Parse(time.RFC3339, pod.Annotations[DebugPodPoolHeartbeatAnnotation])
+	if err != nil || heartbeat.Before(pod.CreationTimestamp.Time) {
+		return pod.CreationTimestamp.Time
+	}
+	return heartbeat
+}
+
+// podRunning tells whether the pod exists and is running.
+func (c *OVNKMCPServerClientSet) podRunning(ctx context.Context, namespace, name string) bool {
+	pod, err := c.clientSet.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	return err == nil && pod.Status.Phase == corev1.PodRunning
+}
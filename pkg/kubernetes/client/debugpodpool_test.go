@@ -0,0 +1,256 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakeclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFakeDebugPodClient returns a fake client whose created pods are named after their generated
+// name and are running, and which executes the fake commands.
+func newFakeDebugPodClient(objects ...runtime.Object) *OVNKMCPServerClientSet {
+	c := NewFakeClient(objects...)
+	created := 0
+	c.clientSet.(*fakeclient.Clientset).PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+		created++
+		pod.Name = fmt.Sprintf("%s%d", pod.GenerateName, created)
+		pod.Status.Phase = corev1.PodRunning
+		return false, nil, nil
+	})
+	c.corev1RestClient = &fake.RESTClient{
+		VersionedAPIPath: "/api/v1",
+		GroupVersion:     schema.GroupVersion{Group: "", Version: "v1"},
+	}
+	c.podExecutor = &fakeExecutor{}
+	return c
+}
+
+// debugPodNames returns the names of the pods of the namespace.
+func debugPodNames(t *testing.T, c *OVNKMCPServerClientSet, namespace string) []string {
+	t.Helper()
+	pods, err := c.ListPods(context.Background(), namespace, "")
+	if err != nil {
+		t.Fatalf("ListPods() error = %v", err)
+	}
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func TestDebugPodPoolReusesPods(t *testing.T) {
+	c := newFakeDebugPodClient()
+	pool, err := NewDebugPodPool(c, time.Hour)
+	if err != nil {
+		t.Fatalf("NewDebugPodPool() error = %v", err)
+	}
+	defer pool.Close()
+
+	run := func(node, image, hostPath string) {
+		t.Helper()
+		stdout, _, err := pool.DebugNode(context.Background(), "", node, image, []string{string(successExecCommand)}, hostPath, hostPath, 0)
+		if err != nil {
+			t.Fatalf("DebugNode() error = %v", err)
+		}
+		if stdout != "Successfully executed command" {
+			t.Fatalf("DebugNode() stdout = %q", stdout)
+		}
+	}
+	run("worker1", "netshoot", "")
+	run("worker1", "netshoot", "")
+	if got := debugPodNames(t, c, "default"); len(got) != 1 {
+		t.Fatalf("pods = %v, want a single pod reused by both commands", got)
+	}
+
+	run("worker2", "netshoot", "")
+	run("worker1", "pwru", "")
+	run("worker1", "pwru", "/sys/kernel/debug")
+	pods, err := c.ListPods(context.Background(), "default", DebugPodPoolLabel)
+	if err != nil {
+		t.Fatalf("ListPods() error = %v", err)
+	}
+	if len(pods) != 4 {
+		t.Fatalf("got %d labelled pods, want one per node, image and host mount", len(pods))
+	}
+
+	pool.Close()
+	if got := debugPodNames(t, c, "default"); len(got) != 0 {
+		t.Errorf("pods = %v after Close(), want none", got)
+	}
+	if _, _, err := pool.DebugNode(context.Background(), "", "worker1", "netshoot", []string{string(successExecCommand)}, "", "", 0); err == nil {
+		t.Errorf("DebugNode() error = nil after Close(), want error")
+	}
+}
+
+func TestDebugPodPoolDeletesIdlePods(t *testing.T) {
+	c := newFakeDebugPodClient()
+	pool, err := NewDebugPodPool(c, time.Minute)
+	if err != nil {
+		t.Fatalf("NewDebugPodPool() error = %v", err)
+	}
+	defer pool.Close()
+	now := time.Now()
+	pool.now = func() time.Time { return now }
+
+	for _, node := range []string{"worker1", "worker2"} {
+		if _, _, err := pool.DebugNode(context.Background(), "", node, "netshoot", []string{string(successExecCommand)}, "", "", 0); err != nil {
+			t.Fatalf("DebugNode() error = %v", err)
+		}
+		now = now.Add(40 * time.Second)
+	}
+
+	// The pod of worker1 was last used 80 seconds ago and the pod of worker2 40 seconds ago.
+	pool.deleteIdlePods()
+	got := debugPodNames(t, c, "default")
+	if len(got) != 1 || !strings.HasPrefix(got[0], "debug-node-worker2-") {
+		t.Fatalf("pods = %v, want only the pod of worker2", got)
+	}
+
+	if _, _, err := pool.DebugNode(context.Background(), "", "worker1", "netshoot", []string{string(successExecCommand)}, "", "", 0); err != nil {
+		t.Fatalf("DebugNode() error = %v", err)
+	}
+	if got := debugPodNames(t, c, "default"); len(got) != 2 {
+		t.Errorf("pods = %v, want a new pod for worker1", got)
+	}
+}
+
+func TestDebugPodPoolReplacesDeletedPods(t *testing.T) {
+	c := newFakeDebugPodClient()
+	pool, err := NewDebugPodPool(c, time.Hour)
+	if err != nil {
+		t.Fatalf("NewDebugPodPool() error = %v", err)
+	}
+	defer pool.Close()
+
+	command := []string{string(successExecCommand)}
+	if _, _, err := pool.DebugNode(context.Background(), "", "worker1", "netshoot", command, "", "", 0); err != nil {
+		t.Fatalf("DebugNode() error = %v", err)
+	}
+	first := debugPodNames(t, c, "default")
+	if err := c.clientSet.CoreV1().Pods("default").Delete(context.Background(), first[0], metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	if _, _, err := pool.DebugNode(context.Background(), "", "worker1", "netshoot", command, "", "", 0); err != nil {
+		t.Fatalf("DebugNode() error = %v after the pod was deleted", err)
+	}
+	second := debugPodNames(t, c, "default")
+	if len(second) != 1 || second[0] == first[0] {
+		t.Errorf("pods = %v, want a new pod replacing %s", second, first[0])
+	}
+
+	// A failing command does not replace a running pod.
+	if _, _, err := pool.DebugNode(context.Background(), "", "worker1", "netshoot", []string{string(errorExecCommand)}, "", "", 0); err == nil {
+		t.Fatalf("DebugNode() error = nil, want the command error")
+	}
+	if got := debugPodNames(t, c, "default"); len(got) != 1 || got[0] != second[0] {
+		t.Errorf("pods = %v, want %s kept", got, second[0])
+	}
+}
+
+func TestDebugPodPoolDeleteOrphanedPods(t *testing.T) {
+	now := time.Now()
+	pooledPod := func(name, namespace, instance string, created, heartbeat time.Time) runtime.Object {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			CreationTimestamp: metav1.NewTime(created),
+			Labels:            map[string]string{DebugPodPoolLabel: "true", DebugPodPoolInstanceLabel: instance},
+		}}
+		if !heartbeat.IsZero() {
+			pod.Annotations = map[string]string{DebugPodPoolHeartbeatAnnotation: heartbeat.UTC().Format(time.RFC3339)}
+		}
+		return pod
+	}
+	c := newFakeDebugPodClient(
+		// Left behind: no heartbeat for longer than the idle TTL.
+		pooledPod("debug-node-worker1-stale", "default", "dead", now.Add(-time.Hour), now.Add(-20*time.Minute)),
+		pooledPod("debug-node-worker2-old", "default", "", now.Add(-time.Hour), time.Time{}),
+		// Used by a live server: created long ago, but with a recent heartbeat.
+		pooledPod("debug-node-worker1-live", "default", "live", now.Add(-time.Hour), now.Add(-time.Minute)),
+		// Just created by a live server.
+		pooledPod("debug-node-worker3-new", "default", "live", now.Add(-2*time.Minute), time.Time{}),
+		// In a namespace that is not reaped.
+		pooledPod("debug-node-worker1-other", "ovn-kubernetes-mcp", "dead", now.Add(-time.Hour), time.Time{}),
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "client", Namespace: "default", CreationTimestamp: metav1.NewTime(now.Add(-time.Hour))}},
+	)
+	pool, err := NewDebugPodPool(c, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("NewDebugPodPool() error = %v", err)
+	}
+	defer pool.Close()
+
+	// The pods of the pool itself are kept, even with a heartbeat as old as those of orphaned pods.
+	pool.now = func() time.Time { return now.Add(-time.Hour) }
+	if _, _, err := pool.DebugNode(context.Background(), "", "worker4", "netshoot", []string{string(successExecCommand)}, "", "", 0); err != nil {
+		t.Fatalf("DebugNode() error = %v", err)
+	}
+	own := debugPodNames(t, c, "default")
+	pool.now = func() time.Time { return now }
+
+	deleted, err := pool.DeleteOrphanedPods(context.Background(), "default")
+	if err != nil {
+		t.Fatalf("DeleteOrphanedPods() error = %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("DeleteOrphanedPods() = %d, want 2", deleted)
+	}
+	got := debugPodNames(t, c, "")
+	want := []string{"client", "debug-node-worker1-live", "debug-node-worker1-other", "debug-node-worker3-new"}
+	for _, name := range own {
+		if strings.HasPrefix(name, "debug-node-worker4-") {
+			want = append(want, name)
+		}
+	}
+	slices.Sort(got)
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("pods = %v, want %v", got, want)
+	}
+}
+
+func TestDebugPodPoolRefreshesHeartbeats(t *testing.T) {
+	c := newFakeDebugPodClient()
+	pool, err := NewDebugPodPool(c, time.Hour)
+	if err != nil {
+		t.Fatalf("NewDebugPodPool() error = %v", err)
+	}
+	defer pool.Close()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	pool.now = func() time.Time { return now }
+	if _, _, err := pool.DebugNode(context.Background(), "", "worker1", "netshoot", []string{string(successExecCommand)}, "", "", 0); err != nil {
+		t.Fatalf("DebugNode() error = %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	pool.refreshHeartbeats()
+	pods, err := c.ListPods(context.Background(), "default", DebugPodPoolInstanceLabel+"="+pool.instance)
+	if err != nil {
+		t.Fatalf("ListPods() error = %v", err)
+	}
+	if len(pods) != 1 {
+		t.Fatalf("got %d pods of the pool, want 1", len(pods))
+	}
+	if got := pods[0].Annotations[DebugPodPoolHeartbeatAnnotation]; got != "2026-01-02T03:05:05Z" {
+		t.Errorf("heartbeat = %q, want the refreshed time", got)
+	}
+}
+
+func TestNewDebugPodPoolInvalidTTL(t *testing.T) {
+	if _, err := NewDebugPodPool(NewFakeClient(), 0); err == nil {
+		t.Errorf("NewDebugPodPool() error = nil, want error for a zero idle TTL")
+	}
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// ephemeralContainerPrefix starts the name of the ephemeral debug containers added to pods.
+	ephemeralContainerPrefix = "mcp-debug-"
+	// ephemeralContainerLifetime is how long an ephemeral debug container runs. Ephemeral
+	// containers cannot be removed from a pod, so they exit on their own, and later commands reuse
+	// them while they run.
+	ephemeralContainerLifetime = time.Hour
+)
+
+// DebugPod runs a command in an ephemeral debug container of a pod with the image, for commands
+// the containers of the pod lack. The debug container shares the network namespace of the pod. A
+// running debug container of the image is reused, otherwise one is added to the pod.
+func (c *OVNKMCPServerClientSet) DebugPod(ctx context.Context, namespace, name, image string, command []string) (string, string, error) {
+	pod, err := c.clientSet.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch pod: %w", err)
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return "", "", fmt.Errorf("cannot debug a pod that is not running; current phase is %s", pod.Status.Phase)
+	}
+
+	container := runningEphemeralContainer(pod, image)
+	if container == "" {
+		container, err = c.addEphemeralContainer(ctx, pod, image)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	stdout, stderr, err := c.ExecPod(ctx, name, namespace, container, command)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to execute command in ephemeral container: %w", err)
+	}
+	return stdout, stderr, nil
+}
+
+// runningEphemeralContainer returns the name of a running ephemeral debug container of the pod
+// with the image, or an empty string.
+func runningEphemeralContainer(pod *corev1.Pod, image string) string {
+	images := map[string]string{}
+	for _, container := range pod.Spec.EphemeralContainers {
+		images[container.Name] = container.Image
+	}
+	for _, status := range pod.Status.EphemeralContainerStatuses {
+		if strings.HasPrefix(status.Name, ephemeralContainerPrefix) && images[status.Name] == image && status.State.Running != nil {
+			return status.Name
+		}
+	}
+	return ""
+}
+
+// addEphemeralContainer adds an ephemeral debug container with the image to the pod and waits
+// for it to be running. It returns the name of the container.
+func (c *OVNKMCPServerClientSet) addEphemeralContainer(ctx context.Context, pod *corev1.Pod, image string) (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate ephemeral container name: %w", err)
+	}
+	name := ephemeralContainerPrefix + hex.EncodeToString(suffix)
+	pod = pod.DeepCopy()
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:    name,
+			Image:   image,
+			Command: []string{"sleep", strconv.Itoa(int(ephemeralContainerLifetime.Seconds()))},
+			SecurityContext: &corev1.SecurityContext{
+				Capabilities: &corev1.Capabilities{
+					Add: []corev1.Capability{"NET_RAW"},
+				},
+			},
+		},
+	})
+	if _, err := c.clientSet.CoreV1().Pods(pod.Namespace).UpdateEphemeralContainers(ctx, pod.Name, pod, metav1.UpdateOptions{}); err != nil {
+		return "", fmt.Errorf("failed to add ephemeral container: %w", err)
+	}
+
+	err := wait.PollUntilContextTimeout(ctx, time.Millisecond*500, time.Minute*1, true, func(ctx context.Context) (bool, error) {
+		pod, err := c.clientSet.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, status := range pod.Status.EphemeralContainerStatuses {
+			if status.Name != name {
+				continue
+			}
+			if status.State.Terminated != nil {
+				return false, fmt.Errorf("ephemeral container %s terminated: %s", name, status.State.Terminated.Reason)
+			}
+			return status.State.Running != nil, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("ephemeral container did not reach running state within timeout of 1 minute: %w", err)
+	}
+	return name, nil
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakeclient "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newFakeEphemeralClient returns a fake client whose added ephemeral containers are running, and
+// which executes the fake commands. It counts the added containers.
+func newFakeEphemeralClient(pod *corev1.Pod) (*OVNKMCPServerClientSet, *fakeExecutor, *int) {
+	c := NewFakeClient(pod)
+	added := 0
+	c.clientSet.(*fakeclient.Clientset).PrependReactor("update", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "ephemeralcontainers" {
+			return false, nil, nil
+		}
+		pod := action.(k8stesting.UpdateAction).GetObject().(*corev1.Pod)
+		added++
+		last := pod.Spec.EphemeralContainers[len(pod.Spec.EphemeralContainers)-1]
+		pod.Status.EphemeralContainerStatuses = append(pod.Status.EphemeralContainerStatuses, corev1.ContainerStatus{
+			Name:  last.Name,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		})
+		return false, nil, nil
+	})
+	c.corev1RestClient = &fake.RESTClient{
+		VersionedAPIPath: "/api/v1",
+		GroupVersion:     schema.GroupVersion{Group: "", Version: "v1"},
+	}
+	ex := &fakeExecutor{}
+	c.podExecutor = ex
+	return c, ex, &added
+}
+
+func TestDebugPod(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "client", Namespace: "default"},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	c, ex, added := newFakeEphemeralClient(pod)
+
+	for _, image := range []string{"netshoot", "netshoot", "busybox"} {
+		stdout, _, err := c.DebugPod(context.Background(), "default", "client", image, []string{string(successExecCommand)})
+		if err != nil {
+			t.Fatalf("DebugPod() error = %v", err)
+		}
+		if stdout != "Successfully executed command" {
+			t.Fatalf("DebugPod() stdout = %q", stdout)
+		}
+		if container := ex.url.Query().Get("container"); !strings.HasPrefix(container, ephemeralContainerPrefix) {
+			t.Errorf("container = %q, want an ephemeral debug container", container)
+		}
+	}
+	if *added != 2 {
+		t.Errorf("added %d ephemeral containers, want one per image", *added)
+	}
+
+	got, err := c.GetPod(context.Background(), "default", "client")
+	if err != nil {
+		t.Fatalf("GetPod() error = %v", err)
+	}
+	if len(got.Spec.EphemeralContainers) != 2 || got.Spec.EphemeralContainers[0].SecurityContext.Capabilities.Add[0] != "NET_RAW" {
+		t.Errorf("ephemeral containers = %+v, want two with NET_RAW", got.Spec.EphemeralContainers)
+	}
+}
+
+func TestDebugPodNotRunning(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "client", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	c, _, added := newFakeEphemeralClient(pod)
+	if _, _, err := c.DebugPod(context.Background(), "default", "client", "netshoot", []string{"true"}); err == nil || !strings.Contains(err.Error(), "not running") {
+		t.Errorf("DebugPod() error = %v, want pod not running", err)
+	}
+	if *added != 0 {
+		t.Errorf("added %d ephemeral containers to a pod that is not running", *added)
+	}
+}
@@ -8,31 +8,21 @@ import (
 
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/utils/ptr"
 )
 
-func (c *OVNKMCPServerClientSet) DebugNode(ctx context.Context, namespace, name, image string, command []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
-	// Validate name
-	if name == "" {
-		return "", "", fmt.Errorf("node name is required")
-	}
-
-	// Validate paths before creating the pod
-	if err := utils.ValidatePath(hostPath, "hostPath", true); err != nil {
-		return "", "", err
-	}
+// debugContainerName is the name of the container of debug pods.
+const debugContainerName = "debug-container"
 
-	if err := utils.ValidatePath(mountPath, "mountPath", true); err != nil {
+func (c *OVNKMCPServerClientSet) DebugNode(ctx context.Context, namespace, name, image string, command []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+	namespace, err := validateDebugNodeParams(namespace, name, hostPath, mountPath)
+	if err != nil {
 		return "", "", err
 	}
 
-	// If namespace is not provided, use the default namespace
-	if namespace == "" {
-		namespace = metav1.NamespaceDefault
-	}
-
 	// If timeout is specified, create a new context with timeout
 	if timeout != 0 {
 		var cancel context.CancelFunc
@@ -40,7 +30,7 @@ func (c *OVNKMCPServerClientSet) DebugNode(ctx context.Context, namespace, name,
 		defer cancel()
 	}
 
-	debugPodName, cleanupPod, err := c.createPod(ctx, name, namespace, image, hostPath, mountPath)
+	debugPodName, cleanupPod, err := c.createPod(ctx, name, namespace, image, hostPath, mountPath, nil, nil)
 	if err != nil {
 		return "", "", err
 	}
@@ -50,7 +40,7 @@ func (c *OVNKMCPServerClientSet) DebugNode(ctx context.Context, namespace, name,
 	}
 
 	// Execute the command in the pod.
-	stdout, stderr, err := c.ExecPod(ctx, debugPodName, namespace, "debug-container", command)
+	stdout, stderr, err := c.ExecPod(ctx, debugPodName, namespace, debugContainerName, command)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to execute command in debug pod: %w", err)
 	}
@@ -58,7 +48,33 @@ func (c *OVNKMCPServerClientSet) DebugNode(ctx context.Context, namespace, name,
 	return stdout, stderr, nil
 }
 
-func (c *OVNKMCPServerClientSet) createPod(ctx context.Context, node, namespace, image, hostPath, mountPath string) (string, func(), error) {
+// validateDebugNodeParams validates the node and paths of a debug pod and returns its namespace,
+// the default namespace if none is given.
+func validateDebugNodeParams(namespace, node, hostPath, mountPath string) (string, error) {
+	// Validate name
+	if node == "" {
+		return "", fmt.Errorf("node name is required")
+	}
+
+	// Validate paths before creating the pod
+	if err := utils.ValidatePath(hostPath, "hostPath", true); err != nil {
+		return "", err
+	}
+
+	if err := utils.ValidatePath(mountPath, "mountPath", true); err != nil {
+		return "", err
+	}
+
+	// If namespace is not provided, use the default namespace
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	return namespace, nil
+}
+
+// createPod creates a debug pod with the given labels on the node and waits for it to be running.
+// It returns the name of the pod and a function deleting it.
+func (c *OVNKMCPServerClientSet) createPod(ctx context.Context, node, namespace, image, hostPath, mountPath string, labels, annotations map[string]string) (string, func(), error) {
 	hostPathType := corev1.HostPathDirectory
 	sleepCommand := []string{"sleep", "infinity"}
 
@@ -86,6 +102,8 @@ func (c *OVNKMCPServerClientSet) createPod(ctx context.Context, node, namespace,
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "debug-node-" + node + "-",
 			Namespace:    namespace,
+			Labels:       labels,
+			Annotations:  annotations,
 		},
 		Spec: corev1.PodSpec{
 			NodeName:      node,
@@ -111,7 +129,7 @@ func (c *OVNKMCPServerClientSet) createPod(ctx context.Context, node, namespace,
 			},
 			Containers: []corev1.Container{
 				{
-					Name:    "debug-container",
+					Name:    debugContainerName,
 					Image:   image,
 					Command: sleepCommand,
 					SecurityContext: &corev1.SecurityContext{
@@ -136,15 +154,7 @@ func (c *OVNKMCPServerClientSet) createPod(ctx context.Context, node, namespace,
 	}
 
 	cleanupPod := func() {
-		// Create a new context with a timeout of 10 seconds to delete the pod.
-		cleanupCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		// Delete the pod.
-		err := c.clientSet.CoreV1().Pods(namespace).Delete(cleanupCtx, createdDebugPod.Name, metav1.DeleteOptions{})
-		if err != nil {
-			log.Printf("failed to cleanup debug pod: %v", err)
-		}
+		c.deletePod(namespace, createdDebugPod.Name)
 	}
 
 	// Wait for the pod to be running.
@@ -163,3 +173,17 @@ func (c *OVNKMCPServerClientSet) createPod(ctx context.Context, node, namespace,
 
 	return createdDebugPod.Name, cleanupPod, nil
 }
+
+// deletePod deletes a debug pod. Failures are logged since the pod is deleted after its command ran,
+// and a pod that no longer exists is not a failure.
+func (c *OVNKMCPServerClientSet) deletePod(namespace, name string) {
+	// Create a new context with a timeout of 10 seconds to delete the pod.
+	cleanupCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Delete the pod.
+	err := c.clientSet.CoreV1().Pods(namespace).Delete(cleanupCtx, name, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		log.Printf("failed to cleanup debug pod: %v", err)
+	}
+}
@@ -34,6 +34,27 @@ func (c *OVNKMCPServerClientSet) GetPodLogs(ctx context.Context, namespace strin
 	return strings.Split(string(logs), "\n"), nil
 }
 
+// ListPods lists the pods in a namespace that match the label selector. An empty
+// namespace lists pods across all namespaces.
+func (c *OVNKMCPServerClientSet) ListPods(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error) {
+	pods, err := c.clientSet.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return pods.Items, nil
+}
+
+// GetPod gets a pod by name and namespace.
+func (c *OVNKMCPServerClientSet) GetPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	pod, err := c.clientSet.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch pod: %w", err)
+	}
+	return pod, nil
+}
+
 // ExecPod executes a command in a pod by name and namespace.
 func (c *OVNKMCPServerClientSet) ExecPod(ctx context.Context, name, namespace, container string, command []string) (string, string, error) {
 	pod, err := c.clientSet.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"io"
 	"net/url"
+	"sort"
 	"strings"
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/rest/fake"
@@ -227,3 +229,69 @@ func TestExecPod(t *testing.T) {
 
 	}
 }
+
+func TestListPods(t *testing.T) {
+	pods := []runtime.Object{
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "ovnkube-node-a", Namespace: "ovn-kubernetes", Labels: map[string]string{"app": "ovnkube-node"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "ovnkube-node-b", Namespace: "ovn-kubernetes", Labels: map[string]string{"app": "ovnkube-node"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "ovnkube-control-plane", Namespace: "ovn-kubernetes", Labels: map[string]string{"app": "ovnkube-control-plane"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", Labels: map[string]string{"app": "ovnkube-node"}}},
+	}
+	tests := []struct {
+		name          string
+		namespace     string
+		labelSelector string
+		expected      []string
+	}{
+		{
+			name:          "label selector within namespace",
+			namespace:     "ovn-kubernetes",
+			labelSelector: "app=ovnkube-node",
+			expected:      []string{"ovnkube-node-a", "ovnkube-node-b"},
+		},
+		{
+			name:      "all pods in namespace",
+			namespace: "ovn-kubernetes",
+			expected:  []string{"ovnkube-control-plane", "ovnkube-node-a", "ovnkube-node-b"},
+		},
+		{
+			name:          "label selector across namespaces",
+			labelSelector: "app=ovnkube-node",
+			expected:      []string{"other", "ovnkube-node-a", "ovnkube-node-b"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fakeclient := NewFakeClient(pods...)
+			result, err := fakeclient.ListPods(context.Background(), test.namespace, test.labelSelector)
+			if err != nil {
+				t.Fatalf("Failed to list pods: %v", err)
+			}
+			names := make([]string, 0, len(result))
+			for _, pod := range result {
+				names = append(names, pod.Name)
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != strings.Join(test.expected, ",") {
+				t.Fatalf("Unexpected pods: got %v, expected %v", names, test.expected)
+			}
+		})
+	}
+}
+
+func TestGetPod(t *testing.T) {
+	fakeclient := NewFakeClient(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "client", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "ovn-worker"},
+	})
+	pod, err := fakeclient.GetPod(context.Background(), "default", "client")
+	if err != nil {
+		t.Fatalf("Failed to get pod: %v", err)
+	}
+	if pod.Spec.NodeName != "ovn-worker" {
+		t.Fatalf("Unexpected pod node: got %s, expected ovn-worker", pod.Spec.NodeName)
+	}
+	if _, err := fakeclient.GetPod(context.Background(), "default", "missing"); err == nil {
+		t.Fatalf("Expected an error for a missing pod")
+	}
+}
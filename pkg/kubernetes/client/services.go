@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetService gets a service by name and namespace.
+func (c *OVNKMCPServerClientSet) GetService(ctx context.Context, namespace, name string) (*corev1.Service, error) {
+	service, err := c.clientSet.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch service: %w", err)
+	}
+	return service, nil
+}
+
+// GetNode gets a node by name.
+func (c *OVNKMCPServerClientSet) GetNode(ctx context.Context, name string) (*corev1.Node, error) {
+	node, err := c.clientSet.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch node: %w", err)
+	}
+	return node, nil
+}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetService(t *testing.T) {
+	fakeclient := NewFakeClient(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       corev1.ServiceSpec{ClusterIP: "10.96.0.10"},
+	})
+	service, err := fakeclient.GetService(context.Background(), "default", "web")
+	if err != nil {
+		t.Fatalf("Failed to get service: %v", err)
+	}
+	if service.Spec.ClusterIP != "10.96.0.10" {
+		t.Fatalf("Unexpected service cluster IP: got %s, expected 10.96.0.10", service.Spec.ClusterIP)
+	}
+	if _, err := fakeclient.GetService(context.Background(), "default", "missing"); err == nil {
+		t.Fatalf("Expected an error for a missing service")
+	}
+}
+
+func TestGetNode(t *testing.T) {
+	fakeclient := NewFakeClient(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "ovn-worker"},
+		Status:     corev1.NodeStatus{Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "172.18.0.3"}}},
+	})
+	node, err := fakeclient.GetNode(context.Background(), "ovn-worker")
+	if err != nil {
+		t.Fatalf("Failed to get node: %v", err)
+	}
+	if len(node.Status.Addresses) != 1 || node.Status.Addresses[0].Address != "172.18.0.3" {
+		t.Fatalf("Unexpected node addresses: %v", node.Status.Addresses)
+	}
+	if _, err := fakeclient.GetNode(context.Background(), "missing"); err == nil {
+		t.Fatalf("Expected an error for a missing node")
+	}
+}
@@ -1,8 +1,12 @@
 package mcp
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 
@@ -12,10 +16,13 @@ import (
 
 type Config struct {
 	Kubeconfig string
+	// DebugPodIdleTTL is how long an unused pooled debug pod is kept. Debug pods are not pooled if it is 0.
+	DebugPodIdleTTL time.Duration
 }
 
 type MCPServer struct {
 	clientSet *client.OVNKMCPServerClientSet
+	debugPods *client.DebugPodPool
 }
 
 func NewMCPServer(cfg Config) (*MCPServer, error) {
@@ -38,11 +45,39 @@ func NewMCPServer(cfg Config) (*MCPServer, error) {
 		return nil, err
 	}
 
+	var debugPods *client.DebugPodPool
+	if cfg.DebugPodIdleTTL > 0 {
+		debugPods, err = client.NewDebugPodPool(clientSet, cfg.DebugPodIdleTTL)
+		if err != nil {
+			return nil, err
+		}
+
+		// Pooled debug pods left behind by a server that did not shut down cleanly are deleted from
+		// the default debug pod namespace, the one the debug pod RBAC grants.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		deleted, err := debugPods.DeleteOrphanedPods(ctx, metav1.NamespaceDefault)
+		if err != nil {
+			log.Printf("Failed to delete orphaned debug pods: %v", err)
+		}
+		if deleted > 0 {
+			log.Printf("Deleted %d orphaned debug pod(s)", deleted)
+		}
+	}
+
 	return &MCPServer{
 		clientSet: clientSet,
+		debugPods: debugPods,
 	}, nil
 }
 
+// Close deletes the pooled debug pods.
+func (s *MCPServer) Close() {
+	if s.debugPods != nil {
+		s.debugPods.Close()
+	}
+}
+
 func (s *MCPServer) AddTools(server *mcp.Server) {
 	mcp.AddTool(server,
 		&mcp.Tool{
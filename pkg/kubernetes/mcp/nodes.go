@@ -6,6 +6,7 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kubernetes/types"
+	corev1 "k8s.io/api/core/v1"
 )
 
 // DebugNode debugs a node by name, by using a debug pod in a namespace, with an image and command.
@@ -22,6 +23,15 @@ func (s *MCPServer) DebugNode(ctx context.Context, req *mcp.CallToolRequest, in
 }
 
 // RunDebugNode runs a debug node pod on the given node by name, namespace, image and command.
+// When debug pods are pooled, the command runs in the pooled pod of the node and image.
 func (s *MCPServer) RunDebugNode(ctx context.Context, namespace string, nodeName string, image string, command []string, hostPath string, mountPath string, timeout time.Duration) (string, string, error) {
+	if s.debugPods != nil {
+		return s.debugPods.DebugNode(ctx, namespace, nodeName, image, command, hostPath, mountPath, timeout)
+	}
 	return s.clientSet.DebugNode(ctx, namespace, nodeName, image, command, hostPath, mountPath, timeout)
 }
+
+// GetNode gets a node by name.
+func (s *MCPServer) GetNode(ctx context.Context, name string) (*corev1.Node, error) {
+	return s.clientSet.GetNode(ctx, name)
+}
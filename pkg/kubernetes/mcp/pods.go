@@ -3,6 +3,8 @@ package mcp
 import (
 	"context"
 
+	corev1 "k8s.io/api/core/v1"
+
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/kubernetes/types"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
@@ -56,3 +58,23 @@ func (s *MCPServer) RunPodExecCommand(ctx context.Context, namespace, name, cont
 	}
 	return result.Stdout, result.Stderr, nil
 }
+
+// ListPods lists the pods in a namespace that match the label selector.
+func (s *MCPServer) ListPods(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error) {
+	return s.clientSet.ListPods(ctx, namespace, labelSelector)
+}
+
+// GetPod gets a pod by name and namespace.
+func (s *MCPServer) GetPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	return s.clientSet.GetPod(ctx, namespace, name)
+}
+
+// RunPodDebugCommand runs a command in an ephemeral debug container of a pod with the image.
+func (s *MCPServer) RunPodDebugCommand(ctx context.Context, namespace, name, image string, command []string) (string, string, error) {
+	return s.clientSet.DebugPod(ctx, namespace, name, image, command)
+}
+
+// GetService gets a service by name and namespace.
+func (s *MCPServer) GetService(ctx context.Context, namespace, name string) (*corev1.Service, error) {
+	return s.clientSet.GetService(ctx, namespace, name)
+}
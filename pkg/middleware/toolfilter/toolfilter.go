@@ -19,9 +19,9 @@ import (
 var toolsByCategory = map[string][]string{
 	"kubernetes":    {"pod-logs", "resource-get", "resource-list"},
 	"ovn":           {"ovn-show", "ovn-get", "ovn-lflow-list", "ovn-trace"},
-	"ovs":           {"ovs-vsctl", "ovs-ofctl", "ovs-appctl"},
-	"kernel":        {"get-conntrack", "get-iptables", "get-nft", "get-ip"},
-	"network-tools": {"tcpdump", "pwru"},
+	"ovs":           {"ovs-vsctl", "ovs-ofctl", "ovs-appctl", "ovs-health-sweep"},
+	"kernel":        {"get-conntrack", "get-conntrack-events", "get-conntrack-entry", "get-iptables", "trace-iptables", "get-nft", "search-nft", "get-ip", "get-route-decision", "get-sysctl", "get-network-counters", "get-ethtool", "get-sockets", "node-network-snapshot"},
+	"network-tools": {"tcpdump", "capture-pod-path", "ovs-mirror-capture", "pwru", "retis", "connectivity-probe", "connectivity-matrix", "dns-check", "path-mtu"},
 	"sosreport":     {"sos-list-plugins", "sos-list-commands", "sos-search-commands", "sos-get-command", "sos-get-pod-logs"},
 	"must-gather":   {"must-gather-get-resource", "must-gather-list-resources", "must-gather-pod-logs", "must-gather-ovnk-info", "must-gather-list-northbound-databases", "must-gather-list-southbound-databases", "must-gather-query-database"},
 }
@@ -218,7 +218,7 @@ func TestResolveDisabled(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
-		want := map[string]bool{"tcpdump": true, "pwru": true, "ovn-show": true}
+		want := map[string]bool{"tcpdump": true, "capture-pod-path": true, "ovs-mirror-capture": true, "pwru": true, "retis": true, "connectivity-probe": true, "connectivity-matrix": true, "dns-check": true, "path-mtu": true, "ovn-show": true}
 		if len(disabled) != len(want) {
 			t.Fatalf("expected %d entries, got %d (%v)", len(want), len(disabled), disabled)
 		}
@@ -0,0 +1,506 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultMatrixConcurrency is the number of probes of a connectivity matrix run at the same time
+	// when no concurrency is given.
+	DefaultMatrixConcurrency = 10
+	// MaxMatrixConcurrency is the maximum number of probes of a connectivity matrix run at the same
+	// time.
+	MaxMatrixConcurrency = 50
+	// MaxMatrixPods is the maximum number of pods, and so of nodes, of a connectivity matrix.
+	MaxMatrixPods = 50
+	// MaxMatrixTargets is the maximum number of services and external endpoints of a connectivity
+	// matrix.
+	MaxMatrixTargets = 20
+	// DefaultMatrixProbeCount is the number of ICMP echo requests of the probes of a connectivity
+	// matrix when no count is given.
+	DefaultMatrixProbeCount = 1
+	// DefaultMatrixProbeTimeout is the number of seconds the probes of a connectivity matrix wait
+	// for a reply when no timeout is given.
+	DefaultMatrixProbeTimeout = 2
+
+	// matrixMaxErrors is the number of distinct error messages kept for a failure group.
+	matrixMaxErrors = 3
+)
+
+// matrixPod is a pod of a connectivity matrix, probing from and probed on its node.
+type matrixPod struct {
+	source types.ProbeSource
+	node   types.MatrixNode
+}
+
+// matrixProbe is a probe from the pod of a node to the pod of another node, a service or an
+// external endpoint.
+type matrixProbe struct {
+	source int
+	target probeTarget
+	// key is the node of the target pod, or the service or external endpoint.
+	key string
+	// targetNode is the node of the target pod, empty for services and external endpoints.
+	targetNode string
+	protocol   string
+	result     types.ProbeResult
+}
+
+// ConnectivityMatrix probes the connectivity between one pod per node, and from these pods to
+// services and external endpoints, running a bounded number of probes at the same time. The
+// failures are grouped by node pair and direction, and by service or external endpoint, and the
+// nodes whose probes all fail while the other nodes succeed are reported as suspects.
+func (s *MCPServer) ConnectivityMatrix(ctx context.Context, req *mcp.CallToolRequest, in types.ConnectivityMatrixParams) (*mcp.CallToolResult, types.ConnectivityMatrixResult, error) {
+	protocol := cmp.Or(in.Protocol, "icmp")
+	if err := validateProbeProtocol(protocol, ""); err != nil {
+		return nil, types.ConnectivityMatrixResult{}, err
+	}
+	if in.PodPort < 0 || in.PodPort > 65535 {
+		return nil, types.ConnectivityMatrixResult{}, fmt.Errorf("invalid pod_port: %d", in.PodPort)
+	}
+	if protocol != "icmp" && in.PodPort == 0 {
+		return nil, types.ConnectivityMatrixResult{}, fmt.Errorf("pod_port is required with protocol '%s'", protocol)
+	}
+	count := cmp.Or(in.Count, DefaultMatrixProbeCount)
+	if err := validateIntMax(count, MaxProbeCount, "count", ""); err != nil {
+		return nil, types.ConnectivityMatrixResult{}, err
+	}
+	probeTimeout := cmp.Or(in.ProbeTimeoutSeconds, DefaultMatrixProbeTimeout)
+	if err := validateIntMax(probeTimeout, MaxProbeTimeout, "probe_timeout_seconds", "seconds"); err != nil {
+		return nil, types.ConnectivityMatrixResult{}, err
+	}
+	concurrency := cmp.Or(in.Concurrency, DefaultMatrixConcurrency)
+	if err := validateIntMax(concurrency, MaxMatrixConcurrency, "concurrency", ""); err != nil {
+		return nil, types.ConnectivityMatrixResult{}, err
+	}
+	if len(in.Services)+len(in.Endpoints) > MaxMatrixTargets {
+		return nil, types.ConnectivityMatrixResult{}, fmt.Errorf("services and external_endpoints cannot exceed %d targets", MaxMatrixTargets)
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	pods, nodesWithoutPods, err := s.matrixPods(ctx, in)
+	if err != nil {
+		return nil, types.ConnectivityMatrixResult{}, err
+	}
+	targets, err := s.matrixTargets(ctx, in.Services, in.Endpoints)
+	if err != nil {
+		return nil, types.ConnectivityMatrixResult{}, err
+	}
+
+	podPort := in.PodPort
+	if protocol == "icmp" {
+		podPort = 0
+	}
+	var probes []*matrixProbe
+	for i := range pods {
+		for j, target := range pods {
+			if i == j {
+				continue
+			}
+			probes = append(probes, &matrixProbe{
+				source:     i,
+				target:     probeTarget{description: "pod " + target.node.Pod, address: target.node.IP, port: podPort},
+				key:        target.node.Node,
+				targetNode: target.node.Node,
+				protocol:   protocol,
+			})
+		}
+		for _, target := range targets {
+			probe := *target
+			probe.source = i
+			probes = append(probes, &probe)
+		}
+	}
+	if in.DebugContainer {
+		// Start the debug containers before the probes, so that the probes from a pod do not add
+		// several debug containers to it at the same time.
+		if err := s.startMatrixDebugContainers(ctx, pods, concurrency); err != nil {
+			return nil, types.ConnectivityMatrixResult{}, err
+		}
+	}
+	s.runMatrixProbes(ctx, pods, probes, count, probeTimeout, concurrency)
+
+	result := summarizeMatrix(pods, probes)
+	result.Protocol = protocol
+	result.NodesWithoutPods = nodesWithoutPods
+	return nil, result, nil
+}
+
+// matrixPods returns the pods of a connectivity matrix, one per node and sorted by node, and the
+// requested nodes without a pod. The pods are given, or picked from a namespace and a label
+// selector.
+func (s *MCPServer) matrixPods(ctx context.Context, in types.ConnectivityMatrixParams) ([]matrixPod, []string, error) {
+	nodes := map[string]bool{}
+	for _, node := range in.Nodes {
+		if !utils.IsKubernetesName(node) {
+			return nil, nil, fmt.Errorf("invalid node: %s", node)
+		}
+		nodes[node] = true
+	}
+
+	var candidates []matrixPod
+	if len(in.Pods) > 0 {
+		if in.Namespace != "" || in.LabelSelector != "" {
+			return nil, nil, fmt.Errorf("pods cannot be combined with namespace or label_selector")
+		}
+		for _, p := range in.Pods {
+			if !utils.IsKubernetesName(p.Namespace) || !utils.IsKubernetesName(p.Name) ||
+				(p.Container != "" && !utils.IsKubernetesName(p.Container)) {
+				return nil, nil, fmt.Errorf("invalid pod: %s/%s", p.Namespace, p.Name)
+			}
+			src := types.ProbeSource{SourceNamespace: p.Namespace, SourcePod: p.Name, SourceContainer: p.Container, DebugContainer: in.DebugContainer}
+			pod, err := s.getPod(ctx, p.Namespace, p.Name)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !matrixPodReady(pod) {
+				return nil, nil, fmt.Errorf("pod %s/%s is not running in the pod network", p.Namespace, p.Name)
+			}
+			candidates = append(candidates, newMatrixPod(pod, src))
+		}
+	} else {
+		if in.Namespace == "" && in.LabelSelector == "" {
+			return nil, nil, fmt.Errorf("pods, namespace or label_selector is required")
+		}
+		if in.Namespace != "" && !utils.IsKubernetesName(in.Namespace) {
+			return nil, nil, fmt.Errorf("invalid namespace: %s", in.Namespace)
+		}
+		list, err := s.listPods(ctx, in.Namespace, in.LabelSelector)
+		if err != nil {
+			return nil, nil, err
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Namespace+"/"+list[i].Name < list[j].Namespace+"/"+list[j].Name
+		})
+		for i := range list {
+			if matrixPodReady(&list[i]) {
+				src := types.ProbeSource{SourceNamespace: list[i].Namespace, SourcePod: list[i].Name, DebugContainer: in.DebugContainer}
+				candidates = append(candidates, newMatrixPod(&list[i], src))
+			}
+		}
+	}
+
+	byNode := map[string]matrixPod{}
+	for _, pod := range candidates {
+		if len(nodes) > 0 && !nodes[pod.node.Node] {
+			continue
+		}
+		if other, ok := byNode[pod.node.Node]; ok {
+			if len(in.Pods) > 0 {
+				return nil, nil, fmt.Errorf("pods %s and %s are both on node %s", other.node.Pod, pod.node.Pod, pod.node.Node)
+			}
+			continue
+		}
+		byNode[pod.node.Node] = pod
+	}
+	if len(byNode) == 0 {
+		return nil, nil, fmt.Errorf("no running pod found to probe from")
+	}
+	if len(byNode) > MaxMatrixPods {
+		return nil, nil, fmt.Errorf("the matrix has %d nodes, more than %d; select fewer nodes", len(byNode), MaxMatrixPods)
+	}
+
+	var pods []matrixPod
+	for _, pod := range byNode {
+		pods = append(pods, pod)
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].node.Node < pods[j].node.Node })
+	var nodesWithoutPods []string
+	for _, node := range in.Nodes {
+		if _, ok := byNode[node]; !ok {
+			nodesWithoutPods = append(nodesWithoutPods, node)
+		}
+	}
+	return pods, nodesWithoutPods, nil
+}
+
+// matrixPodReady reports whether a pod can be probed from and probed: it runs in the pod network
+// and has an IP.
+func matrixPodReady(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" && pod.Spec.NodeName != "" &&
+		!pod.Spec.HostNetwork && pod.DeletionTimestamp == nil
+}
+
+func newMatrixPod(pod *corev1.Pod, src types.ProbeSource) matrixPod {
+	return matrixPod{
+		source: src,
+		node:   types.MatrixNode{Node: pod.Spec.NodeName, Pod: pod.Namespace + "/" + pod.Name, IP: pod.Status.PodIP},
+	}
+}
+
+// matrixTargets resolves the services and external endpoints of a connectivity matrix to probes
+// without a source. Services are probed with a TCP connect, and external endpoints with ICMP, or
+// with a TCP connect when they have a port.
+func (s *MCPServer) matrixTargets(ctx context.Context, services []types.MatrixService, endpoints []types.MatrixEndpoint) ([]*matrixProbe, error) {
+	var targets []*matrixProbe
+	for _, service := range services {
+		target, err := s.resolveProbeTarget(ctx, types.ProbeTarget{
+			TargetType: "service", TargetNamespace: service.Namespace, TargetName: service.Name, Port: service.Port,
+		}, "tcp")
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, &matrixProbe{target: target, key: target.description + " port " + strconv.Itoa(target.port), protocol: "tcp"})
+	}
+	for _, endpoint := range endpoints {
+		protocol := "icmp"
+		if endpoint.Port != 0 {
+			protocol = "tcp"
+		}
+		target, err := s.resolveProbeTarget(ctx, types.ProbeTarget{TargetType: "host", TargetAddress: endpoint.Address, Port: endpoint.Port}, protocol)
+		if err != nil {
+			return nil, err
+		}
+		key := target.description
+		if target.port != 0 {
+			key += " port " + strconv.Itoa(target.port)
+		}
+		targets = append(targets, &matrixProbe{target: target, key: key, protocol: protocol})
+	}
+	return targets, nil
+}
+
+// startMatrixDebugContainers runs a command in the debug container of every pod, which adds the
+// debug containers the pods lack.
+func (s *MCPServer) startMatrixDebugContainers(ctx context.Context, pods []matrixPod, concurrency int) error {
+	errs := make([]error, len(pods))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, pod := range pods {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if _, _, err := s.runPodDebugCommand(ctx, pod.source.SourceNamespace, pod.source.SourcePod, s.cfg.TcpdumpImage, []string{"true"}); err != nil {
+				errs[i] = fmt.Errorf("failed to start the debug container of pod %s: %w", pod.node.Pod, err)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runMatrixProbes runs the probes of a connectivity matrix, at most concurrency at the same time.
+// Probes that cannot run are failed probes of error class 'error'.
+func (s *MCPServer) runMatrixProbes(ctx context.Context, pods []matrixPod, probes []*matrixProbe, count, probeTimeout, concurrency int) {
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			result, err := s.runProbe(ctx, pods[probe.source].source, probe.target, probe.protocol, "", count, probeTimeout)
+			if err != nil {
+				result = types.ProbeResult{Target: probe.target.description, Protocol: probe.protocol, ErrorClass: "error", Error: err.Error()}
+			}
+			probe.result = result
+		}()
+	}
+	wg.Wait()
+}
+
+// matrixFailures collects the error classes and distinct errors of failed probes.
+type matrixFailures struct {
+	classes []string
+	errors  []string
+}
+
+func (f *matrixFailures) add(result types.ProbeResult) {
+	class := cmp.Or(result.ErrorClass, "error")
+	if !slices.Contains(f.classes, class) {
+		f.classes = append(f.classes, class)
+		slices.Sort(f.classes)
+	}
+	if result.Error != "" && len(f.errors) < matrixMaxErrors && !slices.Contains(f.errors, result.Error) {
+		f.errors = append(f.errors, result.Error)
+	}
+}
+
+// summarizeMatrix builds the matrix of the results of the probes, groups the failures and finds
+// the suspect nodes.
+func summarizeMatrix(pods []matrixPod, probes []*matrixProbe) types.ConnectivityMatrixResult {
+	result := types.ConnectivityMatrixResult{Probes: len(probes)}
+	rows := map[string]map[string]string{}
+	for _, pod := range pods {
+		result.Pods = append(result.Pods, pod.node)
+		rows[pod.node.Node] = map[string]string{}
+	}
+
+	type pairFailure struct {
+		matrixFailures
+		forward, reverse bool
+	}
+	pairs := map[[2]string]*pairFailure{}
+	type targetFailure struct {
+		matrixFailures
+		failedFrom []string
+		succeeded  int
+	}
+	targets := map[string]*targetFailure{}
+	var targetKeys []string
+
+	for _, probe := range probes {
+		source := pods[probe.source].node.Node
+		status := "ok"
+		if !probe.result.Success {
+			status = cmp.Or(probe.result.ErrorClass, "error")
+			result.Failed++
+		}
+		rows[source][probe.key] = status
+
+		if probe.targetNode == "" {
+			target, ok := targets[probe.key]
+			if !ok {
+				target = &targetFailure{}
+				targets[probe.key] = target
+				targetKeys = append(targetKeys, probe.key)
+			}
+			if probe.result.Success {
+				target.succeeded++
+			} else {
+				target.failedFrom = append(target.failedFrom, source)
+				target.add(probe.result)
+			}
+			continue
+		}
+		if probe.result.Success {
+			continue
+		}
+		key := [2]string{source, probe.targetNode}
+		forward := source < probe.targetNode
+		if !forward {
+			key = [2]string{probe.targetNode, source}
+		}
+		pair, ok := pairs[key]
+		if !ok {
+			pair = &pairFailure{}
+			pairs[key] = pair
+		}
+		if forward {
+			pair.forward = true
+		} else {
+			pair.reverse = true
+		}
+		pair.add(probe.result)
+	}
+
+	for _, pod := range pods {
+		result.Matrix = append(result.Matrix, types.MatrixRow{Node: pod.node.Node, Results: rows[pod.node.Node]})
+	}
+	var pairKeys [][2]string
+	for key := range pairs {
+		pairKeys = append(pairKeys, key)
+	}
+	slices.SortFunc(pairKeys, func(a, b [2]string) int {
+		return cmp.Or(cmp.Compare(a[0], b[0]), cmp.Compare(a[1], b[1]))
+	})
+	for _, key := range pairKeys {
+		pair := pairs[key]
+		direction := "both"
+		switch {
+		case !pair.reverse:
+			direction = key[0] + " -> " + key[1]
+		case !pair.forward:
+			direction = key[1] + " -> " + key[0]
+		}
+		result.PairFailures = append(result.PairFailures, types.MatrixPairFailure{
+			NodeA: key[0], NodeB: key[1], Direction: direction, ErrorClasses: pair.classes, Errors: pair.errors,
+		})
+	}
+	for _, key := range targetKeys {
+		if target := targets[key]; len(target.failedFrom) > 0 {
+			result.TargetFailures = append(result.TargetFailures, types.MatrixTargetFailure{
+				Target: key, FailedFrom: target.failedFrom, SucceededOn: target.succeeded, ErrorClasses: target.classes, Errors: target.errors,
+			})
+		}
+	}
+	result.Suspects = matrixSuspects(pods, probes)
+	return result
+}
+
+// matrixSuspects returns the nodes whose probes to or from other pods all fail, or whose probes to
+// services and external endpoints all fail, while probes not involving the node succeed. Probes
+// failing for lack of tools do not count.
+func matrixSuspects(pods []matrixPod, probes []*matrixProbe) []types.MatrixSuspect {
+	var suspects []types.MatrixSuspect
+	for _, pod := range pods {
+		node := pod.node.Node
+		var out, outFailed, in, inFailed, targets, targetsFailed int
+		podsOKElsewhere, targetsOKElsewhere := false, false
+		for _, probe := range probes {
+			if probe.result.ErrorClass == "tool_missing" {
+				continue
+			}
+			source := pods[probe.source].node.Node
+			failed := !probe.result.Success
+			switch {
+			case probe.targetNode == "" && source == node:
+				targets++
+				if failed {
+					targetsFailed++
+				}
+			case probe.targetNode == "":
+				targetsOKElsewhere = targetsOKElsewhere || !failed
+			case source == node:
+				out++
+				if failed {
+					outFailed++
+				}
+			case probe.targetNode == node:
+				in++
+				if failed {
+					inFailed++
+				}
+			default:
+				podsOKElsewhere = podsOKElsewhere || !failed
+			}
+		}
+
+		var reason string
+		if podsOKElsewhere {
+			switch outAll, inAll := out > 0 && outFailed == out, in > 0 && inFailed == in; {
+			case outAll && inAll:
+				reason = "all probes from and to the pod of the node failed"
+			case outAll:
+				reason = "all probes from the pod of the node failed"
+			case inAll:
+				reason = "all probes to the pod of the node failed"
+			}
+		}
+		if targetsOKElsewhere && targets > 0 && targetsFailed == targets {
+			if reason != "" {
+				reason += "; "
+			}
+			reason += "all probes to services and external endpoints failed"
+		}
+		if reason != "" {
+			suspects = append(suspects, types.MatrixSuspect{Node: node, Reason: reason})
+		}
+	}
+	return suspects
+}
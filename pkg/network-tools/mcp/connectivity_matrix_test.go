@@ -0,0 +1,234 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func matrixTestPod(name, node, ip string, phase corev1.PodPhase) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "probes"},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status:     corev1.PodStatus{Phase: phase, PodIP: ip},
+	}
+}
+
+// matrixTestPods returns probe pods on the nodes ovn-worker, ovn-worker2 and ovn-worker3, a
+// pending pod and a host network pod.
+func matrixTestPods() []corev1.Pod {
+	hostNetwork := matrixTestPod("a-host", "ovn-worker", "172.18.0.2", corev1.PodRunning)
+	hostNetwork.Spec.HostNetwork = true
+	return []corev1.Pod{
+		matrixTestPod("probe-c", "ovn-worker3", "10.244.3.5", corev1.PodRunning),
+		matrixTestPod("probe-b", "ovn-worker2", "10.244.2.5", corev1.PodRunning),
+		matrixTestPod("probe-z", "ovn-worker", "10.244.1.6", corev1.PodRunning),
+		matrixTestPod("probe-a", "ovn-worker", "10.244.1.5", corev1.PodRunning),
+		matrixTestPod("probe-d", "ovn-worker4", "", corev1.PodPending),
+		hostNetwork,
+	}
+}
+
+// matrixProbeOutput returns the output of the probe script for a probe that failed or not.
+func matrixProbeOutput(cmd []string, failed bool) string {
+	if len(cmd) < 6 || cmd[2] == "true" {
+		return ""
+	}
+	protocol, address := cmd[4], cmd[5]
+	switch {
+	case protocol == "icmp" && failed:
+		return "1 packets transmitted, 0 received, 100% packet loss, time 0ms\n### result 1 1000000 2000000\n"
+	case protocol == "icmp":
+		return "1 packets transmitted, 1 received, 0% packet loss, time 0ms\nrtt min/avg/max/mdev = 0.300/0.300/0.300/0.000 ms\n### result 0 1000000 2000000\n"
+	case failed:
+		return fmt.Sprintf("nc: connect to %s port %s (tcp) failed: Connection timed out\n### result 1 1000000 2000000\n", address, cmd[6])
+	}
+	return "### result 0 1000000 2000000\n"
+}
+
+// getMatrixTestPod returns a pod of the pods.
+func getMatrixTestPod(pods []corev1.Pod, namespace, name string) (*corev1.Pod, error) {
+	for i := range pods {
+		if pods[i].Namespace == namespace && pods[i].Name == name {
+			return &pods[i], nil
+		}
+	}
+	return nil, fmt.Errorf("pod %s/%s not found", namespace, name)
+}
+
+func TestConnectivityMatrixBrokenNode(t *testing.T) {
+	// The pod of ovn-worker3 cannot reach anything, and cannot be reached.
+	pods := matrixTestPods()
+	server := newFakeServer(t, Dependencies{
+		RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+			return matrixProbeOutput(cmd, name == "probe-c" || cmd[5] == "10.244.3.5"), "", nil
+		},
+		ListPods: func(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error) {
+			if namespace != "probes" || labelSelector != "app=probe" {
+				t.Fatalf("listed pods in namespace %q with label selector %q", namespace, labelSelector)
+			}
+			return pods, nil
+		},
+		GetService: func(ctx context.Context, namespace, name string) (*corev1.Service, error) {
+			return &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec:       corev1.ServiceSpec{ClusterIP: "10.96.10.20", Ports: []corev1.ServicePort{{Port: 80}}},
+			}, nil
+		},
+	}, Config{})
+	_, result, err := server.ConnectivityMatrix(context.Background(), nil, types.ConnectivityMatrixParams{
+		Namespace:     "probes",
+		LabelSelector: "app=probe",
+		Services:      []types.MatrixService{{Namespace: "default", Name: "web"}},
+		Endpoints:     []types.MatrixEndpoint{{Address: "8.8.8.8"}},
+	})
+	if err != nil {
+		t.Fatalf("ConnectivityMatrix() error = %v", err)
+	}
+
+	wantPods := []types.MatrixNode{
+		{Node: "ovn-worker", Pod: "probes/probe-a", IP: "10.244.1.5"},
+		{Node: "ovn-worker2", Pod: "probes/probe-b", IP: "10.244.2.5"},
+		{Node: "ovn-worker3", Pod: "probes/probe-c", IP: "10.244.3.5"},
+	}
+	if !reflect.DeepEqual(result.Pods, wantPods) {
+		t.Errorf("pods = %+v, want %+v", result.Pods, wantPods)
+	}
+	if result.Protocol != "icmp" || result.Probes != 12 || result.Failed != 6 {
+		t.Errorf("protocol = %q, probes = %d, failed = %d, want icmp, 12 and 6", result.Protocol, result.Probes, result.Failed)
+	}
+	wantRow := types.MatrixRow{Node: "ovn-worker", Results: map[string]string{
+		"ovn-worker2": "ok", "ovn-worker3": "timeout", "service default/web port 80": "ok", "host 8.8.8.8": "ok",
+	}}
+	if !reflect.DeepEqual(result.Matrix[0], wantRow) {
+		t.Errorf("matrix[0] = %+v, want %+v", result.Matrix[0], wantRow)
+	}
+	wantPairs := []types.MatrixPairFailure{
+		{NodeA: "ovn-worker", NodeB: "ovn-worker3", Direction: "both", ErrorClasses: []string{"timeout"}, Errors: []string{"1 packets transmitted, 0 received, 100% packet loss, time 0ms"}},
+		{NodeA: "ovn-worker2", NodeB: "ovn-worker3", Direction: "both", ErrorClasses: []string{"timeout"}, Errors: []string{"1 packets transmitted, 0 received, 100% packet loss, time 0ms"}},
+	}
+	if !reflect.DeepEqual(result.PairFailures, wantPairs) {
+		t.Errorf("pair failures = %+v, want %+v", result.PairFailures, wantPairs)
+	}
+	wantTargets := []types.MatrixTargetFailure{
+		{Target: "service default/web port 80", FailedFrom: []string{"ovn-worker3"}, SucceededOn: 2, ErrorClasses: []string{"timeout"},
+			Errors: []string{"nc: connect to 10.96.10.20 port 80 (tcp) failed: Connection timed out"}},
+		{Target: "host 8.8.8.8", FailedFrom: []string{"ovn-worker3"}, SucceededOn: 2, ErrorClasses: []string{"timeout"},
+			Errors: []string{"1 packets transmitted, 0 received, 100% packet loss, time 0ms"}},
+	}
+	if !reflect.DeepEqual(result.TargetFailures, wantTargets) {
+		t.Errorf("target failures = %+v, want %+v", result.TargetFailures, wantTargets)
+	}
+	wantSuspects := []types.MatrixSuspect{{
+		Node:   "ovn-worker3",
+		Reason: "all probes from and to the pod of the node failed; all probes to services and external endpoints failed",
+	}}
+	if !reflect.DeepEqual(result.Suspects, wantSuspects) {
+		t.Errorf("suspects = %+v, want %+v", result.Suspects, wantSuspects)
+	}
+}
+
+func TestConnectivityMatrixOneWay(t *testing.T) {
+	// TCP from the pod of ovn-worker2 to the pod of ovn-worker fails, in the debug containers.
+	pods := matrixTestPods()
+	var mu sync.Mutex
+	debugStarts := 0
+	server := newFakeServer(t, Dependencies{
+		RunPodDebugCommand: func(ctx context.Context, namespace, name, image string, cmd []string) (string, string, error) {
+			if len(cmd) == 1 && cmd[0] == "true" {
+				mu.Lock()
+				debugStarts++
+				mu.Unlock()
+				return "", "", nil
+			}
+			return matrixProbeOutput(cmd, name == "probe-b" && cmd[5] == "10.244.1.6"), "", nil
+		},
+		GetPod: func(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+			return getMatrixTestPod(pods, namespace, name)
+		},
+	}, Config{TcpdumpImage: "netshoot"})
+	_, result, err := server.ConnectivityMatrix(context.Background(), nil, types.ConnectivityMatrixParams{
+		Pods: []types.MatrixPod{
+			{Namespace: "probes", Name: "probe-z"},
+			{Namespace: "probes", Name: "probe-b"},
+			{Namespace: "probes", Name: "probe-c"},
+		},
+		Nodes:          []string{"ovn-worker", "ovn-worker2", "ovn-worker4"},
+		DebugContainer: true,
+		Protocol:       "tcp",
+		PodPort:        8080,
+		Concurrency:    1,
+	})
+	if err != nil {
+		t.Fatalf("ConnectivityMatrix() error = %v", err)
+	}
+	if debugStarts != 2 {
+		t.Errorf("started %d debug containers, want one per pod", debugStarts)
+	}
+	if len(result.Pods) != 2 || result.Probes != 2 || result.Failed != 1 {
+		t.Errorf("pods = %+v, probes = %d, failed = %d, want 2 pods, 2 probes and 1 failure", result.Pods, result.Probes, result.Failed)
+	}
+	if !reflect.DeepEqual(result.NodesWithoutPods, []string{"ovn-worker4"}) {
+		t.Errorf("nodes without pods = %q, want ovn-worker4", result.NodesWithoutPods)
+	}
+	if len(result.PairFailures) != 1 || result.PairFailures[0].Direction != "ovn-worker2 -> ovn-worker" {
+		t.Errorf("pair failures = %+v, want ovn-worker2 -> ovn-worker", result.PairFailures)
+	}
+	if len(result.Suspects) != 0 {
+		t.Errorf("suspects = %+v, want none", result.Suspects)
+	}
+}
+
+func TestConnectivityMatrixErrors(t *testing.T) {
+	pods := matrixTestPods()
+	server := newFakeServer(t, Dependencies{
+		GetPod: func(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+			return getMatrixTestPod(pods, namespace, name)
+		},
+		ListPods: func(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error) {
+			return pods, nil
+		},
+	}, Config{})
+	tests := []struct {
+		name    string
+		in      types.ConnectivityMatrixParams
+		wantErr string
+	}{
+		{name: "no pods", in: types.ConnectivityMatrixParams{}, wantErr: "pods, namespace or label_selector is required"},
+		{name: "invalid protocol", in: types.ConnectivityMatrixParams{Protocol: "sctp"}, wantErr: "invalid protocol"},
+		{name: "missing pod port", in: types.ConnectivityMatrixParams{Protocol: "tcp"}, wantErr: "pod_port is required with protocol 'tcp'"},
+		{name: "concurrency too large", in: types.ConnectivityMatrixParams{Concurrency: MaxMatrixConcurrency + 1}, wantErr: "concurrency cannot exceed"},
+		{name: "too many targets", in: types.ConnectivityMatrixParams{Endpoints: make([]types.MatrixEndpoint, MaxMatrixTargets+1)}, wantErr: "cannot exceed 20 targets"},
+		{name: "pods with selector", in: types.ConnectivityMatrixParams{
+			Pods: []types.MatrixPod{{Namespace: "probes", Name: "probe-a"}}, LabelSelector: "app=probe",
+		}, wantErr: "pods cannot be combined"},
+		{name: "pods on the same node", in: types.ConnectivityMatrixParams{
+			Pods: []types.MatrixPod{{Namespace: "probes", Name: "probe-a"}, {Namespace: "probes", Name: "probe-z"}},
+		}, wantErr: "are both on node ovn-worker"},
+		{name: "pod not running", in: types.ConnectivityMatrixParams{
+			Pods: []types.MatrixPod{{Namespace: "probes", Name: "probe-d"}},
+		}, wantErr: "is not running in the pod network"},
+		{name: "invalid pod", in: types.ConnectivityMatrixParams{
+			Pods: []types.MatrixPod{{Namespace: "probes", Name: "probe;reboot"}},
+		}, wantErr: "invalid pod"},
+		{name: "invalid node", in: types.ConnectivityMatrixParams{Namespace: "probes", LabelSelector: "app=probe", Nodes: []string{"-n"}}, wantErr: "invalid node"},
+		{name: "no pod on the nodes", in: types.ConnectivityMatrixParams{Namespace: "probes", LabelSelector: "app=probe", Nodes: []string{"ovn-control-plane"}}, wantErr: "no running pod found"},
+		{name: "invalid endpoint", in: types.ConnectivityMatrixParams{
+			Namespace: "probes", LabelSelector: "app=probe", Endpoints: []types.MatrixEndpoint{{Address: "$(reboot)"}},
+		}, wantErr: "invalid target_address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := server.ConnectivityMatrix(context.Background(), nil, tt.in); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ConnectivityMatrix() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
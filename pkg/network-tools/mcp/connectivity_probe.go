@@ -0,0 +1,421 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultProbeCount is the number of ICMP echo requests sent when no count is given.
+	DefaultProbeCount = 3
+	// MaxProbeCount is the maximum number of ICMP echo requests sent.
+	MaxProbeCount = 10
+	// DefaultProbeTimeout is the number of seconds a probe waits for a reply when no timeout is
+	// given.
+	DefaultProbeTimeout = 5
+	// MaxProbeTimeout is the maximum number of seconds a probe waits for a reply.
+	MaxProbeTimeout = 30
+
+	// probeMarker starts the lines of the probe script holding its results.
+	probeMarker = "### "
+)
+
+var (
+	// hostNamePattern matches DNS host names.
+	hostNamePattern = regexp.MustCompile(`^([a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9]([-a-zA-Z0-9]{0,61}[a-zA-Z0-9])?\.?$`)
+	// httpPathPattern matches the path and query of an HTTP probe.
+	httpPathPattern = regexp.MustCompile(`^/[a-zA-Z0-9._~%/=&?:@+,-]*$`)
+
+	pingStatsPattern   = regexp.MustCompile(`(\d+) packets transmitted, (\d+) (?:packets )?received`)
+	pingRTTPattern     = regexp.MustCompile(`min/avg/max\S* = [\d.]+/([\d.]+)/`)
+	pingAddressPattern = regexp.MustCompile(`(?m)^PING \S+? ?\(([0-9a-fA-F:.]+)\)`)
+	probeResultPattern = regexp.MustCompile(`^result (\d+) (\S*) (\S*)$`)
+	probeHTTPPattern   = regexp.MustCompile(`^http (\d{3}) (\S*) ([\d.]+)$`)
+	probeReplyPattern  = regexp.MustCompile(`^reply (\d+)$`)
+	// probeMissingPattern matches the exec errors of containers without a shell.
+	probeMissingPattern = regexp.MustCompile(`(?i)(executable file not found|no such file or directory)`)
+)
+
+// probeScript runs a probe and prints its exit status and its start and end times in nanoseconds.
+// The arguments are the protocol, the address, the port, the timeout in seconds, the number of
+// ICMP echo requests and the URL of HTTP probes. The script exits successfully so that the output
+// of failed probes is returned.
+const probeScript = `now() { date +%s%N 2>/dev/null; }
+start=$(now)
+case "$1" in
+icmp) ping -c "$5" -W "$4" "$2" 2>&1 ;;
+tcp) nc -z -v -w "$4" "$2" "$3" 2>&1 ;;
+udp)
+	reply=$(printf 'probe\n' | nc -u -w "$4" "$2" "$3")
+	rc=$?
+	echo "### reply $(printf %s "$reply" | wc -c)"
+	(exit $rc)
+	;;
+http) curl -sS -o /dev/null -m "$4" -w '### http %{http_code} %{remote_ip} %{time_total}\n' "$6" 2>&1 ;;
+esac
+echo "### result $? $start $(now)"
+`
+
+// probeTarget is a target resolved to the address and port probed.
+type probeTarget struct {
+	description string
+	address     string
+	port        int
+}
+
+// ConnectivityProbe probes the connectivity from a pod or a node to a pod, a service, a cluster
+// IP, a node port or an external host with ping, a TCP connect, an HTTP GET or a UDP datagram. The
+// probe runs a fixed script whose arguments are validated, and its output is parsed into a result.
+func (s *MCPServer) ConnectivityProbe(ctx context.Context, req *mcp.CallToolRequest, in types.ConnectivityProbeParams) (*mcp.CallToolResult, types.ProbeResult, error) {
+	if err := validateProbeSource(in.ProbeSource); err != nil {
+		return nil, types.ProbeResult{}, err
+	}
+	if err := validateProbeProtocol(in.Protocol, in.HTTPPath); err != nil {
+		return nil, types.ProbeResult{}, err
+	}
+	count := cmp.Or(in.Count, DefaultProbeCount)
+	if err := validateIntMax(count, MaxProbeCount, "count", ""); err != nil {
+		return nil, types.ProbeResult{}, err
+	}
+	probeTimeout := cmp.Or(in.ProbeTimeoutSeconds, DefaultProbeTimeout)
+	if err := validateIntMax(probeTimeout, MaxProbeTimeout, "probe_timeout_seconds", "seconds"); err != nil {
+		return nil, types.ProbeResult{}, err
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	target, err := s.resolveProbeTarget(ctx, in.ProbeTarget, in.Protocol)
+	if err != nil {
+		return nil, types.ProbeResult{}, err
+	}
+	result, err := s.runProbe(ctx, in.ProbeSource, target, in.Protocol, in.HTTPPath, count, probeTimeout)
+	if err != nil {
+		return nil, types.ProbeResult{}, err
+	}
+	return nil, result, nil
+}
+
+// validateProbeSource checks that a probe runs from either a pod or a node.
+func validateProbeSource(src types.ProbeSource) error {
+	if (src.SourcePod == "") == (src.SourceNode == "") {
+		return fmt.Errorf("exactly one of source_pod or source_node is required")
+	}
+	if src.SourcePod != "" {
+		if src.SourceNamespace == "" {
+			return fmt.Errorf("source_namespace is required with source_pod")
+		}
+		for field, name := range map[string]string{"source_namespace": src.SourceNamespace, "source_pod": src.SourcePod, "source_container": src.SourceContainer} {
+			if name != "" && !utils.IsKubernetesName(name) {
+				return fmt.Errorf("invalid %s: %s", field, name)
+			}
+		}
+		return nil
+	}
+	if src.DebugContainer || src.SourceContainer != "" {
+		return fmt.Errorf("source_container and debug_container require source_pod")
+	}
+	if !utils.IsKubernetesName(src.SourceNode) {
+		return fmt.Errorf("invalid source_node: %s", src.SourceNode)
+	}
+	return nil
+}
+
+// validateProbeProtocol checks the protocol of a probe, and the HTTP path of HTTP probes.
+func validateProbeProtocol(protocol, httpPath string) error {
+	switch protocol {
+	case "icmp", "tcp", "udp":
+		if httpPath != "" {
+			return fmt.Errorf("http_path requires protocol 'http'")
+		}
+	case "http":
+		if httpPath != "" && (len(httpPath) > 1024 || !httpPathPattern.MatchString(httpPath)) {
+			return fmt.Errorf("invalid http_path: %s", httpPath)
+		}
+	default:
+		return fmt.Errorf("invalid protocol: %q (must be 'icmp', 'tcp', 'http' or 'udp')", protocol)
+	}
+	return nil
+}
+
+// resolveProbeTarget validates a target and resolves it to the address and port probed. Host
+// names are resolved by the source. The port is not used by ICMP probes.
+func (s *MCPServer) resolveProbeTarget(ctx context.Context, in types.ProbeTarget, protocol string) (probeTarget, error) {
+	if in.Port < 0 || in.Port > 65535 {
+		return probeTarget{}, fmt.Errorf("invalid port: %d", in.Port)
+	}
+	var target probeTarget
+	switch in.TargetType {
+	case "pod":
+		if err := validateProbeTargetName(in); err != nil {
+			return probeTarget{}, err
+		}
+		pod, err := s.getPod(ctx, in.TargetNamespace, in.TargetName)
+		if err != nil {
+			return probeTarget{}, err
+		}
+		if pod.Status.PodIP == "" {
+			return probeTarget{}, fmt.Errorf("pod %s/%s has no IP", in.TargetNamespace, in.TargetName)
+		}
+		target = probeTarget{description: "pod " + in.TargetNamespace + "/" + in.TargetName, address: pod.Status.PodIP, port: in.Port}
+	case "service", "nodeport":
+		if err := validateProbeTargetName(in); err != nil {
+			return probeTarget{}, err
+		}
+		service, err := s.getService(ctx, in.TargetNamespace, in.TargetName)
+		if err != nil {
+			return probeTarget{}, err
+		}
+		port, err := serviceProbePort(service, in.Port)
+		if err != nil {
+			return probeTarget{}, err
+		}
+		if in.TargetType == "service" {
+			if service.Spec.ClusterIP == "" || service.Spec.ClusterIP == corev1.ClusterIPNone {
+				return probeTarget{}, fmt.Errorf("service %s/%s has no cluster IP; probe its pods instead", in.TargetNamespace, in.TargetName)
+			}
+			target = probeTarget{description: "service " + in.TargetNamespace + "/" + in.TargetName, address: service.Spec.ClusterIP, port: int(port.Port)}
+			break
+		}
+		if port.NodePort == 0 {
+			return probeTarget{}, fmt.Errorf("service %s/%s has no node port for port %d", in.TargetNamespace, in.TargetName, port.Port)
+		}
+		if !utils.IsKubernetesName(in.TargetNode) {
+			return probeTarget{}, fmt.Errorf("target_node is required with target_type 'nodeport'")
+		}
+		address, err := s.nodeInternalIP(ctx, in.TargetNode)
+		if err != nil {
+			return probeTarget{}, err
+		}
+		target = probeTarget{description: "nodeport " + in.TargetNamespace + "/" + in.TargetName + " on node " + in.TargetNode, address: address, port: int(port.NodePort)}
+	case "cluster_ip":
+		if net.ParseIP(in.TargetAddress) == nil {
+			return probeTarget{}, fmt.Errorf("invalid target_address: %q (must be an IP address)", in.TargetAddress)
+		}
+		target = probeTarget{description: "cluster IP " + in.TargetAddress, address: in.TargetAddress, port: in.Port}
+	case "host":
+		if net.ParseIP(in.TargetAddress) == nil && (len(in.TargetAddress) > 253 || !hostNamePattern.MatchString(in.TargetAddress)) {
+			return probeTarget{}, fmt.Errorf("invalid target_address: %q (must be an IP address or a host name)", in.TargetAddress)
+		}
+		target = probeTarget{description: "host " + in.TargetAddress, address: in.TargetAddress, port: in.Port}
+	default:
+		return probeTarget{}, fmt.Errorf("invalid target_type: %q (must be 'pod', 'service', 'cluster_ip', 'nodeport' or 'host')", in.TargetType)
+	}
+	if protocol == "icmp" {
+		target.port = 0
+	} else if target.port == 0 {
+		return probeTarget{}, fmt.Errorf("port is required with protocol '%s'", protocol)
+	}
+	return target, nil
+}
+
+// validateProbeTargetName checks the namespace and name of a pod or service target.
+func validateProbeTargetName(in types.ProbeTarget) error {
+	if in.TargetNamespace == "" || in.TargetName == "" {
+		return fmt.Errorf("target_namespace and target_name are required with target_type '%s'", in.TargetType)
+	}
+	if !utils.IsKubernetesName(in.TargetNamespace) || !utils.IsKubernetesName(in.TargetName) {
+		return fmt.Errorf("invalid target: %s/%s", in.TargetNamespace, in.TargetName)
+	}
+	return nil
+}
+
+// serviceProbePort returns the port of a service, or its first port when port is 0.
+func serviceProbePort(service *corev1.Service, port int) (corev1.ServicePort, error) {
+	for _, p := range service.Spec.Ports {
+		if port == 0 || int(p.Port) == port {
+			return p, nil
+		}
+	}
+	if port == 0 {
+		return corev1.ServicePort{}, fmt.Errorf("service %s/%s has no ports", service.Namespace, service.Name)
+	}
+	return corev1.ServicePort{}, fmt.Errorf("service %s/%s has no port %d", service.Namespace, service.Name, port)
+}
+
+// nodeInternalIP returns the first internal IP of a node.
+func (s *MCPServer) nodeInternalIP(ctx context.Context, name string) (string, error) {
+	node, err := s.getNode(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP {
+			return address.Address, nil
+		}
+	}
+	return "", fmt.Errorf("node %s has no internal IP", name)
+}
+
+// runProbe runs a probe from its source to a resolved target and parses its output. A source
+// lacking the shell is reported as a failed probe.
+func (s *MCPServer) runProbe(ctx context.Context, src types.ProbeSource, target probeTarget, protocol, httpPath string, count, probeTimeout int) (types.ProbeResult, error) {
+	url := ""
+	if protocol == "http" {
+		url = "http://" + net.JoinHostPort(target.address, strconv.Itoa(target.port)) + cmp.Or(httpPath, "/")
+	}
+	port := ""
+	if target.port != 0 {
+		port = strconv.Itoa(target.port)
+	}
+	cmd := []string{"sh", "-c", probeScript, "sh", protocol, target.address, port, strconv.Itoa(probeTimeout), strconv.Itoa(count), url}
+
+	result := types.ProbeResult{Target: target.description, Protocol: protocol, Port: target.port}
+	var stdout, stderr string
+	var err error
+	switch {
+	case src.SourceNode != "":
+		result.Source, result.Via = "node "+src.SourceNode, "debug_pod"
+		stdout, stderr, err = s.runDebugNodeCommand(ctx, src.NodePodNamespace, src.SourceNode, s.cfg.TcpdumpImage, cmd, "", "", 0)
+	case src.DebugContainer:
+		result.Source, result.Via = "pod "+src.SourceNamespace+"/"+src.SourcePod, "debug_container"
+		stdout, stderr, err = s.runPodDebugCommand(ctx, src.SourceNamespace, src.SourcePod, s.cfg.TcpdumpImage, cmd)
+	default:
+		result.Source, result.Via = "pod "+src.SourceNamespace+"/"+src.SourcePod, "exec"
+		stdout, stderr, err = s.runPodExecCommand(ctx, src.SourceNamespace, src.SourcePod, src.SourceContainer, cmd)
+	}
+	if err != nil {
+		if result.Via == "exec" && probeMissingPattern.MatchString(err.Error()) {
+			result.ErrorClass = "tool_missing"
+			result.Error = err.Error() + "; retry with debug_container"
+			return result, nil
+		}
+		return types.ProbeResult{}, err
+	}
+	parseProbeOutput(&result, target.address, stdout, stderr)
+	return result, nil
+}
+
+// parseProbeOutput fills a probe result from the output of the probe script.
+func parseProbeOutput(result *types.ProbeResult, address, stdout, stderr string) {
+	exitCode, latency, replyBytes, httpStatus, httpLatency := -1, 0.0, -1, 0, 0.0
+	var lines []string
+	for line := range strings.Lines(stdout) {
+		line = strings.TrimRight(line, "\n")
+		marker, ok := strings.CutPrefix(line, probeMarker)
+		if !ok {
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+			continue
+		}
+		if m := probeResultPattern.FindStringSubmatch(marker); m != nil {
+			exitCode, _ = strconv.Atoi(m[1])
+			// Times are missing when date does not support nanoseconds.
+			start, startErr := strconv.ParseInt(m[2], 10, 64)
+			end, endErr := strconv.ParseInt(m[3], 10, 64)
+			if startErr == nil && endErr == nil && end > start {
+				latency = float64(end-start) / 1e6
+			}
+		} else if m := probeHTTPPattern.FindStringSubmatch(marker); m != nil {
+			httpStatus, _ = strconv.Atoi(m[1])
+			if m[2] != "" {
+				address = m[2]
+			}
+			if seconds, err := strconv.ParseFloat(m[3], 64); err == nil && httpStatus != 0 {
+				httpLatency = seconds * 1000
+			}
+		} else if m := probeReplyPattern.FindStringSubmatch(marker); m != nil {
+			replyBytes, _ = strconv.Atoi(m[1])
+		}
+	}
+	output := strings.Join(lines, "\n")
+	result.Output = strings.TrimSpace(strings.Join([]string{output, strings.TrimSpace(stderr)}, "\n"))
+
+	switch result.Protocol {
+	case "icmp":
+		latency = 0
+		if m := pingStatsPattern.FindStringSubmatch(output); m != nil {
+			result.Sent, _ = strconv.Atoi(m[1])
+			result.Received, _ = strconv.Atoi(m[2])
+		}
+		if m := pingRTTPattern.FindStringSubmatch(output); m != nil {
+			latency, _ = strconv.ParseFloat(m[1], 64)
+		}
+		if m := pingAddressPattern.FindStringSubmatch(output); m != nil {
+			address = m[1]
+		}
+		result.Success = result.Received > 0
+	case "http":
+		result.HTTPStatus = httpStatus
+		if httpLatency > 0 {
+			latency = httpLatency
+		}
+		result.Success = exitCode == 0 && httpStatus > 0 && httpStatus < 400
+	case "udp":
+		result.Success = exitCode == 0 && replyBytes > 0
+	default:
+		result.Success = exitCode == 0
+	}
+	if net.ParseIP(address) == nil {
+		address = probeOutputAddress(output)
+	}
+	result.Address = address
+
+	if result.Success {
+		result.LatencyMs = latency
+		return
+	}
+	result.ErrorClass = classifyProbeError(result, exitCode, replyBytes)
+	if lines := strings.Split(result.Output, "\n"); result.Output != "" {
+		result.Error = lines[len(lines)-1]
+	}
+}
+
+// probeOutputAddress returns the first IP address of the output of a probe, such as the address
+// a host name resolved to, or an empty string.
+func probeOutputAddress(output string) string {
+	for _, field := range strings.Fields(output) {
+		field = strings.Trim(field, "()[],:")
+		if host, _, err := net.SplitHostPort(field); err == nil {
+			field = host
+		}
+		if net.ParseIP(field) != nil {
+			return field
+		}
+	}
+	return ""
+}
+
+// classifyProbeError returns the class of the error of a failed probe from its output and exit
+// status.
+func classifyProbeError(result *types.ProbeResult, exitCode, replyBytes int) string {
+	output := strings.ToLower(result.Output)
+	switch {
+	case exitCode == 127:
+		return "tool_missing"
+	case strings.Contains(output, "bad address"), strings.Contains(output, "name or service not known"),
+		strings.Contains(output, "could not resolve"), strings.Contains(output, "unknown host"),
+		strings.Contains(output, "name resolution"), strings.Contains(output, "nodename nor servname"),
+		result.Protocol == "http" && exitCode == 6:
+		return "dns"
+	case strings.Contains(output, "refused"):
+		return "refused"
+	case strings.Contains(output, "no route to host"), strings.Contains(output, "unreachable"):
+		return "unreachable"
+	case result.HTTPStatus >= 400:
+		return "http_status"
+	case result.Protocol == "udp" && exitCode == 0 && replyBytes == 0:
+		return "no_reply"
+	case strings.Contains(output, "timed out"), strings.Contains(output, "timeout"),
+		result.Protocol == "http" && exitCode == 28,
+		result.Protocol == "icmp" && result.Sent > 0 && result.Received == 0,
+		result.Protocol == "tcp" && exitCode == 1 && output == "":
+		return "timeout"
+	}
+	return "error"
+}
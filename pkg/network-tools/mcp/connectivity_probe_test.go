@@ -0,0 +1,299 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// getProbeTestPod returns the pod "server".
+func getProbeTestPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	if name != "server" {
+		return nil, fmt.Errorf("pod %s/%s not found", namespace, name)
+	}
+	return &corev1.Pod{Status: corev1.PodStatus{PodIP: "10.244.2.5"}}, nil
+}
+
+// getProbeTestService returns the service "web" with a node port, and the headless service
+// "headless".
+func getProbeTestService(ctx context.Context, namespace, name string) (*corev1.Service, error) {
+	switch name {
+	case "web":
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: corev1.ServiceSpec{ClusterIP: "10.96.10.20", Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80, NodePort: 30080},
+				{Name: "metrics", Port: 9090},
+			}},
+		}, nil
+	case "headless":
+		return &corev1.Service{Spec: corev1.ServiceSpec{ClusterIP: corev1.ClusterIPNone, Ports: []corev1.ServicePort{{Port: 80}}}}, nil
+	}
+	return nil, fmt.Errorf("service %s/%s not found", namespace, name)
+}
+
+// getProbeTestNode returns a node with an internal IP.
+func getProbeTestNode(ctx context.Context, name string) (*corev1.Node, error) {
+	return &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+		{Type: corev1.NodeHostName, Address: name},
+		{Type: corev1.NodeInternalIP, Address: "172.18.0.3"},
+	}}}, nil
+}
+
+func TestConnectivityProbeTargets(t *testing.T) {
+	fromPod := types.ProbeSource{SourceNamespace: "default", SourcePod: "client"}
+	tests := []struct {
+		name        string
+		in          types.ConnectivityProbeParams
+		wantCommand string
+		wantTarget  string
+	}{
+		{
+			name:        "pod tcp",
+			in:          types.ConnectivityProbeParams{ProbeSource: fromPod, ProbeTarget: types.ProbeTarget{TargetType: "pod", TargetNamespace: "default", TargetName: "server", Port: 8080}, Protocol: "tcp"},
+			wantCommand: "exec default/client : tcp 10.244.2.5 8080 5 3 ",
+			wantTarget:  "pod default/server",
+		},
+		{
+			name: "service http from debug container",
+			in: types.ConnectivityProbeParams{
+				ProbeSource: types.ProbeSource{SourceNamespace: "default", SourcePod: "client", DebugContainer: true},
+				ProbeTarget: types.ProbeTarget{TargetType: "service", TargetNamespace: "default", TargetName: "web"},
+				Protocol:    "http", HTTPPath: "/healthz?verbose=1", ProbeTimeoutSeconds: 2,
+			},
+			wantCommand: "debug default/client netshoot: http 10.96.10.20 80 2 3 http://10.96.10.20:80/healthz?verbose=1",
+			wantTarget:  "service default/web",
+		},
+		{
+			name:        "nodeport from node",
+			in:          types.ConnectivityProbeParams{ProbeSource: types.ProbeSource{SourceNode: "ovn-worker2"}, ProbeTarget: types.ProbeTarget{TargetType: "nodeport", TargetNamespace: "default", TargetName: "web", TargetNode: "ovn-worker", Port: 80}, Protocol: "tcp"},
+			wantCommand: "node ovn-worker2 netshoot: tcp 172.18.0.3 30080 5 3 ",
+			wantTarget:  "nodeport default/web on node ovn-worker",
+		},
+		{
+			name:        "cluster ip icmp ignores the port",
+			in:          types.ConnectivityProbeParams{ProbeSource: fromPod, ProbeTarget: types.ProbeTarget{TargetType: "cluster_ip", TargetAddress: "fd00:10:96::a", Port: 53}, Protocol: "icmp", Count: 1},
+			wantCommand: "exec default/client : icmp fd00:10:96::a  5 1 ",
+			wantTarget:  "cluster IP fd00:10:96::a",
+		},
+		{
+			name:        "host udp",
+			in:          types.ConnectivityProbeParams{ProbeSource: fromPod, ProbeTarget: types.ProbeTarget{TargetType: "host", TargetAddress: "dns.example.com", Port: 53}, Protocol: "udp"},
+			wantCommand: "exec default/client : udp dns.example.com 53 5 3 ",
+			wantTarget:  "host dns.example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			record := func(runner string, cmd []string) (string, string, error) {
+				if len(cmd) < 4 || cmd[0] != "sh" || cmd[2] != probeScript {
+					t.Fatalf("command = %q, want the probe script", cmd)
+				}
+				commands = append(commands, runner+": "+strings.Join(cmd[4:], " "))
+				return "### result 0 1000000 3000000\n", "", nil
+			}
+			server := newFakeServer(t, Dependencies{
+				RunDebugNodeCommand: func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+					return record("node "+nodeName+" "+image, cmd)
+				},
+				RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+					return record("exec "+namespace+"/"+name+" "+container, cmd)
+				},
+				RunPodDebugCommand: func(ctx context.Context, namespace, name, image string, cmd []string) (string, string, error) {
+					return record("debug "+namespace+"/"+name+" "+image, cmd)
+				},
+				GetPod:     getProbeTestPod,
+				GetService: getProbeTestService,
+				GetNode:    getProbeTestNode,
+			}, Config{TcpdumpImage: "netshoot"})
+			_, result, err := server.ConnectivityProbe(context.Background(), nil, tt.in)
+			if err != nil {
+				t.Fatalf("ConnectivityProbe() error = %v", err)
+			}
+			if len(commands) != 1 || commands[0] != tt.wantCommand {
+				t.Errorf("commands = %q, want %q", commands, tt.wantCommand)
+			}
+			if result.Target != tt.wantTarget {
+				t.Errorf("target = %q, want %q", result.Target, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestParseProbeOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol string
+		address  string
+		stdout   string
+		stderr   string
+		want     types.ProbeResult
+	}{
+		{
+			name:     "icmp",
+			protocol: "icmp",
+			address:  "10.244.2.5",
+			stdout: "PING 10.244.2.5 (10.244.2.5) 56(84) bytes of data.\n64 bytes from 10.244.2.5: icmp_seq=1 ttl=63 time=0.5 ms\n\n" +
+				"--- 10.244.2.5 ping statistics ---\n3 packets transmitted, 3 received, 0% packet loss, time 2003ms\nrtt min/avg/max/mdev = 0.400/0.512/0.700/0.100 ms\n### result 0 1000000 2100000000\n",
+			want: types.ProbeResult{Address: "10.244.2.5", Success: true, LatencyMs: 0.512, Sent: 3, Received: 3},
+		},
+		{
+			name:     "icmp host name with busybox",
+			protocol: "icmp",
+			address:  "example.com",
+			stdout:   "PING example.com (93.184.216.34): 56 data bytes\n\n--- example.com ping statistics ---\n3 packets transmitted, 0 packets received, 100% packet loss\n### result 1 1000000%N 9000000%N\n",
+			want:     types.ProbeResult{Address: "93.184.216.34", Sent: 3, ErrorClass: "timeout", Error: "3 packets transmitted, 0 packets received, 100% packet loss"},
+		},
+		{
+			name:     "tcp",
+			protocol: "tcp",
+			address:  "10.96.10.20",
+			stdout:   "Connection to 10.96.10.20 80 port [tcp/http] succeeded!\n### result 0 1000000 3500000\n",
+			want:     types.ProbeResult{Address: "10.96.10.20", Success: true, LatencyMs: 2.5},
+		},
+		{
+			name:     "tcp refused",
+			protocol: "tcp",
+			address:  "10.96.10.20",
+			stdout:   "nc: connect to 10.96.10.20 port 81 (tcp) failed: Connection refused\n### result 1 1000000 3500000\n",
+			want:     types.ProbeResult{Address: "10.96.10.20", ErrorClass: "refused", Error: "nc: connect to 10.96.10.20 port 81 (tcp) failed: Connection refused"},
+		},
+		{
+			name:     "http",
+			protocol: "http",
+			address:  "web.example.com",
+			stdout:   "### http 200 93.184.216.34 0.012345\n### result 0 1000000 30000000\n",
+			want:     types.ProbeResult{Address: "93.184.216.34", Success: true, LatencyMs: 12.345, HTTPStatus: 200},
+		},
+		{
+			name:     "http status",
+			protocol: "http",
+			address:  "10.96.10.20",
+			stdout:   "### http 503 10.96.10.20 0.002\n### result 0 1000000 30000000\n",
+			want:     types.ProbeResult{Address: "10.96.10.20", HTTPStatus: 503, ErrorClass: "http_status"},
+		},
+		{
+			name:     "http dns",
+			protocol: "http",
+			address:  "missing.example.com",
+			stdout:   "curl: (6) Could not resolve host: missing.example.com\n### http 000  0.001\n### result 6 1000000 30000000\n",
+			want:     types.ProbeResult{ErrorClass: "dns", Error: "curl: (6) Could not resolve host: missing.example.com"},
+		},
+		{
+			name:     "udp without reply",
+			protocol: "udp",
+			address:  "10.96.0.10",
+			stdout:   "### reply 0\n### result 0 1000000 5000000000\n",
+			want:     types.ProbeResult{Address: "10.96.0.10", ErrorClass: "no_reply"},
+		},
+		{
+			name:     "udp reply",
+			protocol: "udp",
+			address:  "10.96.0.10",
+			stdout:   "### reply 12\n### result 0 1000000 5000000\n",
+			want:     types.ProbeResult{Address: "10.96.0.10", Success: true, LatencyMs: 4},
+		},
+		{
+			name:     "tool missing",
+			protocol: "tcp",
+			address:  "10.244.2.5",
+			stdout:   "### result 127 1000000 2000000\n",
+			stderr:   "sh: nc: not found",
+			want:     types.ProbeResult{Address: "10.244.2.5", ErrorClass: "tool_missing", Error: "sh: nc: not found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := types.ProbeResult{Protocol: tt.protocol}
+			parseProbeOutput(&result, tt.address, tt.stdout, tt.stderr)
+			result.Output = ""
+			tt.want.Protocol = tt.protocol
+			if !reflect.DeepEqual(result, tt.want) {
+				t.Errorf("result = %+v, want %+v", result, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectivityProbeWithoutShell(t *testing.T) {
+	server := newFakeServer(t, Dependencies{
+		RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+			return "", "", fmt.Errorf(`failed to execute command in pod: exec: "sh": executable file not found in $PATH`)
+		},
+		GetPod: getProbeTestPod,
+	}, Config{})
+	_, result, err := server.ConnectivityProbe(context.Background(), nil, types.ConnectivityProbeParams{
+		ProbeSource: types.ProbeSource{SourceNamespace: "default", SourcePod: "distroless"},
+		ProbeTarget: types.ProbeTarget{TargetType: "pod", TargetNamespace: "default", TargetName: "server"},
+		Protocol:    "icmp",
+	})
+	if err != nil {
+		t.Fatalf("ConnectivityProbe() error = %v", err)
+	}
+	if result.Success || result.ErrorClass != "tool_missing" || !strings.Contains(result.Error, "debug_container") {
+		t.Errorf("result = %+v, want tool_missing suggesting debug_container", result)
+	}
+}
+
+func TestConnectivityProbeErrors(t *testing.T) {
+	server := newFakeServer(t, Dependencies{GetPod: getProbeTestPod, GetService: getProbeTestService, GetNode: getProbeTestNode}, Config{})
+	valid := types.ConnectivityProbeParams{
+		ProbeSource: types.ProbeSource{SourceNamespace: "default", SourcePod: "client"},
+		ProbeTarget: types.ProbeTarget{TargetType: "pod", TargetNamespace: "default", TargetName: "server", Port: 8080},
+		Protocol:    "tcp",
+	}
+	tests := []struct {
+		name    string
+		modify  func(in *types.ConnectivityProbeParams)
+		wantErr string
+	}{
+		{name: "no source", modify: func(in *types.ConnectivityProbeParams) { in.SourcePod = "" }, wantErr: "exactly one of source_pod or source_node is required"},
+		{name: "debug container from node", modify: func(in *types.ConnectivityProbeParams) {
+			in.ProbeSource = types.ProbeSource{SourceNode: "ovn-worker", DebugContainer: true}
+		}, wantErr: "require source_pod"},
+		{name: "invalid source pod", modify: func(in *types.ConnectivityProbeParams) { in.SourcePod = "client;reboot" }, wantErr: "invalid source_pod"},
+		{name: "invalid protocol", modify: func(in *types.ConnectivityProbeParams) { in.Protocol = "sctp" }, wantErr: "invalid protocol"},
+		{name: "http path without http", modify: func(in *types.ConnectivityProbeParams) { in.HTTPPath = "/" }, wantErr: "http_path requires protocol 'http'"},
+		{name: "invalid http path", modify: func(in *types.ConnectivityProbeParams) { in.Protocol = "http"; in.HTTPPath = "/$(reboot)" }, wantErr: "invalid http_path"},
+		{name: "count too large", modify: func(in *types.ConnectivityProbeParams) { in.Count = 100 }, wantErr: "count"},
+		{name: "missing port", modify: func(in *types.ConnectivityProbeParams) { in.Port = 0 }, wantErr: "port is required with protocol 'tcp'"},
+		{name: "invalid port", modify: func(in *types.ConnectivityProbeParams) { in.Port = 70000 }, wantErr: "invalid port"},
+		{name: "invalid target type", modify: func(in *types.ConnectivityProbeParams) { in.TargetType = "url" }, wantErr: "invalid target_type"},
+		{name: "invalid cluster ip", modify: func(in *types.ConnectivityProbeParams) {
+			in.ProbeTarget = types.ProbeTarget{TargetType: "cluster_ip", TargetAddress: "web.default.svc", Port: 80}
+		}, wantErr: "must be an IP address"},
+		{name: "invalid host", modify: func(in *types.ConnectivityProbeParams) {
+			in.ProbeTarget = types.ProbeTarget{TargetType: "host", TargetAddress: "-oProxyCommand=reboot", Port: 80}
+		}, wantErr: "invalid target_address"},
+		{name: "headless service", modify: func(in *types.ConnectivityProbeParams) {
+			in.ProbeTarget = types.ProbeTarget{TargetType: "service", TargetNamespace: "default", TargetName: "headless"}
+		}, wantErr: "has no cluster IP"},
+		{name: "unknown service port", modify: func(in *types.ConnectivityProbeParams) {
+			in.ProbeTarget = types.ProbeTarget{TargetType: "service", TargetNamespace: "default", TargetName: "web", Port: 443}
+		}, wantErr: "has no port 443"},
+		{name: "port without node port", modify: func(in *types.ConnectivityProbeParams) {
+			in.ProbeTarget = types.ProbeTarget{TargetType: "nodeport", TargetNamespace: "default", TargetName: "web", TargetNode: "ovn-worker", Port: 9090}
+		}, wantErr: "has no node port for port 9090"},
+		{name: "nodeport without node", modify: func(in *types.ConnectivityProbeParams) {
+			in.ProbeTarget = types.ProbeTarget{TargetType: "nodeport", TargetNamespace: "default", TargetName: "web"}
+		}, wantErr: "target_node is required"},
+		{name: "unknown pod", modify: func(in *types.ConnectivityProbeParams) { in.TargetName = "missing" }, wantErr: "pod default/missing not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := valid
+			tt.modify(&in)
+			if _, _, err := server.ConnectivityProbe(context.Background(), nil, in); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ConnectivityProbe() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
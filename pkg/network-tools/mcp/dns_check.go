@@ -0,0 +1,748 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// DefaultDNSServiceNamespace is the namespace of the DNS service when none is given.
+	DefaultDNSServiceNamespace = "kube-system"
+	// DefaultDNSServiceName is the name of the DNS service when none is given.
+	DefaultDNSServiceName = "kube-dns"
+	// DefaultOVNKubeNamespace is the namespace of the ovnkube-node pods when none is given.
+	DefaultOVNKubeNamespace = "ovn-kubernetes"
+	// DefaultDNSQueryTimeout is the number of seconds a DNS query waits for an answer when no
+	// timeout is given.
+	DefaultDNSQueryTimeout = 2
+
+	// ovnkubeNodeLabelSelector selects the ovnkube-node pods.
+	ovnkubeNodeLabelSelector = "app=ovnkube-node"
+	// dnsDefaultNdots is the ndots of resolv.conf files without the option.
+	dnsDefaultNdots = 1
+)
+
+// dnsQueryTypes are the DNS query types a DNS check can query.
+var dnsQueryTypes = []string{"A", "AAAA", "CNAME", "SRV", "TXT", "MX", "NS"}
+
+var (
+	// dnsNamePattern matches DNS names, with the underscores of service names such as
+	// _dns._udp.kube-dns.kube-system.svc.cluster.local.
+	dnsNamePattern   = regexp.MustCompile(`^([a-zA-Z0-9_]([-a-zA-Z0-9_]{0,61}[a-zA-Z0-9])?\.)*[a-zA-Z0-9_]([-a-zA-Z0-9_]{0,61}[a-zA-Z0-9])?\.?$`)
+	digStatusPattern = regexp.MustCompile(`status: ([A-Z]+)`)
+	digTimePattern   = regexp.MustCompile(`Query time: (\d+) msec`)
+	digRCPattern     = regexp.MustCompile(`^rc (\d+)$`)
+)
+
+// dnsQueryScript queries the search candidates of a name to a DNS server with dig, until one has
+// answers or the server does not reply. The arguments are the timeout in seconds, the query type,
+// the server, its port and the candidates.
+const dnsQueryScript = `t=$1 q=$2 server=$3 port=$4
+shift 4
+for name in "$@"; do
+	echo "### query $name"
+	out=$(dig @"$server" -p "$port" +time="$t" +tries=1 +noall +comments +answer +stats "$name" "$q" 2>&1)
+	rc=$?
+	printf '%s\n' "$out"
+	echo "### rc $rc"
+	case "$rc" in 9|127) exit 0 ;; esac
+	case "$out" in *"status: NOERROR"*) case "$out" in *"ANSWER: 0,"*) ;; *) exit 0 ;; esac ;; esac
+done
+`
+
+// dnsPath is a DNS server queried by a DNS check.
+type dnsPath struct {
+	path    types.DNSPath
+	address string
+	port    int
+}
+
+// DNSCheck resolves a name from a pod with the resolver configuration of the pod, against the
+// nameservers of the pod, the DNS service VIP and each DNS pod, and checks the backends of the OVN
+// load balancer of the DNS service. Comparing the paths tells DNS failures from load balancer and
+// ACL failures.
+func (s *MCPServer) DNSCheck(ctx context.Context, req *mcp.CallToolRequest, in types.DNSCheckParams) (*mcp.CallToolResult, types.DNSCheckResult, error) {
+	if in.Namespace == "" || in.Pod == "" {
+		return nil, types.DNSCheckResult{}, fmt.Errorf("namespace and pod are required")
+	}
+	for field, name := range map[string]string{"namespace": in.Namespace, "pod": in.Pod, "container": in.Container, "ovnkube_pod": in.OVNKubePod, "ovnkube_container": in.OVNKubeContainer} {
+		if name != "" && !utils.IsKubernetesName(name) {
+			return nil, types.DNSCheckResult{}, fmt.Errorf("invalid %s: %s", field, name)
+		}
+	}
+	if in.Name == "" || len(in.Name) > 253 || !dnsNamePattern.MatchString(in.Name) {
+		return nil, types.DNSCheckResult{}, fmt.Errorf("invalid name: %q (must be a DNS name)", in.Name)
+	}
+	queryType := strings.ToUpper(cmp.Or(in.QueryType, "A"))
+	if !slices.Contains(dnsQueryTypes, queryType) {
+		return nil, types.DNSCheckResult{}, fmt.Errorf("invalid query_type: %q (must be one of %s)", in.QueryType, strings.Join(dnsQueryTypes, ", "))
+	}
+	serviceNamespace := cmp.Or(in.DNSServiceNamespace, DefaultDNSServiceNamespace)
+	serviceName := cmp.Or(in.DNSServiceName, DefaultDNSServiceName)
+	ovnkubeNamespace := cmp.Or(in.OVNKubeNamespace, DefaultOVNKubeNamespace)
+	for field, name := range map[string]string{"dns_service_namespace": serviceNamespace, "dns_service_name": serviceName, "ovnkube_namespace": ovnkubeNamespace} {
+		if !utils.IsKubernetesName(name) {
+			return nil, types.DNSCheckResult{}, fmt.Errorf("invalid %s: %s", field, name)
+		}
+	}
+	queryTimeout := cmp.Or(in.QueryTimeoutSeconds, DefaultDNSQueryTimeout)
+	if err := validateIntMax(queryTimeout, MaxProbeTimeout, "query_timeout_seconds", "seconds"); err != nil {
+		return nil, types.DNSCheckResult{}, err
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	pod, err := s.getPod(ctx, in.Namespace, in.Pod)
+	if err != nil {
+		return nil, types.DNSCheckResult{}, err
+	}
+	if pod.Status.Phase != corev1.PodRunning {
+		return nil, types.DNSCheckResult{}, fmt.Errorf("pod %s/%s is not running", in.Namespace, in.Pod)
+	}
+	result := types.DNSCheckResult{Pod: in.Namespace + "/" + in.Pod, Via: "exec", Name: in.Name, QueryType: queryType}
+	run := func(cmd []string) (string, string, error) {
+		return s.runPodExecCommand(ctx, in.Namespace, in.Pod, in.Container, cmd)
+	}
+	if in.DebugContainer {
+		result.Via = "debug_container"
+		run = func(cmd []string) (string, string, error) {
+			return s.runPodDebugCommand(ctx, in.Namespace, in.Pod, s.cfg.TcpdumpImage, cmd)
+		}
+	}
+
+	resolvConf, _, err := run([]string{"cat", "/etc/resolv.conf"})
+	if err != nil {
+		if !in.DebugContainer && probeMissingPattern.MatchString(err.Error()) {
+			return nil, types.DNSCheckResult{}, fmt.Errorf("failed to read /etc/resolv.conf: %w; retry with debug_container", err)
+		}
+		return nil, types.DNSCheckResult{}, fmt.Errorf("failed to read /etc/resolv.conf: %w", err)
+	}
+	result.ResolvConf = parseResolvConf(resolvConf)
+	if len(result.ResolvConf.Nameservers) == 0 {
+		return nil, types.DNSCheckResult{}, fmt.Errorf("pod %s/%s has no nameserver in /etc/resolv.conf", in.Namespace, in.Pod)
+	}
+	result.Candidates = dnsCandidates(in.Name, result.ResolvConf)
+
+	service, err := s.getService(ctx, serviceNamespace, serviceName)
+	if err != nil {
+		return nil, types.DNSCheckResult{}, fmt.Errorf("failed to get DNS service: %w", err)
+	}
+	dnsPods, err := s.readyServicePods(ctx, service)
+	if err != nil {
+		return nil, types.DNSCheckResult{}, err
+	}
+
+	paths := dnsPaths(result.ResolvConf, service, dnsPods)
+	if err := runDNSPaths(paths, result.Candidates, queryTimeout, queryType, run); err != nil {
+		if !in.DebugContainer {
+			return nil, types.DNSCheckResult{}, fmt.Errorf("%w; retry with debug_container", err)
+		}
+		return nil, types.DNSCheckResult{}, err
+	}
+	for _, path := range paths {
+		result.Paths = append(result.Paths, path.path)
+	}
+
+	result.LoadBalancer = s.checkDNSLoadBalancer(ctx, ovnkubeNamespace, in.OVNKubePod, in.OVNKubeContainer, pod.Spec.NodeName, service, dnsPods)
+	result.Findings = dnsFindings(result, len(dnsPods))
+	return nil, result, nil
+}
+
+// parseResolvConf parses a resolv.conf file. Nameservers that are not IP addresses and search
+// domains that are not DNS names are skipped.
+func parseResolvConf(text string) types.DNSResolvConf {
+	conf := types.DNSResolvConf{Ndots: dnsDefaultNdots}
+	var domain []string
+	for line := range strings.Lines(text) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") || strings.HasPrefix(fields[0], ";") {
+			continue
+		}
+		switch fields[0] {
+		case "nameserver":
+			if net.ParseIP(fields[1]) != nil {
+				conf.Nameservers = append(conf.Nameservers, fields[1])
+			}
+		case "search", "domain":
+			var domains []string
+			for _, d := range fields[1:] {
+				if len(d) <= 253 && dnsNamePattern.MatchString(d) {
+					domains = append(domains, d)
+				}
+			}
+			if fields[0] == "search" {
+				conf.Search = domains
+			} else {
+				domain = domains[:min(len(domains), 1)]
+			}
+		case "options":
+			for _, option := range fields[1:] {
+				conf.Options = append(conf.Options, option)
+				if value, ok := strings.CutPrefix(option, "ndots:"); ok {
+					if ndots, err := strconv.Atoi(value); err == nil {
+						conf.Ndots = min(max(ndots, 0), 15)
+					}
+				}
+			}
+		}
+	}
+	if conf.Search == nil {
+		conf.Search = domain
+	}
+	return conf
+}
+
+// dnsCandidates returns the names a resolver queries for a name, in order: the name alone when it
+// is absolute; otherwise the name with each search domain, and the name alone first when it has at
+// least ndots dots, or last.
+func dnsCandidates(name string, conf types.DNSResolvConf) []string {
+	if strings.HasSuffix(name, ".") {
+		return []string{name}
+	}
+	var candidates []string
+	for _, domain := range conf.Search {
+		candidates = append(candidates, name+"."+strings.TrimSuffix(domain, ".")+".")
+	}
+	if strings.Count(name, ".") >= conf.Ndots {
+		return append([]string{name + "."}, candidates...)
+	}
+	return append(candidates, name+".")
+}
+
+// readyServicePods returns the ready pods selected by a service, sorted by name.
+func (s *MCPServer) readyServicePods(ctx context.Context, service *corev1.Service) ([]corev1.Pod, error) {
+	if len(service.Spec.Selector) == 0 {
+		return nil, nil
+	}
+	pods, err := s.listPods(ctx, service.Namespace, labels.SelectorFromSet(service.Spec.Selector).String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the pods of service %s/%s: %w", service.Namespace, service.Name, err)
+	}
+	var ready []corev1.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				ready = append(ready, pod)
+				break
+			}
+		}
+	}
+	slices.SortFunc(ready, func(a, b corev1.Pod) int { return strings.Compare(a.Name, b.Name) })
+	return ready, nil
+}
+
+// dnsServicePort returns the DNS port of a service: its UDP port named 'dns', its first UDP port,
+// or its first port.
+func dnsServicePort(service *corev1.Service) (corev1.ServicePort, bool) {
+	var found *corev1.ServicePort
+	for i, port := range service.Spec.Ports {
+		switch {
+		case port.Protocol == corev1.ProtocolUDP && port.Name == "dns":
+			return port, true
+		case port.Protocol == corev1.ProtocolUDP && (found == nil || found.Protocol != corev1.ProtocolUDP):
+			found = &service.Spec.Ports[i]
+		case found == nil:
+			found = &service.Spec.Ports[i]
+		}
+	}
+	if found == nil {
+		return corev1.ServicePort{}, false
+	}
+	return *found, true
+}
+
+// podTargetPort returns the port of a pod a service port is forwarded to, or 0 when a named
+// target port is not a port of the pod.
+func podTargetPort(pod corev1.Pod, port corev1.ServicePort) int {
+	if port.TargetPort.StrVal == "" {
+		return int(cmp.Or(port.TargetPort.IntVal, port.Port))
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, p := range container.Ports {
+			if p.Name == port.TargetPort.StrVal && p.Protocol == cmp.Or(port.Protocol, corev1.ProtocolTCP) {
+				return int(p.ContainerPort)
+			}
+		}
+	}
+	return 0
+}
+
+// dnsPaths returns the DNS servers queried: the nameservers of the pod, the cluster IPs of the
+// DNS service and the DNS pods.
+func dnsPaths(conf types.DNSResolvConf, service *corev1.Service, pods []corev1.Pod) []*dnsPath {
+	var paths []*dnsPath
+	add := func(kind, address string, port int, pod string) {
+		server := net.JoinHostPort(address, strconv.Itoa(port))
+		paths = append(paths, &dnsPath{path: types.DNSPath{Kind: kind, Server: server, Pod: pod}, address: address, port: port})
+	}
+	for _, nameserver := range conf.Nameservers {
+		add("nameserver", nameserver, 53, "")
+	}
+	port, ok := dnsServicePort(service)
+	if !ok {
+		return paths
+	}
+	for _, clusterIP := range serviceClusterIPs(service) {
+		if net.ParseIP(clusterIP) != nil {
+			add("service", clusterIP, int(port.Port), "")
+		}
+	}
+	for _, pod := range pods {
+		if targetPort := podTargetPort(pod, port); targetPort != 0 && net.ParseIP(pod.Status.PodIP) != nil {
+			add("endpoint", pod.Status.PodIP, targetPort, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	return paths
+}
+
+// runDNSPaths queries the candidates to the DNS servers at the same time, and fills the paths
+// with the queries and their outcome. It fails when dig is missing.
+func runDNSPaths(paths []*dnsPath, candidates []string, queryTimeout int, queryType string, run func(cmd []string) (string, string, error)) error {
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cmd := append([]string{"sh", "-c", dnsQueryScript, "sh", strconv.Itoa(queryTimeout), queryType, path.address, strconv.Itoa(path.port)}, candidates...)
+			stdout, stderr, err := run(cmd)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to query %s: %w", path.path.Server, err)
+				return
+			}
+			queries, missing := parseDigOutput(stdout)
+			if missing {
+				errs[i] = fmt.Errorf("dig not found: %s", strings.TrimSpace(cmp.Or(stderr, stdout)))
+				return
+			}
+			path.path.Queries = queries
+			summarizeDNSPath(&path.path)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseDigOutput parses the output of the DNS query script into queries. It reports whether dig
+// is missing.
+func parseDigOutput(output string) ([]types.DNSQuery, bool) {
+	var queries []types.DNSQuery
+	var query *types.DNSQuery
+	var lines []string
+	for line := range strings.Lines(output) {
+		line = strings.TrimSpace(line)
+		marker, ok := strings.CutPrefix(line, probeMarker)
+		switch {
+		case ok && strings.HasPrefix(marker, "query "):
+			queries = append(queries, types.DNSQuery{Name: strings.TrimPrefix(marker, "query ")})
+			query, lines = &queries[len(queries)-1], nil
+		case ok && query != nil:
+			m := digRCPattern.FindStringSubmatch(marker)
+			if m == nil {
+				continue
+			}
+			if m[1] == "127" {
+				return nil, true
+			}
+			if query.Rcode == "" {
+				query.Rcode = "ERROR"
+				if strings.Contains(strings.Join(lines, "\n"), "timed out") {
+					query.Rcode = "TIMEOUT"
+				}
+				if len(lines) > 0 {
+					query.Error = lines[len(lines)-1]
+				}
+			}
+		case query == nil || line == "":
+		case strings.HasPrefix(line, ";"):
+			if m := digStatusPattern.FindStringSubmatch(line); m != nil {
+				query.Rcode = m[1]
+			} else if m := digTimePattern.FindStringSubmatch(line); m != nil {
+				query.TimeMs, _ = strconv.Atoi(m[1])
+			} else if !strings.HasPrefix(line, ";; ") || !strings.HasSuffix(line, ":") {
+				lines = append(lines, line)
+			}
+		default:
+			// Answer records: name, TTL, class, type and data.
+			if fields := strings.Fields(line); len(fields) >= 5 && fields[2] == "IN" {
+				query.Answers = append(query.Answers, fields[0]+" "+fields[3]+" "+strings.Join(fields[4:], " "))
+			} else {
+				lines = append(lines, line)
+			}
+		}
+	}
+	return queries, false
+}
+
+// summarizeDNSPath sets the outcome of a path: answered with the answers of the first query with
+// answers, or the response code of the first query without reply, or of the last query.
+func summarizeDNSPath(path *types.DNSPath) {
+	for _, query := range path.Queries {
+		if query.Rcode == "NOERROR" && len(query.Answers) > 0 {
+			path.Answered, path.Rcode, path.Answers = true, query.Rcode, query.Answers
+			return
+		}
+		if query.Rcode == "TIMEOUT" || query.Rcode == "ERROR" {
+			path.Rcode = query.Rcode
+			return
+		}
+	}
+	if len(path.Queries) > 0 {
+		path.Rcode = path.Queries[len(path.Queries)-1].Rcode
+	}
+}
+
+// checkDNSLoadBalancer compares the backends of the OVN load balancers of the DNS service VIPs with
+// the ready DNS pods. The load balancers are those applied to the logical switch of the node,
+// directly or through load balancer groups, in the northbound database of the ovnkube pod: the
+// ovnkube-node pod of the node, which holds the database of the zone of the node with OVN
+// interconnect, unless ovnkubePod is given.
+func (s *MCPServer) checkDNSLoadBalancer(ctx context.Context, namespace, ovnkubePod, container, node string, service *corev1.Service, pods []corev1.Pod) *types.DNSLoadBalancerCheck {
+	check := &types.DNSLoadBalancerCheck{}
+	if ovnkubePod == "" {
+		ovnkubePods, err := s.listPods(ctx, namespace, ovnkubeNodeLabelSelector)
+		if err != nil {
+			check.Error = err.Error()
+			return check
+		}
+		for _, pod := range ovnkubePods {
+			if pod.Spec.NodeName == node && pod.Status.Phase == corev1.PodRunning {
+				ovnkubePod = pod.Name
+				break
+			}
+		}
+		if ovnkubePod == "" {
+			check.Error = fmt.Sprintf("no running pod with selector %q found on node %s in namespace %s", ovnkubeNodeLabelSelector, node, namespace)
+			return check
+		}
+	}
+	check.OVNKubePod = namespace + "/" + ovnkubePod
+	nbctl := func(args ...string) (string, error) {
+		stdout, _, err := s.runPodExecCommand(ctx, namespace, ovnkubePod, container, append([]string{"ovn-nbctl", "--format=json", "--data=json"}, args...))
+		return stdout, err
+	}
+
+	stdout, err := nbctl("--columns=load_balancer,load_balancer_group", "find", "Logical_Switch", "name="+node)
+	if err != nil {
+		check.Error = fmt.Sprintf("failed to get the logical switch of node %s: %v", node, err)
+		return check
+	}
+	rows, err := parseOVSDBRows(stdout, 2)
+	if err != nil {
+		check.Error = fmt.Sprintf("failed to parse the logical switch of node %s: %v", node, err)
+		return check
+	}
+	if len(rows) == 0 {
+		check.Error = fmt.Sprintf("logical switch %s not found in the northbound database of %s", node, check.OVNKubePod)
+		return check
+	}
+	applied := ovsdbUUIDs(rows[0][0])
+	if groups := ovsdbUUIDs(rows[0][1]); len(groups) > 0 {
+		stdout, err := nbctl("--columns=_uuid,load_balancer", "list", "Load_Balancer_Group")
+		if err != nil {
+			check.Error = fmt.Sprintf("failed to list the load balancer groups: %v", err)
+			return check
+		}
+		rows, err := parseOVSDBRows(stdout, 2)
+		if err != nil {
+			check.Error = fmt.Sprintf("failed to parse the load balancer groups: %v", err)
+			return check
+		}
+		for _, row := range rows {
+			if uuids := ovsdbUUIDs(row[0]); len(uuids) == 1 && slices.Contains(groups, uuids[0]) {
+				applied = append(applied, ovsdbUUIDs(row[1])...)
+			}
+		}
+	}
+
+	stdout, err = nbctl("--columns=_uuid,name,protocol,vips", "list", "Load_Balancer")
+	if err != nil {
+		check.Error = fmt.Sprintf("failed to list the load balancers: %v", err)
+		return check
+	}
+	loadBalancers, err := parseLoadBalancers(stdout)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	loadBalancers = slices.DeleteFunc(loadBalancers, func(lb ovnLoadBalancer) bool { return !slices.Contains(applied, lb.uuid) })
+
+	check.Healthy = true
+	for _, port := range service.Spec.Ports {
+		protocol := strings.ToLower(string(cmp.Or(port.Protocol, corev1.ProtocolTCP)))
+		for _, clusterIP := range serviceClusterIPs(service) {
+			ip := net.ParseIP(clusterIP)
+			if ip == nil {
+				continue
+			}
+			vip := types.DNSLoadBalancerVIP{Protocol: protocol, VIP: net.JoinHostPort(ip.String(), strconv.Itoa(int(port.Port))), Backends: []string{}}
+			for _, lb := range loadBalancers {
+				if backends, ok := lb.vips[vip.VIP]; ok && lb.protocol == protocol {
+					vip.LoadBalancer, vip.Found, vip.Backends = lb.name, true, backends
+					break
+				}
+			}
+			if !vip.Found {
+				check.VIPs = append(check.VIPs, vip)
+				continue
+			}
+			var expected []string
+			for _, pod := range pods {
+				targetPort := podTargetPort(pod, port)
+				for _, podIP := range podIPs(pod) {
+					if podIP := net.ParseIP(podIP); targetPort != 0 && podIP != nil && (podIP.To4() == nil) == (ip.To4() == nil) {
+						expected = append(expected, net.JoinHostPort(podIP.String(), strconv.Itoa(targetPort)))
+					}
+				}
+			}
+			for _, backend := range expected {
+				if !slices.Contains(vip.Backends, backend) {
+					vip.Missing = append(vip.Missing, backend)
+				}
+			}
+			for _, backend := range vip.Backends {
+				if !slices.Contains(expected, backend) {
+					vip.Unexpected = append(vip.Unexpected, backend)
+				}
+			}
+			if len(vip.Missing) > 0 || len(vip.Unexpected) > 0 {
+				check.Healthy = false
+			}
+			check.VIPs = append(check.VIPs, vip)
+		}
+	}
+	return check
+}
+
+// serviceClusterIPs returns the cluster IPs of a service.
+func serviceClusterIPs(service *corev1.Service) []string {
+	if len(service.Spec.ClusterIPs) > 0 {
+		return service.Spec.ClusterIPs
+	}
+	return []string{service.Spec.ClusterIP}
+}
+
+// podIPs returns the IPs of a pod.
+func podIPs(pod corev1.Pod) []string {
+	var ips []string
+	for _, ip := range pod.Status.PodIPs {
+		ips = append(ips, ip.IP)
+	}
+	if len(ips) == 0 && pod.Status.PodIP != "" {
+		ips = append(ips, pod.Status.PodIP)
+	}
+	return ips
+}
+
+// ovnLoadBalancer is a row of the OVN Load_Balancer table.
+type ovnLoadBalancer struct {
+	uuid     string
+	name     string
+	protocol string
+	// vips maps the VIPs to their backends, with the IP addresses in their canonical form.
+	vips map[string][]string
+}
+
+// parseOVSDBRows parses the output of 'ovn-nbctl --format=json --data=json' for a table, and
+// checks that the rows have the number of columns.
+func parseOVSDBRows(output string, columns int) ([][]json.RawMessage, error) {
+	var table struct {
+		Data [][]json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(output), &table); err != nil {
+		return nil, err
+	}
+	for _, row := range table.Data {
+		if len(row) != columns {
+			return nil, fmt.Errorf("unexpected row with %d columns", len(row))
+		}
+	}
+	return table.Data, nil
+}
+
+// ovsdbUUIDs returns the UUIDs of an OVSDB column holding a reference, ["uuid", "<uuid>"], or a
+// set of references, ["set", [["uuid", "<uuid>"], ...]].
+func ovsdbUUIDs(column json.RawMessage) []string {
+	var value []json.RawMessage
+	var kind string
+	if json.Unmarshal(column, &value) != nil || len(value) != 2 || json.Unmarshal(value[0], &kind) != nil {
+		return nil
+	}
+	var uuid string
+	if kind == "uuid" && json.Unmarshal(value[1], &uuid) == nil {
+		return []string{uuid}
+	}
+	var set []json.RawMessage
+	if kind != "set" || json.Unmarshal(value[1], &set) != nil {
+		return nil
+	}
+	var uuids []string
+	for _, element := range set {
+		uuids = append(uuids, ovsdbUUIDs(element)...)
+	}
+	return uuids
+}
+
+// canonicalHostPort returns an IP address and port with the IP address in its canonical form, as
+// OVN and Kubernetes can print IPv6 addresses differently.
+func canonicalHostPort(hostPort string) string {
+	host, port, err := net.SplitHostPort(hostPort)
+	if ip := net.ParseIP(host); err == nil && ip != nil {
+		return net.JoinHostPort(ip.String(), port)
+	}
+	return hostPort
+}
+
+// parseLoadBalancers parses the output of 'ovn-nbctl --format=json --data=json
+// --columns=_uuid,name,protocol,vips list Load_Balancer'. Load balancers without a protocol are
+// TCP.
+func parseLoadBalancers(output string) ([]ovnLoadBalancer, error) {
+	rows, err := parseOVSDBRows(output, 4)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the load balancers: %w", err)
+	}
+	var loadBalancers []ovnLoadBalancer
+	for _, row := range rows {
+		lb := ovnLoadBalancer{protocol: "tcp", vips: map[string][]string{}}
+		if uuids := ovsdbUUIDs(row[0]); len(uuids) == 1 {
+			lb.uuid = uuids[0]
+		}
+		if err := json.Unmarshal(row[1], &lb.name); err != nil {
+			return nil, fmt.Errorf("failed to parse the load balancer name: %w", err)
+		}
+		// An empty optional column is the empty set ["set", []].
+		var protocol string
+		if json.Unmarshal(row[2], &protocol) == nil && protocol != "" {
+			lb.protocol = protocol
+		}
+		var vips []json.RawMessage
+		var pairs [][2]string
+		if err := json.Unmarshal(row[3], &vips); err != nil || len(vips) != 2 || json.Unmarshal(vips[1], &pairs) != nil {
+			return nil, fmt.Errorf("failed to parse the VIPs of load balancer %s", lb.name)
+		}
+		for _, pair := range pairs {
+			backends := []string{}
+			for _, backend := range strings.Split(pair[1], ",") {
+				if backend = strings.TrimSpace(backend); backend != "" {
+					backends = append(backends, canonicalHostPort(backend))
+				}
+			}
+			lb.vips[canonicalHostPort(pair[0])] = backends
+		}
+		loadBalancers = append(loadBalancers, lb)
+	}
+	return loadBalancers, nil
+}
+
+// dnsFindings compares the paths of a DNS check and the load balancer check, and returns what
+// they tell about the failure.
+func dnsFindings(result types.DNSCheckResult, dnsPods int) []string {
+	var findings []string
+	var nameservers, services, endpoints []types.DNSPath
+	for _, path := range result.Paths {
+		switch path.Kind {
+		case "nameserver":
+			nameservers = append(nameservers, path)
+		case "service":
+			services = append(services, path)
+		case "endpoint":
+			endpoints = append(endpoints, path)
+		}
+	}
+	noReply := func(path types.DNSPath) bool { return path.Rcode == "TIMEOUT" || path.Rcode == "ERROR" }
+	servers := func(paths []types.DNSPath, match func(types.DNSPath) bool) []string {
+		var matched []string
+		for _, path := range paths {
+			if match(path) {
+				matched = append(matched, cmp.Or(path.Pod, path.Server))
+			}
+		}
+		return matched
+	}
+	answered := func(path types.DNSPath) bool { return path.Answered }
+	replied := func(path types.DNSPath) bool { return !noReply(path) }
+
+	lbChecked := result.LoadBalancer != nil && result.LoadBalancer.Error == ""
+	var notFound []string
+	if lbChecked {
+		for _, vip := range result.LoadBalancer.VIPs {
+			if !vip.Found {
+				notFound = append(notFound, vip.Protocol+" "+vip.VIP)
+			}
+		}
+	}
+	if len(notFound) > 0 {
+		findings = append(findings, fmt.Sprintf("the DNS service VIPs %v were not found in the OVN load balancers applied to the logical switch of the node of the pod: "+
+			"check that ovnkube-controller synced the service, or set ovnkube_pod to a pod with the northbound database of the node", notFound))
+	}
+	if lbChecked && !result.LoadBalancer.Healthy {
+		var problems []string
+		for _, vip := range result.LoadBalancer.VIPs {
+			switch {
+			case len(vip.Missing) > 0 && len(vip.Unexpected) > 0:
+				problems = append(problems, fmt.Sprintf("%s VIP %s misses backends %v and has unexpected backends %v", vip.Protocol, vip.VIP, vip.Missing, vip.Unexpected))
+			case len(vip.Missing) > 0:
+				problems = append(problems, fmt.Sprintf("%s VIP %s misses backends %v", vip.Protocol, vip.VIP, vip.Missing))
+			case len(vip.Unexpected) > 0:
+				problems = append(problems, fmt.Sprintf("%s VIP %s has unexpected backends %v", vip.Protocol, vip.VIP, vip.Unexpected))
+			}
+		}
+		findings = append(findings, "the OVN load balancer of the DNS service does not match the ready DNS pods: "+strings.Join(problems, "; "))
+	}
+	if dnsPods == 0 {
+		findings = append(findings, "the DNS service has no ready pods")
+	}
+
+	all := result.Paths
+	switch {
+	case len(servers(all, answered)) == len(all):
+		findings = append(findings, "every path resolves the name")
+	case len(servers(all, replied)) == len(all) && len(servers(all, answered)) == 0:
+		findings = append(findings, fmt.Sprintf("every DNS server replies without %s records for the candidates: the name does not resolve, this is not a connectivity failure", result.QueryType))
+	case len(endpoints) > 0 && len(servers(endpoints, noReply)) == len(endpoints) && len(servers(services, noReply)) == len(services):
+		findings = append(findings, "neither the DNS pods nor the DNS service reply: traffic from the pod to the DNS pods is blocked; check the network policies, ACLs and egress firewalls applying to the pod, and the connectivity to the nodes of the DNS pods")
+	case len(servers(services, noReply)) > 0 && len(servers(endpoints, replied)) > 0:
+		finding := fmt.Sprintf("the DNS pods %v reply but the DNS service VIP %v does not: the failure is in the OVN load balancer of the service", servers(endpoints, replied), servers(services, noReply))
+		if lbChecked && result.LoadBalancer.Healthy && len(notFound) == 0 {
+			finding += "; it applies to the logical switch of the pod and its backends match the DNS pods, check the conntrack entries on the node"
+		}
+		findings = append(findings, finding)
+	}
+	if failed := servers(endpoints, noReply); len(failed) > 0 && len(failed) < len(endpoints) {
+		findings = append(findings, fmt.Sprintf("the DNS pods %v do not reply while others do: check the ACLs and the connectivity to their nodes; queries to the service fail when balanced to them", failed))
+	}
+	if failed := servers(endpoints, func(path types.DNSPath) bool { return path.Rcode == "SERVFAIL" }); len(failed) > 0 {
+		findings = append(findings, fmt.Sprintf("the DNS pods %v reply SERVFAIL: check their upstream servers and logs", failed))
+	}
+	if failed := servers(nameservers, noReply); len(failed) > 0 && len(servers(services, replied)) > 0 {
+		findings = append(findings, fmt.Sprintf("the nameservers %v of the pod do not reply while the DNS service does: check the dnsPolicy and dnsConfig of the pod, or the node-local DNS cache", failed))
+	}
+	return findings
+}
@@ -0,0 +1,513 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const testResolvConf = `# generated by kubelet
+search default.svc.cluster.local svc.cluster.local cluster.local
+nameserver 10.96.0.10
+options ndots:5 edns0
+`
+
+func TestParseResolvConf(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want types.DNSResolvConf
+	}{
+		{
+			name: "pod",
+			text: testResolvConf,
+			want: types.DNSResolvConf{
+				Nameservers: []string{"10.96.0.10"},
+				Search:      []string{"default.svc.cluster.local", "svc.cluster.local", "cluster.local"},
+				Ndots:       5,
+				Options:     []string{"ndots:5", "edns0"},
+			},
+		},
+		{
+			name: "domain and invalid entries",
+			text: "domain example.com\nnameserver 8.8.8.8\nnameserver dns.example.com\nnameserver fd00::10\n; comment\n",
+			want: types.DNSResolvConf{Nameservers: []string{"8.8.8.8", "fd00::10"}, Search: []string{"example.com"}, Ndots: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseResolvConf(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseResolvConf() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDNSCandidates(t *testing.T) {
+	conf := types.DNSResolvConf{Search: []string{"default.svc.cluster.local", "svc.cluster.local"}, Ndots: 2}
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{name: "web", want: []string{"web.default.svc.cluster.local.", "web.svc.cluster.local.", "web."}},
+		{name: "web.prod", want: []string{"web.prod.default.svc.cluster.local.", "web.prod.svc.cluster.local.", "web.prod."}},
+		{name: "www.example.com", want: []string{"www.example.com.", "www.example.com.default.svc.cluster.local.", "www.example.com.svc.cluster.local."}},
+		{name: "www.example.com.", want: []string{"www.example.com."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dnsCandidates(tt.name, conf); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dnsCandidates() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// digOutput returns the output of the DNS query script for a query with the status, or a query
+// without reply when the status is empty.
+func digOutput(name, status string, answers ...string) string {
+	if status == "" {
+		return fmt.Sprintf("### query %s\n;; communications error to 10.96.0.10#53: timed out\n;; no servers could be reached\n### rc 9\n", name)
+	}
+	out := fmt.Sprintf("### query %s\n;; Got answer:\n;; ->>HEADER<<- opcode: QUERY, status: %s, id: 4242\n;; flags: qr aa rd; QUERY: 1, ANSWER: %d, AUTHORITY: 0, ADDITIONAL: 1\n\n",
+		name, status, len(answers))
+	if len(answers) > 0 {
+		out += ";; ANSWER SECTION:\n" + strings.Join(answers, "\n") + "\n\n"
+	}
+	return out + ";; Query time: 3 msec\n;; SERVER: 10.96.0.10#53(10.96.0.10) (UDP)\n### rc 0\n"
+}
+
+func TestParseDigOutput(t *testing.T) {
+	output := digOutput("web.default.svc.cluster.local.", "NXDOMAIN") +
+		digOutput("web.svc.cluster.local.", "NOERROR", "web.svc.cluster.local. 30 IN A 10.96.10.20") +
+		digOutput("web.", "")
+	want := []types.DNSQuery{
+		{Name: "web.default.svc.cluster.local.", Rcode: "NXDOMAIN", TimeMs: 3},
+		{Name: "web.svc.cluster.local.", Rcode: "NOERROR", Answers: []string{"web.svc.cluster.local. A 10.96.10.20"}, TimeMs: 3},
+		{Name: "web.", Rcode: "TIMEOUT", Error: ";; no servers could be reached"},
+	}
+	queries, missing := parseDigOutput(output)
+	if missing || !reflect.DeepEqual(queries, want) {
+		t.Errorf("parseDigOutput() = %+v, %v, want %+v", queries, missing, want)
+	}
+	if _, missing := parseDigOutput("### query web.\nsh: dig: not found\n### rc 127\n"); !missing {
+		t.Errorf("parseDigOutput() did not report dig missing")
+	}
+
+	path := types.DNSPath{Queries: want}
+	summarizeDNSPath(&path)
+	if !path.Answered || path.Rcode != "NOERROR" || len(path.Answers) != 1 {
+		t.Errorf("path = %+v, want answered", path)
+	}
+}
+
+func TestParseLoadBalancers(t *testing.T) {
+	output := `{"data":[[["uuid","lb-udp"],"Service_kube-system/kube-dns_UDP_cluster","udp",["map",[["10.96.0.10:53","10.244.0.3:53,10.244.1.4:53"],` +
+		`["[FD00:10:96:0:0:0:0:A]:53","[fd00:10:244:0:0:0:0:3]:53"]]]],` +
+		`[["uuid","lb-tcp"],"Service_kube-system/kube-dns_TCP_cluster",["set",[]],["map",[["10.96.0.10:53",""]]]]],"headings":["_uuid","name","protocol","vips"]}`
+	want := []ovnLoadBalancer{
+		{uuid: "lb-udp", name: "Service_kube-system/kube-dns_UDP_cluster", protocol: "udp", vips: map[string][]string{
+			"10.96.0.10:53": {"10.244.0.3:53", "10.244.1.4:53"}, "[fd00:10:96::a]:53": {"[fd00:10:244::3]:53"},
+		}},
+		{uuid: "lb-tcp", name: "Service_kube-system/kube-dns_TCP_cluster", protocol: "tcp", vips: map[string][]string{"10.96.0.10:53": {}}},
+	}
+	got, err := parseLoadBalancers(output)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("parseLoadBalancers() = %+v, %v, want %+v", got, err, want)
+	}
+	if _, err := parseLoadBalancers(`{"data":[["lb"]]}`); err == nil {
+		t.Errorf("parseLoadBalancers() accepted a row without the columns")
+	}
+}
+
+func TestOVSDBUUIDs(t *testing.T) {
+	tests := []struct {
+		column string
+		want   []string
+	}{
+		{column: `["uuid","a"]`, want: []string{"a"}},
+		{column: `["set",[["uuid","a"],["uuid","b"]]]`, want: []string{"a", "b"}},
+		{column: `["set",[]]`},
+		{column: `"a"`},
+	}
+	for _, tt := range tests {
+		if got := ovsdbUUIDs(json.RawMessage(tt.column)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ovsdbUUIDs(%s) = %q, want %q", tt.column, got, tt.want)
+		}
+	}
+}
+
+// dnsNBDB returns the output of the ovn-nbctl commands of the load balancer check for a
+// northbound database with the logical switch of ovn-worker and the load balancers.
+func dnsNBDB(t *testing.T, cmd []string, loadBalancers string) (string, string, error) {
+	t.Helper()
+	if len(cmd) < 6 || !reflect.DeepEqual(cmd[:3], []string{"ovn-nbctl", "--format=json", "--data=json"}) {
+		return "", "", fmt.Errorf("unexpected command %q", cmd)
+	}
+	switch strings.Join(cmd[3:], " ") {
+	case "--columns=load_balancer,load_balancer_group find Logical_Switch name=ovn-worker":
+		// The cluster load balancers of OVN-Kubernetes are applied through groups.
+		return `{"data":[[["uuid","lb-node"],["set",[["uuid","group-cluster"],["uuid","group-switches"]]]]],"headings":["load_balancer","load_balancer_group"]}`, "", nil
+	case "--columns=_uuid,load_balancer list Load_Balancer_Group":
+		return `{"data":[[["uuid","group-cluster"],["set",[["uuid","lb-udp"],["uuid","lb-tcp"]]]],` +
+			`[["uuid","group-switches"],["set",[]]],[["uuid","group-routers"],["uuid","lb-other"]]],"headings":["_uuid","load_balancer"]}`, "", nil
+	case "--columns=_uuid,name,protocol,vips list Load_Balancer":
+		return loadBalancers, "", nil
+	}
+	return "", "", fmt.Errorf("unexpected command %q", cmd)
+}
+
+func dnsTestPod(name, node, ip string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kube-system"},
+		Spec: corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{
+			Ports: []corev1.ContainerPort{{Name: "dns", ContainerPort: 53, Protocol: corev1.ProtocolUDP}, {Name: "dns-tcp", ContainerPort: 53, Protocol: corev1.ProtocolTCP}},
+		}}},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			PodIP:      ip,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+// getDNSTestPod returns the pod "client" on ovn-worker.
+func getDNSTestPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	if namespace != "default" || name != "client" {
+		return nil, fmt.Errorf("pod %s/%s not found", namespace, name)
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "client", Namespace: "default"},
+		Spec:       corev1.PodSpec{NodeName: "ovn-worker"},
+		Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.244.1.5"},
+	}, nil
+}
+
+// listDNSTestPods returns two ready DNS pods and a DNS pod that is not ready, and the
+// ovnkube-node pods.
+func listDNSTestPods(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error) {
+	switch namespace + " " + labelSelector {
+	case "kube-system k8s-app=kube-dns":
+		notReady := dnsTestPod("coredns-c", "ovn-worker2", "10.244.2.9")
+		notReady.Status.Conditions = nil
+		return []corev1.Pod{dnsTestPod("coredns-b", "ovn-worker", "10.244.1.4"), dnsTestPod("coredns-a", "ovn-control-plane", "10.244.0.3"), notReady}, nil
+	case "ovn-kubernetes app=ovnkube-node":
+		return []corev1.Pod{
+			{ObjectMeta: metav1.ObjectMeta{Name: "ovnkube-node-x", Namespace: "ovn-kubernetes"}, Spec: corev1.PodSpec{NodeName: "ovn-worker2"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "ovnkube-node-y", Namespace: "ovn-kubernetes"}, Spec: corev1.PodSpec{NodeName: "ovn-worker"}, Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+		}, nil
+	}
+	return nil, fmt.Errorf("unexpected pod list in namespace %q with selector %q", namespace, labelSelector)
+}
+
+// getDNSTestService returns the DNS service.
+func getDNSTestService(ctx context.Context, namespace, name string) (*corev1.Service, error) {
+	if namespace != "kube-system" || name != "kube-dns" {
+		return nil, fmt.Errorf("service %s/%s not found", namespace, name)
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: corev1.ServiceSpec{
+			Selector:   map[string]string{"k8s-app": "kube-dns"},
+			ClusterIP:  "10.96.0.10",
+			ClusterIPs: []string{"10.96.0.10"},
+			Ports: []corev1.ServicePort{
+				{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53, TargetPort: intstr.FromString("dns-tcp")},
+				{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53, TargetPort: intstr.FromInt32(53)},
+			},
+		},
+	}, nil
+}
+
+// runDNSTestCommand returns the output of a command run in the pod "client" or in an ovnkube-node
+// pod, whose UDP load balancer misses the second DNS pod. The DNS servers for which noReply
+// returns true do not reply.
+func runDNSTestCommand(t *testing.T, namespace, name string, cmd []string, noReply func(server string) bool) (string, string, error) {
+	switch {
+	case cmd[0] == "cat":
+		return testResolvConf, "", nil
+	case cmd[0] == "ovn-nbctl":
+		if namespace != "ovn-kubernetes" || name != "ovnkube-node-y" {
+			t.Errorf("ovn-nbctl in %s/%s: %q", namespace, name, cmd)
+		}
+		// lb-other has the VIP with every DNS pod, but is not applied to the switch of the pod.
+		return dnsNBDB(t, cmd, `{"data":[[["uuid","lb-udp"],"Service_kube-system/kube-dns_UDP_cluster","udp",["map",[["10.96.0.10:53","10.244.0.3:53"]]]],`+
+			`[["uuid","lb-other"],"Service_kube-system/kube-dns_UDP_node_router_ovn-worker2","udp",["map",[["10.96.0.10:53","10.244.0.3:53,10.244.1.4:53"]]]],`+
+			`[["uuid","lb-tcp"],"Service_kube-system/kube-dns_TCP_cluster",["set",[]],["map",[["10.96.0.10:53","10.244.0.3:53,10.244.1.4:53"]]]]],"headings":["_uuid","name","protocol","vips"]}`)
+	case cmd[2] != dnsQueryScript:
+		return "", "", fmt.Errorf("unexpected command %q", cmd)
+	}
+	if cmd[4] != "2" || cmd[5] != "A" || cmd[7] != "53" {
+		t.Errorf("query arguments = %q", cmd[4:8])
+	}
+	var out string
+	for _, name := range cmd[8:] {
+		switch {
+		case noReply(cmd[6]):
+			return out + digOutput(name, ""), "", nil
+		case name == "kubernetes.default.svc.cluster.local.":
+			return out + digOutput(name, "NOERROR", name+" 30 IN A 10.96.0.1"), "", nil
+		}
+		out += digOutput(name, "NXDOMAIN")
+	}
+	return out, "", nil
+}
+
+func TestDNSCheckLoadBalancerFailure(t *testing.T) {
+	// The DNS service VIP does not reply while the DNS pods do.
+	server := newFakeServer(t, Dependencies{
+		RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+			return runDNSTestCommand(t, namespace, name, cmd, func(server string) bool { return server == "10.96.0.10" })
+		},
+		GetPod:     getDNSTestPod,
+		ListPods:   listDNSTestPods,
+		GetService: getDNSTestService,
+	}, Config{})
+	_, result, err := server.DNSCheck(context.Background(), nil, types.DNSCheckParams{Namespace: "default", Pod: "client", Name: "kubernetes.default"})
+	if err != nil {
+		t.Fatalf("DNSCheck() error = %v", err)
+	}
+
+	wantCandidates := []string{
+		"kubernetes.default.default.svc.cluster.local.", "kubernetes.default.svc.cluster.local.",
+		"kubernetes.default.cluster.local.", "kubernetes.default.",
+	}
+	if !reflect.DeepEqual(result.Candidates, wantCandidates) {
+		t.Errorf("candidates = %q, want %q", result.Candidates, wantCandidates)
+	}
+	var paths []string
+	for _, path := range result.Paths {
+		paths = append(paths, fmt.Sprintf("%s %s %s %v %s %d", path.Kind, path.Server, path.Pod, path.Answered, path.Rcode, len(path.Queries)))
+	}
+	wantPaths := []string{
+		"nameserver 10.96.0.10:53  false TIMEOUT 1",
+		"service 10.96.0.10:53  false TIMEOUT 1",
+		"endpoint 10.244.0.3:53 kube-system/coredns-a true NOERROR 2",
+		"endpoint 10.244.1.4:53 kube-system/coredns-b true NOERROR 2",
+	}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Errorf("paths = %q, want %q", paths, wantPaths)
+	}
+	if got := result.Paths[2].Answers; !reflect.DeepEqual(got, []string{"kubernetes.default.svc.cluster.local. A 10.96.0.1"}) {
+		t.Errorf("answers = %q", got)
+	}
+
+	wantLB := &types.DNSLoadBalancerCheck{
+		OVNKubePod: "ovn-kubernetes/ovnkube-node-y",
+		VIPs: []types.DNSLoadBalancerVIP{
+			{LoadBalancer: "Service_kube-system/kube-dns_TCP_cluster", Protocol: "tcp", VIP: "10.96.0.10:53", Found: true, Backends: []string{"10.244.0.3:53", "10.244.1.4:53"}},
+			{LoadBalancer: "Service_kube-system/kube-dns_UDP_cluster", Protocol: "udp", VIP: "10.96.0.10:53", Found: true, Backends: []string{"10.244.0.3:53"}, Missing: []string{"10.244.1.4:53"}},
+		},
+	}
+	if !reflect.DeepEqual(result.LoadBalancer, wantLB) {
+		t.Errorf("load balancer = %+v, want %+v", result.LoadBalancer, wantLB)
+	}
+	wantFindings := []string{
+		"the OVN load balancer of the DNS service does not match the ready DNS pods: udp VIP 10.96.0.10:53 misses backends [10.244.1.4:53]",
+		"the DNS pods [kube-system/coredns-a kube-system/coredns-b] reply but the DNS service VIP [10.96.0.10:53] does not: the failure is in the OVN load balancer of the service",
+	}
+	if !reflect.DeepEqual(result.Findings, wantFindings) {
+		t.Errorf("findings = %q, want %q", result.Findings, wantFindings)
+	}
+}
+
+func TestCheckDNSLoadBalancer(t *testing.T) {
+	// A dual-stack DNS service with a UDP port only in the load balancers, and IPv6 addresses in
+	// another form than Kubernetes prints them.
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-dns", Namespace: "kube-system"},
+		Spec: corev1.ServiceSpec{
+			ClusterIP:  "10.96.0.10",
+			ClusterIPs: []string{"10.96.0.10", "fd00:10:96::a"},
+			Ports: []corev1.ServicePort{
+				{Name: "dns", Protocol: corev1.ProtocolUDP, Port: 53, TargetPort: intstr.FromInt32(53)},
+				{Name: "dns-tcp", Protocol: corev1.ProtocolTCP, Port: 53, TargetPort: intstr.FromInt32(53)},
+			},
+		},
+	}
+	dnsPod := dnsTestPod("coredns-a", "ovn-control-plane", "10.244.0.3")
+	dnsPod.Status.PodIPs = []corev1.PodIP{{IP: "10.244.0.3"}, {IP: "fd00:10:244::3"}}
+	loadBalancers := `{"data":[[["uuid","lb-udp"],"Service_kube-system/kube-dns_UDP_cluster","udp",["map",[["10.96.0.10:53","10.244.0.3:53"],` +
+		`["[fd00:10:96:0:0:0:0:a]:53","[FD00:10:244::3]:53"]]]]],"headings":["_uuid","name","protocol","vips"]}`
+
+	tests := []struct {
+		name       string
+		ovnkubePod string
+		wantPod    string
+	}{
+		{name: "ovnkube-node pod of the node", wantPod: "ovnkube-node-y"},
+		{name: "given ovnkube pod", ovnkubePod: "ovnkube-db-0", wantPod: "ovnkube-db-0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t, Dependencies{
+				RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+					if name != tt.wantPod || container != "nb-ovsdb" {
+						t.Errorf("ovn-nbctl in %s/%s container %s, want pod %s", namespace, name, container, tt.wantPod)
+					}
+					return dnsNBDB(t, cmd, loadBalancers)
+				},
+				ListPods: func(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error) {
+					return []corev1.Pod{{
+						ObjectMeta: metav1.ObjectMeta{Name: "ovnkube-node-y", Namespace: namespace},
+						Spec:       corev1.PodSpec{NodeName: "ovn-worker"},
+						Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+					}}, nil
+				},
+			}, Config{})
+			got := server.checkDNSLoadBalancer(context.Background(), "ovn-kubernetes", tt.ovnkubePod, "nb-ovsdb", "ovn-worker", service, []corev1.Pod{dnsPod})
+			want := &types.DNSLoadBalancerCheck{
+				OVNKubePod: "ovn-kubernetes/" + tt.wantPod,
+				Healthy:    true,
+				VIPs: []types.DNSLoadBalancerVIP{
+					{LoadBalancer: "Service_kube-system/kube-dns_UDP_cluster", Protocol: "udp", VIP: "10.96.0.10:53", Found: true, Backends: []string{"10.244.0.3:53"}},
+					{LoadBalancer: "Service_kube-system/kube-dns_UDP_cluster", Protocol: "udp", VIP: "[fd00:10:96::a]:53", Found: true, Backends: []string{"[fd00:10:244::3]:53"}},
+					{Protocol: "tcp", VIP: "10.96.0.10:53", Backends: []string{}},
+					{Protocol: "tcp", VIP: "[fd00:10:96::a]:53", Backends: []string{}},
+				},
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("checkDNSLoadBalancer() = %+v, want %+v", got, want)
+			}
+
+			findings := dnsFindings(types.DNSCheckResult{LoadBalancer: got}, 1)
+			wantFinding := "the DNS service VIPs [tcp 10.96.0.10:53 tcp [fd00:10:96::a]:53] were not found in the OVN load balancers applied to the logical switch"
+			if len(findings) == 0 || !strings.HasPrefix(findings[0], wantFinding) {
+				t.Errorf("findings = %q, want %q", findings, wantFinding)
+			}
+		})
+	}
+}
+
+func TestCheckDNSLoadBalancerErrors(t *testing.T) {
+	server := newFakeServer(t, Dependencies{
+		RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+			if strings.Contains(strings.Join(cmd, " "), "Logical_Switch") {
+				return `{"data":[],"headings":["load_balancer","load_balancer_group"]}`, "", nil
+			}
+			return "", "", fmt.Errorf("unexpected command %q", cmd)
+		},
+	}, Config{})
+	got := server.checkDNSLoadBalancer(context.Background(), "ovn-kubernetes", "ovnkube-db-0", "", "ovn-worker", &corev1.Service{}, nil)
+	if want := "logical switch ovn-worker not found in the northbound database of ovn-kubernetes/ovnkube-db-0"; got.Error != want || got.Healthy {
+		t.Errorf("checkDNSLoadBalancer() = %+v, want error %q", got, want)
+	}
+}
+
+func TestDNSCheckFindings(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		noReply func(server string) bool
+		want    string
+	}{
+		{
+			name:    "resolves",
+			query:   "kubernetes.default",
+			noReply: func(server string) bool { return false },
+			want:    "every path resolves the name",
+		},
+		{
+			name:    "name does not exist",
+			query:   "missing.default",
+			noReply: func(server string) bool { return false },
+			want:    "every DNS server replies without A records for the candidates: the name does not resolve, this is not a connectivity failure",
+		},
+		{
+			name:    "blocked",
+			query:   "kubernetes.default",
+			noReply: func(server string) bool { return true },
+			want:    "neither the DNS pods nor the DNS service reply",
+		},
+		{
+			name:    "one DNS pod blocked",
+			query:   "kubernetes.default",
+			noReply: func(server string) bool { return server == "10.244.1.4" },
+			want:    "the DNS pods [kube-system/coredns-b] do not reply while others do",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeServer(t, Dependencies{
+				RunPodDebugCommand: func(ctx context.Context, namespace, name, image string, cmd []string) (string, string, error) {
+					if image != "netshoot" {
+						t.Errorf("debug container image = %q, want the tcpdump image", image)
+					}
+					return runDNSTestCommand(t, namespace, name, cmd, tt.noReply)
+				},
+				RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+					return runDNSTestCommand(t, namespace, name, cmd, tt.noReply)
+				},
+				GetPod:     getDNSTestPod,
+				ListPods:   listDNSTestPods,
+				GetService: getDNSTestService,
+			}, Config{TcpdumpImage: "netshoot"})
+			_, result, err := server.DNSCheck(context.Background(), nil, types.DNSCheckParams{Namespace: "default", Pod: "client", Name: tt.query, DebugContainer: true})
+			if err != nil {
+				t.Fatalf("DNSCheck() error = %v", err)
+			}
+			if result.Via != "debug_container" {
+				t.Errorf("via = %q, want debug_container", result.Via)
+			}
+			if !strings.Contains(strings.Join(result.Findings, "\n"), tt.want) {
+				t.Errorf("findings = %q, want %q", result.Findings, tt.want)
+			}
+		})
+	}
+}
+
+func TestDNSCheckErrors(t *testing.T) {
+	server := newFakeServer(t, Dependencies{
+		RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+			return runDNSTestCommand(t, namespace, name, cmd, func(server string) bool { return false })
+		},
+		GetPod:     getDNSTestPod,
+		ListPods:   listDNSTestPods,
+		GetService: getDNSTestService,
+	}, Config{})
+	tests := []struct {
+		name    string
+		in      types.DNSCheckParams
+		wantErr string
+	}{
+		{name: "missing pod", in: types.DNSCheckParams{Name: "web"}, wantErr: "namespace and pod are required"},
+		{name: "invalid container", in: types.DNSCheckParams{Namespace: "default", Pod: "client", Container: "app;id", Name: "web"}, wantErr: "invalid container"},
+		{name: "missing name", in: types.DNSCheckParams{Namespace: "default", Pod: "client"}, wantErr: "invalid name"},
+		{name: "invalid name", in: types.DNSCheckParams{Namespace: "default", Pod: "client", Name: "+short"}, wantErr: "invalid name"},
+		{name: "invalid query type", in: types.DNSCheckParams{Namespace: "default", Pod: "client", Name: "web", QueryType: "AXFR"}, wantErr: "invalid query_type"},
+		{name: "query timeout too large", in: types.DNSCheckParams{Namespace: "default", Pod: "client", Name: "web", QueryTimeoutSeconds: 60}, wantErr: "query_timeout_seconds cannot exceed"},
+		{name: "unknown DNS service", in: types.DNSCheckParams{Namespace: "default", Pod: "client", Name: "web", DNSServiceNamespace: "openshift-dns", DNSServiceName: "dns-default"}, wantErr: "failed to get DNS service"},
+		{name: "unknown pod", in: types.DNSCheckParams{Namespace: "default", Pod: "server", Name: "web"}, wantErr: "pod default/server not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := server.DNSCheck(context.Background(), nil, tt.in); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DNSCheck() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	// dig is missing from the container of the pod.
+	server = newFakeServer(t, Dependencies{
+		RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+			if cmd[0] == "sh" && cmd[2] == dnsQueryScript {
+				return "### query " + cmd[8] + "\n### rc 127\n", "sh: dig: not found", nil
+			}
+			return runDNSTestCommand(t, namespace, name, cmd, func(server string) bool { return false })
+		},
+		GetPod:     getDNSTestPod,
+		ListPods:   listDNSTestPods,
+		GetService: getDNSTestService,
+	}, Config{})
+	if _, _, err := server.DNSCheck(context.Background(), nil, types.DNSCheckParams{Namespace: "default", Pod: "client", Name: "web"}); err == nil || !strings.Contains(err.Error(), "dig not found") || !strings.Contains(err.Error(), "retry with debug_container") {
+		t.Errorf("DNSCheck() error = %v, want dig not found", err)
+	}
+}
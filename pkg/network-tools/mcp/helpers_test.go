@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// newFakeServer returns a server with the dependencies set in deps. The dependencies left nil
+// return an error, as for a command that fails or an object that does not exist.
+func newFakeServer(t *testing.T, deps Dependencies, cfg Config) *MCPServer {
+	t.Helper()
+	if deps.RunDebugNodeCommand == nil {
+		deps.RunDebugNodeCommand = func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+			return "", "", fmt.Errorf("unexpected debug node command %q", cmd)
+		}
+	}
+	if deps.RunPodExecCommand == nil {
+		deps.RunPodExecCommand = func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+			return "", "", fmt.Errorf("unexpected pod exec command %q", cmd)
+		}
+	}
+	if deps.RunPodDebugCommand == nil {
+		deps.RunPodDebugCommand = func(ctx context.Context, namespace, name, image string, cmd []string) (string, string, error) {
+			return "", "", fmt.Errorf("unexpected pod debug command %q", cmd)
+		}
+	}
+	if deps.GetPod == nil {
+		deps.GetPod = func(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+			return nil, fmt.Errorf("pod %s/%s not found", namespace, name)
+		}
+	}
+	if deps.ListPods == nil {
+		deps.ListPods = func(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error) {
+			return nil, fmt.Errorf("unexpected pod list in namespace %q", namespace)
+		}
+	}
+	if deps.GetService == nil {
+		deps.GetService = func(ctx context.Context, namespace, name string) (*corev1.Service, error) {
+			return nil, fmt.Errorf("service %s/%s not found", namespace, name)
+		}
+	}
+	if deps.GetNode == nil {
+		deps.GetNode = func(ctx context.Context, name string) (*corev1.Node, error) {
+			return nil, fmt.Errorf("node %s not found", name)
+		}
+	}
+	server, err := NewMCPServer(deps, cfg)
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+	return server
+}
@@ -7,38 +7,103 @@ import (
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/timeout"
+	corev1 "k8s.io/api/core/v1"
 )
 
 type RunDebugNodeCommandFuncType func(ctx context.Context, namespace string, nodeName string, image string, command []string, hostPath string, mountPath string, timeout time.Duration) (string, string, error)
 type RunPodExecCommandFuncType func(ctx context.Context, namespace, name, container string, command []string) (string, string, error)
+type RunPodDebugCommandFuncType func(ctx context.Context, namespace, name, image string, command []string) (string, string, error)
+type GetPodFuncType func(ctx context.Context, namespace, name string) (*corev1.Pod, error)
+type ListPodsFuncType func(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error)
+type GetServiceFuncType func(ctx context.Context, namespace, name string) (*corev1.Service, error)
+type GetNodeFuncType func(ctx context.Context, name string) (*corev1.Node, error)
 
 // Config contains the configuration for the network tools MCP server.
 type Config struct {
 	// PwruImage is the container image to use for running the pwru command on the node.
 	PwruImage string
+	// RetisImage is the container image to use for running the retis command on the node.
+	RetisImage string
 	// TcpdumpImage is the container image to use for running the tcpdump command on the node.
 	TcpdumpImage string
+	// PcapStoreMaxBytes is the total size of the pcap captures kept by the server. Default:
+	// DefaultPcapStoreMaxBytes.
+	PcapStoreMaxBytes int
+	// ToolTimeout is the tool timeout of the server, applied to the calls without timeout_seconds.
+	// 0 if it is disabled.
+	ToolTimeout time.Duration
 }
 
 // MCPServer provides MCP server functionality for network tools operations.
 type MCPServer struct {
 	runDebugNodeCommand RunDebugNodeCommandFuncType
 	runPodExecCommand   RunPodExecCommandFuncType
+	runPodDebugCommand  RunPodDebugCommandFuncType
+	getPod              GetPodFuncType
+	listPods            ListPodsFuncType
+	getService          GetServiceFuncType
+	getNode             GetNodeFuncType
 	cfg                 Config
+	pcaps               *pcapStore
+}
+
+// Dependencies are the functions the network tools use to reach the cluster.
+type Dependencies struct {
+	// RunDebugNodeCommand runs a command in a debug pod of a node.
+	RunDebugNodeCommand RunDebugNodeCommandFuncType
+	// RunPodExecCommand runs a command in a container of a pod.
+	RunPodExecCommand RunPodExecCommandFuncType
+	// RunPodDebugCommand runs a command in an ephemeral debug container of a pod.
+	RunPodDebugCommand RunPodDebugCommandFuncType
+	// GetPod gets a pod.
+	GetPod GetPodFuncType
+	// ListPods lists the pods of a namespace matching a label selector.
+	ListPods ListPodsFuncType
+	// GetService gets a service.
+	GetService GetServiceFuncType
+	// GetNode gets a node.
+	GetNode GetNodeFuncType
 }
 
 // NewMCPServer creates a new MCP server instance
-func NewMCPServer(runNodeCommand RunDebugNodeCommandFuncType, runPodExecCommand RunPodExecCommandFuncType, cfg Config) (*MCPServer, error) {
-	if runNodeCommand == nil {
+func NewMCPServer(deps Dependencies, cfg Config) (*MCPServer, error) {
+	if deps.RunDebugNodeCommand == nil {
 		return nil, fmt.Errorf("function to run debug node command is nil")
 	}
-	if runPodExecCommand == nil {
+	if deps.RunPodExecCommand == nil {
 		return nil, fmt.Errorf("function to run pod exec command is nil")
 	}
+	if deps.RunPodDebugCommand == nil {
+		return nil, fmt.Errorf("function to run pod debug command is nil")
+	}
+	if deps.GetPod == nil {
+		return nil, fmt.Errorf("function to get pod is nil")
+	}
+	if deps.ListPods == nil {
+		return nil, fmt.Errorf("function to list pods is nil")
+	}
+	if deps.GetService == nil {
+		return nil, fmt.Errorf("function to get service is nil")
+	}
+	if deps.GetNode == nil {
+		return nil, fmt.Errorf("function to get node is nil")
+	}
+	if cfg.PcapStoreMaxBytes < 0 {
+		return nil, fmt.Errorf("pcap store size cannot be negative")
+	}
+	if cfg.PcapStoreMaxBytes == 0 {
+		cfg.PcapStoreMaxBytes = DefaultPcapStoreMaxBytes
+	}
 	return &MCPServer{
-		runDebugNodeCommand: runNodeCommand,
-		runPodExecCommand:   runPodExecCommand,
+		runDebugNodeCommand: deps.RunDebugNodeCommand,
+		runPodExecCommand:   deps.RunPodExecCommand,
+		runPodDebugCommand:  deps.RunPodDebugCommand,
+		getPod:              deps.GetPod,
+		listPods:            deps.ListPods,
+		getService:          deps.GetService,
+		getNode:             deps.GetNode,
 		cfg:                 cfg,
+		pcaps:               newPcapStore(cfg.PcapStoreMaxBytes),
 	}, nil
 }
 
@@ -62,14 +127,184 @@ Parameters:
 - packet_count: Number of packets to capture (default: 100, max: 1000)
 - bpf_filter: BPF filter expression to match packets (optional, e.g., "tcp and dst port 8080", "host 10.0.0.1")
 - snaplen: Snapshot length in bytes (default: 96, max: 1500)
+- mode: 'text' or 'pcap' (optional, default: 'text').
+        text: Decode the packets with tcpdump -n -e -S -v and return one record per packet in packets.records: timestamp,
+              interface, direction (for the 'any' interface), MAC addresses, VLANs, protocol, addresses, IP ID and ports, TCP flags,
+              absolute seq/ack, frame and payload lengths, ICMP message, and the Geneve VNI, options and outer addresses of
+              encapsulated packets, whose other fields describe the inner packet. packets also holds the top %d talkers
+              by packets, and up to %d TCP resets, TCP segments seen more than once (retransmission hints) and ICMP
+              unreachables. output holds the text printed by tcpdump, with the link layer header (-e) and absolute TCP
+              sequence numbers (-S), and unparsed the lines of output that could not be parsed.
+        pcap: Write the capture in pcap format and store it on the server as an MCP resource that can be downloaded into Wireshark.
+              The result holds the resource URI and a summary computed from the capture: packets, bytes, time range,
+              packets and bytes by protocol, and the top %d conversations by bytes. The oldest captures are dropped
+              once the stored captures exceed the server pcap store size.
 - timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
 
 Examples:
 - Capture on node: {"target_type": "node", "name": "worker-1", "interface": "eth0", "packet_count": 100, "bpf_filter": "tcp port 80"}
 - Capture in pod: {"target_type": "pod", "name": "my-pod", "namespace": "default", "interface": "eth0", "packet_count": 100, "bpf_filter": "host 10.0.0.1"}
-- Capture DNS: {"target_type": "node", "name": "worker-1", "interface": "any", "packet_count": 50, "bpf_filter": "port 53"}`,
-				int(timeout.MaxTimeout.Seconds())),
+- Capture DNS: {"target_type": "node", "name": "worker-1", "interface": "any", "packet_count": 50, "bpf_filter": "port 53"}
+- Capture to pcap: {"target_type": "node", "name": "worker-1", "interface": "genev_sys_6081", "snaplen": 1500, "mode": "pcap"}
+
+Example output (mode='text'):
+{
+  "output": "10:15:00.250000 0a:58:0a:f4:01:03 > 0a:58:0a:f4:02:05, ethertype IPv4 (0x0800), length 74: (tos 0x0, ttl 64, id 0, offset 0, flags [DF], proto TCP (6), length 60)\n    10.244.1.3.45678 > 10.244.2.5.8080: Flags [S], cksum 0x1c46 (correct), seq 1234567890, win 64860, length 0\n...",
+  "stderr": "tcpdump: listening on eth0, link-type EN10MB (Ethernet), snapshot length 96 bytes\n2 packets captured",
+  "packets": {
+    "records": [
+      {"timestamp": "10:15:00.250000", "interface": "eth0", "src_mac": "0a:58:0a:f4:01:03", "dst_mac": "0a:58:0a:f4:02:05", "protocol": "tcp",
+       "src": "10.244.1.3", "dst": "10.244.2.5", "src_port": 45678, "dst_port": 8080, "tcp_flags": ["SYN"], "seq": 1234567890, "length": 74, "payload_length": 0},
+      {"timestamp": "10:15:00.250100", "interface": "eth0", "src_mac": "0a:58:0a:f4:02:05", "dst_mac": "0a:58:0a:f4:01:03", "protocol": "tcp",
+       "src": "10.244.2.5", "dst": "10.244.1.3", "src_port": 8080, "dst_port": 45678, "tcp_flags": ["RST", "ACK"], "seq": 0, "ack": 1234567891, "length": 54, "payload_length": 0}
+    ],
+    "top_talkers": [{"protocol": "tcp", "src": "10.244.1.3:45678", "dst": "10.244.2.5:8080", "packets": 1, "bytes": 74}, {"protocol": "tcp", "src": "10.244.2.5:8080", "dst": "10.244.1.3:45678", "packets": 1, "bytes": 54}],
+    "tcp_resets": [{"timestamp": "10:15:00.250100", "src": "10.244.2.5:8080", "dst": "10.244.1.3:45678"}],
+    "retransmissions": [],
+    "icmp_unreachables": []
+  }
+}
+
+Example output (mode='pcap'):
+{
+  "output": "",
+  "stderr": "tcpdump: listening on genev_sys_6081, link-type EN10MB (Ethernet), snapshot length 1500 bytes\n100 packets captured",
+  "pcap": {
+    "uri": "pcap://tcpdump/20260101T101500Z-1a2b3c4d", "mime_type": "application/vnd.tcpdump.pcap", "size": 48210,
+    "summary": {
+      "link_type": "ethernet", "packets": 100, "bytes": 46586, "start": "2026-01-01T10:15:00.1Z", "end": "2026-01-01T10:15:02.4Z",
+      "protocols": [{"protocol": "tcp", "packets": 96, "bytes": 46250}, {"protocol": "arp", "packets": 4, "bytes": 336}],
+      "conversations": [{"protocol": "tcp", "a": "10.244.1.3:45678", "b": "10.244.2.5:8080", "packets": 96, "bytes": 46250}]
+    }
+  }
+}`,
+				maxTopTalkers, maxPacketEvents, maxPcapConversations, int(timeout.MaxTimeout.Seconds())),
 		}, s.Tcpdump)
+	server.AddResourceTemplate(
+		&mcp.ResourceTemplate{
+			Name:        "tcpdump-pcap",
+			Title:       "tcpdump capture",
+			Description: "Packet capture in pcap format written by the tcpdump tool with mode 'pcap'. Captures are kept in memory until the pcap store is full.",
+			MIMEType:    pcapMIMEType,
+			URITemplate: pcapURIPrefix + "{id}",
+		}, s.ReadPcap)
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "capture-pod-path",
+			Description: fmt.Sprintf(`Capture packets at the same time at every point of the path between two pods, and report the last point where each packet was seen.
+
+Packets are captured with tcpdump, with the same BPF filter and time window, at up to four points:
+- src_pod: the interface of the source pod (pod exec, the container needs tcpdump and GNU timeout)
+- src_node: the node interface of the source node (debug pod)
+- dst_node: the node interface of the destination node (debug pod)
+- dst_pod: the interface of the destination pod (pod exec)
+When both pods run on the same node, only the pods are captured, unless node_interface or src_node_interface is set:
+the node is then captured at src_node. Set debug_container to capture the pods from
+ephemeral debug containers with the tcpdump image, when their containers do not have tcpdump.
+
+Packets are correlated across the points by their TCP sequence and acknowledgement numbers, flags and length, or by their
+IPv4 ID for other protocols. Other IP packets, such as IPv6 UDP or ICMP packets, are correlated by their endpoints, payload
+length and ICMP message, in their order at each point. Packets sent by the destination pod are replies crossing the points in the reverse order. The timestamp of a packet is the
+time it was captured at the first point of its path, by the clock of the node of that point.
+A packet last seen before the end of its path was lost after that point, or the capture of the next point started late:
+compare the first and last timestamps of the points.
+
+Parameters:
+- src_namespace, src_pod: Source pod (required)
+- dst_namespace, dst_pod: Destination pod (required)
+- src_container, dst_container: Containers of the pods to run tcpdump in (optional, default container if not specified)
+- debug_container (optional): Capture the pods from ephemeral debug containers instead of src_container and dst_container (default: false)
+- pod_interface: Interface captured in the pods (default: '%s')
+- node_interface: Interface captured on the nodes (default: '%s', the OVS Geneve interface). Packets leaving the node
+  through the uplink, such as with the local gateway mode or egress IPs, are not seen on the Geneve interface: capture
+  the uplink, such as 'breth0', where Geneve packets are decoded and correlated by their inner headers.
+- src_node_interface, dst_node_interface (optional): Interface captured on the source or the destination node, replacing
+  node_interface, such as the uplink of one node: {"src_node_interface": "breth0"}
+- node_pod_namespace: Namespace of the node debug pods (optional, default: 'default')
+- bpf_filter: BPF filter expression (optional). Default: the packets between the pod IPs, encapsulated in Geneve or not:
+  '(host SRC and host DST) or (geneve and host SRC and host DST)'
+- packet_count: Maximum number of packets to capture at each point (default: 100, max: 1000)
+- snaplen: Snapshot length in bytes (default: 96, max: 1500)
+- duration_seconds: Time window of the captures (default: %d). Must leave %d seconds to check tcpdump and start the
+  captures before timeout_seconds when set, and otherwise before the tool timeout of the server, %d seconds.
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+Examples:
+- {"src_namespace": "default", "src_pod": "client", "dst_namespace": "default", "dst_pod": "server"}
+- From debug containers: {"src_namespace": "default", "src_pod": "client", "dst_namespace": "default", "dst_pod": "server", "debug_container": true}
+- {"src_namespace": "default", "src_pod": "client", "dst_namespace": "default", "dst_pod": "server", "bpf_filter": "tcp port 8080", "duration_seconds": 20}
+
+Example output:
+{
+  "bpf_filter": "(host 10.244.1.3 and host 10.244.2.5) or (geneve and host 10.244.1.3 and host 10.244.2.5)",
+  "duration_seconds": 10,
+  "points": [
+    {"name": "src_pod", "target": "pod default/client", "via": "exec", "interface": "eth0", "packets": 2, "first": "10:15:00.000100", "last": "10:15:01.000100"},
+    {"name": "src_node", "target": "node ovn-worker", "via": "debug_pod", "interface": "genev_sys_6081", "packets": 2, "first": "10:15:00.000150", "last": "10:15:01.000150"},
+    {"name": "dst_node", "target": "node ovn-worker2", "via": "debug_pod", "interface": "genev_sys_6081", "packets": 0},
+    {"name": "dst_pod", "target": "pod default/server", "via": "exec", "interface": "eth0", "packets": 0}
+  ],
+  "packets": [
+    {"direction": "forward", "timestamp": "10:15:00.000100", "protocol": "tcp", "src": "10.244.1.3:45678", "dst": "10.244.2.5:8080",
+     "tcp_flags": ["SYN"], "seq": 1234567890, "payload_length": 0, "seen_at": ["src_pod", "src_node"], "last_seen": "src_node"}
+  ],
+  "last_seen": {"src_node": 1},
+  "uncorrelated": 0
+}`,
+				DefaultPathCapturePodInterface, DefaultPathCaptureNodeInterface, DefaultPathCaptureDuration, int(pathCaptureSetupTime.Seconds()), timeout.DurationLimit(s.cfg.ToolTimeout), int(timeout.MaxTimeout.Seconds())),
+		}, s.PathCapture)
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "ovs-mirror-capture",
+			Description: fmt.Sprintf(`Capture the packets of an OVS port through a temporary OVS mirror.
+
+tcpdump cannot capture some OVS ports, such as the patch ports between br-int and the gateway bridge, or sees packets
+only after they leave OVS. This tool creates, in a node debug pod, an internal output port and a mirror selecting the
+packets sent and received by the port on its bridge, captures the output port with tcpdump, and removes the mirror and
+its output port afterwards. If the capture fails or times out, the mirror is removed from a new debug pod. The mirror
+and its output port are named 'mcpm' followed by 8 hex characters. The debug pod runs ovs-vsctl of its image, or else
+of the host, against the OVS database socket of the host, and the tool fails when neither is available.
+
+The port is given by name on a node, or is the OVS port of a pod, found by its OVN iface-id '<namespace>_<name>'.
+Packets are parsed into records as in the tcpdump tool.
+
+Parameters:
+- port: Name of the OVS port to capture (required with node_name, unless pod_name is specified), e.g. 'patch-br-int-to-breth0_worker-1', 'ovn-k8s-mp0'
+- node_name: Name of the node of the port
+- pod_namespace, pod_name: Pod whose OVS port is captured, on its node (instead of port)
+- node_pod_namespace (optional): Namespace of the debug pod. Default: 'default'
+- bpf_filter: BPF filter expression (optional, e.g. "tcp port 8080", "icmp")
+- packet_count: Maximum number of packets to capture (default: 100, max: 1000)
+- snaplen: Snapshot length in bytes (default: 96, max: 1500)
+- duration_seconds: Time window of the capture (default: %d). Must leave %d seconds to set up the mirror before
+  timeout_seconds when set, and otherwise before the tool timeout of the server, %d seconds.
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+Examples:
+- Patch port: {"node_name": "worker-1", "port": "patch-br-int-to-breth0_worker-1", "bpf_filter": "tcp port 8080"}
+- Pod port: {"pod_namespace": "default", "pod_name": "client", "duration_seconds": 20}
+
+Example output:
+{
+  "node": "worker-1",
+  "port": "patch-br-int-to-breth0_worker-1",
+  "bridge": "br-int",
+  "mirror": "mcpm1a2b3c4d",
+  "duration_seconds": 10,
+  "packets": {
+    "records": [
+      {"timestamp": "10:15:00.250000", "interface": "mcpm1a2b3c4d", "src_mac": "0a:58:a9:fe:01:01", "dst_mac": "02:42:ac:12:00:02", "protocol": "tcp",
+       "src": "172.18.0.2", "dst": "10.244.2.5", "src_port": 45678, "dst_port": 8080, "tcp_flags": ["SYN"], "seq": 1234567890, "length": 74, "payload_length": 0}
+    ],
+    "top_talkers": [{"protocol": "tcp", "src": "172.18.0.2:45678", "dst": "10.244.2.5:8080", "packets": 1, "bytes": 74}],
+    "tcp_resets": [],
+    "retransmissions": [],
+    "icmp_unreachables": []
+  },
+  "stderr": "tcpdump: listening on mcpm1a2b3c4d, link-type EN10MB (Ethernet), snapshot length 96 bytes\n1 packet captured"
+}`,
+				DefaultMirrorCaptureDuration, int(timeout.SetupTime.Seconds()), timeout.DurationLimit(s.cfg.ToolTimeout), int(timeout.MaxTimeout.Seconds())),
+		}, s.MirrorCapture)
 	mcp.AddTool(server,
 		&mcp.Tool{
 			Name: "pwru",
@@ -94,4 +329,320 @@ Examples:
 - ICMP packets: {"node_name": "worker-1", "bpf_filter": "icmp", "output_limit_lines": 100}`,
 				int(timeout.MaxTimeout.Seconds())),
 		}, s.Pwru)
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "retis",
+			Description: fmt.Sprintf(`Trace packets through the kernel networking stack, the OVS datapath and nftables using retis.
+
+Unlike pwru, retis sees the OVS datapath upcalls, the datapath flows matched by the packets and their actions, the
+nftables rules matched by the packets, and the reason packets are dropped. It tracks a packet across its clones and
+copies, so events are grouped by packet into timelines.
+
+This tool creates a debug pod on the specified node running 'retis collect' with the skb, skb-drop, skb-tracking, ovs
+and nft collectors, OVS upcall tracking and flow enrichment, for duration_seconds, or until one more event than
+event_limit is collected. The nft collector installs a temporary nftables table for its probes.
+
+Each packet holds its tracking ID, protocol and addresses from the first event with IP headers, the interfaces it was
+seen on in order, the OVS datapath flows it matched (unique flow ID, match and actions), its drop reason (kernel drop
+reason, or the OVS drop action) and its events ordered by time: probe, interface, OVS event, nftables
+table/chain/verdict and drop reason. OVS userspace events are not tied to a packet and are counted as untracked.
+
+Parameters:
+- node_name: Name of the node to run retis on (required)
+- node_pod_namespace (optional): Namespace of the debug pod on which the command is expected to be executed. Default: 'default'
+- bpf_filter: BPF filter expression to match packets (optional, e.g., "tcp and dst port 8080", "host 10.0.0.1")
+- event_limit: Maximum number of events returned (default: %d, max: %d). The collection stops early and truncated is
+  true when more are collected.
+- duration_seconds: Time window of the collection (default: %d). Must leave %d seconds to start retis before
+  timeout_seconds when set, and otherwise before the tool timeout of the server, %d seconds.
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+Examples:
+- Trace a flow: {"node_name": "worker-1", "bpf_filter": "host 10.244.2.5 and tcp port 8080"}
+- Drops during 30 seconds: {"node_name": "worker-1", "bpf_filter": "icmp", "duration_seconds": 30, "timeout_seconds": 90}
+
+Example output:
+{
+  "node": "worker-1",
+  "duration_seconds": 10,
+  "events": 4,
+  "packets": [
+    {
+      "tracking_id": "ffff8f0a41c2e000-23868034883", "protocol": "tcp", "src": "10.244.1.3:45678", "dst": "10.244.2.5:8080",
+      "interfaces": ["eth0", "genev_sys_6081"],
+      "ovs_flows": ["ufid:5b1e2a3c-8f7d-4c1e-9a0b-1c2d3e4f5a6b recirc_id(0),in_port(2),eth_type(0x0800),ipv4(dst=10.244.2.5) actions:drop"],
+      "drop_reason": "ovs: action_execute action=drop recirc_id=0",
+      "events": [
+        {"timestamp": 23868034883, "probe": "raw_tracepoint:net:netif_receive_skb", "interface": "eth0"},
+        {"timestamp": 23868041220, "probe": "raw_tracepoint:openvswitch:ovs_dp_upcall", "interface": "genev_sys_6081", "ovs": "upcall cmd=1 cpu=0 port=2"},
+        {"timestamp": 23868090112, "probe": "kprobe:ovs_execute_actions", "ovs": "action_execute action=drop recirc_id=0"}
+      ]
+    }
+  ],
+  "untracked": 1
+}`,
+				DefaultRetisEventLimit, MaxRetisEventLimit, DefaultRetisDuration, int(timeout.SetupTime.Seconds()), timeout.DurationLimit(s.cfg.ToolTimeout), int(timeout.MaxTimeout.Seconds())),
+		}, s.Retis)
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "connectivity-probe",
+			Description: fmt.Sprintf(`Actively probe the connectivity from a pod or a node to a pod, a service, a cluster IP, the node port of a
+service, or an external host, and classify failures.
+
+The probe runs ping (icmp), a TCP connect with nc (tcp), an HTTP GET with curl (http) or a UDP datagram waiting for a
+reply with nc (udp). Pod targets are resolved to the pod IP, service targets to the cluster IP and the service port,
+and node port targets to the internal IP of target_node and the node port of the service port.
+
+From a pod, the probe runs in the source container with exec. Containers without a shell or the probe command report
+error_class 'tool_missing'; set debug_container to run the probe in an ephemeral debug container with the tcpdump
+image sharing the network namespace of the pod. Ephemeral containers cannot be removed, so the debug container exits
+after an hour and is reused until then. From a node, the probe runs in a debug pod with the tcpdump image on the
+host network.
+
+Parameters:
+- source_namespace, source_pod: Pod to probe from. Exactly one of source_pod or source_node is required.
+- source_container (optional): Container of the source pod. Default: the first container
+- debug_container (optional): Probe from an ephemeral debug container of the source pod (default: false)
+- source_node: Node to probe from
+- node_pod_namespace (optional): Namespace of the debug pod on which the command is expected to be executed. Default: 'default'
+- target_type: 'pod', 'service', 'cluster_ip', 'nodeport' or 'host' (required)
+- target_namespace, target_name: Pod or service for the 'pod', 'service' and 'nodeport' targets
+- target_node: Node of the 'nodeport' target
+- target_address: IP address for 'cluster_ip', IP address or host name for 'host'
+- port: Port of the pod, cluster IP or host, or service port of the service or node port. Required except for icmp and
+  services, which default to their first port.
+- protocol: 'icmp', 'tcp', 'http' or 'udp' (required)
+- http_path (optional): Path of the HTTP request. Default: '/'
+- count (optional): Number of ICMP echo requests (default: %d, max: %d)
+- probe_timeout_seconds (optional): Timeout of the probe in seconds (default: %d, max: %d)
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+Error classes: 'dns' (host name not resolved), 'refused' (connection refused or port unreachable), 'timeout',
+'unreachable' (no route to host), 'no_reply' (UDP datagram sent without reply), 'http_status' (HTTP status 400 or
+more), 'tool_missing' (shell or probe command missing in the container) and 'error'.
+
+Examples:
+- Pod to pod TCP: {"source_namespace": "default", "source_pod": "client", "target_type": "pod", "target_namespace": "default", "target_name": "server", "port": 8080, "protocol": "tcp"}
+- Pod to service HTTP from a debug container: {"source_namespace": "default", "source_pod": "client", "debug_container": true, "target_type": "service", "target_namespace": "default", "target_name": "web", "protocol": "http", "http_path": "/healthz"}
+- Node to node port: {"source_node": "worker-1", "target_type": "nodeport", "target_namespace": "default", "target_name": "web", "target_node": "worker-2", "port": 80, "protocol": "tcp"}
+- Pod to external host: {"source_namespace": "default", "source_pod": "client", "target_type": "host", "target_address": "example.com", "protocol": "icmp"}
+
+Example output:
+{
+  "source": "pod default/client",
+  "via": "exec",
+  "target": "service default/web",
+  "protocol": "tcp",
+  "address": "10.96.10.20",
+  "port": 80,
+  "success": false,
+  "error_class": "refused",
+  "error": "nc: connect to 10.96.10.20 port 80 (tcp) failed: Connection refused",
+  "output": "nc: connect to 10.96.10.20 port 80 (tcp) failed: Connection refused"
+}`,
+				DefaultProbeCount, MaxProbeCount, DefaultProbeTimeout, MaxProbeTimeout, int(timeout.MaxTimeout.Seconds())),
+		}, s.ConnectivityProbe)
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "connectivity-matrix",
+			Description: fmt.Sprintf(`Probe the connectivity between one pod per node, and from these pods to services and external endpoints,
+and return a matrix with the failures grouped by node pair and direction.
+
+A broken node, such as a node with a broken Geneve tunnel or gateway, shows up as a row and a column of failures in
+the matrix, and as a suspect. The pods are given, or one running pod in the pod network is picked per node from
+namespace and label_selector, such as the pods of a DaemonSet. Probes run from the pods like connectivity-probe:
+pod exec, or an ephemeral debug container with the tcpdump image when debug_container is set. At most concurrency
+probes run at the same time.
+
+Probes between pods use protocol on pod_port. Services are probed with a TCP connect to their cluster IP and port.
+External endpoints are probed with ICMP, or with a TCP connect when they have a port.
+
+The result holds:
+- matrix: one row per source node, with the result of each probe keyed by target node, service or endpoint: 'ok' or
+  the error class (see connectivity-probe)
+- pair_failures: failed probes between the pods of two nodes, with direction 'both' or '<source> -> <target>'
+- target_failures: services and endpoints with failed probes, with the nodes they failed from
+- suspects: nodes whose probes from or to other pods, or to services and endpoints, all fail while the probes of
+  other nodes succeed
+
+Parameters:
+- pods (optional): Pods of the matrix, one per node: [{"namespace", "name", "container"}]
+- namespace, label_selector: Namespace and label selector of the pods picked when pods is not given. At least one is
+  required.
+- nodes (optional): Nodes of the matrix. Default: the nodes of the pods
+- debug_container (optional): Probe from ephemeral debug containers of the pods (default: false)
+- protocol (optional): Protocol of the probes between pods: 'icmp', 'tcp', 'http' or 'udp' (default: 'icmp')
+- pod_port: Port of the probes between pods. Required except for icmp.
+- services (optional): Services to probe: [{"namespace", "name", "port"}]. Default port: the first port
+- external_endpoints (optional): External endpoints to probe: [{"address", "port"}]
+- count (optional): Number of ICMP echo requests of each probe (default: %d, max: %d)
+- probe_timeout_seconds (optional): Timeout of each probe in seconds (default: %d, max: %d)
+- concurrency (optional): Number of probes run at the same time (default: %d, max: %d)
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+At most %d nodes, and %d services and external endpoints, are probed.
+
+Examples:
+- Pods of a DaemonSet: {"namespace": "netshoot", "label_selector": "app=netshoot"}
+- With services and external endpoints: {"namespace": "netshoot", "label_selector": "app=netshoot", "services": [{"namespace": "default", "name": "kubernetes"}], "external_endpoints": [{"address": "8.8.8.8"}, {"address": "example.com", "port": 443}]}
+- TCP between given pods: {"pods": [{"namespace": "default", "name": "web-1"}, {"namespace": "default", "name": "web-2"}], "protocol": "tcp", "pod_port": 8080}
+
+Example output:
+{
+  "pods": [
+    {"node": "worker-1", "pod": "netshoot/netshoot-4x7kq", "ip": "10.244.1.5"},
+    {"node": "worker-2", "pod": "netshoot/netshoot-9bz2m", "ip": "10.244.2.5"},
+    {"node": "worker-3", "pod": "netshoot/netshoot-tq8lw", "ip": "10.244.3.5"}
+  ],
+  "protocol": "icmp",
+  "probes": 9,
+  "failed": 5,
+  "matrix": [
+    {"node": "worker-1", "results": {"host 8.8.8.8": "ok", "worker-2": "ok", "worker-3": "timeout"}},
+    {"node": "worker-2", "results": {"host 8.8.8.8": "ok", "worker-1": "ok", "worker-3": "timeout"}},
+    {"node": "worker-3", "results": {"host 8.8.8.8": "timeout", "worker-1": "timeout", "worker-2": "timeout"}}
+  ],
+  "pair_failures": [
+    {"node_a": "worker-1", "node_b": "worker-3", "direction": "both", "error_classes": ["timeout"], "errors": ["1 packets transmitted, 0 received, 100%% packet loss, time 0ms"]},
+    {"node_a": "worker-2", "node_b": "worker-3", "direction": "both", "error_classes": ["timeout"], "errors": ["1 packets transmitted, 0 received, 100%% packet loss, time 0ms"]}
+  ],
+  "target_failures": [
+    {"target": "host 8.8.8.8", "failed_from": ["worker-3"], "succeeded_on": 2, "error_classes": ["timeout"], "errors": ["1 packets transmitted, 0 received, 100%% packet loss, time 0ms"]}
+  ],
+  "suspects": [
+    {"node": "worker-3", "reason": "all probes from and to the pod of the node failed; all probes to services and external endpoints failed"}
+  ]
+}`,
+				DefaultMatrixProbeCount, MaxProbeCount, DefaultMatrixProbeTimeout, MaxProbeTimeout, DefaultMatrixConcurrency, MaxMatrixConcurrency,
+				int(timeout.MaxTimeout.Seconds()), MaxMatrixPods, MaxMatrixTargets),
+		}, s.ConnectivityMatrix)
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "dns-check",
+			Description: fmt.Sprintf(`Troubleshoot the DNS resolution of a name from a pod, telling DNS failures from OVN load balancer and ACL
+failures.
+
+The tool reads /etc/resolv.conf of the pod, and builds the names the resolver of the pod queries from its search
+domains and ndots. It queries them in order with dig from the pod, until one has answers, against each path:
+- nameserver: the nameservers of resolv.conf
+- service: the cluster IPs of the DNS service
+- endpoint: each ready DNS pod, on its target port
+The pod needs cat and dig; set debug_container to run them in an ephemeral debug container with the tcpdump image.
+
+It also compares the backends of the DNS service VIPs in the OVN load balancers applied to the logical switch of the node
+of the pod, directly or through load balancer groups, with the ready DNS pods. The load balancers are read with ovn-nbctl
+in the ovnkube-node pod of the node, which holds the northbound database of the node with OVN interconnect, or in
+ovnkube_pod. A VIP in none of these load balancers is reported with found false. The findings compare the paths: DNS pods
+replying while the service VIP does not point to the load balancer, nothing replying points to ACLs or network
+policies, and every server replying without records means the name does not resolve.
+
+Parameters:
+- namespace, pod: Pod to resolve from (required)
+- container (optional): Container of the pod. Default: the first container
+- debug_container (optional): Query from an ephemeral debug container of the pod (default: false)
+- name: Name to resolve (required)
+- query_type (optional): 'A', 'AAAA', 'CNAME', 'SRV', 'TXT', 'MX' or 'NS' (default: 'A')
+- dns_service_namespace, dns_service_name (optional): DNS service. Default: '%s', '%s'
+- ovnkube_namespace (optional): Namespace of the ovnkube-node pods. Default: '%s'
+- ovnkube_pod (optional): Pod in ovnkube_namespace running ovn-nbctl against the northbound database, for clusters
+  with a central database. Default: the ovnkube-node pod of the node of the pod
+- ovnkube_container (optional): Container of the ovnkube pod running ovn-nbctl. Default: the first container
+- query_timeout_seconds (optional): Timeout of each query (default: %d, max: %d)
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+Examples:
+- Service name: {"namespace": "default", "pod": "client", "name": "web.prod"}
+- External name from a debug container: {"namespace": "default", "pod": "client", "name": "example.com", "query_type": "AAAA", "debug_container": true}
+- OpenShift: {"namespace": "default", "pod": "client", "name": "kubernetes.default", "dns_service_namespace": "openshift-dns", "dns_service_name": "dns-default", "ovnkube_namespace": "openshift-ovn-kubernetes"}
+
+Example output:
+{
+  "pod": "default/client",
+  "via": "exec",
+  "name": "kubernetes.default",
+  "query_type": "A",
+  "resolv_conf": {"nameservers": ["10.96.0.10"], "search": ["default.svc.cluster.local", "svc.cluster.local", "cluster.local"], "ndots": 5, "options": ["ndots:5"]},
+  "candidates": ["kubernetes.default.default.svc.cluster.local.", "kubernetes.default.svc.cluster.local.", "kubernetes.default.cluster.local.", "kubernetes.default."],
+  "paths": [
+    {"kind": "nameserver", "server": "10.96.0.10:53", "answered": false, "rcode": "TIMEOUT", "queries": [{"name": "kubernetes.default.default.svc.cluster.local.", "rcode": "TIMEOUT", "error": ";; no servers could be reached"}]},
+    {"kind": "service", "server": "10.96.0.10:53", "answered": false, "rcode": "TIMEOUT", "queries": [{"name": "kubernetes.default.default.svc.cluster.local.", "rcode": "TIMEOUT", "error": ";; no servers could be reached"}]},
+    {"kind": "endpoint", "server": "10.244.0.3:53", "pod": "kube-system/coredns-7db6d8ff4d-5x2kq", "answered": true, "rcode": "NOERROR",
+     "answers": ["kubernetes.default.svc.cluster.local. A 10.96.0.1"],
+     "queries": [{"name": "kubernetes.default.default.svc.cluster.local.", "rcode": "NXDOMAIN", "time_ms": 1}, {"name": "kubernetes.default.svc.cluster.local.", "rcode": "NOERROR", "answers": ["kubernetes.default.svc.cluster.local. A 10.96.0.1"], "time_ms": 1}]}
+  ],
+  "load_balancer": {
+    "ovnkube_pod": "ovn-kubernetes/ovnkube-node-8xq2v",
+    "healthy": false,
+    "vips": [
+      {"load_balancer": "Service_kube-system/kube-dns_UDP_cluster", "protocol": "udp", "vip": "10.96.0.10:53", "found": true, "backends": [], "missing": ["10.244.0.3:53"]}
+    ]
+  },
+  "findings": [
+    "the OVN load balancer of the DNS service does not match the ready DNS pods: udp VIP 10.96.0.10:53 misses backends [10.244.0.3:53]",
+    "the DNS pods [kube-system/coredns-7db6d8ff4d-5x2kq] reply but the DNS service VIP [10.96.0.10:53] does not: the failure is in the OVN load balancer of the service"
+  ]
+}`,
+				DefaultDNSServiceNamespace, DefaultDNSServiceName, DefaultOVNKubeNamespace, DefaultDNSQueryTimeout, MaxProbeTimeout,
+				int(timeout.MaxTimeout.Seconds())),
+		}, s.DNSCheck)
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "path-mtu",
+			Description: fmt.Sprintf(`Discover the path MTU from a pod or a node to a pod, a node or an IP address, and report it next to the MTUs
+configured on the nodes.
+
+The tool pings the target with the don't fragment bit, first with the smallest packet size (576 bytes for IPv4, 1280
+for IPv6), then with the largest (max_mtu, or the MTU of the interface of the route of the source to the target), and
+binary searches the largest size that gets through. Sizes are IP packet sizes, including the IP and ICMP headers.
+When the source has no route to the target, no ping runs and error_class is 'no_route'. Pings refused with a fragmentation needed or packet too big error report the MTU of the hop.
+
+It also gathers the MTUs of the nodes of the source and the target from a debug pod: ovn-k8s-mp0 (the pod MTU),
+br-ex, the Geneve interface and the uplink of br-ex from the kernel, the uplink and the encap IP from OVS, and the
+Geneve overhead of the encap IP. The findings flag paths dropping large packets without an error, pod MTUs plus the
+Geneve overhead exceeding the uplink MTU, br-ex and uplink MTU mismatches, and different pod MTUs across nodes.
+
+The source must be a running pod or a node, and needs ip and ping supporting -M do (iputils); set debug_container to ping from an ephemeral debug container with
+the tcpdump image.
+
+Parameters:
+- Source, exactly one of:
+  - source_namespace, source_pod: Pod to ping from, with source_container (optional) or debug_container (optional)
+  - source_node: Node to ping from, in a debug pod in the host network, with node_pod_namespace (optional)
+- target_type: 'pod', 'node' or 'host' (required)
+  - pod: target_namespace, target_name
+  - node: target_node, pinged on its internal IP
+  - host: target_address, an IP address
+- max_mtu (optional): Largest packet size probed (max: %d). Default: the MTU of the interface of the route
+- probe_timeout_seconds (optional): Timeout of each ping (default: %d, max: %d)
+- timeout_seconds (optional): Timeout in seconds for the command execution. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+Examples:
+- Pod to pod: {"source_namespace": "default", "source_pod": "client", "target_type": "pod", "target_namespace": "default", "target_name": "server"}
+- Pod to external IP from a debug container: {"source_namespace": "default", "source_pod": "client", "debug_container": true, "target_type": "host", "target_address": "203.0.113.10"}
+- Node to node with jumbo frames: {"source_node": "ovn-worker", "target_type": "node", "target_node": "ovn-worker2", "max_mtu": 9000}
+
+Example output:
+{
+  "source": "pod default/client",
+  "via": "exec",
+  "target": "pod default/server",
+  "address": "10.244.2.5",
+  "device": "eth0",
+  "device_mtu": 1500,
+  "reachable": true,
+  "path_mtu": 1442,
+  "smallest_failed": 1443,
+  "probes": [{"size": 576, "passed": true}, {"size": 1500, "passed": false, "error": "no reply"}, {"size": 1038, "passed": true}, ...],
+  "nodes": [
+    {"node": "ovn-worker", "interfaces": {"br-ex": 1500, "eth0": 1500, "genev_sys_6081": 65000, "ovn-k8s-mp0": 1500}, "uplink": "eth0", "encap_ip": "172.18.0.3", "geneve_overhead": 58},
+    {"node": "ovn-worker2", "interfaces": {"br-ex": 1500, "eth0": 1500, "genev_sys_6081": 65000, "ovn-k8s-mp0": 1500}, "uplink": "eth0", "encap_ip": "172.18.0.4", "geneve_overhead": 58}
+  ],
+  "findings": [
+    "packets of up to 1442 bytes get through but packets of 1443 bytes do not, below the MTU 1500 of eth0 of the source: no hop reported a smaller MTU, so larger packets are dropped silently and TCP connections hang once they send full-size segments; check the MTU of the underlay, and that ICMP fragmentation needed errors are not filtered",
+    "on node ovn-worker, the pod MTU 1500 of ovn-k8s-mp0 plus the Geneve overhead of 58 bytes exceeds the MTU 1500 of the uplink eth0: full-size pod packets between nodes are dropped once encapsulated; lower the MTU of the cluster network or raise the MTU of the underlay",
+    "on node ovn-worker2, the pod MTU 1500 of ovn-k8s-mp0 plus the Geneve overhead of 58 bytes exceeds the MTU 1500 of the uplink eth0: full-size pod packets between nodes are dropped once encapsulated; lower the MTU of the cluster network or raise the MTU of the underlay"
+  ]
+}`,
+				MaxPathMTU, DefaultPathMTUProbeTimeout, MaxProbeTimeout, int(timeout.MaxTimeout.Seconds())),
+		}, s.PathMTU)
 }
@@ -0,0 +1,215 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/timeout"
+)
+
+const (
+	// DefaultMirrorCaptureDuration is the number of seconds packets are captured for when no
+	// duration is given.
+	DefaultMirrorCaptureDuration = 10
+
+	// mirrorNamePrefix starts the name of the mirrors and of their output ports, followed by
+	// random hex characters to stay within the 15 characters of an interface name.
+	mirrorNamePrefix = "mcpm"
+	// mirrorCleanupTimeout bounds the removal of a mirror after a failed or cancelled capture.
+	mirrorCleanupTimeout = 30 * time.Second
+	// mirrorOutputPrefix starts the line of the capture script holding the port and its bridge.
+	mirrorOutputPrefix = "### mirror "
+)
+
+// ovsPortNamePattern matches OVS port names, which unlike interface names can be longer than 15
+// characters, such as the patch ports between br-int and the gateway bridge.
+var ovsPortNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{0,254}$`)
+
+// ovsctlFunction defines the ovsctl shell function of the scripts running in a debug pod of a
+// node, connected to the OVS database socket of the host. ovsctl runs ovs-vsctl of the image, or
+// else of the host. When the socket or ovs-vsctl is missing, such as when OVS runs only in the
+// ovnkube-node or ovs-node pod without ovs-vsctl on the host, the script prints the reason after
+// ovsctlErrorPrefix and exits.
+const ovsctlFunction = `if [ ! -S /host/var/run/openvswitch/db.sock ]; then
+	echo "### ovsctl-error the OVS database socket /var/run/openvswitch/db.sock is not on the host"
+	exit 0
+fi
+if command -v ovs-vsctl >/dev/null 2>&1; then
+	ovsctl() { ovs-vsctl --timeout=5 --db=unix:/host/var/run/openvswitch/db.sock "$@"; }
+elif chroot /host sh -c 'command -v ovs-vsctl' >/dev/null 2>&1; then
+	ovsctl() { chroot /host ovs-vsctl --timeout=5 "$@"; }
+else
+	echo "### ovsctl-error ovs-vsctl is neither in the image of the debug pod nor on the host"
+	exit 0
+fi
+`
+
+// ovsctlErrorPrefix starts the line of the output of ovsctlFunction telling why ovs-vsctl cannot
+// run on the node.
+const ovsctlErrorPrefix = "### ovsctl-error "
+
+// ovsctlError returns the error reported by ovsctlFunction in the output of a script, if any.
+func (s *MCPServer) ovsctlError(stdout, node string) error {
+	for line := range strings.Lines(stdout) {
+		if reason, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), ovsctlErrorPrefix); ok {
+			return fmt.Errorf("cannot run ovs-vsctl on node %s: %s; set --tcpdump-image to an image with ovs-vsctl (current: %s)",
+				node, reason, s.cfg.TcpdumpImage)
+		}
+	}
+	return nil
+}
+
+// ovsMirrorFunctions defines the shell functions of the mirror scripts. remove_mirror removes the
+// mirror of the name from every bridge, and its output port.
+const ovsMirrorFunctions = ovsctlFunction + `remove_mirror() {
+	for m in $(ovsctl --bare --columns=_uuid find Mirror "name=$1"); do
+		for br in $(ovsctl list-br); do
+			ovsctl remove Bridge "$br" mirrors "$m"
+		done
+	done
+	ovsctl --if-exists del-port "$1"
+}
+`
+
+// mirrorCaptureScript creates the mirror of the port on its bridge, captures on the output port
+// of the mirror, and removes the mirror when the script exits, including when it is interrupted.
+// The arguments are the port, the OVN iface-id of a pod whose port is looked up instead, the
+// mirror name, the duration, the snaplen, the packet count and the BPF filter.
+const mirrorCaptureScript = `port="$1"
+if [ -n "$2" ]; then
+	port=$(ovsctl --bare --columns=name find Interface "external_ids:iface-id=$2")
+	if [ -z "$port" ]; then
+		echo "no OVS interface with external_ids:iface-id=$2" >&2
+		exit 1
+	fi
+fi
+bridge=$(ovsctl port-to-br "$port") || exit 1
+trap 'remove_mirror "$3"' EXIT
+trap 'exit 130' INT TERM HUP
+ovsctl add-port "$bridge" "$3" -- set Interface "$3" type=internal || exit 1
+ovsctl -- --id=@p get Port "$port" -- --id=@o get Port "$3" \
+	-- --id=@m create Mirror "name=$3" select-src-port=@p select-dst-port=@p output-port=@o \
+	-- add Bridge "$bridge" mirrors @m >/dev/null || exit 1
+ip link set "$3" up || exit 1
+echo "### mirror $port $bridge"
+timeout --preserve-status -s INT "$4" tcpdump -n -e -S -v -s "$5" -c "$6" -i "$3" ${7:+"$7"}
+`
+
+// MirrorCapture captures the packets of an OVS port through a temporary mirror, for the ports
+// that tcpdump cannot capture on, such as the internal and patch ports of br-int. The mirror is
+// removed after the capture, and again from a new debug pod when the capture fails or is
+// cancelled.
+func (s *MCPServer) MirrorCapture(ctx context.Context, req *mcp.CallToolRequest, in types.MirrorCaptureParams) (*mcp.CallToolResult, types.MirrorCaptureResult, error) {
+	if (in.Port == "") == (in.PodName == "") {
+		return nil, types.MirrorCaptureResult{}, fmt.Errorf("exactly one of port or pod_name is required")
+	}
+	if in.Port != "" {
+		if in.NodeName == "" {
+			return nil, types.MirrorCaptureResult{}, fmt.Errorf("node_name is required with port")
+		}
+		if !ovsPortNamePattern.MatchString(in.Port) {
+			return nil, types.MirrorCaptureResult{}, fmt.Errorf("invalid port name: %s", in.Port)
+		}
+	}
+	if in.PodName != "" && in.PodNamespace == "" {
+		return nil, types.MirrorCaptureResult{}, fmt.Errorf("pod_namespace is required with pod_name")
+	}
+	if err := validatePacketFilter(in.BPFFilter); err != nil {
+		return nil, types.MirrorCaptureResult{}, err
+	}
+	packetCount := cmp.Or(in.PacketCount, DefaultPacketCount)
+	if err := validateIntMax(packetCount, MaxPacketCount, "packet_count", ""); err != nil {
+		return nil, types.MirrorCaptureResult{}, err
+	}
+	snaplen := cmp.Or(in.Snaplen, DefaultSnaplen)
+	if err := validateIntMax(snaplen, MaxSnaplen, "snaplen", "bytes"); err != nil {
+		return nil, types.MirrorCaptureResult{}, err
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	// The capture ends before the call times out, leaving time to start the debug pod and create the
+	// mirror, so that the packets are returned and the mirror is not left behind.
+	duration, err := timeout.ValidateDuration(ctx, "duration_seconds", in.DurationSeconds, DefaultMirrorCaptureDuration, timeout.SetupTime)
+	if err != nil {
+		return nil, types.MirrorCaptureResult{}, err
+	}
+
+	node, ifaceID := in.NodeName, ""
+	if in.PodName != "" {
+		pod, err := s.getPod(ctx, in.PodNamespace, in.PodName)
+		if err != nil {
+			return nil, types.MirrorCaptureResult{}, err
+		}
+		if pod.Spec.NodeName == "" {
+			return nil, types.MirrorCaptureResult{}, fmt.Errorf("pod %s/%s is not scheduled on a node", in.PodNamespace, in.PodName)
+		}
+		// OVN-Kubernetes sets the iface-id of the OVS interface of a pod to <namespace>_<name>.
+		node, ifaceID = pod.Spec.NodeName, in.PodNamespace+"_"+in.PodName
+	}
+	mirror, err := newMirrorName()
+	if err != nil {
+		return nil, types.MirrorCaptureResult{}, err
+	}
+
+	cmd := []string{"sh", "-c", ovsMirrorFunctions + mirrorCaptureScript, "sh", in.Port, ifaceID, mirror,
+		strconv.Itoa(duration), strconv.Itoa(snaplen), strconv.Itoa(packetCount), in.BPFFilter}
+	stdout, stderr, err := s.runDebugNodeCommand(ctx, in.NodePodNamespace, node, s.cfg.TcpdumpImage, cmd, "", "", 0)
+	if err == nil {
+		err = s.ovsctlError(stdout, node)
+	}
+	if err != nil {
+		// The script may have been stopped after creating the mirror, before removing it.
+		if cleanupErr := s.removeMirror(ctx, in.NodePodNamespace, node, mirror); cleanupErr != nil {
+			return nil, types.MirrorCaptureResult{}, fmt.Errorf("%w; failed to remove mirror %s: %v", err, mirror, cleanupErr)
+		}
+		return nil, types.MirrorCaptureResult{}, err
+	}
+
+	result := types.MirrorCaptureResult{Node: node, Port: in.Port, Mirror: mirror, DurationSeconds: duration, Stderr: stderr}
+	header, capture, _ := strings.Cut(stdout, "\n")
+	if fields := strings.Fields(strings.TrimPrefix(header, mirrorOutputPrefix)); strings.HasPrefix(header, mirrorOutputPrefix) && len(fields) == 2 {
+		result.Port, result.Bridge = fields[0], fields[1]
+	} else {
+		capture = stdout
+	}
+	result.Output = capture
+	result.Packets, result.Unparsed = parseTcpdumpText(capture, mirror)
+	return nil, result, nil
+}
+
+// newMirrorName returns a random name for a mirror and its output port.
+func newMirrorName() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate mirror name: %w", err)
+	}
+	return mirrorNamePrefix + hex.EncodeToString(suffix), nil
+}
+
+// removeMirror removes a mirror and its output port from a node. It runs after the context of the
+// capture is cancelled or expired, so it uses a context of its own.
+func (s *MCPServer) removeMirror(ctx context.Context, namespace, node, mirror string) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mirrorCleanupTimeout)
+	defer cancel()
+	cmd := []string{"sh", "-c", ovsMirrorFunctions + `remove_mirror "$1"`, "sh", mirror}
+	stdout, _, err := s.runDebugNodeCommand(ctx, namespace, node, s.cfg.TcpdumpImage, cmd, "", "", 0)
+	if err != nil {
+		return err
+	}
+	return s.ovsctlError(stdout, node)
+}
@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/timeout"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestMirrorCapture(t *testing.T) {
+	output := "### mirror 4b3a2c1d5e6f7a8 br-int\n" + fmt.Sprintf(pathSYN, 100) + "\n10:15:00.000200 STP 802.1d, Config BPDU"
+	tests := []struct {
+		name         string
+		in           types.MirrorCaptureParams
+		wantCommand  []string
+		wantPort     string
+		wantDuration int
+	}{
+		{
+			name: "port",
+			in: types.MirrorCaptureParams{
+				BaseNetworkDiagParams: types.BaseNetworkDiagParams{BPFFilter: "tcp port 8080"},
+				NodeName:              "ovn-worker2", Port: "patch-br-int-to-breth0_ovn-worker2", DurationSeconds: 5,
+			},
+			wantCommand:  []string{"ovn-worker2", "patch-br-int-to-breth0_ovn-worker2", "", "MIRROR", "5", "96", "100", "tcp port 8080"},
+			wantPort:     "4b3a2c1d5e6f7a8",
+			wantDuration: 5,
+		},
+		{
+			name:         "pod",
+			in:           types.MirrorCaptureParams{PodNamespace: "default", PodName: "client", PacketCount: 10, Snaplen: 1500},
+			wantCommand:  []string{"ovn-worker", "", "default_client", "MIRROR", "10", "1500", "10", ""},
+			wantPort:     "4b3a2c1d5e6f7a8",
+			wantDuration: DefaultMirrorCaptureDuration,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands [][]string
+			server := newFakeServer(t, Dependencies{
+				RunDebugNodeCommand: func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+					commands = append(commands, append([]string{nodeName}, cmd[4:]...))
+					return output, "1 packet captured", nil
+				},
+				GetPod: func(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+					return &corev1.Pod{Spec: corev1.PodSpec{NodeName: "ovn-worker"}}, nil
+				},
+			}, Config{TcpdumpImage: "netshoot"})
+			_, result, err := server.MirrorCapture(context.Background(), nil, tt.in)
+			if err != nil {
+				t.Fatalf("MirrorCapture() error = %v", err)
+			}
+			if !strings.HasPrefix(result.Mirror, mirrorNamePrefix) || len(result.Mirror) != len(mirrorNamePrefix)+8 {
+				t.Errorf("mirror = %q, want %s and 8 hex characters", result.Mirror, mirrorNamePrefix)
+			}
+			wantCommand := append([]string(nil), tt.wantCommand...)
+			wantCommand[3] = result.Mirror
+			if len(commands) != 1 || !reflect.DeepEqual(commands[0], wantCommand) {
+				t.Errorf("commands = %q, want %q", commands, wantCommand)
+			}
+			if result.Node != tt.wantCommand[0] || result.Port != tt.wantPort || result.Bridge != "br-int" || result.DurationSeconds != tt.wantDuration {
+				t.Errorf("result = %+v", result)
+			}
+			if len(result.Packets.Records) != 1 || result.Packets.Records[0].Interface != result.Mirror {
+				t.Errorf("records = %+v, want the SYN on the mirror port", result.Packets.Records)
+			}
+			if !strings.HasPrefix(result.Output, "10:15:00.000100 IP") {
+				t.Errorf("output = %q, want the output of tcpdump after the mirror", result.Output)
+			}
+			if !strings.HasPrefix(result.Unparsed, "10:15:00.000200 STP") {
+				t.Errorf("unparsed = %q, want the unparsed STP packet", result.Unparsed)
+			}
+		})
+	}
+}
+
+func TestMirrorCaptureFailures(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputs      []string
+		errs         []error
+		wantErr      []string
+		wantCommands int
+	}{
+		{
+			name:         "mirror removed",
+			errs:         []error{context.DeadlineExceeded},
+			wantErr:      []string{"context deadline exceeded"},
+			wantCommands: 2,
+		},
+		{
+			name:         "mirror not removed",
+			errs:         []error{context.DeadlineExceeded, fmt.Errorf("node not ready")},
+			wantErr:      []string{"context deadline exceeded; failed to remove mirror mcpm"},
+			wantCommands: 2,
+		},
+		{
+			name:         "ovs-vsctl error with the mirror removed",
+			outputs:      []string{ovsctlErrorPrefix + "ovs-vsctl is neither in the image of the debug pod nor on the host\n", ""},
+			wantErr:      []string{"cannot run ovs-vsctl on node ovn-worker: ovs-vsctl is neither in the image", "(current: netshoot)"},
+			wantCommands: 2,
+		},
+		{
+			name: "without ovs-vsctl",
+			outputs: []string{
+				ovsctlErrorPrefix + "ovs-vsctl is neither in the image of the debug pod nor on the host\n",
+				ovsctlErrorPrefix + "ovs-vsctl is neither in the image of the debug pod nor on the host\n",
+			},
+			wantErr:      []string{"cannot run ovs-vsctl on node ovn-worker: ovs-vsctl is neither in the image", "; failed to remove mirror mcpm"},
+			wantCommands: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands [][]string
+			outputs, errs := tt.outputs, tt.errs
+			server := newFakeServer(t, Dependencies{
+				RunDebugNodeCommand: func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+					commands = append(commands, append([]string{nodeName}, cmd[4:]...))
+					var output string
+					if len(outputs) > 0 {
+						output, outputs = outputs[0], outputs[1:]
+					}
+					var err error
+					if len(errs) > 0 {
+						err, errs = errs[0], errs[1:]
+					}
+					return output, "", err
+				},
+			}, Config{TcpdumpImage: "netshoot"})
+			_, _, err := server.MirrorCapture(context.Background(), nil, types.MirrorCaptureParams{NodeName: "ovn-worker", Port: "br-int"})
+			for _, want := range tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), want) {
+					t.Errorf("MirrorCapture() error = %v, want %q", err, want)
+				}
+			}
+			// A mirror that was created is removed from a new debug pod.
+			if len(commands) != tt.wantCommands || (tt.wantCommands == 2 && !reflect.DeepEqual(commands[1], []string{"ovn-worker", commands[0][3]})) {
+				t.Errorf("commands = %q, want %d commands", commands, tt.wantCommands)
+			}
+		})
+	}
+}
+
+func TestMirrorCaptureErrors(t *testing.T) {
+	server := newFakeServer(t, Dependencies{}, Config{})
+	tests := []struct {
+		name    string
+		in      types.MirrorCaptureParams
+		wantErr string
+	}{
+		{name: "no target", in: types.MirrorCaptureParams{NodeName: "ovn-worker"}, wantErr: "exactly one of port or pod_name is required"},
+		{name: "both targets", in: types.MirrorCaptureParams{NodeName: "ovn-worker", Port: "br-int", PodNamespace: "default", PodName: "client"}, wantErr: "exactly one of port or pod_name is required"},
+		{name: "port without node", in: types.MirrorCaptureParams{Port: "br-int"}, wantErr: "node_name is required with port"},
+		{name: "invalid port", in: types.MirrorCaptureParams{NodeName: "ovn-worker", Port: "br-int;reboot"}, wantErr: "invalid port name"},
+		{name: "pod without namespace", in: types.MirrorCaptureParams{PodName: "client"}, wantErr: "pod_namespace is required with pod_name"},
+		{name: "packet count too large", in: types.MirrorCaptureParams{NodeName: "ovn-worker", Port: "br-int", PacketCount: 5000}, wantErr: "packet_count"},
+		{name: "unknown pod", in: types.MirrorCaptureParams{PodNamespace: "default", PodName: "missing"}, wantErr: "pod default/missing not found"},
+		{
+			name:    "no time to set up the mirror",
+			in:      types.MirrorCaptureParams{NodeName: "ovn-worker", Port: "br-int", DurationSeconds: 10, TimeoutParams: timeout.TimeoutParams{TimeoutSeconds: 30}},
+			wantErr: "seconds left before the call times out",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := server.MirrorCapture(context.Background(), nil, tt.in); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("MirrorCapture() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
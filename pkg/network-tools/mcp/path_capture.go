@@ -0,0 +1,360 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/commandbuilder"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/timeout"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultPathCaptureDuration is the number of seconds packets are captured for when no duration
+	// is given.
+	DefaultPathCaptureDuration = 10
+	// DefaultPathCapturePodInterface is the interface captured in the pods.
+	DefaultPathCapturePodInterface = "eth0"
+	// DefaultPathCaptureNodeInterface is the interface captured on the nodes. The Geneve interface
+	// of OVS carries the packets between the nodes before encapsulation and after decapsulation,
+	// while the packets leaving the node through the uplink, such as breth0, are not seen on it.
+	DefaultPathCaptureNodeInterface = "genev_sys_6081"
+
+	pathPointSrcPod  = "src_pod"
+	pathPointSrcNode = "src_node"
+	pathPointDstNode = "dst_node"
+	pathPointDstPod  = "dst_pod"
+
+	// pathCaptureSetupTime is reserved for the two rounds of commands, checking tcpdump and
+	// capturing, each of which can start debug pods or debug containers.
+	pathCaptureSetupTime = 2 * timeout.SetupTime
+
+	// tcpdumpTimeLayout is the time of day printed by tcpdump.
+	tcpdumpTimeLayout = "15:04:05.999999999"
+
+	pathDirectionForward = "forward"
+	pathDirectionReply   = "reply"
+)
+
+// pathCapturePoint is a point of the path, with the function running commands on it and the
+// packets captured there.
+type pathCapturePoint struct {
+	point   types.CapturePoint
+	run     func(ctx context.Context, cmd []string) (string, string, error)
+	records []types.PacketRecord
+}
+
+// PathCapture captures packets at the same time in the source pod, on the source node, on the
+// destination node and in the destination pod, and correlates the packets across the points to
+// find the last point where each packet was seen.
+func (s *MCPServer) PathCapture(ctx context.Context, req *mcp.CallToolRequest, in types.PathCaptureParams) (*mcp.CallToolResult, types.PathCaptureResult, error) {
+	if in.SrcNamespace == "" || in.SrcPod == "" {
+		return nil, types.PathCaptureResult{}, fmt.Errorf("src_namespace and src_pod are required")
+	}
+	if in.DstNamespace == "" || in.DstPod == "" {
+		return nil, types.PathCaptureResult{}, fmt.Errorf("dst_namespace and dst_pod are required")
+	}
+	if in.DebugContainer && (in.SrcContainer != "" || in.DstContainer != "") {
+		return nil, types.PathCaptureResult{}, fmt.Errorf("src_container and dst_container cannot be used with debug_container")
+	}
+	podInterface := cmp.Or(in.PodInterface, DefaultPathCapturePodInterface)
+	srcNodeInterface := cmp.Or(in.SrcNodeInterface, in.NodeInterface, DefaultPathCaptureNodeInterface)
+	dstNodeInterface := cmp.Or(in.DstNodeInterface, in.NodeInterface, DefaultPathCaptureNodeInterface)
+	for _, iface := range []string{podInterface, srcNodeInterface, dstNodeInterface} {
+		if err := validateInterface(iface); err != nil {
+			return nil, types.PathCaptureResult{}, err
+		}
+	}
+	if err := validatePacketFilter(in.BPFFilter); err != nil {
+		return nil, types.PathCaptureResult{}, err
+	}
+	packetCount := cmp.Or(in.PacketCount, DefaultPacketCount)
+	if err := validateIntMax(packetCount, MaxPacketCount, "packet_count", ""); err != nil {
+		return nil, types.PathCaptureResult{}, err
+	}
+	snaplen := cmp.Or(in.Snaplen, DefaultSnaplen)
+	if err := validateIntMax(snaplen, MaxSnaplen, "snaplen", "bytes"); err != nil {
+		return nil, types.PathCaptureResult{}, err
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	// The captures end before the call times out, so that the packets are returned. tcpdump is
+	// checked before the captures, and each round can start debug pods.
+	duration, err := timeout.ValidateDuration(ctx, "duration_seconds", in.DurationSeconds, DefaultPathCaptureDuration, pathCaptureSetupTime)
+	if err != nil {
+		return nil, types.PathCaptureResult{}, err
+	}
+
+	srcPod, err := s.getCapturedPod(ctx, in.SrcNamespace, in.SrcPod)
+	if err != nil {
+		return nil, types.PathCaptureResult{}, err
+	}
+	dstPod, err := s.getCapturedPod(ctx, in.DstNamespace, in.DstPod)
+	if err != nil {
+		return nil, types.PathCaptureResult{}, err
+	}
+	filter := in.BPFFilter
+	if filter == "" {
+		filter = pathCaptureFilter(srcPod.Status.PodIP, dstPod.Status.PodIP)
+	}
+
+	points := s.pathCapturePoints(in, srcPod, dstPod, podInterface, srcNodeInterface, dstNodeInterface)
+	// Checking tcpdump first starts the debug pods of the nodes, so that the captures start at
+	// about the same time when debug pods are pooled.
+	runPathCapturePoints(ctx, points, func(point *pathCapturePoint) []string {
+		return []string{"tcpdump", "--version"}
+	})
+	outputs := runPathCapturePoints(ctx, points, func(point *pathCapturePoint) []string {
+		cmd := commandbuilder.NewCommand("timeout", "--preserve-status", "-s", "INT", strconv.Itoa(duration),
+			"tcpdump", "-n", "-e", "-S", "-v", "-s", strconv.Itoa(snaplen), "-c", strconv.Itoa(packetCount),
+			"-i", point.point.Interface, filter)
+		return cmd.Build()
+	})
+
+	failed := 0
+	for i, point := range points {
+		if point.point.Error != "" {
+			failed++
+			continue
+		}
+		analysis, _ := parseTcpdumpText(outputs[i], point.point.Interface)
+		point.records = analysis.Records
+		point.point.Packets = len(point.records)
+		if len(point.records) > 0 {
+			point.point.First = point.records[0].Timestamp
+			point.point.Last = point.records[len(point.records)-1].Timestamp
+		}
+	}
+	if failed == len(points) {
+		return nil, types.PathCaptureResult{}, fmt.Errorf("capture failed at every point: %s", points[0].point.Error)
+	}
+
+	packets, lastSeen, uncorrelated := correlatePathPackets(points, dstPod.Status.PodIP, srcPod.Status.PodIP)
+	result := types.PathCaptureResult{
+		BPFFilter:       filter,
+		DurationSeconds: duration,
+		Packets:         packets,
+		LastSeen:        lastSeen,
+		Uncorrelated:    uncorrelated,
+	}
+	for _, point := range points {
+		result.Points = append(result.Points, point.point)
+	}
+	return nil, result, nil
+}
+
+// getCapturedPod returns a pod scheduled on a node and having an IP address.
+func (s *MCPServer) getCapturedPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	pod, err := s.getPod(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if pod.Spec.NodeName == "" {
+		return nil, fmt.Errorf("pod %s/%s is not scheduled on a node", namespace, name)
+	}
+	if pod.Status.PodIP == "" {
+		return nil, fmt.Errorf("pod %s/%s has no IP address", namespace, name)
+	}
+	return pod, nil
+}
+
+// pathCaptureFilter returns the filter matching the packets between the pods, whether they are
+// encapsulated in Geneve or not.
+func pathCaptureFilter(srcIP, dstIP string) string {
+	hosts := fmt.Sprintf("host %s and host %s", srcIP, dstIP)
+	return fmt.Sprintf("(%s) or (geneve and %s)", hosts, hosts)
+}
+
+// pathCapturePoints returns the points of the path from the source pod to the destination pod.
+// Packets between pods of the same node do not cross the Geneve interface, so the node is captured
+// only when a node interface is given, such as the uplink for the packets hairpinned through it.
+func (s *MCPServer) pathCapturePoints(in types.PathCaptureParams, srcPod, dstPod *corev1.Pod, podInterface, srcNodeInterface, dstNodeInterface string) []*pathCapturePoint {
+	podPoint := func(name string, pod *corev1.Pod, container string) *pathCapturePoint {
+		point := &pathCapturePoint{
+			point: types.CapturePoint{Name: name, Target: fmt.Sprintf("pod %s/%s", pod.Namespace, pod.Name), Via: "exec", Interface: podInterface},
+			run: func(ctx context.Context, cmd []string) (string, string, error) {
+				return s.runPodExecCommand(ctx, pod.Namespace, pod.Name, container, cmd)
+			},
+		}
+		if in.DebugContainer {
+			point.point.Via = "debug_container"
+			point.run = func(ctx context.Context, cmd []string) (string, string, error) {
+				return s.runPodDebugCommand(ctx, pod.Namespace, pod.Name, s.cfg.TcpdumpImage, cmd)
+			}
+		}
+		return point
+	}
+	nodePoint := func(name, node, nodeInterface string) *pathCapturePoint {
+		return &pathCapturePoint{
+			point: types.CapturePoint{Name: name, Target: "node " + node, Via: "debug_pod", Interface: nodeInterface},
+			run: func(ctx context.Context, cmd []string) (string, string, error) {
+				return s.runDebugNodeCommand(ctx, in.NodePodNamespace, node, s.cfg.TcpdumpImage, cmd, "", "", 0)
+			},
+		}
+	}
+	if srcPod.Spec.NodeName == dstPod.Spec.NodeName {
+		if in.NodeInterface == "" && in.SrcNodeInterface == "" {
+			return []*pathCapturePoint{
+				podPoint(pathPointSrcPod, srcPod, in.SrcContainer),
+				podPoint(pathPointDstPod, dstPod, in.DstContainer),
+			}
+		}
+		return []*pathCapturePoint{
+			podPoint(pathPointSrcPod, srcPod, in.SrcContainer),
+			nodePoint(pathPointSrcNode, srcPod.Spec.NodeName, srcNodeInterface),
+			podPoint(pathPointDstPod, dstPod, in.DstContainer),
+		}
+	}
+	return []*pathCapturePoint{
+		podPoint(pathPointSrcPod, srcPod, in.SrcContainer),
+		nodePoint(pathPointSrcNode, srcPod.Spec.NodeName, srcNodeInterface),
+		nodePoint(pathPointDstNode, dstPod.Spec.NodeName, dstNodeInterface),
+		podPoint(pathPointDstPod, dstPod, in.DstContainer),
+	}
+}
+
+// runPathCapturePoints runs a command at the same time on the points that did not fail, and
+// returns the output of each point. The points where the command fails are marked as failed.
+func runPathCapturePoints(ctx context.Context, points []*pathCapturePoint, command func(point *pathCapturePoint) []string) []string {
+	outputs := make([]string, len(points))
+	var wg sync.WaitGroup
+	for i, point := range points {
+		if point.point.Error != "" {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stdout, _, err := point.run(ctx, command(point))
+			if err != nil {
+				point.point.Error = err.Error()
+				return
+			}
+			outputs[i] = stdout
+		}()
+	}
+	wg.Wait()
+	return outputs
+}
+
+// pathPacketKey returns the key identifying a packet at every point: the TCP sequence and
+// acknowledgement numbers, flags and length for TCP, and the IP ID for other IPv4 packets. Other IP
+// packets, such as IPv6 UDP packets or IPv4 packets sent with an IP ID of 0, are keyed by their
+// endpoints, payload length and ICMP message, which holds the ICMP echo ID and sequence number.
+// These keys are not unique, so repeated reports that the packets of the same key are told apart
+// by their order at each point. Packets without IP addresses, such as ARP, have no key.
+func pathPacketKey(record types.PacketRecord) (key string, repeated bool, ok bool) {
+	endpoints := fmt.Sprintf("%s %s > %s", record.Protocol,
+		packetEndpoint(record.Src, record.SrcPort), packetEndpoint(record.Dst, record.DstPort))
+	switch {
+	case record.Seq != nil:
+		var ack uint32
+		if record.Ack != nil {
+			ack = *record.Ack
+		}
+		return fmt.Sprintf("%s seq %d ack %d flags %s length %d", endpoints, *record.Seq, ack,
+			strings.Join(record.TCPFlags, ","), record.PayloadLength), false, true
+	case record.IPID != 0:
+		return fmt.Sprintf("%s id %d length %d", endpoints, record.IPID, record.PayloadLength), false, true
+	case record.Src != "" && record.Dst != "":
+		return fmt.Sprintf("%s length %d icmp %q", endpoints, record.PayloadLength, record.ICMP), true, true
+	}
+	return "", false, false
+}
+
+// correlatePathPackets correlates the packets captured at the points of the path, ordered from the
+// source pod to the destination pod. Packets sent by the destination pod, or to the source pod,
+// are replies that cross the points in the reverse order. The timestamp of a packet is the one of
+// the first point of its path where it was seen, as the points capture with the clocks of
+// different nodes. It returns the packets ordered by that timestamp, the number of packets by last
+// point, and the number of packets that cannot be correlated.
+func correlatePathPackets(points []*pathCapturePoint, dstIP, srcIP string) ([]types.PathPacket, map[string]int, int) {
+	packets := map[string]*types.PathPacket{}
+	var keys []string
+	uncorrelated := 0
+	for _, point := range points {
+		seen := map[string]bool{}
+		occurrences := map[string]int{}
+		for _, record := range point.records {
+			key, repeated, ok := pathPacketKey(record)
+			if !ok {
+				uncorrelated++
+				continue
+			}
+			if repeated {
+				occurrences[key]++
+				key = fmt.Sprintf("%s #%d", key, occurrences[key])
+			}
+			packet := packets[key]
+			if packet == nil {
+				packet = &types.PathPacket{
+					Direction:     pathDirectionForward,
+					Timestamp:     record.Timestamp,
+					Protocol:      record.Protocol,
+					Src:           packetEndpoint(record.Src, record.SrcPort),
+					Dst:           packetEndpoint(record.Dst, record.DstPort),
+					IPID:          record.IPID,
+					TCPFlags:      record.TCPFlags,
+					Seq:           record.Seq,
+					PayloadLength: record.PayloadLength,
+					SeenAt:        []string{},
+				}
+				if record.Src == dstIP || record.Dst == srcIP {
+					packet.Direction = pathDirectionReply
+				}
+				packets[key] = packet
+				keys = append(keys, key)
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			packet.SeenAt = append(packet.SeenAt, point.point.Name)
+			if packet.Direction == pathDirectionReply {
+				// Replies reach the points in the reverse order.
+				packet.Timestamp = record.Timestamp
+			}
+		}
+	}
+
+	result := []types.PathPacket{}
+	lastSeen := map[string]int{}
+	for _, key := range keys {
+		packet := packets[key]
+		if packet.Direction == pathDirectionReply {
+			slices.Reverse(packet.SeenAt)
+		}
+		packet.LastSeen = packet.SeenAt[len(packet.SeenAt)-1]
+		lastSeen[packet.LastSeen]++
+		result = append(result, *packet)
+	}
+	slices.SortStableFunc(result, func(a, b types.PathPacket) int {
+		return comparePacketTimes(a.Timestamp, b.Timestamp)
+	})
+	return result, lastSeen, uncorrelated
+}
+
+// comparePacketTimes compares the times of day printed by tcpdump, or else the timestamps as
+// strings.
+func comparePacketTimes(a, b string) int {
+	ta, errA := time.Parse(tcpdumpTimeLayout, a)
+	tb, errB := time.Parse(tcpdumpTimeLayout, b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return ta.Compare(tb)
+}
@@ -0,0 +1,323 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	pathSYN    = "10:15:00.%06d IP 10.244.1.3.45678 > 10.244.2.5.8080: Flags [S], seq 100, win 64860, length 0"
+	pathSYNACK = "10:15:00.%06d IP 10.244.2.5.8080 > 10.244.1.3.45678: Flags [S.], seq 500, ack 101, win 64308, length 0"
+	pathUDP    = "10:15:00.%06d IP (tos 0x0, ttl 64, id 7, offset 0, flags [DF], proto UDP (17), length 40)\n    10.244.1.3.53000 > 10.244.2.5.5353: UDP, length 12"
+	pathARP    = "10:15:00.%06d ARP, Request who-has 10.244.2.1 tell 10.244.2.5, length 28"
+	pathUDP6   = "10:15:00.%06d IP6 (flowlabel 0x1f2e3, hlim 64, next-header UDP (17) payload length: 20) fd00:10:244:1::3.53000 > fd00:10:244:2::5.5353: [udp sum ok] UDP, length 12"
+	pathPing   = "10:15:00.%06d IP (tos 0x0, ttl 64, id 0, offset 0, flags [DF], proto ICMP (1), length 84)\n    10.244.1.3 > 10.244.2.5: ICMP echo request, id 7, seq %d, length 64"
+)
+
+func TestPathCapture(t *testing.T) {
+	wantFilter := "(host 10.244.1.3 and host 10.244.2.5) or (geneve and host 10.244.1.3 and host 10.244.2.5)"
+	wantCapture := "timeout --preserve-status -s INT 10 tcpdump -n -e -S -v -s 96 -c 100 -i "
+	tests := []struct {
+		name             string
+		nodes            map[string]string
+		outputs          map[string]string
+		failing          string
+		in               types.PathCaptureParams
+		wantFilter       string
+		wantCommands     []string
+		wantPoints       []types.CapturePoint
+		wantPackets      []string
+		wantLastSeen     map[string]int
+		wantUncorrelated int
+	}{
+		{
+			name:  "pods on different nodes",
+			nodes: map[string]string{"client": "ovn-worker", "server": "ovn-worker2"},
+			outputs: map[string]string{
+				"pod default/client": fmt.Sprintf(pathSYN, 100) + "\n" + fmt.Sprintf(pathUDP, 200),
+				"node ovn-worker":    fmt.Sprintf(pathSYN, 150),
+				"node ovn-worker2":   fmt.Sprintf(pathSYN, 250) + "\n" + fmt.Sprintf(pathSYNACK, 450),
+				"pod default/server": fmt.Sprintf(pathSYN, 300) + "\n" + fmt.Sprintf(pathSYNACK, 400) + "\n" + fmt.Sprintf(pathARP, 500),
+			},
+			in:         types.PathCaptureParams{SrcNamespace: "default", SrcPod: "client", DstNamespace: "default", DstPod: "server"},
+			wantFilter: wantFilter,
+			wantCommands: []string{
+				"node ovn-worker2: " + wantCapture + "genev_sys_6081 " + wantFilter,
+				"node ovn-worker: " + wantCapture + "genev_sys_6081 " + wantFilter,
+				"pod default/client: " + wantCapture + "eth0 " + wantFilter,
+				"pod default/server: " + wantCapture + "eth0 " + wantFilter,
+			},
+			wantPoints: []types.CapturePoint{
+				{Name: "src_pod", Target: "pod default/client", Via: "exec", Interface: "eth0", Packets: 2, First: "10:15:00.000100", Last: "10:15:00.000200"},
+				{Name: "src_node", Target: "node ovn-worker", Via: "debug_pod", Interface: "genev_sys_6081", Packets: 1, First: "10:15:00.000150", Last: "10:15:00.000150"},
+				{Name: "dst_node", Target: "node ovn-worker2", Via: "debug_pod", Interface: "genev_sys_6081", Packets: 2, First: "10:15:00.000250", Last: "10:15:00.000450"},
+				{Name: "dst_pod", Target: "pod default/server", Via: "exec", Interface: "eth0", Packets: 3, First: "10:15:00.000300", Last: "10:15:00.000500"},
+			},
+			wantPackets: []string{
+				"forward tcp 10.244.1.3:45678 > 10.244.2.5:8080 [src_pod src_node dst_node dst_pod] dst_pod",
+				"forward udp 10.244.1.3:53000 > 10.244.2.5:5353 [src_pod] src_pod",
+				"reply tcp 10.244.2.5:8080 > 10.244.1.3:45678 [dst_pod dst_node] dst_node",
+			},
+			wantLastSeen:     map[string]int{"dst_pod": 1, "src_pod": 1, "dst_node": 1},
+			wantUncorrelated: 1,
+		},
+		{
+			name:  "packets without IP ID",
+			nodes: map[string]string{"client": "ovn-worker", "server": "ovn-worker2"},
+			outputs: map[string]string{
+				"pod default/client": strings.Join([]string{fmt.Sprintf(pathUDP6, 100), fmt.Sprintf(pathUDP6, 110), fmt.Sprintf(pathPing, 120, 1), fmt.Sprintf(pathPing, 130, 2)}, "\n"),
+				"node ovn-worker":    strings.Join([]string{fmt.Sprintf(pathUDP6, 150), fmt.Sprintf(pathUDP6, 160), fmt.Sprintf(pathPing, 170, 1), fmt.Sprintf(pathPing, 175, 2)}, "\n"),
+				"node ovn-worker2":   fmt.Sprintf(pathUDP6, 250) + "\n" + fmt.Sprintf(pathPing, 270, 2),
+				"pod default/server": fmt.Sprintf(pathUDP6, 300),
+			},
+			in: types.PathCaptureParams{
+				BaseNetworkDiagParams: types.BaseNetworkDiagParams{BPFFilter: "udp or icmp"},
+				SrcNamespace:          "default", SrcPod: "client", DstNamespace: "default", DstPod: "server",
+			},
+			wantFilter: "udp or icmp",
+			wantCommands: []string{
+				"node ovn-worker2: " + wantCapture + "genev_sys_6081 udp or icmp",
+				"node ovn-worker: " + wantCapture + "genev_sys_6081 udp or icmp",
+				"pod default/client: " + wantCapture + "eth0 udp or icmp",
+				"pod default/server: " + wantCapture + "eth0 udp or icmp",
+			},
+			wantPoints: []types.CapturePoint{
+				{Name: "src_pod", Target: "pod default/client", Via: "exec", Interface: "eth0", Packets: 4, First: "10:15:00.000100", Last: "10:15:00.000130"},
+				{Name: "src_node", Target: "node ovn-worker", Via: "debug_pod", Interface: "genev_sys_6081", Packets: 4, First: "10:15:00.000150", Last: "10:15:00.000175"},
+				{Name: "dst_node", Target: "node ovn-worker2", Via: "debug_pod", Interface: "genev_sys_6081", Packets: 2, First: "10:15:00.000250", Last: "10:15:00.000270"},
+				{Name: "dst_pod", Target: "pod default/server", Via: "exec", Interface: "eth0", Packets: 1, First: "10:15:00.000300", Last: "10:15:00.000300"},
+			},
+			wantPackets: []string{
+				"forward udp [fd00:10:244:1::3]:53000 > [fd00:10:244:2::5]:5353 [src_pod src_node dst_node dst_pod] dst_pod",
+				"forward udp [fd00:10:244:1::3]:53000 > [fd00:10:244:2::5]:5353 [src_pod src_node] src_node",
+				"forward icmp 10.244.1.3 > 10.244.2.5 [src_pod src_node] src_node",
+				"forward icmp 10.244.1.3 > 10.244.2.5 [src_pod src_node dst_node] dst_node",
+			},
+			wantLastSeen: map[string]int{"dst_pod": 1, "src_node": 2, "dst_node": 1},
+		},
+		{
+			name:    "pods on the same node with a failed point",
+			nodes:   map[string]string{"client": "ovn-worker", "server": "ovn-worker"},
+			outputs: map[string]string{"pod default/client": fmt.Sprintf(pathSYN, 100)},
+			failing: "pod default/server",
+			in: types.PathCaptureParams{
+				BaseNetworkDiagParams: types.BaseNetworkDiagParams{BPFFilter: "tcp port 8080"},
+				SrcNamespace:          "default", SrcPod: "client", DstNamespace: "default", DstPod: "server",
+				PodInterface: "net1", DurationSeconds: 5,
+			},
+			wantFilter:   "tcp port 8080",
+			wantCommands: []string{"pod default/client: timeout --preserve-status -s INT 5 tcpdump -n -e -S -v -s 96 -c 100 -i net1 tcp port 8080"},
+			wantPoints: []types.CapturePoint{
+				{Name: "src_pod", Target: "pod default/client", Via: "exec", Interface: "net1", Packets: 1, First: "10:15:00.000100", Last: "10:15:00.000100"},
+				{Name: "dst_pod", Target: "pod default/server", Via: "exec", Interface: "net1", Error: "failed to run command on pod default/server"},
+			},
+			wantPackets:  []string{"forward tcp 10.244.1.3:45678 > 10.244.2.5:8080 [src_pod] src_pod"},
+			wantLastSeen: map[string]int{"src_pod": 1},
+		},
+		{
+			name:  "uplink of the source node",
+			nodes: map[string]string{"client": "ovn-worker", "server": "ovn-worker2"},
+			in: types.PathCaptureParams{
+				SrcNamespace: "default", SrcPod: "client", DstNamespace: "default", DstPod: "server",
+				SrcNodeInterface: "breth0",
+			},
+			wantFilter: wantFilter,
+			wantCommands: []string{
+				"node ovn-worker2: " + wantCapture + "genev_sys_6081 " + wantFilter,
+				"node ovn-worker: " + wantCapture + "breth0 " + wantFilter,
+				"pod default/client: " + wantCapture + "eth0 " + wantFilter,
+				"pod default/server: " + wantCapture + "eth0 " + wantFilter,
+			},
+			wantPoints: []types.CapturePoint{
+				{Name: "src_pod", Target: "pod default/client", Via: "exec", Interface: "eth0"},
+				{Name: "src_node", Target: "node ovn-worker", Via: "debug_pod", Interface: "breth0"},
+				{Name: "dst_node", Target: "node ovn-worker2", Via: "debug_pod", Interface: "genev_sys_6081"},
+				{Name: "dst_pod", Target: "pod default/server", Via: "exec", Interface: "eth0"},
+			},
+			wantLastSeen: map[string]int{},
+		},
+		{
+			name:    "pods on the same node with a node interface",
+			nodes:   map[string]string{"client": "ovn-worker", "server": "ovn-worker"},
+			outputs: map[string]string{"pod default/client": fmt.Sprintf(pathSYN, 100), "node ovn-worker": fmt.Sprintf(pathSYN, 150)},
+			in: types.PathCaptureParams{
+				SrcNamespace: "default", SrcPod: "client", DstNamespace: "default", DstPod: "server",
+				NodeInterface: "breth0",
+			},
+			wantFilter: wantFilter,
+			wantCommands: []string{
+				"node ovn-worker: " + wantCapture + "breth0 " + wantFilter,
+				"pod default/client: " + wantCapture + "eth0 " + wantFilter,
+				"pod default/server: " + wantCapture + "eth0 " + wantFilter,
+			},
+			wantPoints: []types.CapturePoint{
+				{Name: "src_pod", Target: "pod default/client", Via: "exec", Interface: "eth0", Packets: 1, First: "10:15:00.000100", Last: "10:15:00.000100"},
+				{Name: "src_node", Target: "node ovn-worker", Via: "debug_pod", Interface: "breth0", Packets: 1, First: "10:15:00.000150", Last: "10:15:00.000150"},
+				{Name: "dst_pod", Target: "pod default/server", Via: "exec", Interface: "eth0"},
+			},
+			wantPackets:  []string{"forward tcp 10.244.1.3:45678 > 10.244.2.5:8080 [src_pod src_node] src_node"},
+			wantLastSeen: map[string]int{"src_node": 1},
+		},
+		{
+			name:       "debug containers",
+			nodes:      map[string]string{"client": "ovn-worker", "server": "ovn-worker"},
+			outputs:    map[string]string{"debug container default/client netshoot": fmt.Sprintf(pathSYN, 100)},
+			in:         types.PathCaptureParams{SrcNamespace: "default", SrcPod: "client", DstNamespace: "default", DstPod: "server", DebugContainer: true},
+			wantFilter: wantFilter,
+			wantCommands: []string{
+				"debug container default/client netshoot: " + wantCapture + "eth0 " + wantFilter,
+				"debug container default/server netshoot: " + wantCapture + "eth0 " + wantFilter,
+			},
+			wantPoints: []types.CapturePoint{
+				{Name: "src_pod", Target: "pod default/client", Via: "debug_container", Interface: "eth0", Packets: 1, First: "10:15:00.000100", Last: "10:15:00.000100"},
+				{Name: "dst_pod", Target: "pod default/server", Via: "debug_container", Interface: "eth0"},
+			},
+			wantPackets:  []string{"forward tcp 10.244.1.3:45678 > 10.244.2.5:8080 [src_pod] src_pod"},
+			wantLastSeen: map[string]int{"src_pod": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var commands []string
+			run := func(target string, cmd []string) (string, string, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				if target == tt.failing {
+					return "", "", fmt.Errorf("failed to run command on %s", target)
+				}
+				if cmd[0] == "tcpdump" {
+					return "tcpdump version 4.99.4", "", nil
+				}
+				commands = append(commands, target+": "+strings.Join(cmd, " "))
+				return tt.outputs[target], "2 packets captured", nil
+			}
+			ips := map[string]string{"client": "10.244.1.3", "server": "10.244.2.5"}
+			server := newFakeServer(t, Dependencies{
+				RunDebugNodeCommand: func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+					return run("node "+nodeName, cmd)
+				},
+				RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+					return run("pod "+namespace+"/"+name, cmd)
+				},
+				RunPodDebugCommand: func(ctx context.Context, namespace, name, image string, cmd []string) (string, string, error) {
+					return run("debug container "+namespace+"/"+name+" "+image, cmd)
+				},
+				GetPod: func(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+					return &corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+						Spec:       corev1.PodSpec{NodeName: tt.nodes[name]},
+						Status:     corev1.PodStatus{PodIP: ips[name]},
+					}, nil
+				},
+			}, Config{TcpdumpImage: "netshoot"})
+
+			_, result, err := server.PathCapture(context.Background(), nil, tt.in)
+			if err != nil {
+				t.Fatalf("PathCapture() error = %v", err)
+			}
+			if result.BPFFilter != tt.wantFilter {
+				t.Errorf("filter = %q, want %q", result.BPFFilter, tt.wantFilter)
+			}
+			slices.Sort(commands)
+			if !reflect.DeepEqual(commands, tt.wantCommands) {
+				t.Errorf("commands = %q, want %q", commands, tt.wantCommands)
+			}
+			if !reflect.DeepEqual(result.Points, tt.wantPoints) {
+				t.Errorf("points = %+v, want %+v", result.Points, tt.wantPoints)
+			}
+			var packets []string
+			for _, packet := range result.Packets {
+				packets = append(packets, fmt.Sprintf("%s %s %s > %s %v %s", packet.Direction, packet.Protocol, packet.Src, packet.Dst, packet.SeenAt, packet.LastSeen))
+			}
+			if !reflect.DeepEqual(packets, tt.wantPackets) {
+				t.Errorf("packets = %q, want %q", packets, tt.wantPackets)
+			}
+			if !reflect.DeepEqual(result.LastSeen, tt.wantLastSeen) {
+				t.Errorf("last seen = %v, want %v", result.LastSeen, tt.wantLastSeen)
+			}
+			if result.Uncorrelated != tt.wantUncorrelated {
+				t.Errorf("uncorrelated = %d, want %d", result.Uncorrelated, tt.wantUncorrelated)
+			}
+		})
+	}
+}
+
+func TestPathCaptureErrors(t *testing.T) {
+	server := newFakeServer(t, Dependencies{
+		GetPod: func(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+			if name != "client" && name != "server" {
+				return nil, fmt.Errorf("pod %s/%s not found", namespace, name)
+			}
+			return &corev1.Pod{Spec: corev1.PodSpec{NodeName: "ovn-worker"}, Status: corev1.PodStatus{PodIP: "10.244.1.3"}}, nil
+		},
+	}, Config{})
+	valid := types.PathCaptureParams{SrcNamespace: "default", SrcPod: "client", DstNamespace: "default", DstPod: "server"}
+	tests := []struct {
+		name       string
+		modify     func(in *types.PathCaptureParams)
+		ctxTimeout time.Duration
+		wantErr    string
+	}{
+		{name: "missing source", modify: func(in *types.PathCaptureParams) { in.SrcPod = "" }, wantErr: "src_namespace and src_pod are required"},
+		{name: "invalid interface", modify: func(in *types.PathCaptureParams) { in.NodeInterface = "eth0;reboot" }, wantErr: "invalid interface name"},
+		{name: "invalid destination node interface", modify: func(in *types.PathCaptureParams) { in.DstNodeInterface = "eth0;reboot" }, wantErr: "invalid interface name"},
+		{name: "duration too long", modify: func(in *types.PathCaptureParams) { in.DurationSeconds = 30; in.TimeoutSeconds = 30 }, wantErr: "seconds left before the call times out"},
+		{name: "longer than the tool timeout", modify: func(in *types.PathCaptureParams) { in.DurationSeconds = 200 }, ctxTimeout: 120 * time.Second, wantErr: "seconds left before the call times out"},
+		{name: "no setup time left", modify: func(in *types.PathCaptureParams) { in.DurationSeconds = 70 }, ctxTimeout: 120 * time.Second, wantErr: "seconds left before the call times out"},
+		{name: "container with debug container", modify: func(in *types.PathCaptureParams) { in.SrcContainer = "app"; in.DebugContainer = true }, wantErr: "cannot be used with debug_container"},
+		{name: "unknown pod", modify: func(in *types.PathCaptureParams) { in.DstPod = "missing" }, wantErr: "pod default/missing not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := valid
+			tt.modify(&in)
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+			if _, _, err := server.PathCapture(ctx, nil, in); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("PathCapture() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCorrelatePathPacketsTimestamps(t *testing.T) {
+	seq := func(n uint32) *uint32 { return &n }
+	syn := types.PacketRecord{Protocol: "tcp", Src: "10.244.1.3", SrcPort: 45678, Dst: "10.244.2.5", DstPort: 8080, TCPFlags: []string{"SYN"}, Seq: seq(100)}
+	synAck := types.PacketRecord{Protocol: "tcp", Src: "10.244.2.5", SrcPort: 8080, Dst: "10.244.1.3", DstPort: 45678, TCPFlags: []string{"SYN", "ACK"}, Seq: seq(500), Ack: seq(101)}
+	at := func(record types.PacketRecord, timestamp string) types.PacketRecord {
+		record.Timestamp = timestamp
+		return record
+	}
+	// The clock of the source node is behind the clocks of the pods, and the one of the
+	// destination node ahead.
+	points := []*pathCapturePoint{
+		{point: types.CapturePoint{Name: pathPointSrcPod}, records: []types.PacketRecord{at(syn, "10:15:00.000300"), at(synAck, "10:15:00.000900")}},
+		{point: types.CapturePoint{Name: pathPointSrcNode}, records: []types.PacketRecord{at(syn, "10:14:59.000350"), at(synAck, "10:14:59.000850")}},
+		{point: types.CapturePoint{Name: pathPointDstNode}, records: []types.PacketRecord{at(syn, "10:15:02.000400"), at(synAck, "10:15:02.000800")}},
+		{point: types.CapturePoint{Name: pathPointDstPod}, records: []types.PacketRecord{at(synAck, "10:15:00.000200")}},
+	}
+	packets, _, _ := correlatePathPackets(points, "10.244.2.5", "10.244.1.3")
+	var got []string
+	for _, packet := range packets {
+		got = append(got, packet.Direction+" "+packet.Timestamp)
+	}
+	want := []string{"reply 10:15:00.000200", "forward 10:15:00.000300"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("packets = %q, want %q", got, want)
+	}
+}
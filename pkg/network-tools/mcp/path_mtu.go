@@ -0,0 +1,430 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// DefaultPathMTUProbeTimeout is the number of seconds a ping of the path MTU discovery waits
+	// for a reply when no timeout is given.
+	DefaultPathMTUProbeTimeout = 2
+	// MaxPathMTU is the largest packet size probed.
+	MaxPathMTU = 65535
+
+	// The smallest packet sizes probed: the datagram size every IPv4 host accepts, and the
+	// minimum MTU of IPv6 links. The target is unreachable when they do not get through.
+	minPathMTUIPv4 = 576
+	minPathMTUIPv6 = 1280
+	// The bytes of the IP and ICMP headers of a ping, which are not part of its payload size.
+	icmpOverheadIPv4 = 28
+	icmpOverheadIPv6 = 48
+	// The bytes the Geneve encapsulation adds to a pod packet: the outer IP and UDP headers, the
+	// Geneve header with the option of OVN, and the inner Ethernet header.
+	geneveOverheadIPv4 = 58
+	geneveOverheadIPv6 = 78
+)
+
+var (
+	mtuProbePattern    = regexp.MustCompile(`^probe (\d+) (\d+)$`)
+	mtuDevicePattern   = regexp.MustCompile(`^device (\S+) (\d*)$`)
+	mtuRouteErrPattern = regexp.MustCompile(`^route-error (\d+)$`)
+	mtuRoutePattern    = regexp.MustCompile(`\bmtu (?:lock )?(\d+)`)
+	// mtuErrorPattern matches the fragmentation needed and packet too big errors reported by the
+	// hops of the path, and the error of the local interface refusing a packet larger than its MTU.
+	mtuErrorPattern = regexp.MustCompile(`(?i)(?:frag needed and DF set \(mtu = |packet too big: mtu=|message too long, mtu=)(\d+)`)
+	// pingOptionPattern matches the errors of ping implementations without the -M option.
+	pingOptionPattern = regexp.MustCompile(`(?i)(invalid|unrecognized|illegal|unknown) option`)
+	nodeMTUPattern    = regexp.MustCompile(`^mtu (\S+) (\d+)$`)
+)
+
+// pathMTUScript prints the route of the source to the target, and the interface of the route and
+// its MTU. Without a route with an interface, it prints the error of ip and its exit status after
+// the route-error marker, and exits. It then pings the target with the don't fragment bit, first with the smallest packet
+// size, then with the largest, and binary searches the largest size that gets through. Each ping
+// is followed by its size and exit status. The arguments are the address, the address family
+// option of ping, the bytes of the IP and ICMP headers, the smallest and the largest packet size,
+// 0 for the MTU of the interface, and the timeout in seconds.
+const pathMTUScript = `addr=$1 family=$2 overhead=$3 lo=$4 hi=$5 wait=$6
+route=$(ip -o route get "$addr" 2>&1)
+rc=$?
+dev=$(printf '%s\n' "$route" | sed -n 's/.* dev \([^ ]*\).*/\1/p')
+if [ "$rc" -ne 0 ] || [ -z "$dev" ]; then
+	printf '%s\n' "$route"
+	echo "### route-error $rc"
+	exit 0
+fi
+echo "### route $route"
+mtu=$(cat "/sys/class/net/$dev/mtu" 2>/dev/null)
+echo "### device $dev $mtu"
+[ "$hi" -eq 0 ] && hi=${mtu:-1500}
+probe() {
+	ping "$family" -c 1 -W "$wait" -M do -s $(($1 - overhead)) "$addr" 2>&1
+	rc=$?
+	echo "### probe $1 $rc"
+	return $rc
+}
+probe "$lo" || exit 0
+[ "$hi" -le "$lo" ] && exit 0
+probe "$hi" && exit 0
+while [ $((hi - lo)) -gt 1 ]; do
+	mid=$(((lo + hi) / 2))
+	if probe "$mid"; then lo=$mid; else hi=$mid; fi
+done
+exit 0
+`
+
+// nodeMTUScript prints the MTUs of ovn-k8s-mp0, br-ex and the Geneve interface of a node, the
+// interfaces of br-ex without an OVS type, which are its uplink, and their MTUs, and the encap IP
+// of the node.
+const nodeMTUScript = `mtu() {
+	[ -r "/sys/class/net/$1/mtu" ] && echo "### mtu $1 $(cat "/sys/class/net/$1/mtu")"
+}
+mtu ovn-k8s-mp0
+mtu br-ex
+mtu genev_sys_6081
+for iface in $(ovsctl list-ifaces br-ex); do
+	if [ -z "$(ovsctl get Interface "$iface" type | tr -d '"')" ]; then
+		echo "### uplink $iface"
+		mtu "$iface"
+	fi
+done
+echo "### encap $(ovsctl get Open_vSwitch . external_ids:ovn-encap-ip | tr -d '"')"
+`
+
+// PathMTU discovers the path MTU from a pod or a node to a pod, a node or an IP address with pings
+// that must not be fragmented, and reports it next to the MTUs configured on the nodes of the
+// source and the target.
+func (s *MCPServer) PathMTU(ctx context.Context, req *mcp.CallToolRequest, in types.PathMTUParams) (*mcp.CallToolResult, types.PathMTUResult, error) {
+	if err := validateProbeSource(in.ProbeSource); err != nil {
+		return nil, types.PathMTUResult{}, err
+	}
+	if err := validateIntMax(in.MaxMTU, MaxPathMTU, "max_mtu", "bytes"); err != nil {
+		return nil, types.PathMTUResult{}, err
+	}
+	probeTimeout := cmp.Or(in.ProbeTimeoutSeconds, DefaultPathMTUProbeTimeout)
+	if err := validateIntMax(probeTimeout, MaxProbeTimeout, "probe_timeout_seconds", "seconds"); err != nil {
+		return nil, types.PathMTUResult{}, err
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	target, targetNode, err := s.resolvePathMTUTarget(ctx, in)
+	if err != nil {
+		return nil, types.PathMTUResult{}, err
+	}
+	family, overhead, minMTU := "-4", icmpOverheadIPv4, minPathMTUIPv4
+	if net.ParseIP(target.address).To4() == nil {
+		family, overhead, minMTU = "-6", icmpOverheadIPv6, minPathMTUIPv6
+	}
+	if in.MaxMTU != 0 && in.MaxMTU < minMTU {
+		return nil, types.PathMTUResult{}, fmt.Errorf("max_mtu cannot be less than %d bytes", minMTU)
+	}
+	sourceNode := in.SourceNode
+	if in.SourcePod != "" {
+		pod, err := s.getPod(ctx, in.SourceNamespace, in.SourcePod)
+		if err != nil {
+			return nil, types.PathMTUResult{}, err
+		}
+		if pod.Spec.NodeName == "" {
+			return nil, types.PathMTUResult{}, fmt.Errorf("pod %s/%s is not scheduled on a node", in.SourceNamespace, in.SourcePod)
+		}
+		if pod.Status.Phase != corev1.PodRunning {
+			return nil, types.PathMTUResult{}, fmt.Errorf("pod %s/%s is not running", in.SourceNamespace, in.SourcePod)
+		}
+		sourceNode = pod.Spec.NodeName
+	}
+
+	// The MTUs of the nodes are gathered while the pings run.
+	var nodes []string
+	for _, node := range []string{sourceNode, targetNode} {
+		if node != "" && !slices.Contains(nodes, node) {
+			nodes = append(nodes, node)
+		}
+	}
+	nodeMTUs := make([]types.NodeMTUs, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nodeMTUs[i] = s.nodeMTUs(ctx, in.NodePodNamespace, node)
+		}()
+	}
+
+	cmd := []string{"sh", "-c", pathMTUScript, "sh", target.address, family, strconv.Itoa(overhead), strconv.Itoa(minMTU),
+		strconv.Itoa(in.MaxMTU), strconv.Itoa(probeTimeout)}
+	result := types.PathMTUResult{Target: target.description, Address: target.address}
+	var stdout string
+	switch {
+	case in.SourceNode != "":
+		result.Source, result.Via = "node "+in.SourceNode, "debug_pod"
+		stdout, _, err = s.runDebugNodeCommand(ctx, in.NodePodNamespace, in.SourceNode, s.cfg.TcpdumpImage, cmd, "", "", 0)
+	case in.DebugContainer:
+		result.Source, result.Via = "pod "+in.SourceNamespace+"/"+in.SourcePod, "debug_container"
+		stdout, _, err = s.runPodDebugCommand(ctx, in.SourceNamespace, in.SourcePod, s.cfg.TcpdumpImage, cmd)
+	default:
+		result.Source, result.Via = "pod "+in.SourceNamespace+"/"+in.SourcePod, "exec"
+		stdout, _, err = s.runPodExecCommand(ctx, in.SourceNamespace, in.SourcePod, in.SourceContainer, cmd)
+	}
+	wg.Wait()
+	result.Probes, result.Nodes = []types.MTUProbe{}, nodeMTUs
+	if err != nil {
+		if result.Via == "exec" && probeMissingPattern.MatchString(err.Error()) {
+			result.ErrorClass = "tool_missing"
+			result.Error = err.Error() + "; retry with debug_container"
+			result.Findings = []string{}
+			return nil, result, nil
+		}
+		return nil, types.PathMTUResult{}, err
+	}
+	parsePathMTUOutput(&result, stdout)
+	if result.ErrorClass == "tool_missing" && result.Via == "exec" {
+		result.Error += "; retry with debug_container"
+	}
+	result.Findings = pathMTUFindings(result, sourceNode)
+	return nil, result, nil
+}
+
+// resolvePathMTUTarget validates the target of a path MTU discovery and resolves it to the address
+// pinged, and to the node of pod and node targets.
+func (s *MCPServer) resolvePathMTUTarget(ctx context.Context, in types.PathMTUParams) (probeTarget, string, error) {
+	switch in.TargetType {
+	case "pod":
+		if err := validateProbeTargetName(types.ProbeTarget{TargetType: in.TargetType, TargetNamespace: in.TargetNamespace, TargetName: in.TargetName}); err != nil {
+			return probeTarget{}, "", err
+		}
+		pod, err := s.getPod(ctx, in.TargetNamespace, in.TargetName)
+		if err != nil {
+			return probeTarget{}, "", err
+		}
+		if pod.Status.PodIP == "" {
+			return probeTarget{}, "", fmt.Errorf("pod %s/%s has no IP", in.TargetNamespace, in.TargetName)
+		}
+		return probeTarget{description: "pod " + in.TargetNamespace + "/" + in.TargetName, address: pod.Status.PodIP}, pod.Spec.NodeName, nil
+	case "node":
+		if !utils.IsKubernetesName(in.TargetNode) {
+			return probeTarget{}, "", fmt.Errorf("target_node is required with target_type 'node'")
+		}
+		address, err := s.nodeInternalIP(ctx, in.TargetNode)
+		if err != nil {
+			return probeTarget{}, "", err
+		}
+		return probeTarget{description: "node " + in.TargetNode, address: address}, in.TargetNode, nil
+	case "host":
+		if net.ParseIP(in.TargetAddress) == nil {
+			return probeTarget{}, "", fmt.Errorf("invalid target_address: %q (must be an IP address)", in.TargetAddress)
+		}
+		return probeTarget{description: "host " + in.TargetAddress, address: in.TargetAddress}, "", nil
+	}
+	return probeTarget{}, "", fmt.Errorf("invalid target_type: %q (must be 'pod', 'node' or 'host')", in.TargetType)
+}
+
+// parsePathMTUOutput fills a path MTU result from the output of the path MTU script. The output of
+// each ping precedes its size and exit status.
+func parsePathMTUOutput(result *types.PathMTUResult, stdout string) {
+	var output []string
+	for line := range strings.Lines(stdout) {
+		line = strings.TrimRight(line, "\n")
+		marker, ok := strings.CutPrefix(line, probeMarker)
+		if !ok {
+			if strings.TrimSpace(line) != "" {
+				output = append(output, strings.TrimSpace(line))
+			}
+			continue
+		}
+		if m := mtuRouteErrPattern.FindStringSubmatch(marker); m != nil {
+			if m[1] == "127" {
+				result.ErrorClass = "tool_missing"
+				result.Error = "ip of the source is missing: " + strings.Join(output, "; ")
+				return
+			}
+			result.ErrorClass = "no_route"
+			result.Error = fmt.Sprintf("the source has no route to %s: %s", result.Address, strings.Join(output, "; "))
+			return
+		} else if route, ok := strings.CutPrefix(marker, "route "); ok {
+			if m := mtuRoutePattern.FindStringSubmatch(route); m != nil {
+				result.RouteMTU, _ = strconv.Atoi(m[1])
+			}
+		} else if m := mtuDevicePattern.FindStringSubmatch(marker); m != nil {
+			result.Device = m[1]
+			result.DeviceMTU, _ = strconv.Atoi(m[2])
+		} else if m := mtuProbePattern.FindStringSubmatch(marker); m != nil {
+			size, _ := strconv.Atoi(m[1])
+			rc, _ := strconv.Atoi(m[2])
+			if rc == 127 || slices.ContainsFunc(output, pingOptionPattern.MatchString) {
+				result.ErrorClass = "tool_missing"
+				result.Error = "ping of the source is missing or does not support -M do: " + strings.Join(output, "; ")
+				return
+			}
+			result.Probes = append(result.Probes, mtuProbe(size, rc, output))
+		}
+		output = nil
+	}
+	if len(result.Probes) == 0 {
+		result.ErrorClass = "error"
+		result.Error = "no ping ran: " + strings.Join(output, "; ")
+		return
+	}
+	result.Reachable = result.Probes[0].Passed
+	if !result.Reachable {
+		return
+	}
+	for _, probe := range result.Probes {
+		if probe.Passed {
+			result.PathMTU = max(result.PathMTU, probe.Size)
+		}
+	}
+	for _, probe := range result.Probes {
+		if !probe.Passed && probe.Size > result.PathMTU && (result.SmallestFailed == 0 || probe.Size < result.SmallestFailed) {
+			result.SmallestFailed = probe.Size
+		}
+	}
+}
+
+// mtuProbe returns the probe of a ping from its size, exit status and output.
+func mtuProbe(size, rc int, output []string) types.MTUProbe {
+	probe := types.MTUProbe{Size: size, Passed: rc == 0}
+	if probe.Passed {
+		return probe
+	}
+	for _, line := range output {
+		if m := mtuErrorPattern.FindStringSubmatch(line); m != nil {
+			probe.ReportedMTU, _ = strconv.Atoi(m[1])
+			probe.Error = line
+			return probe
+		}
+	}
+	for _, line := range output {
+		if m := pingStatsPattern.FindStringSubmatch(line); m != nil && m[2] == "0" {
+			probe.Error = "no reply"
+			return probe
+		}
+	}
+	if len(output) > 0 {
+		probe.Error = output[len(output)-1]
+	}
+	return probe
+}
+
+// nodeMTUs gathers the MTUs configured on a node. Failures are reported in the result, so that
+// the path MTU is reported without them.
+func (s *MCPServer) nodeMTUs(ctx context.Context, namespace, node string) types.NodeMTUs {
+	result := types.NodeMTUs{Node: node}
+	cmd := []string{"sh", "-c", ovsctlFunction + nodeMTUScript}
+	stdout, stderr, err := s.runDebugNodeCommand(ctx, namespace, node, s.cfg.TcpdumpImage, cmd, "", "", 0)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	if err := s.ovsctlError(stdout, node); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Interfaces = map[string]int{}
+	for line := range strings.Lines(stdout) {
+		marker, ok := strings.CutPrefix(strings.TrimRight(line, "\n"), probeMarker)
+		if !ok {
+			continue
+		}
+		if m := nodeMTUPattern.FindStringSubmatch(marker); m != nil {
+			result.Interfaces[m[1]], _ = strconv.Atoi(m[2])
+		} else if uplink, ok := strings.CutPrefix(marker, "uplink "); ok && result.Uplink == "" {
+			result.Uplink = uplink
+		} else if encap, ok := strings.CutPrefix(marker, "encap "); ok {
+			result.EncapIP = strings.TrimSpace(encap)
+		}
+	}
+	switch ip := net.ParseIP(result.EncapIP); {
+	case ip == nil:
+	case ip.To4() != nil:
+		result.GeneveOverhead = geneveOverheadIPv4
+	default:
+		result.GeneveOverhead = geneveOverheadIPv6
+	}
+	if len(result.Interfaces) == 0 {
+		result.Error = strings.TrimSpace("no interface MTUs found " + stderr)
+	}
+	return result
+}
+
+// pathMTUFindings explains the path MTU against the MTU of the source and the MTUs configured on
+// the nodes.
+func pathMTUFindings(result types.PathMTUResult, sourceNode string) []string {
+	findings := []string{}
+	if result.ErrorClass != "" {
+		return findings
+	}
+	switch {
+	case !result.Reachable:
+		findings = append(findings, fmt.Sprintf("the target does not reply to a ping of %d bytes: check the connectivity with connectivity-probe before the MTU", result.Probes[0].Size))
+	case result.SmallestFailed == 0 && result.PathMTU == result.DeviceMTU:
+		findings = append(findings, fmt.Sprintf("packets of up to the MTU %d of %s of the source get through: the path does not lower the MTU", result.PathMTU, result.Device))
+	case result.SmallestFailed == 0:
+		findings = append(findings, fmt.Sprintf("packets of up to %d bytes get through, the largest size probed", result.PathMTU))
+	default:
+		finding := fmt.Sprintf("packets of up to %d bytes get through but packets of %d bytes do not", result.PathMTU, result.SmallestFailed)
+		if result.DeviceMTU > result.PathMTU {
+			finding += fmt.Sprintf(", below the MTU %d of %s of the source", result.DeviceMTU, result.Device)
+		}
+		reported := 0
+		for _, probe := range result.Probes {
+			reported = cmp.Or(reported, probe.ReportedMTU)
+		}
+		if reported != 0 {
+			finding += fmt.Sprintf(": a hop reported MTU %d", reported)
+		} else {
+			finding += ": no hop reported a smaller MTU, so larger packets are dropped silently and TCP connections hang once they send full-size segments; check the MTU of the underlay, and that ICMP fragmentation needed errors are not filtered"
+		}
+		findings = append(findings, finding)
+	}
+	if result.RouteMTU != 0 && result.RouteMTU < result.DeviceMTU {
+		findings = append(findings, fmt.Sprintf("the route of the source to the target has MTU %d, below the MTU %d of %s, such as a path MTU learned from a fragmentation needed error", result.RouteMTU, result.DeviceMTU, result.Device))
+	}
+
+	podMTUs := map[int][]string{}
+	for _, node := range result.Nodes {
+		podMTU, ok := node.Interfaces["ovn-k8s-mp0"]
+		if ok {
+			podMTUs[podMTU] = append(podMTUs[podMTU], node.Node)
+		}
+		uplinkMTU, uplinkOK := node.Interfaces[node.Uplink]
+		if ok && uplinkOK && node.GeneveOverhead != 0 && podMTU+node.GeneveOverhead > uplinkMTU {
+			findings = append(findings, fmt.Sprintf("on node %s, the pod MTU %d of ovn-k8s-mp0 plus the Geneve overhead of %d bytes exceeds the MTU %d of the uplink %s: full-size pod packets between nodes are dropped once encapsulated; lower the MTU of the cluster network or raise the MTU of the underlay",
+				node.Node, podMTU, node.GeneveOverhead, uplinkMTU, node.Uplink))
+		}
+		if bridgeMTU, ok := node.Interfaces["br-ex"]; ok && uplinkOK && bridgeMTU != uplinkMTU {
+			findings = append(findings, fmt.Sprintf("on node %s, br-ex has MTU %d but its uplink %s has MTU %d", node.Node, bridgeMTU, node.Uplink, uplinkMTU))
+		}
+		if node.Node == sourceNode && ok && result.Via != "debug_pod" && result.DeviceMTU > podMTU {
+			findings = append(findings, fmt.Sprintf("the MTU %d of %s of the source pod exceeds the pod MTU %d of ovn-k8s-mp0 on node %s", result.DeviceMTU, result.Device, podMTU, node.Node))
+		}
+	}
+	if len(podMTUs) > 1 {
+		var mtus []string
+		for _, node := range result.Nodes {
+			if podMTU, ok := node.Interfaces["ovn-k8s-mp0"]; ok {
+				mtus = append(mtus, fmt.Sprintf("%d on %s", podMTU, node.Node))
+			}
+		}
+		findings = append(findings, "the nodes have different pod MTUs: "+strings.Join(mtus, ", "))
+	}
+	return findings
+}
@@ -0,0 +1,349 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// mtuPingOutput returns the output of the path MTU script for pings of the sizes, passing up to
+// the path MTU. Failing pings report the reported MTU when it is not 0.
+func mtuPingOutput(pathMTU, reportedMTU int, sizes ...int) string {
+	var b strings.Builder
+	for _, size := range sizes {
+		switch {
+		case size <= pathMTU:
+			fmt.Fprintf(&b, "1 packets transmitted, 1 received, 0%% packet loss, time 0ms\n### probe %d 0\n", size)
+		case reportedMTU != 0:
+			fmt.Fprintf(&b, "From 172.18.0.1 icmp_seq=1 Frag needed and DF set (mtu = %d)\n\n1 packets transmitted, 0 received, +1 errors, 100%% packet loss, time 0ms\n### probe %d 1\n", reportedMTU, size)
+		default:
+			fmt.Fprintf(&b, "1 packets transmitted, 0 received, 100%% packet loss, time 0ms\n### probe %d 1\n", size)
+		}
+	}
+	return b.String()
+}
+
+func TestParsePathMTUOutput(t *testing.T) {
+	header := "PING 10.244.2.5 (10.244.2.5) 1372(1400) bytes of data.\n### route 10.244.2.5 via 10.244.1.1 dev eth0 src 10.244.1.4 uid 0 \\    cache \n### device eth0 1400\n"
+	tests := []struct {
+		name   string
+		stdout string
+		want   types.PathMTUResult
+	}{
+		{
+			name:   "device MTU gets through",
+			stdout: header + mtuPingOutput(1400, 0, 576, 1400),
+			want: types.PathMTUResult{Device: "eth0", DeviceMTU: 1400, Reachable: true, PathMTU: 1400,
+				Probes: []types.MTUProbe{{Size: 576, Passed: true}, {Size: 1400, Passed: true}}},
+		},
+		{
+			name:   "binary search with a reported MTU",
+			stdout: header + mtuPingOutput(1300, 1300, 576, 1400, 988, 1194, 1297, 1348, 1322, 1309, 1303, 1300, 1301),
+			want: types.PathMTUResult{Device: "eth0", DeviceMTU: 1400, Reachable: true, PathMTU: 1300, SmallestFailed: 1301,
+				Probes: []types.MTUProbe{
+					{Size: 576, Passed: true},
+					{Size: 1400, ReportedMTU: 1300, Error: "From 172.18.0.1 icmp_seq=1 Frag needed and DF set (mtu = 1300)"},
+					{Size: 988, Passed: true},
+					{Size: 1194, Passed: true},
+					{Size: 1297, Passed: true},
+					{Size: 1348, ReportedMTU: 1300, Error: "From 172.18.0.1 icmp_seq=1 Frag needed and DF set (mtu = 1300)"},
+					{Size: 1322, ReportedMTU: 1300, Error: "From 172.18.0.1 icmp_seq=1 Frag needed and DF set (mtu = 1300)"},
+					{Size: 1309, ReportedMTU: 1300, Error: "From 172.18.0.1 icmp_seq=1 Frag needed and DF set (mtu = 1300)"},
+					{Size: 1303, ReportedMTU: 1300, Error: "From 172.18.0.1 icmp_seq=1 Frag needed and DF set (mtu = 1300)"},
+					{Size: 1300, Passed: true},
+					{Size: 1301, ReportedMTU: 1300, Error: "From 172.18.0.1 icmp_seq=1 Frag needed and DF set (mtu = 1300)"},
+				}},
+		},
+		{
+			name:   "local interface refuses the packet",
+			stdout: "### route 1.1.1.1 via 172.18.0.1 dev br-ex src 172.18.0.3 uid 0 \\    cache expires 593sec mtu 1450\n### device br-ex 1500\n" + mtuPingOutput(1450, 0, 576) + "ping: local error: message too long, mtu=1450\n### probe 1500 1\n",
+			want: types.PathMTUResult{Device: "br-ex", DeviceMTU: 1500, RouteMTU: 1450, Reachable: true, PathMTU: 576, SmallestFailed: 1500,
+				Probes: []types.MTUProbe{{Size: 576, Passed: true}, {Size: 1500, ReportedMTU: 1450, Error: "ping: local error: message too long, mtu=1450"}}},
+		},
+		{
+			name:   "unreachable",
+			stdout: header + mtuPingOutput(0, 0, 576),
+			want: types.PathMTUResult{Device: "eth0", DeviceMTU: 1400,
+				Probes: []types.MTUProbe{{Size: 576, Error: "no reply"}}},
+		},
+		{
+			name:   "ping missing",
+			stdout: "### route \n### device eth0 1400\nsh: ping: not found\n### probe 576 127\n",
+			want: types.PathMTUResult{Device: "eth0", DeviceMTU: 1400, ErrorClass: "tool_missing",
+				Error: "ping of the source is missing or does not support -M do: sh: ping: not found"},
+		},
+		{
+			name:   "ping without -M",
+			stdout: "### route \n### device eth0 1400\nping: unrecognized option: M\n### probe 576 1\n",
+			want: types.PathMTUResult{Device: "eth0", DeviceMTU: 1400, ErrorClass: "tool_missing",
+				Error: "ping of the source is missing or does not support -M do: ping: unrecognized option: M"},
+		},
+		{
+			name:   "no ping",
+			stdout: "sh: syntax error\n",
+			want:   types.PathMTUResult{ErrorClass: "error", Error: "no ping ran: sh: syntax error"},
+		},
+		{
+			name:   "no route",
+			stdout: "RTNETLINK answers: Network is unreachable\n### route-error 2\n",
+			want: types.PathMTUResult{Address: "10.96.0.1", ErrorClass: "no_route",
+				Error: "the source has no route to 10.96.0.1: RTNETLINK answers: Network is unreachable"},
+		},
+		{
+			name:   "ip missing",
+			stdout: "sh: ip: not found\n### route-error 127\n",
+			want:   types.PathMTUResult{ErrorClass: "tool_missing", Error: "ip of the source is missing: sh: ip: not found"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := types.PathMTUResult{Address: tt.want.Address}
+			parsePathMTUOutput(&got, tt.stdout)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePathMTUOutput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// getPathMTUTestPod returns the pods "client" on node ovn-worker and "server" on node ovn-worker2,
+// a pod "pending" not scheduled on a node and a pod "completed" that no longer runs.
+func getPathMTUTestPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	switch name {
+	case "client":
+		return &corev1.Pod{Spec: corev1.PodSpec{NodeName: "ovn-worker"}, Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.244.1.4"}}, nil
+	case "pending":
+		return &corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodPending}}, nil
+	case "completed":
+		return &corev1.Pod{Spec: corev1.PodSpec{NodeName: "ovn-worker"}, Status: corev1.PodStatus{Phase: corev1.PodSucceeded}}, nil
+	case "server":
+		return &corev1.Pod{Spec: corev1.PodSpec{NodeName: "ovn-worker2"}, Status: corev1.PodStatus{PodIP: "10.244.2.5"}}, nil
+	case "server6":
+		return &corev1.Pod{Spec: corev1.PodSpec{NodeName: "ovn-worker2"}, Status: corev1.PodStatus{PodIP: "fd00:10:244:2::5"}}, nil
+	}
+	return nil, fmt.Errorf("pod %s/%s not found", namespace, name)
+}
+
+func TestPathMTU(t *testing.T) {
+	header := "### route 10.244.2.5 dev eth0 src 10.244.1.4 uid 0 \\    cache \n### device eth0 1500\n"
+	fromPod := types.ProbeSource{SourceNamespace: "default", SourcePod: "client"}
+	tests := []struct {
+		name         string
+		in           types.PathMTUParams
+		output       string
+		podMTU       map[string]int
+		nodeErr      string
+		wantCommand  string
+		wantPathMTU  int
+		wantNodes    []string
+		wantFindings []string
+	}{
+		{
+			name:        "pod MTU exceeds the underlay on one node",
+			in:          types.PathMTUParams{ProbeSource: fromPod, TargetType: "pod", TargetNamespace: "default", TargetName: "server"},
+			output:      header + mtuPingOutput(1442, 0, 576, 1500, 1038, 1269, 1384, 1442, 1471, 1456, 1449, 1445, 1443),
+			podMTU:      map[string]int{"ovn-worker": 1500, "ovn-worker2": 1400},
+			wantCommand: "exec default/client: 10.244.2.5 -4 28 576 0 2",
+			wantPathMTU: 1442,
+			wantNodes:   []string{"ovn-worker", "ovn-worker2"},
+			wantFindings: []string{
+				"packets of up to 1442 bytes get through but packets of 1443 bytes do not, below the MTU 1500 of eth0 of the source: no hop reported a smaller MTU, so larger packets are dropped silently and TCP connections hang once they send full-size segments; check the MTU of the underlay, and that ICMP fragmentation needed errors are not filtered",
+				"on node ovn-worker, the pod MTU 1500 of ovn-k8s-mp0 plus the Geneve overhead of 58 bytes exceeds the MTU 1500 of the uplink eth0: full-size pod packets between nodes are dropped once encapsulated; lower the MTU of the cluster network or raise the MTU of the underlay",
+				"the nodes have different pod MTUs: 1500 on ovn-worker, 1400 on ovn-worker2",
+			},
+		},
+		{
+			name:         "healthy path to a node from a debug container",
+			in:           types.PathMTUParams{ProbeSource: types.ProbeSource{SourceNamespace: "default", SourcePod: "client", DebugContainer: true}, TargetType: "node", TargetNode: "ovn-worker2", ProbeTimeoutSeconds: 1},
+			output:       "### route 172.18.0.4 via 10.244.1.1 dev eth0\n### device eth0 1400\n" + mtuPingOutput(1400, 0, 576, 1400),
+			podMTU:       map[string]int{"ovn-worker": 1400, "ovn-worker2": 1400},
+			wantCommand:  "debug default/client netshoot: 172.18.0.4 -4 28 576 0 1",
+			wantPathMTU:  1400,
+			wantNodes:    []string{"ovn-worker", "ovn-worker2"},
+			wantFindings: []string{"packets of up to the MTU 1400 of eth0 of the source get through: the path does not lower the MTU"},
+		},
+		{
+			name:         "external IP from a node with max_mtu",
+			in:           types.PathMTUParams{ProbeSource: types.ProbeSource{SourceNode: "ovn-worker"}, TargetType: "host", TargetAddress: "2001:db8::1", MaxMTU: 9000},
+			output:       "### route 2001:db8::1 dev br-ex\n### device br-ex 9000\n" + mtuPingOutput(9000, 0, 1280, 9000),
+			podMTU:       map[string]int{"ovn-worker": 8900},
+			nodeErr:      "ovn-worker",
+			wantCommand:  "node ovn-worker: 2001:db8::1 -6 48 1280 9000 2",
+			wantPathMTU:  9000,
+			wantNodes:    []string{"ovn-worker"},
+			wantFindings: []string{"packets of up to the MTU 9000 of br-ex of the source get through: the path does not lower the MTU"},
+		},
+		{
+			name:        "unreachable IPv6 pod",
+			in:          types.PathMTUParams{ProbeSource: fromPod, TargetType: "pod", TargetNamespace: "default", TargetName: "server6"},
+			output:      header + mtuPingOutput(0, 0, 1280),
+			podMTU:      map[string]int{"ovn-worker": 1400, "ovn-worker2": 1400},
+			wantCommand: "exec default/client: fd00:10:244:2::5 -6 48 1280 0 2",
+			wantNodes:   []string{"ovn-worker", "ovn-worker2"},
+			wantFindings: []string{
+				"the target does not reply to a ping of 1280 bytes: check the connectivity with connectivity-probe before the MTU",
+				"the MTU 1500 of eth0 of the source pod exceeds the pod MTU 1400 of ovn-k8s-mp0 on node ovn-worker",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands []string
+			ping := func(runner string, cmd []string) (string, string, error) {
+				if len(cmd) < 4 || cmd[2] != pathMTUScript {
+					return "", "", fmt.Errorf("unexpected command %q", cmd)
+				}
+				commands = append(commands, runner+": "+strings.Join(cmd[4:], " "))
+				return tt.output, "", nil
+			}
+			server := newFakeServer(t, Dependencies{
+				RunDebugNodeCommand: func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+					if cmd[2] != ovsctlFunction+nodeMTUScript {
+						return ping("node "+nodeName, cmd)
+					}
+					if nodeName == tt.nodeErr {
+						return "", "", fmt.Errorf("failed to create debug pod on node %s", nodeName)
+					}
+					return fmt.Sprintf("### mtu ovn-k8s-mp0 %d\n### mtu br-ex 1500\n### mtu genev_sys_6081 65000\n### uplink eth0\n### mtu eth0 1500\n### encap 172.18.0.3\n", tt.podMTU[nodeName]), "", nil
+				},
+				RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+					return ping("exec "+namespace+"/"+name, cmd)
+				},
+				RunPodDebugCommand: func(ctx context.Context, namespace, name, image string, cmd []string) (string, string, error) {
+					return ping("debug "+namespace+"/"+name+" "+image, cmd)
+				},
+				GetPod: getPathMTUTestPod,
+				GetNode: func(ctx context.Context, name string) (*corev1.Node, error) {
+					return &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "172.18.0.4"}}}}, nil
+				},
+			}, Config{TcpdumpImage: "netshoot"})
+			_, got, err := server.PathMTU(context.Background(), nil, tt.in)
+			if err != nil {
+				t.Fatalf("PathMTU() error = %v", err)
+			}
+			if want := []string{tt.wantCommand}; !reflect.DeepEqual(commands, want) {
+				t.Errorf("commands = %q, want %q", commands, want)
+			}
+			if got.PathMTU != tt.wantPathMTU {
+				t.Errorf("PathMTU = %d, want %d", got.PathMTU, tt.wantPathMTU)
+			}
+			var nodes []string
+			for _, node := range got.Nodes {
+				nodes = append(nodes, node.Node)
+				if node.Node == tt.nodeErr {
+					if node.Error == "" {
+						t.Errorf("node %s has no error", node.Node)
+					}
+				} else if node.Uplink != "eth0" || node.GeneveOverhead != geneveOverheadIPv4 || node.Interfaces["br-ex"] != 1500 {
+					t.Errorf("node %s = %+v", node.Node, node)
+				}
+			}
+			if !reflect.DeepEqual(nodes, tt.wantNodes) {
+				t.Errorf("nodes = %q, want %q", nodes, tt.wantNodes)
+			}
+			if !reflect.DeepEqual(got.Findings, tt.wantFindings) {
+				t.Errorf("Findings = %q, want %q", got.Findings, tt.wantFindings)
+			}
+		})
+	}
+}
+
+func TestPathMTUWithoutShell(t *testing.T) {
+	server := newFakeServer(t, Dependencies{
+		RunPodExecCommand: func(ctx context.Context, namespace, name, container string, cmd []string) (string, string, error) {
+			return "", "", fmt.Errorf(`exec: "sh": executable file not found in $PATH`)
+		},
+		GetPod: func(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+			return &corev1.Pod{Spec: corev1.PodSpec{NodeName: "ovn-worker"}, Status: corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.244.2.5"}}, nil
+		},
+	}, Config{})
+	_, got, err := server.PathMTU(context.Background(), nil, types.PathMTUParams{
+		ProbeSource: types.ProbeSource{SourceNamespace: "default", SourcePod: "client"}, TargetType: "pod", TargetNamespace: "default", TargetName: "server",
+	})
+	if err != nil {
+		t.Fatalf("PathMTU() error = %v", err)
+	}
+	if got.ErrorClass != "tool_missing" || !strings.HasSuffix(got.Error, "retry with debug_container") {
+		t.Errorf("PathMTU() = %+v, want a tool_missing error", got)
+	}
+}
+
+func TestPathMTUErrors(t *testing.T) {
+	fromPod := types.ProbeSource{SourceNamespace: "default", SourcePod: "client"}
+	tests := []struct {
+		name    string
+		in      types.PathMTUParams
+		wantErr string
+	}{
+		{
+			name:    "no source",
+			in:      types.PathMTUParams{TargetType: "host", TargetAddress: "1.1.1.1"},
+			wantErr: "exactly one of source_pod or source_node is required",
+		},
+		{
+			name:    "invalid target type",
+			in:      types.PathMTUParams{ProbeSource: fromPod, TargetType: "service", TargetNamespace: "default", TargetName: "web"},
+			wantErr: "invalid target_type",
+		},
+		{
+			name:    "host name",
+			in:      types.PathMTUParams{ProbeSource: fromPod, TargetType: "host", TargetAddress: "example.com"},
+			wantErr: "invalid target_address",
+		},
+		{
+			name:    "pod without name",
+			in:      types.PathMTUParams{ProbeSource: fromPod, TargetType: "pod", TargetNamespace: "default"},
+			wantErr: "target_namespace and target_name are required",
+		},
+		{
+			name:    "node without name",
+			in:      types.PathMTUParams{ProbeSource: fromPod, TargetType: "node"},
+			wantErr: "target_node is required",
+		},
+		{
+			name:    "max_mtu too large",
+			in:      types.PathMTUParams{ProbeSource: fromPod, TargetType: "host", TargetAddress: "1.1.1.1", MaxMTU: 70000},
+			wantErr: "max_mtu cannot exceed 65535 bytes",
+		},
+		{
+			name:    "max_mtu too small",
+			in:      types.PathMTUParams{ProbeSource: fromPod, TargetType: "host", TargetAddress: "2001:db8::1", MaxMTU: 1000},
+			wantErr: "max_mtu cannot be less than 1280 bytes",
+		},
+		{
+			name:    "probe timeout too large",
+			in:      types.PathMTUParams{ProbeSource: fromPod, TargetType: "host", TargetAddress: "1.1.1.1", ProbeTimeoutSeconds: 31},
+			wantErr: "probe_timeout_seconds cannot exceed 30 seconds",
+		},
+		{
+			name:    "source pod not found",
+			in:      types.PathMTUParams{ProbeSource: types.ProbeSource{SourceNamespace: "default", SourcePod: "missing"}, TargetType: "host", TargetAddress: "1.1.1.1"},
+			wantErr: "pod default/missing not found",
+		},
+		{
+			name:    "source pod not scheduled",
+			in:      types.PathMTUParams{ProbeSource: types.ProbeSource{SourceNamespace: "default", SourcePod: "pending"}, TargetType: "host", TargetAddress: "1.1.1.1"},
+			wantErr: "pod default/pending is not scheduled on a node",
+		},
+		{
+			name:    "source pod not running",
+			in:      types.PathMTUParams{ProbeSource: types.ProbeSource{SourceNamespace: "default", SourcePod: "completed"}, TargetType: "host", TargetAddress: "1.1.1.1"},
+			wantErr: "pod default/completed is not running",
+		},
+	}
+	server := newFakeServer(t, Dependencies{GetPod: getPathMTUTestPod}, Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := server.PathMTU(context.Background(), nil, tt.in)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("PathMTU() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package mcp
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+)
+
+const (
+	pcapMagicMicroseconds = 0xa1b2c3d4
+	pcapMagicNanoseconds  = 0xa1b23c4d
+	pcapHeaderLen         = 24
+	pcapRecordHeaderLen   = 16
+
+	// Link types written by tcpdump: Ethernet interfaces, tunnels without link layer header, and
+	// the 'any' interface (Linux cooked capture v1 and v2).
+	linkTypeEthernet  = 1
+	linkTypeRaw       = 101
+	linkTypeLinuxSLL  = 113
+	linkTypeLinuxSLL2 = 276
+
+	etherTypeIPv4  = 0x0800
+	etherTypeARP   = 0x0806
+	etherTypeVLAN  = 0x8100
+	etherTypeQinQ  = 0x88a8
+	etherTypeIPv6  = 0x86dd
+	ipProtoICMP    = 1
+	ipProtoTCP     = 6
+	ipProtoUDP     = 17
+	ipProtoICMPv6  = 58
+	ipProtoSCTP    = 132
+	ipv4HeaderLen  = 20
+	ipv6HeaderLen  = 40
+	maxVLANHeaders = 2
+
+	// maxPcapConversations bounds the conversations returned in a capture summary.
+	maxPcapConversations = 20
+)
+
+// pcapLinkTypes names the supported link types.
+var pcapLinkTypes = map[uint32]string{
+	linkTypeEthernet:  "ethernet",
+	linkTypeRaw:       "raw",
+	linkTypeLinuxSLL:  "linux_sll",
+	linkTypeLinuxSLL2: "linux_sll2",
+}
+
+// pcapPacket is the part of a packet the summary is computed from.
+type pcapPacket struct {
+	protocol string
+	src, dst netip.Addr
+	// ports are set for TCP, UDP and SCTP packets.
+	srcPort, dstPort uint16
+	hasPorts         bool
+}
+
+// conversationKey identifies a conversation independently of the direction of its packets.
+type conversationKey struct {
+	protocol string
+	a, b     string
+}
+
+// summarizePcap computes the protocols and the conversations of a pcap file. A last record
+// truncated by the end of the capture is ignored.
+func summarizePcap(data []byte) (types.PcapSummary, error) {
+	if len(data) < pcapHeaderLen {
+		return types.PcapSummary{}, fmt.Errorf("invalid pcap: %d bytes is shorter than the file header", len(data))
+	}
+	var order binary.ByteOrder
+	var nanoseconds bool
+	switch magic := binary.LittleEndian.Uint32(data); {
+	case magic == pcapMagicMicroseconds || magic == pcapMagicNanoseconds:
+		order, nanoseconds = binary.LittleEndian, magic == pcapMagicNanoseconds
+	case binary.BigEndian.Uint32(data) == pcapMagicMicroseconds || binary.BigEndian.Uint32(data) == pcapMagicNanoseconds:
+		order, nanoseconds = binary.BigEndian, binary.BigEndian.Uint32(data) == pcapMagicNanoseconds
+	default:
+		return types.PcapSummary{}, fmt.Errorf("invalid pcap: unknown magic number 0x%08x", magic)
+	}
+	linkType := order.Uint32(data[20:24]) & 0x0fffffff
+	linkName, ok := pcapLinkTypes[linkType]
+	if !ok {
+		return types.PcapSummary{}, fmt.Errorf("unsupported pcap link type %d", linkType)
+	}
+
+	summary := types.PcapSummary{LinkType: linkName}
+	protocols := map[string]*types.PcapProtocol{}
+	conversations := map[conversationKey]*types.PcapConversation{}
+	var start, end time.Time
+	for offset := pcapHeaderLen; offset+pcapRecordHeaderLen <= len(data); {
+		seconds := int64(order.Uint32(data[offset:]))
+		fraction := int64(order.Uint32(data[offset+4:]))
+		capturedLen := int(order.Uint32(data[offset+8:]))
+		originalLen := int(order.Uint32(data[offset+12:]))
+		offset += pcapRecordHeaderLen
+		if capturedLen > len(data)-offset {
+			break
+		}
+		frame := data[offset : offset+capturedLen]
+		offset += capturedLen
+
+		if !nanoseconds {
+			fraction *= int64(time.Microsecond)
+		}
+		timestamp := time.Unix(seconds, fraction).UTC()
+		if summary.Packets == 0 {
+			start = timestamp
+		}
+		end = timestamp
+		summary.Packets++
+		summary.Bytes += originalLen
+
+		packet := decodePcapFrame(linkType, frame)
+		protocol := protocols[packet.protocol]
+		if protocol == nil {
+			protocol = &types.PcapProtocol{Protocol: packet.protocol}
+			protocols[packet.protocol] = protocol
+		}
+		protocol.Packets++
+		protocol.Bytes += originalLen
+
+		if !packet.src.IsValid() || !packet.dst.IsValid() {
+			continue
+		}
+		src, dst := packet.src.String(), packet.dst.String()
+		if packet.hasPorts {
+			src = netip.AddrPortFrom(packet.src, packet.srcPort).String()
+			dst = netip.AddrPortFrom(packet.dst, packet.dstPort).String()
+		}
+		key := conversationKey{protocol: packet.protocol, a: min(src, dst), b: max(src, dst)}
+		conversation := conversations[key]
+		if conversation == nil {
+			conversation = &types.PcapConversation{Protocol: key.protocol, A: key.a, B: key.b}
+			conversations[key] = conversation
+		}
+		conversation.Packets++
+		conversation.Bytes += originalLen
+	}
+	if summary.Packets > 0 {
+		summary.Start = start.Format(time.RFC3339Nano)
+		summary.End = end.Format(time.RFC3339Nano)
+	}
+
+	summary.Protocols = []types.PcapProtocol{}
+	for _, protocol := range protocols {
+		summary.Protocols = append(summary.Protocols, *protocol)
+	}
+	slices.SortFunc(summary.Protocols, func(a, b types.PcapProtocol) int {
+		return cmp.Or(cmp.Compare(b.Packets, a.Packets), cmp.Compare(a.Protocol, b.Protocol))
+	})
+	summary.Conversations = []types.PcapConversation{}
+	for _, conversation := range conversations {
+		summary.Conversations = append(summary.Conversations, *conversation)
+	}
+	slices.SortFunc(summary.Conversations, func(a, b types.PcapConversation) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(b.Packets, a.Packets),
+			cmp.Compare(a.Protocol, b.Protocol), cmp.Compare(a.A, b.A), cmp.Compare(a.B, b.B))
+	})
+	if len(summary.Conversations) > maxPcapConversations {
+		summary.Conversations = summary.Conversations[:maxPcapConversations]
+	}
+	return summary, nil
+}
+
+// decodePcapFrame decodes the link layer header of a frame and its network and transport headers.
+// Frames that cannot be decoded are counted by their EtherType, or as 'unknown'.
+func decodePcapFrame(linkType uint32, frame []byte) pcapPacket {
+	var etherType uint16
+	var payload []byte
+	switch linkType {
+	case linkTypeEthernet:
+		if len(frame) < 14 {
+			return pcapPacket{protocol: "unknown"}
+		}
+		etherType, payload = binary.BigEndian.Uint16(frame[12:]), frame[14:]
+		for i := 0; i < maxVLANHeaders && (etherType == etherTypeVLAN || etherType == etherTypeQinQ) && len(payload) >= 4; i++ {
+			etherType, payload = binary.BigEndian.Uint16(payload[2:]), payload[4:]
+		}
+	case linkTypeLinuxSLL:
+		if len(frame) < 16 {
+			return pcapPacket{protocol: "unknown"}
+		}
+		etherType, payload = binary.BigEndian.Uint16(frame[14:]), frame[16:]
+	case linkTypeLinuxSLL2:
+		if len(frame) < 20 {
+			return pcapPacket{protocol: "unknown"}
+		}
+		etherType, payload = binary.BigEndian.Uint16(frame), frame[20:]
+	case linkTypeRaw:
+		if len(frame) == 0 {
+			return pcapPacket{protocol: "unknown"}
+		}
+		etherType, payload = etherTypeIPv4, frame
+		if frame[0]>>4 == 6 {
+			etherType = etherTypeIPv6
+		}
+	}
+
+	switch etherType {
+	case etherTypeIPv4:
+		return decodeIPv4(payload)
+	case etherTypeIPv6:
+		return decodeIPv6(payload)
+	case etherTypeARP:
+		return pcapPacket{protocol: "arp"}
+	}
+	return pcapPacket{protocol: fmt.Sprintf("ethertype-0x%04x", etherType)}
+}
+
+// decodeIPv4 decodes an IPv4 header and the ports of the transport header. Fragments but the
+// first one have no transport header.
+func decodeIPv4(payload []byte) pcapPacket {
+	if len(payload) < ipv4HeaderLen {
+		return pcapPacket{protocol: "ipv4"}
+	}
+	headerLen := int(payload[0]&0x0f) * 4
+	src, _ := netip.AddrFromSlice(payload[12:16])
+	dst, _ := netip.AddrFromSlice(payload[16:20])
+	packet := pcapPacket{src: src, dst: dst}
+	fragmentOffset := binary.BigEndian.Uint16(payload[6:]) & 0x1fff
+	var transport []byte
+	if headerLen >= ipv4HeaderLen && headerLen <= len(payload) && fragmentOffset == 0 {
+		transport = payload[headerLen:]
+	}
+	decodeTransport(&packet, payload[9], transport)
+	return packet
+}
+
+// decodeIPv6 decodes an IPv6 header and the ports of the transport header. Extension headers are
+// not followed, and the packet is counted by its next header.
+func decodeIPv6(payload []byte) pcapPacket {
+	if len(payload) < ipv6HeaderLen {
+		return pcapPacket{protocol: "ipv6"}
+	}
+	src, _ := netip.AddrFromSlice(payload[8:24])
+	dst, _ := netip.AddrFromSlice(payload[24:40])
+	packet := pcapPacket{src: src, dst: dst}
+	decodeTransport(&packet, payload[6], payload[ipv6HeaderLen:])
+	return packet
+}
+
+// decodeTransport sets the protocol of the packet and the ports of TCP, UDP and SCTP packets.
+func decodeTransport(packet *pcapPacket, protocol byte, transport []byte) {
+	switch protocol {
+	case ipProtoTCP:
+		packet.protocol = "tcp"
+	case ipProtoUDP:
+		packet.protocol = "udp"
+	case ipProtoSCTP:
+		packet.protocol = "sctp"
+	case ipProtoICMP:
+		packet.protocol = "icmp"
+		return
+	case ipProtoICMPv6:
+		packet.protocol = "icmpv6"
+		return
+	default:
+		packet.protocol = fmt.Sprintf("ip-proto-%d", protocol)
+		return
+	}
+	if len(transport) >= 4 {
+		packet.srcPort = binary.BigEndian.Uint16(transport)
+		packet.dstPort = binary.BigEndian.Uint16(transport[2:])
+		packet.hasPorts = true
+	}
+}
@@ -0,0 +1,99 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// DefaultPcapStoreMaxBytes is the default total size of the captures kept by the server.
+	DefaultPcapStoreMaxBytes = 64 << 20
+
+	// pcapURIPrefix starts the URI of the stored captures, followed by the capture ID.
+	pcapURIPrefix = "pcap://tcpdump/"
+	// pcapMIMEType is the MIME type of pcap files.
+	pcapMIMEType = "application/vnd.tcpdump.pcap"
+)
+
+// pcapStore keeps the pcap captures in memory up to a total size. When a capture does not fit,
+// the oldest captures are dropped.
+type pcapStore struct {
+	maxBytes int
+	now      func() time.Time
+
+	mu       sync.Mutex
+	size     int
+	captures map[string][]byte
+	// order holds the IDs of the captures, oldest first.
+	order []string
+}
+
+// newPcapStore creates a store keeping at most maxBytes of captures.
+func newPcapStore(maxBytes int) *pcapStore {
+	return &pcapStore{
+		maxBytes: maxBytes,
+		now:      time.Now,
+		captures: map[string][]byte{},
+	}
+}
+
+// add stores a capture and returns its ID.
+func (p *pcapStore) add(data []byte) (string, error) {
+	if len(data) > p.maxBytes {
+		return "", fmt.Errorf("capture of %d bytes exceeds the pcap store size of %d bytes", len(data), p.maxBytes)
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate capture ID: %w", err)
+	}
+	id := p.now().UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.size+len(data) > p.maxBytes && len(p.order) > 0 {
+		oldest := p.order[0]
+		p.order = p.order[1:]
+		p.size -= len(p.captures[oldest])
+		delete(p.captures, oldest)
+	}
+	p.captures[id] = data
+	p.order = append(p.order, id)
+	p.size += len(data)
+	return id, nil
+}
+
+// get returns the capture of the ID.
+func (p *pcapStore) get(id string) ([]byte, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	data, ok := p.captures[id]
+	return data, ok
+}
+
+// pcapURI returns the resource URI of a capture.
+func pcapURI(id string) string {
+	return pcapURIPrefix + id
+}
+
+// ReadPcap returns a stored capture as a binary resource.
+func (s *MCPServer) ReadPcap(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	id, ok := strings.CutPrefix(uri, pcapURIPrefix)
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	data, ok := s.pcaps.get(id)
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []*mcp.ResourceContents{{URI: uri, MIMEType: pcapMIMEType, Blob: data}},
+	}, nil
+}
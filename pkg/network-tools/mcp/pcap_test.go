@@ -0,0 +1,134 @@
+package mcp
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+)
+
+// testPcap builds a little-endian microsecond pcap file of the link type holding the frames, one
+// second apart.
+func testPcap(linkType uint32, frames ...[]byte) []byte {
+	data := make([]byte, pcapHeaderLen)
+	binary.LittleEndian.PutUint32(data, pcapMagicMicroseconds)
+	binary.LittleEndian.PutUint16(data[4:], 2)
+	binary.LittleEndian.PutUint16(data[6:], 4)
+	binary.LittleEndian.PutUint32(data[16:], 262144)
+	binary.LittleEndian.PutUint32(data[20:], linkType)
+	for i, frame := range frames {
+		record := make([]byte, pcapRecordHeaderLen)
+		binary.LittleEndian.PutUint32(record, uint32(1767262500+i))
+		binary.LittleEndian.PutUint32(record[4:], 250000)
+		binary.LittleEndian.PutUint32(record[8:], uint32(len(frame)))
+		binary.LittleEndian.PutUint32(record[12:], uint32(len(frame)))
+		data = append(append(data, record...), frame...)
+	}
+	return data
+}
+
+// testEthernetFrame builds an Ethernet frame of the EtherType carrying the payload.
+func testEthernetFrame(etherType uint16, payload []byte) []byte {
+	frame := make([]byte, 14)
+	binary.BigEndian.PutUint16(frame[12:], etherType)
+	return append(frame, payload...)
+}
+
+// testIPPacket builds an IPv4 or IPv6 packet of the protocol whose transport header starts with the ports.
+func testIPPacket(src, dst string, protocol byte, srcPort, dstPort uint16) []byte {
+	srcAddr, dstAddr := netip.MustParseAddr(src), netip.MustParseAddr(dst)
+	transport := binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, srcPort), dstPort)
+	transport = append(transport, make([]byte, 16)...)
+	if srcAddr.Is4() {
+		header := make([]byte, ipv4HeaderLen)
+		header[0] = 0x45
+		header[9] = protocol
+		copy(header[12:], srcAddr.AsSlice())
+		copy(header[16:], dstAddr.AsSlice())
+		return append(header, transport...)
+	}
+	header := make([]byte, ipv6HeaderLen)
+	header[0] = 0x60
+	header[6] = protocol
+	copy(header[8:], srcAddr.AsSlice())
+	copy(header[24:], dstAddr.AsSlice())
+	return append(header, transport...)
+}
+
+func TestSummarizePcap(t *testing.T) {
+	request := testEthernetFrame(etherTypeIPv4, testIPPacket("10.244.1.3", "10.244.2.5", ipProtoTCP, 45678, 8080))
+	reply := testEthernetFrame(etherTypeIPv4, testIPPacket("10.244.2.5", "10.244.1.3", ipProtoTCP, 8080, 45678))
+	vlan := append(make([]byte, 12), 0x81, 0x00, 0x00, 0x0a, 0x86, 0xdd)
+	dns := append(vlan, testIPPacket("fd00:10:244:1::3", "fd00:10:96::a", ipProtoUDP, 53000, 53)...)
+	arp := testEthernetFrame(etherTypeARP, make([]byte, 28))
+	data := testPcap(linkTypeEthernet, request, reply, request, dns, arp)
+	// A record truncated by the end of the capture is ignored.
+	data = append(data, make([]byte, pcapRecordHeaderLen+4)...)
+	binary.LittleEndian.PutUint32(data[len(data)-12:], 60)
+
+	summary, err := summarizePcap(data)
+	if err != nil {
+		t.Fatalf("summarizePcap() error = %v", err)
+	}
+	tcpBytes := 3 * len(request)
+	want := types.PcapSummary{
+		LinkType: "ethernet",
+		Packets:  5,
+		Bytes:    tcpBytes + len(dns) + len(arp),
+		Start:    "2026-01-01T10:15:00.25Z",
+		End:      "2026-01-01T10:15:04.25Z",
+		Protocols: []types.PcapProtocol{
+			{Protocol: "tcp", Packets: 3, Bytes: tcpBytes},
+			{Protocol: "arp", Packets: 1, Bytes: len(arp)},
+			{Protocol: "udp", Packets: 1, Bytes: len(dns)},
+		},
+		Conversations: []types.PcapConversation{
+			{Protocol: "tcp", A: "10.244.1.3:45678", B: "10.244.2.5:8080", Packets: 3, Bytes: tcpBytes},
+			{Protocol: "udp", A: "[fd00:10:244:1::3]:53000", B: "[fd00:10:96::a]:53", Packets: 1, Bytes: len(dns)},
+		},
+	}
+	if !reflect.DeepEqual(summary, want) {
+		t.Errorf("summarizePcap() = %+v, want %+v", summary, want)
+	}
+}
+
+func TestSummarizePcapLinuxSLL2(t *testing.T) {
+	frame := make([]byte, 20)
+	binary.BigEndian.PutUint16(frame, etherTypeIPv4)
+	frame = append(frame, testIPPacket("10.244.1.3", "10.244.2.5", ipProtoICMP, 0x0800, 0)...)
+
+	summary, err := summarizePcap(testPcap(linkTypeLinuxSLL2, frame))
+	if err != nil {
+		t.Fatalf("summarizePcap() error = %v", err)
+	}
+	if summary.LinkType != "linux_sll2" || len(summary.Conversations) != 1 {
+		t.Fatalf("summarizePcap() = %+v, want one conversation", summary)
+	}
+	if got := summary.Conversations[0]; got.Protocol != "icmp" || got.A != "10.244.1.3" || got.B != "10.244.2.5" {
+		t.Errorf("conversation = %+v, want icmp between 10.244.1.3 and 10.244.2.5", got)
+	}
+}
+
+func TestSummarizePcapErrors(t *testing.T) {
+	unsupported := testPcap(linkTypeEthernet)
+	binary.LittleEndian.PutUint32(unsupported[20:], 147)
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "short file", data: []byte("tcpdump: eth0"), wantErr: "shorter than the file header"},
+		{name: "text output", data: []byte(strings.Repeat("10:15:00.000000 IP 10.0.0.1 > 10.0.0.2", 2)), wantErr: "unknown magic number"},
+		{name: "unsupported link type", data: unsupported, wantErr: "unsupported pcap link type 147"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := summarizePcap(tt.data); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("summarizePcap() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
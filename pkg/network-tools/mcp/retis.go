@@ -0,0 +1,303 @@
+package mcp
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/timeout"
+)
+
+const (
+	// DefaultRetisEventLimit is the number of events returned when no limit is given.
+	DefaultRetisEventLimit = 500
+	// MaxRetisEventLimit is the maximum number of events returned.
+	MaxRetisEventLimit = 5000
+	// DefaultRetisDuration is the number of seconds events are collected for when no duration is
+	// given.
+	DefaultRetisDuration = 10
+)
+
+// retisScript collects events for a duration into a temporary file, and prints the first
+// events. The arguments are the duration, the number of events to print and the BPF filter.
+// retis stops and writes its events when interrupted, by timeout at the end of the duration, or
+// as soon as the file holds the number of events to print, so that busy nodes do not fill the
+// disk of the debug pod.
+const retisScript = `out=$(mktemp) || exit 1
+trap 'rm -f "$out"' EXIT
+timeout --preserve-status -s INT "$1" retis collect --collectors skb,skb-drop,skb-tracking,ovs,nft \
+	--ovs-track --ovs-enrich-flows --allow-system-changes --out "$out" ${3:+--filter-packet "$3"} >/dev/null &
+pid=$!
+while kill -0 "$pid" 2>/dev/null; do
+	if [ "$(wc -l <"$out")" -ge "$2" ]; then
+		kill -INT "$pid"
+		break
+	fi
+	sleep 1
+done
+wait "$pid"
+head -n "$2" "$out"
+`
+
+// retisEvent is an event of the JSON events file of retis. Each collector adds its own section.
+type retisEvent struct {
+	Common struct {
+		Timestamp uint64 `json:"timestamp"`
+	} `json:"common"`
+	Kernel    *retisProbe `json:"kernel"`
+	Userspace *retisProbe `json:"userspace"`
+	Tracking  *struct {
+		OrigHead  uint64 `json:"orig_head"`
+		Timestamp uint64 `json:"timestamp"`
+	} `json:"skb-tracking"`
+	Skb *struct {
+		Dev *struct {
+			Name string `json:"name"`
+		} `json:"dev"`
+		IP *struct {
+			Saddr    string `json:"saddr"`
+			Daddr    string `json:"daddr"`
+			Protocol int    `json:"protocol"`
+		} `json:"ip"`
+		TCP *retisPorts `json:"tcp"`
+		UDP *retisPorts `json:"udp"`
+	} `json:"skb"`
+	Drop *struct {
+		DropReason string `json:"drop_reason"`
+	} `json:"skb-drop"`
+	OVS    map[string]any `json:"ovs"`
+	DPFlow *struct {
+		UFID string `json:"ufid"`
+		Flow string `json:"flow"`
+	} `json:"dpflow"`
+	Nft *struct {
+		TableName  string  `json:"table_name"`
+		ChainName  string  `json:"chain_name"`
+		Verdict    string  `json:"verdict"`
+		RuleHandle *uint64 `json:"rule_handle"`
+	} `json:"nft"`
+}
+
+type retisProbe struct {
+	Symbol    string `json:"symbol"`
+	ProbeType string `json:"probe_type"`
+}
+
+type retisPorts struct {
+	Sport int `json:"sport"`
+	Dport int `json:"dport"`
+}
+
+// Retis traces packets with retis through the kernel networking stack, the OVS datapath, including
+// its upcalls and flow matches, and nftables. Events are collected for a duration in a debug pod
+// on the node, and are grouped by packet into timelines.
+func (s *MCPServer) Retis(ctx context.Context, req *mcp.CallToolRequest, in types.RetisParams) (*mcp.CallToolResult, types.RetisResult, error) {
+	if in.NodeName == "" {
+		return nil, types.RetisResult{}, fmt.Errorf("node_name is required")
+	}
+	if err := validatePacketFilter(in.BPFFilter); err != nil {
+		return nil, types.RetisResult{}, err
+	}
+	eventLimit := cmp.Or(in.EventLimit, DefaultRetisEventLimit)
+	if err := validateIntMax(eventLimit, MaxRetisEventLimit, "event_limit", ""); err != nil {
+		return nil, types.RetisResult{}, err
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	// The collection ends before the call times out, so that the events are returned, leaving time
+	// to start the debug pod and retis, which loads its probes before collecting.
+	duration, err := timeout.ValidateDuration(ctx, "duration_seconds", in.DurationSeconds, DefaultRetisDuration, timeout.SetupTime)
+	if err != nil {
+		return nil, types.RetisResult{}, err
+	}
+
+	// One more event than the limit is printed to tell whether events were dropped.
+	cmd := []string{"sh", "-c", retisScript, "sh", strconv.Itoa(duration), strconv.Itoa(eventLimit + 1), in.BPFFilter}
+	stdout, stderr, err := s.runDebugNodeCommand(ctx, in.NodePodNamespace, in.NodeName, s.cfg.RetisImage, cmd, "/sys/kernel/debug", "/sys/kernel/debug", 0)
+	if err != nil {
+		return nil, types.RetisResult{}, err
+	}
+
+	result := parseRetisEvents(stdout, eventLimit)
+	result.Node, result.DurationSeconds, result.Stderr = in.NodeName, duration, stderr
+	return nil, result, nil
+}
+
+// parseRetisEvents parses up to eventLimit events of a retis events file and groups the events
+// tracked to a packet into its timeline. Packets are ordered by their first event.
+func parseRetisEvents(output string, eventLimit int) types.RetisResult {
+	result := types.RetisResult{Packets: []types.RetisPacket{}}
+	var unparsed []string
+	packets := map[string]*types.RetisPacket{}
+	var order []string
+	for line := range strings.Lines(output) {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var event retisEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			unparsed = append(unparsed, line)
+			continue
+		}
+		if result.Events == eventLimit {
+			result.Truncated = true
+			break
+		}
+		result.Events++
+		if event.Tracking == nil {
+			result.Untracked++
+			continue
+		}
+		id := fmt.Sprintf("%x-%d", event.Tracking.OrigHead, event.Tracking.Timestamp)
+		packet, ok := packets[id]
+		if !ok {
+			packet = &types.RetisPacket{TrackingID: id}
+			packets[id] = packet
+			order = append(order, id)
+		}
+		addRetisEvent(packet, &event)
+	}
+	for _, id := range order {
+		packet := packets[id]
+		sort.SliceStable(packet.Events, func(i, j int) bool { return packet.Events[i].Timestamp < packet.Events[j].Timestamp })
+		for _, e := range packet.Events {
+			if n := len(packet.Interfaces); e.Interface != "" && (n == 0 || packet.Interfaces[n-1] != e.Interface) {
+				packet.Interfaces = append(packet.Interfaces, e.Interface)
+			}
+		}
+		result.Packets = append(result.Packets, *packet)
+	}
+	sort.SliceStable(result.Packets, func(i, j int) bool {
+		return result.Packets[i].Events[0].Timestamp < result.Packets[j].Events[0].Timestamp
+	})
+	result.Output = strings.Join(unparsed, "\n")
+	return result
+}
+
+// addRetisEvent adds an event to the timeline of its packet, and fills the packet headers, OVS
+// flows and drop reason.
+func addRetisEvent(packet *types.RetisPacket, event *retisEvent) {
+	e := types.RetisEvent{Timestamp: event.Common.Timestamp}
+	for _, probe := range []*retisProbe{event.Kernel, event.Userspace} {
+		if probe != nil {
+			e.Probe = strings.TrimPrefix(probe.ProbeType+":"+probe.Symbol, ":")
+		}
+	}
+	if skb := event.Skb; skb != nil {
+		if skb.Dev != nil {
+			e.Interface = skb.Dev.Name
+		}
+		if skb.IP != nil && packet.Src == "" {
+			packet.Protocol = retisProtocol(skb.IP.Protocol)
+			packet.Src, packet.Dst = skb.IP.Saddr, skb.IP.Daddr
+			if ports := cmp.Or(skb.TCP, skb.UDP); ports != nil {
+				packet.Src = net.JoinHostPort(skb.IP.Saddr, strconv.Itoa(ports.Sport))
+				packet.Dst = net.JoinHostPort(skb.IP.Daddr, strconv.Itoa(ports.Dport))
+			}
+		}
+	}
+	if event.OVS != nil {
+		e.OVS = summarizeRetisOVS(event.OVS)
+		switch event.OVS["event_type"] {
+		case "flow_lookup":
+			if ufid, ok := event.OVS["ufid"].(string); ok && ufid != "" {
+				addRetisFlow(packet, ufid, "")
+			}
+		case "action_execute":
+			if event.OVS["action"] == "drop" && packet.DropReason == "" {
+				packet.DropReason = "ovs: " + e.OVS
+			}
+		}
+	}
+	if flow := event.DPFlow; flow != nil && flow.Flow != "" {
+		addRetisFlow(packet, flow.UFID, flow.Flow)
+	}
+	if nft := event.Nft; nft != nil {
+		e.Nft = fmt.Sprintf("%s/%s %s", nft.TableName, nft.ChainName, nft.Verdict)
+		if nft.RuleHandle != nil {
+			e.Nft += fmt.Sprintf(" (handle %d)", *nft.RuleHandle)
+		}
+	}
+	if event.Drop != nil {
+		e.DropReason = event.Drop.DropReason
+		packet.DropReason = event.Drop.DropReason
+	}
+	packet.Events = append(packet.Events, e)
+}
+
+// addRetisFlow adds an OVS datapath flow to a packet once per UFID, keeping its match and actions
+// when known.
+func addRetisFlow(packet *types.RetisPacket, ufid, flow string) {
+	entry := strings.TrimSpace("ufid:" + ufid + " " + flow)
+	for i, f := range packet.OVSFlows {
+		if f == "ufid:"+ufid || strings.HasPrefix(f, "ufid:"+ufid+" ") {
+			if flow != "" {
+				packet.OVSFlows[i] = entry
+			}
+			return
+		}
+	}
+	packet.OVSFlows = append(packet.OVSFlows, entry)
+}
+
+// summarizeRetisOVS formats an OVS event as its type followed by its fields, nested fields being
+// flattened, e.g. 'action_execute action=output port=3 recirc_id=0'.
+func summarizeRetisOVS(event map[string]any) string {
+	var fields []string
+	var flatten func(prefix string, m map[string]any)
+	flatten = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			switch v := v.(type) {
+			case nil:
+			case map[string]any:
+				flatten(prefix+k+".", v)
+			case float64:
+				fields = append(fields, prefix+k+"="+strconv.FormatFloat(v, 'f', -1, 64))
+			default:
+				fields = append(fields, fmt.Sprintf("%s%s=%v", prefix, k, v))
+			}
+		}
+	}
+	eventType, _ := event["event_type"].(string)
+	rest := make(map[string]any, len(event))
+	for k, v := range event {
+		if k != "event_type" {
+			rest[k] = v
+		}
+	}
+	flatten("", rest)
+	slices.Sort(fields)
+	return strings.TrimSpace(eventType + " " + strings.Join(fields, " "))
+}
+
+// retisProtocol returns the name of an IP protocol number.
+func retisProtocol(protocol int) string {
+	switch protocol {
+	case 1:
+		return "icmp"
+	case 6:
+		return "tcp"
+	case 17:
+		return "udp"
+	case 58:
+		return "icmp6"
+	case 132:
+		return "sctp"
+	}
+	return strconv.Itoa(protocol)
+}
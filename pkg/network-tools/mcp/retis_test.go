@@ -0,0 +1,114 @@
+package mcp
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/timeout"
+)
+
+const retisEvents = `{"common":{"timestamp":2000},"kernel":{"symbol":"openvswitch:ovs_dp_upcall","probe_type":"raw_tracepoint"},"skb-tracking":{"orig_head":4096,"timestamp":1000,"skb":8192},"skb":{"dev":{"name":"ovn-k8s-mp0","ifindex":5}},"ovs":{"event_type":"upcall","port":2,"cmd":1,"cpu":0}}
+{"common":{"timestamp":1000},"kernel":{"symbol":"net:netif_receive_skb","probe_type":"raw_tracepoint"},"skb-tracking":{"orig_head":4096,"timestamp":1000,"skb":8192},"skb":{"dev":{"name":"eth0","ifindex":2},"ip":{"saddr":"10.244.1.3","daddr":"10.244.2.5","protocol":6,"len":60},"tcp":{"sport":45678,"dport":8080,"flags":2}}}
+{"common":{"timestamp":2500},"userspace":{"symbol":"dpif_recv:recv_upcall","probe_type":"usdt"},"ovs":{"event_type":"recv_upcall","queue_id":7,"pkt_size":74}}
+{"common":{"timestamp":3000},"kernel":{"symbol":"openvswitch:ovs_do_execute_action","probe_type":"raw_tracepoint"},"skb-tracking":{"orig_head":4096,"timestamp":1000,"skb":8192},"ovs":{"event_type":"flow_lookup","ufid":"5b1e2a3c-0000-0000-0000-000000000001","n_mask_hit":1}}
+{"common":{"timestamp":3100},"skb-tracking":{"orig_head":4096,"timestamp":1000,"skb":8192},"dpflow":{"ufid":"5b1e2a3c-0000-0000-0000-000000000001","flow":"recirc_id(0),in_port(2),eth_type(0x0800),ipv4(dst=10.244.2.5) actions:drop"}}
+{"common":{"timestamp":3200},"kernel":{"symbol":"openvswitch:ovs_do_execute_action","probe_type":"raw_tracepoint"},"skb-tracking":{"orig_head":4096,"timestamp":1000,"skb":8192},"ovs":{"event_type":"action_execute","action":"drop","reason":{"code":1},"recirc_id":0}}
+{"common":{"timestamp":4000},"kernel":{"symbol":"skb:kfree_skb","probe_type":"raw_tracepoint"},"skb-tracking":{"orig_head":8192,"timestamp":3900,"skb":8192},"skb":{"dev":{"name":"eth0"},"ip":{"saddr":"fd00::3","daddr":"fd00::5","protocol":17}},"nft":{"table_name":"ovn-kubernetes","chain_name":"mgmtport-snat","verdict":"drop","rule_handle":12},"skb-drop":{"drop_reason":"NETFILTER_DROP"}}
+retis: 7 events written
+`
+
+func TestParseRetisEvents(t *testing.T) {
+	result := parseRetisEvents(retisEvents, 100)
+	if result.Events != 7 || result.Untracked != 1 || result.Truncated {
+		t.Errorf("events = %d, untracked = %d, truncated = %v, want 7 events and 1 untracked", result.Events, result.Untracked, result.Truncated)
+	}
+	if result.Output != "retis: 7 events written" {
+		t.Errorf("output = %q, want the unparsed line", result.Output)
+	}
+	want := []types.RetisPacket{
+		{
+			TrackingID: "1000-1000",
+			Protocol:   "tcp",
+			Src:        "10.244.1.3:45678",
+			Dst:        "10.244.2.5:8080",
+			Interfaces: []string{"eth0", "ovn-k8s-mp0"},
+			OVSFlows:   []string{"ufid:5b1e2a3c-0000-0000-0000-000000000001 recirc_id(0),in_port(2),eth_type(0x0800),ipv4(dst=10.244.2.5) actions:drop"},
+			DropReason: "ovs: action_execute action=drop reason.code=1 recirc_id=0",
+			Events: []types.RetisEvent{
+				{Timestamp: 1000, Probe: "raw_tracepoint:net:netif_receive_skb", Interface: "eth0"},
+				{Timestamp: 2000, Probe: "raw_tracepoint:openvswitch:ovs_dp_upcall", Interface: "ovn-k8s-mp0", OVS: "upcall cmd=1 cpu=0 port=2"},
+				{Timestamp: 3000, Probe: "raw_tracepoint:openvswitch:ovs_do_execute_action", OVS: "flow_lookup n_mask_hit=1 ufid=5b1e2a3c-0000-0000-0000-000000000001"},
+				{Timestamp: 3100},
+				{Timestamp: 3200, Probe: "raw_tracepoint:openvswitch:ovs_do_execute_action", OVS: "action_execute action=drop reason.code=1 recirc_id=0"},
+			},
+		},
+		{
+			TrackingID: "2000-3900",
+			Protocol:   "udp",
+			Src:        "fd00::3",
+			Dst:        "fd00::5",
+			Interfaces: []string{"eth0"},
+			DropReason: "NETFILTER_DROP",
+			Events: []types.RetisEvent{
+				{Timestamp: 4000, Probe: "raw_tracepoint:skb:kfree_skb", Interface: "eth0", Nft: "ovn-kubernetes/mgmtport-snat drop (handle 12)", DropReason: "NETFILTER_DROP"},
+			},
+		},
+	}
+	if !reflect.DeepEqual(result.Packets, want) {
+		t.Errorf("packets = %+v, want %+v", result.Packets, want)
+	}
+
+	truncated := parseRetisEvents(retisEvents, 2)
+	if truncated.Events != 2 || !truncated.Truncated || len(truncated.Packets) != 1 {
+		t.Errorf("truncated = %+v, want 2 events of one packet", truncated)
+	}
+}
+
+func TestRetis(t *testing.T) {
+	var gotImage string
+	var gotCmd []string
+	server := newFakeServer(t, Dependencies{
+		RunDebugNodeCommand: func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+			gotImage, gotCmd = image, cmd
+			return retisEvents, "", nil
+		},
+	}, Config{RetisImage: "quay.io/retis/retis"})
+
+	_, result, err := server.Retis(context.Background(), nil, types.RetisParams{
+		BaseNetworkDiagParams: types.BaseNetworkDiagParams{BPFFilter: "tcp port 8080"},
+		NodeName:              "ovn-worker",
+		EventLimit:            50,
+	})
+	if err != nil {
+		t.Fatalf("Retis() error = %v", err)
+	}
+	if gotImage != "quay.io/retis/retis" || !reflect.DeepEqual(gotCmd[3:], []string{"sh", "10", "51", "tcp port 8080"}) {
+		t.Errorf("image = %q, command = %q", gotImage, gotCmd)
+	}
+	if result.Node != "ovn-worker" || result.DurationSeconds != DefaultRetisDuration || len(result.Packets) != 2 {
+		t.Errorf("result = %+v", result)
+	}
+
+	tests := []struct {
+		name    string
+		in      types.RetisParams
+		wantErr string
+	}{
+		{name: "missing node", in: types.RetisParams{}, wantErr: "node_name is required"},
+		{name: "event limit too large", in: types.RetisParams{NodeName: "ovn-worker", EventLimit: MaxRetisEventLimit + 1}, wantErr: "event_limit"},
+		{name: "invalid filter", in: types.RetisParams{NodeName: "ovn-worker", BaseNetworkDiagParams: types.BaseNetworkDiagParams{BPFFilter: "tcp; reboot"}}, wantErr: "invalid"},
+		{name: "negative duration", in: types.RetisParams{NodeName: "ovn-worker", DurationSeconds: -1}, wantErr: "invalid duration_seconds -1: must not be negative"},
+		{name: "no setup time left", in: types.RetisParams{NodeName: "ovn-worker", DurationSeconds: 30, TimeoutParams: timeout.TimeoutParams{TimeoutSeconds: 50}}, wantErr: "seconds left before the call times out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := server.Retis(context.Background(), nil, tt.in); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Retis() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
@@ -19,6 +21,11 @@ const (
 	// MaxSnaplen is set to 1500 bytes (standard Ethernet MTU) to allow full packet capture
 	// when needed for deeper analysis. Users can set snaplen parameter to capture complete packets.
 	MaxSnaplen = 1500
+
+	// TcpdumpModeText returns the packets decoded by tcpdump as packet records.
+	TcpdumpModeText = "text"
+	// TcpdumpModePcap stores the capture in pcap format as an MCP resource.
+	TcpdumpModePcap = "pcap"
 )
 
 // Tcpdump executes the tcpdump packet capture tool on a node or inside a pod.
@@ -55,8 +62,22 @@ func (s *MCPServer) Tcpdump(ctx context.Context, req *mcp.CallToolRequest, in ty
 		return nil, types.CommandResult{}, err
 	}
 
-	cmd := commandbuilder.NewCommand("tcpdump", "-n", "-v",
-		"-s", strconv.Itoa(snaplen),
+	mode := in.Mode
+	if mode == "" {
+		mode = TcpdumpModeText
+	}
+	if mode != TcpdumpModeText && mode != TcpdumpModePcap {
+		return nil, types.CommandResult{}, fmt.Errorf("invalid mode: %s (must be 'text' or 'pcap')", in.Mode)
+	}
+
+	cmd := commandbuilder.NewCommand("tcpdump", "-n")
+	// The link layer header is printed for the MAC addresses and VLANs of the packet records, and
+	// TCP sequence numbers are absolute so that they match across packets and captures.
+	cmd.AddIf(mode == TcpdumpModeText, "-e", "-S", "-v")
+	// The capture is written to stdout, flushed after each packet so that it is complete when
+	// tcpdump stops.
+	cmd.AddIf(mode == TcpdumpModePcap, "-U", "-w", "-")
+	cmd.Add("-s", strconv.Itoa(snaplen),
 		"-c", strconv.Itoa(packetCount))
 	cmd.AddIfNotEmpty(in.Interface, "-i", in.Interface)
 	cmd.AddIfNotEmpty(in.BPFFilter, in.BPFFilter)
@@ -68,20 +89,60 @@ func (s *MCPServer) Tcpdump(ctx context.Context, req *mcp.CallToolRequest, in ty
 		defer cancel()
 	}
 
+	var stdout, stderr string
+	var err error
 	switch in.TargetType {
 	case "node":
-		stdout, stderr, err := s.runDebugNodeCommand(ctx, in.Namespace, in.Name, s.cfg.TcpdumpImage, cmd.Build(), "", "", 0)
-		if err != nil {
-			return nil, types.CommandResult{}, err
-		}
-		return nil, types.CommandResult{Output: stdout, Stderr: stderr}, nil
+		stdout, stderr, err = s.runDebugNodeCommand(ctx, in.Namespace, in.Name, s.cfg.TcpdumpImage, cmd.Build(), "", "", 0)
 	case "pod":
-		stdout, stderr, err := s.runPodExecCommand(ctx, in.Namespace, in.Name, in.ContainerName, cmd.Build())
-		if err != nil {
-			return nil, types.CommandResult{}, err
-		}
-		return nil, types.CommandResult{Output: stdout, Stderr: stderr}, nil
+		stdout, stderr, err = s.runPodExecCommand(ctx, in.Namespace, in.Name, in.ContainerName, cmd.Build())
 	default:
 		return nil, types.CommandResult{}, fmt.Errorf("invalid target_type: %s (must be 'node' or 'pod')", in.TargetType)
 	}
+	if err != nil {
+		return nil, types.CommandResult{}, err
+	}
+	if mode == TcpdumpModePcap {
+		return s.storePcap([]byte(stdout), stderr)
+	}
+	packets, unparsed := parseTcpdumpText(stdout, in.Interface)
+	return nil, types.CommandResult{Output: stdout, Stderr: stderr, Packets: packets, Unparsed: unparsed}, nil
+}
+
+// storePcap stores the capture written by tcpdump and returns its summary and a link to the
+// resource of the capture.
+func (s *MCPServer) storePcap(data []byte, stderr string) (*mcp.CallToolResult, types.CommandResult, error) {
+	if len(data) == 0 {
+		return nil, types.CommandResult{}, fmt.Errorf("tcpdump wrote no capture: %s", strings.TrimSpace(stderr))
+	}
+	summary, err := summarizePcap(data)
+	if err != nil {
+		return nil, types.CommandResult{}, err
+	}
+	id, err := s.pcaps.add(data)
+	if err != nil {
+		return nil, types.CommandResult{}, err
+	}
+	result := types.CommandResult{
+		Stderr: stderr,
+		Pcap: &types.PcapCapture{
+			URI:      pcapURI(id),
+			MIMEType: pcapMIMEType,
+			Size:     len(data),
+			Summary:  summary,
+		},
+	}
+
+	// The result is returned as text, as when no content is set, followed by a link to the capture.
+	text, err := json.Marshal(result)
+	if err != nil {
+		return nil, types.CommandResult{}, fmt.Errorf("failed to marshal tcpdump result: %w", err)
+	}
+	size := int64(len(data))
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(text)},
+			&mcp.ResourceLink{URI: result.Pcap.URI, Name: id + ".pcap", MIMEType: pcapMIMEType, Size: &size},
+		},
+	}, result, nil
 }
@@ -0,0 +1,133 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+)
+
+func TestTcpdumpModes(t *testing.T) {
+	capture := testPcap(linkTypeEthernet, testEthernetFrame(etherTypeIPv4, testIPPacket("10.244.1.3", "10.244.2.5", ipProtoTCP, 45678, 8080)))
+	tests := []struct {
+		name        string
+		mode        string
+		stdout      string
+		wantCommand string
+		wantPcap    bool
+		wantErr     string
+	}{
+		{
+			name:        "text by default",
+			stdout:      "10:15:00.250000 IP 10.244.1.3.45678 > 10.244.2.5.8080: Flags [S]",
+			wantCommand: "tcpdump -n -e -S -v -s 96 -c 100 -i eth0 tcp",
+		},
+		{
+			name:        "pcap",
+			mode:        "pcap",
+			stdout:      string(capture),
+			wantCommand: "tcpdump -n -U -w - -s 96 -c 100 -i eth0 tcp",
+			wantPcap:    true,
+		},
+		{
+			name:    "pcap without capture",
+			mode:    "pcap",
+			wantErr: "tcpdump wrote no capture",
+		},
+		{
+			name:    "invalid mode",
+			mode:    "json",
+			wantErr: "invalid mode",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var command string
+			server := newFakeServer(t, Dependencies{
+				RunDebugNodeCommand: func(ctx context.Context, namespace, nodeName, image string, cmd []string, hostPath, mountPath string, timeout time.Duration) (string, string, error) {
+					command = strings.Join(cmd, " ")
+					return tt.stdout, "1 packet captured", nil
+				},
+			}, Config{})
+			res, result, err := server.Tcpdump(context.Background(), nil, types.TcpdumpParams{
+				BaseNetworkDiagParams: types.BaseNetworkDiagParams{BPFFilter: "tcp"},
+				TargetType:            "node",
+				Name:                  "worker-1",
+				Interface:             "eth0",
+				Mode:                  tt.mode,
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Tcpdump() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Tcpdump() error = %v", err)
+			}
+			if command != tt.wantCommand {
+				t.Errorf("command = %q, want %q", command, tt.wantCommand)
+			}
+			if !tt.wantPcap {
+				if result.Output != tt.stdout || result.Unparsed != "" || result.Pcap != nil || res != nil || result.Packets == nil || len(result.Packets.Records) != 1 {
+					t.Errorf("Tcpdump() = %+v, want the output of tcpdump and one packet record", result)
+				}
+				return
+			}
+
+			if result.Output != "" || result.Pcap == nil || result.Pcap.Size != len(capture) || result.Pcap.Summary.Packets != 1 {
+				t.Fatalf("Tcpdump() = %+v, want the stored capture of one packet", result)
+			}
+			if res == nil || len(res.Content) != 2 {
+				t.Fatalf("Tcpdump() content = %+v, want the result and a resource link", res)
+			}
+			if link, ok := res.Content[1].(*mcp.ResourceLink); !ok || link.URI != result.Pcap.URI {
+				t.Errorf("Tcpdump() content[1] = %+v, want a link to %s", res.Content[1], result.Pcap.URI)
+			}
+
+			read, err := server.ReadPcap(context.Background(), &mcp.ReadResourceRequest{Params: &mcp.ReadResourceParams{URI: result.Pcap.URI}})
+			if err != nil {
+				t.Fatalf("ReadPcap() error = %v", err)
+			}
+			if len(read.Contents) != 1 || !bytes.Equal(read.Contents[0].Blob, capture) || read.Contents[0].MIMEType != pcapMIMEType {
+				t.Errorf("ReadPcap() = %+v, want the capture", read.Contents)
+			}
+		})
+	}
+}
+
+func TestPcapStoreDropsOldestCaptures(t *testing.T) {
+	store := newPcapStore(10)
+	first, err := store.add([]byte("0123"))
+	if err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+	second, err := store.add([]byte("4567"))
+	if err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+	third, err := store.add([]byte("89ab"))
+	if err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+	if _, ok := store.get(first); ok {
+		t.Errorf("get(%s) found the oldest capture, want it dropped", first)
+	}
+	for _, id := range []string{second, third} {
+		if _, ok := store.get(id); !ok {
+			t.Errorf("get(%s) did not find the capture", id)
+		}
+	}
+	if _, err := store.add(make([]byte, 11)); err == nil {
+		t.Errorf("add() error = nil, want error for a capture larger than the store")
+	}
+
+	server := newFakeServer(t, Dependencies{}, Config{})
+	if _, err := server.ReadPcap(context.Background(), &mcp.ReadResourceRequest{Params: &mcp.ReadResourceParams{URI: pcapURI("unknown")}}); err == nil {
+		t.Errorf("ReadPcap() error = nil, want not found")
+	}
+}
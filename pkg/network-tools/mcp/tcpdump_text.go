@@ -0,0 +1,366 @@
+package mcp
+
+import (
+	"cmp"
+	"net"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+)
+
+const (
+	// maxTopTalkers bounds the flows returned as top talkers.
+	maxTopTalkers = 10
+	// maxPacketEvents bounds the resets, retransmissions and ICMP unreachables returned.
+	maxPacketEvents = 20
+)
+
+var (
+	tcpdumpTimestampPattern = regexp.MustCompile(`^(\d{2}:\d{2}:\d{2}\.\d+)\s+(.*)$`)
+	// The 'any' interface prints the interface and the direction of the packet.
+	tcpdumpDirectionPattern = regexp.MustCompile(`^(?:(\S+)\s+)?(In|Out|B|M|P)\s+(?:ifindex \d+\s+)?(.*)$`)
+	tcpdumpEthernetPattern  = regexp.MustCompile(`^([0-9a-f]{2}(?::[0-9a-f]{2}){5})(?: > ([0-9a-f]{2}(?::[0-9a-f]{2}){5}))?,? ethertype (\S+) \(0x[0-9a-f]{4}\), length (\d+):\s*(.*)$`)
+	tcpdumpVLANPattern      = regexp.MustCompile(`^vlan (\d+), p \d+, ethertype (\S+) \(0x[0-9a-f]{4}\),?\s*(.*)$`)
+	// The network header without link layer header, as printed for the inner packets of tunnels.
+	tcpdumpNetworkPattern   = regexp.MustCompile(`^(IP6?|ARP),?\s+(.*)$`)
+	tcpdumpAddressesPattern = regexp.MustCompile(`^(\S+) > (\S+):\s*(.*)$`)
+	tcpdumpIPIDPattern      = regexp.MustCompile(`\bid (\d+),`)
+	tcpdumpIPProtoPattern   = regexp.MustCompile(`(?:proto|next-header) \S+ \((\d+)\)`)
+	tcpdumpIPv6LenPattern   = regexp.MustCompile(`payload length: (\d+)`)
+	tcpdumpLengthPattern    = regexp.MustCompile(`\blength (\d+)`)
+	tcpdumpTCPFlagsPattern  = regexp.MustCompile(`^Flags \[([^\]]*)\]`)
+	tcpdumpTCPSeqPattern    = regexp.MustCompile(`\bseq (\d+)(?::\d+)?`)
+	tcpdumpTCPAckPattern    = regexp.MustCompile(`\back (\d+)`)
+	tcpdumpICMPPattern      = regexp.MustCompile(`^ICMP(6)?,? (.*?), length (\d+)`)
+	tcpdumpGenevePattern    = regexp.MustCompile(`Geneve, Flags \[[^\]]*\], vni 0x([0-9a-f]+)(?:, proto \S+ \(0x[0-9a-f]+\))?(?:, options \[([^\]]*)\])?\s*(.*)$`)
+)
+
+// tcpdumpDirections names the packet directions printed for the 'any' interface.
+var tcpdumpDirections = map[string]string{
+	"In":  "in",
+	"Out": "out",
+	"B":   "broadcast",
+	"M":   "multicast",
+	"P":   "other_host",
+}
+
+// tcpdumpTCPFlags names the TCP flags printed by tcpdump.
+var tcpdumpTCPFlags = map[rune]string{
+	'S': "SYN",
+	'F': "FIN",
+	'P': "PSH",
+	'R': "RST",
+	'U': "URG",
+	'W': "CWR",
+	'E': "ECE",
+	'.': "ACK",
+}
+
+// parseTcpdumpText parses the output of tcpdump -n -e -S -v into packet records, one per packet,
+// and aggregates them. The lines of the packets that cannot be parsed are returned as is. The
+// interface is set on the records that do not print it.
+func parseTcpdumpText(output, iface string) (*types.PacketAnalysis, string) {
+	var records []types.PacketRecord
+	var unparsed []string
+	var packet []string
+	flush := func() {
+		if len(packet) == 0 {
+			return
+		}
+		record, ok := parseTcpdumpPacket(strings.Join(packet, " "))
+		if ok {
+			if record.Interface == "" && iface != "any" {
+				record.Interface = iface
+			}
+			records = append(records, record)
+		} else {
+			unparsed = append(unparsed, packet...)
+		}
+		packet = nil
+	}
+	for _, line := range strings.Split(output, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// The headers and the payload of a packet printed over several lines are indented.
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+			if len(packet) > 0 {
+				packet = append(packet, strings.TrimSpace(line))
+			} else {
+				unparsed = append(unparsed, line)
+			}
+			continue
+		}
+		flush()
+		if tcpdumpTimestampPattern.MatchString(line) {
+			packet = append(packet, line)
+		} else {
+			unparsed = append(unparsed, line)
+		}
+	}
+	flush()
+	return analyzePackets(records), strings.Join(unparsed, "\n")
+}
+
+// parseTcpdumpPacket parses the lines of a packet joined in one line.
+func parseTcpdumpPacket(text string) (types.PacketRecord, bool) {
+	m := tcpdumpTimestampPattern.FindStringSubmatch(text)
+	if m == nil {
+		return types.PacketRecord{}, false
+	}
+	record := types.PacketRecord{Timestamp: m[1]}
+	rest := m[2]
+	if m := tcpdumpDirectionPattern.FindStringSubmatch(rest); m != nil {
+		record.Interface, record.Direction, rest = m[1], tcpdumpDirections[m[2]], m[3]
+	}
+	if !parseTcpdumpFrame(&record, rest) {
+		return types.PacketRecord{}, false
+	}
+	return record, true
+}
+
+// parseTcpdumpFrame parses the link layer header of a frame and its network and transport headers.
+func parseTcpdumpFrame(record *types.PacketRecord, text string) bool {
+	var etherType string
+	// With a SLL header, the only printed address is the source address.
+	if m := tcpdumpEthernetPattern.FindStringSubmatch(text); m != nil {
+		record.SrcMAC, record.DstMAC, etherType = m[1], m[2], m[3]
+		record.Length, _ = strconv.Atoi(m[4])
+		text = m[5]
+		for m := tcpdumpVLANPattern.FindStringSubmatch(text); m != nil; m = tcpdumpVLANPattern.FindStringSubmatch(text) {
+			vlan, _ := strconv.Atoi(m[1])
+			record.VLANs = append(record.VLANs, vlan)
+			etherType, text = m[2], m[3]
+		}
+	} else if m := tcpdumpNetworkPattern.FindStringSubmatch(text); m != nil {
+		etherType, text = map[string]string{"IP": "IPv4", "IP6": "IPv6", "ARP": "ARP"}[m[1]], m[2]
+	} else {
+		return false
+	}
+
+	switch etherType {
+	case "IPv4", "IPv6":
+		return parseTcpdumpIP(record, text)
+	case "ARP":
+		record.Protocol = "arp"
+		record.Info = strings.TrimPrefix(text, "Ethernet (len 6), IPv4 (len 4), ")
+	default:
+		record.Protocol = "ethertype-" + strings.ToLower(etherType)
+		record.Info = text
+	}
+	return true
+}
+
+// parseTcpdumpIP parses the IP header printed by tcpdump -v, followed by the addresses and the
+// transport header.
+func parseTcpdumpIP(record *types.PacketRecord, text string) bool {
+	if strings.HasPrefix(text, "(") {
+		header, rest, ok := cutParenthesized(text)
+		if !ok {
+			return false
+		}
+		if m := tcpdumpIPProtoPattern.FindStringSubmatch(header); m != nil {
+			protocol, _ := strconv.Atoi(m[1])
+			var packet pcapPacket
+			decodeTransport(&packet, byte(protocol), nil)
+			record.Protocol = packet.protocol
+		}
+		if m := tcpdumpIPIDPattern.FindStringSubmatch(header); m != nil {
+			record.IPID, _ = strconv.Atoi(m[1])
+		}
+		if record.Length == 0 {
+			if m := tcpdumpIPv6LenPattern.FindStringSubmatch(header); m != nil {
+				length, _ := strconv.Atoi(m[1])
+				record.Length = length + ipv6HeaderLen
+			} else if m := tcpdumpLengthPattern.FindStringSubmatch(header); m != nil {
+				record.Length, _ = strconv.Atoi(m[1])
+			}
+		}
+		text = rest
+	}
+	m := tcpdumpAddressesPattern.FindStringSubmatch(text)
+	if m == nil {
+		return false
+	}
+	record.Src, record.SrcPort = splitTcpdumpAddress(m[1])
+	record.Dst, record.DstPort = splitTcpdumpAddress(m[2])
+	parseTcpdumpTransport(record, m[3])
+	return true
+}
+
+// parseTcpdumpTransport parses the transport header of a packet. The inner packet of a Geneve
+// packet replaces its addresses and transport header.
+func parseTcpdumpTransport(record *types.PacketRecord, text string) {
+	switch {
+	case record.Protocol == "tcp" || strings.HasPrefix(text, "Flags ["):
+		record.Protocol = "tcp"
+		if m := tcpdumpTCPFlagsPattern.FindStringSubmatch(text); m != nil {
+			for _, flag := range m[1] {
+				if name, ok := tcpdumpTCPFlags[flag]; ok {
+					record.TCPFlags = append(record.TCPFlags, name)
+				}
+			}
+		}
+		if m := tcpdumpTCPSeqPattern.FindStringSubmatch(text); m != nil {
+			record.Seq = parseUint32(m[1])
+		}
+		if m := tcpdumpTCPAckPattern.FindStringSubmatch(text); m != nil {
+			record.Ack = parseUint32(m[1])
+		}
+		if m := tcpdumpLengthPattern.FindStringSubmatch(text); m != nil {
+			record.PayloadLength, _ = strconv.Atoi(m[1])
+		}
+	case strings.HasPrefix(text, "ICMP"):
+		record.Protocol = "icmp"
+		if m := tcpdumpICMPPattern.FindStringSubmatch(text); m != nil {
+			if m[1] != "" {
+				record.Protocol = "icmpv6"
+			}
+			record.ICMP = m[2]
+			record.PayloadLength, _ = strconv.Atoi(m[3])
+		} else {
+			record.ICMP = text
+		}
+	default:
+		if m := tcpdumpGenevePattern.FindStringSubmatch(text); m != nil {
+			var inner types.PacketRecord
+			if parseTcpdumpFrame(&inner, m[3]) {
+				vni, _ := strconv.ParseUint(m[1], 16, 32)
+				geneve := &types.GeneveHeader{OuterSrc: record.Src, OuterDst: record.Dst, VNI: uint32(vni), Options: m[2]}
+				inner.Timestamp, inner.Interface, inner.Direction = record.Timestamp, record.Interface, record.Direction
+				inner.Length, inner.Geneve = record.Length, geneve
+				*record = inner
+				return
+			}
+		}
+		if record.Protocol == "" {
+			record.Protocol = "unknown"
+		}
+		if m := tcpdumpLengthPattern.FindStringSubmatch(text); m != nil {
+			record.PayloadLength, _ = strconv.Atoi(m[1])
+		}
+		record.Info = text
+	}
+}
+
+// cutParenthesized returns the text between the parenthesis starting the text and its matching
+// parenthesis, and the text after it.
+func cutParenthesized(text string) (string, string, bool) {
+	depth := 0
+	for i, c := range text {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return text[1:i], strings.TrimSpace(text[i+1:]), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// splitTcpdumpAddress splits an address printed by tcpdump -n into the IP address and the port
+// that follows it after a dot.
+func splitTcpdumpAddress(address string) (string, int) {
+	if i := strings.LastIndexByte(address, '.'); i >= 0 && net.ParseIP(address[:i]) != nil {
+		if port, err := strconv.Atoi(address[i+1:]); err == nil {
+			return address[:i], port
+		}
+	}
+	return address, 0
+}
+
+// parseUint32 returns the value of a decimal number, or nil when it is not a valid uint32.
+func parseUint32(s string) *uint32 {
+	value, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return nil
+	}
+	v := uint32(value)
+	return &v
+}
+
+// packetEndpoint returns the endpoint of an address and a port.
+func packetEndpoint(address string, port int) string {
+	if port == 0 {
+		return address
+	}
+	return net.JoinHostPort(address, strconv.Itoa(port))
+}
+
+// analyzePackets aggregates the packet records: the flows sending the most packets, the TCP
+// resets, the TCP segments seen more than once, which hint at retransmissions, and the ICMP
+// destination unreachable messages.
+func analyzePackets(records []types.PacketRecord) *types.PacketAnalysis {
+	analysis := &types.PacketAnalysis{
+		Records:          records,
+		TopTalkers:       []types.PacketFlow{},
+		TCPResets:        []types.PacketEvent{},
+		Retransmissions:  []types.TCPRetransmission{},
+		ICMPUnreachables: []types.PacketEvent{},
+	}
+	if analysis.Records == nil {
+		analysis.Records = []types.PacketRecord{}
+	}
+
+	flows := map[types.PacketFlow]*types.PacketFlow{}
+	segments := map[types.TCPRetransmission]*types.TCPRetransmission{}
+	var segmentOrder []types.TCPRetransmission
+	for _, record := range records {
+		if record.Src == "" || record.Dst == "" {
+			continue
+		}
+		src, dst := packetEndpoint(record.Src, record.SrcPort), packetEndpoint(record.Dst, record.DstPort)
+		key := types.PacketFlow{Protocol: record.Protocol, Src: src, Dst: dst}
+		flow := flows[key]
+		if flow == nil {
+			flow = &types.PacketFlow{Protocol: record.Protocol, Src: src, Dst: dst}
+			flows[key] = flow
+		}
+		flow.Packets++
+		flow.Bytes += record.Length
+
+		if slices.Contains(record.TCPFlags, "RST") && len(analysis.TCPResets) < maxPacketEvents {
+			analysis.TCPResets = append(analysis.TCPResets, types.PacketEvent{Timestamp: record.Timestamp, Src: src, Dst: dst})
+		}
+		// Segments without payload nor SYN or FIN do not advance the sequence number, and are
+		// expected to repeat it.
+		if record.Seq != nil && (record.PayloadLength > 0 || slices.Contains(record.TCPFlags, "SYN") || slices.Contains(record.TCPFlags, "FIN")) {
+			segmentKey := types.TCPRetransmission{Src: src, Dst: dst, Seq: *record.Seq}
+			segment := segments[segmentKey]
+			if segment == nil {
+				segment = &types.TCPRetransmission{Src: src, Dst: dst, Seq: *record.Seq}
+				segments[segmentKey] = segment
+				segmentOrder = append(segmentOrder, segmentKey)
+			}
+			segment.Packets++
+		}
+		if strings.Contains(record.ICMP, "unreachable") && len(analysis.ICMPUnreachables) < maxPacketEvents {
+			analysis.ICMPUnreachables = append(analysis.ICMPUnreachables, types.PacketEvent{
+				Timestamp: record.Timestamp, Src: src, Dst: dst, Info: record.ICMP,
+			})
+		}
+	}
+
+	for _, flow := range flows {
+		analysis.TopTalkers = append(analysis.TopTalkers, *flow)
+	}
+	slices.SortFunc(analysis.TopTalkers, func(a, b types.PacketFlow) int {
+		return cmp.Or(cmp.Compare(b.Packets, a.Packets), cmp.Compare(b.Bytes, a.Bytes),
+			cmp.Compare(a.Protocol, b.Protocol), cmp.Compare(a.Src, b.Src), cmp.Compare(a.Dst, b.Dst))
+	})
+	if len(analysis.TopTalkers) > maxTopTalkers {
+		analysis.TopTalkers = analysis.TopTalkers[:maxTopTalkers]
+	}
+	for _, key := range segmentOrder {
+		if segment := segments[key]; segment.Packets > 1 && len(analysis.Retransmissions) < maxPacketEvents {
+			analysis.Retransmissions = append(analysis.Retransmissions, *segment)
+		}
+	}
+	return analysis
+}
@@ -0,0 +1,170 @@
+package mcp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/network-tools/types"
+)
+
+func uint32Ptr(v uint32) *uint32 {
+	return &v
+}
+
+func TestParseTcpdumpPacket(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want types.PacketRecord
+	}{
+		{
+			name: "tcp syn",
+			text: "10:15:00.250000 0a:58:0a:f4:01:03 > 0a:58:0a:f4:02:05, ethertype IPv4 (0x0800), length 74: (tos 0x0, ttl 64, id 12345, offset 0, flags [DF], proto TCP (6), length 60)\n" +
+				"    10.244.1.3.45678 > 10.244.2.5.8080: Flags [S], cksum 0x1c46 (incorrect -> 0x5e2f), seq 1234567890, win 64860, options [mss 1410,sackOK,TS val 1 ecr 0,nop,wscale 7], length 0",
+			want: types.PacketRecord{
+				Timestamp: "10:15:00.250000",
+				SrcMAC:    "0a:58:0a:f4:01:03",
+				DstMAC:    "0a:58:0a:f4:02:05",
+				Protocol:  "tcp",
+				Src:       "10.244.1.3",
+				Dst:       "10.244.2.5",
+				IPID:      12345,
+				SrcPort:   45678,
+				DstPort:   8080,
+				TCPFlags:  []string{"SYN"},
+				Seq:       uint32Ptr(1234567890),
+				Length:    74,
+			},
+		},
+		{
+			name: "any interface with vlan",
+			text: "10:15:00.250100 eth0  In  ifindex 2 0a:58:0a:f4:02:05 ethertype 802.1Q (0x8100), length 84: vlan 10, p 0, ethertype IPv6 (0x86dd), (flowlabel 0x1a2b3, hlim 64, next-header TCP (6) payload length: 20) " +
+				"fd00:10:244:2::5.8080 > fd00:10:244:1::3.45678: Flags [R.], cksum 0x0 (correct), seq 0, ack 1234567891, win 0, length 0",
+			want: types.PacketRecord{
+				Timestamp: "10:15:00.250100",
+				Interface: "eth0",
+				Direction: "in",
+				SrcMAC:    "0a:58:0a:f4:02:05",
+				VLANs:     []int{10},
+				Protocol:  "tcp",
+				Src:       "fd00:10:244:2::5",
+				Dst:       "fd00:10:244:1::3",
+				SrcPort:   8080,
+				DstPort:   45678,
+				TCPFlags:  []string{"RST", "ACK"},
+				Seq:       uint32Ptr(0),
+				Ack:       uint32Ptr(1234567891),
+				Length:    84,
+			},
+		},
+		{
+			name: "geneve",
+			text: "10:15:00.250200 02:42:ac:12:00:03 > 02:42:ac:12:00:04, ethertype IPv4 (0x0800), length 148: (tos 0x0, ttl 64, id 0, offset 0, flags [DF], proto UDP (17), length 134)\n" +
+				"    172.18.0.3.12345 > 172.18.0.4.6081: [bad udp cksum 0x5e2f -> 0x1c46!] Geneve, Flags [C], vni 0x5, proto TEB (0x6558), options [class Open Virtual Networking (OVN) (0x102) type 0x80(C) len 8 data 00010002]\n" +
+				"\t0a:58:0a:f4:01:03 > 0a:58:0a:f4:02:05, ethertype IPv4 (0x0800), length 98: (tos 0x0, ttl 63, id 4, offset 0, flags [DF], proto UDP (17), length 84)\n" +
+				"    10.244.1.3.53000 > 10.244.2.5.53: 4660+ A? kubernetes.default.svc.cluster.local. (54)",
+			want: types.PacketRecord{
+				Timestamp: "10:15:00.250200",
+				SrcMAC:    "0a:58:0a:f4:01:03",
+				DstMAC:    "0a:58:0a:f4:02:05",
+				Protocol:  "udp",
+				Src:       "10.244.1.3",
+				Dst:       "10.244.2.5",
+				IPID:      4,
+				SrcPort:   53000,
+				DstPort:   53,
+				Length:    148,
+				Info:      "4660+ A? kubernetes.default.svc.cluster.local. (54)",
+				Geneve: &types.GeneveHeader{
+					OuterSrc: "172.18.0.3",
+					OuterDst: "172.18.0.4",
+					VNI:      5,
+					Options:  "class Open Virtual Networking (OVN) (0x102) type 0x80(C) len 8 data 00010002",
+				},
+			},
+		},
+		{
+			name: "icmp unreachable",
+			text: "10:15:00.250300 IP (tos 0xc0, ttl 64, id 5, offset 0, flags [none], proto ICMP (1), length 84)\n" +
+				"    10.244.2.5 > 10.244.1.3: ICMP 10.244.2.5 udp port 53 unreachable, length 64\n" +
+				"\t(tos 0x0, ttl 63, id 4, offset 0, flags [DF], proto UDP (17), length 56)",
+			want: types.PacketRecord{
+				Timestamp:     "10:15:00.250300",
+				Protocol:      "icmp",
+				Src:           "10.244.2.5",
+				Dst:           "10.244.1.3",
+				IPID:          5,
+				Length:        84,
+				PayloadLength: 64,
+				ICMP:          "10.244.2.5 udp port 53 unreachable",
+			},
+		},
+		{
+			name: "arp",
+			text: "10:15:00.250400 0a:58:0a:f4:01:03 > ff:ff:ff:ff:ff:ff, ethertype ARP (0x0806), length 42: Ethernet (len 6), IPv4 (len 4), Request who-has 10.244.1.1 tell 10.244.1.3, length 28",
+			want: types.PacketRecord{
+				Timestamp: "10:15:00.250400",
+				SrcMAC:    "0a:58:0a:f4:01:03",
+				DstMAC:    "ff:ff:ff:ff:ff:ff",
+				Protocol:  "arp",
+				Length:    42,
+				Info:      "Request who-has 10.244.1.1 tell 10.244.1.3, length 28",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, unparsed := parseTcpdumpText(tt.text, "")
+			if unparsed != "" {
+				t.Fatalf("parseTcpdumpText() unparsed = %q", unparsed)
+			}
+			if len(analysis.Records) != 1 {
+				t.Fatalf("parseTcpdumpText() records = %+v, want one record", analysis.Records)
+			}
+			if got := analysis.Records[0]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("record = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTcpdumpTextAnalysis(t *testing.T) {
+	output := `10:15:00.000000 IP 10.244.1.3.45678 > 10.244.2.5.8080: Flags [S], seq 100, win 64860, length 0
+10:15:01.000000 IP 10.244.1.3.45678 > 10.244.2.5.8080: Flags [S], seq 100, win 64860, length 0
+10:15:01.000100 IP 10.244.2.5.8080 > 10.244.1.3.45678: Flags [R.], seq 0, ack 101, win 0, length 0
+10:15:02.000000 IP 10.244.1.3.45680 > 10.244.2.5.8080: Flags [.], ack 1, win 502, length 0
+10:15:02.000100 IP 10.244.1.3.45680 > 10.244.2.5.8080: Flags [.], ack 1, win 502, length 0
+10:15:03.000000 IP 10.244.2.5 > 10.244.1.3: ICMP 10.244.2.5 udp port 53 unreachable, length 36
+10:15:04.000000 STP 802.1d, Config BPDU
+    continuation of an unknown packet`
+
+	analysis, unparsed := parseTcpdumpText(output, "eth0")
+	if want := "10:15:04.000000 STP 802.1d, Config BPDU\ncontinuation of an unknown packet"; unparsed != want {
+		t.Errorf("unparsed = %q, want %q", unparsed, want)
+	}
+	if len(analysis.Records) != 6 || analysis.Records[0].Interface != "eth0" {
+		t.Fatalf("records = %+v, want 6 records on eth0", analysis.Records)
+	}
+	wantTalkers := []types.PacketFlow{
+		{Protocol: "tcp", Src: "10.244.1.3:45678", Dst: "10.244.2.5:8080", Packets: 2},
+		{Protocol: "tcp", Src: "10.244.1.3:45680", Dst: "10.244.2.5:8080", Packets: 2},
+		{Protocol: "icmp", Src: "10.244.2.5", Dst: "10.244.1.3", Packets: 1},
+		{Protocol: "tcp", Src: "10.244.2.5:8080", Dst: "10.244.1.3:45678", Packets: 1},
+	}
+	if !reflect.DeepEqual(analysis.TopTalkers, wantTalkers) {
+		t.Errorf("top talkers = %+v, want %+v", analysis.TopTalkers, wantTalkers)
+	}
+	wantResets := []types.PacketEvent{{Timestamp: "10:15:01.000100", Src: "10.244.2.5:8080", Dst: "10.244.1.3:45678"}}
+	if !reflect.DeepEqual(analysis.TCPResets, wantResets) {
+		t.Errorf("resets = %+v, want %+v", analysis.TCPResets, wantResets)
+	}
+	// Pure ACKs repeat their sequence number and are not retransmissions.
+	wantRetransmissions := []types.TCPRetransmission{{Src: "10.244.1.3:45678", Dst: "10.244.2.5:8080", Seq: 100, Packets: 2}}
+	if !reflect.DeepEqual(analysis.Retransmissions, wantRetransmissions) {
+		t.Errorf("retransmissions = %+v, want %+v", analysis.Retransmissions, wantRetransmissions)
+	}
+	wantUnreachables := []types.PacketEvent{{Timestamp: "10:15:03.000000", Src: "10.244.2.5", Dst: "10.244.1.3", Info: "10.244.2.5 udp port 53 unreachable"}}
+	if !reflect.DeepEqual(analysis.ICMPUnreachables, wantUnreachables) {
+		t.Errorf("icmp unreachables = %+v, want %+v", analysis.ICMPUnreachables, wantUnreachables)
+	}
+}
@@ -25,6 +25,9 @@ type TcpdumpParams struct {
 	PacketCount int    `json:"packet_count,omitempty"`
 	Snaplen     int    `json:"snaplen,omitempty"`
 
+	// Mode is 'text' to return the decoded packets, or 'pcap' to store the capture as an MCP resource.
+	Mode string `json:"mode,omitempty"`
+
 	timeout.TimeoutParams
 }
 
@@ -40,8 +43,627 @@ type PwruParams struct {
 	timeout.TimeoutParams
 }
 
+// RetisParams contains parameters for tracing packets through the kernel, the OVS datapath and
+// nftables with retis.
+type RetisParams struct {
+	BaseNetworkDiagParams
+
+	NodeName         string `json:"node_name"`
+	NodePodNamespace string `json:"node_pod_namespace,omitempty"`
+	EventLimit       int    `json:"event_limit,omitempty"`
+	DurationSeconds  int    `json:"duration_seconds,omitempty"`
+
+	timeout.TimeoutParams
+}
+
+// ProbeSource is where a connectivity probe runs from: a pod, with a container of the pod or an
+// ephemeral debug container, or a node, with a debug pod in the host network.
+type ProbeSource struct {
+	SourceNamespace string `json:"source_namespace,omitempty"`
+	SourcePod       string `json:"source_pod,omitempty"`
+	SourceContainer string `json:"source_container,omitempty"`
+	DebugContainer  bool   `json:"debug_container,omitempty"`
+
+	SourceNode       string `json:"source_node,omitempty"`
+	NodePodNamespace string `json:"node_pod_namespace,omitempty"`
+}
+
+// ProbeTarget is the target of a connectivity probe: a pod, a service by its cluster IP, a cluster
+// IP, the node port of a service on a node, or an external host.
+type ProbeTarget struct {
+	// TargetType is 'pod', 'service', 'cluster_ip', 'nodeport' or 'host'.
+	TargetType      string `json:"target_type"`
+	TargetNamespace string `json:"target_namespace,omitempty"`
+	TargetName      string `json:"target_name,omitempty"`
+	TargetNode      string `json:"target_node,omitempty"`
+	TargetAddress   string `json:"target_address,omitempty"`
+	// Port is the port of the pod, cluster IP or host, or the service port of a service or node port.
+	Port int `json:"port,omitempty"`
+}
+
+// ConnectivityProbeParams contains parameters for probing the connectivity from a source to a
+// target with ping, a TCP connect, an HTTP GET or a UDP datagram.
+type ConnectivityProbeParams struct {
+	ProbeSource
+	ProbeTarget
+
+	// Protocol is 'icmp', 'tcp', 'http' or 'udp'.
+	Protocol            string `json:"protocol"`
+	HTTPPath            string `json:"http_path,omitempty"`
+	Count               int    `json:"count,omitempty"`
+	ProbeTimeoutSeconds int    `json:"probe_timeout_seconds,omitempty"`
+
+	timeout.TimeoutParams
+}
+
+// MatrixPod is a pod of a connectivity matrix.
+type MatrixPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Container string `json:"container,omitempty"`
+}
+
+// MatrixService is a service probed from every pod of a connectivity matrix, on its port or its
+// first port.
+type MatrixService struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Port      int    `json:"port,omitempty"`
+}
+
+// MatrixEndpoint is an external endpoint probed from every pod of a connectivity matrix, with ICMP,
+// or with a TCP connect when it has a port.
+type MatrixEndpoint struct {
+	Address string `json:"address"`
+	Port    int    `json:"port,omitempty"`
+}
+
+// ConnectivityMatrixParams contains parameters for probing the connectivity between one pod per
+// node, and from these pods to services and external endpoints.
+type ConnectivityMatrixParams struct {
+	// Pods are the pods of the matrix. When empty, one pod per node is picked from Namespace and
+	// LabelSelector.
+	Pods          []MatrixPod `json:"pods,omitempty"`
+	Namespace     string      `json:"namespace,omitempty"`
+	LabelSelector string      `json:"label_selector,omitempty"`
+	Nodes         []string    `json:"nodes,omitempty"`
+
+	DebugContainer bool `json:"debug_container,omitempty"`
+	// Protocol of the probes between pods: 'icmp', 'tcp', 'http' or 'udp'.
+	Protocol string `json:"protocol,omitempty"`
+	PodPort  int    `json:"pod_port,omitempty"`
+
+	Services  []MatrixService  `json:"services,omitempty"`
+	Endpoints []MatrixEndpoint `json:"external_endpoints,omitempty"`
+
+	Count               int `json:"count,omitempty"`
+	ProbeTimeoutSeconds int `json:"probe_timeout_seconds,omitempty"`
+	Concurrency         int `json:"concurrency,omitempty"`
+
+	timeout.TimeoutParams
+}
+
+// DNSCheckParams contains parameters for resolving a name from a pod, against the nameservers
+// of the pod, the DNS service and each DNS pod, and for checking the OVN load balancer of the DNS
+// service.
+type DNSCheckParams struct {
+	Namespace      string `json:"namespace"`
+	Pod            string `json:"pod"`
+	Container      string `json:"container,omitempty"`
+	DebugContainer bool   `json:"debug_container,omitempty"`
+
+	Name string `json:"name"`
+	// QueryType is 'A', 'AAAA', 'CNAME', 'SRV', 'TXT', 'MX' or 'NS'.
+	QueryType string `json:"query_type,omitempty"`
+
+	DNSServiceNamespace string `json:"dns_service_namespace,omitempty"`
+	DNSServiceName      string `json:"dns_service_name,omitempty"`
+	OVNKubeNamespace    string `json:"ovnkube_namespace,omitempty"`
+	// OVNKubePod is the pod whose northbound database is read, instead of the ovnkube-node pod of
+	// the node of the pod.
+	OVNKubePod       string `json:"ovnkube_pod,omitempty"`
+	OVNKubeContainer string `json:"ovnkube_container,omitempty"`
+
+	QueryTimeoutSeconds int `json:"query_timeout_seconds,omitempty"`
+
+	timeout.TimeoutParams
+}
+
+// PathCaptureParams contains parameters for capturing packets at the same time at the points of
+// the path between a source and a destination pod.
+type PathCaptureParams struct {
+	BaseNetworkDiagParams
+
+	SrcNamespace string `json:"src_namespace"`
+	SrcPod       string `json:"src_pod"`
+	SrcContainer string `json:"src_container,omitempty"`
+	DstNamespace string `json:"dst_namespace"`
+	DstPod       string `json:"dst_pod"`
+	DstContainer string `json:"dst_container,omitempty"`
+	// DebugContainer captures the pods from ephemeral debug containers instead of their
+	// containers.
+	DebugContainer bool `json:"debug_container,omitempty"`
+
+	PodInterface  string `json:"pod_interface,omitempty"`
+	NodeInterface string `json:"node_interface,omitempty"`
+	// SrcNodeInterface and DstNodeInterface replace NodeInterface on the source and the
+	// destination node, such as to capture the uplink of one node.
+	SrcNodeInterface string `json:"src_node_interface,omitempty"`
+	DstNodeInterface string `json:"dst_node_interface,omitempty"`
+	NodePodNamespace string `json:"node_pod_namespace,omitempty"`
+
+	PacketCount     int `json:"packet_count,omitempty"`
+	Snaplen         int `json:"snaplen,omitempty"`
+	DurationSeconds int `json:"duration_seconds,omitempty"`
+
+	timeout.TimeoutParams
+}
+
+// MirrorCaptureParams contains parameters for capturing the packets of an OVS port through a
+// temporary OVS mirror. The port is given by name on a node, or is the port of a pod.
+type MirrorCaptureParams struct {
+	BaseNetworkDiagParams
+
+	NodeName         string `json:"node_name,omitempty"`
+	NodePodNamespace string `json:"node_pod_namespace,omitempty"`
+	Port             string `json:"port,omitempty"`
+
+	PodNamespace string `json:"pod_namespace,omitempty"`
+	PodName      string `json:"pod_name,omitempty"`
+
+	PacketCount     int `json:"packet_count,omitempty"`
+	Snaplen         int `json:"snaplen,omitempty"`
+	DurationSeconds int `json:"duration_seconds,omitempty"`
+
+	timeout.TimeoutParams
+}
+
+// PcapProtocol counts the packets of a protocol in a capture.
+type PcapProtocol struct {
+	Protocol string `json:"protocol"`
+	Packets  int    `json:"packets"`
+	Bytes    int    `json:"bytes"`
+}
+
+// PcapConversation counts the packets exchanged between two endpoints in a capture, in both
+// directions. Endpoints are addresses, with the port for TCP, UDP and SCTP.
+type PcapConversation struct {
+	Protocol string `json:"protocol"`
+	A        string `json:"a"`
+	B        string `json:"b"`
+	Packets  int    `json:"packets"`
+	Bytes    int    `json:"bytes"`
+}
+
+// PcapSummary summarizes the packets of a capture. Bytes are the original packet lengths.
+type PcapSummary struct {
+	LinkType      string             `json:"link_type"`
+	Packets       int                `json:"packets"`
+	Bytes         int                `json:"bytes"`
+	Start         string             `json:"start,omitempty"`
+	End           string             `json:"end,omitempty"`
+	Protocols     []PcapProtocol     `json:"protocols"`
+	Conversations []PcapConversation `json:"conversations"`
+}
+
+// PcapCapture is a capture stored by the server and exposed as an MCP resource.
+type PcapCapture struct {
+	URI      string      `json:"uri"`
+	MIMEType string      `json:"mime_type"`
+	Size     int         `json:"size"`
+	Summary  PcapSummary `json:"summary"`
+}
+
+// GeneveHeader is the Geneve encapsulation of a packet.
+type GeneveHeader struct {
+	OuterSrc string `json:"outer_src"`
+	OuterDst string `json:"outer_dst"`
+	VNI      uint32 `json:"vni"`
+	// Options are the Geneve options as printed by tcpdump.
+	Options string `json:"options,omitempty"`
+}
+
+// PacketRecord is a packet decoded by tcpdump. The addresses and ports of an encapsulated packet
+// are those of the inner packet, and the outer header is in Geneve.
+type PacketRecord struct {
+	Timestamp string `json:"timestamp"`
+	Interface string `json:"interface,omitempty"`
+	// Direction is 'in', 'out', 'broadcast', 'multicast' or 'other_host' for captures on the 'any'
+	// interface.
+	Direction string   `json:"direction,omitempty"`
+	SrcMAC    string   `json:"src_mac,omitempty"`
+	DstMAC    string   `json:"dst_mac,omitempty"`
+	VLANs     []int    `json:"vlans,omitempty"`
+	Protocol  string   `json:"protocol"`
+	Src       string   `json:"src,omitempty"`
+	Dst       string   `json:"dst,omitempty"`
+	IPID      int      `json:"ip_id,omitempty"`
+	SrcPort   int      `json:"src_port,omitempty"`
+	DstPort   int      `json:"dst_port,omitempty"`
+	TCPFlags  []string `json:"tcp_flags,omitempty"`
+	Seq       *uint32  `json:"seq,omitempty"`
+	Ack       *uint32  `json:"ack,omitempty"`
+	// Length is the length of the frame, and PayloadLength the length of the transport payload.
+	Length        int           `json:"length"`
+	PayloadLength int           `json:"payload_length"`
+	ICMP          string        `json:"icmp,omitempty"`
+	Info          string        `json:"info,omitempty"`
+	Geneve        *GeneveHeader `json:"geneve,omitempty"`
+}
+
+// PacketFlow counts the packets sent from a source to a destination. Endpoints are addresses,
+// with the port when the protocol has ports.
+type PacketFlow struct {
+	Protocol string `json:"protocol"`
+	Src      string `json:"src"`
+	Dst      string `json:"dst"`
+	Packets  int    `json:"packets"`
+	Bytes    int    `json:"bytes"`
+}
+
+// PacketEvent is a notable packet of a capture.
+type PacketEvent struct {
+	Timestamp string `json:"timestamp"`
+	Src       string `json:"src"`
+	Dst       string `json:"dst"`
+	Info      string `json:"info,omitempty"`
+}
+
+// TCPRetransmission is a TCP segment seen more than once in a capture.
+type TCPRetransmission struct {
+	Src     string `json:"src"`
+	Dst     string `json:"dst"`
+	Seq     uint32 `json:"seq"`
+	Packets int    `json:"packets"`
+}
+
+// PacketAnalysis holds the packets decoded by tcpdump and aggregations over them.
+type PacketAnalysis struct {
+	Records          []PacketRecord      `json:"records"`
+	TopTalkers       []PacketFlow        `json:"top_talkers"`
+	TCPResets        []PacketEvent       `json:"tcp_resets"`
+	Retransmissions  []TCPRetransmission `json:"retransmissions"`
+	ICMPUnreachables []PacketEvent       `json:"icmp_unreachables"`
+}
+
+// CapturePoint is a point of a path where packets were captured.
+type CapturePoint struct {
+	// Name is 'src_pod', 'src_node', 'dst_node' or 'dst_pod'.
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	// Via is 'exec', 'debug_container' or 'debug_pod'.
+	Via       string `json:"via"`
+	Interface string `json:"interface"`
+	Packets   int    `json:"packets"`
+	// First and Last are the timestamps of the first and the last packet captured, by the clock of
+	// the node of the point.
+	First string `json:"first,omitempty"`
+	Last  string `json:"last,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// PathPacket is a packet correlated across the capture points of a path.
+type PathPacket struct {
+	// Direction is 'forward' for packets from the source pod to the destination pod, and 'reply'
+	// for packets in the other direction.
+	Direction string `json:"direction"`
+	// Timestamp is the time the packet was captured at the first point of its path where it was
+	// seen, by the clock of the node of that point.
+	Timestamp     string   `json:"timestamp"`
+	Protocol      string   `json:"protocol"`
+	Src           string   `json:"src"`
+	Dst           string   `json:"dst"`
+	IPID          int      `json:"ip_id,omitempty"`
+	TCPFlags      []string `json:"tcp_flags,omitempty"`
+	Seq           *uint32  `json:"seq,omitempty"`
+	PayloadLength int      `json:"payload_length"`
+	// SeenAt are the points where the packet was captured, in the order of its direction.
+	SeenAt   []string `json:"seen_at"`
+	LastSeen string   `json:"last_seen"`
+}
+
+// PathCaptureResult holds the packets captured along the path between two pods.
+type PathCaptureResult struct {
+	BPFFilter       string         `json:"bpf_filter"`
+	DurationSeconds int            `json:"duration_seconds"`
+	Points          []CapturePoint `json:"points"`
+	Packets         []PathPacket   `json:"packets"`
+	// LastSeen counts the packets by the last point where they were seen.
+	LastSeen map[string]int `json:"last_seen"`
+	// Uncorrelated counts the captured packets without IP addresses, such as ARP packets.
+	Uncorrelated int `json:"uncorrelated"`
+}
+
+// MirrorCaptureResult holds the packets of an OVS port captured through a temporary mirror.
+type MirrorCaptureResult struct {
+	Node   string `json:"node"`
+	Port   string `json:"port"`
+	Bridge string `json:"bridge"`
+	// Mirror is the name of the mirror and of its output port, both removed after the capture.
+	Mirror          string          `json:"mirror"`
+	DurationSeconds int             `json:"duration_seconds"`
+	Packets         *PacketAnalysis `json:"packets"`
+	// Output holds the text printed by tcpdump, and Unparsed its lines that could not be parsed
+	// into packet records.
+	Output   string `json:"output,omitempty"`
+	Unparsed string `json:"unparsed,omitempty"`
+	Stderr   string `json:"stderr,omitempty"`
+}
+
+// RetisEvent is an event of a packet collected by retis.
+type RetisEvent struct {
+	// Timestamp is the monotonic time of the event in nanoseconds.
+	Timestamp uint64 `json:"timestamp"`
+	// Probe is the probe type and the kernel or userspace symbol, e.g. 'raw_tracepoint:net:netif_receive_skb'.
+	Probe     string `json:"probe"`
+	Interface string `json:"interface,omitempty"`
+	// OVS summarizes the OVS event, e.g. 'upcall port=2 cmd=1' or 'action_execute action=output port=3'.
+	OVS string `json:"ovs,omitempty"`
+	// Nft is the table, chain and verdict of the nftables rule matched by the packet.
+	Nft        string `json:"nft,omitempty"`
+	DropReason string `json:"drop_reason,omitempty"`
+}
+
+// RetisPacket is the timeline of the events of a packet, tracked by retis across its copies and
+// clones.
+type RetisPacket struct {
+	// TrackingID identifies the packet: the address of its first data buffer and the time it was first seen.
+	TrackingID string `json:"tracking_id"`
+	Protocol   string `json:"protocol,omitempty"`
+	Src        string `json:"src,omitempty"`
+	Dst        string `json:"dst,omitempty"`
+	// Interfaces are the interfaces the packet was seen on, in order.
+	Interfaces []string `json:"interfaces,omitempty"`
+	// OVSFlows are the OVS datapath flows the packet matched, with their unique flow ID and actions.
+	OVSFlows []string `json:"ovs_flows,omitempty"`
+	// DropReason is the reason the packet was dropped, by the kernel or by an OVS drop action.
+	DropReason string       `json:"drop_reason,omitempty"`
+	Events     []RetisEvent `json:"events"`
+}
+
+// RetisResult holds the packets traced by retis on a node.
+type RetisResult struct {
+	Node            string        `json:"node"`
+	DurationSeconds int           `json:"duration_seconds"`
+	Events          int           `json:"events"`
+	Packets         []RetisPacket `json:"packets"`
+	// Untracked counts the events not tied to a packet, such as the OVS userspace events of upcalls.
+	Untracked int `json:"untracked"`
+	// Truncated is true when more events than the event limit were collected.
+	Truncated bool `json:"truncated,omitempty"`
+	// Output holds the lines that could not be parsed as retis events.
+	Output string `json:"output,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+}
+
+// ProbeResult is the result of a connectivity probe.
+type ProbeResult struct {
+	// Source is 'pod <namespace>/<name>' or 'node <name>'.
+	Source string `json:"source"`
+	// Via is 'exec', 'debug_container' or 'debug_pod'.
+	Via      string `json:"via"`
+	Target   string `json:"target"`
+	Protocol string `json:"protocol"`
+	// Address is the address probed, resolved from the target, or by the source for host names.
+	Address string `json:"address,omitempty"`
+	Port    int    `json:"port,omitempty"`
+	Success bool   `json:"success"`
+	// LatencyMs is the average round-trip time for ICMP, the total time of the request for HTTP,
+	// and the time of the probe command for TCP and UDP.
+	LatencyMs  float64 `json:"latency_ms,omitempty"`
+	Sent       int     `json:"sent,omitempty"`
+	Received   int     `json:"received,omitempty"`
+	HTTPStatus int     `json:"http_status,omitempty"`
+	// ErrorClass is 'dns', 'refused', 'timeout', 'unreachable', 'no_reply', 'http_status',
+	// 'tool_missing' or 'error'.
+	ErrorClass string `json:"error_class,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Output     string `json:"output,omitempty"`
+}
+
+// MatrixNode is the pod probing from and probed on a node in a connectivity matrix.
+type MatrixNode struct {
+	Node string `json:"node"`
+	Pod  string `json:"pod"`
+	IP   string `json:"ip"`
+}
+
+// MatrixRow holds the results of the probes from the pod of a node. Results are keyed by the node
+// of the target pod, or by the service or external endpoint, and are 'ok' or the error class of
+// the probe.
+type MatrixRow struct {
+	Node    string            `json:"node"`
+	Results map[string]string `json:"results"`
+}
+
+// MatrixPairFailure groups the failed probes between the pods of two nodes.
+type MatrixPairFailure struct {
+	NodeA string `json:"node_a"`
+	NodeB string `json:"node_b"`
+	// Direction is 'both', or '<source node> -> <target node>' when probes fail one way only.
+	Direction    string   `json:"direction"`
+	ErrorClasses []string `json:"error_classes"`
+	Errors       []string `json:"errors,omitempty"`
+}
+
+// MatrixTargetFailure groups the failed probes to a service or an external endpoint.
+type MatrixTargetFailure struct {
+	Target       string   `json:"target"`
+	FailedFrom   []string `json:"failed_from"`
+	SucceededOn  int      `json:"succeeded_on"`
+	ErrorClasses []string `json:"error_classes"`
+	Errors       []string `json:"errors,omitempty"`
+}
+
+// MatrixSuspect is a node whose probes fail while the probes of other nodes succeed.
+type MatrixSuspect struct {
+	Node   string `json:"node"`
+	Reason string `json:"reason"`
+}
+
+// ConnectivityMatrixResult is the result of a connectivity matrix.
+type ConnectivityMatrixResult struct {
+	Pods []MatrixNode `json:"pods"`
+	// NodesWithoutPods are the requested nodes without a pod to probe from.
+	NodesWithoutPods []string              `json:"nodes_without_pods,omitempty"`
+	Protocol         string                `json:"protocol"`
+	Probes           int                   `json:"probes"`
+	Failed           int                   `json:"failed"`
+	Matrix           []MatrixRow           `json:"matrix"`
+	PairFailures     []MatrixPairFailure   `json:"pair_failures,omitempty"`
+	TargetFailures   []MatrixTargetFailure `json:"target_failures,omitempty"`
+	Suspects         []MatrixSuspect       `json:"suspects,omitempty"`
+}
+
+// DNSResolvConf is the resolver configuration of a pod.
+type DNSResolvConf struct {
+	Nameservers []string `json:"nameservers"`
+	Search      []string `json:"search,omitempty"`
+	Ndots       int      `json:"ndots"`
+	Options     []string `json:"options,omitempty"`
+}
+
+// DNSQuery is a query of a name to a DNS server.
+type DNSQuery struct {
+	Name string `json:"name"`
+	// Rcode is the response code, such as 'NOERROR', 'NXDOMAIN' or 'SERVFAIL', or 'TIMEOUT' or
+	// 'ERROR' when the server did not answer.
+	Rcode   string   `json:"rcode"`
+	Answers []string `json:"answers,omitempty"`
+	TimeMs  int      `json:"time_ms,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// DNSPath is the resolution of a name from a pod against a DNS server, trying the search
+// candidates in order until one has answers.
+type DNSPath struct {
+	// Kind is 'nameserver' for the nameservers of the pod, 'service' for the DNS service VIP, and
+	// 'endpoint' for the DNS pods.
+	Kind   string `json:"kind"`
+	Server string `json:"server"`
+	// Pod is the DNS pod of an endpoint.
+	Pod      string     `json:"pod,omitempty"`
+	Answered bool       `json:"answered"`
+	Rcode    string     `json:"rcode"`
+	Answers  []string   `json:"answers,omitempty"`
+	Queries  []DNSQuery `json:"queries"`
+}
+
+// DNSLoadBalancerVIP compares the backends of a VIP of the OVN load balancer of the DNS service
+// with the ready DNS pods.
+type DNSLoadBalancerVIP struct {
+	LoadBalancer string `json:"load_balancer,omitempty"`
+	Protocol     string `json:"protocol"`
+	VIP          string `json:"vip"`
+	// Found tells whether the VIP is in a load balancer applied to the logical switch of the node.
+	// The backends of a VIP that is not found are not compared.
+	Found      bool     `json:"found"`
+	Backends   []string `json:"backends"`
+	Missing    []string `json:"missing,omitempty"`
+	Unexpected []string `json:"unexpected,omitempty"`
+}
+
+// DNSLoadBalancerCheck is the check of the OVN load balancers of the DNS service applied to the
+// logical switch of the node of the pod.
+type DNSLoadBalancerCheck struct {
+	OVNKubePod string `json:"ovnkube_pod,omitempty"`
+	// Healthy tells whether the backends of the VIPs that are found match the ready DNS pods.
+	Healthy bool                 `json:"healthy"`
+	VIPs    []DNSLoadBalancerVIP `json:"vips,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
+// DNSCheckResult is the result of a DNS check.
+type DNSCheckResult struct {
+	Pod string `json:"pod"`
+	// Via is 'exec' or 'debug_container'.
+	Via          string                `json:"via"`
+	Name         string                `json:"name"`
+	QueryType    string                `json:"query_type"`
+	ResolvConf   DNSResolvConf         `json:"resolv_conf"`
+	Candidates   []string              `json:"candidates"`
+	Paths        []DNSPath             `json:"paths"`
+	LoadBalancer *DNSLoadBalancerCheck `json:"load_balancer,omitempty"`
+	Findings     []string              `json:"findings"`
+}
+
+// PathMTUParams contains parameters for discovering the path MTU from a source to a pod, a node
+// or an IP address with pings that must not be fragmented.
+type PathMTUParams struct {
+	ProbeSource
+
+	// TargetType is 'pod', 'node' or 'host'.
+	TargetType      string `json:"target_type"`
+	TargetNamespace string `json:"target_namespace,omitempty"`
+	TargetName      string `json:"target_name,omitempty"`
+	TargetNode      string `json:"target_node,omitempty"`
+	TargetAddress   string `json:"target_address,omitempty"`
+
+	// MaxMTU is the largest packet size probed. The default is the MTU of the interface of the
+	// route of the source to the target.
+	MaxMTU              int `json:"max_mtu,omitempty"`
+	ProbeTimeoutSeconds int `json:"probe_timeout_seconds,omitempty"`
+
+	timeout.TimeoutParams
+}
+
+// MTUProbe is a ping of a packet size with the don't fragment bit.
+type MTUProbe struct {
+	Size   int  `json:"size"`
+	Passed bool `json:"passed"`
+	// ReportedMTU is the MTU of a fragmentation needed or packet too big error, or of the local
+	// interface refusing the packet.
+	ReportedMTU int    `json:"reported_mtu,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// NodeMTUs are the MTUs configured on a node.
+type NodeMTUs struct {
+	Node string `json:"node"`
+	// Interfaces holds the MTUs of ovn-k8s-mp0, br-ex, the Geneve interface and the uplink.
+	Interfaces map[string]int `json:"interfaces,omitempty"`
+	// Uplink is the interface of the node attached to br-ex.
+	Uplink  string `json:"uplink,omitempty"`
+	EncapIP string `json:"encap_ip,omitempty"`
+	// GeneveOverhead is the number of bytes the Geneve encapsulation adds to a pod packet sent
+	// from the encap IP.
+	GeneveOverhead int    `json:"geneve_overhead,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// PathMTUResult is the result of a path MTU discovery.
+type PathMTUResult struct {
+	// Source is 'pod <namespace>/<name>' or 'node <name>'.
+	Source string `json:"source"`
+	// Via is 'exec', 'debug_container' or 'debug_pod'.
+	Via     string `json:"via"`
+	Target  string `json:"target"`
+	Address string `json:"address"`
+	// Device and DeviceMTU are the interface of the route of the source to the target and its MTU,
+	// and RouteMTU the MTU of the route, such as a path MTU learned by the kernel.
+	Device    string `json:"device,omitempty"`
+	DeviceMTU int    `json:"device_mtu,omitempty"`
+	RouteMTU  int    `json:"route_mtu,omitempty"`
+	Reachable bool   `json:"reachable"`
+	// PathMTU is the largest packet size that gets through, and SmallestFailed the smallest packet
+	// size probed that does not.
+	PathMTU        int        `json:"path_mtu,omitempty"`
+	SmallestFailed int        `json:"smallest_failed,omitempty"`
+	Probes         []MTUProbe `json:"probes"`
+	Nodes          []NodeMTUs `json:"nodes,omitempty"`
+	Findings       []string   `json:"findings"`
+	// ErrorClass is 'tool_missing' when the source cannot run ip or the pings, 'no_route' when the
+	// source has no route to the target, and 'error' when no ping ran.
+	ErrorClass string `json:"error_class,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
 // CommandResult represents the output and status of an executed command.
 type CommandResult struct {
-	Output string `json:"output"`
-	Stderr string `json:"stderr,omitempty"`
+	Output  string          `json:"output"`
+	Stderr  string          `json:"stderr,omitempty"`
+	Pcap    *PcapCapture    `json:"pcap,omitempty"`
+	Packets *PacketAnalysis `json:"packets,omitempty"`
+	// Unparsed holds the lines of Output that could not be parsed into packet records.
+	Unparsed string `json:"unparsed,omitempty"`
 }
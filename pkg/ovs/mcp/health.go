@@ -0,0 +1,373 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	ovstypes "github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/ovs/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// DefaultHealthLabelSelector selects the ovnkube-node pods the health sweep runs against.
+	DefaultHealthLabelSelector = "app=ovnkube-node"
+	// DefaultHealthConcurrency is the number of nodes inspected in parallel by default.
+	DefaultHealthConcurrency = 10
+	// MaxHealthConcurrency is the maximum number of nodes inspected in parallel.
+	MaxHealthConcurrency = 50
+)
+
+// tunnelInterfaceTypes are the OVS interface types that carry BFD sessions
+// between chassis.
+var tunnelInterfaceTypes = map[string]bool{
+	"geneve": true,
+	"vxlan":  true,
+	"stt":    true,
+	"gre":    true,
+}
+
+// ovsdbTable is the output of 'ovs-vsctl --format=json --data=json list <table>'.
+type ovsdbTable struct {
+	Headings []string `json:"headings"`
+	Data     [][]any  `json:"data"`
+}
+
+// HealthSweep runs an OVS health check in parallel against every ovnkube-node
+// pod and reports the unhealthy interfaces and bridges grouped by node.
+func (s *MCPServer) HealthSweep(ctx context.Context, req *mcp.CallToolRequest,
+	in ovstypes.HealthSweepParams) (*mcp.CallToolResult, ovstypes.HealthSweepResult, error) {
+	if in.Namespace == "" {
+		return nil, ovstypes.HealthSweepResult{}, fmt.Errorf("namespace is required")
+	}
+	labelSelector := in.LabelSelector
+	if labelSelector == "" {
+		labelSelector = DefaultHealthLabelSelector
+	}
+	if _, err := labels.Parse(labelSelector); err != nil {
+		return nil, ovstypes.HealthSweepResult{}, fmt.Errorf("invalid label selector %q: %w", labelSelector, err)
+	}
+	concurrency := in.MaxConcurrency
+	if concurrency == 0 {
+		concurrency = DefaultHealthConcurrency
+	}
+	if concurrency < 0 || concurrency > MaxHealthConcurrency {
+		return nil, ovstypes.HealthSweepResult{}, fmt.Errorf("max_concurrency must be between 1 and %d", MaxHealthConcurrency)
+	}
+
+	// If timeout is specified, create a new context with timeout
+	var cancel context.CancelFunc
+	ctx, cancel = in.TimeoutParams.WithTimeout(ctx)
+	if cancel != nil {
+		defer cancel()
+	}
+
+	pods, err := s.listPods(ctx, in.Namespace, labelSelector)
+	if err != nil {
+		return nil, ovstypes.HealthSweepResult{}, fmt.Errorf("failed to list pods with selector %q in namespace %s: %w",
+			labelSelector, in.Namespace, err)
+	}
+	targets, missing := selectNodePods(pods, in.Nodes)
+	if len(targets) == 0 && len(missing) == 0 {
+		return nil, ovstypes.HealthSweepResult{}, fmt.Errorf("no running pods with selector %q found in namespace %s",
+			labelSelector, in.Namespace)
+	}
+
+	nodes := make([]ovstypes.NodeHealth, len(targets))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, pod := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			nodes[i] = s.checkNodeHealth(ctx, pod)
+		}()
+	}
+	wg.Wait()
+
+	for _, node := range missing {
+		nodes = append(nodes, ovstypes.NodeHealth{
+			Node:  node,
+			Error: fmt.Sprintf("no running pod with selector %q found on node", labelSelector),
+		})
+	}
+	slices.SortFunc(nodes, func(a, b ovstypes.NodeHealth) int {
+		return strings.Compare(a.Node, b.Node)
+	})
+
+	result := ovstypes.HealthSweepResult{NodesChecked: len(nodes), Nodes: nodes}
+	for _, node := range nodes {
+		switch {
+		case node.Error != "":
+			result.NodesFailed++
+		case !node.Healthy:
+			result.NodesUnhealthy++
+		}
+	}
+	return nil, result, nil
+}
+
+// selectNodePods returns one running pod per node, restricted to the given
+// nodes when the list is not empty. The requested nodes that have no running
+// pod are returned separately.
+func selectNodePods(pods []corev1.Pod, nodes []string) ([]corev1.Pod, []string) {
+	wanted := map[string]bool{}
+	for _, node := range nodes {
+		wanted[node] = true
+	}
+	seen := map[string]bool{}
+	var targets []corev1.Pod
+	for _, pod := range pods {
+		node := pod.Spec.NodeName
+		if node == "" || pod.Status.Phase != corev1.PodRunning || seen[node] {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[node] {
+			continue
+		}
+		seen[node] = true
+		targets = append(targets, pod)
+	}
+	var missing []string
+	for _, node := range nodes {
+		if !seen[node] {
+			missing = append(missing, node)
+		}
+	}
+	slices.Sort(missing)
+	return targets, slices.Compact(missing)
+}
+
+// checkNodeHealth inspects the OVS database of a single ovnkube-node pod.
+func (s *MCPServer) checkNodeHealth(ctx context.Context, pod corev1.Pod) ovstypes.NodeHealth {
+	health := ovstypes.NodeHealth{Node: pod.Spec.NodeName, Pod: pod.Name}
+	tables := map[string]string{
+		"Interface":  "_uuid,name,type,error,ofport,link_state,bfd,bfd_status",
+		"Port":       "_uuid,name,interfaces",
+		"Bridge":     "_uuid,name,ports,controller",
+		"Controller": "_uuid,target,is_connected",
+	}
+	rows := map[string][]map[string]any{}
+	for table, columns := range tables {
+		stdout, stderr, err := s.runPodExecCommand(ctx, pod.Namespace, pod.Name, "",
+			[]string{"ovs-vsctl", "--format=json", "--data=json", "--columns=" + columns, "list", table})
+		if err != nil {
+			health.Error = fmt.Sprintf("failed to list OVS %s table from pod %s/%s: %v", table, pod.Namespace, pod.Name, err)
+			return health
+		}
+		stderr = strings.TrimSpace(stderr)
+		rows[table], err = parseOVSDBTable(stdout)
+		if err != nil {
+			health.Error = fmt.Sprintf("failed to parse OVS %s table from pod %s/%s: %v", table, pod.Namespace, pod.Name, err)
+			if stderr != "" {
+				health.Error += ": " + stderr
+			}
+			return health
+		}
+		// Warnings, such as database version notices, do not hide the findings of the node.
+		if stderr != "" {
+			health.Warnings = append(health.Warnings, fmt.Sprintf("ovs-vsctl list %s: %s", table, stderr))
+		}
+	}
+	slices.Sort(health.Warnings)
+	health.Findings = evaluateOVSHealth(rows["Interface"], rows["Port"], rows["Bridge"], rows["Controller"])
+	health.Healthy = len(health.Findings) == 0
+	return health
+}
+
+// evaluateOVSHealth inspects the Interface, Port, Bridge and Controller rows
+// of an OVS database and returns the findings sorted by bridge and name.
+func evaluateOVSHealth(interfaces, ports, bridges, controllers []map[string]any) []ovstypes.HealthFinding {
+	portBridge := map[string]string{}
+	for _, bridge := range bridges {
+		for _, port := range ovsdbUUIDs(bridge["ports"]) {
+			portBridge[port] = ovsdbString(bridge["name"])
+		}
+	}
+	ifaceBridge := map[string]string{}
+	for _, port := range ports {
+		for _, iface := range ovsdbUUIDs(port["interfaces"]) {
+			ifaceBridge[iface] = portBridge[ovsdbString(port["_uuid"])]
+		}
+	}
+
+	var findings []ovstypes.HealthFinding
+	for _, iface := range interfaces {
+		name := ovsdbString(iface["name"])
+		ifaceType := ovsdbString(iface["type"])
+		bridge := ifaceBridge[ovsdbString(iface["_uuid"])]
+		newFinding := func(kind ovstypes.HealthFindingKind, detail string) ovstypes.HealthFinding {
+			return ovstypes.HealthFinding{Kind: kind, Bridge: bridge, Name: name, Type: ifaceType, Detail: detail}
+		}
+		if ifaceError := ovsdbString(iface["error"]); ifaceError != "" {
+			findings = append(findings, newFinding(ovstypes.HealthInterfaceError, ifaceError))
+		}
+		if ofport, ok := ovsdbInt(iface["ofport"]); ok && ofport == -1 {
+			findings = append(findings, newFinding(ovstypes.HealthInvalidOfport, "ofport is -1, the interface could not be attached to the datapath"))
+		}
+		if ovsdbString(iface["link_state"]) == "down" {
+			findings = append(findings, newFinding(ovstypes.HealthLinkDown, "link_state is down"))
+		}
+		if tunnelInterfaceTypes[ifaceType] && ovsdbMap(iface["bfd"])["enable"] == "true" {
+			status := ovsdbMap(iface["bfd_status"])
+			if status["state"] != "up" {
+				state := status["state"]
+				if state == "" {
+					state = "unknown"
+				}
+				detail := fmt.Sprintf("bfd state is %s", state)
+				if remote := status["remote_state"]; remote != "" {
+					detail += fmt.Sprintf(", remote state is %s", remote)
+				}
+				if diagnostic := status["diagnostic"]; diagnostic != "" {
+					detail += fmt.Sprintf(", diagnostic: %s", diagnostic)
+				}
+				findings = append(findings, newFinding(ovstypes.HealthBFDDown, detail))
+			}
+		}
+	}
+
+	controllerByUUID := map[string]map[string]any{}
+	for _, controller := range controllers {
+		controllerByUUID[ovsdbString(controller["_uuid"])] = controller
+	}
+	for _, bridge := range bridges {
+		uuids := ovsdbUUIDs(bridge["controller"])
+		if len(uuids) == 0 {
+			continue
+		}
+		connected := false
+		var targets []string
+		for _, uuid := range uuids {
+			controller := controllerByUUID[uuid]
+			targets = append(targets, ovsdbString(controller["target"]))
+			if isConnected, ok := controller["is_connected"].(bool); ok && isConnected {
+				connected = true
+			}
+		}
+		if !connected {
+			findings = append(findings, ovstypes.HealthFinding{
+				Kind:   ovstypes.HealthControllerDisconnected,
+				Bridge: ovsdbString(bridge["name"]),
+				Name:   ovsdbString(bridge["name"]),
+				Detail: fmt.Sprintf("no connected controller among %s", strings.Join(targets, ", ")),
+			})
+		}
+	}
+
+	slices.SortStableFunc(findings, func(a, b ovstypes.HealthFinding) int {
+		if c := strings.Compare(a.Bridge, b.Bridge); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return findings
+}
+
+// parseOVSDBTable parses the JSON output of 'ovs-vsctl --format=json list' into
+// one map per row keyed by column name.
+func parseOVSDBTable(output string) ([]map[string]any, error) {
+	var table ovsdbTable
+	if err := json.Unmarshal([]byte(output), &table); err != nil {
+		return nil, err
+	}
+	rows := make([]map[string]any, 0, len(table.Data))
+	for _, data := range table.Data {
+		if len(data) != len(table.Headings) {
+			return nil, fmt.Errorf("row has %d columns, expected %d", len(data), len(table.Headings))
+		}
+		row := make(map[string]any, len(data))
+		for i, heading := range table.Headings {
+			row[heading] = data[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ovsdbString returns the string value of an OVSDB atom. Optional columns are
+// encoded as sets with zero or one element; an empty set yields "".
+func ovsdbString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%v", v)
+	case bool:
+		return fmt.Sprintf("%t", v)
+	case []any:
+		if len(v) != 2 {
+			return ""
+		}
+		switch v[0] {
+		case "uuid", "named-uuid":
+			return ovsdbString(v[1])
+		case "set":
+			if elems, ok := v[1].([]any); ok && len(elems) == 1 {
+				return ovsdbString(elems[0])
+			}
+		}
+	}
+	return ""
+}
+
+// ovsdbInt returns the integer value of an OVSDB atom, or false if the value
+// is empty or not a number.
+func ovsdbInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), true
+	case []any:
+		if len(v) == 2 && v[0] == "set" {
+			if elems, ok := v[1].([]any); ok && len(elems) == 1 {
+				return ovsdbInt(elems[0])
+			}
+		}
+	}
+	return 0, false
+}
+
+// ovsdbUUIDs returns the UUIDs referenced by an OVSDB uuid atom or set of uuids.
+func ovsdbUUIDs(value any) []string {
+	v, ok := value.([]any)
+	if !ok || len(v) != 2 {
+		return nil
+	}
+	switch v[0] {
+	case "uuid":
+		return []string{ovsdbString(v)}
+	case "set":
+		elems, _ := v[1].([]any)
+		var uuids []string
+		for _, elem := range elems {
+			uuids = append(uuids, ovsdbUUIDs(elem)...)
+		}
+		return uuids
+	}
+	return nil
+}
+
+// ovsdbMap returns the string key/value pairs of an OVSDB map.
+func ovsdbMap(value any) map[string]string {
+	result := map[string]string{}
+	v, ok := value.([]any)
+	if !ok || len(v) != 2 || v[0] != "map" {
+		return result
+	}
+	pairs, _ := v[1].([]any)
+	for _, pair := range pairs {
+		kv, ok := pair.([]any)
+		if !ok || len(kv) != 2 {
+			continue
+		}
+		result[ovsdbString(kv[0])] = ovsdbString(kv[1])
+	}
+	return result
+}
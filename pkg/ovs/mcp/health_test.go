@@ -0,0 +1,198 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	ovstypes "github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/ovs/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	healthyInterfaceTable = `{"data":[` +
+		`[["uuid","i1"],"br-int","internal",["set",[]],65534,"up",["map",[]],["map",[]]],` +
+		`[["uuid","i2"],"ovn-abc-0","geneve",["set",[]],1,"up",["map",[["enable","true"]]],["map",[["state","up"],["remote_state","up"]]]]],` +
+		`"headings":["_uuid","name","type","error","ofport","link_state","bfd","bfd_status"]}`
+	unhealthyInterfaceTable = `{"data":[` +
+		`[["uuid","i1"],"br-int","internal",["set",[]],65534,"up",["map",[]],["map",[]]],` +
+		`[["uuid","i2"],"ovn-abc-0","geneve",["set",[]],1,"up",["map",[["enable","true"]]],["map",[["diagnostic","Control Detection Time Expired"],["remote_state","up"],["state","down"]]]],` +
+		`[["uuid","i3"],"veth1234","",["set",["could not open network device veth1234 (No such device)"]],-1,["set",[]],["map",[]],["map",[]]],` +
+		`[["uuid","i4"],"eth1","",["set",[]],2,"down",["map",[]],["map",[]]]],` +
+		`"headings":["_uuid","name","type","error","ofport","link_state","bfd","bfd_status"]}`
+	portTable = `{"data":[` +
+		`[["uuid","p1"],"br-int",["uuid","i1"]],` +
+		`[["uuid","p2"],"ovn-abc-0",["uuid","i2"]],` +
+		`[["uuid","p3"],"veth1234",["uuid","i3"]],` +
+		`[["uuid","p4"],"eth1",["uuid","i4"]]],` +
+		`"headings":["_uuid","name","interfaces"]}`
+	bridgeTable = `{"data":[` +
+		`[["uuid","b1"],"br-int",["set",[["uuid","p1"],["uuid","p2"],["uuid","p3"]]],["set",[]]],` +
+		`[["uuid","b2"],"br-ex",["uuid","p4"],["uuid","c1"]]],` +
+		`"headings":["_uuid","name","ports","controller"]}`
+	connectedControllerTable    = `{"data":[[["uuid","c1"],"tcp:127.0.0.1:6653",true]],"headings":["_uuid","target","is_connected"]}`
+	disconnectedControllerTable = `{"data":[[["uuid","c1"],"tcp:127.0.0.1:6653",false]],"headings":["_uuid","target","is_connected"]}`
+)
+
+func TestEvaluateOVSHealth(t *testing.T) {
+	tests := []struct {
+		name        string
+		interfaces  string
+		controllers string
+		expected    []ovstypes.HealthFinding
+	}{
+		{
+			name:        "healthy node has no findings",
+			interfaces:  healthyInterfaceTable,
+			controllers: connectedControllerTable,
+		},
+		{
+			name:        "unhealthy interfaces and disconnected controller",
+			interfaces:  unhealthyInterfaceTable,
+			controllers: disconnectedControllerTable,
+			expected: []ovstypes.HealthFinding{
+				{Kind: ovstypes.HealthControllerDisconnected, Bridge: "br-ex", Name: "br-ex", Detail: "no connected controller among tcp:127.0.0.1:6653"},
+				{Kind: ovstypes.HealthLinkDown, Bridge: "br-ex", Name: "eth1", Detail: "link_state is down"},
+				{Kind: ovstypes.HealthBFDDown, Bridge: "br-int", Name: "ovn-abc-0", Type: "geneve", Detail: "bfd state is down, remote state is up, diagnostic: Control Detection Time Expired"},
+				{Kind: ovstypes.HealthInterfaceError, Bridge: "br-int", Name: "veth1234", Detail: "could not open network device veth1234 (No such device)"},
+				{Kind: ovstypes.HealthInvalidOfport, Bridge: "br-int", Name: "veth1234", Detail: "ofport is -1, the interface could not be attached to the datapath"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows [][]map[string]any
+			for _, table := range []string{tt.interfaces, portTable, bridgeTable, tt.controllers} {
+				parsed, err := parseOVSDBTable(table)
+				if err != nil {
+					t.Fatalf("parseOVSDBTable() error = %v", err)
+				}
+				rows = append(rows, parsed)
+			}
+			findings := evaluateOVSHealth(rows[0], rows[1], rows[2], rows[3])
+			if diff := cmp.Diff(tt.expected, findings); diff != "" {
+				t.Errorf("evaluateOVSHealth() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseOVSDBTable(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		wantErr bool
+	}{
+		{
+			name:   "valid table",
+			output: portTable,
+		},
+		{
+			name:   "empty table",
+			output: `{"data":[],"headings":["_uuid","name"]}`,
+		},
+		{
+			name:    "invalid json returns error",
+			output:  "Bridge br-int",
+			wantErr: true,
+		},
+		{
+			name:    "row and headings mismatch returns error",
+			output:  `{"data":[["a"]],"headings":["_uuid","name"]}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseOVSDBTable(tt.output)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseOVSDBTable() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHealthSweep(t *testing.T) {
+	newPod := func(name, node string, phase corev1.PodPhase) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ovn-kubernetes"},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	pods := []corev1.Pod{
+		newPod("ovnkube-node-a", "node-a", corev1.PodRunning),
+		newPod("ovnkube-node-b", "node-b", corev1.PodRunning),
+		newPod("ovnkube-node-c", "node-c", corev1.PodRunning),
+		newPod("ovnkube-node-d", "node-d", corev1.PodPending),
+		newPod("ovnkube-node-e", "node-e", corev1.PodRunning),
+		newPod("ovnkube-node-f", "node-f", corev1.PodRunning),
+	}
+	listPods := func(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error) {
+		return pods, nil
+	}
+	runPodExecCommand := func(ctx context.Context, namespace, name, container string, command []string) (string, string, error) {
+		table := command[len(command)-1]
+		switch {
+		case name == "ovnkube-node-c":
+			return "", "", fmt.Errorf("connection refused")
+		case table == "Port" && name == "ovnkube-node-f":
+			return "", "ovs-vsctl: unix:/var/run/openvswitch/db.sock: database connection failed\n", nil
+		case table == "Interface" && name == "ovnkube-node-e":
+			return unhealthyInterfaceTable, "ovs-vsctl: warning: schema version mismatch\n", nil
+		case table == "Interface" && name == "ovnkube-node-b":
+			return unhealthyInterfaceTable, "", nil
+		case table == "Interface":
+			return healthyInterfaceTable, "", nil
+		case table == "Port":
+			return portTable, "", nil
+		case table == "Bridge":
+			return bridgeTable, "", nil
+		default:
+			return connectedControllerTable, "", nil
+		}
+	}
+	server, err := NewMCPServer(runPodExecCommand, listPods)
+	if err != nil {
+		t.Fatalf("NewMCPServer() error = %v", err)
+	}
+
+	_, result, err := server.HealthSweep(context.Background(), nil, ovstypes.HealthSweepParams{
+		Namespace: "ovn-kubernetes",
+		Nodes:     []string{"node-a", "node-b", "node-c", "node-d", "node-e", "node-f"},
+	})
+	if err != nil {
+		t.Fatalf("HealthSweep() error = %v", err)
+	}
+	if result.NodesChecked != 6 || result.NodesUnhealthy != 2 || result.NodesFailed != 3 {
+		t.Fatalf("HealthSweep() unexpected summary: %+v", result)
+	}
+	var summary []string
+	for _, node := range result.Nodes {
+		summary = append(summary, fmt.Sprintf("%s:%t:%d:%d:%t", node.Node, node.Healthy, len(node.Findings), len(node.Warnings), node.Error != ""))
+	}
+	expected := "node-a:true:0:0:false,node-b:false:4:0:false,node-c:false:0:0:true,node-d:false:0:0:true," +
+		"node-e:false:4:1:false,node-f:false:0:0:true"
+	if strings.Join(summary, ",") != expected {
+		t.Errorf("HealthSweep() got %s, expected %s", strings.Join(summary, ","), expected)
+	}
+	if warning := result.Nodes[4].Warnings; len(warning) != 1 || warning[0] != "ovs-vsctl list Interface: ovs-vsctl: warning: schema version mismatch" {
+		t.Errorf("HealthSweep() unexpected warnings for node-e: %v", warning)
+	}
+	if !strings.Contains(result.Nodes[5].Error, "database connection failed") {
+		t.Errorf("HealthSweep() expected the stderr in the error of node-f, got %q", result.Nodes[5].Error)
+	}
+
+	if _, _, err := server.HealthSweep(context.Background(), nil, ovstypes.HealthSweepParams{}); err == nil {
+		t.Errorf("HealthSweep() expected error when namespace is empty")
+	}
+	if _, _, err := server.HealthSweep(context.Background(), nil, ovstypes.HealthSweepParams{
+		Namespace:     "ovn-kubernetes",
+		LabelSelector: "app in (",
+	}); err == nil {
+		t.Errorf("HealthSweep() expected error for invalid label selector")
+	}
+}
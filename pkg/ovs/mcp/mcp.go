@@ -10,22 +10,30 @@ import (
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/headtail"
 	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/pattern"
+	"github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/timeout"
+	corev1 "k8s.io/api/core/v1"
 )
 
 type RunPodExecCommandFuncType func(ctx context.Context, namespace, name, container string, command []string) (string, string, error)
+type ListPodsFuncType func(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error)
 
 // MCPServer provides OVS layer analysis tools
 type MCPServer struct {
 	runPodExecCommand RunPodExecCommandFuncType
+	listPods          ListPodsFuncType
 }
 
 // NewMCPServer creates a new OVS MCP server
-func NewMCPServer(runPodExecCommand RunPodExecCommandFuncType) (*MCPServer, error) {
+func NewMCPServer(runPodExecCommand RunPodExecCommandFuncType, listPods ListPodsFuncType) (*MCPServer, error) {
 	if runPodExecCommand == nil {
 		return nil, fmt.Errorf("function to run pod exec command is nil")
 	}
+	if listPods == nil {
+		return nil, fmt.Errorf("function to list pods is nil")
+	}
 	return &MCPServer{
 		runPodExecCommand: runPodExecCommand,
+		listPods:          listPods,
 	}, nil
 }
 
@@ -145,6 +153,54 @@ Example output (action='ofproto/trace'):
 }
 `, DefaultMaxLines),
 		}, s.Appctl)
+
+	// ovs-health-sweep tool registration
+	mcp.AddTool(server,
+		&mcp.Tool{
+			Name: "ovs-health-sweep",
+			Description: fmt.Sprintf(`ovs-health-sweep runs an OVS health check in parallel against all ovnkube-node pods and reports the unhealthy interfaces and bridges grouped by node.
+Use this as the first step when a node "loses pod networking" instead of reading ovs-vsctl show output node by node.
+
+The following problems are reported:
+- interface-error          : Interface with a non-empty error column.
+- invalid-ofport           : Interface with ofport -1 (could not be attached to the datapath).
+- link-down                : Interface with link_state down.
+- bfd-down                 : Tunnel interface (geneve, vxlan, stt, gre) with BFD enabled whose BFD session is not up.
+- controller-disconnected  : Bridge with controllers configured but none of them connected.
+
+A node that cannot be inspected is reported with an error. ovs-vsctl stderr that comes with a valid output is reported as node warnings.
+
+Parameters:
+- namespace (required): Kubernetes namespace of the ovnkube-node pods
+- label_selector (optional): Label selector of the pods running OVS. Default: '%s'
+- nodes (optional): Restrict the sweep to these node names. Default: all nodes running a matching pod
+- max_concurrency (optional): Number of nodes inspected in parallel. Default: %d, maximum: %d
+- timeout_seconds (optional): Timeout in seconds for the whole sweep. If not specified, server default timeout is used. The maximum value is %d seconds.
+
+Example:
+- namespace='ovn-kubernetes'
+- namespace='openshift-ovn-kubernetes', nodes=['worker-0', 'worker-1']
+
+Example output:
+{
+  "nodes_checked": 2,
+  "nodes_unhealthy": 1,
+  "nodes_failed": 0,
+  "nodes": [
+    {"node": "ovn-worker", "pod": "ovnkube-node-abcde", "healthy": true},
+    {
+      "node": "ovn-worker2",
+      "pod": "ovnkube-node-fghij",
+      "healthy": false,
+      "findings": [
+        {"kind": "bfd-down", "bridge": "br-int", "name": "ovn-0a1b2c-0", "type": "geneve", "detail": "bfd state is down, remote state is up, diagnostic: Control Detection Time Expired"},
+        {"kind": "invalid-ofport", "bridge": "br-int", "name": "veth1234", "detail": "ofport is -1, the interface could not be attached to the datapath"}
+      ]
+    }
+  ]
+}
+`, DefaultHealthLabelSelector, DefaultHealthConcurrency, MaxHealthConcurrency, int(timeout.MaxTimeout.Seconds())),
+		}, s.HealthSweep)
 }
 
 // Vsctl dispatches to the appropriate ovs-vsctl subcommand based on the
@@ -0,0 +1,61 @@
+package types
+
+import "github.com/ovn-kubernetes/ovn-kubernetes-mcp/pkg/utils/timeout"
+
+// HealthFindingKind classifies a problem reported by the OVS health sweep.
+type HealthFindingKind string
+
+const (
+	// HealthInterfaceError is reported for interfaces with a non-empty error column.
+	HealthInterfaceError HealthFindingKind = "interface-error"
+	// HealthInvalidOfport is reported for interfaces whose ofport is -1.
+	HealthInvalidOfport HealthFindingKind = "invalid-ofport"
+	// HealthLinkDown is reported for interfaces whose link_state is down.
+	HealthLinkDown HealthFindingKind = "link-down"
+	// HealthBFDDown is reported for tunnel interfaces with BFD enabled whose
+	// BFD session is not up.
+	HealthBFDDown HealthFindingKind = "bfd-down"
+	// HealthControllerDisconnected is reported for bridges that have
+	// controllers configured but none of them connected.
+	HealthControllerDisconnected HealthFindingKind = "controller-disconnected"
+)
+
+// HealthSweepParams are the parameters for the fleet-wide OVS health sweep.
+// The sweep runs against every pod matched by Namespace and LabelSelector,
+// optionally restricted to the nodes listed in Nodes.
+type HealthSweepParams struct {
+	Namespace      string   `json:"namespace"`
+	LabelSelector  string   `json:"label_selector,omitempty"`
+	Nodes          []string `json:"nodes,omitempty"`
+	MaxConcurrency int      `json:"max_concurrency,omitempty"`
+	timeout.TimeoutParams
+}
+
+// HealthFinding describes a single unhealthy OVS interface or bridge.
+type HealthFinding struct {
+	Kind   HealthFindingKind `json:"kind"`
+	Bridge string            `json:"bridge,omitempty"`
+	Name   string            `json:"name"`
+	Type   string            `json:"type,omitempty"`
+	Detail string            `json:"detail"`
+}
+
+// NodeHealth groups the findings of the OVS health sweep for one node. Error
+// is set when the sweep could not inspect the node at all. Warnings hold what
+// ovs-vsctl printed on stderr along with a valid output.
+type NodeHealth struct {
+	Node     string          `json:"node"`
+	Pod      string          `json:"pod"`
+	Healthy  bool            `json:"healthy"`
+	Findings []HealthFinding `json:"findings,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// HealthSweepResult holds the response of the fleet-wide OVS health sweep.
+type HealthSweepResult struct {
+	NodesChecked   int          `json:"nodes_checked"`
+	NodesUnhealthy int          `json:"nodes_unhealthy"`
+	NodesFailed    int          `json:"nodes_failed"`
+	Nodes          []NodeHealth `json:"nodes"`
+}
@@ -14,48 +14,55 @@ type HeadTailParams struct {
 // head will be applied first. If only one of Head or Tail is set,
 // that one will be applied and ApplyTailFirst will be ignored.
 func (h *HeadTailParams) Apply(lines []string, defaultMaxLines int) []string {
-	// If neither Head nor Tail is set, return the default maximum number of lines.
+	return ApplyTo(h, lines, defaultMaxLines)
+}
+
+// ApplyTo applies the head and tail parameters to a slice of any type,
+// following the same rules as Apply. It is used by tools that return
+// structured items instead of lines.
+func ApplyTo[T any](h *HeadTailParams, items []T, defaultMaxItems int) []T {
+	// If neither Head nor Tail is set, return the default maximum number of items.
 	if h.Head == 0 && h.Tail == 0 {
-		return head(lines, defaultMaxLines)
+		return head(items, defaultMaxItems)
 	}
 	// If both Head and Tail are set, apply them in the order specified by ApplyTailFirst.
 	if h.Head != 0 && h.Tail != 0 {
 		if h.ApplyTailFirst {
-			return head(tail(lines, h.Tail), h.Head)
+			return head(tail(items, h.Tail), h.Head)
 		} else {
-			return tail(head(lines, h.Head), h.Tail)
+			return tail(head(items, h.Head), h.Tail)
 		}
 	}
 	// If only Head is set, apply it.
 	if h.Head != 0 {
-		return head(lines, h.Head)
+		return head(items, h.Head)
 	}
 	// If only Tail is set, apply it.
-	return tail(lines, h.Tail)
+	return tail(items, h.Tail)
 }
 
-// head returns the first n lines of a slice of strings. It will return a new slice of strings
-// with the first n lines. If n is less than or equal to 0, or greater than or equal to the
+// head returns the first n items of a slice. It will return a new slice
+// with the first n items. If n is less than or equal to 0, or greater than or equal to the
 // length of the slice, it will return the entire slice.
-func head(lines []string, n int) []string {
-	if len(lines) == 0 {
-		return lines
+func head[T any](items []T, n int) []T {
+	if len(items) == 0 {
+		return items
 	}
-	if n <= 0 || n >= len(lines) {
-		return lines
+	if n <= 0 || n >= len(items) {
+		return items
 	}
-	return lines[:n]
+	return items[:n]
 }
 
-// tail returns the last n lines of a slice of strings. It will return a new slice of strings
-// with the last n lines. If n is less than or equal to 0, or greater than or equal to the
+// tail returns the last n items of a slice. It will return a new slice
+// with the last n items. If n is less than or equal to 0, or greater than or equal to the
 // length of the slice, it will return the entire slice.
-func tail(lines []string, n int) []string {
-	if len(lines) == 0 {
-		return lines
+func tail[T any](items []T, n int) []T {
+	if len(items) == 0 {
+		return items
 	}
-	if n <= 0 || n >= len(lines) {
-		return lines
+	if n <= 0 || n >= len(items) {
+		return items
 	}
-	return lines[len(lines)-n:]
+	return items[len(items)-n:]
 }
@@ -182,3 +182,38 @@ func TestApply(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyTo(t *testing.T) {
+	type item struct {
+		name string
+	}
+	items := []item{{name: "a"}, {name: "b"}, {name: "c"}, {name: "d"}}
+	tests := []struct {
+		name   string
+		params HeadTailParams
+		want   []item
+	}{
+		{
+			name: "apply with no params",
+			want: []item{{name: "a"}, {name: "b"}},
+		},
+		{
+			name:   "apply with tail",
+			params: HeadTailParams{Tail: 3},
+			want:   []item{{name: "b"}, {name: "c"}, {name: "d"}},
+		},
+		{
+			name:   "apply with both head and tail and apply tail first",
+			params: HeadTailParams{Head: 1, Tail: 2, ApplyTailFirst: true},
+			want:   []item{{name: "c"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := ApplyTo(&test.params, items, 2)
+			if !slices.Equal(got, test.want) {
+				t.Fatalf("ApplyTo() got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
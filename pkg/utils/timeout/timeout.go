@@ -2,12 +2,18 @@ package timeout
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
 // MaxTimeout is the maximum timeout in seconds for the command execution.
 const MaxTimeout = 300 * time.Second
 
+// SetupTime is the time a command bounded in time reserves from the time left before the call
+// times out for its setup, such as starting a debug pod and checking the utilities it needs, so
+// that it ends and its output is returned before the call times out.
+const SetupTime = 30 * time.Second
+
 // TimeoutParams is a type that contains the timeout seconds for the command execution.
 type TimeoutParams struct {
 	// TimeoutSeconds is the timeout in seconds for the command execution.
@@ -31,3 +37,35 @@ func (t *TimeoutParams) WithTimeout(ctx context.Context) (context.Context, conte
 	}
 	return context.WithTimeout(ctx, t.ToDuration())
 }
+
+// ValidateDuration returns the number of seconds a command bounded in time runs for, or
+// defaultSeconds if seconds is 0. The command must end reserve before the deadline of ctx, so that
+// its output is returned: ctx must carry the timeout of the call, timeout_seconds or else the tool
+// timeout of the server. Without a deadline, the command must end before MaxTimeout.
+func ValidateDuration(ctx context.Context, field string, seconds, defaultSeconds int, reserve time.Duration) (int, error) {
+	if seconds < 0 {
+		return 0, fmt.Errorf("invalid %s %d: must not be negative", field, seconds)
+	}
+	if seconds == 0 {
+		seconds = defaultSeconds
+	}
+	left := MaxTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		left = min(left, time.Until(deadline))
+	}
+	left -= reserve
+	if time.Duration(seconds)*time.Second >= left {
+		return 0, fmt.Errorf("invalid %s %d: must be less than the %d seconds left before the call times out; set timeout_seconds (max: %d) to run longer",
+			field, seconds, max(int(left.Seconds()), 0), int(MaxTimeout.Seconds()))
+	}
+	return seconds, nil
+}
+
+// DurationLimit returns the number of seconds a command bounded in time must be shorter than in a
+// call without timeout_seconds, given the tool timeout of the server, 0 if it is disabled.
+func DurationLimit(toolTimeout time.Duration) int {
+	if toolTimeout <= 0 {
+		return int(MaxTimeout.Seconds())
+	}
+	return int(min(toolTimeout, MaxTimeout).Seconds())
+}
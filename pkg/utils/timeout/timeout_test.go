@@ -68,3 +68,57 @@ func TestTimeoutParams_WithTimeout(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		seconds int
+		reserve time.Duration
+		want    int
+		wantErr bool
+	}{
+		{name: "default", timeout: time.Minute, want: 10},
+		{name: "below the deadline", timeout: time.Minute, seconds: 30, want: 30},
+		{name: "not less than the deadline", timeout: time.Minute, seconds: 60, wantErr: true},
+		{name: "below the deadline with reserve", timeout: time.Minute, seconds: 40, reserve: 15 * time.Second, want: 40},
+		{name: "not less than the deadline with reserve", timeout: time.Minute, seconds: 50, reserve: 15 * time.Second, wantErr: true},
+		{name: "below the maximum without deadline", seconds: 200, want: 200},
+		{name: "not less than the maximum without deadline", seconds: int(MaxTimeout.Seconds()), wantErr: true},
+		{name: "not less than the maximum without deadline with reserve", seconds: 280, reserve: SetupTime, wantErr: true},
+		{name: "negative", timeout: time.Minute, seconds: -1, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.timeout != 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				defer cancel()
+			}
+			got, err := ValidateDuration(ctx, "duration_seconds", test.seconds, 10, test.reserve)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ValidateDuration() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("ValidateDuration() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDurationLimit(t *testing.T) {
+	tests := []struct {
+		toolTimeout time.Duration
+		want        int
+	}{
+		{toolTimeout: 0, want: int(MaxTimeout.Seconds())},
+		{toolTimeout: 120 * time.Second, want: 120},
+		{toolTimeout: time.Hour, want: int(MaxTimeout.Seconds())},
+	}
+	for _, test := range tests {
+		if got := DurationLimit(test.toolTimeout); got != test.want {
+			t.Errorf("DurationLimit(%v) = %d, want %d", test.toolTimeout, got, test.want)
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"slices"
 	"strings"
 	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 var (
@@ -125,6 +127,13 @@ func ValidateSafeString(value, fieldName string, allowEmpty bool, shellMetaChara
 	return nil
 }
 
+// IsKubernetesName reports whether name is a valid name of a Kubernetes object, a DNS-1123
+// subdomain such as the names of pods and nodes. The names of namespaces, services and containers
+// are DNS-1123 labels, which are also DNS-1123 subdomains.
+func IsKubernetesName(name string) bool {
+	return len(validation.IsDNS1123Subdomain(name)) == 0
+}
+
 // ValidatePath validates that a path is safe to use as a filesystem path.
 // It ensures the path:
 // - Is absolute (starts with /)
@@ -221,6 +221,33 @@ func TestValidateShellMetacharacters(t *testing.T) {
 	}
 }
 
+func TestIsKubernetesName(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  bool
+	}{
+		{name: "label", value: "kube-system", want: true},
+		{name: "subdomain", value: "ovn-worker.example.com", want: true},
+		{name: "single character", value: "a", want: true},
+		{name: "empty", value: "", want: false},
+		{name: "upper case", value: "Client", want: false},
+		{name: "leading dash", value: "-client", want: false},
+		{name: "trailing dot", value: "client.", want: false},
+		{name: "empty label", value: "client..web", want: false},
+		{name: "underscore", value: "default_client", want: false},
+		{name: "shell metacharacters", value: "client;reboot", want: false},
+		{name: "too long", value: strings.Repeat("a", 254), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsKubernetesName(tt.value); got != tt.want {
+				t.Errorf("IsKubernetesName(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestValidatePath(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -18,10 +18,20 @@ import (
 
 var _ = Describe("Kernel Tools", func() {
 	const (
-		getIPToolName        = "get-ip"
-		getIPTablesToolName  = "get-iptables"
-		getNFTToolName       = "get-nft"
-		getConntrackToolName = "get-conntrack"
+		getIPToolName              = "get-ip"
+		getIPTablesToolName        = "get-iptables"
+		traceIPTablesToolName      = "trace-iptables"
+		getNFTToolName             = "get-nft"
+		searchNFTToolName          = "search-nft"
+		getConntrackToolName       = "get-conntrack"
+		getConntrackEventsToolName = "get-conntrack-events"
+		getConntrackEntryToolName  = "get-conntrack-entry"
+		getRouteDecisionToolName   = "get-route-decision"
+		getSysctlToolName          = "get-sysctl"
+		getNetworkCountersToolName = "get-network-counters"
+		getEthtoolToolName         = "get-ethtool"
+		getSocketsToolName         = "get-sockets"
+		nodeNetworkSnapshotName    = "node-network-snapshot"
 	)
 
 	var nodeName string
@@ -118,13 +128,32 @@ var _ = Describe("Kernel Tools", func() {
 			Expect(output).NotTo(BeEmpty())
 
 			By("Checking the result contains routing information")
-			result := utils.UnmarshalCallToolResult[types.Result](output)
-			Expect(result.Data).NotTo(BeEmpty())
-			// Route output typically contains "via" or "dev" keywords
-			Expect(result.Data).To(Or(
-				ContainSubstring("via"),
-				ContainSubstring("dev"),
-			))
+			result := utils.UnmarshalCallToolResult[types.IPResult](output)
+			Expect(result.Routes).NotTo(BeEmpty())
+			for _, route := range result.Routes {
+				Expect(route.Dst).NotTo(BeEmpty())
+				Expect(route.Table).To(Equal("main"))
+			}
+		})
+
+		It("should retrieve routes of all tables from a node", func() {
+			By("Running get-ip to show routes of all tables")
+			output, err := mcpInspector.
+				MethodCall(getIPToolName, map[string]any{
+					"node":    nodeName,
+					"command": "route show",
+					"table":   "all",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the result contains local table routes")
+			result := utils.UnmarshalCallToolResult[types.IPResult](output)
+			tables := map[string]bool{}
+			for _, route := range result.Routes {
+				tables[route.Table] = true
+			}
+			Expect(tables).To(HaveKey("main"))
+			Expect(tables).To(HaveKey("local"))
 		})
 
 		It("should retrieve network interface information from a node", func() {
@@ -138,14 +167,197 @@ var _ = Describe("Kernel Tools", func() {
 			Expect(output).NotTo(BeEmpty())
 
 			By("Checking the result contains network interface information")
-			result := utils.UnmarshalCallToolResult[types.Result](output)
-			Expect(result.Data).NotTo(BeEmpty())
-			// Link output typically contains interface names and states
-			Expect(result.Data).To(Or(
-				ContainSubstring("state"),
-				ContainSubstring("mtu"),
-				ContainSubstring("qdisc"),
-			))
+			result := utils.UnmarshalCallToolResult[types.IPResult](output)
+			Expect(result.Links).NotTo(BeEmpty())
+			for _, link := range result.Links {
+				Expect(link.Name).NotTo(BeEmpty())
+				Expect(link.MTU).To(BeNumerically(">", 0))
+			}
+		})
+
+		It("should retrieve addresses of a device from a node", func() {
+			By("Running get-ip to show loopback addresses")
+			output, err := mcpInspector.
+				MethodCall(getIPToolName, map[string]any{
+					"node":    nodeName,
+					"command": "address show",
+					"device":  "lo",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the result contains the loopback address")
+			result := utils.UnmarshalCallToolResult[types.IPResult](output)
+			Expect(result.Addresses).NotTo(BeEmpty())
+			Expect(result.Addresses[0].Dev).To(Equal("lo"))
+			Expect(result.Addresses[0].Address).To(Or(Equal("127.0.0.1"), Equal("::1")))
+		})
+	})
+
+	Context("get-route-decision", func() {
+		It("should return the route and the matching rule for a local address", func() {
+			By("Running get-route-decision for the loopback address")
+			output, err := mcpInspector.
+				MethodCall(getRouteDecisionToolName, map[string]any{
+					"node": nodeName,
+					"dst":  "127.0.0.1",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the route is from the local table and matched by the local rule")
+			result := utils.UnmarshalCallToolResult[types.RouteGetResult](output)
+			Expect(result.Route.Type).To(Equal("local"))
+			Expect(result.Route.Table).To(Equal("local"))
+			Expect(result.Rule).NotTo(BeNil())
+			Expect(result.Rule.Table).To(Equal("local"))
+			Expect(result.Netns).To(Equal("node " + nodeName))
+		})
+	})
+
+	Context("get-sysctl", func() {
+		It("should compare the node sysctls with the OVN-Kubernetes baseline", func() {
+			By("Running get-sysctl on the node")
+			output, err := mcpInspector.
+				MethodCall(getSysctlToolName, map[string]any{
+					"node": nodeName,
+					"keys": "net.ipv4.ip_local_port_range",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking IP forwarding is enabled and the gateway bridge is checked")
+			result := utils.UnmarshalCallToolResult[types.SysctlResult](output)
+			Expect(result.Netns).To(Equal("node " + nodeName))
+			Expect(result.Interfaces).To(ContainElement("breth0"))
+			Expect(result.Checks).To(ContainElement(And(
+				HaveField("Key", "net.ipv4.ip_forward"),
+				HaveField("Status", "ok"),
+			)))
+			Expect(result.Values).To(HaveLen(1))
+			Expect(result.Values[0].Key).To(Equal("net.ipv4.ip_local_port_range"))
+		})
+	})
+
+	Context("get-network-counters", func() {
+		It("should report the counters that changed on the node", func() {
+			By("Running get-network-counters on the node")
+			output, err := mcpInspector.
+				MethodCall(getNetworkCountersToolName, map[string]any{
+					"node":             nodeName,
+					"interval_seconds": 2,
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the interval was measured and the IP counters changed")
+			result := utils.UnmarshalCallToolResult[types.NetworkCountersResult](output)
+			Expect(result.IntervalSeconds).To(BeNumerically(">=", 1.5))
+			Expect(result.Counters).To(ContainElement(And(
+				HaveField("Source", "snmp"),
+				HaveField("Name", "IpInReceives"),
+				HaveField("Delta", BeNumerically(">", 0)),
+			)))
+		})
+	})
+
+	Context("get-ethtool", func() {
+		It("should read the driver and offload features of the node interface", func() {
+			By("Running get-ethtool on eth0")
+			output, err := mcpInspector.
+				MethodCall(getEthtoolToolName, map[string]any{
+					"node":      nodeName,
+					"interface": "eth0",
+					"sections":  "driver,features",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the veth driver and the checksum offload are returned")
+			result := utils.UnmarshalCallToolResult[types.EthtoolResult](output)
+			Expect(result.Interfaces).To(HaveLen(1))
+			Expect(result.Interfaces[0].Node).To(Equal(nodeName))
+			Expect(result.Interfaces[0].Driver).To(HaveKeyWithValue("driver", "veth"))
+			Expect(result.Interfaces[0].Features).To(HaveKey("tx-checksumming"))
+			Expect(result.Differences).To(BeEmpty())
+		})
+	})
+
+	Context("get-sockets", func() {
+		It("should list the kubelet listening socket of the node", func() {
+			By("Running get-sockets for listening sockets on the kubelet port")
+			output, err := mcpInspector.
+				MethodCall(getSocketsToolName, map[string]any{
+					"node":     nodeName,
+					"protocol": "tcp",
+					"state":    "listen",
+					"port":     10250,
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the socket is owned by kubelet")
+			result := utils.UnmarshalCallToolResult[types.SocketsResult](output)
+			Expect(result.Sockets).NotTo(BeEmpty())
+			Expect(result.Sockets[0].State).To(Equal("LISTEN"))
+			Expect(result.Sockets[0].Local.Port).To(Equal(10250))
+			Expect(result.Sockets[0].Processes).To(ContainElement(HaveField("Name", "kubelet")))
+			Expect(result.Netns).To(BeEmpty())
+		})
+	})
+
+	Context("node-network-snapshot", func() {
+		It("should collect the network state of the node in one call", func() {
+			By("Running node-network-snapshot for the ip, conntrack and sysctl sections")
+			output, err := mcpInspector.
+				MethodCall(nodeNetworkSnapshotName, map[string]any{
+					"node":     nodeName,
+					"sections": "ip,conntrack,sysctl",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the requested sections are returned")
+			result := utils.UnmarshalCallToolResult[types.NodeNetworkSnapshotResult](output)
+			Expect(result.Node).To(Equal(nodeName))
+			Expect(result.IP).NotTo(BeNil())
+			Expect(result.IP.Links).To(ContainElement(HaveField("Name", "lo")))
+			Expect(result.IP.Routes).NotTo(BeEmpty())
+			Expect(result.Conntrack).NotTo(BeNil())
+			Expect(result.Conntrack.Max).To(BeNumerically(">", 0))
+			Expect(result.Sysctl).NotTo(BeNil())
+			Expect(result.NFT).To(BeNil())
+			Expect(result.OVS).To(BeNil())
+		})
+	})
+
+	Context("pod network namespace", func() {
+		It("should list the links and routes of a pod running on the node", func() {
+			By("Finding a running pod on the node that is not host-networked")
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			podList := &corev1.PodList{}
+			Expect(kubeClient.List(ctx, podList)).To(Succeed())
+			var pod *corev1.Pod
+			for i := range podList.Items {
+				p := &podList.Items[i]
+				if p.Spec.NodeName == nodeName && !p.Spec.HostNetwork && p.Status.Phase == corev1.PodRunning {
+					pod = p
+					break
+				}
+			}
+			if pod == nil {
+				Skip("no running pod that is not host-networked on node " + nodeName)
+			}
+
+			By("Running get-ip in the pod network namespace")
+			output, err := mcpInspector.
+				MethodCall(getIPToolName, map[string]any{
+					"node":          nodeName,
+					"pod_namespace": pod.Namespace,
+					"pod_name":      pod.Name,
+					"command":       "link show",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the pod interface is listed instead of the node interfaces")
+			result := utils.UnmarshalCallToolResult[types.IPResult](output)
+			Expect(result.Netns).To(HavePrefix("pod " + pod.Namespace + "/" + pod.Name))
+			Expect(result.Links).To(ContainElement(HaveField("Name", "eth0")))
+			Expect(result.Links).NotTo(ContainElement(HaveField("Name", "breth0")))
 		})
 	})
 
@@ -162,10 +374,12 @@ var _ = Describe("Kernel Tools", func() {
 			Expect(output).NotTo(BeEmpty())
 
 			By("Checking the result contains iptables rules")
-			result := utils.UnmarshalCallToolResult[types.Result](output)
-			Expect(result.Data).NotTo(BeEmpty())
+			result := utils.UnmarshalCallToolResult[types.IPTablesResult](output)
+			Expect(result.Families).To(HaveLen(1))
+			Expect(result.Families[0].Family).To(Equal("ipv4"))
+			Expect(result.Families[0].Data).NotTo(BeEmpty())
 			// iptables output typically contains chain names
-			Expect(result.Data).To(Or(
+			Expect(result.Families[0].Data).To(Or(
 				ContainSubstring("Chain"),
 				ContainSubstring("target"),
 				ContainSubstring("policy"),
@@ -184,15 +398,64 @@ var _ = Describe("Kernel Tools", func() {
 			Expect(output).NotTo(BeEmpty())
 
 			By("Checking the result contains NAT rules")
-			result := utils.UnmarshalCallToolResult[types.Result](output)
-			Expect(result.Data).NotTo(BeEmpty())
+			result := utils.UnmarshalCallToolResult[types.IPTablesResult](output)
+			Expect(result.Families).To(HaveLen(1))
+			Expect(result.Families[0].Data).NotTo(BeEmpty())
 			// NAT table output should contain PREROUTING, POSTROUTING chains
-			Expect(result.Data).To(Or(
+			Expect(result.Families[0].Data).To(Or(
 				ContainSubstring("PREROUTING"),
 				ContainSubstring("POSTROUTING"),
 				ContainSubstring("Chain"),
 			))
 		})
+
+		It("should retrieve the rules of both IP families labeled per family", func() {
+			By("Running get-iptables with ip_family both")
+			output, err := mcpInspector.
+				MethodCall(getIPTablesToolName, map[string]any{
+					"node":      nodeName,
+					"table":     "filter",
+					"command":   "-S",
+					"ip_family": "both",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking one result is returned per family")
+			result := utils.UnmarshalCallToolResult[types.IPTablesResult](output)
+			Expect(result.Families).To(HaveLen(2))
+			Expect(result.Families[0].Family).To(Equal("ipv4"))
+			Expect(result.Families[0].Command).To(HavePrefix("iptables "))
+			Expect(result.Families[1].Family).To(Equal("ipv6"))
+			Expect(result.Families[1].Command).To(HavePrefix("ip6tables "))
+			for _, family := range result.Families {
+				Expect(family.Data).To(ContainSubstring("-P INPUT"))
+			}
+		})
+	})
+
+	Context("trace-iptables", func() {
+		It("should evaluate the iptables rules for a locally generated packet", func() {
+			By("Running trace-iptables for a loopback packet")
+			output, err := mcpInspector.
+				MethodCall(traceIPTablesToolName, map[string]any{
+					"node":          nodeName,
+					"hook":          "OUTPUT",
+					"protocol":      "tcp",
+					"src":           "127.0.0.1",
+					"dst":           "127.0.0.1",
+					"sport":         40000,
+					"dport":         10250,
+					"out_interface": "lo",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the packet was followed from the OUTPUT hook to a verdict")
+			result := utils.UnmarshalCallToolResult[types.IPTablesTraceResult](output)
+			Expect(result.Hooks).NotTo(BeEmpty())
+			Expect(result.Hooks[0]).To(Equal("OUTPUT"))
+			Expect(result.Verdict).To(BeElementOf("ACCEPT", "DROP", "REJECT"))
+			Expect(result.Packet.Src).To(Equal("127.0.0.1"))
+		})
 	})
 
 	Context("get-nft", func() {
@@ -207,10 +470,61 @@ var _ = Describe("Kernel Tools", func() {
 			Expect(output).NotTo(BeEmpty())
 
 			By("Checking the result contains nftables information")
-			result := utils.UnmarshalCallToolResult[types.Result](output)
-			Expect(result.Data).NotTo(BeEmpty(), "nftables should be configured on OVN-Kubernetes nodes")
-			// nftables output typically contains "table"
-			Expect(result.Data).To(ContainSubstring("table"))
+			result := utils.UnmarshalCallToolResult[types.NFTResult](output)
+			Expect(result.Tables).NotTo(BeEmpty(), "nftables should be configured on OVN-Kubernetes nodes")
+		})
+
+		It("should list the rules of the ovn-kubernetes table", func() {
+			By("Running get-nft to list the inet ovn-kubernetes table")
+			output, err := mcpInspector.
+				MethodCall(getNFTToolName, map[string]any{
+					"node":             nodeName,
+					"command":          "list table",
+					"address_families": "inet",
+					"table":            "ovn-kubernetes",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the result contains parsed chains and rules")
+			result := utils.UnmarshalCallToolResult[types.NFTResult](output)
+			Expect(result.Chains).NotTo(BeEmpty())
+			Expect(result.Rules).NotTo(BeEmpty())
+			for _, rule := range result.Rules {
+				Expect(rule.Table).To(Equal("ovn-kubernetes"))
+				Expect(rule.Chain).NotTo(BeEmpty())
+			}
+		})
+	})
+
+	Context("search-nft", func() {
+		It("should find the rules referencing the management port address", func() {
+			By("Finding the management port address of the node")
+			output, err := mcpInspector.
+				MethodCall(getIPToolName, map[string]any{
+					"node":    nodeName,
+					"command": "address show",
+					"device":  "ovn-k8s-mp0",
+					"options": "-4",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+			addresses := utils.UnmarshalCallToolResult[types.IPResult](output).Addresses
+			Expect(addresses).NotTo(BeEmpty())
+
+			By("Running search-nft for the management port address")
+			output, err = mcpInspector.
+				MethodCall(searchNFTToolName, map[string]any{
+					"node":    nodeName,
+					"address": addresses[0].Address,
+					"table":   "ovn-kubernetes",
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Checking the masquerade rules of the management port are found")
+			result := utils.UnmarshalCallToolResult[types.NFTSearchResult](output)
+			Expect(result.Rules).NotTo(BeEmpty())
+			for _, rule := range result.Rules {
+				Expect(rule.Matches).NotTo(BeEmpty())
+			}
 		})
 	})
 
@@ -222,14 +536,52 @@ var _ = Describe("Kernel Tools", func() {
 			Entry("get-ip invalid command", getIPToolName, map[string]any{
 				"command": "link list",
 			}, "invalid ip command"),
+			Entry("get-ip pod name without namespace", getIPToolName, map[string]any{
+				"command":  "link show",
+				"pod_name": "client",
+			}, "pod_namespace and pod_name must be given together"),
+			Entry("get-ip table with link command", getIPToolName, map[string]any{
+				"command": "link show",
+				"table":   "main",
+			}, "table can only be used with route and rule commands"),
+			Entry("get-route-decision iif without src", getRouteDecisionToolName, map[string]any{
+				"dst": "10.96.0.1",
+				"iif": "breth0",
+			}, "iif requires src"),
+			Entry("get-sysctl invalid key", getSysctlToolName, map[string]any{
+				"keys": "net.ipv4.ip_forward=0",
+			}, "invalid sysctl key"),
+			Entry("get-network-counters negative interval", getNetworkCountersToolName, map[string]any{
+				"interval_seconds": -1,
+			}, "invalid interval_seconds"),
+			Entry("get-ethtool invalid section", getEthtoolToolName, map[string]any{
+				"interface": "eth0",
+				"sections":  "coalesce",
+			}, "invalid sections"),
+			Entry("get-sockets invalid state", getSocketsToolName, map[string]any{
+				"state": "open",
+			}, "invalid state"),
+			Entry("node-network-snapshot invalid section", nodeNetworkSnapshotName, map[string]any{
+				"sections": "ip,routes",
+			}, "invalid sections"),
 			Entry("get-iptables invalid command", getIPTablesToolName, map[string]any{
 				"table":   "filter",
 				"command": "list",
 			}, "invalid iptables command"),
+			Entry("get-iptables ip_family conflicting with filter parameters", getIPTablesToolName, map[string]any{
+				"ip_family":         "ipv4",
+				"filter_parameters": "-6",
+			}, "conflict with ip_family"),
 			Entry("get-iptables invalid table", getIPTablesToolName, map[string]any{
 				"table":   "invalid_table",
 				"command": "-L",
 			}, "invalid table name"),
+			Entry("trace-iptables invalid hook", traceIPTablesToolName, map[string]any{
+				"hook":     "INGRESS",
+				"protocol": "tcp",
+				"src":      "10.244.1.3",
+				"dst":      "10.96.0.1",
+			}, "invalid hook"),
 			Entry("get-nft invalid command", getNFTToolName, map[string]any{
 				"command": "list",
 			}, "invalid nft command"),
@@ -237,9 +589,46 @@ var _ = Describe("Kernel Tools", func() {
 				"command":          "list tables",
 				"address_families": "invalid_family",
 			}, "invalid nft address family"),
+			Entry("get-nft list chain without name", getNFTToolName, map[string]any{
+				"command": "list chain",
+				"table":   "ovn-kubernetes",
+			}, "name is required"),
+			Entry("get-nft ip_family with address_families", getNFTToolName, map[string]any{
+				"command":          "list tables",
+				"address_families": "inet",
+				"ip_family":        "ipv6",
+			}, "ip_family and address_families cannot be used together"),
+			Entry("search-nft without address and port", searchNFTToolName, map[string]any{}, "address or port is required"),
 			Entry("get-conntrack invalid command", getConntrackToolName, map[string]any{
 				"command": "list",
 			}, "invalid command"),
+			Entry("get-conntrack invalid zone", getConntrackToolName, map[string]any{
+				"zone": "70000",
+			}, "invalid zone"),
+			Entry("get-conntrack ip_family with count", getConntrackToolName, map[string]any{
+				"command":   "-C",
+				"ip_family": "both",
+			}, "ip_family can only be used with list operations"),
+			Entry("get-conntrack-events invalid event type", getConntrackEventsToolName, map[string]any{
+				"event_types": "EXPIRE",
+			}, "invalid event_types"),
+			Entry("get-conntrack-events port without protocol", getConntrackEventsToolName, map[string]any{
+				"dport": 443,
+			}, "dport requires protocol"),
+			Entry("get-conntrack-entry invalid protocol", getConntrackEntryToolName, map[string]any{
+				"protocol": "icmp",
+				"src":      "10.244.1.3",
+				"dst":      "10.96.0.1",
+				"sport":    40000,
+				"dport":    443,
+			}, "invalid protocol"),
+			Entry("get-conntrack-entry mixed address families", getConntrackEntryToolName, map[string]any{
+				"protocol": "tcp",
+				"src":      "10.244.1.3",
+				"dst":      "fd00::1",
+				"sport":    40000,
+				"dport":    443,
+			}, "same address family"),
 		)
 
 		DescribeTable("should reject metacharacters in filter parameters",
@@ -277,36 +666,44 @@ var _ = Describe("Kernel Tools", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(output).NotTo(BeEmpty())
 
-			By("Checking the result contains connection list")
-			result := utils.UnmarshalCallToolResult[types.Result](output)
-			Expect(result.Data).NotTo(BeEmpty())
-			// List output contains connection information from conntrack CLI or /proc/net/nf_conntrack.
-			// At least one marker must be present.
-			conntrackListMarkers := []string{
-				"src=", "dst=", "sport=", "dport=", "proto=", "state=",
-				"ESTABLISHED", "TIME_WAIT", "CLOSE",
+			By("Checking the result contains parsed connection entries")
+			result := utils.UnmarshalCallToolResult[types.ConntrackResult](output)
+			Expect(result.Entries).NotTo(BeEmpty())
+			for _, entry := range result.Entries {
+				Expect(entry.Protocol).NotTo(BeEmpty())
+				Expect(entry.Original.Src).NotTo(BeEmpty())
+				Expect(entry.Original.Dst).NotTo(BeEmpty())
 			}
-			Expect(result.Data).To(Satisfy(func(data string) bool {
-				for _, marker := range conntrackListMarkers {
-					if strings.Contains(data, marker) {
-						return true
-					}
-				}
-				return false
-			}))
 
 			// If the summary is present, check if it matches the expected format
 			// The summary format is as follows:
-			// -- conntrack summary --
 			// conntrack v<version> (conntrack-tools): <count> flow entries have been shown.
-			if strings.Contains(result.Data, "-- conntrack summary --") {
+			if result.Summary != "" {
 				By("Checking the summary matches the expected format")
-				lines := strings.Split(strings.TrimSpace(result.Data), "\n")
-				summary := lines[len(lines)-1]
-				Expect(summary).To(MatchRegexp(mcpKernel.ConntrackSummaryPattern.String()))
+				Expect(result.Summary).To(MatchRegexp(mcpKernel.ConntrackSummaryPattern.String()))
 			}
 		})
 
+		It("should aggregate connection tracking entries from a node", func() {
+			By("Running get-conntrack with aggregation")
+			output, err := mcpInspector.
+				MethodCall(getConntrackToolName, map[string]any{
+					"node":      nodeName,
+					"command":   "-L",
+					"aggregate": true,
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).NotTo(BeEmpty())
+
+			By("Checking the result contains the aggregate")
+			result := utils.UnmarshalCallToolResult[types.ConntrackResult](output)
+			Expect(result.Entries).To(BeEmpty())
+			Expect(result.Aggregate).NotTo(BeNil())
+			Expect(result.Aggregate.Total).To(BeNumerically(">", 0))
+			Expect(result.Aggregate.ByZone).NotTo(BeEmpty())
+			Expect(result.Aggregate.ByProtocol).NotTo(BeEmpty())
+		})
+
 		It("should retrieve connection tracking count from a node", func() {
 			By("Running get-conntrack to count connections")
 			output, err := mcpInspector.
@@ -318,7 +715,7 @@ var _ = Describe("Kernel Tools", func() {
 			Expect(output).NotTo(BeEmpty())
 
 			By("Checking the result contains connection count")
-			result := utils.UnmarshalCallToolResult[types.Result](output)
+			result := utils.UnmarshalCallToolResult[types.ConntrackResult](output)
 			Expect(result.Data).NotTo(BeEmpty())
 			// Count output is just a number
 			Expect(result.Data).To(MatchRegexp(`^\s*\d+\s*$`))
@@ -335,7 +732,7 @@ var _ = Describe("Kernel Tools", func() {
 			Expect(output).NotTo(BeEmpty())
 
 			By("Checking the result contains statistics information")
-			result := utils.UnmarshalCallToolResult[types.Result](output)
+			result := utils.UnmarshalCallToolResult[types.ConntrackResult](output)
 			Expect(result.Data).NotTo(BeEmpty())
 			// Conntrack statistics output contains counters
 			Expect(result.Data).To(Or(
@@ -345,4 +742,28 @@ var _ = Describe("Kernel Tools", func() {
 			))
 		})
 	})
+	Context("get-conntrack-events", func() {
+		It("should monitor connection tracking events on a node", func() {
+			By("Running get-conntrack-events for a short duration")
+			output, err := mcpInspector.
+				MethodCall(getConntrackEventsToolName, map[string]any{
+					"node":             nodeName,
+					"duration_seconds": 5,
+					"timeout_seconds":  60,
+				}).Execute()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output).NotTo(BeEmpty())
+
+			By("Checking the result contains the monitoring summary")
+			result := utils.UnmarshalCallToolResult[types.ConntrackEventsResult](output)
+			Expect(result.DurationSeconds).To(Equal(5))
+			for _, event := range result.Events {
+				Expect(event.Type).To(BeElementOf("NEW", "UPDATE", "DESTROY"))
+				Expect(event.Protocol).NotTo(BeEmpty())
+			}
+			if result.Summary != "" {
+				Expect(result.Summary).To(MatchRegexp(mcpKernel.ConntrackSummaryPattern.String()))
+			}
+		})
+	})
 })